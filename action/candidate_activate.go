@@ -0,0 +1,65 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+const (
+	// CandidateActivatePayloadGas represents the CandidateActivate payload gas per uint
+	CandidateActivatePayloadGas = uint64(100)
+	// CandidateActivateBaseIntrinsicGas represents the base intrinsic gas for CandidateActivate
+	CandidateActivateBaseIntrinsicGas = uint64(10000)
+)
+
+// CandidateActivate defines the action of designating a bucket as the self-stake bucket of the caller's candidate
+type CandidateActivate struct {
+	reclaimStake
+}
+
+// NewCandidateActivate returns a CandidateActivate instance
+func NewCandidateActivate(
+	nonce uint64,
+	bucketIndex uint64,
+	payload []byte,
+	gasLimit uint64,
+	gasPrice *big.Int,
+) (*CandidateActivate, error) {
+	return &CandidateActivate{
+		reclaimStake{
+			AbstractAction: AbstractAction{
+				version:  version.ProtocolVersion,
+				nonce:    nonce,
+				gasLimit: gasLimit,
+				gasPrice: gasPrice,
+			},
+			bucketIndex: bucketIndex,
+			payload:     payload,
+		},
+	}, nil
+}
+
+// IntrinsicGas returns the intrinsic gas of a CandidateActivate
+func (ca *CandidateActivate) IntrinsicGas() (uint64, error) {
+	payloadSize := uint64(len(ca.Payload()))
+	return calculateIntrinsicGas(CandidateActivateBaseIntrinsicGas, CandidateActivatePayloadGas, payloadSize)
+}
+
+// Cost returns the total cost of a CandidateActivate
+func (ca *CandidateActivate) Cost() (*big.Int, error) {
+	intrinsicGas, err := ca.IntrinsicGas()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get intrinsic gas for the CandidateActivate")
+	}
+	activateFee := big.NewInt(0).Mul(ca.GasPrice(), big.NewInt(0).SetUint64(intrinsicGas))
+	return activateFee, nil
+}
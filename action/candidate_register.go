@@ -0,0 +1,114 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+const (
+	// CandidateRegisterPayloadGas represents the CandidateRegister payload gas per uint
+	CandidateRegisterPayloadGas = uint64(100)
+	// CandidateRegisterBaseIntrinsicGas represents the base intrinsic gas for CandidateRegister
+	CandidateRegisterBaseIntrinsicGas = uint64(10000)
+)
+
+// CandidateRegister defines the action of registering a candidate owned by the sender
+type CandidateRegister struct {
+	AbstractAction
+
+	name            string
+	operatorAddress string
+	rewardAddress   string
+	payload         []byte
+}
+
+// NewCandidateRegister returns a CandidateRegister instance
+func NewCandidateRegister(
+	nonce uint64,
+	name string,
+	operatorAddress string,
+	rewardAddress string,
+	payload []byte,
+	gasLimit uint64,
+	gasPrice *big.Int,
+) (*CandidateRegister, error) {
+	return &CandidateRegister{
+		AbstractAction: AbstractAction{
+			version:  version.ProtocolVersion,
+			nonce:    nonce,
+			gasLimit: gasLimit,
+			gasPrice: gasPrice,
+		},
+		name:            name,
+		operatorAddress: operatorAddress,
+		rewardAddress:   rewardAddress,
+		payload:         payload,
+	}, nil
+}
+
+// Name returns the candidate name
+func (cr *CandidateRegister) Name() string { return cr.name }
+
+// OperatorAddress returns the operator address
+func (cr *CandidateRegister) OperatorAddress() string { return cr.operatorAddress }
+
+// RewardAddress returns the reward address
+func (cr *CandidateRegister) RewardAddress() string { return cr.rewardAddress }
+
+// Payload returns the payload bytes
+func (cr *CandidateRegister) Payload() []byte { return cr.payload }
+
+// Serialize returns a raw byte stream of the CandidateRegister struct
+func (cr *CandidateRegister) Serialize() []byte {
+	return byteutil.Must(proto.Marshal(cr.Proto()))
+}
+
+// Proto converts to protobuf CandidateRegister Action
+func (cr *CandidateRegister) Proto() *stakingpb.CandidateRegister {
+	return &stakingpb.CandidateRegister{
+		Name:            cr.name,
+		OperatorAddress: cr.operatorAddress,
+		RewardAddress:   cr.rewardAddress,
+		Payload:         cr.payload,
+	}
+}
+
+// LoadProto converts a protobuf's Action to CandidateRegister
+func (cr *CandidateRegister) LoadProto(pbAct *stakingpb.CandidateRegister) error {
+	if pbAct == nil {
+		return errors.New("empty action proto to load")
+	}
+	cr.name = pbAct.GetName()
+	cr.operatorAddress = pbAct.GetOperatorAddress()
+	cr.rewardAddress = pbAct.GetRewardAddress()
+	cr.payload = pbAct.GetPayload()
+	return nil
+}
+
+// IntrinsicGas returns the intrinsic gas of a CandidateRegister
+func (cr *CandidateRegister) IntrinsicGas() (uint64, error) {
+	payloadSize := uint64(len(cr.Payload()))
+	return calculateIntrinsicGas(CandidateRegisterBaseIntrinsicGas, CandidateRegisterPayloadGas, payloadSize)
+}
+
+// Cost returns the total cost of a CandidateRegister
+func (cr *CandidateRegister) Cost() (*big.Int, error) {
+	intrinsicGas, err := cr.IntrinsicGas()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get intrinsic gas for the CandidateRegister")
+	}
+	registerFee := big.NewInt(0).Mul(cr.GasPrice(), big.NewInt(0).SetUint64(intrinsicGas))
+	return registerFee, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+const (
+	// CandidateUpdatePayloadGas represents the CandidateUpdate payload gas per uint
+	CandidateUpdatePayloadGas = uint64(100)
+	// CandidateUpdateBaseIntrinsicGas represents the base intrinsic gas for CandidateUpdate
+	CandidateUpdateBaseIntrinsicGas = uint64(10000)
+)
+
+// CandidateUpdate defines the action of updating the candidate owned by the sender. An empty address leaves the
+// corresponding field unchanged.
+type CandidateUpdate struct {
+	AbstractAction
+
+	operatorAddress string
+	rewardAddress   string
+	payload         []byte
+}
+
+// NewCandidateUpdate returns a CandidateUpdate instance
+func NewCandidateUpdate(
+	nonce uint64,
+	operatorAddress string,
+	rewardAddress string,
+	payload []byte,
+	gasLimit uint64,
+	gasPrice *big.Int,
+) (*CandidateUpdate, error) {
+	return &CandidateUpdate{
+		AbstractAction: AbstractAction{
+			version:  version.ProtocolVersion,
+			nonce:    nonce,
+			gasLimit: gasLimit,
+			gasPrice: gasPrice,
+		},
+		operatorAddress: operatorAddress,
+		rewardAddress:   rewardAddress,
+		payload:         payload,
+	}, nil
+}
+
+// OperatorAddress returns the operator address
+func (cu *CandidateUpdate) OperatorAddress() string { return cu.operatorAddress }
+
+// RewardAddress returns the reward address
+func (cu *CandidateUpdate) RewardAddress() string { return cu.rewardAddress }
+
+// Payload returns the payload bytes
+func (cu *CandidateUpdate) Payload() []byte { return cu.payload }
+
+// Serialize returns a raw byte stream of the CandidateUpdate struct
+func (cu *CandidateUpdate) Serialize() []byte {
+	return byteutil.Must(proto.Marshal(cu.Proto()))
+}
+
+// Proto converts to protobuf CandidateUpdate Action
+func (cu *CandidateUpdate) Proto() *stakingpb.CandidateUpdate {
+	return &stakingpb.CandidateUpdate{
+		OperatorAddress: cu.operatorAddress,
+		RewardAddress:   cu.rewardAddress,
+		Payload:         cu.payload,
+	}
+}
+
+// LoadProto converts a protobuf's Action to CandidateUpdate
+func (cu *CandidateUpdate) LoadProto(pbAct *stakingpb.CandidateUpdate) error {
+	if pbAct == nil {
+		return errors.New("empty action proto to load")
+	}
+	cu.operatorAddress = pbAct.GetOperatorAddress()
+	cu.rewardAddress = pbAct.GetRewardAddress()
+	cu.payload = pbAct.GetPayload()
+	return nil
+}
+
+// IntrinsicGas returns the intrinsic gas of a CandidateUpdate
+func (cu *CandidateUpdate) IntrinsicGas() (uint64, error) {
+	payloadSize := uint64(len(cu.Payload()))
+	return calculateIntrinsicGas(CandidateUpdateBaseIntrinsicGas, CandidateUpdatePayloadGas, payloadSize)
+}
+
+// Cost returns the total cost of a CandidateUpdate
+func (cu *CandidateUpdate) Cost() (*big.Int, error) {
+	intrinsicGas, err := cu.IntrinsicGas()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get intrinsic gas for the CandidateUpdate")
+	}
+	updateFee := big.NewInt(0).Mul(cu.GasPrice(), big.NewInt(0).SetUint64(intrinsicGas))
+	return updateFee, nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+// DoubleSignEvidence is the evidence that a block producer signed two different blocks at the same height and round.
+// It carries the two serialized block headers.
+type DoubleSignEvidence struct {
+	AbstractAction
+
+	header1 []byte
+	header2 []byte
+}
+
+// NewDoubleSignEvidence instantiates a double sign evidence action struct.
+func NewDoubleSignEvidence(
+	nonce uint64,
+	header1 []byte,
+	header2 []byte,
+) *DoubleSignEvidence {
+	return &DoubleSignEvidence{
+		AbstractAction: AbstractAction{
+			version:  version.ProtocolVersion,
+			nonce:    nonce,
+			gasLimit: 0,
+			gasPrice: big.NewInt(0),
+		},
+		header1: header1,
+		header2: header2,
+	}
+}
+
+// Header1 returns the first serialized block header
+func (e *DoubleSignEvidence) Header1() []byte { return e.header1 }
+
+// Header2 returns the second serialized block header
+func (e *DoubleSignEvidence) Header2() []byte { return e.header2 }
+
+// Serialize returns the byte representation of double sign evidence action.
+func (e *DoubleSignEvidence) Serialize() []byte {
+	return byteutil.Must(proto.Marshal(e.Proto()))
+}
+
+// Proto converts double sign evidence action into a proto message.
+func (e *DoubleSignEvidence) Proto() *pollpb.DoubleSignEvidence {
+	return &pollpb.DoubleSignEvidence{
+		Header1: e.header1,
+		Header2: e.header2,
+	}
+}
+
+// LoadProto converts a proto message into double sign evidence action.
+func (e *DoubleSignEvidence) LoadProto(pbAct *pollpb.DoubleSignEvidence) error {
+	if pbAct == nil {
+		return errors.New("empty action proto to load")
+	}
+	if e == nil {
+		return errors.New("nil action to load proto")
+	}
+	e.header1 = pbAct.GetHeader1()
+	e.header2 = pbAct.GetHeader2()
+	return nil
+}
+
+// IntrinsicGas returns the intrinsic gas of a double sign evidence action
+func (e *DoubleSignEvidence) IntrinsicGas() (uint64, error) {
+	return 0, nil
+}
+
+// Cost returns the total cost of a double sign evidence action
+func (e *DoubleSignEvidence) Cost() (*big.Int, error) {
+	return big.NewInt(0), nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoubleSignEvidence(t *testing.T) {
+	require := require.New(t)
+	e := NewDoubleSignEvidence(1, []byte("header1"), []byte("header2"))
+	require.Equal(uint64(1), e.Nonce())
+	igas, err := e.IntrinsicGas()
+	require.NoError(err)
+	require.Equal(uint64(0), igas)
+	cost, err := e.Cost()
+	require.NoError(err)
+	require.Equal(0, big.NewInt(0).Cmp(cost))
+
+	clone := &DoubleSignEvidence{}
+	require.NoError(clone.LoadProto(e.Proto()))
+	require.Equal([]byte("header1"), clone.Header1())
+	require.Equal([]byte("header2"), clone.Header2())
+	require.Error(clone.LoadProto(nil))
+}
@@ -86,6 +86,16 @@ func (p *Protocol) ReadState(context.Context, protocol.StateReader, []byte, ...[
 	return nil, protocol.ErrUnimplemented
 }
 
+// Name returns the name of the protocol
+func (p *Protocol) Name() string {
+	return "Account"
+}
+
+// Version returns the version of the protocol
+func (p *Protocol) Version() uint {
+	return 1
+}
+
 // Register registers the protocol with a unique ID
 func (p *Protocol) Register(r *protocol.Registry) error {
 	return r.Register(protocolID, p)
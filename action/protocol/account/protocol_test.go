@@ -12,6 +12,8 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/iotexproject/iotex-address/address"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/go-pkgs/hash"
@@ -21,8 +23,10 @@ import (
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/db/batch"
 	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
 	"github.com/iotexproject/iotex-core/test/identityset"
 	"github.com/iotexproject/iotex-core/test/mock/mock_chainmanager"
+	"github.com/iotexproject/iotex-core/testutil/teststate"
 )
 
 func TestLoadOrCreateAccountState(t *testing.T) {
@@ -126,3 +130,49 @@ func TestProtocol_Initialize(t *testing.T) {
 	require.NoError(err)
 	require.Equal(big.NewInt(100), acc0.Balance)
 }
+
+func TestAccountUtil_RoundTrip(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	load := func(addr address.Address) *state.Account {
+		acc, err := accountutil.LoadAccount(sm, hash.BytesToHash160(addr.Bytes()))
+		require.NoError(err)
+		return acc
+	}
+
+	// the account created by the protocol is withdrawn from
+	a := identityset.Address(1)
+	require.NoError(createAccount(sm, a.String(), big.NewInt(5)))
+	require.NoError(accountutil.Withdraw(sm, a, big.NewInt(2)))
+	require.Equal(big.NewInt(3), load(a).Balance)
+	err := accountutil.Withdraw(sm, a, big.NewInt(4))
+	require.Equal(accountutil.ErrInsufficientFunds, errors.Cause(err))
+	require.Equal(big.NewInt(3), load(a).Balance)
+	err = accountutil.Deposit(sm, a, big.NewInt(-1))
+	require.Equal(accountutil.ErrInvalidAmount, errors.Cause(err))
+	err = accountutil.Withdraw(sm, identityset.Address(2), big.NewInt(1))
+	require.Equal(accountutil.ErrInsufficientFunds, errors.Cause(err))
+
+	// the account created by a deposit is the same as the recipient of a transfer
+	amount := big.NewInt(7)
+	b, c := identityset.Address(3), identityset.Address(4)
+	require.NoError(accountutil.Deposit(sm, b, amount))
+	amount.SetInt64(0)
+	recipient, err := accountutil.LoadOrCreateAccount(sm, c.String())
+	require.NoError(err)
+	require.NoError(recipient.AddBalance(big.NewInt(7)))
+	require.NoError(accountutil.StoreAccount(sm, c.String(), recipient))
+	bHash, cHash := hash.BytesToHash160(b.Bytes()), hash.BytesToHash160(c.Bytes())
+	dump := sm.Dump()[factory.AccountKVNamespace]
+	require.NotEmpty(dump[string(bHash[:])])
+	require.Equal(dump[string(cHash[:])], dump[string(bHash[:])])
+	require.Equal(big.NewInt(7), load(b).Balance)
+
+	// the nonce is never lowered
+	require.NoError(accountutil.SetAccountNonce(sm, b, 3))
+	require.Equal(uint64(3), load(b).Nonce)
+	err = accountutil.SetAccountNonce(sm, b, 2)
+	require.Equal(accountutil.ErrNonceTooLow, errors.Cause(err))
+	require.Equal(uint64(3), load(b).Nonce)
+	require.Equal(big.NewInt(7), load(b).Balance)
+}
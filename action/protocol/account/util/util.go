@@ -18,6 +18,15 @@ import (
 	"github.com/iotexproject/iotex-core/state"
 )
 
+var (
+	// ErrInsufficientFunds is the error that the balance of an account is lower than the amount withdrawn
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrInvalidAmount is the error that the amount deposited or withdrawn is negative
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrNonceTooLow is the error that the nonce set is lower than the one of the account
+	ErrNonceTooLow = errors.New("nonce too low")
+)
+
 type noncer interface {
 	Nonce() uint64
 }
@@ -56,7 +65,7 @@ func LoadOrCreateAccount(sm protocol.StateManager, encodedAddr string) (*state.A
 // LoadAccount loads an account state
 func LoadAccount(sm protocol.StateReader, addrHash hash.Hash160) (*state.Account, error) {
 	var account state.Account
-	if _, err := sm.State(&account, protocol.LegacyKeyOption(addrHash)); err != nil {
+	if _, err := protocol.LegacyKeyState(sm, &account, "", addrHash); err != nil {
 		if errors.Cause(err) == state.ErrStateNotExist {
 			account = state.EmptyAccount()
 			return &account, nil
@@ -73,7 +82,7 @@ func StoreAccount(sm protocol.StateManager, encodedAddr string, account *state.A
 		return errors.Wrap(err, "failed to get address public key hash from encoded address")
 	}
 	addrHash := hash.BytesToHash160(addr.Bytes())
-	_, err = sm.PutState(account, protocol.LegacyKeyOption(addrHash))
+	_, err = protocol.LegacyKeyPutState(sm, account, "", addrHash)
 	return err
 }
 
@@ -98,7 +107,7 @@ func AccountState(sr protocol.StateReader, encodedAddr string) (*state.Account,
 	}
 	pkHash := hash.BytesToHash160(addr.Bytes())
 	var account state.Account
-	if _, err := sr.State(&account, protocol.LegacyKeyOption(pkHash)); err != nil {
+	if _, err := protocol.LegacyKeyState(sr, &account, "", pkHash); err != nil {
 		if errors.Cause(err) == state.ErrStateNotExist {
 			account = state.EmptyAccount()
 			return &account, nil
@@ -125,3 +134,54 @@ func AccountStateAtHeight(sr protocol.StateReader, encodedAddr string, height ui
 	}
 	return &account, nil
 }
+
+// Deposit adds the amount to the balance of an account. The account which doesn't exist is created, in the same way
+// as the recipient of a transfer is.
+func Deposit(sm protocol.StateManager, addr address.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() < 0 {
+		return errors.Wrapf(ErrInvalidAmount, "failed to deposit %s to %s", amount, addr.String())
+	}
+	account, err := LoadOrCreateAccount(sm, addr.String())
+	if err != nil {
+		return errors.Wrapf(err, "failed to load or create the account of %s", addr.String())
+	}
+	// the balance never shares the amount, which the caller may modify afterwards
+	account.Balance = new(big.Int).Add(account.Balance, amount)
+	return StoreAccount(sm, addr.String(), account)
+}
+
+// Withdraw subtracts the amount from the balance of an account, which fails with ErrInsufficientFunds without changing
+// any state if the balance is lower than the amount
+func Withdraw(sm protocol.StateManager, addr address.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() < 0 {
+		return errors.Wrapf(ErrInvalidAmount, "failed to withdraw %s from %s", amount, addr.String())
+	}
+	account, err := LoadAccount(sm, hash.BytesToHash160(addr.Bytes()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the account of %s", addr.String())
+	}
+	if account.Balance.Cmp(amount) < 0 {
+		return errors.Wrapf(
+			ErrInsufficientFunds,
+			"balance %s of %s is lower than %s",
+			account.Balance,
+			addr.String(),
+			amount,
+		)
+	}
+	account.Balance = new(big.Int).Sub(account.Balance, amount)
+	return StoreAccount(sm, addr.String(), account)
+}
+
+// SetAccountNonce sets the nonce of an account, which can't be lowered. The account which doesn't exist is created.
+func SetAccountNonce(sm protocol.StateManager, addr address.Address, nonce uint64) error {
+	account, err := LoadOrCreateAccount(sm, addr.String())
+	if err != nil {
+		return errors.Wrapf(err, "failed to load or create the account of %s", addr.String())
+	}
+	if nonce < account.Nonce {
+		return errors.Wrapf(ErrNonceTooLow, "nonce %d of %s is lower than %d", nonce, addr.String(), account.Nonce)
+	}
+	account.Nonce = nonce
+	return StoreAccount(sm, addr.String(), account)
+}
@@ -31,6 +31,8 @@ type blockContextKey struct{}
 
 type actionContextKey struct{}
 
+type registryContextKey struct{}
+
 // BlockchainCtx provides blockchain auxiliary information.
 type BlockchainCtx struct {
 	// Genesis is a copy of current genesis
@@ -38,11 +40,17 @@ type BlockchainCtx struct {
 	// History indicates whether to save account/contract history or not
 	History bool
 	// Registry is the pointer protocol registry
+	//
+	// Deprecated: use WithRegistry and GetRegistry instead. It's still populated along with the registry in the
+	// context, and GetRegistry falls back to it, until all the callers are migrated.
 	Registry *Registry
 	// Tip is the information of tip block
 	Tip TipInfo
 	// Candidates is a list of candidates of current round
 	Candidates []*state.Candidate
+	// GetBlockHash returns the hash of the committed block at the height, which is nil if the blocks aren't
+	// accessible
+	GetBlockHash func(uint64) (hash.Hash256, error)
 }
 
 // BlockCtx provides block auxiliary information.
@@ -69,6 +77,10 @@ type ActionCtx struct {
 	IntrinsicGas uint64
 	// Nonce is the nonce of the action
 	Nonce uint64
+	// ReadOnly indicates the action is simulated and its states are discarded, so the handlers skip the side effects
+	// which don't change the states, and refuse the irreversible ones with ErrReadOnly. It's only set by the state
+	// factory, never by the action.
+	ReadOnly bool
 	// History indicates whether to save account/contract history or not
 }
 
@@ -134,3 +146,30 @@ func MustGetActionCtx(ctx context.Context) ActionCtx {
 	}
 	return ac
 }
+
+// WithRegistry adds the protocol registry into context
+func WithRegistry(ctx context.Context, reg *Registry) context.Context {
+	return context.WithValue(ctx, registryContextKey{}, reg)
+}
+
+// GetRegistry gets the protocol registry, which falls back to the one of BlockchainCtx if it isn't added by
+// WithRegistry
+func GetRegistry(ctx context.Context) (*Registry, bool) {
+	if reg, ok := ctx.Value(registryContextKey{}).(*Registry); ok && reg != nil {
+		return reg, true
+	}
+	if bc, ok := GetBlockchainCtx(ctx); ok && bc.Registry != nil {
+		return bc.Registry, true
+	}
+	return nil, false
+}
+
+// MustGetRegistry must get the protocol registry.
+// If the registry doesn't exist, this function panic.
+func MustGetRegistry(ctx context.Context) *Registry {
+	reg, ok := GetRegistry(ctx)
+	if !ok {
+		log.S().Panic("Miss registry context")
+	}
+	return reg
+}
@@ -159,3 +159,38 @@ func TestMustGetActionCtx(t *testing.T) {
 	// Case II: Panic
 	require.Panics(func() { MustGetActionCtx(context.Background()) }, "Miss action context")
 }
+
+func TestGetRegistry(t *testing.T) {
+	require := require.New(t)
+	// Case I: Missing
+	_, ok := GetRegistry(context.Background())
+	require.False(ok)
+	_, ok = GetRegistry(WithBlockchainCtx(context.Background(), BlockchainCtx{}))
+	require.False(ok)
+	_, ok = GetRegistry(WithRegistry(context.Background(), nil))
+	require.False(ok)
+	// Case II: Normal
+	reg := NewRegistry()
+	ret, ok := GetRegistry(WithRegistry(context.Background(), reg))
+	require.True(ok)
+	require.Equal(reg, ret)
+	// Case III: Fall back to the registry of the blockchain context
+	legacy := NewRegistry()
+	ctx := WithBlockchainCtx(context.Background(), BlockchainCtx{Registry: legacy})
+	ret, ok = GetRegistry(ctx)
+	require.True(ok)
+	require.True(legacy == ret)
+	ret, ok = GetRegistry(WithRegistry(ctx, reg))
+	require.True(ok)
+	require.True(reg == ret)
+}
+
+func TestMustGetRegistry(t *testing.T) {
+	require := require.New(t)
+	reg := NewRegistry()
+	// Case I: Normal
+	require.True(reg == MustGetRegistry(WithRegistry(context.Background(), reg)))
+	// Case II: Panic
+	require.Panics(func() { MustGetRegistry(context.Background()) }, "Miss registry context")
+	require.Panics(func() { MustGetRegistry(WithBlockchainCtx(context.Background(), BlockchainCtx{})) })
+}
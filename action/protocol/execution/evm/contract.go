@@ -49,8 +49,8 @@ type (
 		code       []byte // contract byte-code
 		root       hash.Hash256
 		committed  map[hash.Hash256][]byte
-		dao        db.KVStore
-		trie       trie.Trie // storage trie of the contract
+		codeStore  db.KVStoreBasic // store of the byte-codes of the contracts
+		trie       trie.Trie       // storage trie of the contract
 	}
 )
 
@@ -94,7 +94,7 @@ func (c *contract) GetCode() ([]byte, error) {
 	if c.code != nil {
 		return c.code, nil
 	}
-	return c.dao.Get(CodeKVNameSpace, c.Account.CodeHash)
+	return c.codeStore.Get(c.Account.CodeHash)
 }
 
 // SetCode sets the contract's byte-code
@@ -121,7 +121,7 @@ func (c *contract) Commit() error {
 	}
 	if c.dirtyCode {
 		// put the code into storage DB
-		if err := c.dao.Put(CodeKVNameSpace, c.Account.CodeHash, c.code); err != nil {
+		if err := c.codeStore.Put(c.Account.CodeHash, c.code); err != nil {
 			return errors.Wrapf(err, "Failed to store code for new contract, codeHash %x", c.Account.CodeHash[:])
 		}
 		c.dirtyCode = false
@@ -148,27 +148,24 @@ func (c *contract) Snapshot() Contract {
 		code:       c.code,
 		root:       c.Account.Root,
 		committed:  c.committed,
-		dao:        c.dao,
+		codeStore:  c.codeStore,
 		// note we simply save the trie (which is an interface/pointer)
 		// later Revert() call needs to reset the saved trie root
 		trie: c.trie,
 	}
 }
 
-// newContract returns a Contract instance
-func newContract(addr hash.Hash160, account *state.Account, dao db.KVStore) (Contract, error) {
+// newContract returns a Contract instance, whose byte-code is kept in the store of the codes, and whose storage trie is
+// kept in the store of the contracts
+func newContract(addr hash.Hash160, account *state.Account, codeStore, contractStore db.KVStoreBasic) (Contract, error) {
 	c := &contract{
 		Account:   account,
 		root:      account.Root,
 		committed: make(map[hash.Hash256][]byte),
-		dao:       dao,
-	}
-	dbForTrie, err := db.NewKVStoreForTrie(ContractKVNameSpace, dao)
-	if err != nil {
-		return nil, err
+		codeStore: codeStore,
 	}
 	options := []trie.Option{
-		trie.KVStoreOption(dbForTrie),
+		trie.KVStoreOption(contractStore),
 		trie.KeyLengthOption(len(hash.Hash256{})),
 		trie.HashFuncOption(func(data []byte) []byte {
 			h := hash.Hash256b(append(addr[:], data...))
@@ -68,7 +68,7 @@ func TestCreateContract(t *testing.T) {
 
 	flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), cb)
 	require.NoError(err)
-	sm.EXPECT().GetDB().Return(flusher.KVStoreWithBuffer()).AnyTimes()
+	sm.EXPECT().KVStore(gomock.Any()).DoAndReturn(namespaceKVStore(flusher.KVStoreWithBuffer())).AnyTimes()
 	addr := identityset.Address(28)
 	_, err = accountutil.LoadOrCreateAccount(sm, addr.String())
 	require.NoError(err)
@@ -99,6 +99,13 @@ func TestCreateContract(t *testing.T) {
 	require.Equal(codeHash[:], contract1.CodeHash)
 }
 
+// namespaceKVStore returns the KV stores of the namespaces of kv, as the working set does
+func namespaceKVStore(kv db.KVStore) func(string) (db.KVStoreBasic, error) {
+	return func(ns string) (db.KVStoreBasic, error) {
+		return db.NewKVStoreForTrie(ns, kv)
+	}
+}
+
 func TestLoadStoreCommit(t *testing.T) {
 	testLoadStoreCommit := func(cfg config.Config, t *testing.T) {
 		require := require.New(t)
@@ -106,7 +113,12 @@ func TestLoadStoreCommit(t *testing.T) {
 		defer ctrl.Finish()
 		flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), batch.NewCachedBatch())
 		require.NoError(err)
-		cntr1, err := newContract(hash.BytesToHash160(c1[:]), &state.Account{}, flusher.KVStoreWithBuffer())
+		kvStore := namespaceKVStore(flusher.KVStoreWithBuffer())
+		codeStore, err := kvStore(CodeKVNameSpace)
+		require.NoError(err)
+		contractStore, err := kvStore(ContractKVNameSpace)
+		require.NoError(err)
+		cntr1, err := newContract(hash.BytesToHash160(c1[:]), &state.Account{}, codeStore, contractStore)
 		require.NoError(err)
 
 		tests := []cntrTest{
@@ -239,10 +251,16 @@ func TestSnapshot(t *testing.T) {
 	}
 	flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), batch.NewCachedBatch())
 	require.NoError(err)
+	kvStore := namespaceKVStore(flusher.KVStoreWithBuffer())
+	codeStore, err := kvStore(CodeKVNameSpace)
+	require.NoError(err)
+	contractStore, err := kvStore(ContractKVNameSpace)
+	require.NoError(err)
 	c1, err := newContract(
 		hash.BytesToHash160(identityset.Address(28).Bytes()),
 		s,
-		flusher.KVStoreWithBuffer(),
+		codeStore,
+		contractStore,
 	)
 	require.NoError(err)
 	require.NoError(c1.SetState(k2b, v2[:]))
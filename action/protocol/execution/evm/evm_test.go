@@ -35,7 +35,7 @@ func TestExecuteContractFailure(t *testing.T) {
 	sm := mock_chainmanager.NewMockStateManager(ctrl)
 	flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), batch.NewCachedBatch())
 	require.NoError(t, err)
-	sm.EXPECT().GetDB().Return(flusher.KVStoreWithBuffer()).AnyTimes()
+	sm.EXPECT().KVStore(gomock.Any()).DoAndReturn(namespaceKVStore(flusher.KVStoreWithBuffer())).AnyTimes()
 	sm.EXPECT().State(gomock.Any(), gomock.Any()).Return(uint64(0), state.ErrStateNotExist).AnyTimes()
 	sm.EXPECT().PutState(gomock.Any(), gomock.Any()).Return(uint64(0), nil).AnyTimes()
 	sm.EXPECT().Snapshot().Return(1).AnyTimes()
@@ -77,7 +77,7 @@ func TestConstantinople(t *testing.T) {
 	sm := mock_chainmanager.NewMockStateManager(ctrl)
 	flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), batch.NewCachedBatch())
 	require.NoError(err)
-	sm.EXPECT().GetDB().Return(flusher.KVStoreWithBuffer()).AnyTimes()
+	sm.EXPECT().KVStore(gomock.Any()).DoAndReturn(namespaceKVStore(flusher.KVStoreWithBuffer())).AnyTimes()
 
 	ctx := protocol.WithActionCtx(context.Background(), protocol.ActionCtx{
 		Caller: identityset.Address(27),
@@ -56,7 +56,9 @@ type (
 		suicideSnapshot    map[int]deleteAccount // snapshots of suicide accounts
 		preimages          preimageMap
 		preimageSnapshot   map[int]preimageMap
-		dao                db.KVStore
+		codeStore          db.KVStoreBasic
+		contractStore      db.KVStoreBasic
+		preimageStore      db.KVStoreBasic
 		notFixTopicCopyBug bool
 	}
 )
@@ -84,7 +86,9 @@ func NewStateDBAdapter(
 		suicideSnapshot:    make(map[int]deleteAccount),
 		preimages:          make(preimageMap),
 		preimageSnapshot:   make(map[int]preimageMap),
-		dao:                sm.GetDB(),
+		codeStore:          mustKVStore(sm, CodeKVNameSpace),
+		contractStore:      mustKVStore(sm, ContractKVNameSpace),
+		preimageStore:      mustKVStore(sm, PreimageKVNameSpace),
 		notFixTopicCopyBug: notFixTopicCopyBug,
 	}
 	for _, opt := range opts {
@@ -95,6 +99,14 @@ func NewStateDBAdapter(
 	return s
 }
 
+func mustKVStore(sm protocol.StateManager, ns string) db.KVStoreBasic {
+	kv, err := sm.KVStore(ns)
+	if err != nil {
+		log.L().Panic("failed to get the KV store of stateDB", zap.String("namespace", ns), zap.Error(err))
+	}
+	return kv
+}
+
 func (stateDB *StateDBAdapter) logError(err error) {
 	if stateDB.err == nil {
 		stateDB.err = err
@@ -529,7 +541,7 @@ func (stateDB *StateDBAdapter) GetCode(evmAddr common.Address) []byte {
 		log.L().Error("Failed to load account state for address.", log.Hex("addrHash", addr[:]))
 		return nil
 	}
-	code, err := stateDB.dao.Get(CodeKVNameSpace, account.CodeHash[:])
+	code, err := stateDB.codeStore.Get(account.CodeHash[:])
 	if err != nil {
 		// TODO: Suppress the as it's too much now
 		//log.L().Error("Failed to get code from trie.", zap.Error(err))
@@ -658,7 +670,8 @@ func (stateDB *StateDBAdapter) CommitContracts() error {
 			return errors.Wrap(err, "failed to decode address hash")
 		}
 		copy(addr[:], addrBytes)
-		if _, err := stateDB.sm.DelState(protocol.LegacyKeyOption(addr)); err != nil {
+		// the contract created and suicided in the same execution has never been put
+		if _, err := protocol.DelStateIfExists(stateDB.sm, protocol.LegacyKeyOption(addr)); err != nil {
 			stateDB.logError(err)
 			return errors.Wrapf(err, "failed to delete suicide account/contract %x", addr[:])
 		}
@@ -680,7 +693,7 @@ func (stateDB *StateDBAdapter) CommitContracts() error {
 		v := stateDB.preimages[k]
 		h := make([]byte, len(k))
 		copy(h, k[:])
-		stateDB.dao.Put(PreimageKVNameSpace, h, v)
+		stateDB.preimageStore.Put(h, v)
 	}
 	return nil
 }
@@ -698,7 +711,7 @@ func (stateDB *StateDBAdapter) getNewContract(addr hash.Hash160) (Contract, erro
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to load account state for address %x", addr)
 	}
-	contract, err := newContract(addr, account, stateDB.dao)
+	contract, err := newContract(addr, account, stateDB.codeStore, stateDB.contractStore)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create storage trie for new contract %x", addr)
 	}
@@ -58,7 +58,7 @@ func initMockStateManager(ctrl *gomock.Controller) (protocol.StateManager, error
 	if err != nil {
 		return nil, err
 	}
-	sm.EXPECT().GetDB().Return(flusher.KVStoreWithBuffer()).AnyTimes()
+	sm.EXPECT().KVStore(gomock.Any()).DoAndReturn(namespaceKVStore(flusher.KVStoreWithBuffer())).AnyTimes()
 	sm.EXPECT().Snapshot().DoAndReturn(
 		func() int {
 			return cb.Snapshot()
@@ -409,7 +409,7 @@ func TestGetBalanceOnError(t *testing.T) {
 	sm := mock_chainmanager.NewMockStateManager(ctrl)
 	flusher, err := db.NewKVStoreFlusher(db.NewMemKVStore(), batch.NewCachedBatch())
 	require.NoError(t, err)
-	sm.EXPECT().GetDB().Return(flusher.KVStoreWithBuffer()).AnyTimes()
+	sm.EXPECT().KVStore(gomock.Any()).DoAndReturn(namespaceKVStore(flusher.KVStoreWithBuffer())).AnyTimes()
 
 	errs := []error{
 		state.ErrStateNotExist,
@@ -440,10 +440,12 @@ func TestPreimage(t *testing.T) {
 	stateDB.AddPreimage(common.BytesToHash(v1[:]), []byte("fox"))
 	require.NoError(stateDB.CommitContracts())
 	stateDB.clear()
-	k, _ := stateDB.dao.Get(PreimageKVNameSpace, v1[:])
+	preimages, err := sm.KVStore(PreimageKVNameSpace)
+	require.NoError(err)
+	k, _ := preimages.Get(v1[:])
 	require.Equal([]byte("cat"), k)
-	k, _ = stateDB.dao.Get(PreimageKVNameSpace, v2[:])
+	k, _ = preimages.Get(v2[:])
 	require.Equal([]byte("dog"), k)
-	k, _ = stateDB.dao.Get(PreimageKVNameSpace, v3[:])
+	k, _ = preimages.Get(v3[:])
 	require.Equal([]byte("hen"), k)
 }
@@ -1,15 +1,31 @@
 package protocol
 
 import (
+	"context"
+	"sync"
+
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/state"
 )
 
+// ErrInvalidStateOption indicates the options of accessing the states are invalid, such as conflicting with each other
+var ErrInvalidStateOption = errors.New("invalid state option")
+
+var stateConfigPool = sync.Pool{
+	New: func() interface{} {
+		return &StateConfig{}
+	},
+}
+
 // NamespaceOption creates an option for given namesapce
 func NamespaceOption(ns string) StateOption {
 	return func(sc *StateConfig) error {
+		if err := sc.setOption("NamespaceOption"); err != nil {
+			return err
+		}
 		sc.Namespace = ns
 		return nil
 	}
@@ -18,6 +34,9 @@ func NamespaceOption(ns string) StateOption {
 // BlockHeightOption creates an option for given namesapce
 func BlockHeightOption(height uint64) StateOption {
 	return func(sc *StateConfig) error {
+		if err := sc.setOption("BlockHeightOption"); err != nil {
+			return err
+		}
 		sc.AtHeight = true
 		sc.Height = height
 		return nil
@@ -27,6 +46,9 @@ func BlockHeightOption(height uint64) StateOption {
 // KeyOption sets the key for call
 func KeyOption(key []byte) StateOption {
 	return func(cfg *StateConfig) error {
+		if err := cfg.setKeyOption("KeyOption"); err != nil {
+			return err
+		}
 		cfg.Key = make([]byte, len(key))
 		copy(cfg.Key, key)
 		return nil
@@ -36,21 +58,267 @@ func KeyOption(key []byte) StateOption {
 // LegacyKeyOption sets the key for call with legacy key
 func LegacyKeyOption(key hash.Hash160) StateOption {
 	return func(cfg *StateConfig) error {
+		if err := cfg.setKeyOption("LegacyKeyOption"); err != nil {
+			return err
+		}
 		cfg.Key = make([]byte, len(key[:]))
 		copy(cfg.Key, key[:])
 		return nil
 	}
 }
 
-// CreateStateConfig creates a config for accessing stateDB
+// NoCopyKeyOption sets the key for call without copying it, which saves the copy on the hot paths. The caller
+// guarantees the key is never modified afterwards, since the working set may keep it.
+func NoCopyKeyOption(key []byte) StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setKeyOption("NoCopyKeyOption"); err != nil {
+			return err
+		}
+		cfg.Key = key
+		return nil
+	}
+}
+
+// KeysOption makes States read the states of the keys in one call, in the order of the keys. A key which has no state
+// is returned with ErrStateNotExist by the iterator instead of failing the call. It can't be used along with
+// KeyOption or PrefixOption.
+func KeysOption(keys [][]byte) StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setKeyOption("KeysOption"); err != nil {
+			return err
+		}
+		cfg.Keys = make([][]byte, len(keys))
+		for i, key := range keys {
+			cfg.Keys[i] = make([]byte, len(key))
+			copy(cfg.Keys[i], key)
+		}
+		return nil
+	}
+}
+
+// PrefixOption makes States read all the states whose keys start with the prefix in the namespace, ordered by key.
+// It can't be used along with KeyOption or KeysOption.
+func PrefixOption(prefix []byte) StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setKeyOption("PrefixOption"); err != nil {
+			return err
+		}
+		cfg.Prefix = make([]byte, len(prefix))
+		copy(cfg.Prefix, prefix)
+		return nil
+	}
+}
+
+// ObjectOption makes State and PutState share the live object of the state with the working set, which keeps it in its
+// object cache and only serializes it when the working set is finalized, instead of serializing the state in each
+// call. The caller must not mutate an object read or put with the option without putting it back. The readers which
+// don't cache the objects ignore it.
+func ObjectOption() StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setOption("ObjectOption"); err != nil {
+			return err
+		}
+		cfg.Object = true
+		return nil
+	}
+}
+
+// WithContext makes States check the context periodically while scanning the states, and abort with the error of the
+// context once it's done. The reads of a single state ignore it.
+func WithContext(ctx context.Context) StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setOption("WithContext"); err != nil {
+			return err
+		}
+		cfg.Context = ctx
+		return nil
+	}
+}
+
+// IndexOption makes PutState and DelState maintain the reverse index of the state in the namespace indexNS. For each
+// index key indexKeyFn returns for the serialized state, the index keeps an entry keyed by IndexEntryKey pointing to the
+// key of the state, and the entries of the index keys the old state has and the new one doesn't are removed. The
+// entries are written along with the state, so Revert reverts them along with it. It can't be used along with
+// ObjectOption, and the readers ignore it.
+func IndexOption(indexNS string, indexKeyFn func(value []byte) [][]byte) StateOption {
+	return func(cfg *StateConfig) error {
+		if err := cfg.setOption("IndexOption"); err != nil {
+			return err
+		}
+		cfg.IndexNamespace = indexNS
+		cfg.IndexKeys = indexKeyFn
+		return nil
+	}
+}
+
+// IndexEntryKey returns the key of the entry of the index key pointing to the state of the key
+func IndexEntryKey(indexKey, key []byte) []byte {
+	entryKey := make([]byte, 0, len(indexKey)+len(key))
+	entryKey = append(entryKey, indexKey...)
+	return append(entryKey, key...)
+}
+
+// IndexedKeys reads the keys of the states indexed by the index key in the namespace of the index, in the order of
+// the keys
+func IndexedKeys(sr StateReader, indexNS string, indexKey []byte) ([][]byte, error) {
+	_, iter, err := sr.States(NamespaceOption(indexNS), PrefixOption(indexKey))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, 0, iter.Size())
+	for i := 0; i < iter.Size(); i++ {
+		var entry indexEntry
+		if _, err := iter.Next(&entry); err != nil {
+			return nil, err
+		}
+		keys = append(keys, entry)
+	}
+	return keys, nil
+}
+
+// LegacyKeyState reads the state of the legacy key in the namespace, which is the default one if it's empty, through
+// the fast path of the reader if it has one
+func LegacyKeyState(sr StateReader, s interface{}, ns string, key hash.Hash160) (uint64, error) {
+	if r, ok := sr.(LegacyKeyStateReader); ok {
+		return r.LegacyKeyState(s, ns, key)
+	}
+	if ns == "" {
+		return sr.State(s, LegacyKeyOption(key))
+	}
+	return sr.State(s, NamespaceOption(ns), LegacyKeyOption(key))
+}
+
+// LegacyKeyPutState puts the state of the legacy key in the namespace, which is the default one if it's empty, through
+// the fast path of the manager if it has one
+func LegacyKeyPutState(sm StateManager, s interface{}, ns string, key hash.Hash160) (uint64, error) {
+	if m, ok := sm.(LegacyKeyStateManager); ok {
+		return m.LegacyKeyPutState(s, ns, key)
+	}
+	if ns == "" {
+		return sm.PutState(s, LegacyKeyOption(key))
+	}
+	return sm.PutState(s, NamespaceOption(ns), LegacyKeyOption(key))
+}
+
+// StateBytes reads the serialized bytes of the state, without knowing the type of it. It can't be used along with
+// ObjectOption, since the cached object is the state of a type.
+func StateBytes(sr StateReader, opts ...StateOption) ([]byte, uint64, error) {
+	if err := checkNoObjectOption(opts); err != nil {
+		return nil, 0, err
+	}
+	var data serializedState
+	height, err := sr.State(&data, opts...)
+	if err != nil {
+		return nil, height, err
+	}
+	return data, height, nil
+}
+
+// PutStateBytes puts the serialized bytes of the state, the same as PutState puts the state they're deserialized into.
+// It can't be used along with ObjectOption.
+func PutStateBytes(sm StateManager, data []byte, opts ...StateOption) (uint64, error) {
+	if err := checkNoObjectOption(opts); err != nil {
+		return 0, err
+	}
+	s := serializedState(append([]byte{}, data...))
+	return sm.PutState(&s, opts...)
+}
+
+func checkNoObjectOption(opts []StateOption) error {
+	cfg, err := CreateStateConfig(opts...)
+	if err != nil {
+		return err
+	}
+	if cfg.Object {
+		return errors.Wrap(ErrInvalidStateOption, "the serialized state cannot be accessed with ObjectOption")
+	}
+	return nil
+}
+
+// DelStateIfExists deletes the state the same as DelState does, but succeeds if there is no state to delete
+func DelStateIfExists(sm StateManager, opts ...StateOption) (uint64, error) {
+	height, err := sm.DelState(opts...)
+	if errors.Cause(err) == state.ErrStateNotExist {
+		return sm.Height()
+	}
+	return height, err
+}
+
+// CreateStateConfig creates a config for accessing stateDB. An option can't be set twice, and only one of KeyOption,
+// LegacyKeyOption, KeysOption and PrefixOption can be set.
 func CreateStateConfig(opts ...StateOption) (*StateConfig, error) {
 	cfg := StateConfig{AtHeight: false}
+	if err := cfg.apply(opts); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// AcquireStateConfig creates a config the same as CreateStateConfig does, out of a pool of configs which saves the
+// allocations on the hot paths. The config has to be released by ReleaseStateConfig once it isn't used any more, and
+// must not be kept afterwards.
+func AcquireStateConfig(opts ...StateOption) (*StateConfig, error) {
+	cfg := stateConfigPool.Get().(*StateConfig)
+	if err := cfg.apply(opts); err != nil {
+		ReleaseStateConfig(cfg)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ReleaseStateConfig resets the config acquired by AcquireStateConfig, and puts it back to the pool
+func ReleaseStateConfig(cfg *StateConfig) {
+	// the names of the options are kept to reuse the slice
+	*cfg = StateConfig{options: cfg.options[:0]}
+	stateConfigPool.Put(cfg)
+}
+
+func (cfg *StateConfig) apply(opts []StateOption) error {
 	for _, opt := range opts {
-		if err := opt(&cfg); err != nil {
-			return nil, errors.Wrap(err, "failed to execute state option")
+		if err := opt(cfg); err != nil {
+			return errors.Wrap(err, "failed to execute state option")
 		}
 	}
-	return &cfg, nil
+	if cfg.Object && cfg.IndexKeys != nil {
+		// the cached object is serialized later on, while the index is maintained from the serialized state
+		return errors.Wrap(ErrInvalidStateOption, "IndexOption cannot be used along with ObjectOption")
+	}
+	return nil
+}
+
+// CheckHeight checks that the height set by BlockHeightOption, if any, isn't beyond the current height of the reader
+func (cfg *StateConfig) CheckHeight(current uint64) error {
+	if cfg.AtHeight && cfg.Height > current {
+		return errors.Wrapf(
+			ErrInvalidStateOption,
+			"BlockHeightOption of height %d is beyond current height %d",
+			cfg.Height,
+			current,
+		)
+	}
+	return nil
+}
+
+func (cfg *StateConfig) setOption(name string) error {
+	// a call sets a few options, which are scanned faster than looked up in a map
+	for _, option := range cfg.options {
+		if option == name {
+			return errors.Wrapf(ErrInvalidStateOption, "%s is set twice", name)
+		}
+	}
+	cfg.options = append(cfg.options, name)
+	return nil
+}
+
+func (cfg *StateConfig) setKeyOption(name string) error {
+	if cfg.keyOption != "" && cfg.keyOption != name {
+		return errors.Wrapf(ErrInvalidStateOption, "%s cannot be used along with %s", name, cfg.keyOption)
+	}
+	if err := cfg.setOption(name); err != nil {
+		return err
+	}
+	cfg.keyOption = name
+	return nil
 }
 
 type (
@@ -60,15 +328,64 @@ type (
 		AtHeight  bool
 		Height    uint64
 		Key       []byte
+		Keys      [][]byte
+		Prefix    []byte
+		Object    bool
+		// Context bounds the scans of the states, which never ends if it's nil
+		Context context.Context
+		// IndexNamespace is the namespace of the index maintained by IndexKeys, which returns the index keys of a
+		// serialized state
+		IndexNamespace string
+		IndexKeys      func([]byte) [][]byte
+		// the names of the options set, and the name of the option setting the key
+		options   []string
+		keyOption string
+	}
+
+	// indexEntry is an entry of an index, which is the key of the state it points to
+	indexEntry []byte
+
+	// serializedState is a state kept in its serialized bytes
+	serializedState []byte
+
+	// LegacyKeyStateReader is a StateReader with the fast path of reading the state of a legacy key in a namespace,
+	// which saves creating the config out of the options
+	LegacyKeyStateReader interface {
+		LegacyKeyState(s interface{}, ns string, key hash.Hash160) (uint64, error)
+	}
+
+	// LegacyKeyStateManager is a StateManager with the fast path of putting the state of a legacy key in a namespace
+	LegacyKeyStateManager interface {
+		LegacyKeyPutState(s interface{}, ns string, key hash.Hash160) (uint64, error)
 	}
 
 	// StateOption sets parameter for access state
 	StateOption func(*StateConfig) error
 
+	// StateWrite is a state put by PutStates, along with the options of putting it
+	StateWrite struct {
+		State   interface{}
+		Options []StateOption
+	}
+
+	// StateKey is the options of a state deleted by DelStates
+	StateKey []StateOption
+
 	// StateReader defines an interface to read stateDB
 	StateReader interface {
+		// Height returns the height of the block whose states are read. It's the height of the block being worked on
+		// for a working set, whose states include the writes of the actions run so far, and the height of the last
+		// committed block for a reader of the committed states.
 		Height() (uint64, error)
+		// TipHeight returns the height of the last committed block which the states are read atop. It's one less than
+		// Height for a working set, and the same as Height for a reader of the committed states, including the one
+		// pinned at a past height. The responses stamped with a height which can be read at later use TipHeight.
+		TipHeight() (uint64, error)
 		State(interface{}, ...StateOption) (uint64, error)
+		// States reads the states of the keys set by KeysOption in one call, the states whose keys start with the
+		// prefix set by PrefixOption, or all the states of the namespace otherwise. The iterator returns the key of
+		// each state along with it.
+		States(...StateOption) (uint64, state.Iterator, error)
 	}
 
 	// StateManager defines the stateDB interface atop IoTeX blockchain
@@ -79,7 +396,34 @@ type (
 		Revert(int) error
 		// General state
 		PutState(interface{}, ...StateOption) (uint64, error)
+		// DelState deletes the state, and returns ErrStateNotExist if there is no state to delete
 		DelState(...StateOption) (uint64, error)
+		// PutStates puts the states together, either all of which are put or none of which is
+		PutStates([]StateWrite) (uint64, error)
+		// DelStates deletes the states together, either all of which are deleted or none of which is. It returns
+		// ErrStateNotExist if any of them doesn't exist.
+		DelStates([]StateKey) (uint64, error)
+		// GetDB returns the underlying store of all the namespaces.
+		//
+		// Deprecated: the raw writes to any namespace bypass the states, use KVStore instead.
 		GetDB() db.KVStore
+		// KVStore returns the store of the records in the namespace, which are written along with the states, so that
+		// they are covered by the snapshots and the digest. The namespaces of the states kept by the working set can't
+		// be accessed.
+		KVStore(ns string) (db.KVStoreBasic, error)
 	}
 )
+
+func (e *indexEntry) Deserialize(data []byte) error {
+	*e = append((*e)[:0], data...)
+	return nil
+}
+
+func (s *serializedState) Serialize() ([]byte, error) {
+	return *s, nil
+}
+
+func (s *serializedState) Deserialize(data []byte) error {
+	*s = append((*s)[:0], data...)
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStateConfig(t *testing.T) {
+	require := require.New(t)
+
+	cfg, err := CreateStateConfig(NamespaceOption("ns"), KeyOption([]byte("key")), BlockHeightOption(0))
+	require.NoError(err)
+	require.Equal("ns", cfg.Namespace)
+	require.Equal([]byte("key"), cfg.Key)
+	require.True(cfg.AtHeight)
+	require.Equal(uint64(0), cfg.Height)
+
+	keyOptions := map[string]StateOption{
+		"KeyOption":       KeyOption([]byte("key")),
+		"LegacyKeyOption": LegacyKeyOption(hash.Hash160b([]byte("key"))),
+		"KeysOption":      KeysOption([][]byte{[]byte("key")}),
+		"PrefixOption":    PrefixOption([]byte("key")),
+		"NoCopyKeyOption": NoCopyKeyOption([]byte("key")),
+	}
+	for first, opt1 := range keyOptions {
+		for second, opt2 := range keyOptions {
+			_, err := CreateStateConfig(opt1, NamespaceOption("ns"), opt2)
+			require.Equal(ErrInvalidStateOption, errors.Cause(err))
+			if first == second {
+				require.Contains(err.Error(), first+" is set twice")
+			} else {
+				require.Contains(err.Error(), second+" cannot be used along with "+first)
+			}
+		}
+	}
+	_, err = CreateStateConfig(NamespaceOption("ns"), NamespaceOption("ns"))
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "NamespaceOption is set twice")
+	_, err = CreateStateConfig(BlockHeightOption(1), KeyOption([]byte("key")), BlockHeightOption(2))
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "BlockHeightOption is set twice")
+
+	cfg, err = CreateStateConfig(NamespaceOption("ns"))
+	require.NoError(err)
+	require.Nil(cfg.Context)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, err = CreateStateConfig(WithContext(ctx), PrefixOption([]byte("key")))
+	require.NoError(err)
+	require.Equal(ctx, cfg.Context)
+	_, err = CreateStateConfig(WithContext(ctx), WithContext(context.Background()))
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "WithContext is set twice")
+
+	indexKeys := func(value []byte) [][]byte { return [][]byte{value} }
+	cfg, err = CreateStateConfig(KeyOption([]byte("key")), IndexOption("index", indexKeys))
+	require.NoError(err)
+	require.Equal("index", cfg.IndexNamespace)
+	require.Equal([][]byte{[]byte("value")}, cfg.IndexKeys([]byte("value")))
+	_, err = CreateStateConfig(IndexOption("index", indexKeys), IndexOption("index", indexKeys))
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "IndexOption is set twice")
+	_, err = CreateStateConfig(IndexOption("index", indexKeys), ObjectOption())
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "IndexOption cannot be used along with ObjectOption")
+	require.Equal([]byte("indexkey"), IndexEntryKey([]byte("index"), []byte("key")))
+}
+
+func TestNoCopyKeyOption(t *testing.T) {
+	require := require.New(t)
+
+	key := []byte("key")
+	cfg, err := CreateStateConfig(KeyOption(key))
+	require.NoError(err)
+	key[0] = 'K'
+	require.Equal([]byte("key"), cfg.Key)
+	cfg, err = CreateStateConfig(NoCopyKeyOption(key))
+	require.NoError(err)
+	require.Equal([]byte("Key"), cfg.Key)
+}
+
+func TestStateConfigPool(t *testing.T) {
+	require := require.New(t)
+
+	// the released config is reset, and the invalid options release the config as well
+	cfg, err := AcquireStateConfig(NamespaceOption("ns"), KeyOption([]byte("key")), ObjectOption())
+	require.NoError(err)
+	ReleaseStateConfig(cfg)
+	_, err = AcquireStateConfig(NamespaceOption("ns"), NamespaceOption("ns"))
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	for i := 0; i < 10; i++ {
+		cfg, err := AcquireStateConfig(KeyOption([]byte("other")))
+		require.NoError(err)
+		require.Equal("", cfg.Namespace)
+		require.False(cfg.Object)
+		require.Equal([]string{"KeyOption"}, cfg.options)
+		ReleaseStateConfig(cfg)
+	}
+
+	// the configs acquired concurrently never share the options
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ns := fmt.Sprintf("ns%d", i)
+			for j := 0; j < 1000; j++ {
+				key := []byte(fmt.Sprintf("%s-%d", ns, j))
+				cfg, err := AcquireStateConfig(NamespaceOption(ns), KeyOption(key))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if cfg.Namespace != ns || string(cfg.Key) != string(key) || len(cfg.options) != 2 {
+					errs <- errors.Errorf("config of %s is mixed up with %+v", key, cfg)
+					return
+				}
+				ReleaseStateConfig(cfg)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(err)
+	}
+}
+
+func BenchmarkStateConfig(b *testing.B) {
+	key := hash.Hash160b([]byte("key"))
+	b.Run("create", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := CreateStateConfig(NamespaceOption("ns"), LegacyKeyOption(key)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("acquire", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cfg, err := AcquireStateConfig(NamespaceOption("ns"), NoCopyKeyOption(key[:]))
+			if err != nil {
+				b.Fatal(err)
+			}
+			ReleaseStateConfig(cfg)
+		}
+	})
+}
+
+func TestStateConfig_CheckHeight(t *testing.T) {
+	require := require.New(t)
+
+	cfg, err := CreateStateConfig(KeyOption([]byte("key")))
+	require.NoError(err)
+	require.NoError(cfg.CheckHeight(0))
+	for _, height := range []uint64{0, 5, 10} {
+		cfg, err = CreateStateConfig(KeyOption([]byte("key")), BlockHeightOption(height))
+		require.NoError(err)
+		require.NoError(cfg.CheckHeight(10))
+	}
+	cfg, err = CreateStateConfig(KeyOption([]byte("key")), BlockHeightOption(11))
+	require.NoError(err)
+	err = cfg.CheckHeight(10)
+	require.Equal(ErrInvalidStateOption, errors.Cause(err))
+	require.Contains(err.Error(), "height 11 is beyond current height 10")
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/protocolpb"
+)
+
+// PaginationArgs defines a page of the items returned by a ReadState method, which is passed to the method as a
+// protobuf-encoded Pagination argument
+type PaginationArgs struct {
+	Offset uint64
+	Limit  uint64
+}
+
+// Encode returns the canonical encoding of the pagination argument
+func (p PaginationArgs) Encode() []byte {
+	data, err := proto.Marshal(&protocolpb.Pagination{Offset: p.Offset, Limit: p.Limit})
+	if err != nil {
+		// marshaling a message of scalars never fails
+		panic(err)
+	}
+	return data
+}
+
+// Page wraps the encoded items of the page in the response envelope, along with the effective offset and limit, and
+// the total number of the items
+func (p PaginationArgs) Page(data []byte, total uint64) ([]byte, error) {
+	return proto.Marshal(&protocolpb.Page{
+		Data:   data,
+		Offset: p.Offset,
+		Limit:  p.Limit,
+		Total:  total,
+	})
+}
+
+// ParsePagination decodes the pagination argument, which is the first of the args if there is any. The offset and the
+// limit which aren't set take the ones of the defaults, and a limit above maxLimit is clamped to it, unless maxLimit
+// is 0.
+func ParsePagination(args [][]byte, defaults PaginationArgs, maxLimit uint64) (PaginationArgs, error) {
+	p := defaults
+	if len(args) > 0 {
+		var pb protocolpb.Pagination
+		if err := proto.Unmarshal(args[0], &pb); err != nil {
+			return PaginationArgs{}, NewReadStateError(BadArgEncoding, errors.Wrap(err, "failed to unmarshal pagination"))
+		}
+		if pb.Offset != 0 {
+			p.Offset = pb.Offset
+		}
+		if pb.Limit != 0 {
+			p.Limit = pb.Limit
+		}
+	}
+	if maxLimit != 0 && p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+	return p, nil
+}
+
+// Paginate returns the range [start, end) of the page in the items of the length. The page of an offset beyond the
+// items is empty.
+func Paginate(length int, p PaginationArgs) (int, int) {
+	n := uint64(length)
+	if p.Offset >= n {
+		return length, length
+	}
+	end := n
+	if p.Limit < end-p.Offset {
+		end = p.Offset + p.Limit
+	}
+	return int(p.Offset), int(end)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol/protocolpb"
+)
+
+func TestParsePagination(t *testing.T) {
+	require := require.New(t)
+	defaults := PaginationArgs{Offset: 0, Limit: 10}
+	for _, e := range []struct {
+		args     [][]byte
+		maxLimit uint64
+		expected PaginationArgs
+	}{
+		{nil, 0, defaults},
+		{[][]byte{{}}, 0, defaults},
+		{[][]byte{PaginationArgs{Offset: 3}.Encode()}, 0, PaginationArgs{Offset: 3, Limit: 10}},
+		{[][]byte{PaginationArgs{Offset: 3, Limit: 5}.Encode()}, 0, PaginationArgs{Offset: 3, Limit: 5}},
+		{[][]byte{PaginationArgs{Limit: 50}.Encode()}, 0, PaginationArgs{Limit: 50}},
+		// the limit above the max is clamped
+		{[][]byte{PaginationArgs{Limit: 50}.Encode()}, 20, PaginationArgs{Limit: 20}},
+		{[][]byte{PaginationArgs{Limit: 20}.Encode()}, 20, PaginationArgs{Limit: 20}},
+		{nil, 5, PaginationArgs{Limit: 5}},
+	} {
+		p, err := ParsePagination(e.args, defaults, e.maxLimit)
+		require.NoError(err)
+		require.Equal(e.expected, p)
+	}
+	_, err := ParsePagination([][]byte{{0xff}}, defaults, 0)
+	code, ok := ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(BadArgEncoding, code)
+}
+
+func TestPaginate(t *testing.T) {
+	require := require.New(t)
+	for _, e := range []struct {
+		length     int
+		p          PaginationArgs
+		start, end int
+	}{
+		{0, PaginationArgs{Offset: 0, Limit: 10}, 0, 0},
+		{5, PaginationArgs{Offset: 0, Limit: 10}, 0, 5},
+		{5, PaginationArgs{Offset: 0, Limit: 5}, 0, 5},
+		{5, PaginationArgs{Offset: 1, Limit: 2}, 1, 3},
+		{5, PaginationArgs{Offset: 4, Limit: 2}, 4, 5},
+		{5, PaginationArgs{Offset: 2, Limit: 0}, 2, 2},
+		// the pages out of range are empty
+		{5, PaginationArgs{Offset: 5, Limit: 2}, 5, 5},
+		{5, PaginationArgs{Offset: 100, Limit: 2}, 5, 5},
+		// the end doesn't overflow
+		{5, PaginationArgs{Offset: 1, Limit: ^uint64(0)}, 1, 5},
+	} {
+		start, end := Paginate(e.length, e.p)
+		require.Equal(e.start, start)
+		require.Equal(e.end, end)
+	}
+}
+
+func TestPaginationArgs_Page(t *testing.T) {
+	require := require.New(t)
+	p := PaginationArgs{Offset: 2, Limit: 3}
+	data, err := p.Page([]byte{1, 2}, 7)
+	require.NoError(err)
+	var page protocolpb.Page
+	require.NoError(proto.Unmarshal(data, &page))
+	require.Equal([]byte{1, 2}, page.Data)
+	require.Equal(uint64(2), page.Offset)
+	require.Equal(uint64(3), page.Limit)
+	require.Equal(uint64(7), page.Total)
+}
+
+func TestReadStateRouter_PaginationArg(t *testing.T) {
+	require := require.New(t)
+	r := MustNewReadStateRouter(ReadStateMethod{
+		Name:    "method",
+		Args:    []ArgType{PaginationArg},
+		Handler: echoHandler,
+	})
+	arg := PaginationArgs{Offset: 1, Limit: 2}.Encode()
+	data, err := r.ReadState(context.Background(), nil, []byte("method"), arg)
+	require.NoError(err)
+	require.Equal(arg, data)
+	_, err = r.ReadState(context.Background(), nil, []byte("method"), []byte{0xff})
+	code, ok := ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(BadArgEncoding, code)
+}
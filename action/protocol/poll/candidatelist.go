@@ -0,0 +1,86 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+const (
+	// CandidateListV1 is the legacy encoding of the candidate lists read from the poll protocol
+	CandidateListV1 uint32 = 1
+	// CandidateListV2 is the versioned protobuf encoding of the candidate lists read from the poll protocol
+	CandidateListV2 uint32 = 2
+)
+
+// candidateListVersion returns the encoding version requested by the argument after the epoch number, which defaults
+// to the legacy encoding for the clients not aware of the versions
+func candidateListVersion(args [][]byte) (uint32, error) {
+	if len(args) < 2 {
+		return CandidateListV1, nil
+	}
+	if len(args[1]) != 8 {
+		return 0, protocol.ReadStateErrorf(protocol.BadArgEncoding, "invalid version argument of %d bytes", len(args[1]))
+	}
+	version := byteutil.BytesToUint64(args[1])
+	switch version {
+	case uint64(CandidateListV1), uint64(CandidateListV2):
+		return uint32(version), nil
+	default:
+		return 0, protocol.NewReadStateError(
+			protocol.BadArgEncoding,
+			errors.Wrapf(ErrUnknownVersion, "candidate list version %d", version),
+		)
+	}
+}
+
+// serializeCandidateList encodes the candidates of the epoch in the version. The V2 encoding carries the percentage of
+// the voting power each candidate keeps under the probation list and the rule of the epoch, and whether it has enough
+// votes to be elected under the threshold. The raw votes are the tokens staked for a candidate, which are the same as
+// its weighted votes if unknown. The owner address is left empty, as the poll protocol only knows the operator address
+// of a candidate.
+func serializeCandidateList(
+	candidates state.CandidateList,
+	epochNum uint64,
+	version uint32,
+	probationList *vote.ProbationList,
+	rule vote.ProbationRule,
+	threshold *big.Int,
+) ([]byte, error) {
+	switch version {
+	case CandidateListV1:
+		return candidates.Serialize()
+	case CandidateListV2:
+		candidatesPb := make([]*pollpb.CandidateV2, 0, len(candidates))
+		for _, cand := range candidates {
+			candidatesPb = append(candidatesPb, &pollpb.CandidateV2{
+				OperatorAddress:        cand.Address,
+				RewardAddress:          cand.RewardAddress,
+				Votes:                  cand.Votes.String(),
+				RawVotes:               cand.RawVotesOrVotes().String(),
+				ProbationIntensityRate: probationList.IntensityRateOf(cand.Address, rule),
+				Electable:              threshold == nil || cand.Votes.Cmp(threshold) >= 0,
+			})
+		}
+		return proto.Marshal(&pollpb.CandidateListV2{
+			Version:    CandidateListV2,
+			EpochNum:   epochNum,
+			Candidates: candidatesPb,
+		})
+	default:
+		return nil, errors.Wrapf(ErrUnknownVersion, "candidate list version %d", version)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+func TestCandidateListVersion(t *testing.T) {
+	require := require.New(t)
+	epochNum := byteutil.Uint64ToBytes(3)
+
+	tests := []struct {
+		args    [][]byte
+		version uint32
+	}{
+		{nil, CandidateListV1},
+		{[][]byte{epochNum}, CandidateListV1},
+		{[][]byte{epochNum, byteutil.Uint64ToBytes(1)}, CandidateListV1},
+		{[][]byte{epochNum, byteutil.Uint64ToBytes(2)}, CandidateListV2},
+	}
+	for _, test := range tests {
+		version, err := candidateListVersion(test.args)
+		require.NoError(err)
+		require.Equal(test.version, version)
+	}
+	_, err := candidateListVersion([][]byte{epochNum, byteutil.Uint64ToBytes(3)})
+	require.Equal(ErrUnknownVersion, errors.Cause(err))
+	_, err = candidateListVersion([][]byte{epochNum, {2}})
+	require.Error(err)
+}
+
+func TestSerializeCandidateList(t *testing.T) {
+	require := require.New(t)
+	candidates := state.CandidateList{
+		{
+			Address:       "op1",
+			Votes:         big.NewInt(10),
+			RewardAddress: "rw1",
+			RawVotes:      big.NewInt(8),
+		},
+		{
+			Address:       "op2",
+			Votes:         big.NewInt(256),
+			RewardAddress: "rw2",
+		},
+	}
+	probationList := vote.NewProbationList(3, 50)
+	probationList.ProbationInfo["op2"] = 1
+
+	// the golden encodings are pinned for the API gateways
+	v1, err := serializeCandidateList(candidates, 3, CandidateListV1, probationList, vote.ProbationRule{}, nil)
+	require.NoError(err)
+	require.Equal("0a0d0a036f703112010a22037277310a0e0a036f7032120201002203727732", hex.EncodeToString(v1))
+	legacy, err := candidates.Serialize()
+	require.NoError(err)
+	require.Equal(legacy, v1)
+
+	// the threshold lies between the votes of the two candidates
+	v2, err := serializeCandidateList(candidates, 3, CandidateListV2, probationList, vote.ProbationRule{}, big.NewInt(100))
+	require.NoError(err)
+	require.Equal(
+		"080210031a1312036f70311a037277312202313028643a01381a1812036f70321a037277322203323536283230013a03323536",
+		hex.EncodeToString(v2),
+	)
+	listPb := &pollpb.CandidateListV2{}
+	require.NoError(proto.Unmarshal(v2, listPb))
+	require.Equal(CandidateListV2, listPb.Version)
+	require.Equal(uint64(3), listPb.EpochNum)
+	require.Equal(2, len(listPb.Candidates))
+	require.Equal("", listPb.Candidates[1].OwnerAddress)
+	require.Equal("op2", listPb.Candidates[1].OperatorAddress)
+	require.Equal("rw2", listPb.Candidates[1].RewardAddress)
+	require.Equal("256", listPb.Candidates[1].Votes)
+	// the raw votes of a candidate fall back to its votes if unknown
+	require.Equal("8", listPb.Candidates[0].RawVotes)
+	require.Equal("256", listPb.Candidates[1].RawVotes)
+	require.Equal(uint32(50), listPb.Candidates[1].ProbationIntensityRate)
+	require.Equal(uint32(100), listPb.Candidates[0].ProbationIntensityRate)
+	require.False(listPb.Candidates[0].Electable)
+	require.True(listPb.Candidates[1].Electable)
+
+	_, err = serializeCandidateList(candidates, 3, 3, probationList, vote.ProbationRule{}, nil)
+	require.Equal(ErrUnknownVersion, errors.Cause(err))
+}
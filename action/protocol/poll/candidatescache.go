@@ -0,0 +1,151 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/pkg/cache"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// defaultPollCacheSize is the size of the poll result cache if it isn't set
+const defaultPollCacheSize = 32
+
+var pollCacheMtc = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "iotex_poll_cache",
+		Help: "IoTeX poll result cache counter.",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	prometheus.MustRegister(pollCacheMtc)
+}
+
+type (
+	// pollCacheKey is the key of a poll result in the cache
+	pollCacheKey struct {
+		method             string
+		gravityChainHeight uint64
+		epochNum           uint64
+	}
+
+	// candidatesCache caches the candidate lists of the tip epoch. It is cleared once a new epoch begins or a block
+	// changing the poll states is committed, and the lists of the past epochs are never cached, so that the queries of
+	// historical heights don't get the list of the tip epoch.
+	candidatesCache struct {
+		cache      *cache.ThreadSafeLruCache
+		epochNum   uint64
+		generation uint64
+		mutex      sync.Mutex
+		inflight   singleflight.Group
+	}
+)
+
+func newCandidatesCache(size int) *candidatesCache {
+	return &candidatesCache{cache: cache.NewThreadSafeLruCache(size)}
+}
+
+// Get returns a copy of the candidates of the key from the cache, or computes and caches them. The concurrent
+// computations of the same key are merged into one.
+func (c *candidatesCache) Get(
+	ctx context.Context,
+	key pollCacheKey,
+	compute func() (state.CandidateList, error),
+) (state.CandidateList, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
+	c.mutex.Lock()
+	if tipEpochNum > c.epochNum {
+		c.clear()
+		c.epochNum = tipEpochNum
+	}
+	cacheEpochNum, generation := c.epochNum, c.generation
+	c.mutex.Unlock()
+	if tipEpochNum < cacheEpochNum || key.epochNum < tipEpochNum {
+		pollCacheMtc.WithLabelValues("bypass").Inc()
+		return compute()
+	}
+
+	if v, ok := c.cache.Get(key); ok {
+		pollCacheMtc.WithLabelValues("hit").Inc()
+		return cloneCandidates(v.(state.CandidateList)), nil
+	}
+	pollCacheMtc.WithLabelValues("miss").Inc()
+	flight := fmt.Sprintf("%d.%s.%d.%d", generation, key.method, key.gravityChainHeight, key.epochNum)
+	v, err, _ := c.inflight.Do(flight, func() (interface{}, error) {
+		candidates, err := compute()
+		if err != nil {
+			return nil, err
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		// the result computed before an invalidation isn't cached
+		if generation == c.generation {
+			c.cache.Add(key, candidates)
+		}
+		return candidates, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneCandidates(v.(state.CandidateList)), nil
+}
+
+// ReceiveBlock clears the cache once a block with any action of the poll protocol is committed. The cache isn't
+// cleared by the handlers, as the actions may be run on a working set which is never committed.
+func (c *candidatesCache) ReceiveBlock(blk *block.Block) error {
+	for _, selp := range blk.Actions {
+		switch selp.Action().(type) {
+		case *action.PutPollResult, *action.SetDelegateFilter, *action.UpdateDelegates, *action.DoubleSignEvidence:
+			c.Invalidate()
+			return nil
+		}
+	}
+	return nil
+}
+
+// Invalidate clears the cache
+func (c *candidatesCache) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clear()
+}
+
+func (c *candidatesCache) clear() {
+	c.cache.Clear()
+	c.generation++
+}
+
+// cloneCandidates copies the candidates, so that the cached list isn't changed by the callers
+func cloneCandidates(l state.CandidateList) state.CandidateList {
+	if l == nil {
+		return nil
+	}
+	clone := make(state.CandidateList, len(l))
+	for i, c := range l {
+		clone[i] = c.Clone()
+	}
+	return clone
+}
+
+// Len returns the number of cached candidate lists
+func (c *candidatesCache) Len() int {
+	return c.cache.Len()
+}
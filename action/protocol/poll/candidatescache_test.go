@@ -0,0 +1,130 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestCandidatesCache(t *testing.T) {
+	require := require.New(t)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20)))
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	}
+	// tip is in epoch 2
+	bcCtx.Tip.Height = 800
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	candidates := state.CandidateList{
+		{
+			Address:       identityset.Address(1).String(),
+			Votes:         big.NewInt(10),
+			RewardAddress: identityset.Address(1).String(),
+		},
+	}
+	var computed int32
+	compute := func() (state.CandidateList, error) {
+		atomic.AddInt32(&computed, 1)
+		// keep the computation in flight for the concurrent readers
+		time.Sleep(50 * time.Millisecond)
+		return candidates, nil
+	}
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		c := newCandidatesCache(defaultPollCacheSize)
+		key := pollCacheKey{method: "DelegatesByEpoch", epochNum: 2}
+		var wg sync.WaitGroup
+		results := make([]state.CandidateList, 32)
+		errs := make([]error, len(results))
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = c.Get(ctx, key, compute)
+			}(i)
+		}
+		wg.Wait()
+		require.Equal(int32(1), atomic.LoadInt32(&computed))
+		for i := range results {
+			require.NoError(errs[i])
+			require.Equal(candidates, results[i])
+		}
+		require.Equal(1, c.Len())
+
+		// the cached list isn't changed by the callers
+		results[0][0].Votes.SetInt64(0)
+		cached, err := c.Get(ctx, key, compute)
+		require.NoError(err)
+		require.Equal(big.NewInt(10), cached[0].Votes)
+		require.Equal(int32(1), atomic.LoadInt32(&computed))
+
+		// a block without any poll action doesn't clear the cache
+		blk, err := block.NewTestingBuilder().SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(c.ReceiveBlock(&blk))
+		require.Equal(1, c.Len())
+
+		// the poll result of the next epoch is committed
+		r := action.NewPutPollResult(1, 2, nil)
+		elp := (&action.EnvelopeBuilder{}).SetNonce(1).SetAction(r).Build()
+		selp, err := action.Sign(elp, identityset.PrivateKey(27))
+		require.NoError(err)
+		blk, err = block.NewTestingBuilder().AddActions(selp).SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(c.ReceiveBlock(&blk))
+		require.Equal(0, c.Len())
+		_, err = c.Get(ctx, key, compute)
+		require.NoError(err)
+		require.Equal(int32(2), atomic.LoadInt32(&computed))
+	})
+
+	t.Run("historical epochs bypass the cache", func(t *testing.T) {
+		atomic.StoreInt32(&computed, 0)
+		c := newCandidatesCache(defaultPollCacheSize)
+		bypass := testutil.ToFloat64(pollCacheMtc.WithLabelValues("bypass"))
+		key := pollCacheKey{method: "DelegatesByEpoch", epochNum: 1}
+		for i := 0; i < 2; i++ {
+			_, err := c.Get(ctx, key, compute)
+			require.NoError(err)
+		}
+		require.Equal(int32(2), atomic.LoadInt32(&computed))
+		require.Equal(bypass+2, testutil.ToFloat64(pollCacheMtc.WithLabelValues("bypass")))
+		require.Equal(0, c.Len())
+
+		// a reader at an older tip doesn't get the list of the newer epoch
+		key.epochNum = 2
+		_, err := c.Get(ctx, key, compute)
+		require.NoError(err)
+		require.Equal(1, c.Len())
+		oldCtx := protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{
+			Genesis:  config.Default.Genesis,
+			Registry: registry,
+		})
+		key.epochNum = 1
+		_, err = c.Get(oldCtx, key, compute)
+		require.NoError(err)
+		require.Equal(bypass+3, testutil.ToFloat64(pollCacheMtc.WithLabelValues("bypass")))
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// delegateFilter is the allow and deny lists of the life long delegates by operator address. An empty allow list
+// allows every delegate, and the deny list takes precedence over the allow list.
+type delegateFilter struct {
+	allowList []string
+	denyList  []string
+}
+
+// Serialize serializes the delegate filter into bytes
+func (f *delegateFilter) Serialize() ([]byte, error) {
+	return proto.Marshal(&pollpb.DelegateFilter{
+		AllowList: f.allowList,
+		DenyList:  f.denyList,
+	})
+}
+
+// Deserialize deserializes the bytes into the delegate filter
+func (f *delegateFilter) Deserialize(buf []byte) error {
+	pb := &pollpb.DelegateFilter{}
+	if err := proto.Unmarshal(buf, pb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal delegate filter")
+	}
+	f.allowList = pb.GetAllowList()
+	f.denyList = pb.GetDenyList()
+	return nil
+}
+
+// apply returns the candidates passing the filter in their original order
+func (f *delegateFilter) apply(candidates state.CandidateList) state.CandidateList {
+	if len(f.allowList) == 0 && len(f.denyList) == 0 {
+		return candidates
+	}
+	allowed := make(map[string]bool, len(f.allowList))
+	for _, addr := range f.allowList {
+		allowed[addr] = true
+	}
+	denied := make(map[string]bool, len(f.denyList))
+	for _, addr := range f.denyList {
+		denied[addr] = true
+	}
+	filtered := make(state.CandidateList, 0, len(candidates))
+	for _, c := range candidates {
+		if denied[c.Address] || (len(allowed) != 0 && !allowed[c.Address]) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+// readDelegateFilter returns the delegate filter of current epoch, or the one of next epoch if readFromNext is true.
+// An empty filter is returned if the filter has never been set.
+func readDelegateFilter(sr protocol.StateReader, readFromNext bool) (*delegateFilter, error) {
+	keys := []string{candidatesutil.CurDelegateFilterKey}
+	if readFromNext {
+		keys = append([]string{candidatesutil.NxtDelegateFilterKey}, keys...)
+	}
+	for _, k := range keys {
+		f := &delegateFilter{}
+		key := candidatesutil.ConstructKey(k)
+		_, err := sr.State(f, protocol.KeyOption(key[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+		if err == nil {
+			return f, nil
+		}
+		if errors.Cause(err) != state.ErrStateNotExist {
+			return nil, errors.Wrap(err, "failed to read delegate filter")
+		}
+	}
+	return &delegateFilter{}, nil
+}
+
+// shiftDelegateFilter makes the delegate filter of next epoch effective at the start of the epoch
+func shiftDelegateFilter(sm protocol.StateManager) error {
+	next := &delegateFilter{}
+	nextKey := candidatesutil.ConstructKey(candidatesutil.NxtDelegateFilterKey)
+	_, err := sm.State(next, protocol.KeyOption(nextKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		return nil
+	default:
+		return errors.Wrap(err, "failed to read next delegate filter")
+	}
+	curKey := candidatesutil.ConstructKey(candidatesutil.CurDelegateFilterKey)
+	if _, err := sm.PutState(next, protocol.KeyOption(curKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+		return errors.Wrap(err, "failed to write current delegate filter")
+	}
+	return nil
+}
+
+// validateDelegateFilter checks that the delegate filter is set by the admin in genesis with valid addresses
+func validateDelegateFilter(ctx context.Context, f *action.SetDelegateFilter) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	admin := bcCtx.Genesis.DelegateFilterAdmin
+	if admin == "" {
+		return errors.Wrap(ErrInvalidDelegateFilter, "delegate filter isn't enabled")
+	}
+	if actionCtx.Caller == nil || actionCtx.Caller.String() != admin {
+		return errors.Wrapf(ErrInvalidDelegateFilter, "delegate filter is set by %v instead of admin %s", actionCtx.Caller, admin)
+	}
+	for _, list := range [][]string{f.AllowList(), f.DenyList()} {
+		for _, addr := range list {
+			if _, err := address.FromString(addr); err != nil {
+				return errors.Wrapf(ErrInvalidDelegateFilter, "invalid operator address %s", addr)
+			}
+		}
+	}
+	return nil
+}
+
+// handleSetDelegateFilter sets the delegate filter, which takes effect from next epoch
+func handleSetDelegateFilter(
+	ctx context.Context,
+	sm protocol.StateManager,
+	f *action.SetDelegateFilter,
+	protocolAddr string,
+) (*action.Receipt, error) {
+	if err := validateDelegateFilter(ctx, f); err != nil {
+		return failureReceipt(ctx, protocolAddr, err), nil
+	}
+	filter := &delegateFilter{allowList: f.AllowList(), denyList: f.DenyList()}
+	nextKey := candidatesutil.ConstructKey(candidatesutil.NxtDelegateFilterKey)
+	if _, err := sm.PutState(filter, protocol.KeyOption(nextKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to write next delegate filter")
+	}
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	return &action.Receipt{
+		Status:          uint64(iotextypes.ReceiptStatus_Success),
+		ActionHash:      actionCtx.ActionHash,
+		BlockHeight:     blkCtx.BlockHeight,
+		GasConsumed:     actionCtx.IntrinsicGas,
+		ContractAddress: protocolAddr,
+	}, nil
+}
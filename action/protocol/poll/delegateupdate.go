@@ -0,0 +1,213 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// delegateUpdateProposal is an update of the life long delegates along with the current delegates approving it
+type delegateUpdateProposal struct {
+	delegates []genesis.Delegate
+	approvers []string
+}
+
+// Serialize serializes the proposal into bytes
+func (dp *delegateUpdateProposal) Serialize() ([]byte, error) {
+	delegates := action.NewUpdateDelegates(0, dp.delegates).Proto().GetDelegates()
+	return proto.Marshal(&pollpb.DelegateUpdateProposal{
+		Delegates: delegates,
+		Approvers: dp.approvers,
+	})
+}
+
+// Deserialize deserializes the bytes into the proposal
+func (dp *delegateUpdateProposal) Deserialize(buf []byte) error {
+	pb := &pollpb.DelegateUpdateProposal{}
+	if err := proto.Unmarshal(buf, pb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal delegate update proposal")
+	}
+	u := &action.UpdateDelegates{}
+	if err := u.LoadProto(&pollpb.DelegateUpdate{Delegates: pb.GetDelegates()}); err != nil {
+		return err
+	}
+	dp.delegates = u.Delegates()
+	dp.approvers = pb.GetApprovers()
+	return nil
+}
+
+// proposes returns true if the proposal is the update of the same delegates in the same order
+func (dp *delegateUpdateProposal) proposes(delegates []genesis.Delegate) bool {
+	if len(dp.delegates) != len(delegates) {
+		return false
+	}
+	for i, d := range delegates {
+		if dp.delegates[i] != d {
+			return false
+		}
+	}
+	return true
+}
+
+// readLifeLongDelegates returns the life long delegates of current epoch, or the ones of next epoch if readFromNext
+// is true. Nil is returned if the delegates have never been updated.
+func readLifeLongDelegates(sr protocol.StateReader, readFromNext bool) (state.CandidateList, error) {
+	keys := []string{candidatesutil.CurLifeLongDelegatesKey}
+	if readFromNext {
+		keys = append([]string{candidatesutil.NxtLifeLongDelegatesKey}, keys...)
+	}
+	for _, k := range keys {
+		var delegates state.CandidateList
+		key := candidatesutil.ConstructKey(k)
+		_, err := sr.State(&delegates, protocol.KeyOption(key[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+		if err == nil {
+			return delegates, nil
+		}
+		if errors.Cause(err) != state.ErrStateNotExist {
+			return nil, errors.Wrap(err, "failed to read life long delegates")
+		}
+	}
+	return nil, nil
+}
+
+// shiftLifeLongDelegates makes the life long delegates of next epoch effective at the start of the epoch
+func shiftLifeLongDelegates(sm protocol.StateManager) error {
+	var next state.CandidateList
+	nextKey := candidatesutil.ConstructKey(candidatesutil.NxtLifeLongDelegatesKey)
+	_, err := sm.State(&next, protocol.KeyOption(nextKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		return nil
+	default:
+		return errors.Wrap(err, "failed to read next life long delegates")
+	}
+	curKey := candidatesutil.ConstructKey(candidatesutil.CurLifeLongDelegatesKey)
+	if _, err := sm.PutState(&next, protocol.KeyOption(curKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+		return errors.Wrap(err, "failed to write current life long delegates")
+	}
+	return nil
+}
+
+// validateDelegateUpdate checks that the update is sent by the admin in genesis or by one of the current delegates
+// when a quorum is set, and that the new delegates are valid. The new delegates are returned as candidates.
+func validateDelegateUpdate(
+	ctx context.Context,
+	u *action.UpdateDelegates,
+	current state.CandidateList,
+) (state.CandidateList, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	g := bcCtx.Genesis
+	if g.DelegateUpdateAdmin == "" && g.DelegateUpdateQuorum == 0 {
+		return nil, errors.Wrap(ErrInvalidDelegateUpdate, "delegate update isn't enabled")
+	}
+	if actionCtx.Caller == nil {
+		return nil, errors.Wrap(ErrInvalidDelegateUpdate, "delegate update has no sender")
+	}
+	caller := actionCtx.Caller.String()
+	if caller != g.DelegateUpdateAdmin && (g.DelegateUpdateQuorum == 0 || !isOperator(current, caller)) {
+		return nil, errors.Wrapf(
+			ErrInvalidDelegateUpdate,
+			"delegate update is sent by %s, which is neither the admin nor a current delegate",
+			caller,
+		)
+	}
+	if len(u.Delegates()) == 0 {
+		return nil, errors.Wrap(ErrInvalidDelegateUpdate, "no delegate in update")
+	}
+	delegates, err := lifeLongCandidates(u.Delegates())
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidDelegateUpdate, err.Error())
+	}
+	return delegates, nil
+}
+
+// isOperator returns true if the address is the operator address of one of the candidates
+func isOperator(candidates state.CandidateList, addr string) bool {
+	for _, c := range candidates {
+		if c.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// handleUpdateDelegates replaces the life long delegates from next epoch if the update is sent by the admin or is
+// approved by the quorum of current delegates. Otherwise the approval of the sender is recorded, where an update of
+// different delegates replaces the pending one along with its approvals.
+func handleUpdateDelegates(
+	ctx context.Context,
+	sm protocol.StateManager,
+	u *action.UpdateDelegates,
+	current state.CandidateList,
+	protocolAddr string,
+) (*action.Receipt, error) {
+	delegates, err := validateDelegateUpdate(ctx, u, current)
+	if err != nil {
+		return failureReceipt(ctx, protocolAddr, err), nil
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	caller := actionCtx.Caller.String()
+	proposalKey := candidatesutil.ConstructKey(candidatesutil.DelegateUpdateProposalKey)
+	proposal := &delegateUpdateProposal{}
+	_, err = sm.State(proposal, protocol.KeyOption(proposalKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	pending := err == nil
+	if err != nil && errors.Cause(err) != state.ErrStateNotExist {
+		return nil, errors.Wrap(err, "failed to read delegate update proposal")
+	}
+	approved := caller == bcCtx.Genesis.DelegateUpdateAdmin
+	if !approved {
+		if !proposal.proposes(u.Delegates()) {
+			proposal = &delegateUpdateProposal{delegates: u.Delegates()}
+		}
+		// the approvals of the senders who are no longer delegates aren't counted
+		approvers := []string{caller}
+		for _, approver := range proposal.approvers {
+			if approver != caller && isOperator(current, approver) {
+				approvers = append(approvers, approver)
+			}
+		}
+		proposal.approvers = approvers
+		approved = uint64(len(approvers)) >= bcCtx.Genesis.DelegateUpdateQuorum
+	}
+	if !approved {
+		if _, err := sm.PutState(proposal, protocol.KeyOption(proposalKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+			return nil, errors.Wrap(err, "failed to write delegate update proposal")
+		}
+	} else {
+		nextKey := candidatesutil.ConstructKey(candidatesutil.NxtLifeLongDelegatesKey)
+		if _, err := sm.PutState(&delegates, protocol.KeyOption(nextKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+			return nil, errors.Wrap(err, "failed to write next life long delegates")
+		}
+		if pending {
+			if _, err := sm.DelState(protocol.KeyOption(proposalKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+				return nil, errors.Wrap(err, "failed to delete delegate update proposal")
+			}
+		}
+	}
+	return &action.Receipt{
+		Status:          uint64(iotextypes.ReceiptStatus_Success),
+		ActionHash:      actionCtx.ActionHash,
+		BlockHeight:     blkCtx.BlockHeight,
+		GasConsumed:     actionCtx.IntrinsicGas,
+		ContractAddress: protocolAddr,
+	}, nil
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// doubleSign is a verified double sign evidence
+type doubleSign struct {
+	offender     string
+	height       uint64
+	evidenceHash hash.Hash256
+}
+
+// validateDoubleSignEvidence verifies that the two headers of the evidence are signed by the same active block
+// producer of the current epoch, at the same height and round (which is decided by the timestamp), and that they are
+// different blocks. The evidence which has been handled is rejected.
+func validateDoubleSignEvidence(
+	ctx context.Context,
+	p Protocol,
+	sr protocol.StateReader,
+	e *action.DoubleSignEvidence,
+) (*doubleSign, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := bcCtx.Genesis
+	if g.DoubleSignEvidenceHeight == 0 || blkCtx.BlockHeight < g.DoubleSignEvidenceHeight ||
+		g.ProbationListHeight == 0 || blkCtx.BlockHeight < g.ProbationListHeight {
+		return nil, errors.Wrap(ErrInvalidEvidence, "double sign evidence isn't enabled")
+	}
+	header1, header2 := &block.Header{}, &block.Header{}
+	if err := header1.Deserialize(e.Header1()); err != nil {
+		return nil, errors.Wrapf(ErrInvalidEvidence, "failed to deserialize header: %v", err)
+	}
+	if err := header2.Deserialize(e.Header2()); err != nil {
+		return nil, errors.Wrapf(ErrInvalidEvidence, "failed to deserialize header: %v", err)
+	}
+	if header1.Height() != header2.Height() {
+		return nil, errors.Wrapf(ErrInvalidEvidence, "headers at different heights %d and %d", header1.Height(), header2.Height())
+	}
+	if !header1.Timestamp().Equal(header2.Timestamp()) {
+		return nil, errors.Wrap(ErrInvalidEvidence, "headers in different rounds")
+	}
+	hash1, hash2 := header1.HashHeaderCore(), header2.HashHeaderCore()
+	if hash1 == hash2 {
+		return nil, errors.Wrap(ErrInvalidEvidence, "headers of the same block")
+	}
+	if !header1.VerifySignature() || !header2.VerifySignature() {
+		return nil, errors.Wrap(ErrInvalidEvidence, "invalid header signature")
+	}
+	offender := header1.ProducerAddress()
+	if offender != header2.ProducerAddress() {
+		return nil, errors.Wrap(ErrInvalidEvidence, "headers signed by different producers")
+	}
+
+	height := header1.Height()
+	if height >= blkCtx.BlockHeight {
+		return nil, errors.Wrapf(ErrFutureHeight, "double signed height %d isn't before %d", height, blkCtx.BlockHeight)
+	}
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	if rp.GetEpochNum(height) != epochNum {
+		return nil, errors.Wrapf(ErrStaleEvidence, "double signed height %d isn't in epoch %d", height, epochNum)
+	}
+	delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+	if err != nil {
+		return nil, err
+	}
+	isProducer := false
+	for _, d := range delegates {
+		if d.Address == offender {
+			isProducer = true
+			break
+		}
+	}
+	if !isProducer {
+		return nil, errors.Wrapf(ErrInvalidEvidence, "%s isn't an active block producer of epoch %d", offender, epochNum)
+	}
+
+	// the evidence is identified by both blocks regardless of their order
+	if bytes.Compare(hash1[:], hash2[:]) > 0 {
+		hash1, hash2 = hash2, hash1
+	}
+	evidenceHash := hash.Hash256b(append(hash1[:], hash2[:]...))
+	_, _, err = candidatesutil.DoubleSignRecordFromDB(sr, evidenceHash)
+	switch errors.Cause(err) {
+	case nil:
+		return nil, errors.Wrapf(ErrDuplicateEvidence, "evidence %x", evidenceHash)
+	case state.ErrStateNotExist:
+	default:
+		return nil, err
+	}
+	return &doubleSign{
+		offender:     offender,
+		height:       height,
+		evidenceHash: evidenceHash,
+	}, nil
+}
+
+// handleDoubleSignEvidence puts the offender on probation in the next epoch with the offense count of double sign,
+// which cuts its voting power the most and keeps it on probation for as many epochs
+func handleDoubleSignEvidence(
+	ctx context.Context,
+	p Protocol,
+	sm protocol.StateManager,
+	e *action.DoubleSignEvidence,
+	protocolAddr string,
+) (*action.Receipt, error) {
+	ds, err := validateDoubleSignEvidence(ctx, p, sm, e)
+	switch errors.Cause(err) {
+	case nil:
+	case ErrInvalidEvidence, ErrStaleEvidence, ErrDuplicateEvidence, ErrFutureHeight:
+		// the evidence may be valid against the committed state but not against the state of the block, e.g., the
+		// same evidence is sent twice in the block
+		return failureReceipt(ctx, protocolAddr, err), nil
+	default:
+		return nil, err
+	}
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+
+	// the list of the next epoch is merged with the unproductive delegates at the last block of the epoch
+	probationList, _, err := candidatesutil.ProbationListFromDB(sm, epochNum+1)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		probationList = vote.NewProbationList(epochNum+1, intensityPercentage(bcCtx.Genesis.KickoutIntensityRate))
+	default:
+		return nil, err
+	}
+	count := bcCtx.Genesis.DoubleSignProbationCount
+	if probationList.ProbationInfo[ds.offender] > count {
+		count = probationList.ProbationInfo[ds.offender]
+	}
+	probationList.ProbationInfo[ds.offender] = count
+	if probationRule(bcCtx.Genesis, rp.GetEpochHeight(epochNum+1)).Consecutive &&
+		probationList.Streaks[ds.offender] < count {
+		// the offender is cut as much as a repeat offender of as many epochs in a row
+		probationList.Streaks[ds.offender] = count
+	}
+	if err := setProbationList(sm, epochNum+1, probationList); err != nil {
+		return nil, err
+	}
+	if rp.IsEpochEnd(blkCtx.BlockHeight) {
+		// the kick-out list of the next epoch has been written before the actions of the block
+		if err := setNextEpochBlacklist(sm, probationList.Blacklist()); err != nil {
+			return nil, err
+		}
+	}
+	recordKey := candidatesutil.ConstructDoubleSignRecordKey(ds.evidenceHash)
+	if _, err := sm.PutState(
+		&vote.DoubleSignRecord{Offender: ds.offender, Height: ds.height},
+		protocol.KeyOption(recordKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to record double sign evidence")
+	}
+	log.L().Info(
+		"Put double signing block producer on probation",
+		zap.String("offender", ds.offender),
+		zap.Uint64("height", ds.height),
+		zap.Uint32("count", count),
+	)
+
+	data, err := proto.Marshal(&pollpb.DoubleSignLog{
+		Offender:       ds.offender,
+		Height:         ds.height,
+		EpochNum:       epochNum + 1,
+		ProbationCount: count,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &action.Receipt{
+		Status:          uint64(iotextypes.ReceiptStatus_Success),
+		ActionHash:      actionCtx.ActionHash,
+		BlockHeight:     blkCtx.BlockHeight,
+		GasConsumed:     actionCtx.IntrinsicGas,
+		ContractAddress: protocolAddr,
+		Logs: []*action.Log{
+			{
+				Address:     protocolAddr,
+				Data:        data,
+				BlockHeight: blkCtx.BlockHeight,
+				ActionHash:  actionCtx.ActionHash,
+			},
+		},
+	}, nil
+}
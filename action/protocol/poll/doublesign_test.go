@@ -0,0 +1,142 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/go-pkgs/crypto"
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestDoubleSignEvidence(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	ts := time.Unix(1500000000, 0)
+	forge := func(sk crypto.PrivateKey, height uint64, ts time.Time, prevHash hash.Hash256) []byte {
+		blk, err := block.NewTestingBuilder().
+			SetHeight(height).
+			SetTimeStamp(ts).
+			SetPrevBlockHash(prevHash).
+			SignAndBuild(sk)
+		require.NoError(err)
+		header, err := blk.Header.Serialize()
+		require.NoError(err)
+		return header
+	}
+	// A is an active block producer of epoch 1
+	sk := identityset.PrivateKey(1)
+	header1 := forge(sk, 700, ts, hash.ZeroHash256)
+	header2 := forge(sk, 700, ts, hash.Hash256b([]byte("fork")))
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 710})
+	e := action.NewDoubleSignEvidence(0, header1, header2)
+	// disabled
+	require.Equal(ErrInvalidEvidence, errors.Cause(p.Validate(ctx, e)))
+	bcCtx.Genesis.ProbationListHeight = 1
+	bcCtx.Genesis.DoubleSignEvidenceHeight = 1
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+
+	tests := []struct {
+		header1 []byte
+		header2 []byte
+		height  uint64
+		err     error
+	}{
+		{header1, []byte("invalid"), 710, ErrInvalidEvidence},
+		// the same block
+		{header1, header1, 710, ErrInvalidEvidence},
+		// different heights
+		{header1, forge(sk, 701, ts, hash.ZeroHash256), 710, ErrInvalidEvidence},
+		// different rounds
+		{header1, forge(sk, 700, ts.Add(10*time.Second), hash.ZeroHash256), 710, ErrInvalidEvidence},
+		// different producers
+		{header1, forge(identityset.PrivateKey(2), 700, ts, hash.Hash256b([]byte("fork"))), 710, ErrInvalidEvidence},
+		// C isn't an active block producer
+		{
+			forge(identityset.PrivateKey(3), 700, ts, hash.ZeroHash256),
+			forge(identityset.PrivateKey(3), 700, ts, hash.Hash256b([]byte("fork"))),
+			710,
+			ErrInvalidEvidence,
+		},
+		// future height
+		{header1, header2, 700, ErrFutureHeight},
+		// the blocks of the last epoch
+		{header1, header2, 721, ErrStaleEvidence},
+	}
+	for _, test := range tests {
+		testCtx := protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: test.height})
+		e := action.NewDoubleSignEvidence(0, test.header1, test.header2)
+		require.Equal(test.err, errors.Cause(p.Validate(testCtx, e)))
+		// the invalid evidence fails without stopping the block
+		receipt, err := p.Handle(testCtx, e, sm)
+		require.NoError(err)
+		require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
+	}
+
+	require.NoError(p.Validate(ctx, e))
+	receipt, err := p.Handle(ctx, e, sm)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Success), receipt.Status)
+	require.Equal(uint64(710), receipt.BlockHeight)
+	require.Equal(1, len(receipt.Logs))
+	dsLog := &pollpb.DoubleSignLog{}
+	require.NoError(proto.Unmarshal(receipt.Logs[0].Data, dsLog))
+	require.Equal(identityset.Address(1).String(), dsLog.Offender)
+	require.Equal(uint64(700), dsLog.Height)
+	require.Equal(uint64(2), dsLog.EpochNum)
+	require.Equal(bcCtx.Genesis.DoubleSignProbationCount, dsLog.ProbationCount)
+	probationList, _, err := candidatesutil.ProbationListFromDB(sm, 2)
+	require.NoError(err)
+	require.Equal(
+		map[string]uint32{identityset.Address(1).String(): bcCtx.Genesis.DoubleSignProbationCount},
+		probationList.ProbationInfo,
+	)
+
+	// the same evidence is rejected regardless of the order of the headers
+	for _, dup := range []*action.DoubleSignEvidence{e, action.NewDoubleSignEvidence(1, header2, header1)} {
+		require.Equal(ErrDuplicateEvidence, errors.Cause(p.Validate(ctx, dup)))
+		receipt, err = p.Handle(ctx, dup, sm)
+		require.NoError(err)
+		require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
+	}
+	probationList2, _, err := candidatesutil.ProbationListFromDB(sm, 2)
+	require.NoError(err)
+	require.Equal(probationList, probationList2)
+
+	// the penalty survives the merge at the end of the epoch
+	merged, err := mergeProbationList(
+		sm,
+		bcCtx.Genesis,
+		1,
+		[]string{identityset.Address(1).String(), identityset.Address(2).String()},
+		probationRule(bcCtx.Genesis, 721),
+	)
+	require.NoError(err)
+	require.Equal(map[string]uint32{
+		identityset.Address(1).String(): bcCtx.Genesis.DoubleSignProbationCount,
+		identityset.Address(2).String(): 1,
+	}, merged.ProbationInfo)
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
 	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/block"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/pkg/log"
@@ -30,6 +32,16 @@ import (
 	"github.com/iotexproject/iotex-core/state"
 )
 
+// GovernanceOption sets an option of the governance poll protocol
+type GovernanceOption func(*governanceChainCommitteeProtocol)
+
+// WithPollCacheSize sets the max number of poll results in the cache
+func WithPollCacheSize(size int) GovernanceOption {
+	return func(p *governanceChainCommitteeProtocol) {
+		p.cacheSize = size
+	}
+}
+
 type governanceChainCommitteeProtocol struct {
 	candidatesByHeight        CandidatesByHeight
 	getCandidates             GetCandidates
@@ -38,8 +50,6 @@ type governanceChainCommitteeProtocol struct {
 	getBlockTime              GetBlockTime
 	electionCommittee         committee.Committee
 	initGravityChainHeight    uint64
-	numCandidateDelegates     uint64
-	numDelegates              uint64
 	addr                      address.Address
 	initialCandidatesInterval time.Duration
 	sr                        protocol.StateReader
@@ -48,6 +58,9 @@ type governanceChainCommitteeProtocol struct {
 	kickoutEpochPeriod        uint64
 	kickoutIntensity          float64
 	maxKickoutPeriod          uint64
+	cacheSize                 int
+	cache                     *candidatesCache
+	router                    *protocol.ReadStateRouter
 }
 
 // NewGovernanceChainCommitteeProtocol creates a Poll Protocol which fetch result from governance chain
@@ -59,8 +72,6 @@ func NewGovernanceChainCommitteeProtocol(
 	electionCommittee committee.Committee,
 	initGravityChainHeight uint64,
 	getBlockTime GetBlockTime,
-	numCandidateDelegates uint64,
-	numDelegates uint64,
 	initialCandidatesInterval time.Duration,
 	sr protocol.StateReader,
 	productivityByEpoch ProductivityByEpoch,
@@ -68,6 +79,7 @@ func NewGovernanceChainCommitteeProtocol(
 	kickoutEpochPeriod uint64,
 	kickoutIntensity float64,
 	maxKickoutPeriod uint64,
+	opts ...GovernanceOption,
 ) (Protocol, error) {
 	if electionCommittee == nil {
 		return nil, ErrNoElectionCommittee
@@ -81,7 +93,7 @@ func NewGovernanceChainCommitteeProtocol(
 	if err != nil {
 		log.L().Panic("Error when constructing the address of poll protocol", zap.Error(err))
 	}
-	return &governanceChainCommitteeProtocol{
+	p := &governanceChainCommitteeProtocol{
 		candidatesByHeight:        candidatesByHeight,
 		getCandidates:             getCandidates,
 		getKickoutList:            getKickoutList,
@@ -89,8 +101,6 @@ func NewGovernanceChainCommitteeProtocol(
 		electionCommittee:         electionCommittee,
 		initGravityChainHeight:    initGravityChainHeight,
 		getBlockTime:              getBlockTime,
-		numCandidateDelegates:     numCandidateDelegates,
-		numDelegates:              numDelegates,
 		addr:                      addr,
 		initialCandidatesInterval: initialCandidatesInterval,
 		sr:                        sr,
@@ -99,7 +109,14 @@ func NewGovernanceChainCommitteeProtocol(
 		kickoutEpochPeriod:        kickoutEpochPeriod,
 		kickoutIntensity:          kickoutIntensity,
 		maxKickoutPeriod:          maxKickoutPeriod,
-	}, nil
+		cacheSize:                 defaultPollCacheSize,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.cache = newCandidatesCache(p.cacheSize)
+	p.router = protocol.MustNewReadStateRouter(p.ReadStateMethods()...)
+	return p, nil
 }
 
 func (p *governanceChainCommitteeProtocol) CreateGenesisStates(
@@ -131,15 +148,33 @@ func (p *governanceChainCommitteeProtocol) CreateGenesisStates(
 	}
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
 	if hu.IsPost(config.Easter, uint64(1)) {
-		if err := setNextEpochBlacklist(sm, &vote.Blacklist{
+		blackList := &vote.Blacklist{
 			IntensityRate: p.kickoutIntensity,
-		}); err != nil {
+		}
+		if err := setNextEpochBlacklist(sm, blackList); err != nil {
+			return err
+		}
+		if err := setProbationList(sm, 1, probationListFromBlacklist(1, blackList)); err != nil {
 			return err
 		}
 	}
 	return setCandidates(ctx, sm, ds, uint64(1))
 }
 
+// Start verifies that the gravity chain is reachable by pre-loading the poll result of the tip epoch from it, so that a
+// node which can't read the gravity chain fails to start instead of stalling the consensus. The poll result of the
+// genesis block is read in CreateGenesisStates, which waits for the gravity chain instead.
+func (p *governanceChainCommitteeProtocol) Start(ctx context.Context, sr protocol.StateReader) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	if bcCtx.Tip.Height == 0 {
+		return nil
+	}
+	if _, err := p.CalculateCandidatesByHeight(ctx, bcCtx.Tip.Height); err != nil {
+		return errors.Wrapf(err, "failed to load the poll result of height %d from gravity chain", bcCtx.Tip.Height)
+	}
+	return nil
+}
+
 func (p *governanceChainCommitteeProtocol) CreatePostSystemActions(ctx context.Context) ([]action.Envelope, error) {
 	return createPostSystemActions(ctx, p)
 }
@@ -147,21 +182,32 @@ func (p *governanceChainCommitteeProtocol) CreatePostSystemActions(ctx context.C
 func (p *governanceChainCommitteeProtocol) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	blkCtx := protocol.MustGetBlockCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
-	epochNum := rp.GetEpochNum(blkCtx.BlockHeight)
-	epochStartHeight := rp.GetEpochHeight(epochNum)
-	epochLastHeight := rp.GetEpochLastBlockHeight(epochNum)
-	nextEpochStartHeight := rp.GetEpochHeight(epochNum + 1)
+	if kickoutHeight := bcCtx.Genesis.ProductivityKickoutHeight; kickoutHeight == 0 || blkCtx.BlockHeight < kickoutHeight {
+		return nil
+	}
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochNum := rp.EpochOf(blkCtx.BlockHeight)
+	// the candidates of the epoch aren't shifted yet at its first block
+	epochStart := rp.IsEpochStart(blkCtx.BlockHeight)
+	return countProductivity(ctx, sm, epochNum, func() (state.CandidateList, error) {
+		return p.readActiveBlockProducersByEpoch(ctx, epochNum, epochStart, false)
+	})
+}
+
+func (p *governanceChainCommitteeProtocol) OnEpochBoundary(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	boundary protocol.EpochBoundary,
+) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
-	if blkCtx.BlockHeight == epochLastHeight && hu.IsPost(config.Easter, nextEpochStartHeight) {
-		// if the block height is the end of epoch and next epoch is after the Easter height, calculate blacklist for kick-out and write into state DB
-		unqualifiedList, err := p.calculateKickoutBlackList(ctx, sm, epochNum+1)
-		if err != nil {
-			return err
+	switch boundary {
+	case protocol.EpochStart:
+		if hu.IsPre(config.Easter, rp.GetEpochHeight(epochNum)) {
+			return nil
 		}
-		return setNextEpochBlacklist(sm, unqualifiedList)
-	}
-	if blkCtx.BlockHeight == epochStartHeight && hu.IsPost(config.Easter, epochStartHeight) {
 		prevHeight, err := shiftCandidates(sm)
 		if err != nil {
 			return err
@@ -173,16 +219,68 @@ func (p *governanceChainCommitteeProtocol) CreatePreStates(ctx context.Context,
 		if prevHeight != afterHeight {
 			return errors.Wrap(ErrInconsistentHeight, "shifting candidate height is not same as shifting kickout height")
 		}
-		return nil
+	case protocol.EpochEnd:
+		// if the next epoch is after the Easter height, calculate blacklist for kick-out and write into state DB
+		nextEpochStartHeight := rp.GetEpochHeight(epochNum + 1)
+		if hu.IsPre(config.Easter, nextEpochStartHeight) {
+			return nil
+		}
+		if h := bcCtx.Genesis.ProbationListHeight; h != 0 && nextEpochStartHeight >= h {
+			expectedNumBlks, produce, err := p.productionByEpoch(ctx, epochNum)
+			if err != nil {
+				return errors.Wrapf(err, "failed to calculate unproductive delegates of epoch %d", epochNum)
+			}
+			unproductive := p.unproductiveDelegates(expectedNumBlks, produce)
+			probationList, err := mergeProbationList(
+				sm,
+				bcCtx.Genesis,
+				epochNum,
+				unproductive,
+				probationRule(bcCtx.Genesis, nextEpochStartHeight),
+			)
+			if err != nil {
+				return err
+			}
+			if h := bcCtx.Genesis.DelegateStatsHeight; h != 0 && rp.GetEpochHeight(epochNum) >= h {
+				if err := updateDelegateStats(sm, bcCtx.Genesis, epochNum, expectedNumBlks, produce, unproductive); err != nil {
+					return errors.Wrapf(err, "failed to update delegate stats of epoch %d", epochNum)
+				}
+			}
+			if err := setProbationList(sm, epochNum+1, probationList); err != nil {
+				return err
+			}
+			return setNextEpochBlacklist(sm, probationList.Blacklist())
+		}
+		unqualifiedList, err := p.calculateKickoutBlackList(ctx, sm, epochNum+1)
+		if err != nil {
+			return err
+		}
+		if err := setProbationList(sm, epochNum+1, probationListFromBlacklist(epochNum+1, unqualifiedList)); err != nil {
+			return err
+		}
+		return setNextEpochBlacklist(sm, unqualifiedList)
+	case protocol.EpochEndPost:
+		return createEpochSnapshot(ctx, sm, p, epochNum)
 	}
 	return nil
 }
 
 func (p *governanceChainCommitteeProtocol) Handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	if e, ok := act.(*action.DoubleSignEvidence); ok {
+		return handleDoubleSignEvidence(ctx, p, sm, e, p.addr.String())
+	}
 	return handle(ctx, act, sm, p.addr.String())
 }
 
+func (p *governanceChainCommitteeProtocol) ReceiveBlock(blk *block.Block) error {
+	return p.cache.ReceiveBlock(blk)
+}
+
 func (p *governanceChainCommitteeProtocol) Validate(ctx context.Context, act action.Action) error {
+	if e, ok := act.(*action.DoubleSignEvidence); ok {
+		_, err := validateDoubleSignEvidence(ctx, p, p.sr, e)
+		return err
+	}
 	return validate(ctx, p, act)
 }
 
@@ -224,32 +322,59 @@ func (p *governanceChainCommitteeProtocol) candidatesByGravityChainHeight(height
 }
 
 func (p *governanceChainCommitteeProtocol) CalculateCandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	candidates, persisted, err := persistedCandidatesByHeight(ctx, p.sr, height)
+	if err != nil {
+		return nil, err
+	}
+	if persisted {
+		return candidates, nil
+	}
 	gravityHeight, err := p.getGravityHeight(ctx, height)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get gravity chain height")
 	}
-	log.L().Debug(
-		"fetch delegates from gravity chain",
-		zap.Uint64("gravityChainHeight", gravityHeight),
-	)
-	return p.candidatesByGravityChainHeight(gravityHeight)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	key := pollCacheKey{
+		method:             "CalculateCandidatesByHeight",
+		gravityChainHeight: gravityHeight,
+		epochNum:           rp.GetEpochNum(height),
+	}
+	return p.cache.Get(ctx, key, func() (state.CandidateList, error) {
+		log.L().Debug(
+			"fetch delegates from gravity chain",
+			zap.Uint64("gravityChainHeight", gravityHeight),
+		)
+		return p.candidatesByGravityChainHeight(gravityHeight)
+	})
 }
 
 func (p *governanceChainCommitteeProtocol) DelegatesByEpoch(ctx context.Context, epochNum uint64) (state.CandidateList, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
 	if tipEpochNum+1 == epochNum {
-		return p.readActiveBlockProducersByEpoch(ctx, epochNum, true)
+		// the states of the next epoch may still change, so they aren't cached
+		return p.readActiveBlockProducersByEpoch(ctx, epochNum, true, false)
 	} else if tipEpochNum == epochNum {
-		return p.readActiveBlockProducersByEpoch(ctx, epochNum, false)
+		gravityHeight, err := p.getGravityHeight(ctx, rp.GetEpochHeight(epochNum))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get gravity chain height")
+		}
+		key := pollCacheKey{
+			method:             "DelegatesByEpoch",
+			gravityChainHeight: gravityHeight,
+			epochNum:           epochNum,
+		}
+		return p.cache.Get(ctx, key, func() (state.CandidateList, error) {
+			return p.readActiveBlockProducersByEpoch(ctx, epochNum, false, false)
+		})
 	}
 	return nil, errors.Errorf("wrong epochNumber to get delegates, epochNumber %d can't be less than tip epoch number %d", epochNum, tipEpochNum)
 }
 
 func (p *governanceChainCommitteeProtocol) CandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
 	targetEpochNum := rp.GetEpochNum(height)
 	targetEpochStartHeight := rp.GetEpochHeight(targetEpochNum)
@@ -267,72 +392,176 @@ func (p *governanceChainCommitteeProtocol) ReadState(
 	method []byte,
 	args ...[]byte,
 ) ([]byte, error) {
-	blkCtx := protocol.MustGetBlockCtx(ctx)
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
-	tipEpoch := rp.GetEpochNum(blkCtx.BlockHeight)
-	switch string(method) {
-	case "CandidatesByEpoch":
-		if len(args) != 0 {
-			inputEpochNum := byteutil.BytesToUint64(args[0])
-			if inputEpochNum != tipEpoch {
-				return nil, errors.New("previous epoch data isn't available with non-archive node")
-			}
-		}
-		delegates, err := p.readCandidatesByEpoch(ctx, tipEpoch, false)
-		if err != nil {
-			return nil, err
-		}
-		return delegates.Serialize()
-	case "BlockProducersByEpoch":
-		if len(args) != 0 {
-			inputEpochNum := byteutil.BytesToUint64(args[0])
-			if inputEpochNum != tipEpoch {
-				return nil, errors.New("previous epoch data isn't available with non-archive node")
-			}
-		}
-		blockProducers, err := p.readBlockProducersByEpoch(ctx, byteutil.BytesToUint64(args[0]), false)
-		if err != nil {
-			return nil, err
-		}
-		return blockProducers.Serialize()
-	case "ActiveBlockProducersByEpoch":
-		if len(args) != 0 {
-			inputEpochNum := byteutil.BytesToUint64(args[0])
-			if inputEpochNum != tipEpoch {
-				return nil, errors.New("previous epoch data isn't available with non-archive node")
-			}
-		}
-		activeBlockProducers, err := p.readActiveBlockProducersByEpoch(ctx, byteutil.BytesToUint64(args[0]), false)
-		if err != nil {
-			return nil, err
-		}
-		return activeBlockProducers.Serialize()
-	case "GetGravityChainStartHeight":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		gravityStartheight, err := p.getGravityHeight(ctx, byteutil.BytesToUint64(args[0]))
-		if err != nil {
-			return nil, err
-		}
-		return byteutil.Uint64ToBytes(gravityStartheight), nil
-	case "KickoutListByEpoch":
-		if len(args) != 0 {
-			inputEpochNum := byteutil.BytesToUint64(args[0])
-			if inputEpochNum != tipEpoch {
-				return nil, errors.New("previous epoch data isn't available with non-archive node")
-			}
-		}
-		kickoutList, err := p.readKickoutList(ctx, tipEpoch, false)
-		if err != nil {
-			return nil, err
-		}
-		return kickoutList.Serialize()
-	default:
-		return nil, errors.New("corresponding method isn't found")
+	return p.router.ReadState(ctx, sm, method, args...)
+}
+
+// ReadStateMethods describes the methods of ReadState. The methods of the epoch data take an optional epoch argument,
+// which must be the tip epoch as a non-archive node only keeps the data of the tip epoch.
+func (p *governanceChainCommitteeProtocol) ReadStateMethods() []protocol.ReadStateMethod {
+	uint64Arg := []protocol.ArgType{protocol.Uint64Arg}
+	epochAndVersion := []protocol.ArgType{protocol.Uint64Arg, protocol.Uint64Arg}
+	return []protocol.ReadStateMethod{
+		{
+			Name:         "CandidatesByEpoch",
+			OptionalArgs: epochAndVersion,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				tipEpoch := tipEpochNum(ctx)
+				if err := checkTipEpochArg(args, tipEpoch); err != nil {
+					return nil, err
+				}
+				delegates, err := p.readCandidatesByEpoch(ctx, tipEpoch, false)
+				if err != nil {
+					return nil, err
+				}
+				return p.encodeCandidateList(ctx, sm, delegates, tipEpoch, args)
+			},
+		},
+		{
+			Name:         "BlockProducersByEpoch",
+			Args:         uint64Arg,
+			OptionalArgs: uint64Arg,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				if err := checkTipEpochArg(args, tipEpochNum(ctx)); err != nil {
+					return nil, err
+				}
+				epochNum := byteutil.BytesToUint64(args[0])
+				blockProducers, err := p.readBlockProducersByEpoch(ctx, epochNum, false)
+				if err != nil {
+					return nil, err
+				}
+				return p.encodeCandidateList(ctx, sm, blockProducers, epochNum, args)
+			},
+		},
+		{
+			Name:         "ActiveBlockProducersByEpoch",
+			Args:         uint64Arg,
+			OptionalArgs: uint64Arg,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				if err := checkTipEpochArg(args, tipEpochNum(ctx)); err != nil {
+					return nil, err
+				}
+				epochNum := byteutil.BytesToUint64(args[0])
+				activeBlockProducers, err := p.activeBlockProducersByEpoch(ctx, epochNum)
+				if err != nil {
+					return nil, err
+				}
+				return p.encodeCandidateList(ctx, sm, activeBlockProducers, epochNum, args)
+			},
+		},
+		{
+			Name: "GetGravityChainStartHeight",
+			Args: uint64Arg,
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				gravityStartheight, err := p.getGravityHeight(ctx, byteutil.BytesToUint64(args[0]))
+				if err != nil {
+					return nil, err
+				}
+				return byteutil.Uint64ToBytes(gravityStartheight), nil
+			},
+		},
+		{
+			Name:         "KickoutListByEpoch",
+			OptionalArgs: uint64Arg,
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				tipEpoch := tipEpochNum(ctx)
+				if err := checkTipEpochArg(args, tipEpoch); err != nil {
+					return nil, err
+				}
+				kickoutList, err := p.readKickoutList(ctx, tipEpoch, false)
+				if err != nil {
+					return nil, err
+				}
+				return kickoutList.Serialize()
+			},
+		},
+		{
+			Name:      "NextEpochCandidates",
+			ExtraArgs: true,
+			Handler: func(ctx context.Context, _ protocol.StateReader, _ ...[]byte) ([]byte, error) {
+				nextEpochNum := tipEpochNum(ctx) + 1
+				candidates, err := p.readActiveBlockProducersByEpoch(ctx, nextEpochNum, true, true)
+				if err != nil {
+					return nil, err
+				}
+				return serializeNextEpochCandidates(ctx, nextEpochNum, candidates)
+			},
+		},
+		{
+			Name: "ActiveBlockProducersByHeight",
+			Args: uint64Arg,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readActiveBlockProducersByHeight(ctx, sm, p, byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name: "ProductivityByEpoch",
+			Args: uint64Arg,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readProductivityByEpoch(ctx, sm, byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name: "ProbationListByEpoch",
+			Args: uint64Arg,
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				probationList, err := readProbationList(ctx, sm, byteutil.BytesToUint64(args[0]), p.kickoutIntensity)
+				if err != nil {
+					return nil, err
+				}
+				return probationList.Serialize()
+			},
+		},
+		{
+			Name: "GravityChainEndpointByHeight",
+			Args: uint64Arg,
+			Handler: func(_ context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return p.readGravityChainEndpoint(byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name: "DelegateStats",
+			Args: []protocol.ArgType{protocol.PaginationArg},
+			Handler: func(_ context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readDelegateStats(sm, args)
+			},
+		},
+		{
+			Name: "EpochMeta",
+			Args: uint64Arg,
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readEpochMeta(ctx, byteutil.BytesToUint64(args[0]))
+			},
+		},
+	}
+}
 
+// readGravityChainEndpoint returns the name of the gravity chain endpoint which served the election result of the
+// gravity chain height, for diagnosing the failover among the endpoints
+func (p *governanceChainCommitteeProtocol) readGravityChainEndpoint(gravityHeight uint64) ([]byte, error) {
+	fc, ok := p.electionCommittee.(*FailoverCommittee)
+	if !ok {
+		return nil, errors.New("the election committee doesn't fail over among gravity chain endpoints")
+	}
+	endpoint, ok := fc.EndpointByHeight(gravityHeight)
+	if !ok {
+		return nil, errors.Wrapf(state.ErrStateNotExist, "no endpoint has served gravity chain height %d", gravityHeight)
 	}
+	return []byte(endpoint), nil
+}
+
+// Name returns the name of the protocol
+func (p *governanceChainCommitteeProtocol) Name() string {
+	return "Governance chain committee poll"
+}
+
+// Version returns the version of the protocol
+func (p *governanceChainCommitteeProtocol) Version() uint {
+	return 1
+}
+
+// Dependencies returns the IDs of the protocols the protocol depends on
+func (p *governanceChainCommitteeProtocol) Dependencies() []string {
+	return dependencies()
 }
 
 // Register registers the protocol with a unique ID
@@ -346,15 +575,14 @@ func (p *governanceChainCommitteeProtocol) ForceRegister(r *protocol.Registry) e
 }
 
 func (p *governanceChainCommitteeProtocol) readCandidatesByEpoch(ctx context.Context, epochNum uint64, readFromNext bool) (state.CandidateList, error) {
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	return p.readCandidatesByHeight(ctx, rp.GetEpochHeight(epochNum), readFromNext)
 }
 
 func (p *governanceChainCommitteeProtocol) readCandidatesByHeight(ctx context.Context, epochStartHeight uint64, readFromNext bool) (state.CandidateList, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	if hu.IsPre(config.Easter, epochStartHeight) {
 		return p.candidatesByHeight(p.sr, epochStartHeight)
 	}
@@ -375,12 +603,14 @@ func (p *governanceChainCommitteeProtocol) readBlockProducersByEpoch(ctx context
 	if err != nil {
 		return nil, err
 	}
+	candidates = electableCandidates(candidates, bcCtx.Genesis.ElectableVoteThreshold())
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	numCandidateDelegates := rp.NumCandidateDelegatesAt(epochNum)
 	if hu.IsPre(config.Easter, rp.GetEpochHeight(epochNum)) || epochNum == 1 {
 		var blockProducers state.CandidateList
 		for i, candidate := range candidates {
-			if uint64(i) >= p.numCandidateDelegates {
+			if uint64(i) >= numCandidateDelegates {
 				break
 			}
 			blockProducers = append(blockProducers, candidate)
@@ -389,6 +619,14 @@ func (p *governanceChainCommitteeProtocol) readBlockProducersByEpoch(ctx context
 	}
 
 	// After Easter height, kick-out unqualified delegates based on productivity
+	if h := bcCtx.Genesis.ProbationListHeight; h != 0 && rp.GetEpochHeight(epochNum) >= h {
+		return p.filterByProbationList(
+			candidates,
+			epochNum,
+			numCandidateDelegates,
+			probationRule(bcCtx.Genesis, rp.GetEpochHeight(epochNum)),
+		)
+	}
 	unqualifiedList, err := p.readKickoutList(ctx, epochNum, readFromNext)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read kick-out list")
@@ -411,7 +649,7 @@ func (p *governanceChainCommitteeProtocol) readBlockProducersByEpoch(ctx context
 	sorted := util.Sort(updatedVotingPower, epochNum)
 	var verifiedCandidates state.CandidateList
 	for i, name := range sorted {
-		if uint64(i) >= p.numCandidateDelegates {
+		if uint64(i) >= numCandidateDelegates {
 			break
 		}
 		verifiedCandidates = append(verifiedCandidates, candidatesMap[name])
@@ -420,7 +658,14 @@ func (p *governanceChainCommitteeProtocol) readBlockProducersByEpoch(ctx context
 	return verifiedCandidates, nil
 }
 
-func (p *governanceChainCommitteeProtocol) readActiveBlockProducersByEpoch(ctx context.Context, epochNum uint64, readFromNext bool) (state.CandidateList, error) {
+// readActiveBlockProducersByEpoch returns the active block producers of the epoch. If provisional is true, the
+// productivity of the previous epoch is allowed to be incomplete.
+func (p *governanceChainCommitteeProtocol) readActiveBlockProducersByEpoch(
+	ctx context.Context,
+	epochNum uint64,
+	readFromNext bool,
+	provisional bool,
+) (state.CandidateList, error) {
 	blockProducers, err := p.readBlockProducersByEpoch(ctx, epochNum, readFromNext)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get candidates in epoch %d", epochNum)
@@ -428,36 +673,53 @@ func (p *governanceChainCommitteeProtocol) readActiveBlockProducersByEpoch(ctx c
 
 	var blockProducerList []string
 	blockProducerMap := make(map[string]*state.Candidate)
-	for _, bp := range blockProducers {
+	for _, bp := range canonicalCandidates(blockProducers) {
 		blockProducerList = append(blockProducerList, bp.Address)
 		blockProducerMap[bp.Address] = bp
 	}
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	epochHeight := rp.GetEpochHeight(epochNum)
-	crypto.SortCandidates(blockProducerList, epochHeight, crypto.CryptoSeed)
-
-	length := int(p.numDelegates)
-	if len(blockProducerList) < length {
-		// TODO: if the number of delegates is smaller than expected, should it return error or not?
-		length = len(blockProducerList)
-		log.L().Warn(
-			"the number of block producer is less than expected",
-			zap.Int("actual block producer", len(blockProducerList)),
-			zap.Uint64("expected", p.numDelegates),
-		)
+	numDelegates := int(rp.NumDelegatesAt(epochNum))
+	unproductive, err := readUnproductiveDelegates(ctx, p.sr, epochNum, readFromNext, provisional, p.productivityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	blockProducerList = excludeUnproductiveDelegates(blockProducerList, unproductive, numDelegates)
+	if len(blockProducerList) == 0 {
+		candidates, err := p.readCandidatesByEpoch(ctx, epochNum, readFromNext)
+		if err != nil {
+			return nil, err
+		}
+		return nil, noElectedDelegatesError(epochNum, len(candidates), len(blockProducers), len(blockProducerList))
 	}
-	var activeBlockProducers state.CandidateList
-	for i := 0; i < length; i++ {
-		activeBlockProducers = append(activeBlockProducers, blockProducerMap[blockProducerList[i]])
+	seed, err := sortSeed(ctx, epochHeight)
+	if err != nil {
+		return nil, err
 	}
+	crypto.SortCandidates(blockProducerList, epochHeight, seed)
+
+	return selectActiveBlockProducers(
+		blockProducerList,
+		blockProducerMap,
+		numDelegates,
+		bcCtx.Genesis.DelegateShortagePolicy,
+	)
+}
 
-	return activeBlockProducers, nil
+// activeBlockProducersByEpoch returns the active block producers of the epoch, which are read from the cache if the
+// epoch is the tip epoch
+func (p *governanceChainCommitteeProtocol) activeBlockProducersByEpoch(ctx context.Context, epochNum uint64) (state.CandidateList, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	if rp.GetEpochNum(bcCtx.Tip.Height) == epochNum {
+		return p.DelegatesByEpoch(ctx, epochNum)
+	}
+	return p.readActiveBlockProducersByEpoch(ctx, epochNum, false, false)
 }
 
 func (p *governanceChainCommitteeProtocol) readKickoutList(ctx context.Context, epochNum uint64, readFromNext bool) (*vote.Blacklist, error) {
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	unqualifiedList, stateHeight, err := p.getKickoutList(p.sr, readFromNext)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get kickout list when reading from state DB in epoch %d", epochNum)
@@ -470,8 +732,7 @@ func (p *governanceChainCommitteeProtocol) readKickoutList(ctx context.Context,
 }
 
 func (p *governanceChainCommitteeProtocol) getGravityHeight(ctx context.Context, height uint64) (uint64, error) {
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	epochNumber := rp.GetEpochNum(height)
 	epochHeight := rp.GetEpochHeight(epochNumber)
 	blkTime, err := p.getBlockTime(epochHeight)
@@ -485,13 +746,103 @@ func (p *governanceChainCommitteeProtocol) getGravityHeight(ctx context.Context,
 	return p.electionCommittee.HeightByTime(blkTime)
 }
 
+// encodeCandidateList encodes the candidates of the epoch in the version requested by the arguments
+func (p *governanceChainCommitteeProtocol) encodeCandidateList(
+	ctx context.Context,
+	sr protocol.StateReader,
+	candidates state.CandidateList,
+	epochNum uint64,
+	args [][]byte,
+) ([]byte, error) {
+	version, err := candidateListVersion(args)
+	if err != nil {
+		return nil, err
+	}
+	if version == CandidateListV1 {
+		return candidates.Serialize()
+	}
+	probationList, rule, err := p.readProbation(ctx, sr, epochNum)
+	if err != nil {
+		return nil, err
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	return serializeCandidateList(
+		candidates,
+		epochNum,
+		version,
+		probationList,
+		rule,
+		bcCtx.Genesis.ElectableVoteThreshold(),
+	)
+}
+
+// readProbation returns the probation list applied in the epoch, along with the rule by which the voting power of the
+// delegates on it is cut. Before the probation list height, the kick-out list cuts the voting power of all of its
+// delegates by the same intensity rate.
+func (p *governanceChainCommitteeProtocol) readProbation(
+	ctx context.Context,
+	sr protocol.StateReader,
+	epochNum uint64,
+) (*vote.ProbationList, vote.ProbationRule, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochHeight := rp.GetEpochHeight(epochNum)
+	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
+	if epochNum == 1 || hu.IsPre(config.Easter, epochHeight) {
+		// no delegate is kicked out
+		return vote.NewProbationList(epochNum, intensityPercentage(p.kickoutIntensity)), vote.ProbationRule{}, nil
+	}
+	probationList, err := readProbationList(ctx, sr, epochNum, p.kickoutIntensity)
+	if err != nil {
+		return nil, vote.ProbationRule{}, err
+	}
+	if h := bcCtx.Genesis.ProbationListHeight; h != 0 && epochHeight >= h {
+		return probationList, probationRule(bcCtx.Genesis, epochHeight), nil
+	}
+	return probationList, vote.ProbationRule{}, nil
+}
+
+// filterByProbationList cuts the voting power of the delegates on probation in the epoch according to their offense
+// counts, and returns the candidates with the highest voting power. The delegates whose voting power is cut entirely
+// are kicked out.
+func (p *governanceChainCommitteeProtocol) filterByProbationList(
+	candidates state.CandidateList,
+	epochNum uint64,
+	numCandidateDelegates uint64,
+	rule vote.ProbationRule,
+) (state.CandidateList, error) {
+	probationList, _, err := candidatesutil.ProbationListFromDB(p.sr, epochNum)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read probation list of epoch %d", epochNum)
+	}
+	candidatesMap := make(map[string]*state.Candidate)
+	updatedVotingPower := make(map[string]*big.Int)
+	for _, cand := range candidates {
+		candidatesMap[cand.Address] = cand
+		rate := probationList.IntensityRateOf(cand.Address, rule)
+		if rate == 0 {
+			continue
+		}
+		votingPower := new(big.Int).Mul(cand.Votes, big.NewInt(int64(rate)))
+		updatedVotingPower[cand.Address] = votingPower.Div(votingPower, big.NewInt(100))
+	}
+	sorted := util.Sort(updatedVotingPower, epochNum)
+	var verifiedCandidates state.CandidateList
+	for i, name := range sorted {
+		if uint64(i) >= numCandidateDelegates {
+			break
+		}
+		verifiedCandidates = append(verifiedCandidates, candidatesMap[name])
+	}
+	return verifiedCandidates, nil
+}
+
 func (p *governanceChainCommitteeProtocol) calculateKickoutBlackList(
 	ctx context.Context,
 	sm protocol.StateManager,
 	epochNum uint64,
 ) (*vote.Blacklist, error) {
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	easterEpochNum := rp.GetEpochNum(config.Easter)
 
 	nextBlacklist := &vote.Blacklist{
@@ -593,22 +944,37 @@ func (p *governanceChainCommitteeProtocol) calculateUnproductiveDelegatesByEpoch
 	ctx context.Context,
 	epochNum uint64,
 ) ([]string, error) {
+	expectedNumBlks, produce, err := p.productionByEpoch(ctx, epochNum)
+	if err != nil {
+		return nil, err
+	}
+	return p.unproductiveDelegates(expectedNumBlks, produce), nil
+}
+
+// productionByEpoch returns the number of blocks expected from each active block producer of the epoch, along with
+// the number of blocks produced by each of them up to the current block
+func (p *governanceChainCommitteeProtocol) productionByEpoch(
+	ctx context.Context,
+	epochNum uint64,
+) (uint64, map[string]uint64, error) {
 	blkCtx := protocol.MustGetBlockCtx(ctx)
 	numBlks, produce, err := p.productivityByEpoch(ctx, epochNum)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 	// The current block is not included, so that we need to add it to the stats
 	numBlks++
 	produce[blkCtx.Producer.String()]++
+	return numBlks / uint64(len(produce)), produce, nil
+}
 
+// unproductiveDelegates returns the block producers whose productivity is lower than the threshold
+func (p *governanceChainCommitteeProtocol) unproductiveDelegates(expectedNumBlks uint64, produce map[string]uint64) []string {
 	unqualified := make([]string, 0)
-	expectedNumBlks := numBlks / uint64(len(produce))
 	for addr, actualNumBlks := range produce {
 		if actualNumBlks*100/expectedNumBlks < p.productivityThreshold {
 			unqualified = append(unqualified, addr)
 		}
 	}
-
-	return unqualified, nil
+	return unqualified
 }
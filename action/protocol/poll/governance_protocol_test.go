@@ -9,28 +9,38 @@ package poll
 import (
 	"context"
 	"math/big"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-election/committee"
 	"github.com/iotexproject/iotex-election/test/mock/mock_committee"
 	"github.com/iotexproject/iotex-election/types"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/action/protocol/protocolpb"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
 	"github.com/iotexproject/iotex-core/action/protocol/vote"
 	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/identityset"
 	"github.com/iotexproject/iotex-core/test/mock/mock_chainmanager"
+	"github.com/iotexproject/iotex-core/testutil/teststate"
 )
 
 func initConstruct(ctrl *gomock.Controller) (Protocol, context.Context, protocol.StateManager, *types.ElectionResult, error) {
@@ -46,7 +56,7 @@ func initConstruct(ctrl *gomock.Controller) (Protocol, context.Context, protocol
 		},
 	)
 	registry := protocol.NewRegistry()
-	err := registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20))
+	err := registry.Register("rolldpos", rolldpos.NewProtocol(2, 2, 360))
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -127,8 +137,6 @@ func initConstruct(ctrl *gomock.Controller) (Protocol, context.Context, protocol
 		committee,
 		uint64(123456),
 		func(uint64) (time.Time, error) { return time.Now(), nil },
-		2,
-		2,
 		cfg.Chain.PollInitialCandidatesInterval,
 		sm,
 		func(ctx context.Context, epochNum uint64) (uint64, map[string]uint64, error) {
@@ -227,8 +235,6 @@ func TestCreatePreStates(t *testing.T) {
 	p, ctx, sm, _, err := initConstruct(ctrl)
 	require.NoError(err)
 
-	psc, ok := p.(protocol.PreStatesCreator)
-	require.True(ok)
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
 
@@ -263,7 +269,7 @@ func TestCreatePreStates(t *testing.T) {
 					Producer:    identityset.Address(1),
 				},
 			)
-			require.NoError(psc.CreatePreStates(ctx, sm)) // shift
+			require.NoError(runPreStates(ctx, p, sm)) // shift
 			bl := &vote.Blacklist{}
 			candKey := candidatesutil.ConstructKey(candidatesutil.CurKickoutKey)
 			_, err := sm.State(bl, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
@@ -285,7 +291,7 @@ func TestCreatePreStates(t *testing.T) {
 				Producer:    identityset.Address(1),
 			},
 		)
-		require.NoError(psc.CreatePreStates(ctx, sm))
+		require.NoError(runPreStates(ctx, p, sm))
 
 		bl := &vote.Blacklist{}
 		candKey := candidatesutil.ConstructKey(candidatesutil.NxtKickoutKey)
@@ -301,6 +307,534 @@ func TestCreatePreStates(t *testing.T) {
 	}
 }
 
+func TestProbationListByEpoch(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	method := []byte("ProbationListByEpoch")
+	addr2 := identityset.Address(2).String()
+
+	var epochNum uint64
+	for epochNum = 1; epochNum <= 2; epochNum++ {
+		if epochNum > 1 {
+			ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+				BlockHeight: rp.GetEpochHeight(epochNum),
+				Producer:    identityset.Address(1),
+			})
+			require.NoError(runPreStates(ctx, p, sm))
+		}
+		ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+			BlockHeight: rp.GetEpochLastBlockHeight(epochNum),
+			Producer:    identityset.Address(1),
+		})
+		require.NoError(runPreStates(ctx, p, sm))
+	}
+
+	// the delegate is on probation in consecutive epochs with escalating counts
+	for _, e := range []struct {
+		epochNum uint64
+		count    uint32
+		size     int
+	}{
+		{2, 1, 3},
+		{3, 2, 4},
+	} {
+		data, err := p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(e.epochNum))
+		require.NoError(err)
+		pl := &vote.ProbationList{}
+		require.NoError(pl.Deserialize(data))
+		require.Equal(e.size, len(pl.ProbationInfo))
+		require.Equal(e.count, pl.ProbationInfo[addr2])
+		require.Equal(uint32(10), pl.IntensityRate)
+		require.Equal(e.epochNum, pl.EpochNum)
+	}
+
+	// future epoch
+	_, err = p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(4))
+	require.Equal(ErrFutureEpoch, errors.Cause(err))
+	_, err = p.ReadState(ctx, sm, method)
+	require.Error(err)
+
+	// epoch before the kick-out is activated
+	bcCtx.Genesis.EasterBlockHeight = rp.GetEpochHeight(3)
+	data, err := p.ReadState(protocol.WithBlockchainCtx(ctx, bcCtx), sm, method, byteutil.Uint64ToBytes(2))
+	require.NoError(err)
+	pl := &vote.ProbationList{}
+	require.NoError(pl.Deserialize(data))
+	require.Equal(0, len(pl.ProbationInfo))
+}
+
+func TestReadStateErrorCode(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+		BlockHeight: rp.GetEpochHeight(2),
+		Producer:    identityset.Address(1),
+	})
+	for _, test := range []struct {
+		method string
+		args   [][]byte
+		code   protocol.ReadStateErrorCode
+	}{
+		{"UnknownMethod", nil, protocol.UnknownMethod},
+		{"BlockProducersByEpoch", nil, protocol.BadArgCount},
+		{"GetGravityChainStartHeight", nil, protocol.BadArgCount},
+		{"CandidatesByEpoch", [][]byte{{2}}, protocol.BadArgEncoding},
+		{"ProbationListByEpoch", [][]byte{byteutil.Uint64ToBytes(4)}, protocol.EpochOutOfRange},
+		{"CandidatesByEpoch", [][]byte{byteutil.Uint64ToBytes(1)}, protocol.ArchiveUnavailable},
+	} {
+		_, err := p.ReadState(ctx, sm, []byte(test.method), test.args...)
+		require.Error(err)
+		code, ok := protocol.ReadStateErrorCodeOf(err)
+		require.True(ok, test.method)
+		require.Equal(test.code, code, test.method)
+	}
+	// the sentinel error is still the cause
+	_, err = p.ReadState(ctx, sm, []byte("ProbationListByEpoch"), byteutil.Uint64ToBytes(4))
+	require.Equal(ErrFutureEpoch, errors.Cause(err))
+}
+
+func TestProbationListEscalation(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.ProbationListHeight = 1
+	bcCtx.Genesis.ProbationIntensityStep = 5
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	for epochNum := uint64(1); epochNum <= 2; epochNum++ {
+		if epochNum > 1 {
+			ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+				BlockHeight: rp.GetEpochHeight(epochNum),
+				Producer:    identityset.Address(1),
+			})
+			require.NoError(runPreStates(ctx, p, sm))
+		}
+		ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+			BlockHeight: rp.GetEpochLastBlockHeight(epochNum),
+			Producer:    identityset.Address(1),
+		})
+		require.NoError(runPreStates(ctx, p, sm))
+	}
+
+	addrs := make([]string, 5)
+	for i := range addrs {
+		addrs[i] = identityset.Address(i).String()
+	}
+	// A, B and C are unproductive in epoch 1, and B and D in epoch 2
+	for _, e := range []struct {
+		epochNum uint64
+		expected map[string]uint32
+	}{
+		{2, map[string]uint32{addrs[1]: 1, addrs[2]: 1, addrs[3]: 1}},
+		{3, map[string]uint32{addrs[2]: 2, addrs[4]: 1}},
+	} {
+		data, err := p.ReadState(ctx, sm, []byte("ProbationListByEpoch"), byteutil.Uint64ToBytes(e.epochNum))
+		require.NoError(err)
+		pl := &vote.ProbationList{}
+		require.NoError(pl.Deserialize(data))
+		require.Equal(e.epochNum, pl.EpochNum)
+		require.Equal(e.expected, pl.ProbationInfo)
+	}
+	bl, _, err := candidatesutil.KickoutListFromDB(sm, true)
+	require.NoError(err)
+	require.Equal(map[string]uint32{addrs[2]: 2, addrs[4]: 1}, bl.BlacklistInfos)
+
+	// B keeps 5% of the votes for the repeated offense and D keeps 10%, so A and C are the block producers
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+	blockProducers, err := gp.readBlockProducersByEpoch(ctx, 3, true)
+	require.NoError(err)
+	require.Equal(2, len(blockProducers))
+	require.Equal(addrs[1], blockProducers[0].Address)
+	require.Equal(addrs[3], blockProducers[1].Address)
+}
+
+func TestDelegateStats(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.ProbationListHeight = 1
+	bcCtx.Genesis.DelegateStatsHeight = 1
+	bcCtx.Genesis.DelegateStatsRetention = 3
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	addrs := make([]string, 5)
+	for i := range addrs {
+		addrs[i] = identityset.Address(i).String()
+	}
+	// A produces the last block of each epoch on top of its 2 blocks, B misses all its slots in epochs 3 and 4, C
+	// misses most of its slots in epochs 6 and 9, and D is only active in the first 2 epochs
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+	gp.productivityByEpoch = func(_ context.Context, epochNum uint64) (uint64, map[string]uint64, error) {
+		produce := map[string]uint64{addrs[1]: 2, addrs[2]: 3, addrs[3]: 3}
+		if epochNum <= 2 {
+			produce[addrs[4]] = 3
+		}
+		if epochNum == 3 || epochNum == 4 {
+			produce[addrs[2]] = 0
+		}
+		if epochNum == 6 || epochNum == 9 {
+			produce[addrs[3]] = 1
+		}
+		var numBlks uint64
+		for _, count := range produce {
+			numBlks += count
+		}
+		return numBlks, produce, nil
+	}
+	for epochNum := uint64(1); epochNum <= 10; epochNum++ {
+		ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+			BlockHeight: rp.GetEpochLastBlockHeight(epochNum),
+			Producer:    identityset.Address(1),
+		})
+		require.NoError(gp.OnEpochBoundary(ctx, sm, epochNum, protocol.EpochEnd))
+		if epochNum == 5 {
+			stats, _, err := candidatesutil.DelegateStatsFromDB(sm)
+			require.NoError(err)
+			require.Contains(stats.Stats, addrs[4])
+		}
+	}
+
+	stats, _, err := candidatesutil.DelegateStatsFromDB(sm)
+	require.NoError(err)
+	require.Equal(uint64(10), stats.Epoch)
+	// D is dropped after being inactive for more than 3 epochs
+	require.Equal(3, len(stats.Stats))
+	require.Equal(&vote.DelegateStat{
+		ExpectedSlots: 26,
+		ProducedSlots: 30,
+		LastSeenEpoch: 10,
+	}, stats.Stats[addrs[1]])
+	// B is on probation in epochs 4 to 6
+	require.Equal(&vote.DelegateStat{
+		ExpectedSlots:    26,
+		ProducedSlots:    24,
+		ProbationEpochs:  3,
+		LastOffenseEpoch: 4,
+		LastSeenEpoch:    10,
+	}, stats.Stats[addrs[2]])
+	// C is on probation in epochs 7 and 10
+	require.Equal(&vote.DelegateStat{
+		ExpectedSlots:    26,
+		ProducedSlots:    26,
+		ProbationEpochs:  2,
+		LastOffenseEpoch: 9,
+		LastSeenEpoch:    10,
+	}, stats.Stats[addrs[3]])
+
+	// the statistics are paged in ascending order of address
+	sorted := []string{addrs[1], addrs[2], addrs[3]}
+	sort.Strings(sorted)
+	for _, e := range []struct {
+		offset, limit uint64
+		expected      []string
+	}{
+		{0, 10, sorted},
+		{1, 1, sorted[1:2]},
+		{2, 5, sorted[2:]},
+		{3, 5, nil},
+	} {
+		pagination := protocol.PaginationArgs{Offset: e.offset, Limit: e.limit}
+		data, err := p.ReadState(ctx, sm, []byte("DelegateStats"), pagination.Encode())
+		require.NoError(err)
+		envelope := &protocolpb.Page{}
+		require.NoError(proto.Unmarshal(data, envelope))
+		require.Equal(uint64(3), envelope.Total)
+		require.Equal(e.limit, envelope.Limit)
+		page := &pollpb.DelegateStats{}
+		require.NoError(proto.Unmarshal(envelope.Data, page))
+		require.Equal(uint64(10), page.EpochNum)
+		require.Equal(uint64(3), page.Total)
+		require.Equal(len(e.expected), len(page.Stats))
+		for i, stat := range page.Stats {
+			require.Equal(e.expected[i], stat.Address)
+			require.Equal(stats.Stats[stat.Address].ProducedSlots, stat.ProducedSlots)
+		}
+	}
+	_, err = p.ReadState(ctx, sm, []byte("DelegateStats"))
+	code, ok := protocol.ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(protocol.BadArgCount, code)
+	_, err = p.ReadState(ctx, sm, []byte("DelegateStats"), byteutil.Uint64ToBytes(0))
+	code, ok = protocol.ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(protocol.BadArgEncoding, code)
+}
+
+func TestNoElectedDelegates(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.ProbationListHeight = 1
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 721})
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+
+	// the delegates whose voting power is cut entirely are kicked out
+	probationList := vote.NewProbationList(2, 0)
+	probationList.ProbationInfo[identityset.Address(1).String()] = 1
+	probationList.ProbationInfo[identityset.Address(2).String()] = 1
+	require.NoError(setProbationList(sm, 2, probationList))
+	delegates, err := gp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{identityset.Address(3).String(), identityset.Address(4).String()},
+		[]string{delegates[0].Address, delegates[1].Address},
+	)
+
+	// all the candidates are on probation
+	probationList.ProbationInfo[identityset.Address(3).String()] = 1
+	probationList.ProbationInfo[identityset.Address(4).String()] = 1
+	require.NoError(setProbationList(sm, 2, probationList))
+	_, err = gp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.Equal(ErrNoElectedDelegates, errors.Cause(err))
+	require.Contains(err.Error(), "epoch 2 has 4 candidates, 0 of them after probation and 0 after productivity")
+
+	// no candidate is read from the state
+	require.NoError(setProbationList(sm, 2, vote.NewProbationList(2, 0)))
+	gp.getCandidates = func(protocol.StateReader, bool) ([]*state.Candidate, uint64, error) {
+		return nil, 720, nil
+	}
+	_, err = gp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.Equal(ErrNoElectedDelegates, errors.Cause(err))
+	require.Contains(err.Error(), "epoch 2 has 0 candidates, 0 of them after probation and 0 after productivity")
+}
+
+func TestElectableVoteThreshold(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.ProbationListHeight = 1
+	// the threshold lies between the votes of B and C
+	bcCtx.Genesis.ElectableVoteThresholdStr = "21"
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 721})
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+	require.NoError(setProbationList(sm, 2, vote.NewProbationList(2, 0)))
+
+	blockProducers, err := gp.readBlockProducersByEpoch(ctx, 2, false)
+	require.NoError(err)
+	require.Equal(2, len(blockProducers))
+	delegates, err := gp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{identityset.Address(1).String(), identityset.Address(2).String()},
+		[]string{delegates[0].Address, delegates[1].Address},
+	)
+
+	// the eligibility is surfaced in the V2 candidate list
+	data, err := gp.ReadState(
+		ctx,
+		sm,
+		[]byte("CandidatesByEpoch"),
+		byteutil.Uint64ToBytes(2),
+		byteutil.Uint64ToBytes(uint64(CandidateListV2)),
+	)
+	require.NoError(err)
+	listPb := &pollpb.CandidateListV2{}
+	require.NoError(proto.Unmarshal(data, listPb))
+	require.Equal(4, len(listPb.Candidates))
+	for _, cand := range listPb.Candidates {
+		electable := cand.OperatorAddress == identityset.Address(1).String() ||
+			cand.OperatorAddress == identityset.Address(2).String()
+		require.Equal(electable, cand.Electable, cand.OperatorAddress)
+	}
+
+	// no candidate has enough votes
+	bcCtx.Genesis.ElectableVoteThresholdStr = "31"
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	_, err = gp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.Equal(ErrNoElectedDelegates, errors.Cause(err))
+}
+
+func TestNextEpochCandidates(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+	require.NoError(p.CreateGenesisStates(ctx, sm))
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	lastHeight := rp.GetEpochLastBlockHeight(1)
+	method := []byte("NextEpochCandidates")
+	readNext := func(height uint64) (*pollpb.NextEpochCandidates, state.CandidateList) {
+		data, err := p.ReadState(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height}), sm, method)
+		require.NoError(err)
+		next := &pollpb.NextEpochCandidates{}
+		require.NoError(proto.Unmarshal(data, next))
+		var candidates state.CandidateList
+		require.NoError(candidates.LoadProto(next.Candidates))
+		return next, candidates
+	}
+
+	// provisional before the last block of the epoch
+	next, _ := readNext(lastHeight - 20)
+	require.Equal(uint64(2), next.EpochNum)
+	require.True(next.Provisional)
+	require.Equal(uint64(20), next.RemainingBlocks)
+
+	// final at the last block of the epoch, after the kick-out list is calculated
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+		BlockHeight: lastHeight,
+		Producer:    identityset.Address(1),
+	})
+	require.NoError(runPreStates(ctx, p, sm))
+	next, provisional := readNext(lastHeight)
+	require.Equal(uint64(2), next.EpochNum)
+	require.False(next.Provisional)
+	require.Zero(next.RemainingBlocks)
+
+	// the list converges to the actual one of next epoch
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+		BlockHeight: lastHeight + 1,
+		Producer:    identityset.Address(1),
+	})
+	require.NoError(runPreStates(ctx, p, sm))
+	bcCtx.Tip.Height = lastHeight + 1
+	actual, err := p.DelegatesByEpoch(protocol.WithBlockchainCtx(ctx, bcCtx), 2)
+	require.NoError(err)
+	require.Equal(len(actual), len(provisional))
+	for i, d := range actual {
+		require.True(d.Equal(provisional[i]))
+	}
+}
+
+func TestCalculateCandidatesByHeight_PastEpochs(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, _, _, err := initConstruct(ctrl)
+	require.NoError(err)
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+
+	// the gravity chain is not reachable, as for a node syncing from genesis without the access
+	committee := mock_committee.NewMockCommittee(ctrl)
+	committee.EXPECT().HeightByTime(gomock.Any()).Return(uint64(0), errors.New("gravity chain is unreachable")).AnyTimes()
+	committee.EXPECT().ResultByHeight(gomock.Any()).Return(nil, errors.New("gravity chain is unreachable")).AnyTimes()
+	gp.electionCommittee = committee
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	tipHeight := rp.GetEpochHeight(4) + 10
+	bcCtx.Tip.Height = tipHeight
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+
+	// the poll results committed in epochs 1 to 3, which are the candidates of epochs 2 to 4
+	results := make(map[uint64]state.CandidateList)
+	for epochNum := uint64(1); epochNum <= 3; epochNum++ {
+		i := int(epochNum)
+		results[epochNum] = state.CandidateList{
+			{Address: identityset.Address(i).String(), Votes: big.NewInt(100), RewardAddress: identityset.Address(i).String()},
+			{Address: identityset.Address(i + 10).String(), Votes: big.NewInt(50), RewardAddress: identityset.Address(i + 10).String()},
+		}
+	}
+	sr := teststate.New(tipHeight)
+	require.NoError(setEpochSnapshot(sr, 2, results[1], results[1], 0))
+	require.NoError(setEpochSnapshot(sr, 3, results[2], results[2], 0))
+	curKey := candidatesutil.ConstructKey(candidatesutil.CurCandidateKey)
+	current := results[3]
+	_, err = sr.PutState(&current, protocol.KeyOption(curKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	require.NoError(err)
+	gp.sr = sr
+
+	producer := identityset.Address(27)
+	for epochNum := uint64(1); epochNum <= 3; epochNum++ {
+		height := rp.GetEpochHeight(epochNum) + 10
+		candidates, err := p.CalculateCandidatesByHeight(ctx, height)
+		require.NoError(err)
+		require.Equal(results[epochNum], candidates)
+
+		// the poll result of a historical block is validated with on-chain data only
+		validateCtx := protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+			BlockHeight: height,
+			Producer:    producer,
+		})
+		validateCtx = protocol.WithActionCtx(validateCtx, protocol.ActionCtx{Caller: producer})
+		act := action.NewPutPollResult(1, rp.GetEpochHeight(epochNum+1), results[epochNum])
+		require.NoError(p.Validate(validateCtx, act))
+		act = action.NewPutPollResult(1, rp.GetEpochHeight(epochNum+1), results[epochNum%3+1])
+		require.Error(p.Validate(validateCtx, act))
+	}
+
+	// the poll result of the tip epoch has to be calculated from the gravity chain
+	_, err = p.CalculateCandidatesByHeight(ctx, tipHeight)
+	require.Error(err)
+
+	// a past poll result which isn't persisted is not recalculated
+	gp.sr = teststate.New(tipHeight)
+	_, err = p.CalculateCandidatesByHeight(ctx, rp.GetEpochHeight(1))
+	require.Equal(state.ErrStateNotExist, errors.Cause(err))
+}
+
+func TestGovernanceStart(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+	gp, ok := p.(*governanceChainCommitteeProtocol)
+	require.True(ok)
+
+	// the poll result of the tip epoch is pre-loaded
+	require.NoError(p.Start(ctx, sm))
+	require.Equal(1, gp.cache.Len())
+
+	// the only gravity chain endpoint is dead
+	dead, err := NewFailoverCommittee(
+		[]string{"dead"},
+		[]committee.Committee{&fakeCommittee{failures: 1 << 30}},
+		time.Second,
+		time.Minute,
+	)
+	require.NoError(err)
+	gp.electionCommittee = dead
+	gp.cache.Invalidate()
+	start := time.Now()
+	err = p.Start(ctx, sm)
+	require.Equal(ErrNoGravityEndpoint, errors.Cause(err))
+	require.True(time.Since(start) < time.Second)
+	require.Equal(0, gp.cache.Len())
+
+	// the poll result of the genesis block is left to CreateGenesisStates
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Tip.Height = 0
+	require.NoError(p.Start(protocol.WithBlockchainCtx(ctx, bcCtx), sm))
+}
+
 func TestHandle(t *testing.T) {
 	require := require.New(t)
 	ctrl := gomock.NewController(t)
@@ -341,6 +875,10 @@ func TestHandle(t *testing.T) {
 	selp2, err := action.Sign(elp, senderKey)
 	require.NoError(err)
 	require.NotNil(selp2)
+	blkCtx := protocol.MustGetBlockCtx(ctx2)
+	blkCtx.Producer = identityset.Address(27)
+	ctx2 = protocol.WithBlockCtx(ctx2, blkCtx)
+	ctx2 = protocol.WithActionCtx(ctx2, protocol.ActionCtx{Caller: identityset.Address(27)})
 	receipt, err = p.Handle(ctx2, selp2.Action(), sm2)
 	require.NoError(err)
 	require.NotNil(receipt)
@@ -354,6 +892,10 @@ func TestHandle(t *testing.T) {
 	require.Equal(candidates[0].Votes, sc2[0].Votes)
 	require.Equal(candidates[1].Address, sc2[1].Address)
 	require.Equal(candidates[1].Votes, sc2[1].Votes)
+	// Case 3: not put by the block producer
+	receipt, err = p.Handle(protocol.WithActionCtx(ctx2, protocol.ActionCtx{Caller: identityset.Address(28)}), selp2.Action(), sm2)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
 }
 
 func TestProtocol_Validate(t *testing.T) {
@@ -379,7 +921,7 @@ func TestProtocol_Validate(t *testing.T) {
 	require.NotNil(selp)
 	// Case 1: wrong action type
 	require.NoError(p.Validate(ctx, selp.Action()))
-	// Case 2: Only producer could create this protocol
+	// Case 2: poll result not put by the block producer
 	p2, ctx2, sm2, _, err := initConstruct(ctrl)
 	require.NoError(err)
 	require.NoError(p2.CreateGenesisStates(ctx2, sm2))
@@ -387,7 +929,7 @@ func TestProtocol_Validate(t *testing.T) {
 	candKey := candidatesutil.ConstructKey(candidatesutil.NxtCandidateKey)
 	_, err = sm2.State(&sc2, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
 	require.NoError(err)
-	act2 := action.NewPutPollResult(1, 1, sc2)
+	act2 := action.NewPutPollResult(1, 721, sc2)
 	elp = bd.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
 		SetAction(act2).Build()
@@ -409,8 +951,8 @@ func TestProtocol_Validate(t *testing.T) {
 			Caller: caller,
 		},
 	)
-	err = p.Validate(ctx2, selp2.Action())
-	require.True(strings.Contains(err.Error(), "Only producer could create this protocol"))
+	// the producer of the block is checked in handle
+	require.NoError(p2.Validate(ctx2, selp2.Action()))
 	// Case 3: duplicate candidate
 	p3, ctx3, sm3, _, err := initConstruct(ctrl)
 	require.NoError(err)
@@ -418,9 +960,9 @@ func TestProtocol_Validate(t *testing.T) {
 	var sc3 state.CandidateList
 	_, err = sm3.State(&sc3, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
 	require.NoError(err)
-	sc3 = append(sc3, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	sc3 = append(sc3, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	act3 := action.NewPutPollResult(1, 1, sc3)
+	sc3 = append(sc3, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	sc3 = append(sc3, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	act3 := action.NewPutPollResult(1, 721, sc3)
 	elp = bd.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
 		SetAction(act3).Build()
@@ -450,8 +992,8 @@ func TestProtocol_Validate(t *testing.T) {
 	var sc4 state.CandidateList
 	_, err = sm4.State(&sc4, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
 	require.NoError(err)
-	sc4 = append(sc4, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	act4 := action.NewPutPollResult(1, 1, sc4)
+	sc4 = append(sc4, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	act4 := action.NewPutPollResult(1, 721, sc4)
 	bd4 := &action.EnvelopeBuilder{}
 	elp4 := bd4.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
@@ -482,7 +1024,7 @@ func TestProtocol_Validate(t *testing.T) {
 	_, err = sm5.State(&sc5, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
 	require.NoError(err)
 	sc5[0].Votes = big.NewInt(10)
-	act5 := action.NewPutPollResult(1, 1, sc5)
+	act5 := action.NewPutPollResult(1, 721, sc5)
 	bd5 := &action.EnvelopeBuilder{}
 	elp5 := bd5.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
@@ -512,7 +1054,7 @@ func TestProtocol_Validate(t *testing.T) {
 	var sc6 state.CandidateList
 	_, err = sm6.State(&sc6, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
 	require.NoError(err)
-	act6 := action.NewPutPollResult(1, 1, sc6)
+	act6 := action.NewPutPollResult(1, 721, sc6)
 	bd6 := &action.EnvelopeBuilder{}
 	elp6 := bd6.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
@@ -536,6 +1078,67 @@ func TestProtocol_Validate(t *testing.T) {
 		},
 	)
 	require.NoError(p6.Validate(ctx6, selp6.Action()))
+	// Case 7: wrong poll result height, which is checked from the strict poll result height
+	require.NoError(p6.Validate(ctx6, action.NewPutPollResult(1, 1441, sc6)))
+	bcCtx6 := protocol.MustGetBlockchainCtx(ctx6)
+	bcCtx6.Genesis.StrictPollResultHeight = 1
+	strictCtx6 := protocol.WithBlockchainCtx(ctx6, bcCtx6)
+	require.Error(p6.Validate(strictCtx6, action.NewPutPollResult(1, 1, sc6)))
+	require.Error(p6.Validate(strictCtx6, action.NewPutPollResult(1, 1441, sc6)))
+	// Case 8: empty poll result
+	err = p6.Validate(ctx6, action.NewPutPollResult(1, 721, nil))
+	require.Equal(ErrDelegatesNotExist, errors.Cause(err))
+}
+
+func TestValidatePollResultStrictly(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, ctx, sm, _, err := initConstruct(ctrl)
+	require.NoError(err)
+	require.NoError(p.CreateGenesisStates(ctx, sm))
+	var sc state.CandidateList
+	candKey := candidatesutil.ConstructKey(candidatesutil.NxtCandidateKey)
+	_, err = sm.State(&sc, protocol.KeyOption(candKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	require.NoError(err)
+	require.True(len(sc) > 1)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.StrictPollResultHeight = 1
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	nextEpochHeight := rp.GetEpochHeight(2)
+	producer := identityset.Address(27)
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+		BlockHeight: 1,
+		Producer:    producer,
+	})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{
+		Caller: producer,
+	})
+
+	// the poll result doesn't target next epoch
+	require.Error(p.Validate(ctx, action.NewPutPollResult(1, 1, sc)))
+
+	// the list differs only in ordering
+	swapped := make(state.CandidateList, len(sc))
+	copy(swapped, sc)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	err = p.Validate(ctx, action.NewPutPollResult(1, nextEpochHeight, swapped))
+	require.Equal(ErrDelegatesNotAsExpected, errors.Cause(err))
+	require.Contains(err.Error(), "entry 0 is "+sc[1].Address)
+
+	// a missing entry
+	err = p.Validate(ctx, action.NewPutPollResult(1, nextEpochHeight, sc[:len(sc)-1]))
+	require.Equal(ErrDelegatesNotAsExpected, errors.Cause(err))
+	require.Contains(err.Error(), sc[len(sc)-1].Address)
+
+	require.NoError(p.Validate(ctx, action.NewPutPollResult(1, nextEpochHeight, sc)))
+
+	// the poll result targeting current epoch is not handled
+	_, err = p.Handle(ctx, action.NewPutPollResult(1, 1, sc), sm)
+	require.Error(err)
 }
 
 func TestDelegatesByEpoch(t *testing.T) {
@@ -605,3 +1208,176 @@ func TestDelegatesByEpoch(t *testing.T) {
 	}
 
 }
+
+func TestDelegatesByEpoch_Cache(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, _, _, err := initConstruct(ctrl)
+	require.NoError(err)
+
+	hit := testutil.ToFloat64(pollCacheMtc.WithLabelValues("hit"))
+	miss := testutil.ToFloat64(pollCacheMtc.WithLabelValues("miss"))
+	delegates, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.Equal(hit, testutil.ToFloat64(pollCacheMtc.WithLabelValues("hit")))
+	require.Equal(miss+1, testutil.ToFloat64(pollCacheMtc.WithLabelValues("miss")))
+
+	cached, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.Equal(hit+1, testutil.ToFloat64(pollCacheMtc.WithLabelValues("hit")))
+	require.Equal(miss+1, testutil.ToFloat64(pollCacheMtc.WithLabelValues("miss")))
+	require.Equal(len(delegates), len(cached))
+	for i, d := range delegates {
+		require.True(d.Equal(cached[i]))
+	}
+
+	// the cache is cleared once a new epoch begins
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Tip.Height = 1440
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	gp := p.(*governanceChainCommitteeProtocol)
+	require.Equal(1, gp.cache.Len())
+	key := pollCacheKey{method: "test", epochNum: 2}
+	_, err = gp.cache.Get(ctx, key, func() (state.CandidateList, error) {
+		return delegates, nil
+	})
+	require.NoError(err)
+	require.Equal(1, gp.cache.Len())
+	require.Equal(miss+2, testutil.ToFloat64(pollCacheMtc.WithLabelValues("miss")))
+	_, ok := gp.cache.cache.Get(key)
+	require.True(ok)
+}
+
+func TestProductivityKickout(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Default
+	cfg.Genesis.EasterBlockHeight = 1
+	cfg.Genesis.ProductivityThreshold = 85
+	cfg.Genesis.ProductivityKickoutHeight = 1
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(3, 2, 360)))
+	withTip := func(height uint64) context.Context {
+		return protocol.WithBlockchainCtx(
+			context.Background(),
+			protocol.BlockchainCtx{
+				Genesis:  cfg.Genesis,
+				Registry: registry,
+				Tip:      protocol.TipInfo{Height: height},
+			},
+		)
+	}
+	withProducer := func(ctx context.Context, height uint64, producer string) context.Context {
+		addr, err := address.FromString(producer)
+		require.NoError(err)
+		return protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height, Producer: addr})
+	}
+
+	// the last block of epoch 1 has been committed
+	sm := teststate.New(720)
+	require.NoError(setNextEpochBlacklist(sm, &vote.Blacklist{}))
+	_, err := shiftKickoutList(sm)
+	require.NoError(err)
+	candidates := state.CandidateList{}
+	for i, votes := range []int64{30, 22, 20, 10} {
+		candidates = append(candidates, &state.Candidate{
+			Address:       identityset.Address(i + 1).String(),
+			Votes:         big.NewInt(votes),
+			RewardAddress: identityset.Address(i + 11).String(),
+		})
+	}
+	p, err := NewGovernanceChainCommitteeProtocol(
+		func(protocol.StateReader, uint64) ([]*state.Candidate, error) { return candidates, nil },
+		func(protocol.StateReader, bool) ([]*state.Candidate, uint64, error) { return candidates, 720, nil },
+		candidatesutil.KickoutListFromDB,
+		candidatesutil.UnproductiveDelegateFromDB,
+		mock_committee.NewMockCommittee(ctrl),
+		uint64(123456),
+		func(uint64) (time.Time, error) { return time.Now(), nil },
+		cfg.Chain.PollInitialCandidatesInterval,
+		sm,
+		func(context.Context, uint64) (uint64, map[string]uint64, error) { return 0, nil, nil },
+		cfg.Genesis.ProductivityThreshold,
+		cfg.Genesis.KickoutEpochPeriod,
+		cfg.Genesis.KickoutIntensityRate,
+		cfg.Genesis.UnproductiveDelegateMaxCacheSize,
+	)
+	require.NoError(err)
+	gp := p.(*governanceChainCommitteeProtocol)
+
+	// the active block producers of epoch 1 are 2 out of the top 3 candidates
+	ctx := withTip(0)
+	active, err := gp.readActiveBlockProducersByEpoch(ctx, 1, false, false)
+	require.NoError(err)
+	require.Equal(2, len(active))
+	productive, unproductive := active[0].Address, active[1].Address
+	var standby string
+	for _, c := range candidates[:3] {
+		if c.Address != productive && c.Address != unproductive {
+			standby = c.Address
+		}
+	}
+
+	count := func(ctx context.Context, epochNum uint64, epochStart bool) error {
+		return countProductivity(ctx, sm, epochNum, func() (state.CandidateList, error) {
+			return gp.readActiveBlockProducersByEpoch(ctx, epochNum, epochStart, false)
+		})
+	}
+
+	// the blocks of epoch 1 are counted from its start
+	for i := uint64(1); i <= 10; i++ {
+		producer := productive
+		if i == 10 {
+			producer = unproductive
+		}
+		require.NoError(count(withProducer(ctx, i, producer), 1, i == 1))
+	}
+	pd, _, err := candidatesutil.ProductivityFromDB(sm, false)
+	require.NoError(err)
+	require.Equal(uint64(1), pd.Epoch())
+	require.Equal(uint64(9), pd.Count(productive))
+	require.Equal(uint64(1), pd.Count(unproductive))
+	require.Equal([]string{unproductive}, pd.Unproductive(cfg.Genesis.ProductivityThreshold))
+
+	// the unproductive delegate is replaced by the standby candidate in epoch 2
+	ctx = withTip(720)
+	delegates, err := p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.ElementsMatch([]string{productive, standby}, []string{delegates[0].Address, delegates[1].Address})
+
+	// the counts of epoch 1 can't be used before the epoch ends
+	sm.SetHeight(700)
+	_, err = p.DelegatesByEpoch(withTip(700), 2)
+	require.Equal(ErrIncompleteProductivity, errors.Cause(err))
+	sm.SetHeight(720)
+
+	// at the start of epoch 2, the counts of epoch 1 are kept, and the counts restart for the new producers
+	require.NoError(count(withProducer(ctx, 721, standby), 2, true))
+	prev, _, err := candidatesutil.ProductivityFromDB(sm, true)
+	require.NoError(err)
+	require.Equal(pd, prev)
+	cur, _, err := candidatesutil.ProductivityFromDB(sm, false)
+	require.NoError(err)
+	require.Equal(uint64(2), cur.Epoch())
+	require.Equal(uint64(0), cur.Count(productive))
+	require.Equal(uint64(1), cur.Count(standby))
+
+	// the same producers are read after the shift
+	sm.SetHeight(721)
+	delegates2, err := p.DelegatesByEpoch(withTip(721), 2)
+	require.NoError(err)
+	require.Equal(delegates, delegates2)
+}
+
+func TestExcludeUnproductiveDelegates(t *testing.T) {
+	require := require.New(t)
+	producers := []string{"a", "b", "c", "d"}
+	require.Equal(producers, excludeUnproductiveDelegates(producers, nil, 2))
+	require.Equal([]string{"a", "c", "d"}, excludeUnproductiveDelegates(producers, []string{"b"}, 2))
+	require.Equal([]string{"a", "d"}, excludeUnproductiveDelegates(producers, []string{"b", "c"}, 2))
+	// the unproductive delegates are added back if there aren't enough producers left
+	require.Equal([]string{"d", "a"}, excludeUnproductiveDelegates(producers, []string{"a", "b", "c"}, 2))
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-election/committee"
+	"github.com/iotexproject/iotex-election/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/iotexproject/iotex-core/pkg/log"
+)
+
+// maxServedGravityHeights is the max number of gravity chain heights whose serving endpoints are remembered
+const maxServedGravityHeights = 1024
+
+// ErrNoGravityEndpoint is an error that none of the gravity chain endpoints served a call
+var ErrNoGravityEndpoint = errors.New("no gravity chain endpoint is available")
+
+type (
+	// gravityEndpoint is an election committee reading the gravity chain through one endpoint
+	gravityEndpoint struct {
+		name             string
+		committee        committee.Committee
+		quarantinedUntil time.Time
+	}
+
+	// endpointCall is a call to the committee of an endpoint
+	endpointCall func(committee.Committee) (interface{}, error)
+
+	// callResult is the outcome of an endpoint call
+	callResult struct {
+		value interface{}
+		err   error
+	}
+
+	// servedResult records the endpoint which served the election result of a gravity chain height
+	servedResult struct {
+		endpoint string
+		hash     hash.Hash256
+	}
+
+	// FailoverCommittee is an election committee reading the gravity chain through a list of endpoints. The endpoints
+	// are tried in order, and an endpoint failing a call is skipped for a backoff period. The rest of the committee
+	// interface is served by the first endpoint.
+	FailoverCommittee struct {
+		committee.Committee
+
+		mutex     sync.Mutex
+		endpoints []*gravityEndpoint
+		timeout   time.Duration
+		backoff   time.Duration
+		served    map[uint64]servedResult
+		heights   []uint64
+		now       func() time.Time
+	}
+)
+
+// NewFailoverCommittee creates an election committee failing over among the committees of the named endpoints, with
+// a timeout of each call and a backoff period of a failing endpoint
+func NewFailoverCommittee(
+	names []string,
+	committees []committee.Committee,
+	timeout time.Duration,
+	backoff time.Duration,
+) (*FailoverCommittee, error) {
+	if len(committees) == 0 {
+		return nil, errors.New("no gravity chain endpoint is given")
+	}
+	if len(names) != len(committees) {
+		return nil, errors.Errorf("%d names are given for %d gravity chain endpoints", len(names), len(committees))
+	}
+	endpoints := make([]*gravityEndpoint, 0, len(committees))
+	for i, c := range committees {
+		if c == nil {
+			return nil, errors.Errorf("nil committee of gravity chain endpoint %s", names[i])
+		}
+		endpoints = append(endpoints, &gravityEndpoint{name: names[i], committee: c})
+	}
+	return &FailoverCommittee{
+		Committee: committees[0],
+		endpoints: endpoints,
+		timeout:   timeout,
+		backoff:   backoff,
+		served:    make(map[uint64]servedResult),
+		now:       time.Now,
+	}, nil
+}
+
+// Start starts the committees of all the endpoints
+func (fc *FailoverCommittee) Start(ctx context.Context) error {
+	for _, ep := range fc.endpoints {
+		if err := ep.committee.Start(ctx); err != nil {
+			return errors.Wrapf(err, "failed to start the committee of gravity chain endpoint %s", ep.name)
+		}
+	}
+	return nil
+}
+
+// Stop stops the committees of all the endpoints
+func (fc *FailoverCommittee) Stop(ctx context.Context) error {
+	for _, ep := range fc.endpoints {
+		if err := ep.committee.Stop(ctx); err != nil {
+			return errors.Wrapf(err, "failed to stop the committee of gravity chain endpoint %s", ep.name)
+		}
+	}
+	return nil
+}
+
+// ResultByHeight returns the election result of the gravity chain height from the first available endpoint. The
+// result is cross-checked with the one served before for the same height, and with the one of the next available
+// endpoint if any.
+func (fc *FailoverCommittee) ResultByHeight(height uint64) (*types.ElectionResult, error) {
+	name, value, err := fc.call(func(c committee.Committee) (interface{}, error) {
+		return c.ResultByHeight(height)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get election result of gravity chain height %d", height)
+	}
+	result := value.(*types.ElectionResult)
+	h, err := resultHash(result)
+	if err != nil {
+		return nil, err
+	}
+	fc.record(height, name, h)
+	fc.crossCheck(height, name, h)
+	return result, nil
+}
+
+// HeightByTime returns the gravity chain height of the time from the first available endpoint
+func (fc *FailoverCommittee) HeightByTime(ts time.Time) (uint64, error) {
+	_, value, err := fc.call(func(c committee.Committee) (interface{}, error) {
+		return c.HeightByTime(ts)
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get gravity chain height of time %s", ts)
+	}
+	return value.(uint64), nil
+}
+
+// EndpointByHeight returns the endpoint which served the election result of the gravity chain height
+func (fc *FailoverCommittee) EndpointByHeight(height uint64) (string, bool) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	sr, ok := fc.served[height]
+	return sr.endpoint, ok
+}
+
+// call runs the function on the endpoints in order until one succeeds. The quarantined endpoints are tried last, so
+// that a call still goes through when all the endpoints have failed recently.
+func (fc *FailoverCommittee) call(f endpointCall) (string, interface{}, error) {
+	fc.mutex.Lock()
+	now := fc.now()
+	var available, quarantined []*gravityEndpoint
+	for _, ep := range fc.endpoints {
+		if now.Before(ep.quarantinedUntil) {
+			quarantined = append(quarantined, ep)
+		} else {
+			available = append(available, ep)
+		}
+	}
+	fc.mutex.Unlock()
+
+	lastErr := ErrNoGravityEndpoint
+	for _, ep := range append(available, quarantined...) {
+		value, err := fc.callWithTimeout(ep, f)
+		if err == nil {
+			fc.mutex.Lock()
+			ep.quarantinedUntil = time.Time{}
+			fc.mutex.Unlock()
+			return ep.name, value, nil
+		}
+		log.L().Warn("Gravity chain endpoint failed.", zap.String("endpoint", ep.name), zap.Error(err))
+		fc.mutex.Lock()
+		ep.quarantinedUntil = fc.now().Add(fc.backoff)
+		fc.mutex.Unlock()
+		lastErr = errors.Wrapf(ErrNoGravityEndpoint, "last error from endpoint %s: %v", ep.name, err)
+	}
+	return "", nil, lastErr
+}
+
+// callWithTimeout runs the function on the endpoint, and gives up waiting for it after the timeout
+func (fc *FailoverCommittee) callWithTimeout(ep *gravityEndpoint, f endpointCall) (interface{}, error) {
+	if fc.timeout == 0 {
+		return f(ep.committee)
+	}
+	resChan := make(chan callResult, 1)
+	go func() {
+		value, err := f(ep.committee)
+		resChan <- callResult{value: value, err: err}
+	}()
+	select {
+	case res := <-resChan:
+		return res.value, res.err
+	case <-time.After(fc.timeout):
+		return nil, errors.Errorf("call timed out after %s", fc.timeout)
+	}
+}
+
+// record remembers the endpoint serving the election result of the height, and logs an error if the result differs
+// from the one served before
+func (fc *FailoverCommittee) record(height uint64, name string, h hash.Hash256) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	if prev, ok := fc.served[height]; ok {
+		if prev.hash != h {
+			logMismatch(height, prev.endpoint, name)
+		}
+	} else {
+		fc.heights = append(fc.heights, height)
+		if len(fc.heights) > maxServedGravityHeights {
+			delete(fc.served, fc.heights[0])
+			fc.heights = fc.heights[1:]
+		}
+	}
+	fc.served[height] = servedResult{endpoint: name, hash: h}
+}
+
+// crossCheck compares the election result of the height with the one of the next available endpoint. A failure of
+// the other endpoint is ignored, as the result has been served already.
+func (fc *FailoverCommittee) crossCheck(height uint64, name string, h hash.Hash256) {
+	fc.mutex.Lock()
+	now := fc.now()
+	var other *gravityEndpoint
+	for _, ep := range fc.endpoints {
+		if ep.name != name && !now.Before(ep.quarantinedUntil) {
+			other = ep
+			break
+		}
+	}
+	fc.mutex.Unlock()
+	if other == nil {
+		return
+	}
+	value, err := fc.callWithTimeout(other, func(c committee.Committee) (interface{}, error) {
+		return c.ResultByHeight(height)
+	})
+	if err != nil {
+		log.L().Debug("Failed to cross-check election result.", zap.String("endpoint", other.name), zap.Error(err))
+		return
+	}
+	otherHash, err := resultHash(value.(*types.ElectionResult))
+	if err != nil {
+		log.L().Debug("Failed to cross-check election result.", zap.String("endpoint", other.name), zap.Error(err))
+		return
+	}
+	if otherHash != h {
+		logMismatch(height, name, other.name)
+	}
+}
+
+// logMismatch logs the different election results of the same height served by the endpoints, which implies a reorg
+// on the gravity chain or a lying endpoint
+func logMismatch(height uint64, endpoint1, endpoint2 string) {
+	log.L().Error(
+		"Gravity chain endpoints served different election results of the same height.",
+		zap.Uint64("height", height),
+		zap.String("endpoint1", endpoint1),
+		zap.String("endpoint2", endpoint2),
+	)
+}
+
+func resultHash(result *types.ElectionResult) (hash.Hash256, error) {
+	if result == nil {
+		return hash.ZeroHash256, nil
+	}
+	data, err := result.Serialize()
+	if err != nil {
+		return hash.ZeroHash256, errors.Wrap(err, "failed to serialize election result")
+	}
+	return hash.Hash256b(data), nil
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iotexproject/iotex-election/committee"
+	"github.com/iotexproject/iotex-election/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeCommittee serves the scripted election results, after failing the given number of calls
+type fakeCommittee struct {
+	committee.Committee
+
+	results  map[uint64]*types.ElectionResult
+	failures int
+	delay    time.Duration
+	calls    int
+}
+
+func (c *fakeCommittee) Start(context.Context) error { return nil }
+
+func (c *fakeCommittee) Stop(context.Context) error { return nil }
+
+func (c *fakeCommittee) ResultByHeight(height uint64) (*types.ElectionResult, error) {
+	c.calls++
+	if c.failures > 0 {
+		c.failures--
+		return nil, errors.New("endpoint is down")
+	}
+	time.Sleep(c.delay)
+	r, ok := c.results[height]
+	if !ok {
+		return nil, errors.Errorf("no result of height %d", height)
+	}
+	return r, nil
+}
+
+func (c *fakeCommittee) HeightByTime(time.Time) (uint64, error) {
+	c.calls++
+	if c.failures > 0 {
+		c.failures--
+		return 0, errors.New("endpoint is down")
+	}
+	return 100, nil
+}
+
+func TestFailoverCommittee(t *testing.T) {
+	require := require.New(t)
+	r1 := types.NewElectionResultForTest(time.Unix(1000, 0))
+	r2 := types.NewElectionResultForTest(time.Unix(2000, 0))
+
+	_, err := NewFailoverCommittee(nil, nil, time.Second, time.Minute)
+	require.Error(err)
+	_, err = NewFailoverCommittee([]string{"a"}, []committee.Committee{&fakeCommittee{}, &fakeCommittee{}}, time.Second, time.Minute)
+	require.Error(err)
+
+	t.Run("failover and quarantine", func(t *testing.T) {
+		primary := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r1, 20: r1}, failures: 1}
+		backup := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r1, 20: r1}}
+		fc, err := NewFailoverCommittee(
+			[]string{"primary", "backup"},
+			[]committee.Committee{primary, backup},
+			time.Second,
+			time.Minute,
+		)
+		require.NoError(err)
+		now := time.Unix(0, 0)
+		fc.now = func() time.Time { return now }
+		require.NoError(fc.Start(context.Background()))
+
+		r, err := fc.ResultByHeight(10)
+		require.NoError(err)
+		require.Equal(r1, r)
+		endpoint, ok := fc.EndpointByHeight(10)
+		require.True(ok)
+		require.Equal("backup", endpoint)
+
+		// the primary is skipped during the backoff period, and the result is served by the backup alone
+		primaryCalls := primary.calls
+		_, err = fc.ResultByHeight(20)
+		require.NoError(err)
+		require.Equal(primaryCalls, primary.calls)
+		endpoint, _ = fc.EndpointByHeight(20)
+		require.Equal("backup", endpoint)
+
+		// the primary is back after the backoff period
+		now = now.Add(time.Minute)
+		height, err := fc.HeightByTime(now)
+		require.NoError(err)
+		require.Equal(uint64(100), height)
+		require.Equal(primaryCalls+1, primary.calls)
+		_, ok = fc.EndpointByHeight(30)
+		require.False(ok)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		slow := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r1}, delay: 200 * time.Millisecond}
+		fast := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r1}}
+		fc, err := NewFailoverCommittee(
+			[]string{"slow", "fast"},
+			[]committee.Committee{slow, fast},
+			50*time.Millisecond,
+			time.Minute,
+		)
+		require.NoError(err)
+		r, err := fc.ResultByHeight(10)
+		require.NoError(err)
+		require.Equal(r1, r)
+		endpoint, _ := fc.EndpointByHeight(10)
+		require.Equal("fast", endpoint)
+	})
+
+	t.Run("all endpoints fail", func(t *testing.T) {
+		fc, err := NewFailoverCommittee(
+			[]string{"a", "b"},
+			[]committee.Committee{&fakeCommittee{failures: 2}, &fakeCommittee{failures: 2}},
+			time.Second,
+			time.Minute,
+		)
+		require.NoError(err)
+		_, err = fc.ResultByHeight(10)
+		require.Equal(ErrNoGravityEndpoint, errors.Cause(err))
+		// the quarantined endpoints are still tried as the last resort
+		_, err = fc.HeightByTime(time.Now())
+		require.Equal(ErrNoGravityEndpoint, errors.Cause(err))
+		height, err := fc.HeightByTime(time.Now())
+		require.NoError(err)
+		require.Equal(uint64(100), height)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		core, logs := observer.New(zap.ErrorLevel)
+		defer zap.ReplaceGlobals(zap.New(core))()
+		honest := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r1}}
+		lying := &fakeCommittee{results: map[uint64]*types.ElectionResult{10: r2}}
+		fc, err := NewFailoverCommittee(
+			[]string{"honest", "lying"},
+			[]committee.Committee{honest, lying},
+			time.Second,
+			time.Minute,
+		)
+		require.NoError(err)
+		r, err := fc.ResultByHeight(10)
+		require.NoError(err)
+		require.Equal(r1, r)
+		require.Equal(1, logs.FilterMessage("Gravity chain endpoints served different election results of the same height.").Len())
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// hybridProtocol serves the epochs before the activation with the life long delegates, and the epochs from the
+// activation on with the governance chain committee (or the staking committee). The first epoch starting at or after
+// the activation height is the activation epoch, whose block producers are the life long delegates elected under the
+// old rules, since the poll result of the activation epoch is decided in the epoch before it.
+type hybridProtocol struct {
+	lifelong         Protocol
+	governance       Protocol
+	activationHeight uint64
+}
+
+// NewHybridProtocol creates a poll protocol which switches from the life long delegates protocol to the governance
+// protocol at the activation height
+func NewHybridProtocol(lifelong Protocol, governance Protocol, activationHeight uint64) (Protocol, error) {
+	if lifelong == nil || governance == nil {
+		return nil, errors.New("both life long and governance protocols are required")
+	}
+	if activationHeight == 0 {
+		return nil, errors.New("activation height cannot be 0")
+	}
+	return &hybridProtocol{
+		lifelong:         lifelong,
+		governance:       governance,
+		activationHeight: activationHeight,
+	}, nil
+}
+
+// Start starts both protocols. The governance protocol is started before the activation as well, so that a node which
+// can't read the gravity chain finds out before the switch rather than at it.
+func (h *hybridProtocol) Start(ctx context.Context, sr protocol.StateReader) error {
+	if err := h.lifelong.Start(ctx, sr); err != nil {
+		return err
+	}
+	return h.governance.Start(ctx, sr)
+}
+
+func (h *hybridProtocol) ReceiveBlock(blk *block.Block) error {
+	if err := h.lifelong.ReceiveBlock(blk); err != nil {
+		return err
+	}
+	return h.governance.ReceiveBlock(blk)
+}
+
+func (h *hybridProtocol) CreateGenesisStates(ctx context.Context, sm protocol.StateManager) error {
+	return h.protocolByHeight(ctx, 1).CreateGenesisStates(ctx, sm)
+}
+
+func (h *hybridProtocol) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if psc, ok := h.protocolByHeight(ctx, blkCtx.BlockHeight).(protocol.PreStatesCreator); ok {
+		return psc.CreatePreStates(ctx, sm)
+	}
+	return nil
+}
+
+func (h *hybridProtocol) OnEpochBoundary(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	boundary protocol.EpochBoundary,
+) error {
+	// the protocol serving the epoch takes the snapshot of it at EpochEndPost as well
+	if hook, ok := h.protocolByEpoch(ctx, epochNum).(protocol.EpochBoundaryHook); ok {
+		if err := hook.OnEpochBoundary(ctx, sm, epochNum, boundary); err != nil {
+			return err
+		}
+	}
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	activationEpoch := h.activationEpoch(rp)
+	if boundary != protocol.EpochEnd || activationEpoch <= 1 || epochNum != activationEpoch-1 {
+		return nil
+	}
+	return h.prepareActivation(ctx, sm, rp, activationEpoch)
+}
+
+func (h *hybridProtocol) CreatePostSystemActions(ctx context.Context) ([]action.Envelope, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if psac, ok := h.protocolByHeight(ctx, blkCtx.BlockHeight).(protocol.PostSystemActionsCreator); ok {
+		return psac.CreatePostSystemActions(ctx)
+	}
+	return nil, nil
+}
+
+func (h *hybridProtocol) Handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	return h.protocolByHeight(ctx, blkCtx.BlockHeight).Handle(ctx, act, sm)
+}
+
+func (h *hybridProtocol) Validate(ctx context.Context, act action.Action) error {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	return h.protocolByHeight(ctx, blkCtx.BlockHeight).Validate(ctx, act)
+}
+
+func (h *hybridProtocol) CalculateCandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	return h.protocolByHeight(ctx, height).CalculateCandidatesByHeight(ctx, height)
+}
+
+func (h *hybridProtocol) DelegatesByEpoch(ctx context.Context, epochNum uint64) (state.CandidateList, error) {
+	return h.protocolByEpoch(ctx, epochNum).DelegatesByEpoch(ctx, epochNum)
+}
+
+func (h *hybridProtocol) CandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	return h.protocolByHeight(ctx, height).CandidatesByHeight(ctx, height)
+}
+
+func (h *hybridProtocol) ReadState(
+	ctx context.Context,
+	sr protocol.StateReader,
+	method []byte,
+	args ...[]byte,
+) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpoch := rp.GetEpochNum(blkCtx.BlockHeight)
+	p := h.protocolByEpoch(ctx, tipEpoch)
+	switch string(method) {
+	case "GetGravityChainStartHeight", "ActiveBlockProducersByHeight", "GravityChainEndpointByHeight":
+		if len(args) == 1 {
+			height, err := uint64Arg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			p = h.protocolByHeight(ctx, height)
+		}
+	case "NextEpochCandidates":
+		p = h.protocolByEpoch(ctx, tipEpoch+1)
+	case "DelegateStats":
+		// the statistics are only aggregated by the governance chain committee
+		p = h.governance
+	case "DelegateFilter":
+		// the filter in force at the tip is read
+	case "CandidatesByEpoch", "BlockProducersByEpoch", "ActiveBlockProducersByEpoch", "KickoutListByEpoch",
+		"ProductivityByEpoch", "ProbationListByEpoch", "EpochMeta":
+		if len(args) == 1 {
+			epochNum, err := uint64Arg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			p = h.protocolByEpoch(ctx, epochNum)
+		}
+	default:
+		return nil, protocol.ReadStateErrorf(protocol.UnknownMethod, "corresponding method isn't found")
+	}
+	return p.ReadState(ctx, sr, method, args...)
+}
+
+// Name returns the name of the protocol
+func (h *hybridProtocol) Name() string {
+	return "Hybrid poll"
+}
+
+// Version returns the version of the protocol
+func (h *hybridProtocol) Version() uint {
+	return 1
+}
+
+// Dependencies returns the IDs of the protocols the protocol depends on
+func (h *hybridProtocol) Dependencies() []string {
+	return dependencies()
+}
+
+// Register registers the protocol with a unique ID
+func (h *hybridProtocol) Register(r *protocol.Registry) error {
+	return r.Register(protocolID, h)
+}
+
+// ForceRegister registers the protocol with a unique ID and force replacing the previous protocol if it exists
+func (h *hybridProtocol) ForceRegister(r *protocol.Registry) error {
+	return r.ForceRegister(protocolID, h)
+}
+
+// activationEpoch returns the first epoch starting at or after the activation height
+func (h *hybridProtocol) activationEpoch(rp *rolldpos.Protocol) uint64 {
+	epochNum := rp.GetEpochNum(h.activationHeight)
+	if rp.GetEpochHeight(epochNum) < h.activationHeight {
+		epochNum++
+	}
+	return epochNum
+}
+
+func (h *hybridProtocol) protocolByEpoch(ctx context.Context, epochNum uint64) Protocol {
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	if epochNum < h.activationEpoch(rp) {
+		return h.lifelong
+	}
+	return h.governance
+}
+
+func (h *hybridProtocol) protocolByHeight(ctx context.Context, height uint64) Protocol {
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	return h.protocolByEpoch(ctx, rp.GetEpochNum(height))
+}
+
+// prepareActivation writes the life long delegates as the candidates of the activation epoch, together with an empty
+// kick-out list, so that the governance protocol finds the states it shifts at the start of the activation epoch
+func (h *hybridProtocol) prepareActivation(
+	ctx context.Context,
+	sm protocol.StateManager,
+	rp *rolldpos.Protocol,
+	activationEpoch uint64,
+) error {
+	activationEpochHeight := rp.GetEpochHeight(activationEpoch)
+	candidates, err := h.lifelong.CandidatesByHeight(ctx, rp.GetEpochHeight(activationEpoch-1))
+	if err != nil {
+		return errors.Wrap(err, "failed to read life long delegates")
+	}
+	log.L().Info(
+		"Handing over poll to governance protocol",
+		zap.Uint64("activationEpoch", activationEpoch),
+		zap.Uint64("activationEpochHeight", activationEpochHeight),
+	)
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
+	if hu.IsPost(config.Easter, activationEpochHeight) {
+		blackList := &vote.Blacklist{
+			IntensityRate: bcCtx.Genesis.KickoutIntensityRate,
+		}
+		if err := setNextEpochBlacklist(sm, blackList); err != nil {
+			return err
+		}
+		if err := setProbationList(sm, activationEpoch, probationListFromBlacklist(activationEpoch, blackList)); err != nil {
+			return err
+		}
+	}
+	return setCandidates(ctx, sm, candidates, activationEpochHeight)
+}
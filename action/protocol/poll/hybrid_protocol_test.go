@@ -0,0 +1,123 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/testutil/teststate"
+)
+
+func TestHybridProtocol(t *testing.T) {
+	require := require.New(t)
+	delegates := config.Default.Genesis.Delegates
+	lifelong, err := NewLifeLongDelegatesProtocol(delegates[:4])
+	require.NoError(err)
+	governance, err := NewLifeLongDelegatesProtocol(delegates[4:8])
+	require.NoError(err)
+	_, err = NewHybridProtocol(lifelong, governance, 0)
+	require.Error(err)
+	_, err = NewHybridProtocol(nil, governance, 12)
+	require.Error(err)
+	// activation height 12 is in the middle of epoch 2, so epoch 3 is the first one served by governance
+	p, err := NewHybridProtocol(lifelong, governance, 12)
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	require.NoError(p.Register(registry))
+	require.Equal(p, FindProtocol(registry))
+	g := config.Default.Genesis
+	g.EasterBlockHeight = 1
+	lastHeight := rp.GetEpochLastBlockHeight(2)
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	}
+	bcCtx.Tip.Height = lastHeight - 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: lastHeight})
+
+	t.Run("producers on both sides", func(t *testing.T) {
+		expectedOld, err := lifelong.DelegatesByEpoch(ctx, 2)
+		require.NoError(err)
+		expectedNew, err := governance.DelegatesByEpoch(ctx, 3)
+		require.NoError(err)
+		require.NotEqual(expectedOld[0].Address, expectedNew[0].Address)
+		ds, err := p.DelegatesByEpoch(ctx, 2)
+		require.NoError(err)
+		require.Equal(expectedOld, ds)
+		ds, err = p.DelegatesByEpoch(ctx, 3)
+		require.NoError(err)
+		require.Equal(expectedNew, ds)
+
+		expectedOld, err = lifelong.CalculateCandidatesByHeight(ctx, lastHeight)
+		require.NoError(err)
+		cs, err := p.CalculateCandidatesByHeight(ctx, lastHeight)
+		require.NoError(err)
+		require.Equal(expectedOld, cs)
+		expectedNew, err = governance.CandidatesByHeight(ctx, lastHeight+1)
+		require.NoError(err)
+		cs, err = p.CandidatesByHeight(ctx, lastHeight+1)
+		require.NoError(err)
+		require.Equal(expectedNew, cs)
+
+		expected, err := lifelong.ReadState(ctx, nil, []byte("BlockProducersByEpoch"))
+		require.NoError(err)
+		res, err := p.ReadState(ctx, nil, []byte("BlockProducersByEpoch"), byteutil.Uint64ToBytes(2))
+		require.NoError(err)
+		require.Equal(expected, res)
+		expected, err = governance.ReadState(ctx, nil, []byte("BlockProducersByEpoch"))
+		require.NoError(err)
+		res, err = p.ReadState(ctx, nil, []byte("BlockProducersByEpoch"), byteutil.Uint64ToBytes(3))
+		require.NoError(err)
+		require.Equal(expected, res)
+		_, err = p.ReadState(ctx, nil, []byte("UnknownMethod"), byteutil.Uint64ToBytes(3))
+		require.Error(err)
+	})
+
+	t.Run("hand over at the boundary", func(t *testing.T) {
+		sm := teststate.New(0)
+		// no hand over before the last block of the last life long epoch
+		sm.SetHeight(lastHeight - 1)
+		require.NoError(runPreStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: lastHeight - 1}), p, sm))
+		_, _, err := candidatesutil.CandidatesFromDB(sm, true)
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+
+		sm.SetHeight(lastHeight)
+		require.NoError(runPreStates(ctx, p, sm))
+		next, _, err := candidatesutil.CandidatesFromDB(sm, true)
+		require.NoError(err)
+		old, err := lifelong.CandidatesByHeight(ctx, rp.GetEpochHeight(2))
+		require.NoError(err)
+		require.Equal(old, state.CandidateList(next))
+		bl, _, err := candidatesutil.KickoutListFromDB(sm, true)
+		require.NoError(err)
+		require.Equal(0, len(bl.BlacklistInfos))
+		_, _, err = candidatesutil.ProbationListFromDB(sm, 3)
+		require.NoError(err)
+
+		// the producers elected under the old rules serve the activation epoch
+		sm.SetHeight(lastHeight + 1)
+		_, err = shiftCandidates(sm)
+		require.NoError(err)
+		cur, _, err := candidatesutil.CandidatesFromDB(sm, false)
+		require.NoError(err)
+		require.Equal(old, state.CandidateList(cur))
+	})
+}
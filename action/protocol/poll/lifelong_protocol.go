@@ -7,7 +7,10 @@
 package poll
 
 import (
+	"bytes"
 	"context"
+	"math/big"
+	"sort"
 
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-address/address"
@@ -17,38 +20,139 @@ import (
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/block"
 	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
 )
 
 type lifeLongDelegatesProtocol struct {
 	delegates state.CandidateList
 	addr      address.Address
+	sr        protocol.StateReader
+	cacheSize int
+	cache     *candidatesCache
+	router    *protocol.ReadStateRouter
 }
 
-// NewLifeLongDelegatesProtocol creates a poll protocol with life long delegates
-func NewLifeLongDelegatesProtocol(delegates []genesis.Delegate) Protocol {
-	var l state.CandidateList
-	for _, delegate := range delegates {
-		rewardAddress := delegate.RewardAddr()
-		if rewardAddress == nil {
-			rewardAddress = delegate.OperatorAddr()
+// LifeLongOption sets an option of the life long delegates protocol
+type LifeLongOption func(*lifeLongDelegatesProtocol)
+
+// WithLifeLongStateReader sets the state reader to load the delegates of past epochs
+func WithLifeLongStateReader(sr protocol.StateReader) LifeLongOption {
+	return func(p *lifeLongDelegatesProtocol) {
+		p.sr = sr
+	}
+}
+
+// WithLifeLongCacheSize sets the max number of candidate lists in the cache
+func WithLifeLongCacheSize(size int) LifeLongOption {
+	return func(p *lifeLongDelegatesProtocol) {
+		p.cacheSize = size
+	}
+}
+
+// NewLifeLongDelegatesProtocol creates a poll protocol with life long delegates, which are sorted by votes
+func NewLifeLongDelegatesProtocol(delegates []genesis.Delegate, opts ...LifeLongOption) (Protocol, error) {
+	l, err := lifeLongCandidates(delegates)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.Hash160b([]byte(protocolID))
+	addr, err := address.FromBytes(h[:])
+	if err != nil {
+		log.L().Panic("Error when constructing the address of poll protocol", zap.Error(err))
+	}
+	p := &lifeLongDelegatesProtocol{delegates: l, addr: addr, cacheSize: defaultPollCacheSize}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.cache = newCandidatesCache(p.cacheSize)
+	p.router = protocol.MustNewReadStateRouter(p.ReadStateMethods()...)
+	return p, nil
+}
+
+// lifeLongCandidates validates the delegates and converts them to candidates sorted by votes. The operator and reward
+// addresses must be valid and nonzero, the operator addresses must be unique, and the reward address of a delegate
+// can't be the operator address of another one. The error names the index of the offending delegate.
+func lifeLongCandidates(delegates []genesis.Delegate) (state.CandidateList, error) {
+	l := make(state.CandidateList, 0, len(delegates))
+	operators := make(map[string]int, len(delegates))
+	for i, delegate := range delegates {
+		operator, err := delegateAddress(delegate.OperatorAddrStr)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidLifeLongDelegate, "delegate %d has invalid operator address: %v", i, err)
+		}
+		if j, ok := operators[operator.String()]; ok {
+			return nil, errors.Wrapf(
+				ErrInvalidLifeLongDelegate,
+				"delegate %d has the same operator address %s as delegate %d",
+				i,
+				operator.String(),
+				j,
+			)
+		}
+		operators[operator.String()] = i
+		rewardAddress := operator
+		if delegate.RewardAddrStr != "" {
+			if rewardAddress, err = delegateAddress(delegate.RewardAddrStr); err != nil {
+				return nil, errors.Wrapf(ErrInvalidLifeLongDelegate, "delegate %d has invalid reward address: %v", i, err)
+			}
+		}
+		votes, err := delegateVotes(delegate)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidLifeLongDelegate, "delegate %d has %v", i, err)
 		}
 		l = append(l, &state.Candidate{
-			Address: delegate.OperatorAddr().String(),
-			// TODO: load votes from genesis
-			Votes:         delegate.Votes(),
+			Address:       operator.String(),
+			Votes:         votes,
 			RewardAddress: rewardAddress.String(),
 		})
 	}
-	h := hash.Hash160b([]byte(protocolID))
-	addr, err := address.FromBytes(h[:])
+	for _, c := range l {
+		if j, ok := operators[c.RewardAddress]; ok && c.RewardAddress != c.Address {
+			return nil, errors.Wrapf(
+				ErrInvalidLifeLongDelegate,
+				"delegate %d has the operator address of delegate %d as its reward address %s",
+				operators[c.Address],
+				j,
+				c.RewardAddress,
+			)
+		}
+	}
+	sort.Sort(l)
+	return l, nil
+}
+
+// delegateAddress parses the address of a delegate, which can't be the zero address
+func delegateAddress(addrStr string) (address.Address, error) {
+	addr, err := address.FromString(addrStr)
 	if err != nil {
-		log.L().Panic("Error when constructing the address of poll protocol", zap.Error(err))
+		return nil, errors.Wrapf(err, "failed to parse %q", addrStr)
 	}
-	return &lifeLongDelegatesProtocol{delegates: l, addr: addr}
+	if bytes.Equal(addr.Bytes(), make([]byte, len(addr.Bytes()))) {
+		return nil, errors.Errorf("zero address %s", addrStr)
+	}
+	return addr, nil
+}
+
+// delegateVotes parses the votes of the delegate into a new big int, missing votes are treated as zero
+func delegateVotes(delegate genesis.Delegate) (*big.Int, error) {
+	if delegate.VotesStr == "" {
+		return big.NewInt(0), nil
+	}
+	votes, ok := new(big.Int).SetString(delegate.VotesStr, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid votes %q", delegate.VotesStr)
+	}
+	if votes.Sign() < 0 {
+		return nil, errors.Errorf("negative votes %s", delegate.VotesStr)
+	}
+	return votes, nil
 }
 
 func (p *lifeLongDelegatesProtocol) CreateGenesisStates(
@@ -59,36 +163,121 @@ func (p *lifeLongDelegatesProtocol) CreateGenesisStates(
 	if blkCtx.BlockHeight != 0 {
 		return errors.Errorf("Cannot create genesis state for height %d", blkCtx.BlockHeight)
 	}
+	if len(p.delegates) == 0 {
+		return errors.Wrap(ErrInvalidLifeLongDelegate, "no life long delegate in genesis")
+	}
 	log.L().Info("Creating genesis states for lifelong delegates protocol")
 	return setCandidates(ctx, sm, p.delegates, uint64(1))
 }
 
+// Start pre-builds the sorted active block producers of the tip epoch, which are cached until the epoch ends
+func (p *lifeLongDelegatesProtocol) Start(ctx context.Context, sr protocol.StateReader) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
+	if tipEpochNum == 0 {
+		return nil
+	}
+	if _, err := p.DelegatesByEpoch(ctx, tipEpochNum); err != nil {
+		return errors.Wrapf(err, "failed to build the active block producers of epoch %d", tipEpochNum)
+	}
+	return nil
+}
+
+func (p *lifeLongDelegatesProtocol) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if h := bcCtx.Genesis.ProductivityKickoutHeight; h == 0 || blkCtx.BlockHeight < h {
+		return nil
+	}
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	// the delegate filter of the epoch isn't shifted yet at its first block
+	epochStart := rp.IsEpochStart(blkCtx.BlockHeight)
+	return countProductivity(ctx, sm, epochNum, func() (state.CandidateList, error) {
+		return p.readActiveBlockProducersByEpoch(ctx, epochNum, epochStart, false)
+	})
+}
+
+func (p *lifeLongDelegatesProtocol) OnEpochBoundary(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	boundary protocol.EpochBoundary,
+) error {
+	switch boundary {
+	case protocol.EpochStart:
+		if err := shiftLifeLongDelegates(sm); err != nil {
+			return err
+		}
+		return shiftDelegateFilter(sm)
+	case protocol.EpochEndPost:
+		return createEpochSnapshot(ctx, sm, p, epochNum)
+	}
+	return nil
+}
+
 func (p *lifeLongDelegatesProtocol) Handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	if f, ok := act.(*action.SetDelegateFilter); ok {
+		return handleSetDelegateFilter(ctx, sm, f, p.addr.String())
+	}
+	if u, ok := act.(*action.UpdateDelegates); ok {
+		current, err := p.readDelegates(sm, false)
+		if err != nil {
+			return nil, err
+		}
+		return handleUpdateDelegates(ctx, sm, u, current, p.addr.String())
+	}
 	return handle(ctx, act, sm, p.addr.String())
 }
 
+func (p *lifeLongDelegatesProtocol) ReceiveBlock(blk *block.Block) error {
+	return p.cache.ReceiveBlock(blk)
+}
+
 func (p *lifeLongDelegatesProtocol) Validate(ctx context.Context, act action.Action) error {
+	if f, ok := act.(*action.SetDelegateFilter); ok {
+		return validateDelegateFilter(ctx, f)
+	}
+	if u, ok := act.(*action.UpdateDelegates); ok {
+		current, err := p.readDelegates(p.sr, false)
+		if err != nil {
+			return err
+		}
+		_, err = validateDelegateUpdate(ctx, u, current)
+		return err
+	}
 	return validate(ctx, p, act)
 }
 
 func (p *lifeLongDelegatesProtocol) CalculateCandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
-	return p.delegates, nil
+	return p.delegatesByHeight(ctx, height)
 }
 
+// DelegatesByEpoch returns the active block producers of any epoch up to the next one. As the delegates are static,
+// the producers of a past epoch are computed with the seed of its epoch height, unless they are persisted in the
+// epoch snapshot.
 func (p *lifeLongDelegatesProtocol) DelegatesByEpoch(ctx context.Context, epochNum uint64) (state.CandidateList, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
+	if epochNum == 0 || epochNum > tipEpochNum+1 {
+		return nil, errors.Errorf("wrong epochNumber to get delegates, epochNumber %d should be in [1, %d]", epochNum, tipEpochNum+1)
+	}
 	if tipEpochNum+1 == epochNum {
-		return p.readActiveBlockProducersByEpoch(ctx, epochNum, true)
-	} else if tipEpochNum == epochNum {
-		return p.readActiveBlockProducersByEpoch(ctx, epochNum, false)
+		return p.readActiveBlockProducersByEpoch(ctx, epochNum, true, false)
+	}
+	if tipEpochNum == epochNum {
+		key := pollCacheKey{method: "DelegatesByEpoch", epochNum: epochNum}
+		return p.cache.Get(ctx, key, func() (state.CandidateList, error) {
+			return p.readActiveBlockProducersByEpoch(ctx, epochNum, false, false)
+		})
 	}
-	return nil, errors.Errorf("wrong epochNumber to get delegates, epochNumber %d can't be less than tip epoch number %d", epochNum, tipEpochNum)
+	return p.readActiveBlockProducersByEpoch(ctx, epochNum, false, false)
 }
 
 func (p *lifeLongDelegatesProtocol) CandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
-	return p.delegates, nil
+	return p.delegatesByHeight(ctx, height)
 }
 
 func (p *lifeLongDelegatesProtocol) ReadState(
@@ -97,21 +286,100 @@ func (p *lifeLongDelegatesProtocol) ReadState(
 	method []byte,
 	args ...[]byte,
 ) ([]byte, error) {
-	switch string(method) {
-	case "CandidatesByEpoch":
-		fallthrough
-	case "BlockProducersByEpoch":
-		fallthrough
-	case "ActiveBlockProducersByEpoch":
-		return p.readBlockProducers()
-	case "GetGravityChainStartHeight":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		return args[0], nil
-	default:
-		return nil, errors.New("corresponding method isn't found")
+	return p.router.ReadState(ctx, sr, method, args...)
+}
+
+// ReadStateMethods describes the methods of ReadState
+func (p *lifeLongDelegatesProtocol) ReadStateMethods() []protocol.ReadStateMethod {
+	epochAndVersion := []protocol.ArgType{protocol.Uint64Arg, protocol.Uint64Arg}
+	readBlockProducers := func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+		return p.readBlockProducers(ctx, args)
 	}
+	return []protocol.ReadStateMethod{
+		{Name: "CandidatesByEpoch", OptionalArgs: epochAndVersion, Handler: readBlockProducers},
+		{Name: "BlockProducersByEpoch", OptionalArgs: epochAndVersion, Handler: readBlockProducers},
+		{Name: "ActiveBlockProducersByEpoch", OptionalArgs: epochAndVersion, Handler: readBlockProducers},
+		{
+			Name: "GetGravityChainStartHeight",
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				bcCtx := protocol.MustGetBlockchainCtx(ctx)
+				gravityStartHeight, err := gravityChainHeight(bcCtx.Genesis, byteutil.BytesToUint64(args[0]))
+				if err != nil {
+					return nil, err
+				}
+				return byteutil.Uint64ToBytes(gravityStartHeight), nil
+			},
+		},
+		{
+			Name:      "NextEpochCandidates",
+			ExtraArgs: true,
+			Handler: func(ctx context.Context, _ protocol.StateReader, _ ...[]byte) ([]byte, error) {
+				blkCtx := protocol.MustGetBlockCtx(ctx)
+				rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+				nextEpochNum := rp.GetEpochNum(blkCtx.BlockHeight) + 1
+				candidates, err := p.readActiveBlockProducersByEpoch(ctx, nextEpochNum, true, true)
+				if err != nil {
+					return nil, err
+				}
+				return serializeNextEpochCandidates(ctx, nextEpochNum, candidates)
+			},
+		},
+		{
+			Name: "ActiveBlockProducersByHeight",
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readActiveBlockProducersByHeight(ctx, sr, p, byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name: "ProductivityByEpoch",
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readProductivityByEpoch(ctx, sr, byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name: "ProbationListByEpoch",
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return p.readProbationList(ctx, byteutil.BytesToUint64(args[0]))
+			},
+		},
+		{
+			Name:      "DelegateFilter",
+			ExtraArgs: true,
+			Handler: func(_ context.Context, sr protocol.StateReader, _ ...[]byte) ([]byte, error) {
+				f, err := readDelegateFilter(sr, false)
+				if err != nil {
+					return nil, err
+				}
+				return f.Serialize()
+			},
+		},
+		{
+			Name: "EpochMeta",
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(ctx context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return readEpochMeta(ctx, byteutil.BytesToUint64(args[0]))
+			},
+		},
+	}
+}
+
+// Name returns the name of the protocol
+func (p *lifeLongDelegatesProtocol) Name() string {
+	return "Lifelong delegates poll"
+}
+
+// Version returns the version of the protocol
+func (p *lifeLongDelegatesProtocol) Version() uint {
+	return 1
+}
+
+// Dependencies returns the IDs of the protocols the protocol depends on
+func (p *lifeLongDelegatesProtocol) Dependencies() []string {
+	return dependencies()
 }
 
 // Register registers the protocol with a unique ID
@@ -124,36 +392,145 @@ func (p *lifeLongDelegatesProtocol) ForceRegister(r *protocol.Registry) error {
 	return r.ForceRegister(protocolID, p)
 }
 
-func (p *lifeLongDelegatesProtocol) readBlockProducers() ([]byte, error) {
-	return p.delegates.Serialize()
+// readDelegates returns the life long delegates of current epoch, or the ones of next epoch if readFromNext is true.
+// The delegates passed to the constructor are returned until the first update lands in the state.
+func (p *lifeLongDelegatesProtocol) readDelegates(sr protocol.StateReader, readFromNext bool) (state.CandidateList, error) {
+	if sr == nil {
+		return p.delegates, nil
+	}
+	delegates, err := readLifeLongDelegates(sr, readFromNext)
+	if err != nil {
+		return nil, err
+	}
+	if delegates == nil {
+		return p.delegates, nil
+	}
+	return delegates, nil
+}
+
+// delegatesByHeight returns the life long delegates of the epoch of the height, which are the ones of next epoch if
+// the epoch is after the tip epoch
+func (p *lifeLongDelegatesProtocol) delegatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	if p.sr == nil {
+		return p.delegates, nil
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	return p.readDelegates(p.sr, rp.GetEpochNum(height) > rp.GetEpochNum(bcCtx.Tip.Height))
 }
 
-func (p *lifeLongDelegatesProtocol) readActiveBlockProducersByEpoch(ctx context.Context, epochNum uint64, _ bool) (state.CandidateList, error) {
+// readProbationList returns an empty probation list, as the life long delegates are never kicked out
+func (p *lifeLongDelegatesProtocol) readProbationList(ctx context.Context, epochNum uint64) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	if epochNum > tipEpochNum+1 {
+		return nil, protocol.NewReadStateError(
+			protocol.EpochOutOfRange,
+			errors.Wrapf(ErrFutureEpoch, "epoch %d is after next epoch %d", epochNum, tipEpochNum+1),
+		)
+	}
+	return vote.NewProbationList(epochNum, 0).Serialize()
+}
+
+// readBlockProducers returns the life long delegates of the epoch, which are its candidates and block producers and
+// are never on probation
+func (p *lifeLongDelegatesProtocol) readBlockProducers(ctx context.Context, args [][]byte) ([]byte, error) {
+	version, err := candidateListVersion(args)
+	if err != nil {
+		return nil, err
+	}
+	var epochNum uint64
+	if len(args) != 0 {
+		if epochNum, err = uint64Arg(args, 0); err != nil {
+			return nil, err
+		}
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	delegates, err := p.readDelegates(p.sr, epochNum > rp.GetEpochNum(bcCtx.Tip.Height))
+	if err != nil {
+		return nil, err
+	}
+	return serializeCandidateList(
+		delegates,
+		epochNum,
+		version,
+		vote.NewProbationList(epochNum, 0),
+		vote.ProbationRule{},
+		bcCtx.Genesis.ElectableVoteThreshold(),
+	)
+}
+
+// readActiveBlockProducersByEpoch returns the active block producers of the epoch. If readFromNext is false, the epoch
+// has started, so the finalized producers persisted in its snapshot are returned if any. Otherwise the producers are
+// computed provisionally from the life long delegates of the epoch, as the poll result of the next epoch isn't
+// committed yet. The delegates denied by the delegate filter of the epoch are skipped before the top candidates are
+// taken, and the delegates which were unproductive in the previous epoch are kicked out. If provisional is true, the
+// productivity of the previous epoch is allowed to be incomplete.
+func (p *lifeLongDelegatesProtocol) readActiveBlockProducersByEpoch(
+	ctx context.Context,
+	epochNum uint64,
+	readFromNext bool,
+	provisional bool,
+) (state.CandidateList, error) {
+	if !readFromNext && p.sr != nil {
+		delegates, _, err := candidatesutil.EpochSnapshotFromDB(p.sr, epochNum)
+		if errors.Cause(err) != state.ErrStateNotExist {
+			return delegates, err
+		}
+	}
 	var blockProducerList []string
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	blockProducerMap := make(map[string]*state.Candidate)
-	delegates := p.delegates
-	if len(p.delegates) > int(rp.NumCandidateDelegates()) {
-		delegates = p.delegates[:rp.NumCandidateDelegates()]
+	lifeLongDelegates, err := p.readDelegates(p.sr, readFromNext)
+	if err != nil {
+		return nil, err
+	}
+	delegates := canonicalCandidates(electableCandidates(lifeLongDelegates, bcCtx.Genesis.ElectableVoteThreshold()))
+	if p.sr != nil {
+		filter, err := readDelegateFilter(p.sr, readFromNext)
+		if err != nil {
+			return nil, err
+		}
+		delegates = filter.apply(delegates)
+	}
+	if numCandidateDelegates := rp.NumCandidateDelegatesAt(epochNum); len(delegates) > int(numCandidateDelegates) {
+		delegates = delegates[:numCandidateDelegates]
 	}
 	for _, bp := range delegates {
 		blockProducerList = append(blockProducerList, bp.Address)
 		blockProducerMap[bp.Address] = bp
 	}
-
-	epochHeight := rp.GetEpochHeight(epochNum)
-	crypto.SortCandidates(blockProducerList, epochHeight, crypto.CryptoSeed)
-	// TODO: kick-out unqualified delegates based on productivity
-	length := int(rp.NumDelegates())
-	if len(blockProducerList) < length {
-		// TODO: if the number of delegates is smaller than expected, should it return error or not?
-		length = len(blockProducerList)
+	numDelegates := int(rp.NumDelegatesAt(epochNum))
+	if p.sr != nil {
+		unproductive, err := readUnproductiveDelegates(
+			ctx,
+			p.sr,
+			epochNum,
+			readFromNext,
+			provisional,
+			bcCtx.Genesis.ProductivityThreshold,
+		)
+		if err != nil {
+			return nil, err
+		}
+		blockProducerList = excludeUnproductiveDelegates(blockProducerList, unproductive, numDelegates)
 	}
-
-	var activeBlockProducers state.CandidateList
-	for i := 0; i < length; i++ {
-		activeBlockProducers = append(activeBlockProducers, blockProducerMap[blockProducerList[i]])
+	if len(blockProducerList) == 0 {
+		return nil, noElectedDelegatesError(epochNum, len(lifeLongDelegates), len(delegates), len(blockProducerList))
+	}
+	epochHeight := rp.GetEpochHeight(epochNum)
+	seed, err := sortSeed(ctx, epochHeight)
+	if err != nil {
+		return nil, err
 	}
-	return activeBlockProducers, nil
+	crypto.SortCandidates(blockProducerList, epochHeight, seed)
+	return selectActiveBlockProducers(
+		blockProducerList,
+		blockProducerMap,
+		numDelegates,
+		bcCtx.Genesis.DelegateShortagePolicy,
+	)
 }
@@ -7,26 +7,47 @@
 package poll
 
 import (
+	"bytes"
 	"context"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
 	"github.com/iotexproject/iotex-core/test/mock/mock_chainmanager"
+	"github.com/iotexproject/iotex-core/testutil/teststate"
 )
 
 func initLifeLongDelegateProtocol(ctrl *gomock.Controller) (Protocol, context.Context, protocol.StateManager, error) {
 	genesisConfig := config.Default.Genesis
 	delegates := genesisConfig.Delegates
-	p := NewLifeLongDelegatesProtocol(delegates)
+	p, err := NewLifeLongDelegatesProtocol(delegates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	registry := protocol.NewRegistry()
-	err := registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20))
+	err = registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20))
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -76,6 +97,132 @@ func initLifeLongDelegateProtocol(ctrl *gomock.Controller) (Protocol, context.Co
 	return p, ctx, sm, nil
 }
 
+func TestNewLifeLongDelegatesProtocol(t *testing.T) {
+	require := require.New(t)
+	addr1 := identityset.Address(1).String()
+	addr2 := identityset.Address(2).String()
+	addr3 := identityset.Address(3).String()
+	addr4 := identityset.Address(4).String()
+
+	// sorted by votes, missing votes are treated as zero
+	delegates := []genesis.Delegate{
+		{OperatorAddrStr: addr1, VotesStr: "10"},
+		{OperatorAddrStr: addr2, RewardAddrStr: addr4},
+		{OperatorAddrStr: addr3, VotesStr: "20"},
+	}
+	p, err := NewLifeLongDelegatesProtocol(delegates)
+	require.NoError(err)
+	candidates, err := p.CalculateCandidatesByHeight(context.Background(), 1)
+	require.NoError(err)
+	require.Equal(3, len(candidates))
+	require.Equal(addr3, candidates[0].Address)
+	require.Equal(addr3, candidates[0].RewardAddress)
+	require.Equal(addr1, candidates[1].Address)
+	require.Equal(addr2, candidates[2].Address)
+	require.Equal(addr4, candidates[2].RewardAddress)
+	require.Zero(candidates[2].Votes.Sign())
+
+	// the candidates don't alias the genesis delegates
+	delegates[2].VotesStr = "1"
+	require.Equal("20", candidates[0].Votes.String())
+
+	// all-zero votes are sorted by address deterministically
+	zeros := []genesis.Delegate{
+		{OperatorAddrStr: addr1, VotesStr: "0"},
+		{OperatorAddrStr: addr2, VotesStr: "0"},
+		{OperatorAddrStr: addr3, VotesStr: "0"},
+	}
+	p, err = NewLifeLongDelegatesProtocol(zeros)
+	require.NoError(err)
+	expected, err := p.CalculateCandidatesByHeight(context.Background(), 1)
+	require.NoError(err)
+	zeros[0], zeros[2] = zeros[2], zeros[0]
+	p, err = NewLifeLongDelegatesProtocol(zeros)
+	require.NoError(err)
+	candidates, err = p.CalculateCandidatesByHeight(context.Background(), 1)
+	require.NoError(err)
+	require.Equal(expected, candidates)
+
+	// a delegate may set its own operator address as the reward address
+	_, err = NewLifeLongDelegatesProtocol([]genesis.Delegate{{OperatorAddrStr: addr1, RewardAddrStr: addr1}})
+	require.NoError(err)
+}
+
+func TestNewLifeLongDelegatesProtocol_InvalidDelegates(t *testing.T) {
+	require := require.New(t)
+	addr1 := identityset.Address(1).String()
+	addr2 := identityset.Address(2).String()
+	zero, err := address.FromBytes(make([]byte, 20))
+	require.NoError(err)
+
+	for _, e := range []struct {
+		desc      string
+		delegates []genesis.Delegate
+		msg       string
+	}{
+		{
+			"duplicate operator address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1, VotesStr: "1"}, {OperatorAddrStr: addr1, VotesStr: "2"}},
+			"delegate 1 has the same operator address",
+		},
+		{
+			"reward address of another delegate's operator address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1}, {OperatorAddrStr: addr2, RewardAddrStr: addr1}},
+			"delegate 1 has the operator address of delegate 0",
+		},
+		{
+			"empty operator address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1}, {VotesStr: "1"}},
+			"delegate 1 has invalid operator address",
+		},
+		{
+			"malformed operator address",
+			[]genesis.Delegate{{OperatorAddrStr: "io1invalid", VotesStr: "1"}},
+			"delegate 0 has invalid operator address",
+		},
+		{
+			"zero operator address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1}, {OperatorAddrStr: zero.String()}},
+			"delegate 1 has invalid operator address",
+		},
+		{
+			"malformed reward address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1, RewardAddrStr: "io1invalid", VotesStr: "1"}},
+			"delegate 0 has invalid reward address",
+		},
+		{
+			"zero reward address",
+			[]genesis.Delegate{{OperatorAddrStr: addr1, RewardAddrStr: zero.String()}},
+			"delegate 0 has invalid reward address",
+		},
+		{
+			"negative votes",
+			[]genesis.Delegate{{OperatorAddrStr: addr1}, {OperatorAddrStr: addr2, VotesStr: "-1"}},
+			"delegate 1 has negative votes",
+		},
+		{
+			"malformed votes",
+			[]genesis.Delegate{{OperatorAddrStr: addr1, VotesStr: "abc"}},
+			"delegate 0 has invalid votes",
+		},
+		{
+			"fractional votes",
+			[]genesis.Delegate{{OperatorAddrStr: addr1, VotesStr: "1.5"}},
+			"delegate 0 has invalid votes",
+		},
+	} {
+		_, err := NewLifeLongDelegatesProtocol(e.delegates)
+		require.Equal(ErrInvalidLifeLongDelegate, errors.Cause(err), e.desc)
+		require.Contains(err.Error(), e.msg, e.desc)
+	}
+
+	// a chain without life long delegates fails to create the genesis states
+	p, err := NewLifeLongDelegatesProtocol(nil)
+	require.NoError(err)
+	err = p.CreateGenesisStates(protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{}), teststate.New(0))
+	require.Equal(ErrInvalidLifeLongDelegate, errors.Cause(err))
+}
+
 func TestCreateGenesisStates_WithLifeLong(t *testing.T) {
 	require := require.New(t)
 	ctrl := gomock.NewController(t)
@@ -109,3 +256,1158 @@ func TestProtocol_Validate_WithLifeLong(t *testing.T) {
 	require.NoError(err)
 	require.NoError(p.Validate(ctx, nil))
 }
+
+func TestDelegateShortagePolicy_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+
+	// 2 candidates for 4 slots
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:2])
+	require.NoError(err)
+	first := config.Default.Genesis.Delegates[0].OperatorAddr().String()
+	second := config.Default.Genesis.Delegates[1].OperatorAddr().String()
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 4, 20)))
+
+	for _, e := range []struct {
+		policy string
+		num    int
+		err    error
+	}{
+		{ShrinkPolicy, 2, nil},
+		{"", 2, nil},
+		{ErrorPolicy, 0, ErrNotEnoughDelegates},
+		{PadPolicy, 4, nil},
+	} {
+		g := config.Default.Genesis
+		g.DelegateShortagePolicy = e.policy
+		ctx := protocol.WithBlockchainCtx(
+			context.Background(),
+			protocol.BlockchainCtx{
+				Genesis:  g,
+				Registry: registry,
+			},
+		)
+		delegates, err := p.DelegatesByEpoch(ctx, 1)
+		require.Equal(e.err, errors.Cause(err), e.policy)
+		require.Equal(e.num, len(delegates), e.policy)
+		if e.policy == PadPolicy {
+			// the slots are filled round-robin
+			require.Equal(delegates[0], delegates[2])
+			require.Equal(delegates[1], delegates[3])
+			require.ElementsMatch([]string{first, second}, []string{delegates[0].Address, delegates[1].Address})
+		}
+	}
+
+	g := config.Default.Genesis
+	g.DelegateShortagePolicy = "drop"
+	_, err = p.DelegatesByEpoch(protocol.WithBlockchainCtx(
+		context.Background(),
+		protocol.BlockchainCtx{
+			Genesis:  g,
+			Registry: registry,
+		},
+	), 1)
+	require.Error(err)
+}
+
+func TestSortSeed_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+	lp, ok := p.(*lifeLongDelegatesProtocol)
+	require.True(ok)
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 36, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	order := func(g genesis.Genesis, getBlockHash func(uint64) (hash.Hash256, error), epochNum uint64) ([]string, error) {
+		ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+			Genesis:      g,
+			Registry:     registry,
+			GetBlockHash: getBlockHash,
+		})
+		delegates, err := lp.readActiveBlockProducersByEpoch(ctx, epochNum, true, false)
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]string, 0, len(delegates))
+		for _, d := range delegates {
+			addrs = append(addrs, d.Address)
+		}
+		return addrs, nil
+	}
+	var requested []uint64
+	blockHash := func(b byte) func(uint64) (hash.Hash256, error) {
+		return func(height uint64) (hash.Hash256, error) {
+			requested = append(requested, height)
+			return hash.BytesToHash256([]byte{b}), nil
+		}
+	}
+
+	constant := make(map[uint64][]string)
+	for _, epochNum := range []uint64{2, 3} {
+		constant[epochNum], err = order(config.Default.Genesis, nil, epochNum)
+		require.NoError(err)
+	}
+	require.NotEqual(constant[2], constant[3])
+
+	// the epochs starting before the fork are ordered by the fixed seed
+	g := config.Default.Genesis
+	g.SortSeedStrategy = BlockHashSeedStrategy
+	g.SortSeedHeight = rp.GetEpochHeight(3)
+	res, err := order(g, blockHash(1), 2)
+	require.NoError(err)
+	require.Equal(constant[2], res)
+	res, err = order(g, nil, 2)
+	require.NoError(err)
+	require.Equal(constant[2], res)
+	require.Empty(requested)
+
+	// the epochs starting after the fork are ordered by the hash of the last block of the previous epoch
+	first, err := order(g, blockHash(1), 3)
+	require.NoError(err)
+	require.Equal([]uint64{rp.GetEpochHeight(3) - 1}, requested)
+	require.ElementsMatch(constant[3], first)
+	require.NotEqual(constant[3], first)
+	res, err = order(g, blockHash(1), 3)
+	require.NoError(err)
+	require.Equal(first, res)
+	second, err := order(g, blockHash(2), 3)
+	require.NoError(err)
+	require.ElementsMatch(first, second)
+	require.NotEqual(first, second)
+	_, err = order(g, nil, 3)
+	require.Error(err)
+	_, err = order(g, func(uint64) (hash.Hash256, error) {
+		return hash.ZeroHash256, errors.New("not found")
+	}, 3)
+	require.Error(err)
+
+	// the constant strategy keeps the fixed seed after the fork
+	g.SortSeedStrategy = ConstantSeedStrategy
+	res, err = order(g, blockHash(1), 3)
+	require.NoError(err)
+	require.Equal(constant[3], res)
+	g.SortSeedStrategy = "random"
+	_, err = order(g, blockHash(1), 3)
+	require.Error(err)
+}
+
+func TestNoElectedDelegates_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(nil)
+	require.NoError(err)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 4, 20)))
+	ctx := protocol.WithBlockchainCtx(
+		context.Background(),
+		protocol.BlockchainCtx{
+			Genesis:  config.Default.Genesis,
+			Registry: registry,
+		},
+	)
+	_, err = p.DelegatesByEpoch(ctx, 1)
+	require.Equal(ErrNoElectedDelegates, errors.Cause(err))
+	require.Contains(err.Error(), "epoch 1 has 0 candidates")
+}
+
+func TestElectableVoteThreshold_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	delegates := []genesis.Delegate{
+		{OperatorAddrStr: identityset.Address(1).String(), VotesStr: "30"},
+		{OperatorAddrStr: identityset.Address(2).String(), VotesStr: "20"},
+		{OperatorAddrStr: identityset.Address(3).String(), VotesStr: "10"},
+	}
+	p, err := NewLifeLongDelegatesProtocol(delegates)
+	require.NoError(err)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 4, 20)))
+	g := config.Default.Genesis
+	// the threshold lies between the votes of the last two delegates
+	g.ElectableVoteThresholdStr = "15"
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	active, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{identityset.Address(1).String(), identityset.Address(2).String()},
+		[]string{active[0].Address, active[1].Address},
+	)
+
+	data, err := p.ReadState(
+		ctx,
+		nil,
+		[]byte("BlockProducersByEpoch"),
+		byteutil.Uint64ToBytes(1),
+		byteutil.Uint64ToBytes(uint64(CandidateListV2)),
+	)
+	require.NoError(err)
+	listPb := &pollpb.CandidateListV2{}
+	require.NoError(proto.Unmarshal(data, listPb))
+	require.Equal(3, len(listPb.Candidates))
+	require.True(listPb.Candidates[0].Electable)
+	require.True(listPb.Candidates[1].Electable)
+	require.False(listPb.Candidates[2].Electable)
+
+	g.ElectableVoteThresholdStr = "31"
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g, Registry: registry})
+	_, err = p.DelegatesByEpoch(ctx, 1)
+	require.Equal(ErrNoElectedDelegates, errors.Cause(err))
+}
+
+func TestCommitteeSchedule_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:8])
+	require.NoError(err)
+	// the committee grows from 4 to 6 delegates at epoch 3
+	rp := rolldpos.NewProtocol(36, 4, 20, rolldpos.EnableCommitteeSchedule(
+		[]genesis.EpochValue{{Epoch: 3, Value: 6}},
+		nil,
+	))
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rp))
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	}
+	bcCtx.Tip.Height = rp.GetEpochHeight(3)
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+
+	// replaying a pre-fork epoch still uses the old committee size
+	delegates, err := p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.Equal(4, len(delegates))
+	delegates, err = p.DelegatesByEpoch(ctx, 3)
+	require.NoError(err)
+	require.Equal(6, len(delegates))
+	delegates, err = p.DelegatesByEpoch(ctx, 4)
+	require.NoError(err)
+	require.Equal(6, len(delegates))
+	// the epochs before the fork keep their length, and the epochs after it have a block more per delegate
+	require.Equal(uint64(80)*2+1, rp.GetEpochHeight(3))
+	require.Equal(uint64(80)*2+1+120, rp.GetEpochHeight(4))
+}
+
+func TestEpochMeta_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:8])
+	require.NoError(err)
+	// the committee grows from 4 to 6 delegates and from 5 to 8 candidates at epoch 3
+	rp := rolldpos.NewProtocol(5, 4, 20, rolldpos.EnableCommitteeSchedule(
+		[]genesis.EpochValue{{Epoch: 3, Value: 6}},
+		[]genesis.EpochValue{{Epoch: 3, Value: 8}},
+	))
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rp))
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	})
+	// the tip is in epoch 3
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: rp.GetEpochHeight(3) + 1})
+	read := func(epochNum uint64) (*pollpb.EpochMeta, error) {
+		data, err := p.ReadState(ctx, nil, []byte("EpochMeta"), byteutil.Uint64ToBytes(epochNum))
+		if err != nil {
+			return nil, err
+		}
+		meta := &pollpb.EpochMeta{}
+		require.NoError(proto.Unmarshal(data, meta))
+		return meta, nil
+	}
+
+	for _, test := range []struct {
+		epochNum              uint64
+		startHeight           uint64
+		numDelegates          uint64
+		numCandidateDelegates uint64
+		status                pollpb.EpochMeta_EpochStatus
+	}{
+		{1, 1, 4, 5, pollpb.EpochMeta_PAST},
+		{2, 81, 4, 5, pollpb.EpochMeta_PAST},
+		{3, 161, 6, 8, pollpb.EpochMeta_CURRENT},
+		{4, 281, 6, 8, pollpb.EpochMeta_FUTURE},
+	} {
+		meta, err := read(test.epochNum)
+		require.NoError(err)
+		require.Equal(test.epochNum, meta.EpochNum)
+		require.Equal(test.startHeight, meta.StartHeight)
+		// the epoch length is unchanged by the committee schedule
+		require.Equal(test.startHeight+79, meta.EndHeight)
+		require.Equal(uint64(80), meta.NumBlocks)
+		require.Equal(test.numDelegates, meta.NumDelegates)
+		require.Equal(test.numCandidateDelegates, meta.NumCandidateDelegates)
+		require.Equal(test.status, meta.Status)
+	}
+
+	for _, epochNum := range []uint64{0, 5} {
+		_, err := read(epochNum)
+		code, ok := protocol.ReadStateErrorCodeOf(err)
+		require.True(ok)
+		require.Equal(protocol.EpochOutOfRange, code)
+	}
+	_, err = p.ReadState(ctx, nil, []byte("EpochMeta"))
+	code, ok := protocol.ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(protocol.BadArgCount, code)
+}
+
+func TestDelegateFilter_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	var delegates []genesis.Delegate
+	for i, votes := range []string{"50", "40", "30", "20", "10"} {
+		delegates = append(delegates, genesis.Delegate{OperatorAddrStr: identityset.Address(i + 1).String(), VotesStr: votes})
+	}
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(delegates, WithLifeLongStateReader(sm))
+	require.NoError(err)
+	rp := rolldpos.NewProtocol(4, 4, 1)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rp))
+	admin := identityset.Address(10)
+	g := config.Default.Genesis
+	g.DelegateFilterAdmin = admin.String()
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	}
+	bcCtx.Tip.Height = 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 2})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: admin})
+
+	denied := identityset.Address(2).String()
+	act := action.NewSetDelegateFilter(1, nil, []string{denied})
+	require.NoError(p.Validate(ctx, act))
+	// only the admin sets the filter with valid addresses
+	nonAdminCtx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(11)})
+	require.Equal(ErrInvalidDelegateFilter, errors.Cause(p.Validate(nonAdminCtx, act)))
+	receipt, err := p.Handle(nonAdminCtx, act, sm)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
+	require.Equal(ErrInvalidDelegateFilter, errors.Cause(p.Validate(ctx, action.NewSetDelegateFilter(1, nil, []string{"xyz"}))))
+	disabled := bcCtx
+	disabled.Genesis.DelegateFilterAdmin = ""
+	require.Equal(ErrInvalidDelegateFilter, errors.Cause(p.Validate(protocol.WithBlockchainCtx(ctx, disabled), act)))
+
+	active, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.Equal(4, len(active))
+	receipt, err = p.Handle(ctx, act, sm)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Success), receipt.Status)
+
+	addresses := func(candidates state.CandidateList) []string {
+		var addrs []string
+		for _, c := range candidates {
+			addrs = append(addrs, c.Address)
+		}
+		return addrs
+	}
+	// the filter takes effect from next epoch, where the fifth delegate is promoted
+	expected := []string{
+		identityset.Address(1).String(),
+		identityset.Address(3).String(),
+		identityset.Address(4).String(),
+		identityset.Address(5).String(),
+	}
+	active, err = p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.Contains(addresses(active), denied)
+	active, err = p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.ElementsMatch(expected, addresses(active))
+
+	// the filter is shifted at the start of next epoch
+	epochHeight := rp.GetEpochHeight(2)
+	hook, ok := p.(protocol.EpochBoundaryHook)
+	require.True(ok)
+	require.NoError(hook.OnEpochBoundary(
+		protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: epochHeight}),
+		sm,
+		2,
+		protocol.EpochStart,
+	))
+	bcCtx.Tip.Height = epochHeight
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	active, err = p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.ElementsMatch(expected, addresses(active))
+
+	data, err := p.ReadState(ctx, sm, []byte("DelegateFilter"))
+	require.NoError(err)
+	filter := &pollpb.DelegateFilter{}
+	require.NoError(proto.Unmarshal(data, filter))
+	require.Empty(filter.AllowList)
+	require.Equal([]string{denied}, filter.DenyList)
+
+	// the deny list takes precedence over a non-empty allow list
+	f := &delegateFilter{
+		allowList: []string{identityset.Address(1).String(), denied},
+		denyList:  []string{denied},
+	}
+	candidates, err := p.CalculateCandidatesByHeight(ctx, epochHeight)
+	require.NoError(err)
+	require.Equal([]string{identityset.Address(1).String()}, addresses(f.apply(candidates)))
+}
+
+func TestUpdateDelegates_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	var delegates []genesis.Delegate
+	for i, votes := range []string{"50", "40", "30", "20"} {
+		delegates = append(delegates, genesis.Delegate{OperatorAddrStr: identityset.Address(i + 1).String(), VotesStr: votes})
+	}
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(delegates, WithLifeLongStateReader(sm))
+	require.NoError(err)
+	rp := rolldpos.NewProtocol(4, 4, 1)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rp))
+	admin := identityset.Address(10)
+	g := config.Default.Genesis
+	g.DelegateUpdateAdmin = admin.String()
+	g.DelegateUpdateQuorum = 2
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	}
+	bcCtx.Tip.Height = 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 2})
+	callerCtx := func(i int) context.Context {
+		return protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(i)})
+	}
+	addresses := func(candidates state.CandidateList) []string {
+		var addrs []string
+		for _, c := range candidates {
+			addrs = append(addrs, c.Address)
+		}
+		return addrs
+	}
+
+	added := identityset.Address(5).String()
+	updated := append([]genesis.Delegate{{OperatorAddrStr: added, VotesStr: "60"}}, delegates...)
+	act := action.NewUpdateDelegates(1, updated)
+	require.NoError(p.Validate(callerCtx(10), act))
+	require.NoError(p.Validate(callerCtx(1), act))
+	// only the admin and the current delegates send the update with valid delegates
+	require.Equal(ErrInvalidDelegateUpdate, errors.Cause(p.Validate(callerCtx(11), act)))
+	receipt, err := p.Handle(callerCtx(11), act, sm)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
+	for _, invalid := range [][]genesis.Delegate{
+		nil,
+		{{OperatorAddrStr: "xyz"}},
+		{{OperatorAddrStr: added, RewardAddrStr: "xyz"}},
+		{{OperatorAddrStr: added, VotesStr: "x"}},
+		{{OperatorAddrStr: added, VotesStr: "-1"}},
+		{{OperatorAddrStr: added}, {OperatorAddrStr: added, VotesStr: "1"}},
+	} {
+		require.Equal(ErrInvalidDelegateUpdate, errors.Cause(p.Validate(callerCtx(10), action.NewUpdateDelegates(1, invalid))))
+	}
+	noQuorum := bcCtx
+	noQuorum.Genesis.DelegateUpdateQuorum = 0
+	require.Equal(ErrInvalidDelegateUpdate, errors.Cause(p.Validate(
+		protocol.WithBlockchainCtx(callerCtx(1), noQuorum),
+		act,
+	)))
+	disabled := noQuorum
+	disabled.Genesis.DelegateUpdateAdmin = ""
+	require.Equal(ErrInvalidDelegateUpdate, errors.Cause(p.Validate(
+		protocol.WithBlockchainCtx(callerCtx(10), disabled),
+		act,
+	)))
+
+	current, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.NotContains(addresses(current), added)
+	// the update is applied once it is approved by 2 delegates, where a different update resets the approvals
+	_, err = p.Handle(callerCtx(1), act, sm)
+	require.NoError(err)
+	_, err = p.Handle(callerCtx(2), action.NewUpdateDelegates(1, updated[:4]), sm)
+	require.NoError(err)
+	next, err := p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.NotContains(addresses(next), added)
+	_, err = p.Handle(callerCtx(1), act, sm)
+	require.NoError(err)
+	next, err = p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.NotContains(addresses(next), added)
+	receipt, err = p.Handle(callerCtx(3), act, sm)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Success), receipt.Status)
+
+	// the delegate added in the middle of epoch 1 only produces blocks from epoch 2
+	current, err = p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.NotContains(addresses(current), added)
+	candidates, err := p.CandidatesByHeight(ctx, 2)
+	require.NoError(err)
+	require.Equal(4, len(candidates))
+	next, err = p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.Contains(addresses(next), added)
+	candidates, err = p.CandidatesByHeight(ctx, rp.GetEpochHeight(2))
+	require.NoError(err)
+	require.Equal(5, len(candidates))
+	require.Equal(added, candidates[0].Address)
+
+	epochHeight := rp.GetEpochHeight(2)
+	hook, ok := p.(protocol.EpochBoundaryHook)
+	require.True(ok)
+	require.NoError(hook.OnEpochBoundary(
+		protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: epochHeight}),
+		sm,
+		2,
+		protocol.EpochStart,
+	))
+	bcCtx.Tip.Height = epochHeight
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	active, err := p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.ElementsMatch(addresses(next), addresses(active))
+	data, err := p.ReadState(ctx, sm, []byte("CandidatesByEpoch"), byteutil.Uint64ToBytes(2))
+	require.NoError(err)
+	var l state.CandidateList
+	require.NoError(l.Deserialize(data))
+	require.Equal(addresses(candidates), addresses(l))
+
+	// the admin replaces the delegates alone, which takes effect from next epoch as well
+	adminCtx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: admin})
+	_, err = p.Handle(adminCtx, action.NewUpdateDelegates(2, delegates[:2]), sm)
+	require.NoError(err)
+	active, err = p.DelegatesByEpoch(ctx, 2)
+	require.NoError(err)
+	require.Equal(4, len(active))
+	next, err = p.DelegatesByEpoch(ctx, 3)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{identityset.Address(1).String(), identityset.Address(2).String()},
+		addresses(next),
+	)
+}
+
+func TestSelectActiveBlockProducers(t *testing.T) {
+	require := require.New(t)
+
+	bpMap := map[string]*state.Candidate{
+		"a": {Address: "a"},
+		"b": {Address: "b"},
+		"c": {Address: "c"},
+	}
+	addrs := func(l state.CandidateList) []string {
+		var r []string
+		for _, c := range l {
+			r = append(r, c.Address)
+		}
+		return r
+	}
+	for _, policy := range []string{ShrinkPolicy, ErrorPolicy, PadPolicy} {
+		// there are enough block producers
+		l, err := selectActiveBlockProducers([]string{"c", "a", "b"}, bpMap, 2, policy)
+		require.NoError(err)
+		require.Equal([]string{"c", "a"}, addrs(l))
+	}
+	l, err := selectActiveBlockProducers([]string{"c", "a"}, bpMap, 4, ShrinkPolicy)
+	require.NoError(err)
+	require.Equal([]string{"c", "a"}, addrs(l))
+	_, err = selectActiveBlockProducers([]string{"c", "a"}, bpMap, 4, ErrorPolicy)
+	require.Equal(ErrNotEnoughDelegates, errors.Cause(err))
+	l, err = selectActiveBlockProducers([]string{"c", "a"}, bpMap, 4, PadPolicy)
+	require.NoError(err)
+	require.Equal([]string{"c", "a", "c", "a"}, addrs(l))
+	_, err = selectActiveBlockProducers(nil, bpMap, 4, PadPolicy)
+	require.Equal(ErrNotEnoughDelegates, errors.Cause(err))
+}
+
+func TestCanonicalCandidates(t *testing.T) {
+	require := require.New(t)
+
+	var candidates state.CandidateList
+	for i := 0; i < 6; i++ {
+		votes := big.NewInt(10)
+		if i == 5 {
+			votes = big.NewInt(20)
+		}
+		candidates = append(candidates, &state.Candidate{
+			Address:       identityset.Address(i).String(),
+			Votes:         votes,
+			RewardAddress: identityset.Address(i).String(),
+		})
+	}
+	reversed := make(state.CandidateList, len(candidates))
+	for i, c := range candidates {
+		reversed[len(candidates)-1-i] = c
+	}
+
+	sorted := canonicalCandidates(candidates)
+	require.Equal(sorted, canonicalCandidates(reversed))
+	require.Equal(identityset.Address(5).String(), sorted[0].Address)
+	for i := 2; i < len(sorted); i++ {
+		prev, err := address.FromString(sorted[i-1].Address)
+		require.NoError(err)
+		cur, err := address.FromString(sorted[i].Address)
+		require.NoError(err)
+		require.True(bytes.Compare(prev.Bytes(), cur.Bytes()) < 0)
+	}
+	// the input is left untouched
+	require.Equal(identityset.Address(0).String(), candidates[0].Address)
+
+	// only a part of the equal-vote candidates fit in, so the producers depend on the input order without the
+	// canonical order
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(4, 3, 1)))
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	})
+	p1 := &lifeLongDelegatesProtocol{delegates: candidates}
+	p2 := &lifeLongDelegatesProtocol{delegates: reversed}
+	bp1, err := p1.readActiveBlockProducersByEpoch(ctx, 1, false, false)
+	require.NoError(err)
+	bp2, err := p2.readActiveBlockProducersByEpoch(ctx, 1, false, false)
+	require.NoError(err)
+	require.Equal(3, len(bp1))
+	require.Equal(bp1, bp2)
+}
+
+func TestGetGravityChainStartHeight_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, err := initLifeLongDelegateProtocol(ctrl)
+	require.NoError(err)
+
+	method := []byte("GetGravityChainStartHeight")
+	_, err = p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(721))
+	require.Equal(ErrNoGravityChain, errors.Cause(err))
+	_, err = p.ReadState(ctx, sm, method)
+	require.Error(err)
+
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	bcCtx.Genesis.GravityChainStartHeight = 100
+	ctx = protocol.WithBlockchainCtx(ctx, bcCtx)
+	data, err := p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(721))
+	require.NoError(err)
+	require.Equal(uint64(580), byteutil.BytesToUint64(data))
+}
+
+func TestGravityChainHeight(t *testing.T) {
+	require := require.New(t)
+	g := config.Default.Genesis
+	g.BlockInterval = 10 * time.Second
+	g.GravityChainBlockInterval = 15 * time.Second
+	g.GravityChainStartHeight = 100
+
+	tests := []struct {
+		epochHeight uint64
+		ceiling     uint64
+		expected    uint64
+	}{
+		{0, 0, 100},
+		{1, 0, 100},
+		{721, 0, 580},
+		{1441, 0, 1060},
+		{7201, 0, 4900},
+		{7201, 1000, 1000},
+		{721, 1000, 580},
+	}
+	for _, test := range tests {
+		g.GravityChainCeilingHeight = test.ceiling
+		height, err := gravityChainHeight(g, test.epochHeight)
+		require.NoError(err)
+		require.Equal(test.expected, height)
+	}
+
+	g.GravityChainStartHeight = 0
+	_, err := gravityChainHeight(g, 721)
+	require.Equal(ErrNoGravityChain, errors.Cause(err))
+	g.GravityChainStartHeight = 100
+	g.EnableGravityChainVoting = false
+	_, err = gravityChainHeight(g, 721)
+	require.Equal(ErrNoGravityChain, errors.Cause(err))
+	g.EnableGravityChainVoting = true
+	g.GravityChainBlockInterval = 0
+	_, err = gravityChainHeight(g, 721)
+	require.Error(err)
+}
+
+func TestProbationListByEpoch_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, err := initLifeLongDelegateProtocol(ctrl)
+	require.NoError(err)
+
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	method := []byte("ProbationListByEpoch")
+	for _, epochNum := range []uint64{1, 2} {
+		data, err := p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(epochNum))
+		require.NoError(err)
+		pl := &vote.ProbationList{}
+		require.NoError(pl.Deserialize(data))
+		require.Equal(0, len(pl.ProbationInfo))
+	}
+	_, err = p.ReadState(ctx, sm, method, byteutil.Uint64ToBytes(3))
+	require.Equal(ErrFutureEpoch, errors.Cause(err))
+}
+
+func TestReadStateErrorCode_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	p, ctx, sm, err := initLifeLongDelegateProtocol(ctrl)
+	require.NoError(err)
+
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	for _, test := range []struct {
+		method string
+		args   [][]byte
+		code   protocol.ReadStateErrorCode
+	}{
+		{"UnknownMethod", nil, protocol.UnknownMethod},
+		{"ProductivityByEpoch", nil, protocol.BadArgCount},
+		{"ActiveBlockProducersByHeight", [][]byte{byteutil.Uint64ToBytes(1), byteutil.Uint64ToBytes(1)}, protocol.BadArgCount},
+		{"ProbationListByEpoch", [][]byte{{1, 2, 3}}, protocol.BadArgEncoding},
+		{"CandidatesByEpoch", [][]byte{byteutil.Uint64ToBytes(1), {9}}, protocol.BadArgEncoding},
+		{"ProbationListByEpoch", [][]byte{byteutil.Uint64ToBytes(3)}, protocol.EpochOutOfRange},
+	} {
+		_, err := p.ReadState(ctx, sm, []byte(test.method), test.args...)
+		require.Error(err)
+		code, ok := protocol.ReadStateErrorCodeOf(err)
+		require.True(ok, test.method)
+		require.Equal(test.code, code, test.method)
+	}
+	// the message is kept for the logs
+	_, err = p.ReadState(ctx, sm, []byte("UnknownMethod"))
+	require.Equal("corresponding method isn't found", err.Error())
+
+	// the reads routed by the registry are validated the same way
+	registry := protocol.MustGetBlockchainCtx(ctx).Registry
+	require.NoError(p.Register(registry))
+	_, err = registry.ReadState(ctx, sm, protocolID, []byte("EpochMeta"), []byte{1})
+	code, ok := protocol.ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(protocol.BadArgEncoding, code)
+	_, err = registry.ReadState(ctx, sm, protocolID, []byte("EpochMeta"), byteutil.Uint64ToBytes(1), []byte{1})
+	code, ok = protocol.ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(protocol.BadArgCount, code)
+	expected, err := p.ReadState(ctx, sm, []byte("CandidatesByEpoch"))
+	require.NoError(err)
+	routed, err := registry.ReadState(ctx, sm, protocolID, []byte("CandidatesByEpoch"))
+	require.NoError(err)
+	require.Equal(expected, routed)
+}
+
+func TestDelegatesByEpoch_WithLifeLongSnapshot(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates, WithLifeLongStateReader(sm))
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	g := config.Default.Genesis
+	g.EpochSnapshotHeight = 1
+	g.EpochSnapshotRetention = 2
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	}
+
+	// advance several epochs
+	active := make(map[uint64]state.CandidateList)
+	for epochNum := uint64(1); epochNum <= 4; epochNum++ {
+		bcCtx.Tip.Height = rp.GetEpochHeight(epochNum)
+		ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+		active[epochNum], err = p.DelegatesByEpoch(ctx, epochNum)
+		require.NoError(err)
+		for height := rp.GetEpochHeight(epochNum); height <= rp.GetEpochLastBlockHeight(epochNum); height++ {
+			sm.SetHeight(height)
+			require.NoError(runPostStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height}), p, sm))
+		}
+	}
+
+	// the candidate set changes
+	p, err = NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:4], WithLifeLongStateReader(sm))
+	require.NoError(err)
+	bcCtx.Tip.Height = rp.GetEpochHeight(5)
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	for _, epochNum := range []uint64{3, 4} {
+		delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+		require.NoError(err)
+		require.Equal(active[epochNum], delegates)
+		candidates, _, err := candidatesutil.EpochCandidatesFromDB(sm, epochNum)
+		require.NoError(err)
+		require.Equal(len(config.Default.Genesis.Delegates), len(candidates))
+	}
+	current, err := p.DelegatesByEpoch(ctx, 5)
+	require.NoError(err)
+	require.Equal(4, len(current))
+
+	// the snapshots out of retention are removed, so the producers are computed from the current delegates
+	for _, epochNum := range []uint64{1, 2} {
+		_, _, err := candidatesutil.EpochSnapshotFromDB(sm, epochNum)
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+		require.NoError(err)
+		require.Equal(4, len(delegates))
+	}
+
+	// past epochs are computed without a state reader
+	p, err = NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+	delegates, err := p.DelegatesByEpoch(ctx, 4)
+	require.NoError(err)
+	require.Equal(active[4], delegates)
+}
+
+func TestReadActiveBlockProducersByEpoch_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates, WithLifeLongStateReader(sm))
+	require.NoError(err)
+	lp, ok := p.(*lifeLongDelegatesProtocol)
+	require.True(ok)
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 4, 2)))
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	})
+
+	provisional, err := lp.readActiveBlockProducersByEpoch(ctx, 2, true, false)
+	require.NoError(err)
+	current, err := lp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.NoError(err)
+	require.Equal(provisional, current)
+
+	// the votes of the delegates finalized for the epoch differ from the latest ones
+	finalized := state.CandidateList{
+		{
+			Address:       identityset.Address(5).String(),
+			Votes:         big.NewInt(100),
+			RewardAddress: identityset.Address(5).String(),
+		},
+	}
+	require.NoError(setEpochSnapshot(sm, 2, finalized, finalized, 0))
+	current, err = lp.readActiveBlockProducersByEpoch(ctx, 2, false, false)
+	require.NoError(err)
+	require.Equal(finalized, current)
+	next, err := lp.readActiveBlockProducersByEpoch(ctx, 2, true, false)
+	require.NoError(err)
+	require.Equal(provisional, next)
+	require.NotEqual(current, next)
+}
+
+func TestActiveBlockProducersByHeight_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates, WithLifeLongStateReader(sm))
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	g := config.Default.Genesis
+	g.EpochSnapshotHeight = 1
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	}
+	active := make(map[uint64][]byte)
+	for epochNum := uint64(1); epochNum <= 3; epochNum++ {
+		bcCtx.Tip.Height = rp.GetEpochHeight(epochNum)
+		ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+		delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+		require.NoError(err)
+		active[epochNum], err = delegates.Serialize()
+		require.NoError(err)
+		for height := rp.GetEpochHeight(epochNum); height <= rp.GetEpochLastBlockHeight(epochNum); height++ {
+			sm.SetHeight(height)
+			require.NoError(runPostStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height}), p, sm))
+		}
+	}
+	require.NotEqual(active[1], active[2])
+	require.NotEqual(active[2], active[3])
+
+	// tip is the first block of epoch 3
+	tip := rp.GetEpochHeight(3)
+	bcCtx.Tip.Height = tip
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: tip})
+	for _, test := range []struct {
+		height   uint64
+		epochNum uint64
+	}{
+		{1, 1},
+		{rp.GetEpochLastBlockHeight(1), 1},
+		{rp.GetEpochHeight(2), 2},
+		{rp.GetEpochLastBlockHeight(2), 2},
+		{tip, 3},
+	} {
+		res, err := p.ReadState(ctx, sm, []byte("ActiveBlockProducersByHeight"), byteutil.Uint64ToBytes(test.height))
+		require.NoError(err)
+		require.Equal(active[test.epochNum], res, "height %d", test.height)
+	}
+	_, err = p.ReadState(ctx, sm, []byte("ActiveBlockProducersByHeight"), byteutil.Uint64ToBytes(tip+1))
+	require.Equal(ErrFutureHeight, errors.Cause(err))
+	_, err = p.ReadState(ctx, sm, []byte("ActiveBlockProducersByHeight"), byteutil.Uint64ToBytes(0))
+	require.Error(err)
+	_, err = p.ReadState(ctx, sm, []byte("ActiveBlockProducersByHeight"))
+	require.Error(err)
+}
+
+func TestDelegatesByEpoch_WithLifeLongPastEpoch(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	}
+	served := make(map[uint64]state.CandidateList)
+	for _, epochNum := range []uint64{1, 2, 49} {
+		bcCtx.Tip.Height = rp.GetEpochHeight(epochNum)
+		served[epochNum], err = p.DelegatesByEpoch(protocol.WithBlockchainCtx(context.Background(), bcCtx), epochNum)
+		require.NoError(err)
+	}
+	require.NotEqual(served[1], served[2])
+
+	// the chain is at epoch 50
+	bcCtx.Tip.Height = rp.GetEpochHeight(50) + 3
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	for epochNum, expected := range served {
+		delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+		require.NoError(err)
+		require.Equal(expected, delegates, "epoch %d", epochNum)
+	}
+	_, err = p.DelegatesByEpoch(ctx, 51)
+	require.NoError(err)
+	_, err = p.DelegatesByEpoch(ctx, 52)
+	require.Error(err)
+	_, err = p.DelegatesByEpoch(ctx, 0)
+	require.Error(err)
+}
+
+func TestStart_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+	lp := p.(*lifeLongDelegatesProtocol)
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	bcCtx := protocol.BlockchainCtx{
+		Genesis:  config.Default.Genesis,
+		Registry: registry,
+	}
+
+	// nothing to pre-build before the first block
+	require.NoError(p.Start(protocol.WithBlockchainCtx(context.Background(), bcCtx), nil))
+	require.Equal(0, lp.cache.Len())
+
+	// the active block producers of the tip epoch are pre-built
+	bcCtx.Tip.Height = rp.GetEpochHeight(3) + 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), bcCtx)
+	require.NoError(p.Start(ctx, nil))
+	require.Equal(1, lp.cache.Len())
+	hit := testutil.ToFloat64(pollCacheMtc.WithLabelValues("hit"))
+	_, err = p.DelegatesByEpoch(ctx, 3)
+	require.NoError(err)
+	require.Equal(hit+1, testutil.ToFloat64(pollCacheMtc.WithLabelValues("hit")))
+
+	// a genesis which elects no delegate fails the start
+	bcCtx.Genesis.ElectableVoteThresholdStr = "1000000000000000000000000000000"
+	p, err = NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+	require.Error(p.Start(protocol.WithBlockchainCtx(context.Background(), bcCtx), nil))
+}
+
+func TestCreatePostStates_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates)
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	cfg := config.Default
+	cfg.Genesis.EpochSnapshotHeight = 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  cfg.Genesis,
+		Registry: registry,
+		Tip:      protocol.TipInfo{Height: rp.GetEpochHeight(1)},
+	})
+	sf, err := factory.NewStateDB(cfg, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(protocol.WithBlockCtx(ctx, protocol.BlockCtx{})))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+
+	digest := func(height uint64, post bool) hash.Hash256 {
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+		if post {
+			require.NoError(runPostStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height}), p, ws))
+		}
+		require.NoError(ws.Finalize())
+		h, err := ws.Digest()
+		require.NoError(err)
+		return h
+	}
+	empty := digest(1, false)
+	// no writes at the blocks before the last one of the epoch
+	for height := rp.GetEpochHeight(1); height < rp.GetEpochLastBlockHeight(1); height++ {
+		require.Equal(empty, digest(height, true))
+	}
+	require.NotEqual(empty, digest(rp.GetEpochLastBlockHeight(1), true))
+}
+
+func TestProductivityByEpoch_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:4])
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	g := config.Default.Genesis
+	g.ProductivityKickoutHeight = 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	})
+	active, err := p.DelegatesByEpoch(ctx, 1)
+	require.NoError(err)
+	require.Equal(4, len(active))
+
+	produce := func(height uint64, producer string) {
+		addr, err := address.FromString(producer)
+		require.NoError(err)
+		sm.SetHeight(height)
+		require.NoError(runPreStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+			BlockHeight: height,
+			Producer:    addr,
+		}), p, sm))
+	}
+	read := func(height, epochNum uint64) (*pollpb.ProductivityByEpoch, error) {
+		data, err := p.ReadState(
+			protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height}),
+			sm,
+			[]byte("ProductivityByEpoch"),
+			byteutil.Uint64ToBytes(epochNum),
+		)
+		if err != nil {
+			return nil, err
+		}
+		productivity := &pollpb.ProductivityByEpoch{}
+		require.NoError(proto.Unmarshal(data, productivity))
+		return productivity, nil
+	}
+
+	// the last active delegate misses all its slots in epoch 1
+	lastHeight := rp.GetEpochLastBlockHeight(1)
+	for height := rp.GetEpochHeight(1); height <= lastHeight; height++ {
+		produce(height, active[int(height-1)%3].Address)
+	}
+	expected := map[string]uint64{
+		active[0].Address: 3,
+		active[1].Address: 3,
+		active[2].Address: 2,
+		active[3].Address: 0,
+	}
+	check := func(productivity *pollpb.ProductivityByEpoch) {
+		require.Equal(uint64(1), productivity.EpochNum)
+		require.Equal(uint64(2), productivity.ExpectedCount)
+		require.Equal(len(expected), len(productivity.Counts))
+		for _, c := range productivity.Counts {
+			require.Equal(expected[c.Address], c.Count, c.Address)
+		}
+	}
+	productivity, err := read(lastHeight, 1)
+	require.NoError(err)
+	check(productivity)
+
+	// the counts are archived at the start of next epoch
+	produce(lastHeight+1, active[3].Address)
+	productivity, err = read(lastHeight+1, 1)
+	require.NoError(err)
+	check(productivity)
+	productivity, err = read(lastHeight+1, 2)
+	require.NoError(err)
+	var total uint64
+	for _, c := range productivity.Counts {
+		total += c.Count
+	}
+	require.Equal(uint64(1), total)
+
+	_, err = read(lastHeight+1, 3)
+	require.Equal(ErrFutureEpoch, errors.Cause(err))
+}
+
+func TestProductivityKickout_WithLifeLong(t *testing.T) {
+	require := require.New(t)
+	sm := teststate.New(0)
+	p, err := NewLifeLongDelegatesProtocol(config.Default.Genesis.Delegates[:4], WithLifeLongStateReader(sm))
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(4, 3, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	g := config.Default.Genesis
+	g.ProductivityThreshold = 85
+	g.ProductivityKickoutHeight = 1
+	withTip := func(height uint64) context.Context {
+		return protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+			Genesis:  g,
+			Registry: registry,
+			Tip:      protocol.TipInfo{Height: height},
+		})
+	}
+	active, err := p.DelegatesByEpoch(withTip(0), 1)
+	require.NoError(err)
+	require.Equal(3, len(active))
+	unproductive := active[2].Address
+	var standby string
+	for _, d := range config.Default.Genesis.Delegates[:4] {
+		addr := d.OperatorAddr().String()
+		if addr != active[0].Address && addr != active[1].Address && addr != unproductive {
+			standby = addr
+		}
+	}
+	require.NotEmpty(standby)
+
+	// the last active delegate misses all its slots in epoch 1
+	lastHeight := rp.GetEpochLastBlockHeight(1)
+	for height := rp.GetEpochHeight(1); height <= lastHeight; height++ {
+		addr, err := address.FromString(active[int(height)%2].Address)
+		require.NoError(err)
+		sm.SetHeight(height)
+		require.NoError(runPreStates(protocol.WithBlockCtx(withTip(height-1), protocol.BlockCtx{
+			BlockHeight: height,
+			Producer:    addr,
+		}), p, sm))
+	}
+
+	// the unproductive delegate is replaced by the standby one in epoch 2
+	delegates, err := p.DelegatesByEpoch(withTip(lastHeight), 2)
+	require.NoError(err)
+	require.Equal(3, len(delegates))
+	addrs := []string{delegates[0].Address, delegates[1].Address, delegates[2].Address}
+	require.ElementsMatch([]string{active[0].Address, active[1].Address, standby}, addrs)
+
+	// the counts of epoch 1 can't be used before the epoch ends
+	sm.SetHeight(lastHeight - 1)
+	_, err = p.DelegatesByEpoch(withTip(lastHeight-1), 2)
+	require.Equal(ErrIncompleteProductivity, errors.Cause(err))
+}
@@ -16,8 +16,8 @@ import (
 
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/vote"
+	"github.com/iotexproject/iotex-core/blockchain/block"
 	"github.com/iotexproject/iotex-core/config"
-	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/state"
 )
 
@@ -40,6 +40,67 @@ var ErrDelegatesNotAsExpected = errors.New("delegates are not as expected")
 // ErrDelegatesNotExist is an error that the delegates cannot be prepared
 var ErrDelegatesNotExist = errors.New("delegates cannot be found")
 
+// ErrIncompleteProductivity is an error that the block production counts of the previous epoch aren't complete yet
+var ErrIncompleteProductivity = errors.New("productivity of the previous epoch is incomplete")
+
+// ErrNotEnoughDelegates is an error that there are fewer block producers than the number of delegates
+var ErrNotEnoughDelegates = errors.New("not enough delegates")
+
+// ErrNoElectedDelegates is an error that the election of an epoch filters out all the delegates, which halts the
+// consensus
+var ErrNoElectedDelegates = errors.New("no delegate is elected")
+
+// ErrFutureEpoch is an error that the data of the epoch isn't available yet
+var ErrFutureEpoch = errors.New("epoch is in the future")
+
+// ErrNotBlockProducer is an error that the poll result isn't put by the producer of the block
+var ErrNotBlockProducer = errors.New("only producer could put poll result")
+
+// ErrFutureHeight is an error that the height is beyond the tip
+var ErrFutureHeight = errors.New("height is in the future")
+
+// ErrInvalidEvidence is an error that the double sign evidence doesn't prove a block producer signed two blocks
+var ErrInvalidEvidence = errors.New("invalid double sign evidence")
+
+// ErrStaleEvidence is an error that the double signed blocks aren't in the current epoch
+var ErrStaleEvidence = errors.New("stale double sign evidence")
+
+// ErrDuplicateEvidence is an error that the double sign evidence has been handled
+var ErrDuplicateEvidence = errors.New("duplicate double sign evidence")
+
+// ErrInvalidDelegateFilter is an error that the allow and deny lists of the life long delegates can't be set
+var ErrInvalidDelegateFilter = errors.New("invalid delegate filter")
+
+// ErrInvalidLifeLongDelegate is an error that a life long delegate has invalid or conflicting addresses or votes
+var ErrInvalidLifeLongDelegate = errors.New("invalid life long delegate")
+
+// ErrInvalidDelegateUpdate is an error that the life long delegates can't be replaced by the update
+var ErrInvalidDelegateUpdate = errors.New("invalid delegate update")
+
+// ErrNoGravityChain is an error that the poll protocol doesn't read from a gravity chain
+var ErrNoGravityChain = errors.New("no gravity chain")
+
+// ErrUnknownVersion is an error that the requested encoding version isn't supported
+var ErrUnknownVersion = errors.New("unknown encoding version")
+
+const (
+	// ShrinkPolicy produces blocks with the available block producers if there are fewer than the number of delegates
+	ShrinkPolicy = "shrink"
+	// ErrorPolicy refuses to compute the active block producers if there are fewer than the number of delegates
+	ErrorPolicy = "error"
+	// PadPolicy repeats the block producers round-robin to fill the slots if there are fewer than the number of
+	// delegates
+	PadPolicy = "pad"
+)
+
+const (
+	// ConstantSeedStrategy orders the active block producers of every epoch by the fixed seed
+	ConstantSeedStrategy = "constant"
+	// BlockHashSeedStrategy orders the active block producers of an epoch by the hash of the last block of the
+	// previous epoch mixed with the epoch height, so that the order isn't known until the previous epoch ends
+	BlockHashSeedStrategy = "blockHash"
+)
+
 // CandidatesByHeight returns the candidates of a given height
 type CandidatesByHeight func(protocol.StateReader, uint64) ([]*state.Candidate, error)
 
@@ -62,43 +123,39 @@ type ProductivityByEpoch func(context.Context, uint64) (uint64, map[string]uint6
 type Protocol interface {
 	protocol.Protocol
 	protocol.GenesisStateCreator
+	// Start is called once the state factory is up and before the node joins the consensus, to verify the external
+	// dependencies and warm up the caches with the committed states. A protocol which has nothing to prepare returns
+	// nil.
+	Start(context.Context, protocol.StateReader) error
+	// ReceiveBlock is called once a block is committed, to drop the states cached from the states before it
+	ReceiveBlock(*block.Block) error
 	DelegatesByEpoch(context.Context, uint64) (state.CandidateList, error)
 	CandidatesByHeight(context.Context, uint64) (state.CandidateList, error)
-	// CalculateCandidatesByHeight calculates candidate and returns candidates by chain height
+	// CalculateCandidatesByHeight calculates candidate and returns candidates by chain height. The candidates of a height
+	// in the tip epoch or the next one are calculated, while those of a past epoch are the poll result committed to the
+	// state, so that old blocks are validated with on-chain data only.
 	CalculateCandidatesByHeight(context.Context, uint64) (state.CandidateList, error)
 }
 
+// dependencies returns the IDs of the protocols which the poll protocols depend on, the rolldpos protocol telling the
+// epochs of the heights
+func dependencies() []string {
+	return []string{"rolldpos"}
+}
+
 // FindProtocol finds the registered protocol from registry
 func FindProtocol(registry *protocol.Registry) Protocol {
-	if registry == nil {
-		return nil
-	}
-	p, ok := registry.Find(protocolID)
-	if !ok {
+	var pp Protocol
+	if !protocol.FindAs(registry, protocolID, &pp) {
 		return nil
 	}
-	pp, ok := p.(Protocol)
-	if !ok {
-		log.S().Panic("fail to cast poll protocol")
-	}
 	return pp
 }
 
 // MustGetProtocol return a registered protocol from registry
 func MustGetProtocol(registry *protocol.Registry) Protocol {
-	if registry == nil {
-		log.S().Panic("registry cannot be nil")
-	}
-	p, ok := registry.Find(protocolID)
-	if !ok {
-		log.S().Panic("poll protocol is not registered")
-	}
-
-	pp, ok := p.(Protocol)
-	if !ok {
-		log.S().Panic("fail to cast poll protocol")
-	}
-
+	var pp Protocol
+	protocol.MustFindAs(registry, protocolID, &pp)
 	return pp
 }
 
@@ -124,7 +181,11 @@ func NewProtocol(
 		if uint64(len(delegates)) < genesisConfig.NumDelegates {
 			return nil, errors.New("invalid delegate address in genesis block")
 		}
-		return NewLifeLongDelegatesProtocol(delegates), nil
+		return NewLifeLongDelegatesProtocol(
+			delegates,
+			WithLifeLongStateReader(sr),
+			WithLifeLongCacheSize(cfg.Chain.PollCacheSize),
+		)
 	}
 	var pollProtocol, governance Protocol
 	var err error
@@ -136,8 +197,6 @@ func NewProtocol(
 		electionCommittee,
 		genesisConfig.GravityChainStartHeight,
 		getBlockTimeFunc,
-		genesisConfig.NumCandidateDelegates,
-		genesisConfig.NumDelegates,
 		cfg.Chain.PollInitialCandidatesInterval,
 		sr,
 		productivityByEpoch,
@@ -145,6 +204,7 @@ func NewProtocol(
 		genesisConfig.KickoutEpochPeriod,
 		genesisConfig.KickoutIntensityRate,
 		genesisConfig.UnproductiveDelegateMaxCacheSize,
+		WithPollCacheSize(cfg.Chain.PollCacheSize),
 	); err != nil {
 		return nil, err
 	}
@@ -163,5 +223,19 @@ func NewProtocol(
 	); err != nil {
 		return nil, err
 	}
+	if genesisConfig.GovernanceActivationHeight > 1 {
+		if uint64(len(genesisConfig.Delegates)) < genesisConfig.NumDelegates {
+			return nil, errors.New("invalid delegate address in genesis block")
+		}
+		lifelong, err := NewLifeLongDelegatesProtocol(
+			genesisConfig.Delegates,
+			WithLifeLongStateReader(sr),
+			WithLifeLongCacheSize(cfg.Chain.PollCacheSize),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewHybridProtocol(lifelong, pollProtocol, genesisConfig.GovernanceActivationHeight)
+	}
 	return pollProtocol, nil
 }
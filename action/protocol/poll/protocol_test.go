@@ -17,6 +17,7 @@ import (
 	"github.com/iotexproject/iotex-election/test/mock/mock_committee"
 
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/test/mock/mock_chainmanager"
 )
@@ -74,3 +75,51 @@ func TestMustGetProtocol(t *testing.T) {
 	require.NoError(p.Register(re))
 	require.NotNil(FindProtocol(re))
 }
+
+// runPreStates creates the pre states of the block and delivers its epoch boundaries to the protocol, as the factory
+// does before running the actions of the block
+func runPreStates(ctx context.Context, p Protocol, sm protocol.StateManager) error {
+	if psc, ok := p.(protocol.PreStatesCreator); ok {
+		if err := psc.CreatePreStates(ctx, sm); err != nil {
+			return err
+		}
+	}
+	hook, ok := p.(protocol.EpochBoundaryHook)
+	if !ok {
+		return nil
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	epochNum := rp.EpochOf(blkCtx.BlockHeight)
+	if rp.IsEpochStart(blkCtx.BlockHeight) {
+		if err := hook.OnEpochBoundary(ctx, sm, epochNum, protocol.EpochStart); err != nil {
+			return err
+		}
+	}
+	if rp.IsEpochEnd(blkCtx.BlockHeight) {
+		return hook.OnEpochBoundary(ctx, sm, epochNum, protocol.EpochEnd)
+	}
+	return nil
+}
+
+// runPostStates creates the post states of the block and delivers its epoch boundaries to the protocol, as the
+// factory does after running the actions of the block
+func runPostStates(ctx context.Context, p Protocol, sm protocol.StateManager) error {
+	if psc, ok := p.(protocol.PostStatesCreator); ok {
+		if err := psc.CreatePostStates(ctx, sm); err != nil {
+			return err
+		}
+	}
+	hook, ok := p.(protocol.EpochBoundaryHook)
+	if !ok {
+		return nil
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	if !rp.IsEpochEnd(blkCtx.BlockHeight) {
+		return nil
+	}
+	return hook.OnEpochBoundary(ctx, sm, rp.EpochOf(blkCtx.BlockHeight), protocol.EpochEndPost)
+}
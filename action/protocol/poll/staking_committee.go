@@ -28,6 +28,7 @@ import (
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/execution/evm"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/blockchain/block"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/pkg/prometheustimer"
@@ -156,7 +157,7 @@ func (sc *stakingCommittee) CreateGenesisStates(ctx context.Context, sm protocol
 	return nil
 }
 
-func (sc *stakingCommittee) Start(ctx context.Context) error {
+func (sc *stakingCommittee) Start(ctx context.Context, sr protocol.StateReader) error {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	if bcCtx.Genesis.NativeStakingContractAddress == "" && bcCtx.Genesis.NativeStakingContractCode != "" {
 		caller, _ := address.FromString(nativeStakingContractCreator)
@@ -166,7 +167,11 @@ func (sc *stakingCommittee) Start(ctx context.Context) error {
 		log.L().Info("Loaded native staking contract", zap.String("address", iotxAddr.String()))
 	}
 
-	return nil
+	return sc.governanceStaking.Start(ctx, sr)
+}
+
+func (sc *stakingCommittee) ReceiveBlock(blk *block.Block) error {
+	return sc.governanceStaking.ReceiveBlock(blk)
 }
 
 func (sc *stakingCommittee) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
@@ -177,6 +182,22 @@ func (sc *stakingCommittee) CreatePreStates(ctx context.Context, sm protocol.Sta
 	return nil
 }
 
+func (sc *stakingCommittee) OnEpochBoundary(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	boundary protocol.EpochBoundary,
+) error {
+	if boundary == protocol.EpochEndPost {
+		// the snapshot is of the merged delegates
+		return createEpochSnapshot(ctx, sm, sc, epochNum)
+	}
+	if hook, ok := sc.governanceStaking.(protocol.EpochBoundaryHook); ok {
+		return hook.OnEpochBoundary(ctx, sm, epochNum, boundary)
+	}
+	return nil
+}
+
 func (sc *stakingCommittee) CreatePostSystemActions(ctx context.Context) ([]action.Envelope, error) {
 	return createPostSystemActions(ctx, sc)
 }
@@ -190,11 +211,22 @@ func (sc *stakingCommittee) Handle(ctx context.Context, act action.Action, sm pr
 }
 
 func (sc *stakingCommittee) Validate(ctx context.Context, act action.Action) error {
+	if _, ok := act.(*action.DoubleSignEvidence); ok {
+		return sc.governanceStaking.Validate(ctx, act)
+	}
 	return validate(ctx, sc, act)
 }
 
 // CalculateCandidatesByHeight calculates delegates with native staking and returns merged list
 func (sc *stakingCommittee) CalculateCandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	// the committed poll result of a past epoch has the native votes merged already
+	candidates, persisted, err := persistedCandidatesByHeight(ctx, sc.stateReader, height)
+	if err != nil {
+		return nil, err
+	}
+	if persisted {
+		return candidates, nil
+	}
 	timer := sc.timerFactory.NewTimer("Governance")
 	cand, err := sc.governanceStaking.CalculateCandidatesByHeight(ctx, height)
 	if err != nil {
@@ -202,7 +234,7 @@ func (sc *stakingCommittee) CalculateCandidatesByHeight(ctx context.Context, hei
 	}
 	timer.End()
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
 	// convert to epoch start height
 	if hu.IsPre(config.Cook, rp.GetEpochHeight(rp.GetEpochNum(height))) {
@@ -243,6 +275,21 @@ func (sc *stakingCommittee) ReadState(ctx context.Context, sr protocol.StateRead
 	return sc.governanceStaking.ReadState(ctx, sr, method, args...)
 }
 
+// Name returns the name of the protocol
+func (sc *stakingCommittee) Name() string {
+	return "Staking committee poll"
+}
+
+// Version returns the version of the protocol
+func (sc *stakingCommittee) Version() uint {
+	return 1
+}
+
+// Dependencies returns the IDs of the protocols the protocol depends on
+func (sc *stakingCommittee) Dependencies() []string {
+	return dependencies()
+}
+
 // Register registers the protocol with a unique ID
 func (sc *stakingCommittee) Register(r *protocol.Registry) error {
 	return r.Register(protocolID, sc)
@@ -298,7 +345,7 @@ func (sc *stakingCommittee) persistNativeBuckets(ctx context.Context, receipt *a
 	// Start to write native buckets archive after cook and only when the action is executed successfully
 	blkCtx := protocol.MustGetBlockCtx(ctx)
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	epochHeight := rp.GetEpochHeight(rp.GetEpochNum(blkCtx.BlockHeight))
 	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
 	if hu.IsPre(config.Cook, epochHeight) {
@@ -21,6 +21,7 @@ import (
 	"github.com/iotexproject/iotex-address/address"
 	"github.com/iotexproject/iotex-election/test/mock/mock_committee"
 	"github.com/iotexproject/iotex-election/types"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
@@ -101,8 +102,6 @@ func initConstructStakingCommittee(ctrl *gomock.Controller) (Protocol, context.C
 		committee,
 		uint64(123456),
 		func(uint64) (time.Time, error) { return time.Now(), nil },
-		cfg.Genesis.NumCandidateDelegates,
-		cfg.Genesis.NumDelegates,
 		cfg.Chain.PollInitialCandidatesInterval,
 		sm,
 		func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -221,6 +220,10 @@ func TestHandle_StakingCommittee(t *testing.T) {
 	selp2, err := action.Sign(elp, senderKey)
 	require.NoError(err)
 	require.NotNil(selp2)
+	blkCtx := protocol.MustGetBlockCtx(ctx2)
+	blkCtx.Producer = identityset.Address(27)
+	ctx2 = protocol.WithBlockCtx(ctx2, blkCtx)
+	ctx2 = protocol.WithActionCtx(ctx2, protocol.ActionCtx{Caller: identityset.Address(27)})
 	receipt, err = p.Handle(ctx2, selp2.Action(), sm2)
 	require.NoError(err)
 	require.NotNil(receipt)
@@ -232,6 +235,10 @@ func TestHandle_StakingCommittee(t *testing.T) {
 	require.Equal(candidates[0].Votes, sc2[0].Votes)
 	require.Equal(candidates[1].Address, sc2[1].Address)
 	require.Equal(candidates[1].Votes, sc2[1].Votes)
+	// Case 3: not put by the block producer
+	receipt, err = p.Handle(protocol.WithActionCtx(ctx2, protocol.ActionCtx{Caller: identityset.Address(28)}), selp2.Action(), sm2)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), receipt.Status)
 }
 
 func TestProtocol_Validate_StakingCommittee(t *testing.T) {
@@ -257,14 +264,14 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 	require.NotNil(selp)
 	// Case 1: wrong action type
 	require.NoError(p.Validate(ctx, selp.Action()))
-	// Case 2: Only producer could create this protocol
+	// Case 2: poll result not put by the block producer
 	p2, ctx2, sm2, _, err := initConstructStakingCommittee(ctrl)
 	require.NoError(err)
 	require.NoError(p2.CreateGenesisStates(ctx2, sm2))
 	var sc2 state.CandidateList
 	_, err = sm2.State(&sc2, protocol.LegacyKeyOption(candidatesutil.ConstructLegacyKey(1)))
 	require.NoError(err)
-	act2 := action.NewPutPollResult(1, 1, sc2)
+	act2 := action.NewPutPollResult(1, 721, sc2)
 	elp = bd.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
 		SetAction(act2).Build()
@@ -286,8 +293,8 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 			Caller: caller,
 		},
 	)
-	err = p.Validate(ctx2, selp2.Action())
-	require.True(strings.Contains(err.Error(), "Only producer could create this protocol"))
+	// the producer of the block is checked in handle
+	require.NoError(p2.Validate(ctx2, selp2.Action()))
 	// Case 3: duplicate candidate
 	p3, ctx3, sm3, _, err := initConstructStakingCommittee(ctrl)
 	require.NoError(err)
@@ -295,9 +302,9 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 	var sc3 state.CandidateList
 	_, err = sm3.State(&sc3, protocol.LegacyKeyOption(candidatesutil.ConstructLegacyKey(1)))
 	require.NoError(err)
-	sc3 = append(sc3, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	sc3 = append(sc3, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	act3 := action.NewPutPollResult(1, 1, sc3)
+	sc3 = append(sc3, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	sc3 = append(sc3, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	act3 := action.NewPutPollResult(1, 721, sc3)
 	elp = bd.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
 		SetAction(act3).Build()
@@ -327,8 +334,8 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 	var sc4 state.CandidateList
 	_, err = sm4.State(&sc4, protocol.LegacyKeyOption(candidatesutil.ConstructLegacyKey(1)))
 	require.NoError(err)
-	sc4 = append(sc4, &state.Candidate{"1", big.NewInt(10), "2", nil})
-	act4 := action.NewPutPollResult(1, 1, sc4)
+	sc4 = append(sc4, &state.Candidate{Address: "1", Votes: big.NewInt(10), RewardAddress: "2"})
+	act4 := action.NewPutPollResult(1, 721, sc4)
 	bd4 := &action.EnvelopeBuilder{}
 	elp4 := bd4.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
@@ -358,7 +365,7 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 	var sc5 state.CandidateList
 	_, err = sm5.State(&sc5, protocol.LegacyKeyOption(candidatesutil.ConstructLegacyKey(1)))
 	sc5[0].Votes = big.NewInt(10)
-	act5 := action.NewPutPollResult(1, 1, sc5)
+	act5 := action.NewPutPollResult(1, 721, sc5)
 	bd5 := &action.EnvelopeBuilder{}
 	elp5 := bd5.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
@@ -388,7 +395,7 @@ func TestProtocol_Validate_StakingCommittee(t *testing.T) {
 	var sc6 state.CandidateList
 	_, err = sm6.State(&sc6, protocol.LegacyKeyOption(candidatesutil.ConstructLegacyKey(1)))
 	require.NoError(err)
-	act6 := action.NewPutPollResult(1, 1, sc6)
+	act6 := action.NewPutPollResult(1, 721, sc6)
 	bd6 := &action.EnvelopeBuilder{}
 	elp6 := bd6.SetGasLimit(uint64(100000)).
 		SetGasPrice(big.NewInt(10)).
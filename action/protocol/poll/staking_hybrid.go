@@ -0,0 +1,243 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// NativeCandidates returns the candidates registered on the native staking protocol
+type NativeCandidates func(protocol.StateReader) (state.CandidateList, error)
+
+// stakingHybridProtocol adds the votes of the candidates on the native staking protocol to their votes on the gravity
+// chain during the migration period between the activation and the deactivation heights in genesis. Everything else
+// is served by the gravity chain poll protocol.
+type stakingHybridProtocol struct {
+	gravity          Protocol
+	nativeCandidates NativeCandidates
+	sr               protocol.StateReader
+}
+
+// NewStakingHybridProtocol creates a poll protocol merging the native staking candidates into the candidates of the
+// gravity chain poll protocol
+func NewStakingHybridProtocol(gravity Protocol, nativeCandidates NativeCandidates, sr protocol.StateReader) (Protocol, error) {
+	if gravity == nil || nativeCandidates == nil {
+		return nil, errors.New("both gravity chain poll and native candidates are required")
+	}
+	if sr == nil {
+		return nil, errors.New("state reader is required to read native candidates")
+	}
+	return &stakingHybridProtocol{
+		gravity:          gravity,
+		nativeCandidates: nativeCandidates,
+		sr:               sr,
+	}, nil
+}
+
+func (sh *stakingHybridProtocol) Start(ctx context.Context, sr protocol.StateReader) error {
+	return sh.gravity.Start(ctx, sr)
+}
+
+func (sh *stakingHybridProtocol) ReceiveBlock(blk *block.Block) error {
+	return sh.gravity.ReceiveBlock(blk)
+}
+
+func (sh *stakingHybridProtocol) CreateGenesisStates(ctx context.Context, sm protocol.StateManager) error {
+	return sh.gravity.CreateGenesisStates(ctx, sm)
+}
+
+func (sh *stakingHybridProtocol) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
+	if psc, ok := sh.gravity.(protocol.PreStatesCreator); ok {
+		return psc.CreatePreStates(ctx, sm)
+	}
+	return nil
+}
+
+func (sh *stakingHybridProtocol) OnEpochBoundary(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	boundary protocol.EpochBoundary,
+) error {
+	if boundary == protocol.EpochEndPost {
+		return createEpochSnapshot(ctx, sm, sh, epochNum)
+	}
+	if hook, ok := sh.gravity.(protocol.EpochBoundaryHook); ok {
+		return hook.OnEpochBoundary(ctx, sm, epochNum, boundary)
+	}
+	return nil
+}
+
+func (sh *stakingHybridProtocol) CreatePostSystemActions(ctx context.Context) ([]action.Envelope, error) {
+	// the poll result carries the merged candidates
+	return createPostSystemActions(ctx, sh)
+}
+
+func (sh *stakingHybridProtocol) Handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	return sh.gravity.Handle(ctx, act, sm)
+}
+
+func (sh *stakingHybridProtocol) Validate(ctx context.Context, act action.Action) error {
+	if _, ok := act.(*action.PutPollResult); ok {
+		return validate(ctx, sh, act)
+	}
+	return sh.gravity.Validate(ctx, act)
+}
+
+// CalculateCandidatesByHeight calculates the candidates of the gravity chain poll, and merges the native staking
+// candidates into them if the epoch of the height is in the migration period
+func (sh *stakingHybridProtocol) CalculateCandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	// the committed poll result of a past epoch has the native votes merged already
+	candidates, persisted, err := persistedCandidatesByHeight(ctx, sh.sr, height)
+	if err != nil {
+		return nil, err
+	}
+	if persisted {
+		return candidates, nil
+	}
+	gravity, err := sh.gravity.CalculateCandidatesByHeight(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if !sh.isMigrating(ctx, height) {
+		return gravity, nil
+	}
+	native, err := sh.nativeCandidates(sh.sr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get native staking candidates")
+	}
+	return mergeHybridCandidates(gravity, native), nil
+}
+
+func (sh *stakingHybridProtocol) DelegatesByEpoch(ctx context.Context, epochNum uint64) (state.CandidateList, error) {
+	return sh.gravity.DelegatesByEpoch(ctx, epochNum)
+}
+
+func (sh *stakingHybridProtocol) CandidatesByHeight(ctx context.Context, height uint64) (state.CandidateList, error) {
+	return sh.gravity.CandidatesByHeight(ctx, height)
+}
+
+func (sh *stakingHybridProtocol) ReadState(
+	ctx context.Context,
+	sr protocol.StateReader,
+	method []byte,
+	args ...[]byte,
+) ([]byte, error) {
+	return sh.gravity.ReadState(ctx, sr, method, args...)
+}
+
+// Name returns the name of the protocol
+func (sh *stakingHybridProtocol) Name() string {
+	return "Staking hybrid poll"
+}
+
+// Version returns the version of the protocol
+func (sh *stakingHybridProtocol) Version() uint {
+	return 1
+}
+
+// Dependencies returns the IDs of the protocols the protocol depends on
+func (sh *stakingHybridProtocol) Dependencies() []string {
+	return dependencies()
+}
+
+// Register registers the protocol with a unique ID
+func (sh *stakingHybridProtocol) Register(r *protocol.Registry) error {
+	return r.Register(protocolID, sh)
+}
+
+// ForceRegister registers the protocol with a unique ID and force replacing the previous protocol if it exists
+func (sh *stakingHybridProtocol) ForceRegister(r *protocol.Registry) error {
+	return r.ForceRegister(protocolID, sh)
+}
+
+// isMigrating returns true if the epoch start height of the height is in the migration period
+func (sh *stakingHybridProtocol) isMigrating(ctx context.Context, height uint64) bool {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochHeight := rp.GetEpochHeight(rp.GetEpochNum(height))
+	g := bcCtx.Genesis
+	if g.StakingHybridActivationHeight == 0 || epochHeight < g.StakingHybridActivationHeight {
+		return false
+	}
+	return g.StakingHybridDeactivationHeight == 0 || epochHeight < g.StakingHybridDeactivationHeight
+}
+
+// mergeHybridCandidates merges the native staking candidates into the gravity chain candidates by operator address.
+// The votes of a candidate on both sides are summed, and the reward address and name of its native registration are
+// preferred. A candidate on one side only keeps its own votes. The merged list is in the canonical order, and doesn't
+// depend on the order of either input list, as it is consensus critical.
+func mergeHybridCandidates(gravity, native state.CandidateList) state.CandidateList {
+	gravityByAddr := candidatesByOperator(gravity)
+	nativeByAddr := candidatesByOperator(native)
+	merged := make(state.CandidateList, 0, len(gravityByAddr)+len(nativeByAddr))
+	for addr, g := range gravityByAddr {
+		if n, ok := nativeByAddr[addr]; ok {
+			addRawVotes(g, n)
+			g.Votes.Add(g.Votes, n.Votes)
+			g.RewardAddress = n.RewardAddress
+			if len(n.CanName) != 0 {
+				g.CanName = n.CanName
+			}
+		}
+		merged = append(merged, g)
+	}
+	for addr, n := range nativeByAddr {
+		if _, ok := gravityByAddr[addr]; !ok {
+			merged = append(merged, n)
+		}
+	}
+	return canonicalCandidates(merged)
+}
+
+// candidatesByOperator clones the candidates into a map by operator address. The votes of the candidates sharing an
+// operator address are summed, and the registration of the first of them in a total order is kept.
+func candidatesByOperator(candidates state.CandidateList) map[string]*state.Candidate {
+	sorted := make(state.CandidateList, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Address != sorted[j].Address {
+			return sorted[i].Address < sorted[j].Address
+		}
+		if res := sorted[i].Votes.Cmp(sorted[j].Votes); res != 0 {
+			return res > 0
+		}
+		if sorted[i].RewardAddress != sorted[j].RewardAddress {
+			return sorted[i].RewardAddress < sorted[j].RewardAddress
+		}
+		return bytes.Compare(sorted[i].CanName, sorted[j].CanName) < 0
+	})
+	byAddr := make(map[string]*state.Candidate, len(sorted))
+	for _, c := range sorted {
+		if prev, ok := byAddr[c.Address]; ok {
+			addRawVotes(prev, c)
+			prev.Votes.Add(prev.Votes, c.Votes)
+			continue
+		}
+		byAddr[c.Address] = c.Clone()
+	}
+	return byAddr
+}
+
+// addRawVotes adds the raw votes of c to dst, which are kept unknown if neither of them is known
+func addRawVotes(dst, c *state.Candidate) {
+	if dst.RawVotes == nil && c.RawVotes == nil {
+		return
+	}
+	dst.RawVotes = new(big.Int).Add(dst.RawVotesOrVotes(), c.RawVotesOrVotes())
+}
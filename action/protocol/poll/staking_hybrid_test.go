@@ -0,0 +1,145 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/test/identityset"
+	"github.com/iotexproject/iotex-core/testutil/teststate"
+)
+
+func TestMergeHybridCandidates(t *testing.T) {
+	require := require.New(t)
+	addr := func(i int) string { return identityset.Address(i).String() }
+	gravity := state.CandidateList{
+		{Address: addr(1), Votes: big.NewInt(100), RewardAddress: addr(11), CanName: []byte("g1")},
+		{Address: addr(2), Votes: big.NewInt(50), RewardAddress: addr(12), CanName: []byte("g2")},
+		{Address: addr(3), Votes: big.NewInt(30), RewardAddress: addr(13), CanName: []byte("g3")},
+	}
+	native := state.CandidateList{
+		{Address: addr(2), Votes: big.NewInt(70), RewardAddress: addr(22), CanName: []byte("n2")},
+		{Address: addr(3), Votes: big.NewInt(10), RewardAddress: addr(23)},
+		{Address: addr(4), Votes: big.NewInt(90), RewardAddress: addr(24), CanName: []byte("n4")},
+		// a duplicate registration of the same operator is summed
+		{Address: addr(4), Votes: big.NewInt(5), RewardAddress: addr(25), CanName: []byte("n5")},
+	}
+	expected := state.CandidateList{
+		{Address: addr(2), Votes: big.NewInt(120), RewardAddress: addr(22), CanName: []byte("n2")},
+		{Address: addr(1), Votes: big.NewInt(100), RewardAddress: addr(11), CanName: []byte("g1")},
+		{Address: addr(4), Votes: big.NewInt(95), RewardAddress: addr(24), CanName: []byte("n4")},
+		{Address: addr(3), Votes: big.NewInt(40), RewardAddress: addr(23), CanName: []byte("g3")},
+	}
+	merged := mergeHybridCandidates(gravity, native)
+	require.Equal(expected, merged)
+	// the inputs are untouched
+	require.Equal(big.NewInt(50), gravity[1].Votes)
+	require.Equal(big.NewInt(70), native[0].Votes)
+
+	// the merged list doesn't depend on the order of the inputs
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		g := make(state.CandidateList, len(gravity))
+		copy(g, gravity)
+		n := make(state.CandidateList, len(native))
+		copy(n, native)
+		r.Shuffle(len(g), func(i, j int) { g[i], g[j] = g[j], g[i] })
+		r.Shuffle(len(n), func(i, j int) { n[i], n[j] = n[j], n[i] })
+		require.Equal(expected, mergeHybridCandidates(g, n))
+	}
+
+	require.Equal(canonicalCandidates(gravity), mergeHybridCandidates(gravity, nil))
+	require.Equal(state.CandidateList{}, mergeHybridCandidates(nil, nil))
+
+	// the raw votes are summed, falling back to the votes of a candidate whose raw votes are unknown
+	native[0].RawVotes = big.NewInt(60)
+	native[2].RawVotes = big.NewInt(80)
+	native[3].RawVotes = big.NewInt(4)
+	merged = mergeHybridCandidates(gravity, native)
+	require.Nil(merged[1].RawVotes)
+	require.Nil(merged[3].RawVotes)
+	require.Equal(big.NewInt(110), merged[0].RawVotes)
+	require.Equal(big.NewInt(84), merged[2].RawVotes)
+	require.Equal(big.NewInt(60), native[0].RawVotes)
+}
+
+func TestStakingHybridProtocol(t *testing.T) {
+	require := require.New(t)
+	delegates := config.Default.Genesis.Delegates
+	gravity, err := NewLifeLongDelegatesProtocol(delegates[:4])
+	require.NoError(err)
+	native := state.CandidateList{
+		{Address: delegates[0].OperatorAddr().String(), Votes: big.NewInt(1), RewardAddress: identityset.Address(9).String()},
+		{Address: identityset.Address(10).String(), Votes: big.NewInt(1), RewardAddress: identityset.Address(10).String()},
+	}
+	var nativeErr error
+	nativeCandidates := func(protocol.StateReader) (state.CandidateList, error) {
+		return native, nativeErr
+	}
+	sm := teststate.New(0)
+	_, err = NewStakingHybridProtocol(nil, nativeCandidates, sm)
+	require.Error(err)
+	_, err = NewStakingHybridProtocol(gravity, nil, sm)
+	require.Error(err)
+	_, err = NewStakingHybridProtocol(gravity, nativeCandidates, nil)
+	require.Error(err)
+	p, err := NewStakingHybridProtocol(gravity, nativeCandidates, sm)
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	rp := rolldpos.NewProtocol(36, 4, 2)
+	require.NoError(registry.Register("rolldpos", rp))
+	require.NoError(p.Register(registry))
+	require.Equal(p, FindProtocol(registry))
+	g := config.Default.Genesis
+	// the migration period is from epoch 2 to epoch 3
+	g.StakingHybridActivationHeight = rp.GetEpochHeight(2)
+	g.StakingHybridDeactivationHeight = rp.GetEpochHeight(4)
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	})
+
+	for epoch := uint64(1); epoch <= 4; epoch++ {
+		height := rp.GetEpochLastBlockHeight(epoch)
+		expected, err := gravity.CalculateCandidatesByHeight(ctx, height)
+		require.NoError(err)
+		if epoch == 2 || epoch == 3 {
+			expected = mergeHybridCandidates(expected, native)
+		}
+		cs, err := p.CalculateCandidatesByHeight(ctx, height)
+		require.NoError(err)
+		require.Equal(expected, cs)
+	}
+
+	nativeErr = errors.New("failed to read native candidates")
+	_, err = p.CalculateCandidatesByHeight(ctx, rp.GetEpochHeight(2))
+	require.Error(err)
+	_, err = p.CalculateCandidatesByHeight(ctx, rp.GetEpochHeight(4))
+	require.NoError(err)
+
+	// the deactivation height 0 means the migration never ends
+	g.StakingHybridDeactivationHeight = 0
+	ctx = protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  g,
+		Registry: registry,
+	})
+	nativeErr = nil
+	cs, err := p.CalculateCandidatesByHeight(ctx, rp.GetEpochHeight(10))
+	require.NoError(err)
+	require.Len(cs, 5)
+}
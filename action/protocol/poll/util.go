@@ -7,10 +7,15 @@
 package poll
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
+	"sort"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-address/address"
 	"github.com/iotexproject/iotex-proto/golang/iotextypes"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -18,14 +23,24 @@ import (
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
 	accountutil "github.com/iotexproject/iotex-core/action/protocol/account/util"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
 	"github.com/iotexproject/iotex-core/action/protocol/vote"
 	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
 )
 
+// delegateStatsPageDefaults and maxDelegateStatsPageLimit define the pagination of the delegate statistics
+var (
+	delegateStatsPageDefaults        = protocol.PaginationArgs{Limit: 100}
+	maxDelegateStatsPageLimit uint64 = 1000
+)
+
 func validateDelegates(cs state.CandidateList) error {
 	zero := big.NewInt(0)
 	addrs := map[string]bool{}
@@ -54,6 +69,16 @@ func handle(ctx context.Context, act action.Action, sm protocol.StateManager, pr
 		return nil, nil
 	}
 	zap.L().Debug("Handle PutPollResult Action", zap.Uint64("height", r.Height()))
+	// the producer of the block is only known when the block is run, so the sender is checked here rather than in
+	// validate, and the poll result of anyone else is failed rather than the block
+	if err := validatePollResultSender(ctx); err != nil {
+		return failureReceipt(ctx, protocolAddr, err), nil
+	}
+	if isStrictPollResult(ctx) {
+		if err := validatePollResultHeight(ctx, r); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := setCandidates(ctx, sm, r.Candidates(), r.Height()); err != nil {
 		return nil, errors.Wrap(err, "failed to set candidates")
@@ -67,18 +92,38 @@ func handle(ctx context.Context, act action.Action, sm protocol.StateManager, pr
 	}, nil
 }
 
+// failureReceipt returns the receipt of an action which is rejected by its handler. The action is still included in
+// the block, so that an invalid action sent by anyone doesn't stop the block from being produced.
+func failureReceipt(ctx context.Context, protocolAddr string, err error) *action.Receipt {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	log.L().Debug("Action is rejected", log.Hex("actionHash", actionCtx.ActionHash[:]), zap.Error(err))
+	return &action.Receipt{
+		Status:          uint64(iotextypes.ReceiptStatus_Failure),
+		ActionHash:      actionCtx.ActionHash,
+		BlockHeight:     blkCtx.BlockHeight,
+		GasConsumed:     actionCtx.IntrinsicGas,
+		ContractAddress: protocolAddr,
+	}
+}
+
 func validate(ctx context.Context, p Protocol, act action.Action) error {
 	ppr, ok := act.(*action.PutPollResult)
 	if !ok {
 		return nil
 	}
-	actionCtx := protocol.MustGetActionCtx(ctx)
 	blkCtx := protocol.MustGetBlockCtx(ctx)
 
-	if blkCtx.Producer.String() != actionCtx.Caller.String() {
-		return errors.New("Only producer could create this protocol")
-	}
 	proposedDelegates := ppr.Candidates()
+	if len(proposedDelegates) == 0 {
+		return errors.Wrap(ErrDelegatesNotExist, "empty poll result")
+	}
+	strict := isStrictPollResult(ctx)
+	if strict {
+		if err := validatePollResultHeight(ctx, ppr); err != nil {
+			return err
+		}
+	}
 	if err := validateDelegates(proposedDelegates); err != nil {
 		return err
 	}
@@ -86,6 +131,9 @@ func validate(ctx context.Context, p Protocol, act action.Action) error {
 	if err != nil {
 		return err
 	}
+	if strict {
+		return validatePollResultStrictly(ppr, ds)
+	}
 	if len(ds) != len(proposedDelegates) {
 		msg := fmt.Sprintf(", %d, is not as expected, %d",
 			len(proposedDelegates),
@@ -103,10 +151,113 @@ func validate(ctx context.Context, p Protocol, act action.Action) error {
 	return nil
 }
 
-func createPostSystemActions(ctx context.Context, p Protocol) ([]action.Envelope, error) {
+// isStrictPollResult returns true if the poll result in the block is checked strictly, in its height and in the
+// serialized candidates
+func isStrictPollResult(ctx context.Context) bool {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	h := bcCtx.Genesis.StrictPollResultHeight
+	return h != 0 && blkCtx.BlockHeight >= h
+}
+
+// validatePollResultSender checks that the poll result is put by the producer of the block
+func validatePollResultSender(ctx context.Context) error {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if actionCtx.Caller == nil || blkCtx.Producer == nil || actionCtx.Caller.String() != blkCtx.Producer.String() {
+		return errors.Wrapf(ErrNotBlockProducer, "poll result is put by %v in the block produced by %v", actionCtx.Caller, blkCtx.Producer)
+	}
+	return nil
+}
+
+// validatePollResultHeight checks that the poll result targets the start height of the next epoch
+func validatePollResultHeight(ctx context.Context, ppr *action.PutPollResult) error {
 	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	nextEpochHeight := rp.GetEpochHeight(rp.GetEpochNum(blkCtx.BlockHeight) + 1)
+	if ppr.Height() != nextEpochHeight {
+		return errors.Errorf("poll result height %d is not next epoch height %d", ppr.Height(), nextEpochHeight)
+	}
+	return nil
+}
+
+// validatePollResultStrictly checks that the serialized candidate list of the poll result is the same as the
+// expected one
+func validatePollResultStrictly(ppr *action.PutPollResult, expected state.CandidateList) error {
+	proposed := ppr.Candidates()
+	proposedBytes, err := proposed.Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize proposed delegates")
+	}
+	expectedBytes, err := expected.Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize expected delegates")
+	}
+	if bytes.Equal(proposedBytes, expectedBytes) {
+		return nil
+	}
+	for i, d := range expected {
+		if i >= len(proposed) {
+			return errors.Wrapf(ErrDelegatesNotAsExpected, "entry %d is missing, %s expected", i, d.Address)
+		}
+		if !proposed[i].Equal(d) {
+			return errors.Wrapf(
+				ErrDelegatesNotAsExpected,
+				"entry %d is %s with %s votes, %s with %s votes expected",
+				i,
+				proposed[i].Address,
+				proposed[i].Votes,
+				d.Address,
+				d.Votes,
+			)
+		}
+	}
+	if len(proposed) > len(expected) {
+		return errors.Wrapf(ErrDelegatesNotAsExpected, "entry %d %s is unexpected", len(expected), proposed[len(expected)].Address)
+	}
+	return errors.Wrap(ErrDelegatesNotAsExpected, "serialized delegates are different")
+}
+
+// persistedCandidatesByHeight returns the poll result committed in the epoch of the height, which is the candidate
+// list of the next epoch. It returns false if the height is in the tip epoch or the next one, whose poll result has
+// to be calculated.
+func persistedCandidatesByHeight(ctx context.Context, sr protocol.StateReader, height uint64) (state.CandidateList, bool, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	epochNum := rp.GetEpochNum(height)
+	tipEpochNum := rp.GetEpochNum(bcCtx.Tip.Height)
+	if epochNum >= tipEpochNum {
+		return nil, false, nil
+	}
+	nextEpochNum := epochNum + 1
+	nextEpochHeight := rp.GetEpochHeight(nextEpochNum)
+	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
+	var (
+		candidates state.CandidateList
+		err        error
+	)
+	switch {
+	case hu.IsPre(config.Easter, nextEpochHeight):
+		candidates, err = candidatesutil.CandidatesByHeight(sr, nextEpochHeight)
+	case nextEpochNum == tipEpochNum:
+		// the candidates of the tip epoch are not in an epoch snapshot until the end of the epoch
+		var stateHeight uint64
+		candidates, stateHeight, err = candidatesutil.CandidatesFromDB(sr, false)
+		if err == nil && rp.GetEpochNum(stateHeight) != tipEpochNum {
+			err = ErrInconsistentHeight
+		}
+	default:
+		candidates, _, err = candidatesutil.EpochCandidatesFromDB(sr, nextEpochNum)
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to read the poll result of past epoch %d", epochNum)
+	}
+	return candidates, true, nil
+}
+
+func createPostSystemActions(ctx context.Context, p Protocol) ([]action.Envelope, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	epochNum := rp.GetEpochNum(blkCtx.BlockHeight)
 	lastBlkHeight := rp.GetEpochLastBlockHeight(epochNum)
 	epochHeight := rp.GetEpochHeight(epochNum)
@@ -154,7 +305,7 @@ func setCandidates(
 	height uint64, // epoch start height
 ) error {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	rp := rolldpos.MustGetProtocol(bcCtx.Registry)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
 	epochNum := rp.GetEpochNum(height)
 	if height != rp.GetEpochHeight(epochNum) {
 		return errors.New("put poll result height should be epoch start height")
@@ -196,6 +347,250 @@ func setNextEpochBlacklist(
 	return err
 }
 
+// setProbationList records the probation list applied in the epoch
+func setProbationList(
+	sm protocol.StateManager,
+	epochNum uint64,
+	probationList *vote.ProbationList,
+) error {
+	probationListKey := candidatesutil.ConstructProbationListKey(epochNum)
+	_, err := sm.PutState(probationList, protocol.KeyOption(probationListKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	return err
+}
+
+// probationListFromBlacklist converts the kick-out list applied in the epoch to a probation list
+func probationListFromBlacklist(epochNum uint64, blackList *vote.Blacklist) *vote.ProbationList {
+	probationList := vote.NewProbationList(epochNum, intensityPercentage(blackList.IntensityRate))
+	for addr, count := range blackList.BlacklistInfos {
+		probationList.ProbationInfo[addr] = count
+	}
+	return probationList
+}
+
+// intensityPercentage converts the kick-out intensity rate in [0, 1) to percentage
+func intensityPercentage(intensityRate float64) uint32 {
+	return uint32(intensityRate * 100)
+}
+
+// probationRule returns the escalation rule defined in genesis of the probation list applied from the height
+func probationRule(g genesis.Genesis, height uint64) vote.ProbationRule {
+	return vote.ProbationRule{
+		IntensityStep: g.ProbationIntensityStep,
+		MaxCount:      g.ProbationMaxCount,
+		Consecutive:   g.ProbationEscalationHeight != 0 && height >= g.ProbationEscalationHeight,
+		IntensityCap:  g.ProbationIntensityCap,
+	}
+}
+
+// mergeProbationList derives the probation list of the next epoch from the list of the epoch and its unproductive
+// delegates by the rule of the next epoch. The double signing delegates already put on probation in the next epoch
+// keep their higher counts and streaks.
+func mergeProbationList(
+	sr protocol.StateReader,
+	g genesis.Genesis,
+	epochNum uint64,
+	unproductive []string,
+	rule vote.ProbationRule,
+) (*vote.ProbationList, error) {
+	prev, _, err := candidatesutil.ProbationListFromDB(sr, epochNum)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		prev = vote.NewProbationList(epochNum, intensityPercentage(g.KickoutIntensityRate))
+	default:
+		return nil, err
+	}
+	merged := prev.Merge(epochNum+1, unproductive, rule)
+	penalized, _, err := candidatesutil.ProbationListFromDB(sr, epochNum+1)
+	switch errors.Cause(err) {
+	case nil:
+		for addr, count := range penalized.ProbationInfo {
+			if count > merged.ProbationInfo[addr] {
+				merged.ProbationInfo[addr] = count
+			}
+		}
+		for addr, streak := range penalized.Streaks {
+			if streak > merged.Streaks[addr] {
+				merged.Streaks[addr] = streak
+			}
+		}
+	case state.ErrStateNotExist:
+	default:
+		return nil, err
+	}
+	return merged, nil
+}
+
+// updateDelegateStats aggregates the production of the epoch and the probation list applied in it into the
+// reliability of the delegates, and drops the statistics of the delegates which fall out of the retention
+func updateDelegateStats(
+	sm protocol.StateManager,
+	g genesis.Genesis,
+	epochNum uint64,
+	expectedNumBlks uint64,
+	produce map[string]uint64,
+	unproductive []string,
+) error {
+	stats, _, err := candidatesutil.DelegateStatsFromDB(sm)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		stats = vote.NewDelegateStats()
+	default:
+		return err
+	}
+	probationList, _, err := candidatesutil.ProbationListFromDB(sm, epochNum)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		probationList = nil
+	default:
+		return err
+	}
+	stats.Add(epochNum, expectedNumBlks, produce, probationList, unproductive, g.DelegateStatsRetention)
+	statsKey := candidatesutil.ConstructKey(candidatesutil.DelegateStatsKey)
+	_, err = sm.PutState(stats, protocol.KeyOption(statsKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	return err
+}
+
+// readDelegateStats returns the page of the reliability of the delegates in ascending order of address, defined by
+// the pagination argument
+func readDelegateStats(sr protocol.StateReader, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	pagination, err := protocol.ParsePagination(args, delegateStatsPageDefaults, maxDelegateStatsPageLimit)
+	if err != nil {
+		return nil, err
+	}
+	stats, _, err := candidatesutil.DelegateStatsFromDB(sr)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		stats = vote.NewDelegateStats()
+	default:
+		return nil, err
+	}
+	addrs := stats.Addresses()
+	page := &pollpb.DelegateStats{
+		EpochNum: stats.Epoch,
+		Total:    uint64(len(addrs)),
+	}
+	start, end := protocol.Paginate(len(addrs), pagination)
+	for _, addr := range addrs[start:end] {
+		stat := stats.Stats[addr]
+		page.Stats = append(page.Stats, &pollpb.DelegateStat{
+			Address:          addr,
+			ExpectedSlots:    stat.ExpectedSlots,
+			ProducedSlots:    stat.ProducedSlots,
+			ProbationEpochs:  stat.ProbationEpochs,
+			LastOffenseEpoch: stat.LastOffenseEpoch,
+			LastSeenEpoch:    stat.LastSeenEpoch,
+		})
+	}
+	data, err := proto.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Page(data, page.Total)
+}
+
+// readProbationList returns the probation list applied in the epoch, which is empty before the kick-out is activated
+// at Easter height
+func readProbationList(
+	ctx context.Context,
+	sr protocol.StateReader,
+	epochNum uint64,
+	intensityRate float64,
+) (*vote.ProbationList, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	if epochNum > tipEpochNum+1 {
+		return nil, protocol.NewReadStateError(
+			protocol.EpochOutOfRange,
+			errors.Wrapf(ErrFutureEpoch, "epoch %d is after next epoch %d", epochNum, tipEpochNum+1),
+		)
+	}
+	hu := config.NewHeightUpgrade(&bcCtx.Genesis)
+	if hu.IsPre(config.Easter, rp.GetEpochHeight(epochNum)) {
+		return vote.NewProbationList(epochNum, intensityPercentage(intensityRate)), nil
+	}
+	probationList, _, err := candidatesutil.ProbationListFromDB(sr, epochNum)
+	if err != nil {
+		if epochNum > tipEpochNum && errors.Cause(err) == state.ErrStateNotExist {
+			// the probation list of next epoch is calculated at the last block of current epoch
+			return nil, protocol.NewReadStateError(
+				protocol.EpochOutOfRange,
+				errors.Wrapf(ErrFutureEpoch, "probation list of epoch %d isn't calculated yet", epochNum),
+			)
+		}
+		return nil, err
+	}
+	return probationList, nil
+}
+
+// createEpochSnapshot persists the final active block producers and candidates of the epoch after running the actions
+// of its last block, and removes the snapshot which falls out of the retention
+func createEpochSnapshot(ctx context.Context, sm protocol.StateManager, p Protocol, epochNum uint64) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if h := bcCtx.Genesis.EpochSnapshotHeight; h == 0 || blkCtx.BlockHeight < h {
+		return nil
+	}
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	delegates, err := p.DelegatesByEpoch(ctx, epochNum)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get delegates of epoch %d", epochNum)
+	}
+	candidates, err := p.CandidatesByHeight(ctx, rp.GetEpochHeight(epochNum))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get candidates of epoch %d", epochNum)
+	}
+	return setEpochSnapshot(sm, epochNum, delegates, candidates, bcCtx.Genesis.EpochSnapshotRetention)
+}
+
+// setEpochSnapshot persists the active block producers and candidates of the epoch, and removes the snapshot which
+// falls out of the retention
+func setEpochSnapshot(
+	sm protocol.StateManager,
+	epochNum uint64,
+	delegates state.CandidateList,
+	candidates state.CandidateList,
+	retention uint64,
+) error {
+	snapshotKey := candidatesutil.ConstructEpochSnapshotKey(epochNum)
+	if _, err := sm.PutState(&delegates, protocol.KeyOption(snapshotKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+		return errors.Wrapf(err, "failed to put delegates snapshot of epoch %d", epochNum)
+	}
+	candidatesKey := candidatesutil.ConstructEpochCandidatesKey(epochNum)
+	// the raw votes of the candidates are kept along with them
+	snapshot := candidatesutil.EpochCandidates(candidates)
+	if _, err := sm.PutState(&snapshot, protocol.KeyOption(candidatesKey[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+		return errors.Wrapf(err, "failed to put candidates snapshot of epoch %d", epochNum)
+	}
+	if retention == 0 || epochNum <= retention {
+		return nil
+	}
+	expiredEpochNum := epochNum - retention
+	if _, _, err := candidatesutil.EpochSnapshotFromDB(sm, expiredEpochNum); err != nil {
+		if errors.Cause(err) == state.ErrStateNotExist {
+			return nil
+		}
+		return err
+	}
+	for _, key := range []hash.Hash256{
+		candidatesutil.ConstructEpochSnapshotKey(expiredEpochNum),
+		candidatesutil.ConstructEpochCandidatesKey(expiredEpochNum),
+	} {
+		if _, err := sm.DelState(protocol.KeyOption(key[:]), protocol.NamespaceOption(protocol.SystemNamespace)); err != nil {
+			return errors.Wrapf(err, "failed to delete snapshot of epoch %d", expiredEpochNum)
+		}
+	}
+	return nil
+}
+
 // setUnproductiveDelegates sets the upd struct with updkey
 func setUnproductiveDelegates(
 	sm protocol.StateManager,
@@ -206,6 +601,399 @@ func setUnproductiveDelegates(
 	return err
 }
 
+// sortSeed returns the seed ordering the active block producers of the epoch starting at the height. The fixed seed
+// is used before the epochs reach the sort seed height, so that the order of the past epochs is kept.
+func sortSeed(ctx context.Context, epochHeight uint64) ([]byte, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	g := bcCtx.Genesis
+	if g.SortSeedHeight == 0 || epochHeight < g.SortSeedHeight {
+		return crypto.CryptoSeed, nil
+	}
+	switch g.SortSeedStrategy {
+	case ConstantSeedStrategy, "":
+		return crypto.CryptoSeed, nil
+	case BlockHashSeedStrategy:
+		if bcCtx.GetBlockHash == nil {
+			return nil, errors.New("block hash isn't accessible to derive the sort seed")
+		}
+		blkHash, err := bcCtx.GetBlockHash(epochHeight - 1)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get hash of block %d to derive the sort seed", epochHeight-1)
+		}
+		seed := hash.Hash256b(append(blkHash[:], byteutil.Uint64ToBytesBigEndian(epochHeight)...))
+		return seed[:], nil
+	default:
+		return nil, errors.Errorf("invalid sort seed strategy %s", g.SortSeedStrategy)
+	}
+}
+
+// selectActiveBlockProducers returns the first num block producers of the sorted list. If there are fewer, the
+// shortage is handled by the policy.
+func selectActiveBlockProducers(
+	sorted []string,
+	blockProducerMap map[string]*state.Candidate,
+	num int,
+	policy string,
+) (state.CandidateList, error) {
+	length := num
+	if len(sorted) < num {
+		log.L().Warn(
+			"the number of block producer is less than expected",
+			zap.Int("actual block producer", len(sorted)),
+			zap.Int("expected", num),
+			zap.String("policy", policy),
+		)
+		switch policy {
+		case ShrinkPolicy, "":
+			length = len(sorted)
+		case ErrorPolicy:
+			return nil, errors.Wrapf(ErrNotEnoughDelegates, "%d block producers, expected %d", len(sorted), num)
+		case PadPolicy:
+			if len(sorted) == 0 {
+				return nil, errors.Wrap(ErrNotEnoughDelegates, "no block producer to pad with")
+			}
+		default:
+			return nil, errors.Errorf("invalid delegate shortage policy %s", policy)
+		}
+	}
+	var activeBlockProducers state.CandidateList
+	for i := 0; i < length; i++ {
+		activeBlockProducers = append(activeBlockProducers, blockProducerMap[sorted[i%len(sorted)]])
+	}
+	return activeBlockProducers, nil
+}
+
+// noElectedDelegatesError returns the error that no delegate is elected in the epoch, along with the number of the
+// delegates left at each stage of the election, so that the stage which filters out all of them can be told
+func noElectedDelegatesError(epochNum uint64, candidates, afterProbation, afterProductivity int) error {
+	return errors.Wrapf(
+		ErrNoElectedDelegates,
+		"epoch %d has %d candidates, %d of them after probation and %d after productivity",
+		epochNum,
+		candidates,
+		afterProbation,
+		afterProductivity,
+	)
+}
+
+// countProductivity counts the block being produced in the block production counts of the epoch. At the start of an
+// epoch, the counts of the previous epoch are kept for the kick-out, and the counts restart from zero for each of the
+// active block producers of the epoch.
+func countProductivity(
+	ctx context.Context,
+	sm protocol.StateManager,
+	epochNum uint64,
+	activeBlockProducers func() (state.CandidateList, error),
+) error {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	pd, _, err := candidatesutil.ProductivityFromDB(sm, false)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		pd = nil
+	default:
+		return err
+	}
+	if pd == nil || pd.Epoch() != epochNum {
+		if pd != nil {
+			if err := setProductivity(sm, pd, true); err != nil {
+				return errors.Wrapf(err, "failed to keep productivity of epoch %d", pd.Epoch())
+			}
+		}
+		bps, err := activeBlockProducers()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get active block producers of epoch %d", epochNum)
+		}
+		producers := make([]string, 0, len(bps))
+		for _, bp := range bps {
+			producers = append(producers, bp.Address)
+		}
+		pd = vote.NewProductivity(epochNum, producers)
+	}
+	pd.Add(blkCtx.Producer.String())
+	return setProductivity(sm, pd, false)
+}
+
+// readProductivityByEpoch returns the block production counts of the epoch, which is either current or previous
+// epoch, along with the number of blocks expected from each active block producer
+func readProductivityByEpoch(ctx context.Context, sr protocol.StateReader, epochNum uint64) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	if epochNum > tipEpochNum {
+		return nil, protocol.NewReadStateError(
+			protocol.EpochOutOfRange,
+			errors.Wrapf(ErrFutureEpoch, "epoch %d is after tip epoch %d", epochNum, tipEpochNum),
+		)
+	}
+	if epochNum+1 < tipEpochNum {
+		return nil, protocol.ReadStateErrorf(protocol.EpochOutOfRange, "productivity before previous epoch isn't available")
+	}
+	pd, _, err := candidatesutil.ProductivityFromDB(sr, epochNum != tipEpochNum)
+	if err != nil {
+		return nil, err
+	}
+	if pd.Epoch() != epochNum {
+		return nil, errors.Wrapf(state.ErrStateNotExist, "productivity of epoch %d isn't counted", epochNum)
+	}
+	counts := pd.Proto().Counts
+	productivity := &pollpb.ProductivityByEpoch{
+		EpochNum: epochNum,
+		Counts:   make([]*pollpb.ProducerCount, 0, len(counts)),
+	}
+	for _, c := range counts {
+		productivity.Counts = append(productivity.Counts, &pollpb.ProducerCount{
+			Address: c.Address,
+			Count:   c.Count,
+		})
+	}
+	if len(counts) != 0 {
+		numBlocks := rp.GetEpochLastBlockHeight(epochNum) - rp.GetEpochHeight(epochNum) + 1
+		productivity.ExpectedCount = numBlocks / uint64(len(counts))
+	}
+	return proto.Marshal(productivity)
+}
+
+// readEpochMeta returns the boundaries and the committee sizes of the epoch, which is no later than next epoch, and
+// whether it is a past, current or future epoch
+func readEpochMeta(ctx context.Context, epochNum uint64) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	tipEpochNum := rp.GetEpochNum(blkCtx.BlockHeight)
+	if epochNum == 0 {
+		return nil, protocol.ReadStateErrorf(protocol.EpochOutOfRange, "epoch starts from 1")
+	}
+	if epochNum > tipEpochNum+1 {
+		return nil, protocol.NewReadStateError(
+			protocol.EpochOutOfRange,
+			errors.Wrapf(ErrFutureEpoch, "epoch %d is after next epoch %d", epochNum, tipEpochNum+1),
+		)
+	}
+	status := pollpb.EpochMeta_CURRENT
+	switch {
+	case epochNum < tipEpochNum:
+		status = pollpb.EpochMeta_PAST
+	case epochNum > tipEpochNum:
+		status = pollpb.EpochMeta_FUTURE
+	}
+	startHeight := rp.GetEpochHeight(epochNum)
+	endHeight := rp.GetEpochLastBlockHeight(epochNum)
+	return proto.Marshal(&pollpb.EpochMeta{
+		EpochNum:              epochNum,
+		StartHeight:           startHeight,
+		EndHeight:             endHeight,
+		NumBlocks:             endHeight - startHeight + 1,
+		NumDelegates:          rp.NumDelegatesAt(epochNum),
+		NumCandidateDelegates: rp.NumCandidateDelegatesAt(epochNum),
+		Status:                status,
+	})
+}
+
+// setProductivity sets the block production counts of current epoch, or of previous epoch if prev is true
+func setProductivity(
+	sm protocol.StateManager,
+	productivity *vote.Productivity,
+	prev bool,
+) error {
+	productivityKey := candidatesutil.ConstructKey(candidatesutil.CurProductivityKey)
+	if prev {
+		productivityKey = candidatesutil.ConstructKey(candidatesutil.PrevProductivityKey)
+	}
+	_, err := sm.PutState(productivity, protocol.KeyOption(productivityKey[:]), protocol.NamespaceOption(protocol.SystemNamespace))
+	return err
+}
+
+// readActiveBlockProducersByHeight returns the serialized active block producers of the epoch of the given height.
+// They are calculated for the tip epoch, and loaded from the epoch snapshot for the past epochs.
+func readActiveBlockProducersByHeight(
+	ctx context.Context,
+	sr protocol.StateReader,
+	p Protocol,
+	height uint64,
+) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	if height == 0 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgEncoding, "invalid height 0")
+	}
+	if height > blkCtx.BlockHeight {
+		return nil, protocol.NewReadStateError(
+			protocol.EpochOutOfRange,
+			errors.Wrapf(ErrFutureHeight, "height %d is beyond tip height %d", height, blkCtx.BlockHeight),
+		)
+	}
+	epochNum := rp.GetEpochNum(height)
+	var blockProducers state.CandidateList
+	var err error
+	if epochNum == rp.GetEpochNum(blkCtx.BlockHeight) {
+		blockProducers, err = p.DelegatesByEpoch(ctx, epochNum)
+	} else {
+		blockProducers, _, err = candidatesutil.EpochSnapshotFromDB(sr, epochNum)
+		if errors.Cause(err) == state.ErrStateNotExist {
+			// the snapshot isn't persisted or falls out of the retention
+			err = protocol.NewReadStateError(protocol.ArchiveUnavailable, err)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get active block producers of epoch %d", epochNum)
+	}
+	return blockProducers.Serialize()
+}
+
+// uint64Arg decodes the argument of the index, which is an uint64 in 8 bytes
+func uint64Arg(args [][]byte, i int) (uint64, error) {
+	if i >= len(args) {
+		return 0, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	if len(args[i]) != 8 {
+		return 0, protocol.ReadStateErrorf(protocol.BadArgEncoding, "invalid uint64 argument of %d bytes", len(args[i]))
+	}
+	return byteutil.BytesToUint64(args[i]), nil
+}
+
+// tipEpochNum returns the number of the epoch of the block in the context
+func tipEpochNum(ctx context.Context) uint64 {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	return rp.GetEpochNum(blkCtx.BlockHeight)
+}
+
+// checkTipEpochArg checks that the optional epoch argument is the tip epoch, as a non-archive node only keeps the data
+// of the tip epoch
+func checkTipEpochArg(args [][]byte, tipEpochNum uint64) error {
+	if len(args) == 0 {
+		return nil
+	}
+	epochNum, err := uint64Arg(args, 0)
+	if err != nil {
+		return err
+	}
+	if epochNum != tipEpochNum {
+		return protocol.ReadStateErrorf(protocol.ArchiveUnavailable, "previous epoch data isn't available with non-archive node")
+	}
+	return nil
+}
+
+// serializeNextEpochCandidates serializes the tentative active block producers of next epoch, which are provisional
+// until the last block of current epoch
+func serializeNextEpochCandidates(ctx context.Context, nextEpochNum uint64, candidates state.CandidateList) ([]byte, error) {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	lastHeight := rp.GetEpochLastBlockHeight(nextEpochNum - 1)
+	var remaining uint64
+	if blkCtx.BlockHeight < lastHeight {
+		remaining = lastHeight - blkCtx.BlockHeight
+	}
+	return proto.Marshal(&pollpb.NextEpochCandidates{
+		EpochNum:        nextEpochNum,
+		Candidates:      candidates.Proto(),
+		Provisional:     remaining != 0,
+		RemainingBlocks: remaining,
+	})
+}
+
+// canonicalCandidates returns a copy of the candidates sorted by votes in descending order and then by address bytes
+// in ascending order, so that the candidates with equal votes have the same relative order on every node, no matter
+// in which order the list was assembled
+func canonicalCandidates(candidates state.CandidateList) state.CandidateList {
+	addrBytes := make(map[string][]byte, len(candidates))
+	for _, c := range candidates {
+		if addr, err := address.FromString(c.Address); err == nil {
+			addrBytes[c.Address] = addr.Bytes()
+		} else {
+			addrBytes[c.Address] = []byte(c.Address)
+		}
+	}
+	sorted := make(state.CandidateList, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if res := sorted[i].Votes.Cmp(sorted[j].Votes); res != 0 {
+			return res > 0
+		}
+		return bytes.Compare(addrBytes[sorted[i].Address], addrBytes[sorted[j].Address]) < 0
+	})
+	return sorted
+}
+
+// electableCandidates returns the candidates whose votes are no less than the threshold in their original order, so
+// that the candidates with too few votes never fill the committee slots
+func electableCandidates(candidates state.CandidateList, threshold *big.Int) state.CandidateList {
+	if threshold == nil || threshold.Sign() <= 0 {
+		return candidates
+	}
+	electable := make(state.CandidateList, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Votes.Cmp(threshold) >= 0 {
+			electable = append(electable, c)
+		}
+	}
+	return electable
+}
+
+// readUnproductiveDelegates returns the delegates whose productivity in the epoch before the given one is lower than
+// the threshold. The productivity is read from the block production counts in the state, so that every node derives
+// the same result. An error is returned if the counting didn't cover the whole previous epoch, unless the result is
+// provisional.
+func readUnproductiveDelegates(
+	ctx context.Context,
+	sr protocol.StateReader,
+	epochNum uint64,
+	readFromNext bool,
+	provisional bool,
+	threshold uint64,
+) ([]string, error) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	rp := rolldpos.MustGetProtocol(protocol.MustGetRegistry(ctx))
+	kickoutHeight := bcCtx.Genesis.ProductivityKickoutHeight
+	if kickoutHeight == 0 || epochNum <= 1 || rp.GetEpochHeight(epochNum-1) < kickoutHeight {
+		return nil, nil
+	}
+	// before the shift at the start of the epoch, the counts of the previous epoch are still the current ones
+	pd, stateHeight, err := candidatesutil.ProductivityFromDB(sr, !readFromNext)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get productivity of epoch %d", epochNum-1)
+	}
+	if pd.Epoch() != epochNum-1 {
+		return nil, errors.Wrapf(
+			ErrInconsistentHeight,
+			"productivity of epoch %d is read for epoch %d",
+			pd.Epoch(),
+			epochNum-1,
+		)
+	}
+	if readFromNext && !provisional && stateHeight < rp.GetEpochLastBlockHeight(epochNum-1) {
+		return nil, errors.Wrapf(ErrIncompleteProductivity, "state height %d is in epoch %d", stateHeight, epochNum-1)
+	}
+	return pd.Unproductive(threshold), nil
+}
+
+// excludeUnproductiveDelegates removes the unproductive delegates from the block producers. If less than the given
+// number of block producers are left, the removed ones are added back in their original order.
+func excludeUnproductiveDelegates(blockProducers []string, unproductive []string, num int) []string {
+	if len(unproductive) == 0 {
+		return blockProducers
+	}
+	unproductiveMap := make(map[string]bool, len(unproductive))
+	for _, addr := range unproductive {
+		unproductiveMap[addr] = true
+	}
+	qualified := make([]string, 0, len(blockProducers))
+	for _, addr := range blockProducers {
+		if !unproductiveMap[addr] {
+			qualified = append(qualified, addr)
+		}
+	}
+	for _, addr := range blockProducers {
+		if len(qualified) >= num {
+			break
+		}
+		if unproductiveMap[addr] {
+			qualified = append(qualified, addr)
+		}
+	}
+	return qualified
+}
+
 // shiftCandidates updates current data with next data of candidate list
 func shiftCandidates(sm protocol.StateManager) (uint64, error) {
 	zap.L().Debug("Shift candidatelist from next key to current key")
@@ -257,3 +1045,32 @@ func shiftKickoutList(sm protocol.StateManager) (uint64, error) {
 	}
 	return stateHeight, nil
 }
+
+// gravityChainHeight derives the gravity chain height corresponding to the epoch height, by converting the time
+// elapsed since genesis with the block intervals of the two chains, clamped to the known range of gravity chain
+func gravityChainHeight(g genesis.Genesis, epochHeight uint64) (uint64, error) {
+	if !g.EnableGravityChainVoting || g.GravityChainStartHeight == 0 {
+		return 0, ErrNoGravityChain
+	}
+	if g.BlockInterval <= 0 || g.GravityChainBlockInterval <= 0 {
+		return 0, errors.Errorf(
+			"invalid block intervals %s and %s",
+			g.BlockInterval,
+			g.GravityChainBlockInterval,
+		)
+	}
+	var elapsed uint64
+	if epochHeight > 1 {
+		elapsed = epochHeight - 1
+	}
+	offset := new(big.Int).Mul(new(big.Int).SetUint64(elapsed), big.NewInt(int64(g.BlockInterval)))
+	offset.Div(offset, big.NewInt(int64(g.GravityChainBlockInterval)))
+	height := new(big.Int).Add(offset, new(big.Int).SetUint64(g.GravityChainStartHeight))
+	if g.GravityChainCeilingHeight != 0 && height.Cmp(new(big.Int).SetUint64(g.GravityChainCeilingHeight)) > 0 {
+		return g.GravityChainCeilingHeight, nil
+	}
+	if !height.IsUint64() {
+		return 0, errors.Errorf("gravity chain height of epoch height %d overflows", epochHeight)
+	}
+	return height.Uint64(), nil
+}
@@ -17,6 +17,8 @@ import (
 var (
 	// ErrUnimplemented indicates a method is not implemented yet
 	ErrUnimplemented = errors.New("method is unimplemented")
+	// ErrReadOnly indicates an irreversible operation is refused in running a simulated action
+	ErrReadOnly = errors.New("operation is refused in read-only mode")
 )
 
 const (
@@ -31,9 +33,36 @@ type Protocol interface {
 	ReadState(context.Context, StateReader, []byte, ...[]byte) ([]byte, error)
 	Register(*Registry) error
 	ForceRegister(*Registry) error
+	// Name returns the human-readable name of the protocol, which is unique among the registered protocols unless
+	// it's empty
+	Name() string
+	// Version returns the version of the protocol, which is bumped once the schema of its states changes
+	Version() uint
 }
 
-// GenesisStateCreator creates some genesis states
+// Metadata implements the Name and Version of Protocol, which the existing protocols embed to implement them
+type Metadata struct {
+	name    string
+	version uint
+}
+
+// NewMetadata creates the metadata of the name and the version
+func NewMetadata(name string, version uint) Metadata {
+	return Metadata{name: name, version: version}
+}
+
+// Name returns the name of the protocol
+func (m Metadata) Name() string {
+	return m.name
+}
+
+// Version returns the version of the protocol
+func (m Metadata) Version() uint {
+	return m.version
+}
+
+// GenesisStateCreator creates some genesis states. The genesis states of the protocols it depends on, if it's a
+// Dependent, are created before its own ones.
 type GenesisStateCreator interface {
 	CreateGenesisStates(context.Context, StateManager) error
 }
@@ -43,6 +72,56 @@ type PreStatesCreator interface {
 	CreatePreStates(context.Context, StateManager) error
 }
 
+// PostStatesCreator creates states after running the actions of a block
+type PostStatesCreator interface {
+	CreatePostStates(context.Context, StateManager) error
+}
+
+// Starter is a protocol which prepares itself with the committed states, once the chain catches up with its tip and
+// before the chain runs any new block
+type Starter interface {
+	Start(context.Context, StateReader) error
+}
+
+// Dependent is a protocol which requires other protocols, which are started and create their genesis states before
+// it does
+type Dependent interface {
+	// Dependencies returns the IDs of the protocols it depends on
+	Dependencies() []string
+}
+
+// Migrator is a protocol whose stored states are upgraded when the node starts, if they are of an earlier version than
+// the protocol
+type Migrator interface {
+	// StateVersion returns the version of the stored states
+	StateVersion(context.Context, StateReader) (uint, error)
+	// Migrate upgrades the stored states of the version to the version of the protocol
+	Migrate(context.Context, StateReader, uint) error
+}
+
+// Stopper is a protocol which releases its resources when the chain stops
+type Stopper interface {
+	Stop(context.Context) error
+}
+
+// EpochBoundary is the boundary of an epoch which a block is at
+type EpochBoundary int
+
+const (
+	// EpochStart is the first block of an epoch, before running the actions of the block
+	EpochStart EpochBoundary = iota
+	// EpochEnd is the last block of an epoch, before running the actions of the block
+	EpochEnd
+	// EpochEndPost is the last block of an epoch, after running the actions of the block
+	EpochEndPost
+)
+
+// EpochBoundaryHook is called at the boundaries of each epoch, right after the pre (or post) states of the protocol
+// are created. If the block both starts and ends an epoch, EpochStart is delivered before EpochEnd.
+type EpochBoundaryHook interface {
+	OnEpochBoundary(ctx context.Context, sm StateManager, epochNum uint64, boundary EpochBoundary) error
+}
+
 // PostSystemActionsCreator creates a list of system actions to be appended to block actions
 type PostSystemActionsCreator interface {
 	CreatePostSystemActions(context.Context) ([]action.Envelope, error)
@@ -60,7 +139,8 @@ type ActionEnvelopeValidator interface {
 
 // ActionHandler is the interface for the action handlers. For each incoming action, the assembled actions will be
 // called one by one to process it. ActionHandler implementation is supposed to parse the sub-type of the action to
-// decide if it wants to handle this action or not.
+// decide if it wants to handle this action or not. An error wrapped with a status by WrapWithStatus fails the action
+// with the failure receipt of the status, and any other error fails the block.
 type ActionHandler interface {
 	Handle(context.Context, action.Action, StateManager) (*action.Receipt, error)
 }
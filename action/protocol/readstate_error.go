@@ -0,0 +1,71 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ReadStateErrorCode is the code of an error caused by the request of ReadState rather than the node
+type ReadStateErrorCode int
+
+const (
+	// UnknownMethod means the protocol doesn't have the method
+	UnknownMethod ReadStateErrorCode = iota + 1
+	// BadArgCount means the method is called with a wrong number of arguments
+	BadArgCount
+	// BadArgEncoding means an argument of the method can't be decoded
+	BadArgEncoding
+	// EpochOutOfRange means the data of the epoch isn't available yet or anymore
+	EpochOutOfRange
+	// ArchiveUnavailable means the data is only available on an archive node
+	ArchiveUnavailable
+)
+
+// ReadStateError is an error of ReadState with a code. The message is the one of the underlying error.
+type ReadStateError struct {
+	code ReadStateErrorCode
+	err  error
+}
+
+// NewReadStateError annotates the error with the code
+func NewReadStateError(code ReadStateErrorCode, err error) error {
+	return &ReadStateError{code: code, err: err}
+}
+
+// ReadStateErrorf creates an error of the code with the formatted message
+func ReadStateErrorf(code ReadStateErrorCode, format string, args ...interface{}) error {
+	return NewReadStateError(code, errors.Errorf(format, args...))
+}
+
+// Code returns the code of the error
+func (e *ReadStateError) Code() ReadStateErrorCode { return e.code }
+
+// Error returns the message of the underlying error
+func (e *ReadStateError) Error() string { return e.err.Error() }
+
+// Cause returns the underlying error, so that the sentinel errors are still found by errors.Cause
+func (e *ReadStateError) Cause() error { return e.err }
+
+// ReadStateErrorCodeOf returns the code of the first ReadStateError in the chain of the error, and false if there is
+// none
+func ReadStateErrorCodeOf(err error) (ReadStateErrorCode, bool) {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		if e, ok := err.(*ReadStateError); ok {
+			return e.code, true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return 0, false
+}
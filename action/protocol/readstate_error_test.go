@@ -0,0 +1,36 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStateError(t *testing.T) {
+	require := require.New(t)
+	sentinel := errors.New("epoch is in the future")
+	err := errors.Wrap(NewReadStateError(EpochOutOfRange, errors.Wrap(sentinel, "epoch 3")), "failed to read")
+	code, ok := ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(EpochOutOfRange, code)
+	require.Equal(sentinel, errors.Cause(err))
+	require.Equal("failed to read: epoch 3: epoch is in the future", err.Error())
+
+	err = ReadStateErrorf(BadArgCount, "invalid number of arguments %d", 2)
+	code, ok = ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(BadArgCount, code)
+	require.Equal("invalid number of arguments 2", err.Error())
+
+	_, ok = ReadStateErrorCodeOf(errors.New("corresponding method isn't found"))
+	require.False(ok)
+	_, ok = ReadStateErrorCodeOf(nil)
+	require.False(ok)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iotexproject/iotex-address/address"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/protocolpb"
+)
+
+// ArgType is the type of an argument of a ReadState method, which the argument is validated against
+type ArgType int
+
+const (
+	// BytesArg is an argument of any bytes, which the handler decodes itself
+	BytesArg ArgType = iota
+	// Uint64Arg is an uint64 in 8 bytes of big endian
+	Uint64Arg
+	// AddressArg is an encoded address
+	AddressArg
+	// PaginationArg is a protobuf-encoded Pagination, which is decoded by ParsePagination
+	PaginationArg
+)
+
+type (
+	// ReadStateHandler reads the state of a method with the validated arguments
+	ReadStateHandler func(context.Context, StateReader, ...[]byte) ([]byte, error)
+
+	// ReadStateMethod describes a ReadState method of a protocol
+	ReadStateMethod struct {
+		Name string
+		// Args are the types of the arguments the method requires
+		Args []ArgType
+		// OptionalArgs are the types of the arguments which may follow the required ones, in order
+		OptionalArgs []ArgType
+		// ExtraArgs accepts any arguments after the described ones without validation, for the methods which have
+		// always ignored them
+		ExtraArgs bool
+		Handler   ReadStateHandler
+	}
+
+	// ReadStateDescriber is a protocol describing its ReadState methods, which the registry routes the reads of the
+	// protocol to
+	ReadStateDescriber interface {
+		ReadStateMethods() []ReadStateMethod
+	}
+
+	// ReadStateRouter dispatches the reads to the handlers of the methods, after validating the arguments
+	ReadStateRouter struct {
+		methods map[string]ReadStateMethod
+	}
+)
+
+// NewReadStateRouter creates a router of the methods, which are named uniquely
+func NewReadStateRouter(methods ...ReadStateMethod) (*ReadStateRouter, error) {
+	r := &ReadStateRouter{
+		methods: make(map[string]ReadStateMethod, len(methods)),
+	}
+	for _, m := range methods {
+		if m.Name == "" {
+			return nil, errors.New("method has no name")
+		}
+		if m.Handler == nil {
+			return nil, errors.Errorf("method %s has no handler", m.Name)
+		}
+		if _, ok := r.methods[m.Name]; ok {
+			return nil, errors.Errorf("method %s is described twice", m.Name)
+		}
+		r.methods[m.Name] = m
+	}
+	return r, nil
+}
+
+// MustNewReadStateRouter creates a router of the methods, and panics if the methods are invalid
+func MustNewReadStateRouter(methods ...ReadStateMethod) *ReadStateRouter {
+	r, err := NewReadStateRouter(methods...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Methods returns the names of the methods in alphabetical order
+func (r *ReadStateRouter) Methods() []string {
+	names := make([]string, 0, len(r.methods))
+	for name := range r.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadState validates the arguments against the descriptor of the method, and calls its handler
+func (r *ReadStateRouter) ReadState(ctx context.Context, sr StateReader, method []byte, args ...[]byte) ([]byte, error) {
+	m, ok := r.methods[string(method)]
+	if !ok {
+		return nil, ReadStateErrorf(UnknownMethod, "corresponding method isn't found")
+	}
+	described := len(m.Args) + len(m.OptionalArgs)
+	if len(args) < len(m.Args) || (len(args) > described && !m.ExtraArgs) {
+		return nil, ReadStateErrorf(BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	for i, arg := range args {
+		if i >= described {
+			break
+		}
+		var t ArgType
+		if i < len(m.Args) {
+			t = m.Args[i]
+		} else {
+			t = m.OptionalArgs[i-len(m.Args)]
+		}
+		if err := validateArg(t, arg); err != nil {
+			return nil, NewReadStateError(BadArgEncoding, errors.Wrapf(err, "invalid argument %d", i))
+		}
+	}
+	return m.Handler(ctx, sr, args...)
+}
+
+func validateArg(t ArgType, arg []byte) error {
+	switch t {
+	case Uint64Arg:
+		if len(arg) != 8 {
+			return errors.Errorf("invalid uint64 argument of %d bytes", len(arg))
+		}
+	case AddressArg:
+		if _, err := address.FromString(string(arg)); err != nil {
+			return err
+		}
+	case PaginationArg:
+		if err := proto.Unmarshal(arg, &protocolpb.Pagination{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
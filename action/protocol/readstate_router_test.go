@@ -0,0 +1,154 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+// describedProtocol is a protocol describing its ReadState methods
+type describedProtocol struct {
+	Protocol
+	methods []ReadStateMethod
+}
+
+func (p *describedProtocol) ReadStateMethods() []ReadStateMethod {
+	return p.methods
+}
+
+func (p *describedProtocol) Name() string { return "" }
+
+func (p *describedProtocol) Version() uint { return 0 }
+
+func echoHandler(_ context.Context, _ StateReader, args ...[]byte) ([]byte, error) {
+	return bytes.Join(args, nil), nil
+}
+
+func TestNewReadStateRouter(t *testing.T) {
+	require := require.New(t)
+	_, err := NewReadStateRouter(ReadStateMethod{Handler: echoHandler})
+	require.Error(err)
+	_, err = NewReadStateRouter(ReadStateMethod{Name: "a"})
+	require.Error(err)
+	_, err = NewReadStateRouter(ReadStateMethod{Name: "a", Handler: echoHandler}, ReadStateMethod{Name: "a", Handler: echoHandler})
+	require.Error(err)
+	r, err := NewReadStateRouter(ReadStateMethod{Name: "b", Handler: echoHandler}, ReadStateMethod{Name: "a", Handler: echoHandler})
+	require.NoError(err)
+	require.Equal([]string{"a", "b"}, r.Methods())
+	require.Panics(func() { MustNewReadStateRouter(ReadStateMethod{Name: "a"}) })
+}
+
+func TestReadStateRouter_ReadState(t *testing.T) {
+	require := require.New(t)
+	r := MustNewReadStateRouter(ReadStateMethod{
+		Name:         "method",
+		Args:         []ArgType{AddressArg, Uint64Arg},
+		OptionalArgs: []ArgType{BytesArg, Uint64Arg},
+		Handler:      echoHandler,
+	})
+	addr := []byte(identityset.Address(1).String())
+	height := byteutil.Uint64ToBytes(1)
+	for _, args := range [][][]byte{
+		{addr, height},
+		{addr, height, {1}},
+		{addr, height, {}, height},
+	} {
+		data, err := r.ReadState(context.Background(), nil, []byte("method"), args...)
+		require.NoError(err)
+		require.Equal(bytes.Join(args, nil), data)
+	}
+	// the extra arguments of the method accepting them aren't validated
+	r = MustNewReadStateRouter(ReadStateMethod{
+		Name:      "method",
+		Args:      []ArgType{Uint64Arg},
+		ExtraArgs: true,
+		Handler:   echoHandler,
+	})
+	data, err := r.ReadState(context.Background(), nil, []byte("method"), height, nil, []byte{1})
+	require.NoError(err)
+	require.Equal(append(height, 1), data)
+	_, err = r.ReadState(context.Background(), nil, []byte("method"))
+	code, ok := ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(BadArgCount, code)
+}
+
+func TestReadStateRouter_ArgErrors(t *testing.T) {
+	require := require.New(t)
+	r := MustNewReadStateRouter(ReadStateMethod{
+		Name:         "method",
+		Args:         []ArgType{AddressArg, Uint64Arg},
+		OptionalArgs: []ArgType{BytesArg, Uint64Arg},
+		Handler:      echoHandler,
+	})
+	addr := []byte(identityset.Address(1).String())
+	height := byteutil.Uint64ToBytes(1)
+	for _, test := range []struct {
+		method string
+		args   [][]byte
+		code   ReadStateErrorCode
+	}{
+		{"unknown", [][]byte{addr, height}, UnknownMethod},
+		{"method", [][]byte{addr}, BadArgCount},
+		{"method", [][]byte{addr, height, {}, height, {}}, BadArgCount},
+		{"method", [][]byte{[]byte("invalid"), height}, BadArgEncoding},
+		{"method", [][]byte{addr, {1}}, BadArgEncoding},
+		{"method", [][]byte{addr, height, {}, {1}}, BadArgEncoding},
+	} {
+		_, err := r.ReadState(context.Background(), nil, []byte(test.method), test.args...)
+		code, ok := ReadStateErrorCodeOf(err)
+		require.True(ok)
+		require.Equal(test.code, code)
+	}
+}
+
+func TestRegistry_ReadState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	require := require.New(t)
+
+	reg := NewRegistry()
+	undescribed := NewMockProtocol(ctrl)
+	undescribed.EXPECT().Name().Return("").AnyTimes()
+	undescribed.EXPECT().ReadState(gomock.Any(), gomock.Any(), []byte("method"), []byte{1}).Return([]byte{2}, nil).Times(1)
+	require.NoError(reg.Register("undescribed", undescribed))
+	require.NoError(reg.Register("described", &describedProtocol{
+		methods: []ReadStateMethod{{Name: "method", Args: []ArgType{Uint64Arg}, Handler: echoHandler}},
+	}))
+	require.Error(reg.Register("invalid", &describedProtocol{methods: []ReadStateMethod{{Name: "method"}}}))
+
+	// the reads of the protocol which doesn't describe its methods are passed to it
+	data, err := reg.ReadState(context.Background(), nil, "undescribed", []byte("method"), []byte{1})
+	require.NoError(err)
+	require.Equal([]byte{2}, data)
+	data, err = reg.ReadState(context.Background(), nil, "described", []byte("method"), byteutil.Uint64ToBytes(3))
+	require.NoError(err)
+	require.Equal(byteutil.Uint64ToBytes(3), data)
+	_, err = reg.ReadState(context.Background(), nil, "described", []byte("method"), []byte{1})
+	code, ok := ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(BadArgEncoding, code)
+	_, err = reg.ReadState(context.Background(), nil, "unregistered", []byte("method"))
+	require.Error(err)
+
+	// the replacing protocol replaces the router as well
+	require.NoError(reg.ForceRegister("described", &describedProtocol{
+		methods: []ReadStateMethod{{Name: "other", Handler: echoHandler}},
+	}))
+	_, err = reg.ReadState(context.Background(), nil, "described", []byte("method"), byteutil.Uint64ToBytes(3))
+	code, ok = ReadStateErrorCodeOf(err)
+	require.True(ok)
+	require.Equal(UnknownMethod, code)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+
+	"github.com/iotexproject/iotex-core/action"
+)
+
+// ReceiptStatusRange is a range of the receipt status codes, from Min to Max inclusive, reserved by the owner. The codes
+// are part of the receipts, so neither a range nor a code in it ever changes once released, and the new codes of an
+// owner are appended to its range.
+type ReceiptStatusRange struct {
+	Owner string
+	Min   uint64
+	Max   uint64
+}
+
+// receiptStatusRanges are the reserved ranges in ascending order. The ranges before the registry are kept as they were
+// released: the generic Failure and Success, the statuses of the evm, the staking statuses and the timeout status.
+var receiptStatusRanges = []ReceiptStatusRange{
+	{Owner: "generic", Min: 0, Max: 99},
+	{Owner: "execution", Min: 100, Max: 199},
+	{Owner: "staking", Min: 200, Max: 299},
+	{Owner: "runtime", Min: 300, Max: 399},
+	{Owner: "poll", Min: 400, Max: 499},
+	{Owner: "rewarding", Min: 500, Max: 599},
+	{Owner: "account", Min: 600, Max: 699},
+}
+
+// ReceiptStatusRanges returns the reserved ranges of the receipt status codes in ascending order
+func ReceiptStatusRanges() []ReceiptStatusRange {
+	return append([]ReceiptStatusRange{}, receiptStatusRanges...)
+}
+
+// ReceiptStatusRangeOf returns the range reserved by the owner, and false if it has none
+func ReceiptStatusRangeOf(owner string) (ReceiptStatusRange, bool) {
+	for _, r := range receiptStatusRanges {
+		if r.Owner == owner {
+			return r, true
+		}
+	}
+	return ReceiptStatusRange{}, false
+}
+
+// Contains returns whether the code is in the range
+func (r ReceiptStatusRange) Contains(code uint64) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// StatusError is an error of handling an action which fails the action with the status, rather than the block. The
+// message is the one of the underlying error.
+type StatusError struct {
+	status uint64
+	err    error
+}
+
+// WrapWithStatus annotates the error of handling an action with the status of the failure receipt of the action
+func WrapWithStatus(err error, status uint64) error {
+	if err == nil {
+		return nil
+	}
+	return &StatusError{status: status, err: err}
+}
+
+// Status returns the status of the error
+func (e *StatusError) Status() uint64 { return e.status }
+
+// Error returns the message of the underlying error
+func (e *StatusError) Error() string { return e.err.Error() }
+
+// Cause returns the underlying error, so that the sentinel errors are still found by errors.Cause
+func (e *StatusError) Cause() error { return e.err }
+
+// ReceiptStatusOf returns the status of the first StatusError in the chain of the error. An error without a status
+// maps to the generic Failure, and false is returned along with it.
+func ReceiptStatusOf(err error) (uint64, bool) {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		if e, ok := err.(*StatusError); ok {
+			return e.status, true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return uint64(iotextypes.ReceiptStatus_Failure), false
+}
+
+// FailureReceipt returns the failure receipt of the action in the context with the status the error carries, which
+// consumes the intrinsic gas of the action. False is returned if the error carries no status, which still fails the
+// block.
+func FailureReceipt(ctx context.Context, err error) (*action.Receipt, bool) {
+	status, ok := ReceiptStatusOf(err)
+	if !ok {
+		return nil, false
+	}
+	actionCtx := MustGetActionCtx(ctx)
+	return &action.Receipt{
+		Status:      status,
+		BlockHeight: MustGetBlockCtx(ctx).BlockHeight,
+		ActionHash:  actionCtx.ActionHash,
+		GasConsumed: actionCtx.IntrinsicGas,
+	}, true
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+)
+
+func TestReceiptStatusRanges(t *testing.T) {
+	require := require.New(t)
+
+	// the ranges and the codes are part of the receipts, so their values never change
+	require.Equal([]ReceiptStatusRange{
+		{Owner: "generic", Min: 0, Max: 99},
+		{Owner: "execution", Min: 100, Max: 199},
+		{Owner: "staking", Min: 200, Max: 299},
+		{Owner: "runtime", Min: 300, Max: 399},
+		{Owner: "poll", Min: 400, Max: 499},
+		{Owner: "rewarding", Min: 500, Max: 599},
+		{Owner: "account", Min: 600, Max: 699},
+	}, ReceiptStatusRanges())
+	require.Equal(uint64(0), uint64(iotextypes.ReceiptStatus_Failure))
+	require.Equal(uint64(1), uint64(iotextypes.ReceiptStatus_Success))
+	require.Equal(uint64(100), uint64(iotextypes.ReceiptStatus_ErrUnknown))
+	require.Equal(uint64(300), action.ReceiptStatusExecutionTimeout)
+	require.Equal(uint64(301), action.ReceiptStatusBufferFull)
+
+	ranges := ReceiptStatusRanges()
+	for i := 1; i < len(ranges); i++ {
+		require.True(ranges[i-1].Max < ranges[i].Min, "%s overlaps %s", ranges[i-1].Owner, ranges[i].Owner)
+	}
+	r, ok := ReceiptStatusRangeOf("runtime")
+	require.True(ok)
+	require.True(r.Contains(action.ReceiptStatusExecutionTimeout))
+	require.True(r.Contains(action.ReceiptStatusBufferFull))
+	require.False(r.Contains(400))
+	_, ok = ReceiptStatusRangeOf("unknown")
+	require.False(ok)
+	// the ranges returned are a copy
+	ranges[0].Max = 0
+	require.Equal(uint64(99), ReceiptStatusRanges()[0].Max)
+}
+
+func TestStatusError(t *testing.T) {
+	require := require.New(t)
+	sentinel := errors.New("caller is unauthorized")
+	err := errors.Wrap(WrapWithStatus(errors.Wrap(sentinel, "delegate1"), 202), "failed to handle")
+	status, ok := ReceiptStatusOf(err)
+	require.True(ok)
+	require.Equal(uint64(202), status)
+	require.Equal(sentinel, errors.Cause(err))
+	require.Equal("failed to handle: delegate1: caller is unauthorized", err.Error())
+
+	// an error without a status maps to the generic failure
+	status, ok = ReceiptStatusOf(sentinel)
+	require.False(ok)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), status)
+	_, ok = ReceiptStatusOf(nil)
+	require.False(ok)
+	require.NoError(WrapWithStatus(nil, 202))
+
+	actionHash := hash.Hash256b([]byte("action"))
+	ctx := WithBlockCtx(context.Background(), BlockCtx{BlockHeight: 3})
+	ctx = WithActionCtx(ctx, ActionCtx{ActionHash: actionHash, IntrinsicGas: 10000})
+	receipt, ok := FailureReceipt(ctx, err)
+	require.True(ok)
+	require.Equal(&action.Receipt{
+		Status:      202,
+		BlockHeight: 3,
+		ActionHash:  actionHash,
+		GasConsumed: 10000,
+	}, receipt)
+	_, ok = FailureReceipt(ctx, sentinel)
+	require.False(ok)
+}
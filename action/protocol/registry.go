@@ -7,56 +7,123 @@
 package protocol
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/pkg/log"
 )
 
-// Registry is the hub of all protocols deployed on the chain
+// Registry is the hub of all protocols deployed on the chain. The protocols are kept in the order of their priorities,
+// and the ones of the same priority in the order of registration, so that they are iterated in the same order on all
+// nodes.
 type Registry struct {
-	mu        sync.RWMutex
-	ids       map[string]int
-	protocols []Protocol
+	mu      sync.RWMutex
+	ids     map[string]int
+	entries []*registryEntry
+	started bool
+}
+
+type registryEntry struct {
+	id       string
+	protocol Protocol
+	priority int
+	// router routes the reads of the protocol if it describes its ReadState methods
+	router *ReadStateRouter
+}
+
+// ProtocolInfo is the metadata of a registered protocol
+type ProtocolInfo struct {
+	ID      string
+	Name    string
+	Version uint
+}
+
+// RegisterOption sets an option of registering a protocol
+type RegisterOption func(*registryEntry)
+
+// PriorityOption sets the priority of the protocol. The protocols of higher priorities come before the others in All,
+// regardless of the order of registration. The default priority is 0.
+func PriorityOption(priority int) RegisterOption {
+	return func(e *registryEntry) {
+		e.priority = priority
+	}
 }
 
 // NewRegistry create a new Registry
 func NewRegistry() *Registry {
 	return &Registry{
-		ids:       make(map[string]int, 0),
-		protocols: make([]Protocol, 0),
+		ids:     make(map[string]int, 0),
+		entries: make([]*registryEntry, 0),
 	}
 }
 
-func (r *Registry) register(id string, p Protocol, force bool) error {
+func (r *Registry) register(id string, p Protocol, force bool, opts ...RegisterOption) error {
 	idx, loaded := r.ids[id]
-	if loaded {
-		if !force {
-			return errors.Errorf("Protocol with ID %s is already registered", id)
+	if loaded && !force {
+		return errors.Errorf("Protocol with ID %s is already registered", id)
+	}
+	if p != nil && p.Name() != "" {
+		for _, e := range r.entries {
+			if e.id != id && e.protocol != nil && e.protocol.Name() == p.Name() {
+				return errors.Errorf("Protocol with name %s is already registered with ID %s", p.Name(), e.id)
+			}
 		}
-		r.protocols[idx] = p
+	}
+	var router *ReadStateRouter
+	if d, ok := p.(ReadStateDescriber); ok {
+		var err error
+		if router, err = NewReadStateRouter(d.ReadStateMethods()...); err != nil {
+			return errors.Wrapf(err, "invalid ReadState methods of protocol %s", id)
+		}
+	}
+	if loaded {
+		// the replacing protocol takes the position and the priority of the replaced one
+		r.entries[idx].protocol = p
+		r.entries[idx].router = router
 
 		return nil
 	}
-	r.ids[id] = len(r.ids)
-	r.protocols = append(r.protocols, p)
+	e := &registryEntry{
+		id:       id,
+		protocol: p,
+		router:   router,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	r.entries = append(r.entries, e)
+	// the entries are sorted stably, so that the ones of the same priority remain in the order of registration
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].priority > r.entries[j].priority
+	})
+	for i, e := range r.entries {
+		r.ids[e.id] = i
+	}
 
 	return nil
 }
 
 // Register registers the protocol with a unique ID
-func (r *Registry) Register(id string, p Protocol) error {
+func (r *Registry) Register(id string, p Protocol, opts ...RegisterOption) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	return r.register(id, p, false)
+	return r.register(id, p, false, opts...)
 }
 
-// ForceRegister registers the protocol with a unique ID and force replacing the previous protocol if it exists
-func (r *Registry) ForceRegister(id string, p Protocol) error {
+// ForceRegister registers the protocol with a unique ID and force replacing the previous protocol if it exists. The
+// options only apply if there is no previous protocol.
+func (r *Registry) ForceRegister(id string, p Protocol, opts ...RegisterOption) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	return r.register(id, p, true)
+	return r.register(id, p, true, opts...)
 }
 
 // Find finds a protocol by ID
@@ -68,18 +135,312 @@ func (r *Registry) Find(id string) (Protocol, bool) {
 		return nil, false
 	}
 
-	return r.protocols[idx], true
+	return r.entries[idx].protocol, true
+}
+
+// FindAs finds the protocol of the ID and sets the target to it, the same way as errors.As does, so that the callers
+// needn't assert the type of the protocol themselves. The target must be a non-nil pointer to the type of the protocol
+// or to an interface it implements. It returns false if the registry is nil, the protocol isn't registered, or the
+// protocol isn't of the type.
+func FindAs(reg *Registry, id string, target interface{}) bool {
+	val := targetValue(target)
+	if reg == nil {
+		return false
+	}
+	p, ok := reg.Find(id)
+	if !ok {
+		return false
+	}
+	return setTarget(val, p)
 }
 
-// All returns all protocols
+// MustFindAs finds the protocol of the ID and sets the target to it as FindAs does, and panics if the protocol isn't
+// registered or isn't of the type
+func MustFindAs(reg *Registry, id string, target interface{}) {
+	if reg == nil {
+		log.S().Panic("registry cannot be nil")
+	}
+	p, ok := reg.Find(id)
+	if !ok {
+		log.S().Panicf("protocol %s is not registered", id)
+	}
+	if !setTarget(targetValue(target), p) {
+		log.S().Panicf("fail to cast protocol %s of type %T to %s", id, p, reflect.TypeOf(target).Elem())
+	}
+}
+
+// FindByInterface sets the target to the first protocol in the order of All which implements the interface the target
+// points to, for the callers which don't know the ID of the protocol. The target must be a non-nil pointer to an
+// interface. It returns false if no protocol implements the interface.
+func FindByInterface(reg *Registry, target interface{}) bool {
+	val := targetValue(target)
+	if val.Type().Elem().Kind() != reflect.Interface {
+		log.S().Panicf("target of type %T isn't a pointer to an interface", target)
+	}
+	for _, p := range reg.All() {
+		if setTarget(val, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func targetValue(target interface{}) reflect.Value {
+	if target == nil {
+		log.S().Panic("target cannot be nil")
+	}
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		log.S().Panicf("target of type %T isn't a non-nil pointer", target)
+	}
+	return val
+}
+
+func setTarget(val reflect.Value, p Protocol) bool {
+	if p == nil || !reflect.TypeOf(p).AssignableTo(val.Type().Elem()) {
+		return false
+	}
+	val.Elem().Set(reflect.ValueOf(p))
+	return true
+}
+
+// ReadState reads the state of the method of the protocol. The reads of a protocol describing its methods are routed
+// by the registry, which validates the arguments, and the others are passed to the ReadState of the protocol.
+func (r *Registry) ReadState(
+	ctx context.Context,
+	sr StateReader,
+	id string,
+	method []byte,
+	args ...[]byte,
+) ([]byte, error) {
+	r.mu.RLock()
+	idx, loaded := r.ids[id]
+	if !loaded {
+		r.mu.RUnlock()
+		return nil, errors.Errorf("protocol %s isn't registered", id)
+	}
+	p, router := r.entries[idx].protocol, r.entries[idx].router
+	r.mu.RUnlock()
+	if router != nil {
+		return router.ReadState(ctx, sr, method, args...)
+	}
+
+	return p.ReadState(ctx, sr, method, args...)
+}
+
+// All returns all protocols in the order of priorities, and in the order of registration for the same priority
 func (r *Registry) All() []Protocol {
 	if r == nil {
 		return nil
 	}
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	all := make([]Protocol, len(r.protocols))
-	copy(all, r.protocols)
+	all := make([]Protocol, len(r.entries))
+	for i, e := range r.entries {
+		all[i] = e.protocol
+	}
 
 	return all
 }
+
+// Metadata returns the metadata of all protocols in the order of All
+func (r *Registry) Metadata() []ProtocolInfo {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]ProtocolInfo, len(r.entries))
+	for i, e := range r.entries {
+		infos[i].ID = e.id
+		if e.protocol != nil {
+			infos[i].Name = e.protocol.Name()
+			infos[i].Version = e.protocol.Version()
+		}
+	}
+
+	return infos
+}
+
+// AllInDependencyOrder returns all protocols where each protocol comes after the protocols it depends on, and the
+// protocols independent of each other come in the order of All. An error is returned if a protocol depends on a
+// protocol which isn't registered, or if the dependencies form a cycle.
+func (r *Registry) AllInDependencyOrder() ([]Protocol, error) {
+	if r == nil {
+		return nil, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries, err := r.entriesInDependencyOrder()
+	if err != nil {
+		return nil, err
+	}
+	all := make([]Protocol, len(entries))
+	for i, e := range entries {
+		all[i] = e.protocol
+	}
+
+	return all, nil
+}
+
+// GenesisStatesCreationOrder returns the protocols which are GenesisStateCreators in the order of creating the genesis
+// states, which is the order of AllInDependencyOrder, so that each protocol creates its genesis states after the
+// protocols it depends on regardless of the order of registration
+func (r *Registry) GenesisStatesCreationOrder() ([]Protocol, error) {
+	all, err := r.AllInDependencyOrder()
+	if err != nil {
+		return nil, err
+	}
+	creators := make([]Protocol, 0, len(all))
+	for _, p := range all {
+		if _, ok := p.(GenesisStateCreator); ok {
+			creators = append(creators, p)
+		}
+	}
+
+	return creators, nil
+}
+
+func (r *Registry) entriesInDependencyOrder() ([]*registryEntry, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	var (
+		sorted = make([]*registryEntry, 0, len(r.entries))
+		states = make(map[string]int, len(r.entries))
+		path   []string
+		visit  func(*registryEntry) error
+	)
+	visit = func(e *registryEntry) error {
+		switch states[e.id] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("protocols depend on each other in a cycle %s -> %s", strings.Join(path, " -> "), e.id)
+		}
+		states[e.id] = visiting
+		path = append(path, e.id)
+		if d, ok := e.protocol.(Dependent); ok {
+			for _, id := range d.Dependencies() {
+				idx, loaded := r.ids[id]
+				if !loaded {
+					return errors.Errorf(
+						"protocol %s depends on protocol %s, which isn't registered",
+						strings.Join(path, " -> "),
+						id,
+					)
+				}
+				if err := visit(r.entries[idx]); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[e.id] = visited
+		sorted = append(sorted, e)
+		return nil
+	}
+	for _, e := range r.entries {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// StartAll starts the protocols which are Starters in the order of AllInDependencyOrder, after migrating the states of
+// the Migrators of earlier versions. The protocols are only started once, and the error of the first protocol failing
+// to migrate or start is returned.
+func (r *Registry) StartAll(ctx context.Context, sr StateReader) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	if r.started {
+		r.mu.Unlock()
+		return errors.New("protocols are already started")
+	}
+	entries, err := r.entriesInDependencyOrder()
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	r.started = true
+	r.mu.Unlock()
+
+	for _, e := range entries {
+		if m, ok := e.protocol.(Migrator); ok {
+			if err := migrate(ctx, sr, e.id, m, e.protocol.Version()); err != nil {
+				return err
+			}
+		}
+		if s, ok := e.protocol.(Starter); ok {
+			if err := s.Start(ctx, sr); err != nil {
+				return errors.Wrapf(err, "failed to start protocol %s", e.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func migrate(ctx context.Context, sr StateReader, id string, m Migrator, version uint) error {
+	stored, err := m.StateVersion(ctx, sr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the version of the states of protocol %s", id)
+	}
+	switch {
+	case stored > version:
+		return errors.Errorf(
+			"states of protocol %s are of version %d, later than the protocol of version %d",
+			id,
+			stored,
+			version,
+		)
+	case stored < version:
+		if err := m.Migrate(ctx, sr, stored); err != nil {
+			return errors.Wrapf(
+				err,
+				"failed to migrate the states of protocol %s from version %d to %d",
+				id,
+				stored,
+				version,
+			)
+		}
+	}
+
+	return nil
+}
+
+// StopAll stops the protocols which are Stoppers in the reverse order of AllInDependencyOrder. All of them are stopped even if some
+// fail to, and the errors are returned together.
+func (r *Registry) StopAll(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	if !r.started {
+		r.mu.Unlock()
+		return nil
+	}
+	r.started = false
+	// the dependencies have been checked in starting
+	entries, _ := r.entriesInDependencyOrder()
+	r.mu.Unlock()
+
+	var errs []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		if s, ok := entries[i].protocol.(Stopper); ok {
+			if err := s.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to stop protocol %s: %v", entries[i].id, err))
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -7,9 +7,12 @@
 package protocol
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,6 +32,7 @@ func TestFind(t *testing.T) {
 	require := require.New(t)
 	reg := NewRegistry()
 	p := NewMockProtocol(ctrl)
+	p.EXPECT().Name().Return("").AnyTimes()
 	require.NoError(reg.Register("1", p))
 	// Case I: Normal
 	_, ok := reg.Find("1")
@@ -41,12 +45,58 @@ func TestFind(t *testing.T) {
 	require.Nil(reg.Find("2"))
 }
 
+func TestFindAs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	require := require.New(t)
+	reg := NewRegistry()
+	p := NewMockProtocol(ctrl)
+	p.EXPECT().Name().Return("").AnyTimes()
+	d := &describedProtocol{}
+	require.NoError(reg.Register("mock", p))
+	require.NoError(reg.Register("described", d))
+
+	// Case I: Normal
+	var mock *MockProtocol
+	require.True(FindAs(reg, "mock", &mock))
+	require.Equal(p, mock)
+	var found Protocol
+	require.True(FindAs(reg, "described", &found))
+	require.Equal(d, found)
+	// Case II: Not exist
+	require.False(FindAs(reg, "unknown", &found))
+	require.False(FindAs(nil, "mock", &found))
+	require.Panics(func() { MustFindAs(reg, "unknown", &mock) })
+	require.Panics(func() { MustFindAs(nil, "mock", &mock) })
+	// Case III: Wrong type
+	require.False(FindAs(reg, "described", &mock))
+	require.Equal(p, mock)
+	require.Panics(func() { MustFindAs(reg, "described", &mock) })
+	var describer ReadStateDescriber
+	require.False(FindAs(reg, "mock", &describer))
+	require.Nil(describer)
+	// Case IV: Invalid target
+	require.Panics(func() { FindAs(reg, "mock", *mock) })
+	require.Panics(func() { FindAs(reg, "mock", nil) })
+
+	// the first protocol implementing the interface is found
+	require.True(FindByInterface(reg, &describer))
+	require.Equal(d, describer)
+	var starter Starter
+	require.False(FindByInterface(reg, &starter))
+	require.Panics(func() { FindByInterface(reg, &mock) })
+	MustFindAs(reg, "mock", &mock)
+	require.Equal(p, mock)
+}
+
 func TestAll(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	require := require.New(t)
 	reg := NewRegistry()
 	p := NewMockProtocol(ctrl)
+	p.EXPECT().Name().Return("").AnyTimes()
 	require.NoError(reg.Register("1", p))
 	// Case I: Normal
 	require.Equal(1, len(reg.All()))
@@ -57,3 +107,244 @@ func TestAll(t *testing.T) {
 	require.Equal(all[0], p)
 	require.Nil(all[1])
 }
+
+// idProtocol is a protocol told apart by its ID, whose methods are never called
+type idProtocol struct {
+	Protocol
+	id string
+}
+
+func (p *idProtocol) Name() string { return "" }
+
+func (p *idProtocol) Version() uint { return 0 }
+
+func TestAll_Order(t *testing.T) {
+	require := require.New(t)
+
+	ps := make(map[string]Protocol)
+	for _, id := range []string{"account", "rolldpos", "poll", "staking", "rewarding"} {
+		ps[id] = &idProtocol{id: id}
+	}
+	priorities := map[string]int{
+		"account":  2,
+		"rolldpos": 1,
+	}
+	register := func(ids ...string) *Registry {
+		reg := NewRegistry()
+		for _, id := range ids {
+			var opts []RegisterOption
+			if priority, ok := priorities[id]; ok {
+				opts = append(opts, PriorityOption(priority))
+			}
+			require.NoError(reg.Register(id, ps[id], opts...))
+		}
+		return reg
+	}
+	expected := []Protocol{ps["account"], ps["rolldpos"], ps["poll"], ps["staking"], ps["rewarding"]}
+	for _, ids := range [][]string{
+		{"account", "rolldpos", "poll", "staking", "rewarding"},
+		{"poll", "staking", "rewarding", "rolldpos", "account"},
+		{"poll", "rolldpos", "staking", "account", "rewarding"},
+		{"rolldpos", "poll", "account", "staking", "rewarding"},
+	} {
+		reg := register(ids...)
+		require.Equal(expected, reg.All())
+		for id, p := range ps {
+			found, ok := reg.Find(id)
+			require.True(ok)
+			require.Equal(p, found)
+		}
+	}
+
+	// the replacing protocol keeps the position and the priority of the replaced one
+	reg := register("poll", "account", "staking", "rolldpos", "rewarding")
+	poll := &idProtocol{id: "poll2"}
+	require.NoError(reg.ForceRegister("poll", poll, PriorityOption(3)))
+	account := &idProtocol{id: "account2"}
+	require.NoError(reg.ForceRegister("account", account))
+	require.Equal([]Protocol{account, ps["rolldpos"], poll, ps["staking"], ps["rewarding"]}, reg.All())
+}
+
+// lifecycleProtocol records the calls of starting and stopping it
+type lifecycleProtocol struct {
+	Protocol
+	id    string
+	calls *[]string
+	err   error
+}
+
+func (p *lifecycleProtocol) Name() string { return "" }
+
+func (p *lifecycleProtocol) Version() uint { return 0 }
+
+func (p *lifecycleProtocol) Start(context.Context, StateReader) error {
+	*p.calls = append(*p.calls, "start "+p.id)
+	return p.err
+}
+
+func (p *lifecycleProtocol) Stop(context.Context) error {
+	*p.calls = append(*p.calls, "stop "+p.id)
+	return p.err
+}
+
+func TestStartAll_StopAll(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var calls []string
+	reg := NewRegistry()
+	require.NoError(reg.Register("1", &lifecycleProtocol{id: "1", calls: &calls}))
+	require.NoError(reg.Register("2", &idProtocol{id: "2"}))
+	require.NoError(reg.Register("3", &lifecycleProtocol{id: "3", calls: &calls}))
+	require.NoError(reg.Register("0", &lifecycleProtocol{id: "0", calls: &calls}, PriorityOption(1)))
+	// nothing to stop before starting
+	require.NoError(reg.StopAll(ctx))
+	require.Empty(calls)
+
+	require.NoError(reg.StartAll(ctx, nil))
+	require.Equal([]string{"start 0", "start 1", "start 3"}, calls)
+	// the protocols aren't started twice
+	require.Error(reg.StartAll(ctx, nil))
+	require.Equal([]string{"start 0", "start 1", "start 3"}, calls)
+	calls = nil
+	require.NoError(reg.StopAll(ctx))
+	require.Equal([]string{"stop 3", "stop 1", "stop 0"}, calls)
+
+	// starting stops at the failing protocol, while stopping goes through all of them
+	calls = nil
+	reg = NewRegistry()
+	require.NoError(reg.Register("1", &lifecycleProtocol{id: "1", calls: &calls}))
+	require.NoError(reg.Register("2", &lifecycleProtocol{id: "2", calls: &calls, err: errors.New("unavailable")}))
+	require.NoError(reg.Register("3", &lifecycleProtocol{id: "3", calls: &calls, err: errors.New("unavailable")}))
+	err := reg.StartAll(ctx, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "failed to start protocol 2")
+	require.Equal([]string{"start 1", "start 2"}, calls)
+	calls = nil
+	err = reg.StopAll(ctx)
+	require.Error(err)
+	require.Contains(err.Error(), "failed to stop protocol 3")
+	require.Contains(err.Error(), "failed to stop protocol 2")
+	require.Equal([]string{"stop 3", "stop 2", "stop 1"}, calls)
+}
+
+// versionedProtocol is a protocol of a name and a version, whose states are stored of a version
+type versionedProtocol struct {
+	lifecycleProtocol
+	name    string
+	version uint
+	stored  uint
+}
+
+func (p *versionedProtocol) Name() string { return p.name }
+
+func (p *versionedProtocol) Version() uint { return p.version }
+
+func (p *versionedProtocol) StateVersion(context.Context, StateReader) (uint, error) {
+	return p.stored, nil
+}
+
+func (p *versionedProtocol) Migrate(_ context.Context, _ StateReader, from uint) error {
+	*p.calls = append(*p.calls, fmt.Sprintf("migrate %s from %d", p.id, from))
+	p.stored = p.version
+	return nil
+}
+
+func TestMetadata(t *testing.T) {
+	require := require.New(t)
+
+	var calls []string
+	newProtocol := func(id, name string, version, stored uint) *versionedProtocol {
+		return &versionedProtocol{
+			lifecycleProtocol: lifecycleProtocol{id: id, calls: &calls},
+			name:              name,
+			version:           version,
+			stored:            stored,
+		}
+	}
+	account := newProtocol("account", "Account", 1, 1)
+	staking := newProtocol("staking", "Staking", 3, 1)
+	reg := NewRegistry()
+	require.NoError(reg.Register("staking", staking))
+	require.NoError(reg.Register("account", account, PriorityOption(1)))
+	require.NoError(reg.Register("unnamed", &idProtocol{id: "unnamed"}))
+	require.NoError(reg.Register("nil", nil))
+	require.Equal([]ProtocolInfo{
+		{ID: "account", Name: "Account", Version: 1},
+		{ID: "staking", Name: "Staking", Version: 3},
+		{ID: "unnamed"},
+		{ID: "nil"},
+	}, reg.Metadata())
+	require.Nil((*Registry)(nil).Metadata())
+
+	// the names are unique among the IDs, except the empty one
+	err := reg.Register("account2", newProtocol("account2", "Account", 1, 1))
+	require.Error(err)
+	require.Contains(err.Error(), "Protocol with name Account is already registered with ID account")
+	require.NoError(reg.Register("unnamed2", &idProtocol{id: "unnamed2"}))
+	// but the protocol replacing another one may keep its name
+	account = newProtocol("account", "Account", 2, 2)
+	require.NoError(reg.ForceRegister("account", account))
+	require.Equal(ProtocolInfo{ID: "account", Name: "Account", Version: 2}, reg.Metadata()[0])
+
+	// only the states of an earlier version are migrated, before starting the protocol
+	require.NoError(reg.StartAll(context.Background(), nil))
+	require.Equal([]string{"start account", "migrate staking from 1", "start staking"}, calls)
+	require.NoError(reg.StopAll(context.Background()))
+
+	// the states of a later version fail the start
+	reg = NewRegistry()
+	require.NoError(reg.Register("staking", newProtocol("staking", "Staking", 1, 2)))
+	err = reg.StartAll(context.Background(), nil)
+	require.Error(err)
+	require.Contains(err.Error(), "states of protocol staking are of version 2, later than the protocol of version 1")
+}
+
+// dependentProtocol is a protocol depending on other protocols
+type dependentProtocol struct {
+	idProtocol
+	dependencies []string
+}
+
+func (p *dependentProtocol) Dependencies() []string {
+	return p.dependencies
+}
+
+func TestAllInDependencyOrder(t *testing.T) {
+	require := require.New(t)
+
+	account := &idProtocol{id: "account"}
+	rolldpos := &idProtocol{id: "rolldpos"}
+	staking := &dependentProtocol{idProtocol: idProtocol{id: "staking"}, dependencies: []string{"account"}}
+	poll := &dependentProtocol{idProtocol: idProtocol{id: "poll"}, dependencies: []string{"rolldpos", "staking"}}
+	rewarding := &idProtocol{id: "rewarding"}
+	reg := NewRegistry()
+	require.NoError(reg.Register("poll", poll))
+	require.NoError(reg.Register("rewarding", rewarding))
+	require.NoError(reg.Register("staking", staking))
+	require.NoError(reg.Register("rolldpos", rolldpos))
+	require.NoError(reg.Register("account", account))
+	all, err := reg.AllInDependencyOrder()
+	require.NoError(err)
+	require.Equal([]Protocol{rolldpos, account, staking, poll, rewarding}, all)
+	// the registration order still applies to All
+	require.Equal([]Protocol{poll, rewarding, staking, rolldpos, account}, reg.All())
+
+	// a missing dependency
+	reg = NewRegistry()
+	require.NoError(reg.Register("poll", poll))
+	require.NoError(reg.Register("rolldpos", rolldpos))
+	require.NoError(reg.Register("staking", staking))
+	_, err = reg.AllInDependencyOrder()
+	require.EqualError(err, "protocol poll -> staking depends on protocol account, which isn't registered")
+	require.Error(reg.StartAll(context.Background(), nil))
+
+	// a cycle
+	reg = NewRegistry()
+	require.NoError(reg.Register("rewarding", rewarding))
+	require.NoError(reg.Register("a", &dependentProtocol{idProtocol: idProtocol{id: "a"}, dependencies: []string{"b"}}))
+	require.NoError(reg.Register("b", &dependentProtocol{idProtocol: idProtocol{id: "b"}, dependencies: []string{"a"}}))
+	_, err = reg.AllInDependencyOrder()
+	require.EqualError(err, "protocols depend on each other in a cycle a -> b -> a")
+	require.Error(reg.StartAll(context.Background(), nil))
+}
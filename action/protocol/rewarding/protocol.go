@@ -10,8 +10,6 @@ import (
 	"context"
 	"math/big"
 
-	"github.com/pkg/errors"
-
 	"go.uber.org/zap"
 
 	"github.com/iotexproject/go-pkgs/hash"
@@ -55,6 +53,7 @@ type Protocol struct {
 	keyPrefix           []byte
 	addr                address.Address
 	kickoutIntensity    float64
+	router              *protocol.ReadStateRouter
 }
 
 // NewProtocol instantiates a rewarding protocol instance.
@@ -68,13 +67,15 @@ func NewProtocol(
 	if err != nil {
 		log.L().Panic("Error when constructing the address of rewarding protocol", zap.Error(err))
 	}
-	return &Protocol{
+	p := &Protocol{
 		productivityByEpoch: productivityByEpoch,
 		getKickoutList:      getKickoutList,
 		keyPrefix:           h[:],
 		addr:                addr,
 		kickoutIntensity:    kickoutIntensityRate,
 	}
+	p.router = protocol.MustNewReadStateRouter(p.ReadStateMethods()...)
+	return p
 }
 
 // FindProtocol finds the registered protocol from registry
@@ -194,44 +195,69 @@ func (p *Protocol) Validate(
 	return nil
 }
 
-// ReadState read the state on blockchain via protocol
+// ReadState read the state on blockchain via protocol, routing the read to the method described in ReadStateMethods
 func (p *Protocol) ReadState(
 	ctx context.Context,
 	sm protocol.StateReader,
 	method []byte,
 	args ...[]byte,
 ) ([]byte, error) {
-	switch string(method) {
-	case "AvailableBalance":
-		balance, err := p.AvailableBalance(ctx, sm)
-		if err != nil {
-			return nil, err
-		}
-		return []byte(balance.String()), nil
-	case "TotalBalance":
-		balance, err := p.TotalBalance(ctx, sm)
-		if err != nil {
-			return nil, err
-		}
-		return []byte(balance.String()), nil
-	case "UnclaimedBalance":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		addr, err := address.FromString(string(args[0]))
-		if err != nil {
-			return nil, err
-		}
-		balance, err := p.UnclaimedBalance(ctx, sm, addr)
-		if err != nil {
-			return nil, err
-		}
-		return []byte(balance.String()), nil
-	default:
-		return nil, errors.New("corresponding method isn't found")
+	return p.router.ReadState(ctx, sm, method, args...)
+}
+
+// ReadStateMethods describes the methods of ReadState
+func (p *Protocol) ReadStateMethods() []protocol.ReadStateMethod {
+	return []protocol.ReadStateMethod{
+		{
+			Name:      "AvailableBalance",
+			ExtraArgs: true,
+			Handler: func(ctx context.Context, sm protocol.StateReader, _ ...[]byte) ([]byte, error) {
+				balance, err := p.AvailableBalance(ctx, sm)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(balance.String()), nil
+			},
+		},
+		{
+			Name:      "TotalBalance",
+			ExtraArgs: true,
+			Handler: func(ctx context.Context, sm protocol.StateReader, _ ...[]byte) ([]byte, error) {
+				balance, err := p.TotalBalance(ctx, sm)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(balance.String()), nil
+			},
+		},
+		{
+			Name: "UnclaimedBalance",
+			Args: []protocol.ArgType{protocol.AddressArg},
+			Handler: func(ctx context.Context, sm protocol.StateReader, args ...[]byte) ([]byte, error) {
+				addr, err := address.FromString(string(args[0]))
+				if err != nil {
+					return nil, err
+				}
+				balance, err := p.UnclaimedBalance(ctx, sm, addr)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(balance.String()), nil
+			},
+		},
 	}
 }
 
+// Name returns the name of the protocol
+func (p *Protocol) Name() string {
+	return "Rewarding"
+}
+
+// Version returns the version of the protocol
+func (p *Protocol) Version() uint {
+	return 1
+}
+
 // Register registers the protocol with a unique ID
 func (p *Protocol) Register(r *protocol.Registry) error {
 	return r.Register(protocolID, p)
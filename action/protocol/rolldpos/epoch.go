@@ -8,11 +8,13 @@ package rolldpos
 
 import (
 	"context"
+	"sort"
 
 	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 )
@@ -27,37 +29,25 @@ type Protocol struct {
 	numSubEpochsDardanelles uint64
 	dardanellesHeight       uint64
 	dardanellesOn           bool
+	// the committee sizes changed at the fork epochs
+	numDelegatesSchedule          []genesis.EpochValue
+	numCandidateDelegatesSchedule []genesis.EpochValue
+	router                        *protocol.ReadStateRouter
 }
 
 // FindProtocol return a registered protocol from registry
 func FindProtocol(registry *protocol.Registry) *Protocol {
-	if registry == nil {
+	var rp *Protocol
+	if !protocol.FindAs(registry, protocolID, &rp) {
 		return nil
 	}
-	p, ok := registry.Find(protocolID)
-	if !ok {
-		return nil
-	}
-	rp, ok := p.(*Protocol)
-	if !ok {
-		log.S().Panic("fail to cast rolldpos protocol")
-	}
 	return rp
 }
 
 // MustGetProtocol return a registered protocol from registry
 func MustGetProtocol(registry *protocol.Registry) *Protocol {
-	if registry == nil {
-		log.S().Panic("registry cannot be nil")
-	}
-	p, ok := registry.Find(protocolID)
-	if !ok {
-		log.S().Panic("rolldpos protocol is not registered")
-	}
-	rp, ok := p.(*Protocol)
-	if !ok {
-		log.S().Panic("fail to cast to rolldpos protocol")
-	}
+	var rp *Protocol
+	protocol.MustFindAs(registry, protocolID, &rp)
 	return rp
 }
 
@@ -74,6 +64,49 @@ func EnableDardanellesSubEpoch(height, numSubEpochs uint64) Option {
 	}
 }
 
+// EnableCommitteeSchedule will change the number of delegates and candidate delegates from the epochs of the
+// schedules on. The length of an epoch changes along with the number of delegates, so that every delegate still
+// produces a block in each sub epoch.
+func EnableCommitteeSchedule(numDelegates, numCandidateDelegates []genesis.EpochValue) Option {
+	return func(p *Protocol) error {
+		if err := validateSchedule(numDelegates); err != nil {
+			return errors.Wrap(err, "invalid schedule of number of delegates")
+		}
+		if err := validateSchedule(numCandidateDelegates); err != nil {
+			return errors.Wrap(err, "invalid schedule of number of candidate delegates")
+		}
+		p.numDelegatesSchedule = numDelegates
+		p.numCandidateDelegatesSchedule = numCandidateDelegates
+		return nil
+	}
+}
+
+func validateSchedule(schedule []genesis.EpochValue) error {
+	var lastEpoch uint64
+	for _, ev := range schedule {
+		if ev.Epoch <= lastEpoch {
+			return errors.Errorf("epoch %d isn't after epoch %d", ev.Epoch, lastEpoch)
+		}
+		if ev.Value == 0 {
+			return errors.Errorf("zero value at epoch %d", ev.Epoch)
+		}
+		lastEpoch = ev.Epoch
+	}
+	return nil
+}
+
+// scheduledValue returns the value of the schedule in effect at the epoch, or the default value before the schedule
+func scheduledValue(schedule []genesis.EpochValue, epochNum uint64, defaultValue uint64) uint64 {
+	value := defaultValue
+	for _, ev := range schedule {
+		if ev.Epoch > epochNum {
+			break
+		}
+		value = ev.Value
+	}
+	return value
+}
+
 // NewProtocol returns a new rolldpos protocol
 func NewProtocol(numCandidateDelegates, numDelegates, numSubEpochs uint64, opts ...Option) *Protocol {
 	if numCandidateDelegates < numDelegates {
@@ -89,6 +122,7 @@ func NewProtocol(numCandidateDelegates, numDelegates, numSubEpochs uint64, opts
 			log.S().Panicf("Failed to execute epoch protocol creation option %p: %v", opt, err)
 		}
 	}
+	p.router = protocol.MustNewReadStateRouter(p.ReadStateMethods()...)
 	return p
 }
 
@@ -97,46 +131,55 @@ func (p *Protocol) Handle(context.Context, action.Action, protocol.StateManager)
 	return nil, nil
 }
 
-// ReadState read the state on blockchain via protocol
+// ReadState read the state on blockchain via protocol, routing the read to the method described in ReadStateMethods
 func (p *Protocol) ReadState(ctx context.Context, sm protocol.StateReader, method []byte, args ...[]byte) ([]byte, error) {
-	switch string(method) {
-	case "NumCandidateDelegates":
-		return byteutil.Uint64ToBytes(p.numCandidateDelegates), nil
-	case "NumDelegates":
-		return byteutil.Uint64ToBytes(p.numDelegates), nil
-	case "NumSubEpochs":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		numSubEpochs := p.NumSubEpochs(byteutil.BytesToUint64(args[0]))
-		return byteutil.Uint64ToBytes(numSubEpochs), nil
-	case "EpochNumber":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		epochNumber := p.GetEpochNum(byteutil.BytesToUint64(args[0]))
-		return byteutil.Uint64ToBytes(epochNumber), nil
-	case "EpochHeight":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
-		}
-		epochHeight := p.GetEpochHeight(byteutil.BytesToUint64(args[0]))
-		return byteutil.Uint64ToBytes(epochHeight), nil
-	case "EpochLastHeight":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
+	return p.router.ReadState(ctx, sm, method, args...)
+}
+
+// ReadStateMethods describes the methods of ReadState. The committee sizes are the ones of the epoch if the optional
+// epoch number is given, and the initial ones otherwise.
+func (p *Protocol) ReadStateMethods() []protocol.ReadStateMethod {
+	epochNum := []protocol.ArgType{protocol.Uint64Arg}
+	committeeSize := func(name string, initial uint64, at func(uint64) uint64) protocol.ReadStateMethod {
+		return protocol.ReadStateMethod{
+			Name:         name,
+			OptionalArgs: epochNum,
+			Handler: func(_ context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				if len(args) != 0 {
+					return byteutil.Uint64ToBytes(at(byteutil.BytesToUint64(args[0]))), nil
+				}
+				return byteutil.Uint64ToBytes(initial), nil
+			},
 		}
-		epochLastHeight := p.GetEpochLastBlockHeight(byteutil.BytesToUint64(args[0]))
-		return byteutil.Uint64ToBytes(epochLastHeight), nil
-	case "SubEpochNumber":
-		if len(args) != 1 {
-			return nil, errors.Errorf("invalid number of arguments %d", len(args))
+	}
+	uint64Method := func(name string, f func(uint64) uint64) protocol.ReadStateMethod {
+		return protocol.ReadStateMethod{
+			Name: name,
+			Args: []protocol.ArgType{protocol.Uint64Arg},
+			Handler: func(_ context.Context, _ protocol.StateReader, args ...[]byte) ([]byte, error) {
+				return byteutil.Uint64ToBytes(f(byteutil.BytesToUint64(args[0]))), nil
+			},
 		}
-		subEpochNumber := p.GetSubEpochNum(byteutil.BytesToUint64(args[0]))
-		return byteutil.Uint64ToBytes(subEpochNumber), nil
-	default:
-		return nil, errors.New("corresponding method isn't found")
 	}
+	return []protocol.ReadStateMethod{
+		committeeSize("NumCandidateDelegates", p.numCandidateDelegates, p.NumCandidateDelegatesAt),
+		committeeSize("NumDelegates", p.numDelegates, p.NumDelegatesAt),
+		uint64Method("NumSubEpochs", p.NumSubEpochs),
+		uint64Method("EpochNumber", p.GetEpochNum),
+		uint64Method("EpochHeight", p.GetEpochHeight),
+		uint64Method("EpochLastHeight", p.GetEpochLastBlockHeight),
+		uint64Method("SubEpochNumber", p.GetSubEpochNum),
+	}
+}
+
+// Name returns the name of the protocol
+func (p *Protocol) Name() string {
+	return "Roll-DPoS"
+}
+
+// Version returns the version of the protocol
+func (p *Protocol) Version() uint {
+	return 1
 }
 
 // Register registers the protocol with a unique ID
@@ -154,16 +197,31 @@ func (p *Protocol) Validate(context.Context, action.Action) error {
 	return nil
 }
 
-// NumCandidateDelegates returns the number of delegate candidates for an epoch
+// NumCandidateDelegates returns the number of delegate candidates for an epoch before the committee schedule
 func (p *Protocol) NumCandidateDelegates() uint64 {
 	return p.numCandidateDelegates
 }
 
-// NumDelegates returns the number of delegates in an epoch
+// NumDelegates returns the number of delegates in an epoch before the committee schedule
 func (p *Protocol) NumDelegates() uint64 {
 	return p.numDelegates
 }
 
+// NumCandidateDelegatesAt returns the number of delegate candidates in the epoch, which is no less than the number of
+// delegates in the epoch
+func (p *Protocol) NumCandidateDelegatesAt(epochNum uint64) uint64 {
+	numCandidateDelegates := scheduledValue(p.numCandidateDelegatesSchedule, epochNum, p.numCandidateDelegates)
+	if numDelegates := p.NumDelegatesAt(epochNum); numCandidateDelegates < numDelegates {
+		return numDelegates
+	}
+	return numCandidateDelegates
+}
+
+// NumDelegatesAt returns the number of delegates in the epoch
+func (p *Protocol) NumDelegatesAt(epochNum uint64) uint64 {
+	return scheduledValue(p.numDelegatesSchedule, epochNum, p.numDelegates)
+}
+
 // NumSubEpochs returns the number of subEpochs given a block height
 func (p *Protocol) NumSubEpochs(height uint64) uint64 {
 	if !p.dardanellesOn || height < p.dardanellesHeight {
@@ -172,17 +230,71 @@ func (p *Protocol) NumSubEpochs(height uint64) uint64 {
 	return p.numSubEpochsDardanelles
 }
 
-// GetEpochNum returns the number of the epoch for a given height
-func (p *Protocol) GetEpochNum(height uint64) uint64 {
+// epochSegment is a run of epochs of the same length, which starts from the epoch at the height
+type epochSegment struct {
+	epochNum uint64
+	height   uint64
+	length   uint64
+}
+
+// segments returns the runs of epochs of the same length in ascending order. The length of an epoch changes with the
+// number of delegates of the schedule, and with the number of sub epochs from the epoch which the Dardanelles height
+// belongs to if dardanellesOn is true.
+func (p *Protocol) segments(dardanellesOn bool) []epochSegment {
+	var dardanellesEpoch uint64
+	epochNums := []uint64{1}
+	if dardanellesOn {
+		dardanellesEpoch = epochNumOf(p.segments(false), p.dardanellesHeight)
+		epochNums = append(epochNums, dardanellesEpoch)
+	}
+	for _, ev := range p.numDelegatesSchedule {
+		epochNums = append(epochNums, ev.Epoch)
+	}
+	sort.Slice(epochNums, func(i, j int) bool { return epochNums[i] < epochNums[j] })
+
+	segments := make([]epochSegment, 0, len(epochNums))
+	for _, epochNum := range epochNums {
+		numSubEpochs := p.numSubEpochs
+		if dardanellesOn && epochNum >= dardanellesEpoch {
+			numSubEpochs = p.numSubEpochsDardanelles
+		}
+		s := epochSegment{epochNum: epochNum, length: p.NumDelegatesAt(epochNum) * numSubEpochs}
+		if n := len(segments); n == 0 {
+			// the first epoch starts at height 1, or at 0 if it's epoch 0
+			s.height = epochNum
+		} else {
+			last := segments[n-1]
+			if last.epochNum == epochNum {
+				continue
+			}
+			s.height = last.height + (epochNum-last.epochNum)*last.length
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// epochNumOf returns the number of the epoch which the height belongs to in the segments
+func epochNumOf(segments []epochSegment, height uint64) uint64 {
 	if height == 0 {
 		return 0
 	}
+	s := segments[0]
+	for _, next := range segments[1:] {
+		if next.height > height {
+			break
+		}
+		s = next
+	}
+	return s.epochNum + (height-s.height)/s.length
+}
+
+// GetEpochNum returns the number of the epoch for a given height
+func (p *Protocol) GetEpochNum(height uint64) uint64 {
 	if !p.dardanellesOn || height <= p.dardanellesHeight {
-		return (height-1)/p.numDelegates/p.numSubEpochs + 1
+		return epochNumOf(p.segments(false), height)
 	}
-	dardanellesEpoch := p.GetEpochNum(p.dardanellesHeight)
-	dardanellesEpochHeight := p.GetEpochHeight(dardanellesEpoch)
-	return dardanellesEpoch + (height-dardanellesEpochHeight)/p.numDelegates/p.numSubEpochsDardanelles
+	return epochNumOf(p.segments(true), height)
 }
 
 // GetEpochHeight returns the start height of an epoch
@@ -190,12 +302,15 @@ func (p *Protocol) GetEpochHeight(epochNum uint64) uint64 {
 	if epochNum == 0 {
 		return 0
 	}
-	dardanellesEpoch := p.GetEpochNum(p.dardanellesHeight)
-	if !p.dardanellesOn || epochNum <= dardanellesEpoch {
-		return (epochNum-1)*p.numDelegates*p.numSubEpochs + 1
+	segments := p.segments(p.dardanellesOn)
+	s := segments[0]
+	for _, next := range segments[1:] {
+		if next.epochNum > epochNum {
+			break
+		}
+		s = next
 	}
-	dardanellesEpochHeight := p.GetEpochHeight(dardanellesEpoch)
-	return dardanellesEpochHeight + (epochNum-dardanellesEpoch)*p.numDelegates*p.numSubEpochsDardanelles
+	return s.height + (epochNum-s.epochNum)*s.length
 }
 
 // GetEpochLastBlockHeight returns the last height of an epoch
@@ -203,7 +318,29 @@ func (p *Protocol) GetEpochLastBlockHeight(epochNum uint64) uint64 {
 	return p.GetEpochHeight(epochNum+1) - 1
 }
 
+// EpochOf returns the number of the epoch which the block height belongs to
+func (p *Protocol) EpochOf(height uint64) uint64 {
+	return p.GetEpochNum(height)
+}
+
+// IsEpochStart returns true if the block height is the first block of its epoch
+func (p *Protocol) IsEpochStart(height uint64) bool {
+	if height == 0 {
+		return false
+	}
+	return height == p.GetEpochHeight(p.GetEpochNum(height))
+}
+
+// IsEpochEnd returns true if the block height is the last block of its epoch
+func (p *Protocol) IsEpochEnd(height uint64) bool {
+	if height == 0 {
+		return false
+	}
+	return height == p.GetEpochLastBlockHeight(p.GetEpochNum(height))
+}
+
 // GetSubEpochNum returns the sub epoch number of a block height
 func (p *Protocol) GetSubEpochNum(height uint64) uint64 {
-	return (height - p.GetEpochHeight(p.GetEpochNum(height))) / p.numDelegates
+	epochNum := p.GetEpochNum(height)
+	return (height - p.GetEpochHeight(epochNum)) / p.NumDelegatesAt(epochNum)
 }
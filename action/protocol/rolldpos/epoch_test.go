@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 )
 
@@ -56,6 +57,100 @@ func TestProtocol_NumDelegates(t *testing.T) {
 	require.Equal(uint64(4), p.NumDelegates())
 }
 
+func TestEnableCommitteeSchedule(t *testing.T) {
+	require := require.New(t)
+	p := NewProtocol(23, 4, 3, EnableCommitteeSchedule(
+		[]genesis.EpochValue{{Epoch: 5, Value: 6}, {Epoch: 10, Value: 8}},
+		[]genesis.EpochValue{{Epoch: 5, Value: 30}, {Epoch: 12, Value: 7}},
+	))
+	// the length of an epoch isn't affected before the schedule
+	require.Equal(uint64(4), p.NumDelegates())
+	require.Equal(uint64(23), p.NumCandidateDelegates())
+	require.Equal(uint64(13), p.GetEpochHeight(2))
+
+	tests := []struct {
+		epochNum              uint64
+		numDelegates          uint64
+		numCandidateDelegates uint64
+	}{
+		{1, 4, 23},
+		{4, 4, 23},
+		{5, 6, 30},
+		{9, 6, 30},
+		{10, 8, 30},
+		{12, 8, 8},
+		{100, 8, 8},
+	}
+	for _, test := range tests {
+		require.Equal(test.numDelegates, p.NumDelegatesAt(test.epochNum))
+		require.Equal(test.numCandidateDelegates, p.NumCandidateDelegatesAt(test.epochNum))
+		result, err := p.ReadState(context.Background(), nil, []byte("NumDelegates"), byteutil.Uint64ToBytes(test.epochNum))
+		require.NoError(err)
+		require.Equal(test.numDelegates, byteutil.BytesToUint64(result))
+		result, err = p.ReadState(context.Background(), nil, []byte("NumCandidateDelegates"), byteutil.Uint64ToBytes(test.epochNum))
+		require.NoError(err)
+		require.Equal(test.numCandidateDelegates, byteutil.BytesToUint64(result))
+	}
+	result, err := p.ReadState(context.Background(), nil, []byte("NumDelegates"))
+	require.NoError(err)
+	require.Equal(uint64(4), byteutil.BytesToUint64(result))
+	// the epoch number must be of 8 bytes
+	for _, method := range []string{"NumDelegates", "NumCandidateDelegates", "EpochHeight"} {
+		_, err = p.ReadState(context.Background(), nil, []byte(method), []byte{1})
+		require.Error(err)
+	}
+
+	// the epochs of 4 delegates have 12 blocks, the ones of 6 delegates from epoch 5 have 18 blocks, and the ones of
+	// 8 delegates from epoch 10 have 24 blocks
+	heights := []struct {
+		height      uint64
+		epochNum    uint64
+		subEpochNum uint64
+		start       bool
+		end         bool
+	}{
+		{48, 4, 2, false, true},
+		{49, 5, 0, true, false},
+		{55, 5, 1, false, false},
+		{66, 5, 2, false, true},
+		{67, 6, 0, true, false},
+		{138, 9, 2, false, true},
+		{139, 10, 0, true, false},
+		{147, 10, 1, false, false},
+		{162, 10, 2, false, true},
+		{163, 11, 0, true, false},
+	}
+	for _, test := range heights {
+		require.Equal(test.epochNum, p.GetEpochNum(test.height), "height %d", test.height)
+		require.Equal(test.subEpochNum, p.GetSubEpochNum(test.height), "height %d", test.height)
+		require.Equal(test.start, p.IsEpochStart(test.height), "height %d", test.height)
+		require.Equal(test.end, p.IsEpochEnd(test.height), "height %d", test.height)
+	}
+	for epochNum, height := range map[uint64]uint64{4: 37, 5: 49, 6: 67, 10: 139, 11: 163} {
+		require.Equal(height, p.GetEpochHeight(epochNum))
+	}
+
+	// the sub epochs of Dardanelles apply to the epochs of the schedule as well
+	p = NewProtocol(23, 4, 3,
+		EnableDardanellesSubEpoch(25, 1),
+		EnableCommitteeSchedule([]genesis.EpochValue{{Epoch: 5, Value: 6}}, nil),
+	)
+	for epochNum, height := range map[uint64]uint64{3: 25, 4: 29, 5: 33, 6: 39} {
+		require.Equal(height, p.GetEpochHeight(epochNum))
+		require.Equal(epochNum, p.GetEpochNum(height))
+	}
+
+	p = NewProtocol(23, 4, 3)
+	for _, schedule := range [][]genesis.EpochValue{
+		{{Epoch: 5, Value: 6}, {Epoch: 5, Value: 8}},
+		{{Epoch: 5, Value: 6}, {Epoch: 3, Value: 8}},
+		{{Epoch: 5, Value: 0}},
+	} {
+		require.Error(EnableCommitteeSchedule(schedule, nil)(p))
+		require.Error(EnableCommitteeSchedule(nil, schedule)(p))
+	}
+}
+
 func TestProtocol_ReadState(t *testing.T) {
 	require := require.New(t)
 	p := NewProtocol(23, 4, 3)
@@ -243,3 +338,36 @@ func TestGetSubEpochNum(t *testing.T) {
 		require.Equal(expectedSubEpochNums[i], subEpochNum)
 	}
 }
+
+func TestEpochBoundary(t *testing.T) {
+	require := require.New(t)
+	// epochs of 12 blocks, and of 24 blocks from the epoch which height 30 belongs to
+	p := NewProtocol(23, 4, 3, EnableDardanellesSubEpoch(30, 6))
+
+	starts := map[uint64]uint64{1: 1, 13: 2, 25: 3, 49: 4, 73: 5}
+	ends := map[uint64]uint64{12: 1, 24: 2, 48: 3, 72: 4}
+	for height := uint64(0); height <= 80; height++ {
+		epochNum, isStart := starts[height]
+		require.Equal(isStart, p.IsEpochStart(height), "height %d", height)
+		if isStart {
+			require.Equal(epochNum, p.EpochOf(height))
+		}
+		epochNum, isEnd := ends[height]
+		require.Equal(isEnd, p.IsEpochEnd(height), "height %d", height)
+		if isEnd {
+			require.Equal(epochNum, p.EpochOf(height))
+		}
+	}
+	// the epoch in which the number of sub epochs changes takes the new length from its start
+	require.Equal(uint64(3), p.EpochOf(30))
+	require.Equal(uint64(3), p.EpochOf(36))
+	require.Equal(uint64(3), p.EpochOf(48))
+
+	// an epoch of a single block both starts and ends at it
+	p = NewProtocol(1, 1, 1)
+	for height := uint64(1); height <= 3; height++ {
+		require.True(p.IsEpochStart(height))
+		require.True(p.IsEpochEnd(height))
+		require.Equal(height, p.EpochOf(height))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+)
+
+var (
+	// delegateListKey is the key of the owners of all the delegates
+	delegateListKey = []byte("delegateList")
+	// voterListKey is the key of all the voters which own buckets
+	voterListKey = []byte("voterList")
+)
+
+// addressList is a list of addresses in the order they are added
+type addressList []string
+
+// Serialize serializes the address list to bytes
+func (l *addressList) Serialize() ([]byte, error) {
+	return proto.Marshal(&stakingpb.Addresses{Addresses: *l})
+}
+
+// Deserialize deserializes bytes to the address list
+func (l *addressList) Deserialize(buf []byte) error {
+	pb := &stakingpb.Addresses{}
+	if err := proto.Unmarshal(buf, pb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal address list")
+	}
+	*l = pb.Addresses
+	return nil
+}
+
+func stakingGetAddressList(sr protocol.StateReader, key []byte) (addressList, error) {
+	var l addressList
+	_, err := sr.State(
+		&l,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(key))
+	switch errors.Cause(err) {
+	case nil, state.ErrStateNotExist:
+		return l, nil
+	default:
+		return nil, err
+	}
+}
+
+// stakingAddToAddressList returns the write of the list of the key with the address added
+func stakingAddToAddressList(sr protocol.StateReader, key []byte, addr string) (protocol.StateWrite, error) {
+	l, err := stakingGetAddressList(sr, key)
+	if err != nil {
+		return protocol.StateWrite{}, err
+	}
+	l = append(l, addr)
+	return addressListWrite(key, l), nil
+}
+
+// stakingRemoveFromAddressList returns the write of the list of the key with the address removed
+func stakingRemoveFromAddressList(sr protocol.StateReader, key []byte, addr string) (protocol.StateWrite, error) {
+	l, err := stakingGetAddressList(sr, key)
+	if err != nil {
+		return protocol.StateWrite{}, err
+	}
+	for i, a := range l {
+		if a == addr {
+			l = append(l[:i], l[i+1:]...)
+			break
+		}
+	}
+	return addressListWrite(key, l), nil
+}
+
+func addressListWrite(key []byte, l addressList) protocol.StateWrite {
+	return protocol.StateWrite{
+		State:   &l,
+		Options: []protocol.StateOption{protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeyOption(key)},
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state/factory"
+)
+
+// ErrStateAudit indicates that the staking state violates an invariant
+var ErrStateAudit = errors.New("staking state audit failed")
+
+// StateAudit scans the staking state and checks that the owner, name and reward address indices are consistent with
+// the delegate records, that every bucket index appears in exactly one voter's bucket indices and points to a bucket
+// owned by the voter, that every bucket appears in exactly one candidate's buckets, which are the buckets stored under
+// the candidate name and counted by the candidate's bucket count, and that the votes of every delegate equal the sum
+// of the weighted votes of its buckets. All the violations are reported in the returned error.
+func StateAudit(sr protocol.StateReader, g genesis.Genesis) error {
+	return stateAudit(sr, g.VoteWeightCalConsts)
+}
+
+func stateAudit(sr protocol.StateReader, c genesis.VoteWeightCalConsts) error {
+	var violations []string
+	report := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	// delegates and their indices
+	owners, err := stakingGetAddressList(sr, delegateListKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to get delegate list")
+	}
+	delegates := make(DelegateMap)
+	seenOwners := make(map[string]bool)
+	for _, owner := range owners {
+		if seenOwners[owner] {
+			report("owner %s appears more than once in delegate list", owner)
+			continue
+		}
+		seenOwners[owner] = true
+		d, err := stakingGetDelegate(sr, owner)
+		if err != nil {
+			report("failed to get delegate owned by %s: %v", owner, err)
+			continue
+		}
+		if d.Owner != owner {
+			report("delegate under owner key %s has owner %s", owner, d.Owner)
+		}
+		if prev, ok := delegates[d.CanName]; ok {
+			report("name %x is used by delegates owned by %s and %s", d.CanName, prev.Owner, owner)
+		}
+		delegates[d.CanName] = d
+		var nameOwner delegateOwner
+		if _, err := sr.State(
+			&nameOwner,
+			protocol.NamespaceOption(factory.StakingNameSpace),
+			protocol.KeyOption(delegateNameKey(d.CanName))); err != nil {
+			report("failed to get owner of name key %x: %v", delegateNameKey(d.CanName), err)
+		} else if string(nameOwner) != owner {
+			report("name key %x points to %s, expected %s", delegateNameKey(d.CanName), nameOwner, owner)
+		}
+		rewardOwner, err := stakingGetRewardAddressOwner(sr, d.RewardAddress)
+		if err != nil {
+			report("failed to get owner of reward address %s: %v", d.RewardAddress, err)
+			continue
+		}
+		if rewardOwner != owner {
+			// the reward address may be shared by the delegates registered before the activation
+			other, err := stakingGetDelegate(sr, rewardOwner)
+			if err != nil || other.RewardAddress != d.RewardAddress {
+				report("reward address %s points to %s, whose reward address is different", d.RewardAddress, rewardOwner)
+			}
+		}
+	}
+
+	// buckets and their indices
+	total, err := stakingGetTotalCount(sr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get total bucket count")
+	}
+	voters, err := stakingGetAddressList(sr, voterListKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to get voter list")
+	}
+	votes := make(map[CandName]*big.Int)
+	seenBuckets := make(map[uint64]string)
+	indexedNames := make(map[uint64]CandName)
+	for _, voter := range voters {
+		bis, err := stakingGetBucketIndices(sr, voter)
+		if err != nil {
+			report("failed to get bucket indices of voter %s: %v", voter, err)
+			continue
+		}
+		for _, bi := range bis.GetIndices() {
+			if prev, ok := seenBuckets[bi.Index]; ok {
+				report("bucket %d appears in bucket indices of both %s and %s", bi.Index, prev, voter)
+				continue
+			}
+			seenBuckets[bi.Index] = voter
+			indexedNames[bi.Index] = ToCandName(bi.CanName)
+			if bi.Index >= total {
+				report("bucket %d of voter %s exceeds total bucket count %d", bi.Index, voter, total)
+			}
+			name := ToCandName(bi.CanName)
+			bucket, err := stakingGetBucket(sr, name, bi.Index)
+			if err != nil {
+				report("failed to get bucket %d at key %x: %v", bi.Index, bucketKey(name, bi.Index), err)
+				continue
+			}
+			if bucket.Owner != voter {
+				report("bucket %d is owned by %s, expected %s", bi.Index, bucket.Owner, voter)
+			}
+			if ToCandName([]byte(bucket.CandidateName)) != name {
+				report("bucket %d votes for %s, but is indexed under %x", bi.Index, bucket.CandidateName, name)
+			}
+			if bucket.IsUnstaked() {
+				continue
+			}
+			d, ok := delegates[name]
+			selfStake := ok && d.SelfStakeBucketIdx == bi.Index && d.Owner == voter
+			if _, ok := votes[name]; !ok {
+				votes[name] = big.NewInt(0)
+			}
+			votes[name].Add(votes[name], calculateVoteWeight(c, bucket, selfStake))
+		}
+	}
+
+	// the buckets of the candidates
+	names := make(map[CandName]bool)
+	for name := range delegates {
+		names[name] = true
+	}
+	for _, name := range indexedNames {
+		names[name] = true
+	}
+	listedNames := make(map[uint64]CandName)
+	for name := range names {
+		listed, err := candidateBuckets(sr, name)
+		if err != nil {
+			report("failed to get buckets of candidate %x: %v", name, err)
+			continue
+		}
+		for _, index := range listed {
+			if prev, ok := listedNames[index]; ok {
+				report("bucket %d appears in buckets of both candidate %x and %x", index, prev, name)
+				continue
+			}
+			listedNames[index] = name
+			indexed, ok := indexedNames[index]
+			switch {
+			case !ok:
+				report("bucket %d of candidate %x isn't in any voter's bucket indices", index, name)
+			case indexed != name:
+				report("bucket %d of candidate %x is indexed under candidate %x", index, name, indexed)
+			}
+		}
+		count, err := stakingGetCandidateBucketCount(sr, name)
+		if err != nil {
+			report("failed to get bucket count of candidate %x: %v", name, err)
+			continue
+		}
+		if count != uint64(len(listed)) {
+			report("candidate %x has bucket count %d, expected %d", name, count, len(listed))
+		}
+	}
+	for index, name := range indexedNames {
+		if _, ok := listedNames[index]; !ok {
+			report("bucket %d indexed under candidate %x isn't in the candidate's buckets", index, name)
+		}
+	}
+
+	for name, d := range delegates {
+		expected, ok := votes[name]
+		if !ok {
+			expected = big.NewInt(0)
+		}
+		if d.Votes.Cmp(expected) != 0 {
+			report("delegate %x has votes %s, expected %s", name, d.Votes, expected)
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return errors.Wrap(ErrStateAudit, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// candidateBuckets returns the indices of the buckets stored under the candidate name in ascending order
+func candidateBuckets(sr protocol.StateReader, name CandName) ([]uint64, error) {
+	_, iter, err := sr.States(protocol.NamespaceOption(factory.StakingNameSpace), protocol.PrefixOption(name[:]))
+	if err != nil {
+		return nil, err
+	}
+	var indices []uint64
+	for i := 0; i < iter.Size(); i++ {
+		var vb VoteBucket
+		key, err := iter.Next(&vb)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != len(bucketKey(name, 0)) {
+			return nil, errors.Errorf("unexpected key %x of bucket", key)
+		}
+		indices = append(indices, byteutil.BytesToUint64BigEndian(key[len(name):]))
+	}
+	return indices, nil
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestStateAudit(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	c := genesis.Default.VoteWeightCalConsts
+	name1 := ToCandName([]byte("delegate1"))
+	name2 := ToCandName([]byte("delegate2"))
+	voter1 := identityset.Address(1).String()
+	voter2 := identityset.Address(2).String()
+	owner1 := identityset.Address(11).String()
+	owner2 := identityset.Address(21).String()
+
+	var sfs []factory.Factory
+	defer func() {
+		for _, sf := range sfs {
+			require.NoError(sf.Stop(ctx))
+		}
+	}()
+	// newState builds a consistent staking state with two delegates, where the bucket 0 is the self-stake bucket of
+	// delegate1 and the bucket 2 is unstaked
+	newState := func() factory.WorkingSet {
+		sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+		require.NoError(err)
+		require.NoError(sf.Start(ctx))
+		sfs = append(sfs, sf)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+
+		delegates := map[CandName]*Delegate{
+			name1: {
+				Owner:              owner1,
+				Address:            identityset.Address(12).String(),
+				RewardAddress:      identityset.Address(13).String(),
+				CanName:            name1,
+				Votes:              big.NewInt(0),
+				SelfStakeBucketIdx: 0,
+			},
+			name2: {
+				Owner:              owner2,
+				Address:            identityset.Address(22).String(),
+				RewardAddress:      identityset.Address(23).String(),
+				CanName:            name2,
+				Votes:              big.NewInt(0),
+				SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+			},
+		}
+		for i, e := range []struct {
+			owner     string
+			name      CandName
+			unstaked  bool
+			autoStake bool
+		}{
+			{owner1, name1, false, true},
+			{voter1, name2, false, false},
+			{voter1, name1, true, false},
+			{voter2, name1, false, true},
+		} {
+			vb, err := NewVoteBucket(e.name.String(), e.owner, "1000000", 91, time.Unix(1580000000, 0), e.autoStake)
+			require.NoError(err)
+			if e.unstaked {
+				vb.UnstakeStartTime.Seconds = 1590000000
+			} else {
+				d := delegates[e.name]
+				require.NoError(d.AddVote(calculateVoteWeight(c, vb, d.SelfStakeBucketIdx == uint64(i))))
+			}
+			require.NoError(stakingPutBucket(ws, e.name, vb))
+			require.NoError(stakingPutBucketIndex(ws, e.owner, NewBucketIndex(uint64(i), e.name)))
+		}
+		for _, d := range delegates {
+			require.NoError(stakingPutDelegate(ws, d))
+			require.NoError(stakingPutRewardAddressOwner(ws, d.RewardAddress, d.Owner))
+		}
+		require.NoError(stateAudit(ws, c))
+		return ws
+	}
+
+	for _, e := range []struct {
+		desc    string
+		corrupt func(protocol.StateManager)
+	}{
+		{
+			"bucket in two voters' indices",
+			func(sm protocol.StateManager) {
+				require.NoError(stakingPutBucketIndex(sm, voter2, NewBucketIndex(1, name2)))
+			},
+		},
+		{
+			"bucket owned by another voter",
+			func(sm protocol.StateManager) {
+				vb, err := stakingGetBucket(sm, name1, 3)
+				require.NoError(err)
+				vb.Owner = voter1
+				_, err = sm.PutState(
+					vb,
+					protocol.NamespaceOption(factory.StakingNameSpace),
+					protocol.KeyOption(bucketKey(name1, 3)))
+				require.NoError(err)
+			},
+		},
+		{
+			"votes mismatch",
+			func(sm protocol.StateManager) {
+				d, err := stakingGetDelegateByName(sm, name2)
+				require.NoError(err)
+				require.NoError(d.AddVote(big.NewInt(1)))
+				require.NoError(stakingPutDelegate(sm, d))
+			},
+		},
+		{
+			"self-stake bonus missing",
+			func(sm protocol.StateManager) {
+				d, err := stakingGetDelegateByName(sm, name1)
+				require.NoError(err)
+				d.SelfStakeBucketIdx = NoSelfStakeBucketIndex
+				require.NoError(stakingPutDelegate(sm, d))
+			},
+		},
+		{
+			"name index points to another owner",
+			func(sm protocol.StateManager) {
+				o := delegateOwner(owner2)
+				_, err := sm.PutState(
+					&o,
+					protocol.NamespaceOption(factory.StakingNameSpace),
+					protocol.KeyOption(delegateNameKey(name1)))
+				require.NoError(err)
+			},
+		},
+		{
+			"reward index points to another owner",
+			func(sm protocol.StateManager) {
+				require.NoError(stakingPutRewardAddressOwner(sm, identityset.Address(13).String(), owner2))
+			},
+		},
+		{
+			"bucket count mismatch",
+			func(sm protocol.StateManager) {
+				require.NoError(stakingPutCandidateBucketCount(sm, name2, 2))
+			},
+		},
+		{
+			"bucket in no voter's indices",
+			func(sm protocol.StateManager) {
+				vb, err := stakingGetBucket(sm, name1, 3)
+				require.NoError(err)
+				_, err = sm.PutState(
+					vb,
+					protocol.NamespaceOption(factory.StakingNameSpace),
+					protocol.KeyOption(bucketKey(name1, 9)))
+				require.NoError(err)
+			},
+		},
+		{
+			"bucket indexed under another candidate",
+			func(sm protocol.StateManager) {
+				require.NoError(stakingDelBucketIndex(sm, voter2, 3))
+				require.NoError(stakingPutBucketIndex(sm, voter2, NewBucketIndex(3, name2)))
+			},
+		},
+		{
+			"delegate missing",
+			func(sm protocol.StateManager) {
+				key, err := delegateKey(owner2)
+				require.NoError(err)
+				_, err = sm.DelState(
+					protocol.NamespaceOption(factory.StakingNameSpace),
+					protocol.KeyOption(key))
+				require.NoError(err)
+			},
+		},
+	} {
+		ws := newState()
+		e.corrupt(ws)
+		err := stateAudit(ws, c)
+		require.Error(err, e.desc)
+		require.Equal(ErrStateAudit, errors.Cause(err), e.desc)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// role is the relation between the caller of an action and the candidates
+type role int
+
+const (
+	// roleStranger is neither the owner nor the operator of any candidate
+	roleStranger role = iota
+	// roleOwner owns a candidate, and controls the candidate record and its self-stake
+	roleOwner
+	// roleOperator operates a candidate, which is only used to sign blocks
+	roleOperator
+)
+
+// authorities is the matrix of the roles allowed to take each candidate action. An owner can't register another
+// candidate, and an operator can't change the candidate record it operates, but can register a candidate of its own.
+var authorities = map[string]map[role]bool{
+	"candidateRegister": {
+		roleStranger: true,
+		roleOperator: true,
+	},
+	"candidateUpdate": {
+		roleOwner: true,
+	},
+	"candidateActivate": {
+		roleOwner: true,
+	},
+}
+
+// isAuthorized returns true if the role is allowed to take the action
+func isAuthorized(act action.Action, r role) bool {
+	return authorities[actionType(act)][r]
+}
+
+// callerRole returns the role of the caller. If the caller owns a candidate, the candidate is returned as well.
+func callerRole(sr protocol.StateReader, caller string) (role, *Delegate, error) {
+	d, err := stakingGetDelegate(sr, caller)
+	switch errors.Cause(err) {
+	case nil:
+		return roleOwner, d, nil
+	case state.ErrStateNotExist:
+	default:
+		return roleStranger, nil, errors.Wrapf(err, "failed to get delegate owned by %s", caller)
+	}
+	// the operators aren't indexed, because they are only looked up when the caller isn't an owner
+	owners, err := stakingGetAddressList(sr, delegateListKey)
+	if err != nil {
+		return roleStranger, nil, errors.Wrap(err, "failed to get delegate list")
+	}
+	for _, owner := range owners {
+		d, err := stakingGetDelegate(sr, owner)
+		if err != nil {
+			return roleStranger, nil, errors.Wrapf(err, "failed to get delegate owned by %s", owner)
+		}
+		if d.Address == caller {
+			return roleOperator, nil, nil
+		}
+	}
+	return roleStranger, nil, nil
+}
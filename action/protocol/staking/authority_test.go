@@ -0,0 +1,126 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/unit"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestCandidateAuthority(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	g := genesis.Default
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	owner := identityset.Address(1)
+	operator := identityset.Address(11)
+	stranger := identityset.Address(3)
+	name := ToCandName([]byte("delegate1"))
+
+	var sfs []factory.Factory
+	defer func() {
+		for _, sf := range sfs {
+			require.NoError(sf.Stop(ctx))
+		}
+	}()
+	// newState registers delegate1 with a bucket of the owner qualified for self-stake
+	newState := func() factory.WorkingSet {
+		sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+		require.NoError(err)
+		require.NoError(sf.Start(ctx))
+		sfs = append(sfs, sf)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+
+		vb, err := NewVoteBucket("delegate1", owner.String(), unit.ConvertIotxToRau(1200000).String(), 91, time.Unix(1580000000, 0), true)
+		require.NoError(err)
+		require.NoError(stakingPutBucket(ws, name, vb))
+		require.NoError(stakingPutBucketIndex(ws, owner.String(), NewBucketIndex(0, name)))
+		require.NoError(stakingPutDelegate(ws, &Delegate{
+			Owner:              owner.String(),
+			Address:            operator.String(),
+			RewardAddress:      identityset.Address(21).String(),
+			CanName:            name,
+			Votes:              calculateVoteWeight(g.VoteWeightCalConsts, vb, false),
+			SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+		}))
+		require.NoError(stakingPutRewardAddressOwner(ws, identityset.Address(21).String(), owner.String()))
+		return ws
+	}
+
+	register, err := action.NewCandidateRegister(1, "delegate2", identityset.Address(12).String(), identityset.Address(22).String(), nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	update, err := action.NewCandidateUpdate(1, identityset.Address(13).String(), identityset.Address(23).String(), nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	activate, err := action.NewCandidateActivate(1, 0, nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+
+	p := NewProtocol()
+	for _, e := range []struct {
+		act    action.Action
+		caller int
+		status uint64
+	}{
+		{register, 1, ReceiptStatusErrUnauthorized},
+		{register, 11, uint64(iotextypes.ReceiptStatus_Success)},
+		{register, 3, uint64(iotextypes.ReceiptStatus_Success)},
+		{update, 1, uint64(iotextypes.ReceiptStatus_Success)},
+		{update, 11, ReceiptStatusErrUnauthorized},
+		{update, 3, ReceiptStatusErrUnauthorized},
+		{activate, 1, uint64(iotextypes.ReceiptStatus_Success)},
+		{activate, 11, ReceiptStatusErrUnauthorized},
+		{activate, 3, ReceiptStatusErrUnauthorized},
+	} {
+		ws := newState()
+		caller := identityset.Address(e.caller)
+		ctx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: caller})
+		r, err := handle(ctx, p, e.act, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status, "%s by %s", actionType(e.act), caller)
+
+		// the candidate record is only changed by the owner
+		d, err := stakingGetDelegateByName(ws, name)
+		require.NoError(err)
+		require.Equal(owner.String(), d.Owner)
+		if e.caller != 1 {
+			require.Equal(operator.String(), d.Address)
+			require.Equal(uint64(NoSelfStakeBucketIndex), d.SelfStakeBucketIdx)
+		}
+		require.NoError(StateAudit(ws, genesis.Default))
+	}
+
+	// the roles are resolved from the candidate records
+	ws := newState()
+	for _, e := range []struct {
+		caller string
+		role   role
+	}{
+		{owner.String(), roleOwner},
+		{operator.String(), roleOperator},
+		{stranger.String(), roleStranger},
+	} {
+		r, d, err := callerRole(ws, e.caller)
+		require.NoError(err)
+		require.Equal(e.role, r)
+		require.Equal(e.role == roleOwner, d != nil)
+	}
+}
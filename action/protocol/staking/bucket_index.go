@@ -85,6 +85,16 @@ func (bis *BucketIndices) deleteBucketIndex(index uint64) {
 	}
 }
 
+// findBucketIndex returns the bucket index of the given index, or nil if it isn't in the bucket indices
+func (bis *BucketIndices) findBucketIndex(index uint64) *BucketIndex {
+	for _, bucketIndex := range bis.Indices {
+		if bucketIndex.Index == index {
+			return NewBucketIndex(bucketIndex.Index, ToCandName(bucketIndex.CanName))
+		}
+	}
+	return nil
+}
+
 func stakingGetBucketIndices(sr protocol.StateReader, voterAddr string) (*BucketIndices, error) {
 	addrHash, err := addrToHash(voterAddr)
 	if err != nil {
@@ -105,18 +115,32 @@ func stakingPutBucketIndex(sm protocol.StateManager, voterAddr string, bucketInd
 	if err != nil {
 		return errors.Wrap(err, "failed to get address hash from voter's address")
 	}
-	var bis BucketIndices
-	if _, err := sm.State(
+	var (
+		bis    BucketIndices
+		writes []protocol.StateWrite
+	)
+	_, err = sm.State(
 		&bis,
 		protocol.NamespaceOption(factory.StakingNameSpace),
-		protocol.LegacyKeyOption(addrHash)); err != nil && errors.Cause(err) != state.ErrStateNotExist {
+		protocol.LegacyKeyOption(addrHash))
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		// the voter is added to the voter list along with its first bucket index
+		w, err := stakingAddToAddressList(sm, voterListKey, voterAddr)
+		if err != nil {
+			return errors.Wrap(err, "failed to add voter to voter list")
+		}
+		writes = append(writes, w)
+	default:
 		return err
 	}
 	bis.addBucketIndex(bucketIndex)
-	_, err = sm.PutState(
-		&bis,
-		protocol.NamespaceOption(factory.StakingNameSpace),
-		protocol.LegacyKeyOption(addrHash))
+	writes = append(writes, protocol.StateWrite{
+		State:   &bis,
+		Options: []protocol.StateOption{protocol.NamespaceOption(factory.StakingNameSpace), protocol.LegacyKeyOption(addrHash)},
+	})
+	_, err = sm.PutStates(writes)
 	return err
 }
 
@@ -133,16 +157,25 @@ func stakingDelBucketIndex(sm protocol.StateManager, voterAddr string, index uin
 		return err
 	}
 	bis.deleteBucketIndex(index)
-	if len(bis.GetIndices()) == 0 {
-		_, err = sm.DelState(
-			protocol.NamespaceOption(factory.StakingNameSpace),
-			protocol.LegacyKeyOption(addrHash))
-	} else {
+	if len(bis.GetIndices()) != 0 {
 		_, err = sm.PutState(
 			&bis,
 			protocol.NamespaceOption(factory.StakingNameSpace),
 			protocol.LegacyKeyOption(addrHash))
+		return err
+	}
+	// the voter is removed from the voter list along with its last bucket index, the list being read ahead so that
+	// only the writes are left to fail after the deletion
+	w, err := stakingRemoveFromAddressList(sm, voterListKey, voterAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove voter from voter list")
+	}
+	if _, err := sm.DelState(
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.LegacyKeyOption(addrHash)); err != nil {
+		return err
 	}
+	_, err = sm.PutState(w.State, w.Options...)
 	return err
 }
 
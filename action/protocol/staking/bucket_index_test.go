@@ -11,10 +11,12 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
@@ -151,6 +153,76 @@ func TestGetPutBucketIndex(t *testing.T) {
 	})
 }
 
+func TestVoterIndexOption(t *testing.T) {
+	require := require.New(t)
+	// the buckets are indexed by their owners, instead of keeping the bucket indices of each voter by hand
+	const voterIndexNS = "voterIndex"
+	voterIndex := protocol.IndexOption(voterIndexNS, func(value []byte) [][]byte {
+		var vb VoteBucket
+		if err := vb.Deserialize(value); err != nil {
+			return nil
+		}
+		addrHash, err := addrToHash(vb.Owner)
+		if err != nil {
+			return nil
+		}
+		return [][]byte{addrHash[:]}
+	})
+	voter1, voter2 := identityset.Address(1).String(), identityset.Address(2).String()
+	name := fakeCanName(identityset.Address(3).String(), 3)
+	putBucket := func(sm protocol.StateManager, index uint64, owner string) {
+		vb, err := NewVoteBucket("test", owner, "100", 21, time.Now(), true)
+		require.NoError(err)
+		_, err = sm.PutState(vb, protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeyOption(bucketKey(name, index)), voterIndex)
+		require.NoError(err)
+	}
+	buckets := func(sr protocol.StateReader, voter string) [][]byte {
+		addrHash, err := addrToHash(voter)
+		require.NoError(err)
+		keys, err := protocol.IndexedKeys(sr, voterIndexNS, addrHash[:])
+		require.NoError(err)
+		return keys
+	}
+
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	ctx := context.Background()
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	// create
+	putBucket(ws, 0, voter1)
+	putBucket(ws, 1, voter1)
+	require.Equal([][]byte{bucketKey(name, 0), bucketKey(name, 1)}, buckets(ws, voter1))
+	require.Empty(buckets(ws, voter2))
+
+	// transfer, which is reverted along with the index
+	s := ws.Snapshot()
+	putBucket(ws, 0, voter2)
+	require.Equal([][]byte{bucketKey(name, 1)}, buckets(ws, voter1))
+	require.Equal([][]byte{bucketKey(name, 0)}, buckets(ws, voter2))
+	require.NoError(ws.Revert(s))
+	require.Equal([][]byte{bucketKey(name, 0), bucketKey(name, 1)}, buckets(ws, voter1))
+	require.Empty(buckets(ws, voter2))
+	putBucket(ws, 0, voter2)
+
+	// withdraw
+	_, err = ws.DelState(protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeyOption(bucketKey(name, 1)), voterIndex)
+	require.NoError(err)
+	require.Empty(buckets(ws, voter1))
+	require.Equal([][]byte{bucketKey(name, 0)}, buckets(ws, voter2))
+
+	// the index is committed along with the buckets
+	require.NoError(ws.Finalize())
+	require.NoError(ws.Commit())
+	require.Empty(buckets(sf, voter1))
+	require.Equal([][]byte{bucketKey(name, 0)}, buckets(sf, voter2))
+}
+
 func fakeCanName(addr string, index uint64) CandName {
 	var name CandName
 	copy(name[:4], addr[3:])
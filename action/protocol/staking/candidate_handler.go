@@ -0,0 +1,166 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+func (p *Protocol) handleCandidateRegister(ctx context.Context, act *action.CandidateRegister, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	owner := actionCtx.Caller.String()
+
+	// an owner can only register one delegate
+	r, _, err := callerRole(sm, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized(act, r) {
+		return nil, failAction(errors.Wrapf(ErrUnauthorized, "owner %s", owner))
+	}
+	if !isValidCandidateName(act.Name()) ||
+		!isValidAddress(act.OperatorAddress()) ||
+		!isValidAddress(act.RewardAddress()) {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	// the name is unique
+	name := ToCandName([]byte(act.Name()))
+	_, err = stakingGetDelegateByName(sm, name)
+	if err == nil {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	if errors.Cause(err) != state.ErrStateNotExist {
+		return nil, errors.Wrapf(err, "failed to get delegate %s", act.Name())
+	}
+	conflict, err := isRewardAddressConflict(sm, g, blkCtx.BlockHeight, act.RewardAddress(), owner)
+	if err != nil {
+		return nil, err
+	}
+	if conflict {
+		return nil, failAction(errors.Wrapf(ErrRewardAddressConflict, "reward address %s", act.RewardAddress()))
+	}
+
+	if err := stakingPutDelegate(sm, &Delegate{
+		Owner:              owner,
+		Address:            act.OperatorAddress(),
+		RewardAddress:      act.RewardAddress(),
+		CanName:            name,
+		Votes:              big.NewInt(0),
+		SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to put delegate %s", act.Name())
+	}
+	if err := stakingPutRewardAddressOwner(sm, act.RewardAddress(), owner); err != nil {
+		return nil, errors.Wrapf(err, "failed to put owner of reward address %s", act.RewardAddress())
+	}
+	receipt := p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx)
+	if blkCtx.BlockHeight >= g.BucketIndexReceiptHeight {
+		// no bucket is staked at the registration, so the index tells that the self-stake bucket isn't designated yet
+		receipt.AddBucketIndexLog(p.addr.String(), NoSelfStakeBucketIndex)
+	}
+	return receipt, nil
+}
+
+func (p *Protocol) handleCandidateUpdate(ctx context.Context, act *action.CandidateUpdate, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	owner := actionCtx.Caller.String()
+
+	r, d, err := callerRole(sm, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized(act, r) {
+		return nil, failAction(errors.Wrapf(ErrUnauthorized, "owner %s", owner))
+	}
+	if (act.OperatorAddress() != "" && !isValidAddress(act.OperatorAddress())) ||
+		(act.RewardAddress() != "" && !isValidAddress(act.RewardAddress())) {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+
+	if act.OperatorAddress() != "" {
+		d.Address = act.OperatorAddress()
+	}
+	if act.RewardAddress() != "" && act.RewardAddress() != d.RewardAddress {
+		conflict, err := isRewardAddressConflict(sm, g, blkCtx.BlockHeight, act.RewardAddress(), owner)
+		if err != nil {
+			return nil, err
+		}
+		if conflict {
+			return nil, failAction(errors.Wrapf(ErrRewardAddressConflict, "reward address %s", act.RewardAddress()))
+		}
+		// the index of the old reward address may point to another delegate registered before the activation
+		prevOwner, err := stakingGetRewardAddressOwner(sm, d.RewardAddress)
+		switch errors.Cause(err) {
+		case nil:
+			if prevOwner == owner {
+				if err := stakingDelRewardAddressOwner(sm, d.RewardAddress); err != nil {
+					return nil, errors.Wrapf(err, "failed to delete owner of reward address %s", d.RewardAddress)
+				}
+			}
+		case state.ErrStateNotExist:
+		default:
+			return nil, errors.Wrapf(err, "failed to get owner of reward address %s", d.RewardAddress)
+		}
+		if err := stakingPutRewardAddressOwner(sm, act.RewardAddress(), owner); err != nil {
+			return nil, errors.Wrapf(err, "failed to put owner of reward address %s", act.RewardAddress())
+		}
+		d.RewardAddress = act.RewardAddress()
+	}
+	if err := stakingPutDelegate(sm, d); err != nil {
+		return nil, errors.Wrapf(err, "failed to put delegate owned by %s", owner)
+	}
+	return p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx), nil
+}
+
+// isRewardAddressConflict returns true if the reward address is used by a delegate of another owner. Delegates
+// registered before the activation height may share a reward address.
+func isRewardAddressConflict(sr protocol.StateReader, g genesis.Genesis, height uint64, rewardAddr, owner string) (bool, error) {
+	if height < g.RewardAddressUniqueHeight {
+		return false, nil
+	}
+	o, err := stakingGetRewardAddressOwner(sr, rewardAddr)
+	switch errors.Cause(err) {
+	case nil:
+		return o != owner, nil
+	case state.ErrStateNotExist:
+		return false, nil
+	default:
+		return false, errors.Wrapf(err, "failed to get owner of reward address %s", rewardAddr)
+	}
+}
+
+// isValidCandidateName returns true if the name consists of 1 to 12 lowercase letters and digits
+func isValidCandidateName(name string) bool {
+	if len(name) == 0 || len(name) > len(CandName{}) {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidAddress(addr string) bool {
+	_, err := address.FromString(addr)
+	return err == nil
+}
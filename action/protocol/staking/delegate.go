@@ -7,6 +7,8 @@
 package staking
 
 import (
+	"bytes"
+	"math"
 	"math/big"
 	"sort"
 	"strings"
@@ -14,7 +16,19 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
+	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+)
+
+const (
+	delegateKeyPrefix       = "delegate"
+	delegateNameKeyPrefix   = "delegateName"
+	delegateRewardKeyPrefix = "delegateReward"
+
+	// NoSelfStakeBucketIndex indicates that a delegate hasn't designated a self-stake bucket
+	NoSelfStakeBucketIndex = math.MaxUint64
 )
 
 var (
@@ -33,6 +47,10 @@ type (
 		RewardAddress string
 		CanName       CandName
 		Votes         *big.Int
+		// SelfStakeBucketIdx is the index of the bucket which the owner stakes to its own candidate
+		SelfStakeBucketIdx uint64
+
+		version uint8
 	}
 
 	// DelegateList is a list of delegates which is sortable
@@ -40,6 +58,9 @@ type (
 
 	// DelegateMap is a map of delegates using [12]byte name as key
 	DelegateMap map[CandName]*Delegate
+
+	// delegateOwner is the owner address of a delegate, indexed by the delegate name
+	delegateOwner string
 )
 
 // ToCandName converts byte slice to CandName
@@ -52,6 +73,11 @@ func ToCandName(b []byte) CandName {
 	return c
 }
 
+// String returns the name without the zero-byte padding
+func (c CandName) String() string {
+	return string(bytes.TrimLeft(c[:], "\x00"))
+}
+
 // AddVote adds vote
 func (d *Delegate) AddVote(amount *big.Int) error {
 	if amount.Sign() < 0 {
@@ -78,20 +104,22 @@ func (d *Delegate) toProto() *stakingpb.Delegate {
 	name := make([]byte, len(d.CanName))
 	copy(name, d.CanName[:])
 	return &stakingpb.Delegate{
-		Owner:         d.Owner,
-		Address:       d.Address,
-		RewardAddress: d.RewardAddress,
-		CanName:       name,
-		Votes:         d.Votes.Bytes(),
+		Owner:              d.Owner,
+		Address:            d.Address,
+		RewardAddress:      d.RewardAddress,
+		CanName:            name,
+		Votes:              d.Votes.Bytes(),
+		SelfStakeBucketIdx: d.SelfStakeBucketIdx,
 	}
 }
 
 func fromProto(pb *stakingpb.Delegate) *Delegate {
 	d := Delegate{
-		Owner:         pb.Owner,
-		Address:       pb.Address,
-		RewardAddress: pb.RewardAddress,
-		CanName:       ToCandName(pb.CanName),
+		Owner:              pb.Owner,
+		Address:            pb.Address,
+		RewardAddress:      pb.RewardAddress,
+		CanName:            ToCandName(pb.CanName),
+		SelfStakeBucketIdx: pb.SelfStakeBucketIdx,
 	}
 
 	if len(pb.Votes) > 0 {
@@ -100,6 +128,46 @@ func fromProto(pb *stakingpb.Delegate) *Delegate {
 	return &d
 }
 
+// Serialize serializes a delegate to bytes
+func (d *Delegate) Serialize() ([]byte, error) {
+	return serializeVersioned(d.toProto(), d.version)
+}
+
+// Deserialize deserializes bytes to a delegate
+func (d *Delegate) Deserialize(buf []byte) error {
+	pb := &stakingpb.Delegate{}
+	version, err := deserializeVersioned(buf, pb)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal delegate")
+	}
+	*d = *fromProto(pb)
+	if d.Votes == nil {
+		d.Votes = big.NewInt(0)
+	}
+	d.version = version
+	return nil
+}
+
+// Version returns the version which the delegate was deserialized from
+func (d *Delegate) Version() uint8 {
+	return d.version
+}
+
+func (d *Delegate) setVersion(version uint8) {
+	d.version = version
+}
+
+// Serialize serializes the owner address to bytes
+func (o *delegateOwner) Serialize() ([]byte, error) {
+	return []byte(*o), nil
+}
+
+// Deserialize deserializes bytes to the owner address
+func (o *delegateOwner) Deserialize(buf []byte) error {
+	*o = delegateOwner(buf)
+	return nil
+}
+
 func (l DelegateList) Len() int      { return len(l) }
 func (l DelegateList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
 func (l DelegateList) Less(i, j int) bool {
@@ -163,3 +231,195 @@ func (m DelegateMap) Deserialize(buf []byte) error {
 	}
 	return nil
 }
+
+func stakingGetDelegate(sr protocol.StateReader, owner string) (*Delegate, error) {
+	key, err := delegateKey(owner)
+	if err != nil {
+		return nil, err
+	}
+	var d Delegate
+	if _, err := sr.State(
+		&d,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(key)); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func stakingGetDelegateByName(sr protocol.StateReader, name CandName) (*Delegate, error) {
+	var owner delegateOwner
+	if _, err := sr.State(
+		&owner,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(delegateNameKey(name))); err != nil {
+		return nil, err
+	}
+	return stakingGetDelegate(sr, string(owner))
+}
+
+// ActiveCandidates returns the delegates which have designated a self-stake bucket as poll candidates, whose address
+// is the operator address of the delegate. The votes of a candidate are its weighted votes, and its raw votes are the
+// amount staked in its buckets which aren't unstaked.
+func ActiveCandidates(sr protocol.StateReader) (state.CandidateList, error) {
+	owners, err := stakingGetAddressList(sr, delegateListKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get delegate list")
+	}
+	rawVotes, err := stakingRawVotes(sr)
+	if err != nil {
+		return nil, err
+	}
+	var candidates state.CandidateList
+	for _, owner := range owners {
+		d, err := stakingGetDelegate(sr, owner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get delegate owned by %s", owner)
+		}
+		if d.SelfStakeBucketIdx == NoSelfStakeBucketIndex {
+			continue
+		}
+		name := d.CanName
+		raw, ok := rawVotes[name]
+		if !ok {
+			raw = big.NewInt(0)
+		}
+		candidates = append(candidates, &state.Candidate{
+			Address:       d.Address,
+			Votes:         new(big.Int).Set(d.Votes),
+			RewardAddress: d.RewardAddress,
+			CanName:       name[:],
+			RawVotes:      raw,
+		})
+	}
+	return candidates, nil
+}
+
+// stakingRawVotes returns the amount staked in the buckets which aren't unstaked by candidate name
+func stakingRawVotes(sr protocol.StateReader) (map[CandName]*big.Int, error) {
+	voters, err := stakingGetAddressList(sr, voterListKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get voter list")
+	}
+	votes := make(map[CandName]*big.Int)
+	for _, voter := range voters {
+		bis, err := stakingGetBucketIndices(sr, voter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bucket indices of voter %s", voter)
+		}
+		for _, bi := range bis.GetIndices() {
+			name := ToCandName(bi.CanName)
+			bucket, err := stakingGetBucket(sr, name, bi.Index)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get bucket %d", bi.Index)
+			}
+			if bucket.IsUnstaked() {
+				continue
+			}
+			if _, ok := votes[name]; !ok {
+				votes[name] = big.NewInt(0)
+			}
+			votes[name].Add(votes[name], bucket.Amount())
+		}
+	}
+	return votes, nil
+}
+
+func stakingPutDelegate(sm protocol.StateManager, d *Delegate) error {
+	key, err := delegateKey(d.Owner)
+	if err != nil {
+		return err
+	}
+	var writes []protocol.StateWrite
+	_, err = stakingGetDelegate(sm, d.Owner)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		w, err := stakingAddToAddressList(sm, delegateListKey, d.Owner)
+		if err != nil {
+			return errors.Wrap(err, "failed to add owner to delegate list")
+		}
+		writes = append(writes, w)
+	default:
+		return err
+	}
+	owner := delegateOwner(d.Owner)
+	writes = append(
+		writes,
+		protocol.StateWrite{
+			State:   d,
+			Options: []protocol.StateOption{protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeyOption(key)},
+		},
+		protocol.StateWrite{
+			State: &owner,
+			Options: []protocol.StateOption{
+				protocol.NamespaceOption(factory.StakingNameSpace),
+				protocol.KeyOption(delegateNameKey(d.CanName)),
+			},
+		},
+	)
+	_, err = sm.PutStates(writes)
+	return err
+}
+
+// delegateKey returns the key of the delegate owned by the given address, the prefix keeps it apart from the
+// bucket keys in the same namespace
+func delegateKey(owner string) ([]byte, error) {
+	addrHash, err := addrToHash(owner)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get address hash from owner's address")
+	}
+	return append([]byte(delegateKeyPrefix), addrHash[:]...), nil
+}
+
+func delegateNameKey(name CandName) []byte {
+	return append([]byte(delegateNameKeyPrefix), name[:]...)
+}
+
+// stakingGetRewardAddressOwner returns the owner of the delegate which uses the reward address
+func stakingGetRewardAddressOwner(sr protocol.StateReader, rewardAddr string) (string, error) {
+	key, err := delegateRewardKey(rewardAddr)
+	if err != nil {
+		return "", err
+	}
+	var owner delegateOwner
+	if _, err := sr.State(
+		&owner,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(key)); err != nil {
+		return "", err
+	}
+	return string(owner), nil
+}
+
+func stakingPutRewardAddressOwner(sm protocol.StateManager, rewardAddr, ownerAddr string) error {
+	key, err := delegateRewardKey(rewardAddr)
+	if err != nil {
+		return err
+	}
+	owner := delegateOwner(ownerAddr)
+	_, err = sm.PutState(
+		&owner,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(key))
+	return err
+}
+
+func stakingDelRewardAddressOwner(sm protocol.StateManager, rewardAddr string) error {
+	key, err := delegateRewardKey(rewardAddr)
+	if err != nil {
+		return err
+	}
+	_, err = sm.DelState(
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(key))
+	return err
+}
+
+func delegateRewardKey(rewardAddr string) ([]byte, error) {
+	addrHash, err := addrToHash(rewardAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get address hash from reward address")
+	}
+	return append([]byte(delegateRewardKeyPrefix), addrHash[:]...), nil
+}
@@ -1,11 +1,15 @@
 package staking
 
 import (
+	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state/factory"
 	"github.com/iotexproject/iotex-core/test/identityset"
 )
 
@@ -82,3 +86,74 @@ func TestCandMap(t *testing.T) {
 		r.Equal(v.d, c[v.index])
 	}
 }
+
+func TestActiveCandidates(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	r.NoError(err)
+	r.NoError(sf.Start(ctx))
+	defer func() {
+		r.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	r.NoError(err)
+
+	candidates, err := ActiveCandidates(ws)
+	r.NoError(err)
+	r.Empty(candidates)
+
+	active := &Delegate{
+		Owner:              identityset.Address(1).String(),
+		Address:            identityset.Address(11).String(),
+		RewardAddress:      identityset.Address(12).String(),
+		CanName:            ToCandName([]byte("active")),
+		Votes:              big.NewInt(100),
+		SelfStakeBucketIdx: 0,
+	}
+	r.NoError(stakingPutDelegate(ws, active))
+	// a delegate without a self-stake bucket isn't a candidate
+	r.NoError(stakingPutDelegate(ws, &Delegate{
+		Owner:              identityset.Address(2).String(),
+		Address:            identityset.Address(13).String(),
+		RewardAddress:      identityset.Address(2).String(),
+		CanName:            ToCandName([]byte("inactive")),
+		Votes:              big.NewInt(200),
+		SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+	}))
+
+	// the raw votes count the amount of the buckets which aren't unstaked
+	for _, e := range []struct {
+		owner    string
+		name     CandName
+		amount   string
+		unstaked bool
+	}{
+		{active.Owner, active.CanName, "60", false},
+		{identityset.Address(3).String(), active.CanName, "30", false},
+		{identityset.Address(3).String(), active.CanName, "20", true},
+		{identityset.Address(3).String(), ToCandName([]byte("inactive")), "50", false},
+	} {
+		vb, err := NewVoteBucket(e.name.String(), e.owner, e.amount, 91, time.Unix(1580000000, 0), true)
+		r.NoError(err)
+		if e.unstaked {
+			vb.UnstakeStartTime.Seconds = 1590000000
+		}
+		count, err := stakingGetTotalCount(ws)
+		r.NoError(err)
+		r.NoError(stakingPutBucket(ws, e.name, vb))
+		r.NoError(stakingPutBucketIndex(ws, e.owner, NewBucketIndex(count, e.name)))
+	}
+
+	candidates, err = ActiveCandidates(ws)
+	r.NoError(err)
+	r.Equal(1, len(candidates))
+	r.Equal(big.NewInt(90), candidates[0].RawVotes)
+	r.Equal(active.Address, candidates[0].Address)
+	r.Equal(active.RewardAddress, candidates[0].RewardAddress)
+	r.Equal(active.Votes, candidates[0].Votes)
+	r.Equal(active.CanName[:], candidates[0].CanName)
+	// the votes are copied
+	candidates[0].Votes.SetInt64(1)
+	r.Equal(big.NewInt(100), active.Votes)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+type (
+	// LegacyBucket is a bucket staked on the gravity chain before native staking is activated
+	LegacyBucket struct {
+		Voter     string
+		Candidate string
+		Amount    *big.Int
+		Duration  uint32
+		AutoStake bool
+	}
+
+	// BucketImporter supplies the legacy buckets to import when native staking is activated
+	BucketImporter interface {
+		LegacyBuckets(context.Context) ([]*LegacyBucket, error)
+	}
+)
+
+// importLegacyBuckets creates the buckets, the bucket indices and the delegate votes of the legacy buckets. The
+// buckets are sorted before being created, so that the same dataset always results in the same state regardless of
+// the order in which the importer returns it.
+func importLegacyBuckets(ctx context.Context, sm protocol.StateManager, importer BucketImporter) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	buckets, err := importer.LegacyBuckets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get legacy buckets")
+	}
+	sorted := make([]*LegacyBucket, len(buckets))
+	copy(sorted, buckets)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].less(sorted[j])
+	})
+
+	for _, lb := range sorted {
+		name := ToCandName([]byte(lb.Candidate))
+		bucket, err := NewVoteBucket(lb.Candidate, lb.Voter, lb.Amount.String(), lb.Duration, blkCtx.BlockTimeStamp, lb.AutoStake)
+		if err != nil {
+			return errors.Wrapf(err, "invalid legacy bucket of voter %s", lb.Voter)
+		}
+		index, err := stakingGetTotalCount(sm)
+		if err != nil {
+			return errors.Wrap(err, "failed to get total bucket count")
+		}
+		if err := stakingPutBucket(sm, name, bucket); err != nil {
+			return errors.Wrapf(err, "failed to put bucket %d", index)
+		}
+		if err := stakingPutBucketIndex(sm, lb.Voter, NewBucketIndex(index, name)); err != nil {
+			return errors.Wrapf(err, "failed to put bucket index %d", index)
+		}
+		d, err := stakingGetDelegateByName(sm, name)
+		switch errors.Cause(err) {
+		case nil:
+			if err := d.AddVote(calculateVoteWeight(bcCtx.Genesis.VoteWeightCalConsts, bucket, false)); err != nil {
+				return err
+			}
+			if err := stakingPutDelegate(sm, d); err != nil {
+				return errors.Wrapf(err, "failed to put delegate %s", lb.Candidate)
+			}
+		case state.ErrStateNotExist:
+			// the candidate hasn't registered natively, so there is no vote total to update
+		default:
+			return errors.Wrapf(err, "failed to get delegate %s", lb.Candidate)
+		}
+	}
+	return nil
+}
+
+func (lb *LegacyBucket) less(other *LegacyBucket) bool {
+	if res := strings.Compare(lb.Voter, other.Voter); res != 0 {
+		return res < 0
+	}
+	if res := strings.Compare(lb.Candidate, other.Candidate); res != 0 {
+		return res < 0
+	}
+	if res := lb.Amount.Cmp(other.Amount); res != 0 {
+		return res < 0
+	}
+	if lb.Duration != other.Duration {
+		return lb.Duration < other.Duration
+	}
+	return !lb.AutoStake && other.AutoStake
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+type staticImporter []*LegacyBucket
+
+func (si staticImporter) LegacyBuckets(context.Context) ([]*LegacyBucket, error) {
+	return si, nil
+}
+
+func TestImportLegacyBuckets(t *testing.T) {
+	require := require.New(t)
+
+	buckets := []*LegacyBucket{
+		{identityset.Address(1).String(), "delegate1", big.NewInt(100), 7, false},
+		{identityset.Address(1).String(), "delegate1", big.NewInt(100), 7, true},
+		{identityset.Address(1).String(), "delegate2", big.NewInt(200), 14, false},
+		{identityset.Address(2).String(), "delegate1", big.NewInt(300), 0, false},
+		{identityset.Address(3).String(), "delegate3", big.NewInt(400), 91, true},
+	}
+	g := genesis.Default
+	g.ActivationHeight = 1
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
+		BlockHeight:    1,
+		BlockTimeStamp: time.Unix(1580000000, 0),
+	})
+
+	var sfs []factory.Factory
+	defer func() {
+		for _, sf := range sfs {
+			require.NoError(sf.Stop(ctx))
+		}
+	}()
+	newWorkingSet := func() factory.WorkingSet {
+		sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+		require.NoError(err)
+		require.NoError(sf.Start(ctx))
+		sfs = append(sfs, sf)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+		return ws
+	}
+	importBuckets := func(input []*LegacyBucket) (protocol.StateManager, hash.Hash256) {
+		ws := newWorkingSet()
+		require.NoError(stakingPutDelegate(ws, &Delegate{
+			Owner:              identityset.Address(11).String(),
+			Address:            identityset.Address(12).String(),
+			RewardAddress:      identityset.Address(13).String(),
+			CanName:            ToCandName([]byte("delegate1")),
+			Votes:              big.NewInt(0),
+			SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+		}))
+
+		p := NewProtocol(WithBucketImporter(staticImporter(input)))
+		require.NoError(p.CreatePreStates(ctx, ws))
+		require.NoError(ws.Finalize())
+		digest, err := ws.Digest()
+		require.NoError(err)
+		return ws, digest
+	}
+
+	ws, expected := importBuckets(buckets)
+	for i := 0; i < 5; i++ {
+		shuffled := make([]*LegacyBucket, len(buckets))
+		copy(shuffled, buckets)
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		_, digest := importBuckets(shuffled)
+		require.Equal(expected, digest)
+	}
+
+	count, err := stakingGetTotalCount(ws)
+	require.NoError(err)
+	require.Equal(uint64(len(buckets)), count)
+	bis, err := stakingGetBucketIndices(ws, identityset.Address(1).String())
+	require.NoError(err)
+	require.Equal(3, len(bis.Indices))
+	// only the registered delegate has a vote total
+	votes := big.NewInt(0)
+	for _, bi := range bis.Indices[:2] {
+		vb, err := stakingGetBucket(ws, ToCandName(bi.CanName), bi.Index)
+		require.NoError(err)
+		require.Equal("delegate1", vb.CandidateName)
+		votes.Add(votes, calculateVoteWeight(g.VoteWeightCalConsts, vb, false))
+	}
+	bis, err = stakingGetBucketIndices(ws, identityset.Address(2).String())
+	require.NoError(err)
+	require.Equal(1, len(bis.Indices))
+	votes.Add(votes, big.NewInt(300))
+	d, err := stakingGetDelegateByName(ws, ToCandName([]byte("delegate1")))
+	require.NoError(err)
+	require.Equal(votes.String(), d.Votes.String())
+
+	// nothing is imported at other heights
+	ws = newWorkingSet()
+	p := NewProtocol(WithBucketImporter(staticImporter(buckets)))
+	require.NoError(p.CreatePreStates(protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 2}), ws))
+	count, err = stakingGetTotalCount(ws)
+	require.NoError(err)
+	require.Zero(count)
+}
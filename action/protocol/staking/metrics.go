@@ -0,0 +1,143 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/pkg/unit"
+)
+
+const (
+	// status classes of the handled staking actions
+	statusSuccess = "success"
+	statusFailure = "failure"
+	statusError   = "error"
+)
+
+var (
+	stakingActionMtc = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iotex_staking_action",
+			Help: "IoTeX staking actions by type and status",
+		},
+		[]string{"type", "status"},
+	)
+	stakingActionLatencyMtc = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "iotex_staking_action_latency",
+			Help:    "IoTeX staking action handling latency in milliseconds",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 15),
+		},
+		[]string{"type"},
+	)
+	stakingStateMtc = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "iotex_staking_state",
+			Help: "IoTeX staking candidate count and total staked amount in IOTX",
+		},
+		[]string{"type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(stakingActionMtc)
+	prometheus.MustRegister(stakingActionLatencyMtc)
+	prometheus.MustRegister(stakingStateMtc)
+}
+
+// actionType returns the metric label of a staking action
+func actionType(act action.Action) string {
+	switch act.(type) {
+	case *action.CreateStake:
+		return "createStake"
+	case *action.Unstake:
+		return "unstake"
+	case *action.WithdrawStake:
+		return "withdrawStake"
+	case *action.ChangeCandidate:
+		return "changeCandidate"
+	case *action.TransferStake:
+		return "transferStake"
+	case *action.DepositToStake:
+		return "depositToStake"
+	case *action.Restake:
+		return "restake"
+	case *action.CandidateRegister:
+		return "candidateRegister"
+	case *action.CandidateUpdate:
+		return "candidateUpdate"
+	case *action.CandidateActivate:
+		return "candidateActivate"
+	default:
+		return ""
+	}
+}
+
+// statusClass groups the receipt status into success, failure and error, to keep the metric labels low-cardinality. An
+// error with a status fails the action rather than the block, so it's a failure.
+func statusClass(r *action.Receipt, err error) string {
+	if _, ok := protocol.ReceiptStatusOf(err); ok {
+		return statusFailure
+	}
+	switch {
+	case err != nil:
+		return statusError
+	case r.Status == uint64(iotextypes.ReceiptStatus_Success):
+		return statusSuccess
+	default:
+		return statusFailure
+	}
+}
+
+// observeAction records the status and the latency of a handled staking action
+func observeAction(actType string, start time.Time, r *action.Receipt, err error) {
+	if err == nil && r == nil {
+		// the action isn't handled
+		return
+	}
+	stakingActionMtc.WithLabelValues(actType, statusClass(r, err)).Inc()
+	stakingActionLatencyMtc.WithLabelValues(actType).Observe(float64(time.Since(start).Nanoseconds()) / 1e6)
+}
+
+// refreshStateMetrics sets the candidate count and the total staked amount of the buckets that haven't been unstaked
+func refreshStateMetrics(sr protocol.StateReader) error {
+	owners, err := stakingGetAddressList(sr, delegateListKey)
+	if err != nil {
+		return err
+	}
+	voters, err := stakingGetAddressList(sr, voterListKey)
+	if err != nil {
+		return err
+	}
+	total := big.NewInt(0)
+	for _, voter := range voters {
+		bis, err := stakingGetBucketIndices(sr, voter)
+		if err != nil {
+			return err
+		}
+		for _, bi := range bis.GetIndices() {
+			bucket, err := stakingGetBucket(sr, ToCandName(bi.CanName), bi.Index)
+			if err != nil {
+				return err
+			}
+			if !bucket.IsUnstaked() {
+				total.Add(total, bucket.Amount())
+			}
+		}
+	}
+	iotx, _ := new(big.Float).Quo(new(big.Float).SetInt(total), big.NewFloat(float64(unit.Iotx))).Float64()
+	stakingStateMtc.WithLabelValues("candidates").Set(float64(len(owners)))
+	stakingStateMtc.WithLabelValues("totalStaked").Set(iotx)
+	return nil
+}
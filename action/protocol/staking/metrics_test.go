@@ -0,0 +1,127 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/unit"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestStakingMetrics(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20)))
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: genesis.Default, Registry: registry})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	p := NewProtocol(WithStateMetrics(ws, 3))
+
+	success := testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusSuccess))
+	failure := testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusFailure))
+	for i, name := range []string{"delegate1", "delegate2", "Delegate3"} {
+		ctx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(i)})
+		act, err := action.NewCandidateRegister(1, name, identityset.Address(i+10).String(), identityset.Address(i+20).String(), nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		_, err = p.Handle(ctx, act, ws)
+		require.NoError(err)
+	}
+	require.Equal(success+2, testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusSuccess)))
+	require.Equal(failure+1, testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusFailure)))
+
+	// the state gauges are refreshed once every interval of committed blocks
+	for _, e := range []struct {
+		voter    int
+		name     string
+		amount   int64
+		unstaked bool
+	}{
+		{1, "delegate1", 100, false},
+		{2, "delegate2", 200, false},
+		{2, "delegate1", 400, true},
+	} {
+		index, err := stakingGetTotalCount(ws)
+		require.NoError(err)
+		vb, err := NewVoteBucket(e.name, identityset.Address(e.voter).String(), unit.ConvertIotxToRau(e.amount).String(), 7, time.Unix(1580000000, 0), false)
+		require.NoError(err)
+		if e.unstaked {
+			vb.UnstakeStartTime.Seconds = 1590000000
+		}
+		require.NoError(stakingPutBucket(ws, ToCandName([]byte(e.name)), vb))
+		require.NoError(stakingPutBucketIndex(ws, identityset.Address(e.voter).String(), NewBucketIndex(index, ToCandName([]byte(e.name)))))
+	}
+	receive := func(height uint64) {
+		blk, err := block.NewTestingBuilder().SetHeight(height).SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(p.ReceiveBlock(&blk))
+	}
+	receive(2)
+	require.NotEqual(float64(300), testutil.ToFloat64(stakingStateMtc.WithLabelValues("totalStaked")))
+	receive(3)
+	require.Equal(float64(2), testutil.ToFloat64(stakingStateMtc.WithLabelValues("candidates")))
+	require.Equal(float64(300), testutil.ToFloat64(stakingStateMtc.WithLabelValues("totalStaked")))
+}
+
+func TestStakingMetrics_ReadOnly(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	registry := protocol.NewRegistry()
+	require.NoError(registry.Register("rolldpos", rolldpos.NewProtocol(36, 36, 20)))
+	require.NoError(NewProtocol().Register(registry))
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: genesis.Default, Registry: registry})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+
+	act, err := action.NewCandidateRegister(1, "delegate1", identityset.Address(10).String(), identityset.Address(20).String(), nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	elp := (&action.EnvelopeBuilder{}).SetNonce(1).SetGasLimit(100000).SetGasPrice(big.NewInt(0)).SetAction(act).Build()
+	selp, err := action.Sign(elp, identityset.PrivateKey(1))
+	require.NoError(err)
+
+	success := testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusSuccess))
+	simulated, err := ws.SimulateAction(ctx, selp)
+	require.NoError(err)
+	require.Equal(success, testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusSuccess)))
+	// the simulated action leaves no states behind, so running it gets the same receipt
+	receipt, err := ws.RunAction(ctx, selp)
+	require.NoError(err)
+	require.Equal(receipt, simulated)
+	require.Equal(success+1, testutil.ToFloat64(stakingActionMtc.WithLabelValues("candidateRegister", statusSuccess)))
+}
@@ -8,37 +8,157 @@ package staking
 
 import (
 	"context"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
+	accountutil "github.com/iotexproject/iotex-core/action/protocol/account/util"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
 )
 
 // protocolID is the protocol ID
 const protocolID = "staking"
 
-// Protocol defines the protocol of handling staking
-type Protocol struct {
-	addr address.Address
+const (
+	// ReceiptStatusErrRewardAddressConflict indicates that the reward address is used by another candidate
+	ReceiptStatusErrRewardAddressConflict = uint64(200)
+	// ReceiptStatusErrPayloadTooLarge indicates that the payload of the action exceeds the maximum size
+	ReceiptStatusErrPayloadTooLarge = uint64(201)
+	// ReceiptStatusErrUnauthorized indicates that the caller isn't allowed to take the action on the candidate
+	ReceiptStatusErrUnauthorized = uint64(202)
+	// ReceiptStatusErrBucketCapExceeded indicates that the voter or the candidate already has the maximum number of
+	// buckets
+	ReceiptStatusErrBucketCapExceeded = uint64(203)
+	// ReceiptStatusErrInvalidRecipient indicates that the payload of a withdrawal isn't a valid recipient address
+	ReceiptStatusErrInvalidRecipient = uint64(204)
+)
+
+var (
+	// ErrPayloadTooLarge indicates that the payload of a staking action exceeds the maximum size
+	ErrPayloadTooLarge = errors.New("payload is too large")
+	// ErrRewardAddressConflict indicates that the reward address is used by another candidate
+	ErrRewardAddressConflict = errors.New("reward address is used by another candidate")
+	// ErrUnauthorized indicates that the caller isn't allowed to take the action on the candidate
+	ErrUnauthorized = errors.New("caller is unauthorized")
+	// ErrBucketCapExceeded indicates that the voter or the candidate already has the maximum number of buckets
+	ErrBucketCapExceeded = errors.New("bucket cap is exceeded")
+	// ErrInvalidRecipient indicates that the payload of a withdrawal isn't a valid recipient address
+	ErrInvalidRecipient = errors.New("invalid recipient")
+)
+
+// receiptStatuses maps the errors failing a staking action to the statuses of the failure receipts, and the ones
+// which aren't mapped fail the action with the generic Failure
+var receiptStatuses = map[error]uint64{
+	ErrRewardAddressConflict: ReceiptStatusErrRewardAddressConflict,
+	ErrPayloadTooLarge:       ReceiptStatusErrPayloadTooLarge,
+	ErrUnauthorized:          ReceiptStatusErrUnauthorized,
+	ErrBucketCapExceeded:     ReceiptStatusErrBucketCapExceeded,
+	ErrInvalidRecipient:      ReceiptStatusErrInvalidRecipient,
+}
+
+type (
+	// Protocol defines the protocol of handling staking
+	Protocol struct {
+		addr     address.Address
+		importer BucketImporter
+		// the committed states which the state metrics are refreshed from
+		metricsReader   protocol.StateReader
+		metricsInterval uint64
+		router          *protocol.ReadStateRouter
+	}
+
+	// Option sets an option of the staking protocol
+	Option func(*Protocol)
+)
+
+// WithBucketImporter sets the importer of the legacy buckets, which are imported at the activation height
+func WithBucketImporter(importer BucketImporter) Option {
+	return func(p *Protocol) {
+		p.importer = importer
+	}
+}
+
+// WithStateMetrics refreshes the staking state metrics from the committed states once every interval of blocks
+func WithStateMetrics(sr protocol.StateReader, interval uint64) Option {
+	return func(p *Protocol) {
+		p.metricsReader = sr
+		p.metricsInterval = interval
+	}
 }
 
 // NewProtocol instantiates the protocol of staking
-func NewProtocol() *Protocol {
+func NewProtocol(opts ...Option) *Protocol {
 	h := hash.Hash160b([]byte(protocolID))
 	addr, err := address.FromBytes(h[:])
 	if err != nil {
 		log.L().Panic("Error when constructing the address of staking protocol", zap.Error(err))
 	}
 
-	return &Protocol{addr: addr}
+	p := &Protocol{
+		addr: addr,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.router = protocol.MustNewReadStateRouter(p.ReadStateMethods()...)
+	return p
+}
+
+// CreatePreStates imports the legacy buckets at the activation height
+func (p *Protocol) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if p.importer != nil && blkCtx.BlockHeight == bcCtx.Genesis.ActivationHeight {
+		return importLegacyBuckets(ctx, withStateVersion(ctx, sm), p.importer)
+	}
+	return nil
+}
+
+// ReceiveBlock refreshes the staking state metrics from the committed states once every interval of blocks. The
+// refresh scans all the buckets, so it is run on the committed blocks rather than in the block processing.
+func (p *Protocol) ReceiveBlock(blk *block.Block) error {
+	if p.metricsReader == nil || p.metricsInterval == 0 || blk.Height()%p.metricsInterval != 0 {
+		return nil
+	}
+	if err := refreshStateMetrics(p.metricsReader); err != nil {
+		log.L().Error("Error when refreshing staking state metrics", zap.Error(err))
+	}
+	return nil
 }
 
 // Handle handles a staking message
 func (p *Protocol) Handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	start := time.Now()
+	r, err := p.handle(ctx, act, withStateVersion(ctx, sm))
+	if actionCtx, ok := protocol.GetActionCtx(ctx); !ok || !actionCtx.ReadOnly {
+		// the simulated actions aren't counted
+		observeAction(actionType(act), start, r, err)
+	}
+	return r, err
+}
+
+func (p *Protocol) handle(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	if actionType(act) != "" && isPayloadTooLarge(protocol.MustGetBlockchainCtx(ctx).Genesis, act) {
+		// the cap is checked before the payload is looked into, so a withdrawal whose payload is too large to be a
+		// recipient fails here rather than with the invalid recipient status
+		return nil, failAction(errors.Wrapf(
+			ErrPayloadTooLarge,
+			"payload exceeds %d bytes",
+			protocol.MustGetBlockchainCtx(ctx).Genesis.MaxPayloadSize,
+		))
+	}
 	switch act := act.(type) {
 	case *action.CreateStake:
 		return p.handleCreateStake(ctx, act, sm)
@@ -54,20 +174,84 @@ func (p *Protocol) Handle(ctx context.Context, act action.Action, sm protocol.St
 		return p.handleDepositToStake(ctx, act, sm)
 	case *action.Restake:
 		return p.handleRestake(ctx, act, sm)
+	case *action.CandidateRegister:
+		return p.handleCandidateRegister(ctx, act, sm)
+	case *action.CandidateUpdate:
+		return p.handleCandidateUpdate(ctx, act, sm)
+	case *action.CandidateActivate:
+		return p.handleCandidateActivate(ctx, act, sm)
 	}
 	return nil, nil
 }
 
 // Validate validates a staking message
 func (p *Protocol) Validate(ctx context.Context, act action.Action) error {
-	//TODO
+	if actionType(act) == "" {
+		return nil
+	}
+	if g := protocol.MustGetBlockchainCtx(ctx).Genesis; isPayloadTooLarge(g, act) {
+		return errors.Wrapf(ErrPayloadTooLarge, "payload exceeds %d bytes", g.MaxPayloadSize)
+	}
 	return nil
 }
 
-// ReadState read the state on blockchain via protocol
-func (p *Protocol) ReadState(context.Context, protocol.StateReader, []byte, ...[]byte) ([]byte, error) {
-	//TODO
-	return nil, protocol.ErrUnimplemented
+// ReadState read the state on blockchain via protocol, routing the read to the method described in ReadStateMethods
+func (p *Protocol) ReadState(ctx context.Context, sr protocol.StateReader, method []byte, args ...[]byte) ([]byte, error) {
+	return p.router.ReadState(ctx, sr, method, args...)
+}
+
+// ReadStateMethods describes the methods of ReadState. Each method accepts an optional trailing argument after all its
+// arguments including the optional ones, which is a protobuf-encoded ReadStateHeight. If it is given, the state at
+// that height is read, and the result is wrapped in a ReadStateResponse along with the height.
+func (p *Protocol) ReadStateMethods() []protocol.ReadStateMethod {
+	page := []protocol.ArgType{protocol.BytesArg, protocol.PaginationArg}
+	return []protocol.ReadStateMethod{
+		readStateMethodAtHeight("voterTotal", readStateVoterTotal, []protocol.ArgType{protocol.BytesArg}, nil),
+		readStateMethodAtHeight("delegateByName", readStateDelegateByName, []protocol.ArgType{protocol.BytesArg}, nil),
+		readStateMethodAtHeight("bucketsByVoter", readStateBucketsByVoter, page, []protocol.ArgType{protocol.BytesArg}),
+		readStateMethodAtHeight("compositeBuckets", readStateCompositeBuckets, page, nil),
+	}
+}
+
+// readStateMethodAtHeight describes a method which reads the state at the height of the optional trailing argument
+func readStateMethodAtHeight(name string, read readStateFunc, args, optionalArgs []protocol.ArgType) protocol.ReadStateMethod {
+	numArgs := len(args) + len(optionalArgs)
+	return protocol.ReadStateMethod{
+		Name:         name,
+		Args:         args,
+		OptionalArgs: append(append([]protocol.ArgType{}, optionalArgs...), protocol.BytesArg),
+		Handler: func(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+			if len(args) != numArgs+1 {
+				return read(ctx, sr, args...)
+			}
+			var height stakingpb.ReadStateHeight
+			if err := proto.Unmarshal(args[numArgs], &height); err != nil {
+				return nil, protocol.NewReadStateError(protocol.BadArgEncoding, errors.Wrap(err, "failed to unmarshal height"))
+			}
+			data, err := read(ctx, &heightReader{StateReader: sr, height: height.Height}, args[:numArgs]...)
+			if errors.Cause(err) == factory.ErrNotSupported {
+				// the state of a past height is only kept by an archive node
+				err = protocol.NewReadStateError(protocol.ArchiveUnavailable, err)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read state at height %d", height.Height)
+			}
+			return proto.Marshal(&stakingpb.ReadStateResponse{
+				Data:   data,
+				Height: height.Height,
+			})
+		},
+	}
+}
+
+// Name returns the name of the protocol
+func (p *Protocol) Name() string {
+	return "Staking"
+}
+
+// Version returns the version of the protocol
+func (p *Protocol) Version() uint {
+	return 1
 }
 
 // Register registers the protocol with a unique ID
@@ -80,9 +264,68 @@ func (p *Protocol) ForceRegister(r *protocol.Registry) error {
 	return r.ForceRegister(protocolID, p)
 }
 
-func (p *Protocol) handleCreateStake(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
-	// TODO
-	return nil, nil
+func (p *Protocol) handleCreateStake(ctx context.Context, act *action.CreateStake, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	caller := actionCtx.Caller.String()
+
+	if act.Amount() == nil || act.Amount().Sign() <= 0 {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	name := ToCandName([]byte(act.CandName()))
+	d, err := stakingGetDelegateByName(sm, name)
+	if errors.Cause(err) == state.ErrStateNotExist {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get delegate %s", act.CandName())
+	}
+	staker, err := accountutil.LoadOrCreateAccount(sm, caller)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load or create the account of staker %s", caller)
+	}
+	if staker.Balance.Cmp(act.Amount()) < 0 {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+
+	exceeded, err := isBucketCapExceeded(sm, g, caller, &name)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded {
+		return nil, failAction(errors.Wrapf(ErrBucketCapExceeded, "voter %s or candidate %s", caller, act.CandName()))
+	}
+
+	bucket, err := NewVoteBucket(act.CandName(), caller, act.Amount().String(), act.Duration(), blkCtx.BlockTimeStamp, act.AutoStake())
+	if err != nil {
+		return nil, err
+	}
+	index, err := stakingGetTotalCount(sm)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get total bucket count")
+	}
+	if err := stakingPutBucket(sm, name, bucket); err != nil {
+		return nil, errors.Wrapf(err, "failed to put bucket %d", index)
+	}
+	if err := stakingPutBucketIndex(sm, caller, NewBucketIndex(index, name)); err != nil {
+		return nil, errors.Wrapf(err, "failed to put bucket index %d", index)
+	}
+	if err := d.AddVote(calculateVoteWeight(g.VoteWeightCalConsts, bucket, false)); err != nil {
+		return nil, err
+	}
+	if err := stakingPutDelegate(sm, d); err != nil {
+		return nil, errors.Wrapf(err, "failed to put delegate %s", act.CandName())
+	}
+	if err := accountutil.Withdraw(sm, actionCtx.Caller, act.Amount()); err != nil {
+		return nil, errors.Wrapf(err, "failed to lock the staked amount of %s", caller)
+	}
+
+	receipt := p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx)
+	if blkCtx.BlockHeight >= g.BucketIndexReceiptHeight {
+		receipt.AddBucketIndexLog(p.addr.String(), index)
+	}
+	return receipt, nil
 }
 
 func (p *Protocol) handleUnstake(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
@@ -90,9 +333,89 @@ func (p *Protocol) handleUnstake(ctx context.Context, act action.Action, sm prot
 	return nil, nil
 }
 
-func (p *Protocol) handleWithdrawStake(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
-	// TODO
-	return nil, nil
+func (p *Protocol) handleWithdrawStake(ctx context.Context, act *action.WithdrawStake, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	caller := actionCtx.Caller.String()
+
+	recipient, ok := withdrawRecipient(g, blkCtx.BlockHeight, act.Payload(), actionCtx.Caller)
+	if !ok {
+		return nil, failAction(errors.Wrapf(ErrInvalidRecipient, "payload %x", act.Payload()))
+	}
+	// only the owner can withdraw a bucket, whoever the recipient is
+	bis, err := stakingGetBucketIndices(sm, caller)
+	if errors.Cause(err) == state.ErrStateNotExist {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket indices of %s", caller)
+	}
+	bi := bis.findBucketIndex(act.BucketIndex())
+	if bi == nil {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	name := ToCandName(bi.CanName)
+	bucket, err := stakingGetBucket(sm, name, bi.Index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket %d", bi.Index)
+	}
+	if bucket.Owner != caller || !bucket.IsUnstaked() {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+
+	// the votes of an unstaked bucket have been taken off the candidate, but it may still be the self-stake bucket
+	d, err := stakingGetDelegateByName(sm, name)
+	switch errors.Cause(err) {
+	case nil:
+		if d.SelfStakeBucketIdx == bi.Index {
+			d.SelfStakeBucketIdx = NoSelfStakeBucketIndex
+			if err := stakingPutDelegate(sm, d); err != nil {
+				return nil, errors.Wrapf(err, "failed to put delegate %x", name)
+			}
+		}
+	case state.ErrStateNotExist:
+	default:
+		return nil, errors.Wrapf(err, "failed to get delegate %x", name)
+	}
+	if err := stakingDelBucket(sm, name, bi.Index); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete bucket %d", bi.Index)
+	}
+	if err := stakingDelBucketIndex(sm, caller, bi.Index); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete bucket index %d of %s", bi.Index, caller)
+	}
+	if err := accountutil.Deposit(sm, recipient, bucket.Amount()); err != nil {
+		return nil, errors.Wrapf(err, "failed to credit the withdrawn amount to %s", recipient.String())
+	}
+
+	data, err := proto.Marshal(&stakingpb.WithdrawLog{
+		BucketIndex: bi.Index,
+		Recipient:   recipient.String(),
+		Amount:      bucket.StakedAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx, &action.Log{
+		Address:     p.addr.String(),
+		Data:        data,
+		BlockHeight: blkCtx.BlockHeight,
+		ActionHash:  actionCtx.ActionHash,
+	}), nil
+}
+
+// withdrawRecipient returns the recipient of a withdrawal. From the activation height, a non-empty payload must be
+// exactly an encoded address, otherwise the withdrawal fails rather than crediting an unintended account. Before it,
+// the payload is ignored and the owner is credited.
+func withdrawRecipient(g genesis.Genesis, height uint64, payload []byte, owner address.Address) (address.Address, bool) {
+	if height < g.WithdrawRecipientHeight || len(payload) == 0 {
+		return owner, true
+	}
+	addr, err := address.FromString(string(payload))
+	if err != nil || addr.String() != string(payload) {
+		return nil, false
+	}
+	return addr, true
 }
 
 func (p *Protocol) handleChangeCandidate(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
@@ -100,9 +423,78 @@ func (p *Protocol) handleChangeCandidate(ctx context.Context, act action.Action,
 	return nil, nil
 }
 
-func (p *Protocol) handleTransferStake(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
-	// TODO
-	return nil, nil
+func (p *Protocol) handleTransferStake(ctx context.Context, act *action.TransferStake, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	caller := actionCtx.Caller.String()
+	recipient := act.Name()
+
+	if !isValidAddress(recipient) || recipient == caller {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	bis, err := stakingGetBucketIndices(sm, caller)
+	if errors.Cause(err) == state.ErrStateNotExist {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket indices of %s", caller)
+	}
+	bi := bis.findBucketIndex(act.BucketIndex())
+	if bi == nil {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	name := ToCandName(bi.CanName)
+	bucket, err := stakingGetBucket(sm, name, bi.Index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket %d", bi.Index)
+	}
+	if bucket.Owner != caller {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	// the bucket keeps voting for the same candidate, so only the recipient's cap is checked
+	exceeded, err := isBucketCapExceeded(sm, g, recipient, nil)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded {
+		return nil, failAction(errors.Wrapf(ErrBucketCapExceeded, "recipient %s", recipient))
+	}
+
+	// a self-stake bucket loses its bonus once it leaves the candidate's owner
+	d, err := stakingGetDelegateByName(sm, name)
+	switch errors.Cause(err) {
+	case nil:
+		if d.SelfStakeBucketIdx == bi.Index && d.Owner == caller {
+			if !bucket.IsUnstaked() {
+				if err := switchSelfStakeBonus(g.VoteWeightCalConsts, d, bucket, false); err != nil {
+					return nil, err
+				}
+			}
+			d.SelfStakeBucketIdx = NoSelfStakeBucketIndex
+			if err := stakingPutDelegate(sm, d); err != nil {
+				return nil, errors.Wrapf(err, "failed to put delegate %x", name)
+			}
+		}
+	case state.ErrStateNotExist:
+	default:
+		return nil, errors.Wrapf(err, "failed to get delegate %x", name)
+	}
+
+	bucket.Owner = recipient
+	if _, err := sm.PutState(
+		bucket,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(bucketKey(name, bi.Index))); err != nil {
+		return nil, errors.Wrapf(err, "failed to put bucket %d", bi.Index)
+	}
+	if err := stakingDelBucketIndex(sm, caller, bi.Index); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete bucket index %d of %s", bi.Index, caller)
+	}
+	if err := stakingPutBucketIndex(sm, recipient, bi); err != nil {
+		return nil, errors.Wrapf(err, "failed to put bucket index %d of %s", bi.Index, recipient)
+	}
+	return p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx), nil
 }
 
 func (p *Protocol) handleDepositToStake(ctx context.Context, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
@@ -114,3 +506,132 @@ func (p *Protocol) handleRestake(ctx context.Context, act action.Action, sm prot
 	// TODO
 	return nil, nil
 }
+
+func (p *Protocol) handleCandidateActivate(ctx context.Context, act *action.CandidateActivate, sm protocol.StateManager) (*action.Receipt, error) {
+	actionCtx := protocol.MustGetActionCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	g := protocol.MustGetBlockchainCtx(ctx).Genesis
+	caller := actionCtx.Caller.String()
+
+	r, d, err := callerRole(sm, caller)
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized(act, r) {
+		return nil, failAction(errors.Wrapf(ErrUnauthorized, "caller %s", caller))
+	}
+	bucket, err := stakingGetBucket(sm, d.CanName, act.BucketIndex())
+	if errors.Cause(err) == state.ErrStateNotExist {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket %d", act.BucketIndex())
+	}
+	if bucket.Owner != caller ||
+		bucket.IsUnstaked() ||
+		bucket.Amount().Cmp(g.SelfStakeThreshold()) < 0 ||
+		bucket.StakedDuration < g.SelfStakeMinDuration {
+		return p.createReceipt(uint64(iotextypes.ReceiptStatus_Failure), blkCtx.BlockHeight, actionCtx), nil
+	}
+
+	oldIndex := d.SelfStakeBucketIdx
+	if oldIndex != act.BucketIndex() {
+		if oldIndex != NoSelfStakeBucketIndex {
+			// the previous self-stake bucket falls back to normal votes, unless it has already been unstaked
+			prev, err := stakingGetBucket(sm, d.CanName, oldIndex)
+			switch errors.Cause(err) {
+			case nil:
+				if !prev.IsUnstaked() {
+					if err := switchSelfStakeBonus(g.VoteWeightCalConsts, d, prev, false); err != nil {
+						return nil, err
+					}
+				}
+			case state.ErrStateNotExist:
+			default:
+				return nil, errors.Wrapf(err, "failed to get bucket %d", oldIndex)
+			}
+		}
+		if err := switchSelfStakeBonus(g.VoteWeightCalConsts, d, bucket, true); err != nil {
+			return nil, err
+		}
+		d.SelfStakeBucketIdx = act.BucketIndex()
+		if err := stakingPutDelegate(sm, d); err != nil {
+			return nil, errors.Wrapf(err, "failed to put delegate owned by %s", caller)
+		}
+	}
+
+	data, err := proto.Marshal(&stakingpb.SelfStakeLog{
+		CanName:        d.CanName[:],
+		OldBucketIndex: oldIndex,
+		NewBucketIndex: act.BucketIndex(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.createReceipt(uint64(iotextypes.ReceiptStatus_Success), blkCtx.BlockHeight, actionCtx, &action.Log{
+		Address:     p.addr.String(),
+		Data:        data,
+		BlockHeight: blkCtx.BlockHeight,
+		ActionHash:  actionCtx.ActionHash,
+	}), nil
+}
+
+// isBucketCapExceeded returns true if adding a bucket to the voter, or to the candidate if the name is given, would
+// exceed the caps of the genesis. The counts are read from the working state, which includes the buckets added by the
+// earlier actions of the same block.
+func isBucketCapExceeded(sr protocol.StateReader, g genesis.Genesis, voter string, name *CandName) (bool, error) {
+	bis, err := stakingGetBucketIndices(sr, voter)
+	switch errors.Cause(err) {
+	case nil:
+		if uint64(len(bis.GetIndices())) >= g.MaxBucketsPerVoter {
+			return true, nil
+		}
+	case state.ErrStateNotExist:
+	default:
+		return false, errors.Wrapf(err, "failed to get bucket indices of %s", voter)
+	}
+	if name == nil {
+		return false, nil
+	}
+	count, err := stakingGetCandidateBucketCount(sr, *name)
+	if err != nil {
+		return false, err
+	}
+	return count >= g.MaxBucketsPerCandidate, nil
+}
+
+// isPayloadTooLarge returns true if the payload of the action exceeds the cap in genesis
+func isPayloadTooLarge(g genesis.Genesis, act action.Action) bool {
+	pa, ok := act.(interface{ Payload() []byte })
+	return ok && len(pa.Payload()) > int(g.MaxPayloadSize)
+}
+
+// failAction annotates the error failing the action with the status mapped from its cause
+func failAction(err error) error {
+	return protocol.WrapWithStatus(err, receiptStatuses[errors.Cause(err)])
+}
+
+func (p *Protocol) createReceipt(
+	status uint64,
+	blkHeight uint64,
+	actionCtx protocol.ActionCtx,
+	logs ...*action.Log,
+) *action.Receipt {
+	return &action.Receipt{
+		Status:          status,
+		BlockHeight:     blkHeight,
+		ActionHash:      actionCtx.ActionHash,
+		GasConsumed:     actionCtx.IntrinsicGas,
+		ContractAddress: p.addr.String(),
+		Logs:            logs,
+	}
+}
+
+// switchSelfStakeBonus replaces the votes of a bucket counted in the delegate with the votes with or without the
+// self-stake bonus
+func switchSelfStakeBonus(c genesis.VoteWeightCalConsts, d *Delegate, bucket *VoteBucket, selfStake bool) error {
+	if err := d.SubVote(calculateVoteWeight(c, bucket, !selfStake)); err != nil {
+		return errors.Wrapf(err, "failed to subtract votes of bucket from delegate %x", d.CanName)
+	}
+	return d.AddVote(calculateVoteWeight(c, bucket, selfStake))
+}
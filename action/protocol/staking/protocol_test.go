@@ -0,0 +1,613 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	accountutil "github.com/iotexproject/iotex-core/action/protocol/account/util"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/unit"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestProtocol_HandleCandidateActivate(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	owner := identityset.Address(1).String()
+	name := ToCandName([]byte("delegate1"))
+	buckets := []struct {
+		owner    string
+		amount   *big.Int
+		duration uint32
+	}{
+		{owner, unit.ConvertIotxToRau(1200000), 91},
+		{owner, unit.ConvertIotxToRau(2000000), 182},
+		{owner, unit.ConvertIotxToRau(100), 91},
+		{identityset.Address(2).String(), unit.ConvertIotxToRau(2000000), 182},
+	}
+	weights := make([]*big.Int, len(buckets))
+	bonusWeights := make([]*big.Int, len(buckets))
+	votes := big.NewInt(0)
+	for i, b := range buckets {
+		vb, err := NewVoteBucket("delegate1", b.owner, b.amount.String(), b.duration, time.Now(), true)
+		require.NoError(err)
+		require.NoError(stakingPutBucket(ws, name, vb))
+		weights[i] = calculateVoteWeight(g.VoteWeightCalConsts, vb, false)
+		bonusWeights[i] = calculateVoteWeight(g.VoteWeightCalConsts, vb, true)
+		votes.Add(votes, weights[i])
+	}
+	require.NoError(stakingPutDelegate(ws, &Delegate{
+		Owner:              owner,
+		Address:            identityset.Address(11).String(),
+		RewardAddress:      identityset.Address(21).String(),
+		CanName:            name,
+		Votes:              votes,
+		SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+	}))
+
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(1)})
+	p := NewProtocol()
+
+	tests := []struct {
+		index    uint64
+		status   iotextypes.ReceiptStatus
+		oldIndex uint64
+		selfIdx  uint64
+		bonus    int
+	}{
+		{0, iotextypes.ReceiptStatus_Success, NoSelfStakeBucketIndex, 0, 0},
+		// switch to another qualifying bucket
+		{1, iotextypes.ReceiptStatus_Success, 0, 1, 1},
+		// bucket amount is below the self-stake threshold
+		{2, iotextypes.ReceiptStatus_Failure, 0, 1, 1},
+		// bucket is owned by someone else
+		{3, iotextypes.ReceiptStatus_Failure, 0, 1, 1},
+		// bucket doesn't exist
+		{10, iotextypes.ReceiptStatus_Failure, 0, 1, 1},
+		// switch back
+		{0, iotextypes.ReceiptStatus_Success, 1, 0, 0},
+	}
+	for _, e := range tests {
+		act, err := action.NewCandidateActivate(1, e.index, nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		require.Equal(uint64(e.status), r.Status)
+		if e.status == iotextypes.ReceiptStatus_Success {
+			require.Equal(1, len(r.Logs))
+			var log stakingpb.SelfStakeLog
+			require.NoError(proto.Unmarshal(r.Logs[0].Data, &log))
+			require.Equal(e.oldIndex, log.OldBucketIndex)
+			require.Equal(e.index, log.NewBucketIndex)
+		}
+
+		d, err := stakingGetDelegate(ws, owner)
+		require.NoError(err)
+		require.Equal(e.selfIdx, d.SelfStakeBucketIdx)
+		expected := new(big.Int).Sub(votes, weights[e.bonus])
+		expected.Add(expected, bonusWeights[e.bonus])
+		require.Equal(expected.String(), d.Votes.String())
+	}
+
+	// caller doesn't own a delegate
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(2)})
+	act, err := action.NewCandidateActivate(1, 3, nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	r, err := handle(ctx, p, act, ws)
+	require.NoError(err)
+	require.Equal(ReceiptStatusErrUnauthorized, r.Status)
+}
+
+func TestProtocol_HandleCandidateRegisterRewardAddress(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	g.RewardAddressUniqueHeight = 10
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	p := NewProtocol()
+	reward := identityset.Address(20).String()
+
+	tests := []struct {
+		height uint64
+		caller int
+		name   string
+		reward string
+		status uint64
+	}{
+		// duplicate reward addresses are allowed before the activation height
+		{1, 1, "delegate1", reward, uint64(iotextypes.ReceiptStatus_Success)},
+		{1, 2, "delegate2", reward, uint64(iotextypes.ReceiptStatus_Success)},
+		{10, 3, "delegate3", reward, ReceiptStatusErrRewardAddressConflict},
+		{10, 3, "delegate3", identityset.Address(23).String(), uint64(iotextypes.ReceiptStatus_Success)},
+		// name is taken
+		{10, 4, "delegate3", identityset.Address(24).String(), uint64(iotextypes.ReceiptStatus_Failure)},
+		// invalid name
+		{10, 4, "Delegate4", identityset.Address(24).String(), uint64(iotextypes.ReceiptStatus_Failure)},
+	}
+	for _, e := range tests {
+		ctx := protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: e.height})
+		ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(e.caller)})
+		act, err := action.NewCandidateRegister(1, e.name, identityset.Address(e.caller+10).String(), e.reward, nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status)
+	}
+
+	// both delegates registered before the activation keep exposing the shared reward address
+	for _, name := range []string{"delegate1", "delegate2"} {
+		data, err := p.ReadState(ctx, ws, []byte("delegateByName"), []byte(name))
+		require.NoError(err)
+		var d stakingpb.Delegate
+		require.NoError(proto.Unmarshal(data, &d))
+		require.Equal(reward, d.RewardAddress)
+	}
+
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 10})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(3)})
+	// update to a reward address used by another delegate
+	act, err := action.NewCandidateUpdate(2, "", reward, nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	r, err := handle(ctx, p, act, ws)
+	require.NoError(err)
+	require.Equal(ReceiptStatusErrRewardAddressConflict, r.Status)
+	// update to a new reward address releases the old one
+	act, err = action.NewCandidateUpdate(2, "", identityset.Address(25).String(), nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	r, err = handle(ctx, p, act, ws)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Success), r.Status)
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(4)})
+	act2, err := action.NewCandidateRegister(1, "delegate4", identityset.Address(14).String(), identityset.Address(23).String(), nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	r, err = handle(ctx, p, act2, ws)
+	require.NoError(err)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Success), r.Status)
+	// the receipt tells that no self-stake bucket is designated yet
+	index, err := r.BucketIndex()
+	require.NoError(err)
+	require.Equal(uint64(NoSelfStakeBucketIndex), index)
+}
+
+func TestProtocol_PayloadSizeCap(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1})
+	g.MaxPayloadSize = 64
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	p := NewProtocol()
+	size := int(g.MaxPayloadSize)
+
+	tests := []struct {
+		caller  int
+		name    string
+		payload []byte
+		valid   bool
+		status  uint64
+	}{
+		{1, "delegate1", make([]byte, size+1), false, ReceiptStatusErrPayloadTooLarge},
+		{1, "delegate1", make([]byte, size), true, uint64(iotextypes.ReceiptStatus_Success)},
+		{2, "delegate2", nil, true, uint64(iotextypes.ReceiptStatus_Success)},
+	}
+	for _, e := range tests {
+		ctx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(e.caller)})
+		act, err := action.NewCandidateRegister(1, e.name, identityset.Address(e.caller+10).String(), identityset.Address(e.caller+20).String(), e.payload, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		gas, err := act.IntrinsicGas()
+		require.NoError(err)
+		require.Equal(action.CandidateRegisterBaseIntrinsicGas+action.CandidateRegisterPayloadGas*uint64(len(e.payload)), gas)
+		err = p.Validate(ctx, act)
+		if e.valid {
+			require.NoError(err)
+		} else {
+			require.Equal(ErrPayloadTooLarge, errors.Cause(err))
+		}
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status)
+	}
+
+	// the cap applies to all the staking actions
+	act, err := action.NewCreateStake(1, "delegate1", big.NewInt(100), 7, false, make([]byte, size+1), uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	require.Equal(ErrPayloadTooLarge, errors.Cause(p.Validate(ctx, act)))
+	act, err = action.NewCreateStake(1, "delegate1", big.NewInt(100), 7, false, make([]byte, size), uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	require.NoError(p.Validate(ctx, act))
+
+	// the cap is checked before the payload of a withdrawal is parsed as the recipient
+	g.WithdrawRecipientHeight = 1
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(1)})
+	recipient := identityset.Address(2).String()
+	for _, e := range []struct {
+		payload []byte
+		status  uint64
+	}{
+		{[]byte(recipient + strings.Repeat(" ", size+1-len(recipient))), ReceiptStatusErrPayloadTooLarge},
+		{[]byte(recipient + strings.Repeat(" ", size-len(recipient))), ReceiptStatusErrInvalidRecipient},
+	} {
+		withdraw, err := action.NewWithdrawStake(1, 0, e.payload, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, withdraw, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status)
+	}
+}
+
+func TestProtocol_HandleCreateStake(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	g.BucketIndexReceiptHeight = 2
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(1)})
+	p := NewProtocol()
+	require.NoError(stakingPutDelegate(ws, &Delegate{
+		Owner:              identityset.Address(11).String(),
+		Address:            identityset.Address(12).String(),
+		RewardAddress:      identityset.Address(13).String(),
+		CanName:            ToCandName([]byte("delegate1")),
+		Votes:              big.NewInt(0),
+		SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+	}))
+	require.NoError(stakingPutRewardAddressOwner(ws, identityset.Address(13).String(), identityset.Address(11).String()))
+	staker, err := accountutil.LoadOrCreateAccount(ws, identityset.Address(1).String())
+	require.NoError(err)
+	require.NoError(staker.AddBalance(big.NewInt(1000)))
+	require.NoError(accountutil.StoreAccount(ws, identityset.Address(1).String(), staker))
+
+	tests := []struct {
+		height uint64
+		name   string
+		amount int64
+		status uint64
+		index  int64
+	}{
+		// the receipt carries no bucket index before the activation height
+		{1, "delegate1", 100, uint64(iotextypes.ReceiptStatus_Success), -1},
+		{2, "delegate1", 200, uint64(iotextypes.ReceiptStatus_Success), 1},
+		{2, "delegate1", 300, uint64(iotextypes.ReceiptStatus_Success), 2},
+		// the candidate doesn't exist
+		{2, "delegate2", 100, uint64(iotextypes.ReceiptStatus_Failure), -1},
+		// insufficient balance
+		{2, "delegate1", 500, uint64(iotextypes.ReceiptStatus_Failure), -1},
+	}
+	for _, e := range tests {
+		ctx := protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: e.height, BlockTimeStamp: time.Unix(1580000000, 0)})
+		act, err := action.NewCreateStake(1, e.name, big.NewInt(e.amount), 0, false, nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status)
+		require.Equal(p.addr.String(), r.ContractAddress)
+		index, err := r.BucketIndex()
+		if e.index < 0 {
+			require.Equal(action.ErrNoBucketIndex, err)
+		} else {
+			require.NoError(err)
+			require.Equal(uint64(e.index), index)
+		}
+	}
+
+	// the bucket index survives the receipt serialization
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 2, BlockTimeStamp: time.Unix(1580000000, 0)})
+	act, err := action.NewCreateStake(1, "delegate1", big.NewInt(400), 0, false, nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	r, err := handle(ctx, p, act, ws)
+	require.NoError(err)
+	data, err := r.Serialize()
+	require.NoError(err)
+	r2 := &action.Receipt{}
+	require.NoError(r2.Deserialize(data))
+	index, err := r2.BucketIndex()
+	require.NoError(err)
+	require.Equal(uint64(3), index)
+
+	staker, err = accountutil.LoadOrCreateAccount(ws, identityset.Address(1).String())
+	require.NoError(err)
+	require.Equal("0", staker.Balance.String())
+	d, err := stakingGetDelegateByName(ws, ToCandName([]byte("delegate1")))
+	require.NoError(err)
+	require.Equal("1000", d.Votes.String())
+	require.NoError(StateAudit(ws, genesis.Default))
+}
+
+func TestProtocol_BucketCaps(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	g.MaxBucketsPerVoter = 3
+	g.MaxBucketsPerCandidate = 5
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 1, BlockTimeStamp: time.Unix(1580000000, 0)})
+	p := NewProtocol()
+	name := ToCandName([]byte("delegate1"))
+	require.NoError(stakingPutDelegate(ws, &Delegate{
+		Owner:              identityset.Address(11).String(),
+		Address:            identityset.Address(12).String(),
+		RewardAddress:      identityset.Address(13).String(),
+		CanName:            name,
+		Votes:              big.NewInt(0),
+		SelfStakeBucketIdx: NoSelfStakeBucketIndex,
+	}))
+	require.NoError(stakingPutRewardAddressOwner(ws, identityset.Address(13).String(), identityset.Address(11).String()))
+	for _, i := range []int{1, 2} {
+		staker, err := accountutil.LoadOrCreateAccount(ws, identityset.Address(i).String())
+		require.NoError(err)
+		require.NoError(staker.AddBalance(big.NewInt(1000)))
+		require.NoError(accountutil.StoreAccount(ws, identityset.Address(i).String(), staker))
+	}
+
+	create := func(caller int) uint64 {
+		ctx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(caller)})
+		act, err := action.NewCreateStake(1, "delegate1", big.NewInt(100), 0, false, nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		return r.Status
+	}
+	transfer := func(caller, recipient int, index uint64) uint64 {
+		ctx := protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(caller)})
+		act, err := action.NewTransferStake(1, identityset.Address(recipient).String(), index, nil, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		return r.Status
+	}
+	success := uint64(iotextypes.ReceiptStatus_Success)
+
+	// the creates of the same block count toward the cap of the voter
+	for i := 0; i < 3; i++ {
+		require.Equal(success, create(1))
+	}
+	require.Equal(ReceiptStatusErrBucketCapExceeded, create(1))
+	// a transfer away frees the capacity
+	require.Equal(success, transfer(1, 2, 0))
+	require.Equal(success, create(1))
+	// a transfer counts toward the cap of the recipient
+	require.Equal(success, transfer(1, 2, 1))
+	require.Equal(success, transfer(1, 2, 2))
+	require.Equal(ReceiptStatusErrBucketCapExceeded, transfer(1, 2, 3))
+	act, err := action.NewTransferStake(1, identityset.Address(2).String(), 3, nil, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	_, err = p.Handle(protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(1)}), act, ws)
+	require.Equal(ErrBucketCapExceeded, errors.Cause(err))
+	require.Contains(err.Error(), identityset.Address(2).String())
+	// only the owner can transfer a bucket
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), transfer(1, 3, 0))
+
+	// the candidate has 4 buckets, so only one more can be created
+	require.Equal(success, transfer(2, 3, 0))
+	require.Equal(success, create(2))
+	require.Equal(ReceiptStatusErrBucketCapExceeded, create(1))
+	count, err := stakingGetCandidateBucketCount(ws, name)
+	require.NoError(err)
+	require.Equal(uint64(5), count)
+
+	for _, e := range []struct {
+		voter   int
+		indices []uint64
+	}{
+		{1, []uint64{3}},
+		{2, []uint64{1, 2, 4}},
+		{3, []uint64{0}},
+	} {
+		bis, err := stakingGetBucketIndices(ws, identityset.Address(e.voter).String())
+		require.NoError(err)
+		var indices []uint64
+		for _, bi := range bis.GetIndices() {
+			indices = append(indices, bi.Index)
+		}
+		require.Equal(e.indices, indices)
+	}
+	require.NoError(StateAudit(ws, genesis.Default))
+}
+
+func TestProtocol_HandleWithdrawStake(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	g := genesis.Default
+	g.WithdrawRecipientHeight = 2
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Genesis: g})
+	p := NewProtocol()
+	owner := identityset.Address(1)
+	name := ToCandName([]byte("delegate1"))
+	unstakeTime, err := ptypes.TimestampProto(time.Unix(1580000000, 0))
+	require.NoError(err)
+	// buckets 0 to 5 are unstaked, and bucket 6 isn't
+	for i := uint64(0); i < 7; i++ {
+		vb, err := NewVoteBucket("delegate1", owner.String(), "100", 0, time.Unix(1570000000, 0), false)
+		require.NoError(err)
+		if i < 6 {
+			vb.UnstakeStartTime = unstakeTime
+		}
+		require.NoError(stakingPutBucket(ws, name, vb))
+		require.NoError(stakingPutBucketIndex(ws, owner.String(), NewBucketIndex(i, name)))
+	}
+
+	tests := []struct {
+		height    uint64
+		caller    int
+		index     uint64
+		payload   []byte
+		status    uint64
+		recipient int
+	}{
+		// the payload is ignored before the activation height
+		{1, 1, 0, []byte("cold wallet"), uint64(iotextypes.ReceiptStatus_Success), 1},
+		// explicit recipient
+		{2, 1, 1, []byte(identityset.Address(2).String()), uint64(iotextypes.ReceiptStatus_Success), 2},
+		// empty payload
+		{2, 1, 2, nil, uint64(iotextypes.ReceiptStatus_Success), 1},
+		// malformed payload
+		{2, 1, 3, []byte("cold wallet"), ReceiptStatusErrInvalidRecipient, 0},
+		{2, 1, 3, []byte(identityset.Address(2).String() + " "), ReceiptStatusErrInvalidRecipient, 0},
+		// only the owner can withdraw, even to itself
+		{2, 2, 4, []byte(identityset.Address(2).String()), uint64(iotextypes.ReceiptStatus_Failure), 0},
+		// the bucket isn't unstaked
+		{2, 1, 6, nil, uint64(iotextypes.ReceiptStatus_Failure), 0},
+	}
+	for _, e := range tests {
+		ctx := protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: e.height})
+		ctx = protocol.WithActionCtx(ctx, protocol.ActionCtx{Caller: identityset.Address(e.caller)})
+		var before *big.Int
+		if e.recipient != 0 {
+			acct, err := accountutil.LoadOrCreateAccount(ws, identityset.Address(e.recipient).String())
+			require.NoError(err)
+			before = acct.Balance
+		}
+		act, err := action.NewWithdrawStake(1, e.index, e.payload, uint64(100000), big.NewInt(0))
+		require.NoError(err)
+		r, err := handle(ctx, p, act, ws)
+		require.NoError(err)
+		require.Equal(e.status, r.Status)
+
+		_, err = stakingGetBucket(ws, name, e.index)
+		if e.recipient == 0 {
+			require.NoError(err)
+			require.Empty(r.Logs)
+			continue
+		}
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		acct, err := accountutil.LoadOrCreateAccount(ws, identityset.Address(e.recipient).String())
+		require.NoError(err)
+		require.Equal(new(big.Int).Add(before, big.NewInt(100)), acct.Balance)
+		require.Len(r.Logs, 1)
+		var log stakingpb.WithdrawLog
+		require.NoError(proto.Unmarshal(r.Logs[0].Data, &log))
+		require.Equal(e.index, log.BucketIndex)
+		require.Equal(identityset.Address(e.recipient).String(), log.Recipient)
+		require.Equal("100", log.Amount)
+	}
+
+	bis, err := stakingGetBucketIndices(ws, owner.String())
+	require.NoError(err)
+	require.Len(bis.GetIndices(), 4)
+	count, err := stakingGetCandidateBucketCount(ws, name)
+	require.NoError(err)
+	require.Equal(uint64(4), count)
+}
+
+func TestReceiptStatusStability(t *testing.T) {
+	require := require.New(t)
+
+	// the statuses are part of the receipts, so their values never change
+	for code, expected := range map[uint64]uint64{
+		ReceiptStatusErrRewardAddressConflict: 200,
+		ReceiptStatusErrPayloadTooLarge:       201,
+		ReceiptStatusErrUnauthorized:          202,
+		ReceiptStatusErrBucketCapExceeded:     203,
+		ReceiptStatusErrInvalidRecipient:      204,
+	} {
+		require.Equal(expected, code)
+	}
+	r, ok := protocol.ReceiptStatusRangeOf(protocolID)
+	require.True(ok)
+	for sentinel, code := range receiptStatuses {
+		require.True(r.Contains(code), "status %d of %v", code, sentinel)
+		status, ok := protocol.ReceiptStatusOf(failAction(errors.Wrap(sentinel, "failed")))
+		require.True(ok)
+		require.Equal(code, status)
+	}
+	// the errors which aren't mapped fail the action with the generic failure
+	status, ok := protocol.ReceiptStatusOf(failAction(errors.New("failed")))
+	require.True(ok)
+	require.Equal(uint64(iotextypes.ReceiptStatus_Failure), status)
+}
+
+// handle handles the action like running it in a working set, which turns the error with a status into the failure
+// receipt
+func handle(ctx context.Context, p *Protocol, act action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	r, err := p.Handle(ctx, act, sm)
+	if receipt, ok := protocol.FailureReceipt(ctx, err); ok {
+		return receipt, nil
+	}
+	return r, err
+}
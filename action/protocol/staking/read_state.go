@@ -0,0 +1,214 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// bucketPageDefaults and maxBucketPageLimit define the pagination of the buckets of a voter
+var (
+	bucketPageDefaults        = protocol.PaginationArgs{Limit: 100}
+	maxBucketPageLimit uint64 = 1000
+)
+
+type (
+	readStateFunc func(context.Context, protocol.StateReader, ...[]byte) ([]byte, error)
+
+	// heightReader reads the states at a given height
+	heightReader struct {
+		protocol.StateReader
+		height uint64
+	}
+)
+
+// Height returns the height at which the states are read
+func (r *heightReader) Height() (uint64, error) {
+	return r.height, nil
+}
+
+// TipHeight returns the height at which the states are read, which is committed
+func (r *heightReader) TipHeight() (uint64, error) {
+	return r.height, nil
+}
+
+// State reads a state at the given height
+func (r *heightReader) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	return r.StateReader.State(s, append(opts, protocol.BlockHeightOption(r.height))...)
+}
+
+// States reads the states of the keys at the given height
+func (r *heightReader) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	return r.StateReader.States(append(opts, protocol.BlockHeightOption(r.height))...)
+}
+
+// readStateVoterTotal returns the total staked amount, the total weighted votes and the number of buckets of a voter.
+// Unstaked buckets are still locked, so they count towards the staked amount but not towards the votes.
+func readStateVoterTotal(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	// the response is stamped with the committed height, at which the later reads can be made
+	height, err := sr.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	staked, votes := big.NewInt(0), big.NewInt(0)
+	total := stakingpb.VoterTotal{Height: height}
+	bis, err := stakingGetBucketIndices(sr, string(args[0]))
+	switch errors.Cause(err) {
+	case nil:
+		for _, bi := range bis.GetIndices() {
+			bucket, err := stakingGetBucket(sr, ToCandName(bi.CanName), bi.Index)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get bucket %d", bi.Index)
+			}
+			staked.Add(staked, bucket.Amount())
+			if !bucket.IsUnstaked() {
+				votes.Add(votes, calculateVoteWeight(bcCtx.Genesis.VoteWeightCalConsts, bucket, false))
+			}
+			total.BucketCount++
+		}
+	case state.ErrStateNotExist:
+		// the voter doesn't own any bucket
+	default:
+		return nil, err
+	}
+	total.StakedAmount = staked.String()
+	total.Votes = votes.String()
+	return proto.Marshal(&total)
+}
+
+// readStateDelegateByName returns the delegate of the given name. The reward address is always exposed, so that the
+// reward addresses shared by delegates registered before the uniqueness activation can be surfaced.
+func readStateDelegateByName(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	d, err := stakingGetDelegateByName(sr, ToCandName(args[0]))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get delegate %s", args[0])
+	}
+	return proto.Marshal(d.toProto())
+}
+
+// readStateBucketsByVoter returns a page of the buckets owned by a voter. The arguments are the voter address, the
+// pagination of the buckets, and an optional flag, which returns composite buckets if it is set to 1.
+func readStateBucketsByVoter(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	if len(args) == 3 && len(args[2]) == 1 && args[2][0] == 1 {
+		return readStateCompositeBuckets(ctx, sr, args[:2]...)
+	}
+	indices, pagination, total, err := pageBucketIndices(sr, args...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := stakingGetBuckets(sr, indices)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get buckets")
+	}
+	buckets := stakingpb.VoteBuckets{}
+	for _, bucket := range page {
+		buckets.Buckets = append(buckets.Buckets, &bucket.Bucket)
+	}
+	data, err := proto.Marshal(&buckets)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Page(data, total)
+}
+
+// readStateCompositeBuckets returns a page of the buckets owned by a voter, each of which is joined with the current
+// name and status of its candidate. The arguments are the voter address and the pagination of the buckets.
+// The join is made against the state at the time of the call, so the candidate info is consistent within a single
+// response, but may change between the pages requested by different calls. A bucket whose candidate no longer
+// exists comes with the raw candidate name of the bucket and an empty candidate name.
+func readStateCompositeBuckets(ctx context.Context, sr protocol.StateReader, args ...[]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, protocol.ReadStateErrorf(protocol.BadArgCount, "invalid number of arguments %d", len(args))
+	}
+	height, err := sr.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	indices, pagination, total, err := pageBucketIndices(sr, args...)
+	if err != nil {
+		return nil, err
+	}
+	page, err := stakingGetBuckets(sr, indices)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get buckets")
+	}
+	buckets := stakingpb.CompositeBuckets{Height: height}
+	delegates := make(DelegateMap)
+	for i, bi := range indices {
+		name := ToCandName(bi.CanName)
+		bucket := page[i]
+		d, ok := delegates[name]
+		if !ok {
+			d, err = stakingGetDelegateByName(sr, name)
+			switch errors.Cause(err) {
+			case nil:
+			case state.ErrStateNotExist:
+				// the candidate has been deregistered
+				d = nil
+			default:
+				return nil, errors.Wrapf(err, "failed to get delegate %x", name)
+			}
+			delegates[name] = d
+		}
+		cb := &stakingpb.CompositeBucket{
+			Bucket: &bucket.Bucket,
+			Index:  bi.Index,
+		}
+		if d != nil {
+			cb.CandidateOwner = d.Owner
+			cb.CandidateName = d.CanName.String()
+			cb.CandidateActive = d.SelfStakeBucketIdx != NoSelfStakeBucketIndex
+		}
+		buckets.Buckets = append(buckets.Buckets, cb)
+	}
+	data, err := proto.Marshal(&buckets)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Page(data, total)
+}
+
+// pageBucketIndices returns the bucket indices of a voter in the page defined by the pagination argument, along with
+// the effective pagination and the total number of the buckets of the voter
+func pageBucketIndices(
+	sr protocol.StateReader,
+	args ...[]byte,
+) ([]*stakingpb.BucketIndex, protocol.PaginationArgs, uint64, error) {
+	pagination, err := protocol.ParsePagination(args[1:2], bucketPageDefaults, maxBucketPageLimit)
+	if err != nil {
+		return nil, pagination, 0, err
+	}
+	bis, err := stakingGetBucketIndices(sr, string(args[0]))
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		return nil, pagination, 0, nil
+	default:
+		return nil, pagination, 0, err
+	}
+	indices := bis.GetIndices()
+	start, end := protocol.Paginate(len(indices), pagination)
+	return indices[start:end], pagination, uint64(len(indices)), nil
+}
@@ -0,0 +1,390 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action"
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/protocolpb"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/test/identityset"
+	"github.com/iotexproject/iotex-core/testutil"
+)
+
+func TestReadStateVoterTotal(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{
+		Genesis: genesis.Default,
+	})
+
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+	voter := identityset.Address(1).String()
+	tests := []struct {
+		name      CandName
+		amount    string
+		duration  uint32
+		autoStake bool
+		unstaked  bool
+	}{
+		{CandName{1, 2, 3}, "1000000000000000000000", 7, false, false},
+		{CandName{1, 2, 3}, "2000000000000000000000", 91, true, false},
+		{CandName{2, 3, 4}, "3000000000000000000000", 0, false, true},
+	}
+	expectedStaked, expectedVotes := big.NewInt(0), big.NewInt(0)
+	for i, e := range tests {
+		vb, err := NewVoteBucket("testname", voter, e.amount, e.duration, time.Now(), e.autoStake)
+		require.NoError(err)
+		if e.unstaked {
+			vb.UnstakeStartTime, err = ptypes.TimestampProto(time.Now())
+			require.NoError(err)
+			require.True(vb.IsUnstaked())
+		} else {
+			expectedVotes.Add(expectedVotes, calculateVoteWeight(genesis.Default.VoteWeightCalConsts, vb, false))
+		}
+		expectedStaked.Add(expectedStaked, vb.Amount())
+		require.NoError(stakingPutBucket(ws, e.name, vb))
+		require.NoError(stakingPutBucketIndex(ws, voter, NewBucketIndex(uint64(i), e.name)))
+	}
+
+	p := NewProtocol()
+	data, err := p.ReadState(ctx, ws, []byte("voterTotal"), []byte(voter))
+	require.NoError(err)
+	var total stakingpb.VoterTotal
+	require.NoError(proto.Unmarshal(data, &total))
+	require.Equal(expectedStaked.String(), total.StakedAmount)
+	require.Equal(expectedVotes.String(), total.Votes)
+	require.Equal(uint64(3), total.BucketCount)
+	// the working set of block 1 is built atop the committed genesis
+	require.Equal(uint64(0), total.Height)
+	// unstaked bucket is excluded from the votes
+	require.True(expectedVotes.Cmp(expectedStaked) < 0)
+
+	// a voter without buckets
+	data, err = p.ReadState(ctx, ws, []byte("voterTotal"), []byte(identityset.Address(2).String()))
+	require.NoError(err)
+	require.NoError(proto.Unmarshal(data, &total))
+	require.Equal("0", total.StakedAmount)
+	require.Equal("0", total.Votes)
+	require.Equal(uint64(0), total.BucketCount)
+
+	_, err = p.ReadState(ctx, ws, []byte("voterTotal"))
+	require.Error(err)
+	_, err = p.ReadState(ctx, ws, []byte("unknown"))
+	require.Error(err)
+
+	// the read routed by the registry is the same
+	registry := protocol.NewRegistry()
+	require.NoError(p.Register(registry))
+	routed, err := registry.ReadState(ctx, ws, protocolID, []byte("voterTotal"), []byte(identityset.Address(2).String()))
+	require.NoError(err)
+	require.Equal(data, routed)
+	for _, test := range []struct {
+		method string
+		args   [][]byte
+		code   protocol.ReadStateErrorCode
+	}{
+		{"voterTotal", nil, protocol.BadArgCount},
+		{"compositeBuckets", [][]byte{[]byte(voter), {0xff}}, protocol.BadArgEncoding},
+		{"bucketsByVoter", [][]byte{[]byte(voter), byteutil.Uint64ToBytes(0), byteutil.Uint64ToBytes(10)}, protocol.BadArgEncoding},
+	} {
+		_, err := registry.ReadState(ctx, ws, protocolID, []byte(test.method), test.args...)
+		code, ok := protocol.ReadStateErrorCodeOf(err)
+		require.True(ok, test.method)
+		require.Equal(test.code, code, test.method)
+	}
+}
+
+func TestReadStateCompositeBuckets(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	voter := identityset.Address(1).String()
+	active, deregistered := ToCandName([]byte("active")), ToCandName([]byte("deregistered"))
+	require.NoError(stakingPutDelegate(ws, &Delegate{
+		Owner:              identityset.Address(2).String(),
+		Address:            identityset.Address(12).String(),
+		RewardAddress:      identityset.Address(22).String(),
+		CanName:            active,
+		Votes:              big.NewInt(0),
+		SelfStakeBucketIdx: 0,
+	}))
+	for i, name := range []CandName{active, deregistered, active} {
+		vb, err := NewVoteBucket(name.String(), voter, "100", 7, time.Now(), false)
+		require.NoError(err)
+		require.NoError(stakingPutBucket(ws, name, vb))
+		require.NoError(stakingPutBucketIndex(ws, voter, NewBucketIndex(uint64(i), name)))
+	}
+
+	p := NewProtocol()
+	pagination := protocol.PaginationArgs{Offset: 1, Limit: 5}.Encode()
+	data, err := p.ReadState(ctx, ws, []byte("bucketsByVoter"), []byte(voter), pagination)
+	require.NoError(err)
+	var page protocolpb.Page
+	require.NoError(proto.Unmarshal(data, &page))
+	require.Equal(uint64(3), page.Total)
+	var buckets stakingpb.VoteBuckets
+	require.NoError(proto.Unmarshal(page.Data, &buckets))
+	require.Equal(2, len(buckets.Buckets))
+	require.Equal("deregistered", buckets.Buckets[0].CandidateName)
+
+	for _, data := range [][]byte{
+		byteutil.Must(p.ReadState(ctx, ws, []byte("compositeBuckets"), []byte(voter), pagination)),
+		byteutil.Must(p.ReadState(ctx, ws, []byte("bucketsByVoter"), []byte(voter), pagination, []byte{1})),
+	} {
+		require.NoError(proto.Unmarshal(data, &page))
+		var cbs stakingpb.CompositeBuckets
+		require.NoError(proto.Unmarshal(page.Data, &cbs))
+		require.Equal(2, len(cbs.Buckets))
+		// the candidate of the bucket has been deregistered
+		require.Equal(uint64(1), cbs.Buckets[0].Index)
+		require.Equal("deregistered", cbs.Buckets[0].Bucket.CandidateName)
+		require.Empty(cbs.Buckets[0].CandidateName)
+		require.Empty(cbs.Buckets[0].CandidateOwner)
+		require.False(cbs.Buckets[0].CandidateActive)
+		require.Equal(uint64(2), cbs.Buckets[1].Index)
+		require.Equal("active", cbs.Buckets[1].CandidateName)
+		require.Equal(identityset.Address(2).String(), cbs.Buckets[1].CandidateOwner)
+		require.True(cbs.Buckets[1].CandidateActive)
+	}
+
+	// offset beyond the last bucket
+	pagination = protocol.PaginationArgs{Offset: 3, Limit: 5}.Encode()
+	data, err = p.ReadState(ctx, ws, []byte("compositeBuckets"), []byte(voter), pagination)
+	require.NoError(err)
+	require.NoError(proto.Unmarshal(data, &page))
+	require.Equal(uint64(3), page.Total)
+	var cbs stakingpb.CompositeBuckets
+	require.NoError(proto.Unmarshal(page.Data, &cbs))
+	require.Empty(cbs.Buckets)
+
+	// the limit above the max is clamped, and the effective one is echoed
+	pagination = protocol.PaginationArgs{Limit: maxBucketPageLimit + 1}.Encode()
+	data, err = p.ReadState(ctx, ws, []byte("bucketsByVoter"), []byte(voter), pagination)
+	require.NoError(err)
+	require.NoError(proto.Unmarshal(data, &page))
+	require.Equal(maxBucketPageLimit, page.Limit)
+	require.NoError(proto.Unmarshal(page.Data, &buckets))
+	require.Equal(3, len(buckets.Buckets))
+}
+
+// stakingActivity adds a bucket for the voter in each block
+type stakingActivity struct {
+	protocol.Metadata
+	voter string
+}
+
+func (a *stakingActivity) CreatePreStates(ctx context.Context, sm protocol.StateManager) error {
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	name := ToCandName([]byte("delegate1"))
+	index := blkCtx.BlockHeight - 1
+	vb, err := NewVoteBucket("delegate1", a.voter, big.NewInt(int64(blkCtx.BlockHeight*100)).String(), 7, blkCtx.BlockTimeStamp, false)
+	if err != nil {
+		return err
+	}
+	if _, err := sm.PutState(
+		vb,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(bucketKey(name, index))); err != nil {
+		return err
+	}
+	bis, err := stakingGetBucketIndices(sm, a.voter)
+	switch errors.Cause(err) {
+	case nil:
+	case state.ErrStateNotExist:
+		bis = NewBucketIndices()
+	default:
+		return err
+	}
+	bis.addBucketIndex(NewBucketIndex(index, name))
+	addrHash, err := addrToHash(a.voter)
+	if err != nil {
+		return err
+	}
+	_, err = sm.PutState(
+		bis,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.LegacyKeyOption(addrHash))
+	return err
+}
+
+func (a *stakingActivity) Handle(context.Context, action.Action, protocol.StateManager) (*action.Receipt, error) {
+	return nil, nil
+}
+
+func (a *stakingActivity) Validate(context.Context, action.Action) error {
+	return nil
+}
+
+func (a *stakingActivity) ReadState(context.Context, protocol.StateReader, []byte, ...[]byte) ([]byte, error) {
+	return nil, protocol.ErrUnimplemented
+}
+
+func (a *stakingActivity) Register(r *protocol.Registry) error {
+	return r.Register("stakingActivity", a)
+}
+
+func (a *stakingActivity) ForceRegister(r *protocol.Registry) error {
+	return r.ForceRegister("stakingActivity", a)
+}
+
+func TestReadStateAtHeight(t *testing.T) {
+	require := require.New(t)
+
+	testTrieFile, _ := ioutil.TempFile(os.TempDir(), "trie.test")
+	testTriePath := testTrieFile.Name()
+	defer testutil.CleanupPath(t, testTriePath)
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := factory.NewFactory(cfg, factory.DefaultTrieOption())
+	require.NoError(err)
+
+	voter := identityset.Address(1).String()
+	registry := protocol.NewRegistry()
+	require.NoError((&stakingActivity{voter: voter}).Register(registry))
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  genesis.Default,
+		Registry: registry,
+	})
+	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: 0})
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	prevHash := hash.ZeroHash256
+	for height := uint64(1); height <= 3; height++ {
+		blk, err := block.NewTestingBuilder().
+			SetHeight(height).
+			SetPrevBlockHash(prevHash).
+			SetTimeStamp(testutil.TimestampNow()).
+			SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(sf.Commit(ctx, &blk))
+		prevHash = blk.HashBlock()
+	}
+
+	p := NewProtocol()
+	// the latest state is returned without the envelope
+	data, err := p.ReadState(ctx, sf, []byte("voterTotal"), []byte(voter))
+	require.NoError(err)
+	var total stakingpb.VoterTotal
+	require.NoError(proto.Unmarshal(data, &total))
+	require.Equal(uint64(3), total.Height)
+	require.Equal(uint64(3), total.BucketCount)
+	require.Equal("600", total.StakedAmount)
+
+	for _, e := range []struct {
+		height uint64
+		count  uint64
+		amount string
+	}{
+		{1, 1, "100"},
+		{2, 2, "300"},
+		{3, 3, "600"},
+	} {
+		arg, err := proto.Marshal(&stakingpb.ReadStateHeight{Height: e.height})
+		require.NoError(err)
+		data, err := p.ReadState(ctx, sf, []byte("voterTotal"), []byte(voter), arg)
+		require.NoError(err)
+		var resp stakingpb.ReadStateResponse
+		require.NoError(proto.Unmarshal(data, &resp))
+		require.Equal(e.height, resp.Height)
+		var total stakingpb.VoterTotal
+		require.NoError(proto.Unmarshal(resp.Data, &total))
+		require.Equal(e.height, total.Height)
+		require.Equal(e.count, total.BucketCount)
+		require.Equal(e.amount, total.StakedAmount)
+
+		// the optional flag has to be given along with the height
+		pagination := protocol.PaginationArgs{Limit: 10}.Encode()
+		data, err = p.ReadState(ctx, sf, []byte("bucketsByVoter"), []byte(voter), pagination, []byte{0}, arg)
+		require.NoError(err)
+		require.NoError(proto.Unmarshal(data, &resp))
+		var page protocolpb.Page
+		require.NoError(proto.Unmarshal(resp.Data, &page))
+		var buckets stakingpb.VoteBuckets
+		require.NoError(proto.Unmarshal(page.Data, &buckets))
+		require.Equal(int(e.count), len(buckets.Buckets))
+	}
+
+	// the heights without archive data are rejected instead of being served with the latest state
+	arg, err := proto.Marshal(&stakingpb.ReadStateHeight{Height: 10})
+	require.NoError(err)
+	_, err = p.ReadState(ctx, sf, []byte("voterTotal"), []byte(voter), arg)
+	require.Equal(factory.ErrNoArchiveData, errors.Cause(err))
+
+	// the state db doesn't support archive mode
+	sdb, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sdb.Start(ctx))
+	defer func() {
+		require.NoError(sdb.Stop(ctx))
+	}()
+	_, err = p.ReadState(ctx, sdb, []byte("voterTotal"), []byte(voter), arg)
+	require.Equal(factory.ErrNotSupported, errors.Cause(err))
+}
+
+func TestReadStateErrorCode(t *testing.T) {
+	require := require.New(t)
+	p := NewProtocol()
+	voter := []byte(identityset.Address(1).String())
+	for _, test := range []struct {
+		method string
+		args   [][]byte
+		code   protocol.ReadStateErrorCode
+	}{
+		{"unknownMethod", nil, protocol.UnknownMethod},
+		{"voterTotal", nil, protocol.BadArgCount},
+		{"delegateByName", [][]byte{[]byte("a"), []byte("b"), []byte("c")}, protocol.BadArgCount},
+		{"compositeBuckets", [][]byte{voter}, protocol.BadArgCount},
+		{"bucketsByVoter", [][]byte{voter, {0xff}}, protocol.BadArgEncoding},
+		{"voterTotal", [][]byte{voter, {0xff}}, protocol.BadArgEncoding},
+	} {
+		_, err := p.ReadState(context.Background(), nil, []byte(test.method), test.args...)
+		require.Error(err)
+		code, ok := protocol.ReadStateErrorCodeOf(err)
+		require.True(ok, test.method)
+		require.Equal(test.code, code, test.method)
+	}
+}
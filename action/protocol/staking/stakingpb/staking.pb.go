@@ -6,6 +6,7 @@ package stakingpb
 import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	iotextypes "github.com/iotexproject/iotex-proto/golang/iotextypes"
 	math "math"
 )
 
@@ -112,6 +113,7 @@ type Delegate struct {
 	RewardAddress        string   `protobuf:"bytes,3,opt,name=rewardAddress,proto3" json:"rewardAddress,omitempty"`
 	CanName              []byte   `protobuf:"bytes,4,opt,name=canName,proto3" json:"canName,omitempty"`
 	Votes                []byte   `protobuf:"bytes,5,opt,name=votes,proto3" json:"votes,omitempty"`
+	SelfStakeBucketIdx   uint64   `protobuf:"varint,6,opt,name=selfStakeBucketIdx,proto3" json:"selfStakeBucketIdx,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -177,6 +179,13 @@ func (m *Delegate) GetVotes() []byte {
 	return nil
 }
 
+func (m *Delegate) GetSelfStakeBucketIdx() uint64 {
+	if m != nil {
+		return m.SelfStakeBucketIdx
+	}
+	return 0
+}
+
 type Delegates struct {
 	Delegates            []*Delegate `protobuf:"bytes,1,rep,name=delegates,proto3" json:"delegates,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
@@ -216,30 +225,640 @@ func (m *Delegates) GetDelegates() []*Delegate {
 	return nil
 }
 
+type VoterTotal struct {
+	StakedAmount         string   `protobuf:"bytes,1,opt,name=stakedAmount,proto3" json:"stakedAmount,omitempty"`
+	Votes                string   `protobuf:"bytes,2,opt,name=votes,proto3" json:"votes,omitempty"`
+	BucketCount          uint64   `protobuf:"varint,3,opt,name=bucketCount,proto3" json:"bucketCount,omitempty"`
+	Height               uint64   `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VoterTotal) Reset()         { *m = VoterTotal{} }
+func (m *VoterTotal) String() string { return proto.CompactTextString(m) }
+func (*VoterTotal) ProtoMessage()    {}
+func (*VoterTotal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{4}
+}
+
+func (m *VoterTotal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VoterTotal.Unmarshal(m, b)
+}
+func (m *VoterTotal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VoterTotal.Marshal(b, m, deterministic)
+}
+func (m *VoterTotal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VoterTotal.Merge(m, src)
+}
+func (m *VoterTotal) XXX_Size() int {
+	return xxx_messageInfo_VoterTotal.Size(m)
+}
+func (m *VoterTotal) XXX_DiscardUnknown() {
+	xxx_messageInfo_VoterTotal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VoterTotal proto.InternalMessageInfo
+
+func (m *VoterTotal) GetStakedAmount() string {
+	if m != nil {
+		return m.StakedAmount
+	}
+	return ""
+}
+
+func (m *VoterTotal) GetVotes() string {
+	if m != nil {
+		return m.Votes
+	}
+	return ""
+}
+
+func (m *VoterTotal) GetBucketCount() uint64 {
+	if m != nil {
+		return m.BucketCount
+	}
+	return 0
+}
+
+func (m *VoterTotal) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type SelfStakeLog struct {
+	CanName              []byte   `protobuf:"bytes,1,opt,name=canName,proto3" json:"canName,omitempty"`
+	OldBucketIndex       uint64   `protobuf:"varint,2,opt,name=oldBucketIndex,proto3" json:"oldBucketIndex,omitempty"`
+	NewBucketIndex       uint64   `protobuf:"varint,3,opt,name=newBucketIndex,proto3" json:"newBucketIndex,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SelfStakeLog) Reset()         { *m = SelfStakeLog{} }
+func (m *SelfStakeLog) String() string { return proto.CompactTextString(m) }
+func (*SelfStakeLog) ProtoMessage()    {}
+func (*SelfStakeLog) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{5}
+}
+
+func (m *SelfStakeLog) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SelfStakeLog.Unmarshal(m, b)
+}
+func (m *SelfStakeLog) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SelfStakeLog.Marshal(b, m, deterministic)
+}
+func (m *SelfStakeLog) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SelfStakeLog.Merge(m, src)
+}
+func (m *SelfStakeLog) XXX_Size() int {
+	return xxx_messageInfo_SelfStakeLog.Size(m)
+}
+func (m *SelfStakeLog) XXX_DiscardUnknown() {
+	xxx_messageInfo_SelfStakeLog.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SelfStakeLog proto.InternalMessageInfo
+
+func (m *SelfStakeLog) GetCanName() []byte {
+	if m != nil {
+		return m.CanName
+	}
+	return nil
+}
+
+func (m *SelfStakeLog) GetOldBucketIndex() uint64 {
+	if m != nil {
+		return m.OldBucketIndex
+	}
+	return 0
+}
+
+func (m *SelfStakeLog) GetNewBucketIndex() uint64 {
+	if m != nil {
+		return m.NewBucketIndex
+	}
+	return 0
+}
+
+type WithdrawLog struct {
+	BucketIndex          uint64   `protobuf:"varint,1,opt,name=bucketIndex,proto3" json:"bucketIndex,omitempty"`
+	Recipient            string   `protobuf:"bytes,2,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	Amount               string   `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WithdrawLog) Reset()         { *m = WithdrawLog{} }
+func (m *WithdrawLog) String() string { return proto.CompactTextString(m) }
+func (*WithdrawLog) ProtoMessage()    {}
+func (*WithdrawLog) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{6}
+}
+
+func (m *WithdrawLog) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WithdrawLog.Unmarshal(m, b)
+}
+func (m *WithdrawLog) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WithdrawLog.Marshal(b, m, deterministic)
+}
+func (m *WithdrawLog) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WithdrawLog.Merge(m, src)
+}
+func (m *WithdrawLog) XXX_Size() int {
+	return xxx_messageInfo_WithdrawLog.Size(m)
+}
+func (m *WithdrawLog) XXX_DiscardUnknown() {
+	xxx_messageInfo_WithdrawLog.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WithdrawLog proto.InternalMessageInfo
+
+func (m *WithdrawLog) GetBucketIndex() uint64 {
+	if m != nil {
+		return m.BucketIndex
+	}
+	return 0
+}
+
+func (m *WithdrawLog) GetRecipient() string {
+	if m != nil {
+		return m.Recipient
+	}
+	return ""
+}
+
+func (m *WithdrawLog) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+type VoteBuckets struct {
+	Buckets              []*iotextypes.Bucket `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *VoteBuckets) Reset()         { *m = VoteBuckets{} }
+func (m *VoteBuckets) String() string { return proto.CompactTextString(m) }
+func (*VoteBuckets) ProtoMessage()    {}
+func (*VoteBuckets) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{7}
+}
+
+func (m *VoteBuckets) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VoteBuckets.Unmarshal(m, b)
+}
+func (m *VoteBuckets) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VoteBuckets.Marshal(b, m, deterministic)
+}
+func (m *VoteBuckets) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VoteBuckets.Merge(m, src)
+}
+func (m *VoteBuckets) XXX_Size() int {
+	return xxx_messageInfo_VoteBuckets.Size(m)
+}
+func (m *VoteBuckets) XXX_DiscardUnknown() {
+	xxx_messageInfo_VoteBuckets.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VoteBuckets proto.InternalMessageInfo
+
+func (m *VoteBuckets) GetBuckets() []*iotextypes.Bucket {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+type CompositeBucket struct {
+	Bucket               *iotextypes.Bucket `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Index                uint64             `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	CandidateOwner       string             `protobuf:"bytes,3,opt,name=candidateOwner,proto3" json:"candidateOwner,omitempty"`
+	CandidateName        string             `protobuf:"bytes,4,opt,name=candidateName,proto3" json:"candidateName,omitempty"`
+	CandidateActive      bool               `protobuf:"varint,5,opt,name=candidateActive,proto3" json:"candidateActive,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *CompositeBucket) Reset()         { *m = CompositeBucket{} }
+func (m *CompositeBucket) String() string { return proto.CompactTextString(m) }
+func (*CompositeBucket) ProtoMessage()    {}
+func (*CompositeBucket) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{8}
+}
+
+func (m *CompositeBucket) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompositeBucket.Unmarshal(m, b)
+}
+func (m *CompositeBucket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompositeBucket.Marshal(b, m, deterministic)
+}
+func (m *CompositeBucket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompositeBucket.Merge(m, src)
+}
+func (m *CompositeBucket) XXX_Size() int {
+	return xxx_messageInfo_CompositeBucket.Size(m)
+}
+func (m *CompositeBucket) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompositeBucket.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompositeBucket proto.InternalMessageInfo
+
+func (m *CompositeBucket) GetBucket() *iotextypes.Bucket {
+	if m != nil {
+		return m.Bucket
+	}
+	return nil
+}
+
+func (m *CompositeBucket) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *CompositeBucket) GetCandidateOwner() string {
+	if m != nil {
+		return m.CandidateOwner
+	}
+	return ""
+}
+
+func (m *CompositeBucket) GetCandidateName() string {
+	if m != nil {
+		return m.CandidateName
+	}
+	return ""
+}
+
+func (m *CompositeBucket) GetCandidateActive() bool {
+	if m != nil {
+		return m.CandidateActive
+	}
+	return false
+}
+
+type CompositeBuckets struct {
+	Buckets              []*CompositeBucket `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Height               uint64             `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *CompositeBuckets) Reset()         { *m = CompositeBuckets{} }
+func (m *CompositeBuckets) String() string { return proto.CompactTextString(m) }
+func (*CompositeBuckets) ProtoMessage()    {}
+func (*CompositeBuckets) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{9}
+}
+
+func (m *CompositeBuckets) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompositeBuckets.Unmarshal(m, b)
+}
+func (m *CompositeBuckets) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompositeBuckets.Marshal(b, m, deterministic)
+}
+func (m *CompositeBuckets) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompositeBuckets.Merge(m, src)
+}
+func (m *CompositeBuckets) XXX_Size() int {
+	return xxx_messageInfo_CompositeBuckets.Size(m)
+}
+func (m *CompositeBuckets) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompositeBuckets.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompositeBuckets proto.InternalMessageInfo
+
+func (m *CompositeBuckets) GetBuckets() []*CompositeBucket {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *CompositeBuckets) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type CandidateRegister struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	OperatorAddress      string   `protobuf:"bytes,2,opt,name=operatorAddress,proto3" json:"operatorAddress,omitempty"`
+	RewardAddress        string   `protobuf:"bytes,3,opt,name=rewardAddress,proto3" json:"rewardAddress,omitempty"`
+	Payload              []byte   `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CandidateRegister) Reset()         { *m = CandidateRegister{} }
+func (m *CandidateRegister) String() string { return proto.CompactTextString(m) }
+func (*CandidateRegister) ProtoMessage()    {}
+func (*CandidateRegister) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{10}
+}
+
+func (m *CandidateRegister) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CandidateRegister.Unmarshal(m, b)
+}
+func (m *CandidateRegister) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CandidateRegister.Marshal(b, m, deterministic)
+}
+func (m *CandidateRegister) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CandidateRegister.Merge(m, src)
+}
+func (m *CandidateRegister) XXX_Size() int {
+	return xxx_messageInfo_CandidateRegister.Size(m)
+}
+func (m *CandidateRegister) XXX_DiscardUnknown() {
+	xxx_messageInfo_CandidateRegister.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CandidateRegister proto.InternalMessageInfo
+
+func (m *CandidateRegister) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CandidateRegister) GetOperatorAddress() string {
+	if m != nil {
+		return m.OperatorAddress
+	}
+	return ""
+}
+
+func (m *CandidateRegister) GetRewardAddress() string {
+	if m != nil {
+		return m.RewardAddress
+	}
+	return ""
+}
+
+func (m *CandidateRegister) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+type CandidateUpdate struct {
+	OperatorAddress      string   `protobuf:"bytes,1,opt,name=operatorAddress,proto3" json:"operatorAddress,omitempty"`
+	RewardAddress        string   `protobuf:"bytes,2,opt,name=rewardAddress,proto3" json:"rewardAddress,omitempty"`
+	Payload              []byte   `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CandidateUpdate) Reset()         { *m = CandidateUpdate{} }
+func (m *CandidateUpdate) String() string { return proto.CompactTextString(m) }
+func (*CandidateUpdate) ProtoMessage()    {}
+func (*CandidateUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{11}
+}
+
+func (m *CandidateUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CandidateUpdate.Unmarshal(m, b)
+}
+func (m *CandidateUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CandidateUpdate.Marshal(b, m, deterministic)
+}
+func (m *CandidateUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CandidateUpdate.Merge(m, src)
+}
+func (m *CandidateUpdate) XXX_Size() int {
+	return xxx_messageInfo_CandidateUpdate.Size(m)
+}
+func (m *CandidateUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CandidateUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CandidateUpdate proto.InternalMessageInfo
+
+func (m *CandidateUpdate) GetOperatorAddress() string {
+	if m != nil {
+		return m.OperatorAddress
+	}
+	return ""
+}
+
+func (m *CandidateUpdate) GetRewardAddress() string {
+	if m != nil {
+		return m.RewardAddress
+	}
+	return ""
+}
+
+func (m *CandidateUpdate) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+type Addresses struct {
+	Addresses            []string `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Addresses) Reset()         { *m = Addresses{} }
+func (m *Addresses) String() string { return proto.CompactTextString(m) }
+func (*Addresses) ProtoMessage()    {}
+func (*Addresses) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{12}
+}
+
+func (m *Addresses) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Addresses.Unmarshal(m, b)
+}
+func (m *Addresses) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Addresses.Marshal(b, m, deterministic)
+}
+func (m *Addresses) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Addresses.Merge(m, src)
+}
+func (m *Addresses) XXX_Size() int {
+	return xxx_messageInfo_Addresses.Size(m)
+}
+func (m *Addresses) XXX_DiscardUnknown() {
+	xxx_messageInfo_Addresses.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Addresses proto.InternalMessageInfo
+
+func (m *Addresses) GetAddresses() []string {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+type ReadStateHeight struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReadStateHeight) Reset()         { *m = ReadStateHeight{} }
+func (m *ReadStateHeight) String() string { return proto.CompactTextString(m) }
+func (*ReadStateHeight) ProtoMessage()    {}
+func (*ReadStateHeight) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{13}
+}
+
+func (m *ReadStateHeight) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadStateHeight.Unmarshal(m, b)
+}
+func (m *ReadStateHeight) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadStateHeight.Marshal(b, m, deterministic)
+}
+func (m *ReadStateHeight) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadStateHeight.Merge(m, src)
+}
+func (m *ReadStateHeight) XXX_Size() int {
+	return xxx_messageInfo_ReadStateHeight.Size(m)
+}
+func (m *ReadStateHeight) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadStateHeight.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReadStateHeight proto.InternalMessageInfo
+
+func (m *ReadStateHeight) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type ReadStateResponse struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Height               uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReadStateResponse) Reset()         { *m = ReadStateResponse{} }
+func (m *ReadStateResponse) String() string { return proto.CompactTextString(m) }
+func (*ReadStateResponse) ProtoMessage()    {}
+func (*ReadStateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_289e7c8aea278311, []int{14}
+}
+
+func (m *ReadStateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadStateResponse.Unmarshal(m, b)
+}
+func (m *ReadStateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadStateResponse.Marshal(b, m, deterministic)
+}
+func (m *ReadStateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadStateResponse.Merge(m, src)
+}
+func (m *ReadStateResponse) XXX_Size() int {
+	return xxx_messageInfo_ReadStateResponse.Size(m)
+}
+func (m *ReadStateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadStateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReadStateResponse proto.InternalMessageInfo
+
+func (m *ReadStateResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ReadStateResponse) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*BucketIndex)(nil), "stakingpb.BucketIndex")
 	proto.RegisterType((*BucketIndices)(nil), "stakingpb.BucketIndices")
 	proto.RegisterType((*Delegate)(nil), "stakingpb.Delegate")
 	proto.RegisterType((*Delegates)(nil), "stakingpb.Delegates")
+	proto.RegisterType((*VoterTotal)(nil), "stakingpb.VoterTotal")
+	proto.RegisterType((*SelfStakeLog)(nil), "stakingpb.SelfStakeLog")
+	proto.RegisterType((*WithdrawLog)(nil), "stakingpb.WithdrawLog")
+	proto.RegisterType((*VoteBuckets)(nil), "stakingpb.VoteBuckets")
+	proto.RegisterType((*CompositeBucket)(nil), "stakingpb.CompositeBucket")
+	proto.RegisterType((*CompositeBuckets)(nil), "stakingpb.CompositeBuckets")
+	proto.RegisterType((*CandidateRegister)(nil), "stakingpb.CandidateRegister")
+	proto.RegisterType((*CandidateUpdate)(nil), "stakingpb.CandidateUpdate")
+	proto.RegisterType((*Addresses)(nil), "stakingpb.Addresses")
+	proto.RegisterType((*ReadStateHeight)(nil), "stakingpb.ReadStateHeight")
+	proto.RegisterType((*ReadStateResponse)(nil), "stakingpb.ReadStateResponse")
 }
 
 func init() { proto.RegisterFile("staking.proto", fileDescriptor_289e7c8aea278311) }
 
 var fileDescriptor_289e7c8aea278311 = []byte{
-	// 232 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x90, 0xcf, 0x4e, 0x03, 0x21,
-	0x10, 0x87, 0x83, 0x6d, 0xad, 0x4c, 0xdd, 0x0b, 0x1a, 0xc3, 0x71, 0x43, 0x3c, 0xec, 0x69, 0xe3,
-	0x9f, 0xb3, 0x26, 0x35, 0x5e, 0xbc, 0x78, 0xe0, 0x0d, 0xe8, 0x32, 0x69, 0x48, 0x15, 0x1a, 0x40,
-	0xeb, 0x63, 0xf8, 0xc8, 0x06, 0x58, 0xea, 0xf6, 0x36, 0xdf, 0xcc, 0x90, 0xef, 0x37, 0x40, 0x13,
-	0xa2, 0xda, 0x19, 0xbb, 0xed, 0xf7, 0xde, 0x45, 0xc7, 0xe8, 0x88, 0xfb, 0x8d, 0x78, 0x82, 0xd5,
-	0xcb, 0xd7, 0xb0, 0xc3, 0xf8, 0x66, 0x35, 0xfe, 0xb0, 0x6b, 0x58, 0x98, 0x54, 0x70, 0xd2, 0x92,
-	0x6e, 0x2e, 0x0b, 0x30, 0x0e, 0xcb, 0x41, 0xd9, 0x77, 0xf5, 0x89, 0xfc, 0xac, 0x25, 0xdd, 0xa5,
-	0xac, 0x28, 0xd6, 0xd0, 0x1c, 0x9f, 0x9b, 0x01, 0x03, 0xbb, 0x83, 0xa5, 0x29, 0x25, 0x27, 0xed,
-	0xac, 0x5b, 0x3d, 0xdc, 0xf4, 0x47, 0x59, 0x3f, 0x31, 0xc9, 0xba, 0x26, 0x7e, 0x09, 0x5c, 0xbc,
-	0xe2, 0x07, 0x6e, 0x55, 0xc4, 0xe4, 0x77, 0x07, 0x8b, 0x3e, 0xfb, 0xa9, 0x2c, 0x90, 0xfc, 0x4a,
-	0x6b, 0x8f, 0x21, 0x64, 0x3f, 0x95, 0x15, 0xd9, 0x2d, 0x34, 0x1e, 0x0f, 0xca, 0xeb, 0xf5, 0x38,
-	0x9f, 0xe5, 0xf9, 0x69, 0x73, 0x9a, 0x7f, 0x7e, 0x92, 0x3f, 0xf9, 0xbe, 0x5d, 0xc4, 0xc0, 0x17,
-	0xb9, 0x5f, 0x40, 0x3c, 0x03, 0xad, 0x89, 0x02, 0xbb, 0x07, 0xaa, 0x2b, 0x8c, 0x37, 0x5d, 0x4d,
-	0x6e, 0xaa, 0x8b, 0xf2, 0x7f, 0x6b, 0x73, 0x9e, 0xbf, 0xf9, 0xf1, 0x2f, 0x00, 0x00, 0xff, 0xff,
-	0xa8, 0x00, 0x87, 0x0c, 0x77, 0x01, 0x00, 0x00,
+	// 649 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x4d, 0x6b, 0xdb, 0x4a,
+	0x14, 0x45, 0xb6, 0xe3, 0x44, 0xd7, 0x49, 0xfc, 0x32, 0xef, 0x11, 0x44, 0x78, 0x0b, 0x23, 0x4a,
+	0x70, 0x4a, 0x71, 0xfa, 0xb5, 0x2b, 0x6d, 0x71, 0xd3, 0x45, 0x03, 0xa5, 0x85, 0x49, 0x3f, 0xb6,
+	0x9d, 0x68, 0x6e, 0x9d, 0x21, 0xb6, 0x46, 0x68, 0x26, 0xb1, 0x43, 0x37, 0xfd, 0x0b, 0xfd, 0x47,
+	0xdd, 0xf7, 0x4f, 0x95, 0x19, 0xcd, 0xc8, 0x23, 0x91, 0xd2, 0x6c, 0x8c, 0xee, 0x99, 0x23, 0x9d,
+	0x73, 0xcf, 0xdc, 0x8b, 0x61, 0x47, 0x69, 0x76, 0x29, 0xf2, 0xd9, 0xa4, 0x28, 0xa5, 0x96, 0x24,
+	0x76, 0x65, 0x71, 0x7e, 0x90, 0x58, 0xe4, 0x58, 0xdf, 0x14, 0xa8, 0x8e, 0x59, 0xa6, 0x85, 0xcc,
+	0x2b, 0x52, 0xfa, 0x1c, 0x06, 0xaf, 0xae, 0xb2, 0x4b, 0xd4, 0xa7, 0x39, 0xc7, 0x15, 0xf9, 0x0f,
+	0x36, 0x84, 0x79, 0x48, 0xa2, 0x51, 0x34, 0xee, 0xd1, 0xaa, 0x20, 0x09, 0x6c, 0x66, 0x2c, 0x7f,
+	0xc7, 0x16, 0x98, 0x74, 0x46, 0xd1, 0x78, 0x9b, 0xfa, 0x32, 0x9d, 0xc2, 0x4e, 0xfd, 0xba, 0xc8,
+	0x50, 0x91, 0x87, 0xb0, 0x29, 0xaa, 0xc7, 0x24, 0x1a, 0x75, 0xc7, 0x83, 0xc7, 0xfb, 0x93, 0xda,
+	0xc6, 0x24, 0x50, 0xa2, 0x9e, 0x96, 0xfe, 0x8c, 0x60, 0xeb, 0x35, 0xce, 0x71, 0xc6, 0x34, 0x1a,
+	0x7d, 0xb9, 0xcc, 0xb1, 0xb4, 0xfa, 0x31, 0xad, 0x0a, 0xa3, 0xcf, 0x38, 0x2f, 0x51, 0x29, 0xab,
+	0x1f, 0x53, 0x5f, 0x92, 0x7b, 0xb0, 0x53, 0xe2, 0x92, 0x95, 0x7c, 0xea, 0xce, 0xbb, 0xf6, 0xbc,
+	0x09, 0x86, 0xfe, 0x7b, 0x0d, 0xff, 0x46, 0xef, 0x5a, 0x6a, 0x54, 0xc9, 0x86, 0xc5, 0xab, 0x82,
+	0x4c, 0x80, 0x28, 0x9c, 0x7f, 0x3d, 0xd3, 0xec, 0x12, 0x9d, 0x67, 0xbe, 0x4a, 0xfa, 0x36, 0x92,
+	0x5b, 0x4e, 0xd2, 0x17, 0x10, 0xfb, 0x0e, 0x14, 0x79, 0x04, 0x31, 0xf7, 0x85, 0xcb, 0xe0, 0xdf,
+	0x20, 0x03, 0x4f, 0xa4, 0x6b, 0x56, 0xfa, 0x3d, 0x02, 0xf8, 0x24, 0x35, 0x96, 0x1f, 0xa4, 0x66,
+	0x73, 0x92, 0xc2, 0xb6, 0xe1, 0x23, 0x9f, 0x2e, 0xe4, 0x55, 0xae, 0x5d, 0x16, 0x0d, 0x6c, 0x6d,
+	0xbc, 0x0a, 0xc4, 0x19, 0x1f, 0xc1, 0xe0, 0xdc, 0xba, 0x3a, 0xb1, 0x2f, 0x76, 0xad, 0xe3, 0x10,
+	0x22, 0xfb, 0xd0, 0xbf, 0x40, 0x31, 0xbb, 0xd0, 0x36, 0x89, 0x1e, 0x75, 0x55, 0xba, 0x82, 0xed,
+	0x33, 0xdf, 0xd8, 0x5b, 0x39, 0x0b, 0x23, 0x8b, 0x9a, 0x91, 0x1d, 0xc2, 0xae, 0x9c, 0xf3, 0xe0,
+	0x2a, 0xad, 0x85, 0x1e, 0x6d, 0xa1, 0x86, 0x97, 0xe3, 0x32, 0xe4, 0x55, 0x76, 0x5a, 0x68, 0x8a,
+	0x30, 0xf8, 0x2c, 0xf4, 0x05, 0x2f, 0xd9, 0xd2, 0x08, 0xd7, 0x2d, 0x9c, 0x06, 0x73, 0x18, 0x42,
+	0xe4, 0x7f, 0x88, 0x4b, 0xcc, 0x44, 0x21, 0x30, 0xd7, 0xae, 0xfd, 0x35, 0x60, 0x1a, 0x64, 0x8b,
+	0xba, 0xfb, 0x98, 0xba, 0x2a, 0x7d, 0x06, 0x03, 0x13, 0x71, 0xa5, 0xac, 0xc8, 0x03, 0xd8, 0xac,
+	0xbe, 0xe9, 0xef, 0x88, 0x4c, 0x84, 0xd4, 0xb8, 0xb2, 0x2b, 0xe2, 0x06, 0x95, 0x7a, 0x4a, 0xfa,
+	0x2b, 0x82, 0xe1, 0x89, 0x5c, 0x14, 0x52, 0x09, 0xff, 0x09, 0x72, 0x1f, 0xfa, 0xd5, 0xb1, 0xf5,
+	0x78, 0xfb, 0x07, 0x1c, 0x63, 0xbd, 0x56, 0x9d, 0x70, 0xad, 0x0e, 0x61, 0x37, 0x63, 0x39, 0x17,
+	0x9c, 0x69, 0x7c, 0x6f, 0xa7, 0xbe, 0xb2, 0xdc, 0x42, 0xcd, 0x90, 0xd7, 0x48, 0x3d, 0xc4, 0x31,
+	0x6d, 0x82, 0x64, 0x0c, 0xc3, 0x1a, 0x98, 0x66, 0x5a, 0x5c, 0xa3, 0x1d, 0xea, 0x2d, 0xda, 0x86,
+	0xd3, 0x2f, 0xf0, 0x4f, 0xab, 0x19, 0x45, 0x9e, 0xb6, 0xf3, 0x38, 0x08, 0x66, 0xb6, 0xc5, 0xae,
+	0x73, 0x09, 0xa6, 0xa9, 0xd3, 0x98, 0xa6, 0x1f, 0x11, 0xec, 0x9d, 0x78, 0x55, 0x8a, 0x33, 0xa1,
+	0x34, 0x96, 0x84, 0x40, 0x2f, 0xf7, 0x03, 0x15, 0xd3, 0x5e, 0xee, 0x5c, 0xcb, 0x02, 0x4b, 0xa6,
+	0x65, 0x39, 0x6d, 0xac, 0x78, 0x1b, 0xbe, 0xfb, 0xaa, 0x17, 0xec, 0x66, 0x2e, 0x19, 0xf7, 0xab,
+	0xee, 0xca, 0xf4, 0x1b, 0x0c, 0x6b, 0x4b, 0x1f, 0x0b, 0xf3, 0x7b, 0x9b, 0x78, 0x74, 0x47, 0xf1,
+	0xce, 0x5f, 0xc4, 0xbb, 0x4d, 0xf1, 0x23, 0x88, 0x1d, 0x09, 0x95, 0x19, 0x60, 0xe6, 0x0b, 0x9b,
+	0x76, 0x4c, 0xd7, 0x40, 0x7a, 0x04, 0x43, 0x8a, 0x8c, 0x9f, 0x69, 0xa6, 0xf1, 0x8d, 0x8d, 0x33,
+	0x88, 0x39, 0x6a, 0xc4, 0xfc, 0x12, 0xf6, 0x6a, 0x2a, 0x45, 0x55, 0xc8, 0x5c, 0xa1, 0x49, 0x99,
+	0x33, 0xcd, 0xdc, 0xda, 0xda, 0xe7, 0x3f, 0xdd, 0xd3, 0x79, 0xdf, 0xfe, 0x09, 0x3c, 0xf9, 0x3d,
+	0x00, 0xb1, 0x15, 0xc3, 0x3c, 0x3a, 0x06, 0x00, 0x00,
 }
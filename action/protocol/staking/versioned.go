@@ -0,0 +1,110 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+)
+
+// The versions of the serialized bucket and delegate. A version 1 record is the bare protobuf encoding, while the
+// later versions start with the magic byte followed by the version byte. The magic byte can't start a protobuf
+// encoding, since it decodes as a tag with field number 0, which is invalid in protobuf.
+const (
+	stateVersion1 = uint8(1)
+	stateVersion2 = uint8(2)
+
+	currentStateVersion = stateVersion2
+
+	stateVersionMagic = byte(0)
+)
+
+// ErrUnsupportedStateVersion indicates that a state object is encoded in a version that this node doesn't support
+var ErrUnsupportedStateVersion = errors.New("unsupported state version")
+
+type (
+	// versioned is a state object which knows the version it was deserialized from, and which is serialized in the
+	// version set to it
+	versioned interface {
+		Serialize() ([]byte, error)
+		Deserialize([]byte) error
+		Version() uint8
+		setVersion(uint8)
+	}
+
+	// versionedStateManager writes the buckets and the delegates in the version of the block height. The ones read
+	// in an older version are rewritten in that version, so that the records are upgraded lazily the first time
+	// they are read by a handler.
+	versionedStateManager struct {
+		protocol.StateManager
+		version uint8
+	}
+)
+
+// stateVersion returns the version in which the buckets and the delegates are written at the height of the block
+func stateVersion(ctx context.Context) uint8 {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	if h := bcCtx.Genesis.VersionedStateHeight; h != 0 && blkCtx.BlockHeight >= h {
+		return currentStateVersion
+	}
+	return stateVersion1
+}
+
+func withStateVersion(ctx context.Context, sm protocol.StateManager) protocol.StateManager {
+	return &versionedStateManager{StateManager: sm, version: stateVersion(ctx)}
+}
+
+func (vsm *versionedStateManager) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	h, err := vsm.StateManager.State(s, opts...)
+	if err != nil {
+		return h, err
+	}
+	if v, ok := s.(versioned); ok && v.Version() < vsm.version {
+		v.setVersion(vsm.version)
+		return vsm.StateManager.PutState(v, opts...)
+	}
+	return h, nil
+}
+
+func (vsm *versionedStateManager) PutState(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	if v, ok := s.(versioned); ok {
+		v.setVersion(vsm.version)
+	}
+	return vsm.StateManager.PutState(s, opts...)
+}
+
+// serializeVersioned encodes the protobuf message in the version, where an unset version is version 1
+func serializeVersioned(pb proto.Message, version uint8) ([]byte, error) {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	if version <= stateVersion1 {
+		return data, nil
+	}
+	return append([]byte{stateVersionMagic, version}, data...), nil
+}
+
+// deserializeVersioned decodes the data into the protobuf message and returns the version of the data
+func deserializeVersioned(data []byte, pb proto.Message) (uint8, error) {
+	if len(data) == 0 || data[0] != stateVersionMagic {
+		return stateVersion1, proto.Unmarshal(data, pb)
+	}
+	if len(data) < 2 {
+		return 0, errors.Wrap(ErrUnsupportedStateVersion, "no version after the magic byte")
+	}
+	version := data[1]
+	if version <= stateVersion1 || version > currentStateVersion {
+		return 0, errors.Wrapf(ErrUnsupportedStateVersion, "version %d", version)
+	}
+	return version, proto.Unmarshal(data[2:], pb)
+}
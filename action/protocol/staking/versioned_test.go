@@ -0,0 +1,203 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package staking
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state/factory"
+)
+
+const (
+	testOwner = "io14s0vgnj0pjnazu4hsqlksdk7slah9vcfscn9ks"
+	// golden encodings of the bucket and the delegate below
+	bucketV1Hex   = "0a0964656c656761746531120431303030185b22060880c6b3f1052a060880c6b3f105320038014229696f3134733076676e6a30706a6e617a75346873716c6b73646b37736c61683976636673636e396b73"
+	delegateV1Hex = "0a29696f3134733076676e6a30706a6e617a75346873716c6b73646b37736c61683976636673636e396b731229696f3134733076676e6a30706a6e617a75346873716c6b73646b37736c61683976636673636e396b731a29696f3134733076676e6a30706a6e617a75346873716c6b73646b37736c61683976636673636e396b73220c00000064656c6567617465312a0203e83007"
+)
+
+func testBucket() *VoteBucket {
+	return &VoteBucket{
+		Bucket: iotextypes.Bucket{
+			CandidateName:    "delegate1",
+			StakedAmount:     "1000",
+			StakedDuration:   91,
+			CreateTime:       &timestamp.Timestamp{Seconds: 1580000000},
+			StakeStartTime:   &timestamp.Timestamp{Seconds: 1580000000},
+			UnstakeStartTime: &timestamp.Timestamp{},
+			AutoStake:        true,
+			Owner:            testOwner,
+		},
+	}
+}
+
+func testDelegate() *Delegate {
+	return &Delegate{
+		Owner:              testOwner,
+		Address:            testOwner,
+		RewardAddress:      testOwner,
+		CanName:            ToCandName([]byte("delegate1")),
+		Votes:              big.NewInt(1000),
+		SelfStakeBucketIdx: 7,
+	}
+}
+
+func TestVersionedBucket(t *testing.T) {
+	require := require.New(t)
+
+	v1, err := hex.DecodeString(bucketV1Hex)
+	require.NoError(err)
+	v2 := append([]byte{stateVersionMagic, stateVersion2}, v1...)
+
+	vb := testBucket()
+	data, err := vb.Serialize()
+	require.NoError(err)
+	require.Equal(v1, data)
+	vb.setVersion(stateVersion2)
+	data, err = vb.Serialize()
+	require.NoError(err)
+	require.Equal(v2, data)
+
+	for _, e := range []struct {
+		data    []byte
+		version uint8
+	}{
+		{v1, stateVersion1},
+		{v2, stateVersion2},
+	} {
+		vb = &VoteBucket{}
+		require.NoError(vb.Deserialize(e.data))
+		require.Equal(e.version, vb.Version())
+		require.Equal("delegate1", vb.CandidateName)
+		require.Equal("1000", vb.StakedAmount)
+		require.Equal(uint32(91), vb.StakedDuration)
+		require.Equal(int64(1580000000), vb.CreateTime.Seconds)
+		require.True(vb.AutoStake)
+		require.Equal(testOwner, vb.Owner)
+	}
+
+	// a higher version is rejected instead of being decoded partially
+	vb = &VoteBucket{}
+	err = vb.Deserialize(append([]byte{stateVersionMagic, currentStateVersion + 1}, v1...))
+	require.Equal(ErrUnsupportedStateVersion, errors.Cause(err))
+}
+
+func TestVersionedDelegate(t *testing.T) {
+	require := require.New(t)
+
+	v1, err := hex.DecodeString(delegateV1Hex)
+	require.NoError(err)
+	v2 := append([]byte{stateVersionMagic, stateVersion2}, v1...)
+
+	d := testDelegate()
+	data, err := d.Serialize()
+	require.NoError(err)
+	require.Equal(v1, data)
+	d.setVersion(stateVersion2)
+	data, err = d.Serialize()
+	require.NoError(err)
+	require.Equal(v2, data)
+
+	for _, e := range []struct {
+		data    []byte
+		version uint8
+	}{
+		{v1, stateVersion1},
+		{v2, stateVersion2},
+	} {
+		d = &Delegate{}
+		require.NoError(d.Deserialize(e.data))
+		require.Equal(e.version, d.Version())
+		require.Equal(testOwner, d.Owner)
+		require.Equal(testOwner, d.RewardAddress)
+		require.Equal(ToCandName([]byte("delegate1")), d.CanName)
+		require.Equal("1000", d.Votes.String())
+		require.Equal(uint64(7), d.SelfStakeBucketIdx)
+	}
+
+	d = &Delegate{}
+	err = d.Deserialize(append([]byte{stateVersionMagic, currentStateVersion + 1}, v1...))
+	require.Equal(ErrUnsupportedStateVersion, errors.Cause(err))
+}
+
+func TestMigrateState(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	sf, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+
+	// write the legacy records directly
+	name := ToCandName([]byte("delegate1"))
+	bucketV1, err := hex.DecodeString(bucketV1Hex)
+	require.NoError(err)
+	delegateV1, err := hex.DecodeString(delegateV1Hex)
+	require.NoError(err)
+	dKey, err := delegateKey(testOwner)
+	require.NoError(err)
+	kv, err := ws.KVStore(factory.StakingNameSpace)
+	require.NoError(err)
+	require.NoError(kv.Put(bucketKey(name, 0), bucketV1))
+	require.NoError(kv.Put(dKey, delegateV1))
+
+	g := config.Default.Genesis
+	g.VersionedStateHeight = 10
+	withHeight := func(height uint64) context.Context {
+		ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{Genesis: g})
+		return protocol.WithBlockCtx(ctx, protocol.BlockCtx{BlockHeight: height})
+	}
+	stored := func(key []byte) []byte {
+		data, err := kv.Get(key)
+		require.NoError(err)
+		return data
+	}
+
+	// the records are neither upgraded nor written in version 2 before the height
+	sm := withStateVersion(withHeight(9), ws)
+	vb, err := stakingGetBucket(sm, name, 0)
+	require.NoError(err)
+	require.Equal(stateVersion1, vb.Version())
+	d, err := stakingGetDelegate(sm, testOwner)
+	require.NoError(err)
+	require.Equal(stateVersion1, d.Version())
+	require.Equal(bucketV1, stored(bucketKey(name, 0)))
+	require.NoError(stakingPutDelegate(sm, d))
+	require.Equal(delegateV1, stored(dKey))
+
+	// the records read from the height are upgraded
+	sm = withStateVersion(withHeight(10), ws)
+	_, err = stakingGetBucket(sm, name, 0)
+	require.NoError(err)
+	_, err = stakingGetDelegate(sm, testOwner)
+	require.NoError(err)
+	require.Equal(append([]byte{stateVersionMagic, stateVersion2}, bucketV1...), stored(bucketKey(name, 0)))
+	require.Equal(append([]byte{stateVersionMagic, stateVersion2}, delegateV1...), stored(dKey))
+	vb, err = stakingGetBucket(ws, name, 0)
+	require.NoError(err)
+	require.Equal(currentStateVersion, vb.Version())
+	d, err = stakingGetDelegate(ws, testOwner)
+	require.NoError(err)
+	require.Equal(currentStateVersion, d.Version())
+
+	_, err = stakingGetBucket(sm, name, 1)
+	require.Error(err)
+}
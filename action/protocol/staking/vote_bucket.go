@@ -7,24 +7,31 @@
 package staking
 
 import (
-	"errors"
+	"math"
 	"math/big"
 	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/iotexproject/iotex-address/address"
 	"github.com/iotexproject/iotex-proto/golang/iotextypes"
+	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/staking/stakingpb"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/state/factory"
 )
 
+const candidateBucketCountKeyPrefix = "candidateBuckets"
+
 type (
 	// VoteBucket is an alias of proto definition
 	VoteBucket struct {
 		iotextypes.Bucket
+
+		version uint8
 	}
 
 	// totalBucketCount stores the total bucket count
@@ -54,7 +61,7 @@ func NewVoteBucket(name, owner, amount string, duration uint32, ctime time.Time,
 	unstakeTime, _ := ptypes.TimestampProto(time.Unix(0, 0))
 
 	bucket := VoteBucket{
-		iotextypes.Bucket{
+		Bucket: iotextypes.Bucket{
 			CandidateName:    name,
 			StakedAmount:     amount,
 			StakedDuration:   duration,
@@ -70,12 +77,40 @@ func NewVoteBucket(name, owner, amount string, duration uint32, ctime time.Time,
 
 // Deserialize deserializes bytes into bucket
 func (vb *VoteBucket) Deserialize(data []byte) error {
-	return proto.Unmarshal(data, vb)
+	version, err := deserializeVersioned(data, &vb.Bucket)
+	if err != nil {
+		return errors.Wrap(err, "failed to deserialize bucket")
+	}
+	vb.version = version
+	return nil
 }
 
 // Serialize serializes bucket into bytes
 func (vb *VoteBucket) Serialize() ([]byte, error) {
-	return proto.Marshal(vb)
+	return serializeVersioned(&vb.Bucket, vb.version)
+}
+
+// Version returns the version which the bucket was deserialized from
+func (vb *VoteBucket) Version() uint8 {
+	return vb.version
+}
+
+func (vb *VoteBucket) setVersion(version uint8) {
+	vb.version = version
+}
+
+// Amount returns the staked amount of the bucket
+func (vb *VoteBucket) Amount() *big.Int {
+	amount, ok := new(big.Int).SetString(vb.StakedAmount, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+	return amount
+}
+
+// IsUnstaked returns true if the bucket has been unstaked
+func (vb *VoteBucket) IsUnstaked() bool {
+	return vb.UnstakeStartTime != nil && vb.UnstakeStartTime.Seconds > 0
 }
 
 // Deserialize deserializes bytes into bucket count
@@ -114,6 +149,27 @@ func stakingGetBucket(sr protocol.StateReader, name CandName, index uint64) (*Vo
 	return &vb, nil
 }
 
+// stakingGetBuckets reads the buckets of the indices in one call, in the order of the indices
+func stakingGetBuckets(sr protocol.StateReader, indices []*stakingpb.BucketIndex) ([]*VoteBucket, error) {
+	keys := make([][]byte, 0, len(indices))
+	for _, bi := range indices {
+		keys = append(keys, bucketKey(ToCandName(bi.CanName), bi.Index))
+	}
+	_, iter, err := sr.States(protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeysOption(keys))
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]*VoteBucket, 0, len(keys))
+	for range keys {
+		var vb VoteBucket
+		if _, err := iter.Next(&vb); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, &vb)
+	}
+	return buckets, nil
+}
+
 func stakingPutBucket(sm protocol.StateManager, name CandName, bucket *VoteBucket) error {
 	var tc totalBucketCount
 	if _, err := sm.State(
@@ -130,20 +186,82 @@ func stakingPutBucket(sm protocol.StateManager, name CandName, bucket *VoteBucke
 		return err
 	}
 	tc.count++
-	_, err := sm.PutState(
+	if _, err := sm.PutState(
 		&tc,
 		protocol.NamespaceOption(factory.StakingNameSpace),
-		protocol.KeyOption(factory.TotalBucketKey))
-	return err
+		protocol.KeyOption(factory.TotalBucketKey)); err != nil {
+		return err
+	}
+	count, err := stakingGetCandidateBucketCount(sm, name)
+	if err != nil {
+		return err
+	}
+	return stakingPutCandidateBucketCount(sm, name, count+1)
 }
 
 func stakingDelBucket(sm protocol.StateManager, name CandName, index uint64) error {
-	_, err := sm.DelState(
+	if _, err := sm.DelState(
 		protocol.NamespaceOption(factory.StakingNameSpace),
-		protocol.KeyOption(bucketKey(name, index)))
+		protocol.KeyOption(bucketKey(name, index))); err != nil {
+		return err
+	}
+	count, err := stakingGetCandidateBucketCount(sm, name)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.Errorf("bucket count of candidate %x is already 0", name)
+	}
+	return stakingPutCandidateBucketCount(sm, name, count-1)
+}
+
+// stakingGetCandidateBucketCount returns the number of buckets voting for the candidate
+func stakingGetCandidateBucketCount(sr protocol.StateReader, name CandName) (uint64, error) {
+	var bc totalBucketCount
+	_, err := sr.State(
+		&bc,
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(candidateBucketCountKey(name)))
+	switch errors.Cause(err) {
+	case nil:
+		return bc.count, nil
+	case state.ErrStateNotExist:
+		return 0, nil
+	default:
+		return 0, errors.Wrapf(err, "failed to get bucket count of candidate %x", name)
+	}
+}
+
+func stakingPutCandidateBucketCount(sm protocol.StateManager, name CandName, count uint64) error {
+	_, err := sm.PutState(
+		&totalBucketCount{count: count},
+		protocol.NamespaceOption(factory.StakingNameSpace),
+		protocol.KeyOption(candidateBucketCountKey(name)))
 	return err
 }
 
+// calculateVoteWeight calculates the weighted votes of a bucket
+func calculateVoteWeight(c genesis.VoteWeightCalConsts, vb *VoteBucket, selfStake bool) *big.Int {
+	weight := float64(1)
+	var m float64
+	if vb.AutoStake {
+		m = c.AutoStake
+	}
+	if vb.StakedDuration > 0 {
+		weight += math.Log(float64(vb.StakedDuration)*(1+m)) / math.Log(c.DurationLg) / 100
+	}
+	if selfStake && vb.AutoStake {
+		weight *= c.SelfStake
+	}
+	amount := new(big.Float).SetInt(vb.Amount())
+	weighted, _ := amount.Mul(amount, big.NewFloat(weight)).Int(nil)
+	return weighted
+}
+
 func bucketKey(name CandName, index uint64) []byte {
 	return append(name[:], byteutil.Uint64ToBytesBigEndian(index)...)
 }
+
+func candidateBucketCountKey(name CandName) []byte {
+	return append([]byte(candidateBucketCountKeyPrefix), name[:]...)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/state/factory"
@@ -153,3 +154,51 @@ func TestGetPutStaking(t *testing.T) {
 		testGetPut(sdb, t)
 	})
 }
+
+func TestBucketStateBytes(t *testing.T) {
+	require := require.New(t)
+	sdb, err := factory.NewStateDB(config.Default, factory.InMemStateDBOption())
+	require.NoError(err)
+	ctx := context.Background()
+	require.NoError(sdb.Start(ctx))
+	defer func() {
+		require.NoError(sdb.Stop(ctx))
+	}()
+	ws, err := sdb.NewWorkingSet()
+	require.NoError(err)
+
+	name := CandName{1, 2, 3, 4}
+	vb, err := NewVoteBucket("testname", "io14s0vgnj0pjnazu4hsqlksdk7slah9vcfscn9ks", "2100000000", 21, time.Now(), true)
+	require.NoError(err)
+	require.NoError(stakingPutBucket(ws, name, vb))
+	expected, err := vb.Serialize()
+	require.NoError(err)
+	opts := []protocol.StateOption{protocol.NamespaceOption(factory.StakingNameSpace), protocol.KeyOption(bucketKey(name, 0))}
+	data, _, err := protocol.StateBytes(ws, opts...)
+	require.NoError(err)
+	require.Equal(expected, data)
+	_, _, err = protocol.StateBytes(ws, append(opts, protocol.ObjectOption())...)
+	require.Equal(protocol.ErrInvalidStateOption, errors.Cause(err))
+	// the bytes are read from the namespace they're put in
+	_, _, err = protocol.StateBytes(ws, protocol.KeyOption(bucketKey(name, 0)))
+	require.Equal(state.ErrStateNotExist, errors.Cause(err))
+
+	// the bytes put are read as the bucket, which reads the same bytes again
+	vb.StakedAmount = "100"
+	modified, err := vb.Serialize()
+	require.NoError(err)
+	_, err = protocol.PutStateBytes(ws, modified, opts...)
+	require.NoError(err)
+	vb1, err := stakingGetBucket(ws, name, 0)
+	require.NoError(err)
+	require.Equal("100", vb1.StakedAmount)
+	require.Equal(vb.Owner, vb1.Owner)
+	data, _, err = protocol.StateBytes(ws, opts...)
+	require.NoError(err)
+	require.Equal(modified, data)
+	require.NoError(ws.Finalize())
+	require.NoError(ws.Commit())
+	data, _, err = protocol.StateBytes(sdb, opts...)
+	require.NoError(err)
+	require.Equal(modified, data)
+}
@@ -7,12 +7,17 @@
 package candidatesutil
 
 import (
+	"fmt"
+	"math/big"
+
 	"go.uber.org/zap"
 
 	"github.com/pkg/errors"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
 	"github.com/iotexproject/iotex-core/action/protocol/vote"
 	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
@@ -37,6 +42,42 @@ const NxtKickoutKey = "NextKickoutKey."
 // UnproductiveDelegateKey is the key of unproductive Delegate struct
 const UnproductiveDelegateKey = "UnproductiveDelegateKey."
 
+// CurProductivityKey is the key of the block production counts of current epoch
+const CurProductivityKey = "CurrentProductivityKey."
+
+// PrevProductivityKey is the key of the block production counts of previous epoch
+const PrevProductivityKey = "PreviousProductivityKey."
+
+// CurDelegateFilterKey is the key of the allow and deny lists of the life long delegates in current epoch
+const CurDelegateFilterKey = "CurrentDelegateFilterKey."
+
+// NxtDelegateFilterKey is the key of the allow and deny lists of the life long delegates from next epoch
+const NxtDelegateFilterKey = "NextDelegateFilterKey."
+
+// CurLifeLongDelegatesKey is the key of the life long delegates in current epoch
+const CurLifeLongDelegatesKey = "CurrentLifeLongDelegatesKey."
+
+// NxtLifeLongDelegatesKey is the key of the life long delegates from next epoch
+const NxtLifeLongDelegatesKey = "NextLifeLongDelegatesKey."
+
+// DelegateUpdateProposalKey is the key of the update of the life long delegates pending the approvals of the delegates
+const DelegateUpdateProposalKey = "DelegateUpdateProposalKey."
+
+// DelegateStatsKey is the key of the reliability of the delegates aggregated over the epochs
+const DelegateStatsKey = "DelegateStatsKey."
+
+// EpochSnapshotKeyFormat is the format of the key of the active block producers of an epoch
+const EpochSnapshotKeyFormat = "poll/epoch-%d"
+
+// EpochCandidatesKeyFormat is the format of the key of the candidates of an epoch
+const EpochCandidatesKeyFormat = "poll/epoch-%d/candidates"
+
+// ProbationListPrefix is the prefix of the key of the kick-out list applied in an epoch
+const ProbationListPrefix = "ProbationList."
+
+// DoubleSignRecordPrefix is the prefix of the key of a handled double sign evidence
+const DoubleSignRecordPrefix = "DoubleSignRecord."
+
 // CandidatesByHeight returns array of Candidates in candidate pool of a given height (deprecated version)
 func CandidatesByHeight(sr protocol.StateReader, height uint64) ([]*state.Candidate, error) {
 	var candidates state.CandidateList
@@ -143,6 +184,168 @@ func UnproductiveDelegateFromDB(sr protocol.StateReader) (*vote.UnproductiveDele
 	return nil, err
 }
 
+// ProductivityFromDB returns the block production counts of current epoch, or of previous epoch if prev is true
+func ProductivityFromDB(sr protocol.StateReader, prev bool) (*vote.Productivity, uint64, error) {
+	productivity := &vote.Productivity{}
+	productivityKey := ConstructKey(CurProductivityKey)
+	if prev {
+		productivityKey = ConstructKey(PrevProductivityKey)
+	}
+	stateHeight, err := sr.State(
+		productivity,
+		protocol.KeyOption(productivityKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return productivity, stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrapf(err, "failed to get productivity with prev: %t", prev)
+}
+
+// ProbationListFromDB returns the probation list applied in the epoch
+func ProbationListFromDB(sr protocol.StateReader, epochNum uint64) (*vote.ProbationList, uint64, error) {
+	probationList := &vote.ProbationList{}
+	probationListKey := ConstructProbationListKey(epochNum)
+	stateHeight, err := sr.State(
+		probationList,
+		protocol.KeyOption(probationListKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return probationList, stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrapf(err, "failed to get probation list of epoch %d", epochNum)
+}
+
+// DelegateStatsFromDB returns the reliability of the delegates aggregated over the epochs
+func DelegateStatsFromDB(sr protocol.StateReader) (*vote.DelegateStats, uint64, error) {
+	stats := &vote.DelegateStats{}
+	statsKey := ConstructKey(DelegateStatsKey)
+	stateHeight, err := sr.State(
+		stats,
+		protocol.KeyOption(statsKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return stats, stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrap(err, "failed to get delegate stats")
+}
+
+// DoubleSignRecordFromDB returns the record of the handled double sign evidence
+func DoubleSignRecordFromDB(sr protocol.StateReader, evidenceHash hash.Hash256) (*vote.DoubleSignRecord, uint64, error) {
+	record := &vote.DoubleSignRecord{}
+	recordKey := ConstructDoubleSignRecordKey(evidenceHash)
+	stateHeight, err := sr.State(
+		record,
+		protocol.KeyOption(recordKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return record, stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrapf(err, "failed to get double sign record %x", evidenceHash)
+}
+
+// EpochSnapshotFromDB returns the active block producers persisted at the last block of the epoch
+func EpochSnapshotFromDB(sr protocol.StateReader, epochNum uint64) (state.CandidateList, uint64, error) {
+	var delegates state.CandidateList
+	snapshotKey := ConstructEpochSnapshotKey(epochNum)
+	stateHeight, err := sr.State(
+		&delegates,
+		protocol.KeyOption(snapshotKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return delegates, stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrapf(err, "failed to get delegates snapshot of epoch %d", epochNum)
+}
+
+// EpochCandidates is the candidates snapshot of an epoch. It is encoded as state.CandidateList with the raw votes of
+// the candidates on top, so that either encoding can be read as the other.
+type EpochCandidates state.CandidateList
+
+// Serialize serializes the candidates snapshot to bytes
+func (ec *EpochCandidates) Serialize() ([]byte, error) {
+	candidatesPb := make([]*pollpb.SnapshotCandidate, 0, len(*ec))
+	for _, cand := range *ec {
+		candPb := &pollpb.SnapshotCandidate{
+			Address:       cand.Address,
+			RewardAddress: cand.RewardAddress,
+		}
+		if cand.Votes != nil {
+			candPb.Votes = cand.Votes.Bytes()
+		}
+		if cand.RawVotes != nil {
+			candPb.RawVotes = cand.RawVotes.Bytes()
+		}
+		candidatesPb = append(candidatesPb, candPb)
+	}
+	return proto.Marshal(&pollpb.SnapshotCandidateList{Candidates: candidatesPb})
+}
+
+// Deserialize deserializes bytes to the candidates snapshot. The raw votes are nil if they weren't persisted.
+func (ec *EpochCandidates) Deserialize(buf []byte) error {
+	listPb := &pollpb.SnapshotCandidateList{}
+	if err := proto.Unmarshal(buf, listPb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal candidates snapshot")
+	}
+	candidates := make(EpochCandidates, 0, len(listPb.Candidates))
+	for _, candPb := range listPb.Candidates {
+		cand := &state.Candidate{
+			Address:       candPb.Address,
+			Votes:         new(big.Int).SetBytes(candPb.Votes),
+			RewardAddress: candPb.RewardAddress,
+		}
+		if len(candPb.RawVotes) != 0 {
+			cand.RawVotes = new(big.Int).SetBytes(candPb.RawVotes)
+		}
+		candidates = append(candidates, cand)
+	}
+	*ec = candidates
+	return nil
+}
+
+// EpochCandidatesFromDB returns the candidates persisted at the last block of the epoch
+func EpochCandidatesFromDB(sr protocol.StateReader, epochNum uint64) (state.CandidateList, uint64, error) {
+	var candidates EpochCandidates
+	candidatesKey := ConstructEpochCandidatesKey(epochNum)
+	stateHeight, err := sr.State(
+		&candidates,
+		protocol.KeyOption(candidatesKey[:]),
+		protocol.NamespaceOption(protocol.SystemNamespace),
+	)
+	if err == nil {
+		return state.CandidateList(candidates), stateHeight, nil
+	}
+	return nil, stateHeight, errors.Wrapf(err, "failed to get candidates snapshot of epoch %d", epochNum)
+}
+
+// ConstructEpochCandidatesKey constructs a key for the candidates of the epoch
+func ConstructEpochCandidatesKey(epochNum uint64) hash.Hash256 {
+	return ConstructKey(fmt.Sprintf(EpochCandidatesKeyFormat, epochNum))
+}
+
+// ConstructEpochSnapshotKey constructs a key for the active block producers of the epoch
+func ConstructEpochSnapshotKey(epochNum uint64) hash.Hash256 {
+	return ConstructKey(fmt.Sprintf(EpochSnapshotKeyFormat, epochNum))
+}
+
+// ConstructProbationListKey constructs a key for the kick-out list applied in the epoch
+func ConstructProbationListKey(epochNum uint64) hash.Hash256 {
+	k := []byte(ProbationListPrefix)
+	k = append(k, byteutil.Uint64ToBytes(epochNum)...)
+	return hash.Hash256b(k)
+}
+
+// ConstructDoubleSignRecordKey constructs a key for the record of a handled double sign evidence
+func ConstructDoubleSignRecordKey(evidenceHash hash.Hash256) hash.Hash256 {
+	k := []byte(DoubleSignRecordPrefix)
+	k = append(k, evidenceHash[:]...)
+	return hash.Hash256b(k)
+}
+
 // ConstructLegacyKey constructs a key for candidates storage (deprecated version)
 func ConstructLegacyKey(height uint64) hash.Hash160 {
 	heightInBytes := byteutil.Uint64ToBytes(height)
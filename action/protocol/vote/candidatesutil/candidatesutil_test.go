@@ -0,0 +1,59 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package candidatesutil
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestEpochCandidatesSerialize(t *testing.T) {
+	require := require.New(t)
+	candidates := state.CandidateList{
+		{
+			Address:       identityset.Address(1).String(),
+			Votes:         big.NewInt(150),
+			RewardAddress: identityset.Address(11).String(),
+			RawVotes:      big.NewInt(100),
+		},
+		{
+			Address:       identityset.Address(2).String(),
+			Votes:         big.NewInt(80),
+			RewardAddress: identityset.Address(12).String(),
+		},
+	}
+	ec := EpochCandidates(candidates)
+	sbytes, err := ec.Serialize()
+	require.NoError(err)
+	var ec2 EpochCandidates
+	require.NoError(ec2.Deserialize(sbytes))
+	require.Equal(ec, ec2)
+
+	// a snapshot is readable as a candidate list without the raw votes
+	var l state.CandidateList
+	require.NoError(l.Deserialize(sbytes))
+	require.Equal(2, len(l))
+	for i, c := range l {
+		require.Equal(candidates[i].Address, c.Address)
+		require.Equal(candidates[i].Votes, c.Votes)
+		require.Equal(candidates[i].RewardAddress, c.RewardAddress)
+		require.Nil(c.RawVotes)
+	}
+
+	// a snapshot persisted as a candidate list is read with unknown raw votes
+	sbytes, err = l.Serialize()
+	require.NoError(err)
+	require.NoError(ec2.Deserialize(sbytes))
+	require.Equal(EpochCandidates(l), ec2)
+	require.Nil(ec2[0].RawVotes)
+	require.Equal(big.NewInt(150), ec2[0].RawVotesOrVotes())
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	updpb "github.com/iotexproject/iotex-core/action/protocol/vote/unproductivedelegatepb"
+)
+
+// DelegateStat is the reliability of a delegate aggregated over the epochs
+type DelegateStat struct {
+	// ExpectedSlots is the total number of blocks expected from the delegate as an active block producer
+	ExpectedSlots uint64
+	// ProducedSlots is the total number of blocks produced by the delegate
+	ProducedSlots uint64
+	// ProbationEpochs is the number of epochs in which the delegate was on probation
+	ProbationEpochs uint64
+	// LastOffenseEpoch is the last epoch in which the delegate was unproductive, 0 means it never was
+	LastOffenseEpoch uint64
+	// LastSeenEpoch is the last epoch in which the delegate was an active block producer or on probation
+	LastSeenEpoch uint64
+}
+
+// DelegateStats is the reliability of the delegates aggregated up to an epoch, where key is the address of the
+// delegate
+type DelegateStats struct {
+	Stats map[string]*DelegateStat
+	Epoch uint64
+}
+
+// NewDelegateStats creates empty statistics
+func NewDelegateStats() *DelegateStats {
+	return &DelegateStats{
+		Stats: make(map[string]*DelegateStat),
+	}
+}
+
+// Add aggregates the epoch into the statistics, with the number of blocks expected from each active block producer,
+// the blocks produced by them, the probation list applied in the epoch and the unproductive delegates of the epoch.
+// The statistics of a delegate not seen for more than retention epochs are dropped, 0 retention keeps all of them. An
+// epoch which is already aggregated is skipped.
+func (ds *DelegateStats) Add(
+	epochNum uint64,
+	expected uint64,
+	produced map[string]uint64,
+	probationList *ProbationList,
+	unproductive []string,
+	retention uint64,
+) {
+	if epochNum <= ds.Epoch {
+		return
+	}
+	ds.Epoch = epochNum
+	for addr, count := range produced {
+		stat := ds.stat(addr)
+		stat.ExpectedSlots += expected
+		stat.ProducedSlots += count
+		stat.LastSeenEpoch = epochNum
+	}
+	if probationList != nil {
+		for addr := range probationList.ProbationInfo {
+			stat := ds.stat(addr)
+			stat.ProbationEpochs++
+			stat.LastSeenEpoch = epochNum
+		}
+	}
+	for _, addr := range unproductive {
+		stat := ds.stat(addr)
+		stat.LastOffenseEpoch = epochNum
+		stat.LastSeenEpoch = epochNum
+	}
+	if retention == 0 {
+		return
+	}
+	for addr, stat := range ds.Stats {
+		if stat.LastSeenEpoch+retention < epochNum {
+			delete(ds.Stats, addr)
+		}
+	}
+}
+
+func (ds *DelegateStats) stat(addr string) *DelegateStat {
+	stat, ok := ds.Stats[addr]
+	if !ok {
+		stat = &DelegateStat{}
+		ds.Stats[addr] = stat
+	}
+	return stat
+}
+
+// Addresses returns the addresses of the delegates in ascending order
+func (ds *DelegateStats) Addresses() []string {
+	addrs := make([]string, 0, len(ds.Stats))
+	for addr := range ds.Stats {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Serialize serializes the statistics to bytes
+func (ds *DelegateStats) Serialize() ([]byte, error) {
+	return proto.Marshal(ds.Proto())
+}
+
+// Proto converts the statistics to a protobuf message, where the delegates are sorted by address
+func (ds *DelegateStats) Proto() *updpb.DelegateStats {
+	addrs := ds.Addresses()
+	stats := make([]*updpb.DelegateStat, 0, len(addrs))
+	for _, addr := range addrs {
+		stat := ds.Stats[addr]
+		stats = append(stats, &updpb.DelegateStat{
+			Address:          addr,
+			ExpectedSlots:    stat.ExpectedSlots,
+			ProducedSlots:    stat.ProducedSlots,
+			ProbationEpochs:  stat.ProbationEpochs,
+			LastOffenseEpoch: stat.LastOffenseEpoch,
+			LastSeenEpoch:    stat.LastSeenEpoch,
+		})
+	}
+	return &updpb.DelegateStats{
+		Epoch: ds.Epoch,
+		Stats: stats,
+	}
+}
+
+// Deserialize deserializes bytes to the statistics
+func (ds *DelegateStats) Deserialize(buf []byte) error {
+	dspb := &updpb.DelegateStats{}
+	if err := proto.Unmarshal(buf, dspb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal delegate stats")
+	}
+	return ds.LoadProto(dspb)
+}
+
+// LoadProto loads the statistics from proto
+func (ds *DelegateStats) LoadProto(dspb *updpb.DelegateStats) error {
+	stats := make(map[string]*DelegateStat, len(dspb.Stats))
+	for _, s := range dspb.Stats {
+		if _, ok := stats[s.Address]; ok {
+			return errors.Errorf("duplicate delegate %s in delegate stats", s.Address)
+		}
+		stats[s.Address] = &DelegateStat{
+			ExpectedSlots:    s.ExpectedSlots,
+			ProducedSlots:    s.ProducedSlots,
+			ProbationEpochs:  s.ProbationEpochs,
+			LastOffenseEpoch: s.LastOffenseEpoch,
+			LastSeenEpoch:    s.LastSeenEpoch,
+		}
+	}
+	ds.Stats = stats
+	ds.Epoch = dspb.Epoch
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegateStatsAdd(t *testing.T) {
+	r := require.New(t)
+	const (
+		expected  = 10
+		threshold = 50
+		retention = 3
+	)
+	// a is always productive, b misses all its slots in epochs 3 and 4, c misses most of its slots in the even
+	// epochs, and d is only active in the first 2 epochs
+	produce := func(epochNum uint64) map[string]uint64 {
+		produced := map[string]uint64{"a": 10, "b": 10, "c": 10}
+		if epochNum == 3 || epochNum == 4 {
+			produced["b"] = 0
+		}
+		if epochNum%2 == 0 {
+			produced["c"] = 4
+		}
+		if epochNum <= 2 {
+			produced["d"] = 10
+		}
+		return produced
+	}
+
+	ds := NewDelegateStats()
+	pl := NewProbationList(1, 50)
+	for epochNum := uint64(1); epochNum <= 10; epochNum++ {
+		produced := produce(epochNum)
+		unproductive := []string{}
+		for addr, count := range produced {
+			if count*100/expected < threshold {
+				unproductive = append(unproductive, addr)
+			}
+		}
+		ds.Add(epochNum, expected, produced, pl, unproductive, retention)
+		if epochNum == 5 {
+			r.Contains(ds.Stats, "d")
+		}
+		pl = pl.Merge(epochNum+1, unproductive, ProbationRule{})
+	}
+	r.Equal(uint64(10), ds.Epoch)
+	r.Equal([]string{"a", "b", "c"}, ds.Addresses())
+	r.Equal(&DelegateStat{
+		ExpectedSlots: 100,
+		ProducedSlots: 100,
+		LastSeenEpoch: 10,
+	}, ds.Stats["a"])
+	// b is on probation in epochs 4 to 6
+	r.Equal(&DelegateStat{
+		ExpectedSlots:    100,
+		ProducedSlots:    80,
+		ProbationEpochs:  3,
+		LastOffenseEpoch: 4,
+		LastSeenEpoch:    10,
+	}, ds.Stats["b"])
+	// c is on probation in the odd epochs after its offenses
+	r.Equal(&DelegateStat{
+		ExpectedSlots:    100,
+		ProducedSlots:    70,
+		ProbationEpochs:  4,
+		LastOffenseEpoch: 10,
+		LastSeenEpoch:    10,
+	}, ds.Stats["c"])
+
+	// an aggregated epoch is skipped
+	ds.Add(10, expected, produce(10), pl, nil, retention)
+	r.Equal(uint64(100), ds.Stats["a"].ExpectedSlots)
+
+	// 0 retention keeps the inactive delegates
+	all := NewDelegateStats()
+	all.Add(1, expected, map[string]uint64{"d": 10}, nil, nil, 0)
+	all.Add(100, expected, map[string]uint64{"a": 10}, nil, nil, 0)
+	r.Equal([]string{"a", "d"}, all.Addresses())
+}
+
+func TestDelegateStatsSerializeAndDeserialize(t *testing.T) {
+	r := require.New(t)
+	ds := NewDelegateStats()
+	ds.Add(3, 10, map[string]uint64{"b": 2, "a": 10}, NewProbationList(3, 50), []string{"b"}, 0)
+	sbytes, err := ds.Serialize()
+	r.NoError(err)
+	ds2 := &DelegateStats{}
+	r.NoError(ds2.Deserialize(sbytes))
+	r.Equal(ds, ds2)
+	// the delegates are serialized in the order of address
+	pb := ds.Proto()
+	r.Equal("a", pb.Stats[0].Address)
+	r.Equal("b", pb.Stats[1].Address)
+
+	r.Error(ds2.Deserialize([]byte{0x12, 0x05}))
+	pb.Stats[1].Address = "a"
+	r.Error(ds2.LoadProto(pb))
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	updpb "github.com/iotexproject/iotex-core/action/protocol/vote/unproductivedelegatepb"
+)
+
+// DoubleSignRecord records a double sign evidence which has been handled, so that the same evidence isn't accepted
+// twice
+type DoubleSignRecord struct {
+	// Offender is the address of the block producer which signed both blocks
+	Offender string
+	// Height is the height of both blocks
+	Height uint64
+}
+
+// Serialize serializes the double sign record to bytes
+func (r *DoubleSignRecord) Serialize() ([]byte, error) {
+	return proto.Marshal(&updpb.DoubleSignRecord{
+		Offender: r.Offender,
+		Height:   r.Height,
+	})
+}
+
+// Deserialize deserializes bytes to the double sign record
+func (r *DoubleSignRecord) Deserialize(buf []byte) error {
+	rpb := &updpb.DoubleSignRecord{}
+	if err := proto.Unmarshal(buf, rpb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal double sign record")
+	}
+	r.Offender = rpb.Offender
+	r.Height = rpb.Height
+	return nil
+}
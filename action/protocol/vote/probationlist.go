@@ -0,0 +1,173 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	updpb "github.com/iotexproject/iotex-core/action/protocol/vote/unproductivedelegatepb"
+)
+
+// ProbationRule defines how the probation escalates for the delegates which are unproductive repeatedly
+type ProbationRule struct {
+	// IntensityStep is the intensity rate in percentage further cut from the voting power for each repeated offense
+	IntensityStep uint32
+	// MaxCount is the highest offense count of a delegate, 0 means there is no limit
+	MaxCount uint32
+	// Consecutive makes the intensity escalate with the consecutive offending epochs of a delegate rather than its
+	// offense count, so that a productive epoch brings it back to the intensity rate of the first offense
+	Consecutive bool
+	// IntensityCap is the highest intensity rate in percentage cut by the escalation, 0 means there is no limit
+	IntensityCap uint32
+}
+
+// ProbationList defines the delegates on probation in an epoch, where key is the address of the delegate and value
+// is the number of its offenses
+type ProbationList struct {
+	ProbationInfo map[string]uint32
+	// Streaks is the number of consecutive epochs in which the delegate on probation was unproductive, up to the
+	// last epoch. It is only tracked under the consecutive rule
+	Streaks map[string]uint32
+	// IntensityRate is the percentage of the voting power kept by a delegate on probation for its first offense
+	IntensityRate uint32
+	EpochNum      uint64
+}
+
+// NewProbationList creates an empty probation list of the epoch
+func NewProbationList(epochNum uint64, intensityRate uint32) *ProbationList {
+	return &ProbationList{
+		ProbationInfo: make(map[string]uint32),
+		Streaks:       make(map[string]uint32),
+		IntensityRate: intensityRate,
+		EpochNum:      epochNum,
+	}
+}
+
+// Merge returns the probation list of the given epoch, by adding the unproductive delegates of the last epoch to the
+// list. The count of an unproductive delegate increases by one up to the max count of the rule, and the count of a
+// delegate on probation which was productive decreases by one, so that a repeat offender stays on probation longer.
+// The delegate is released when its count reaches 0. Under the consecutive rule, the streak of an unproductive delegate
+// increases by one as well, and the streak of a productive delegate is reset.
+func (pl *ProbationList) Merge(epochNum uint64, unproductive []string, rule ProbationRule) *ProbationList {
+	merged := NewProbationList(epochNum, pl.IntensityRate)
+	offenders := make(map[string]bool, len(unproductive))
+	for _, addr := range unproductive {
+		offenders[addr] = true
+	}
+	for addr, count := range pl.ProbationInfo {
+		if !offenders[addr] && count > 1 {
+			merged.ProbationInfo[addr] = count - 1
+		}
+	}
+	for addr := range offenders {
+		count := pl.ProbationInfo[addr] + 1
+		if rule.MaxCount != 0 && count > rule.MaxCount {
+			count = rule.MaxCount
+		}
+		merged.ProbationInfo[addr] = count
+		if rule.Consecutive {
+			merged.Streaks[addr] = pl.Streaks[addr] + 1
+		}
+	}
+	return merged
+}
+
+// IntensityRateOf returns the percentage of the voting power kept by the delegate. A delegate which is not on
+// probation keeps all of it, and each repeated offense cuts the intensity step of the rule further, up to the
+// intensity cap of the rule. Under the consecutive rule, only the offenses in a row count.
+func (pl *ProbationList) IntensityRateOf(addr string, rule ProbationRule) uint32 {
+	count, ok := pl.ProbationInfo[addr]
+	if !ok || count == 0 {
+		return 100
+	}
+	if rule.Consecutive {
+		count = pl.Streaks[addr]
+		if count == 0 {
+			// the delegate is still on probation for its former offenses
+			count = 1
+		}
+	}
+	cut := uint64(count-1) * uint64(rule.IntensityStep)
+	if rule.IntensityCap != 0 && cut > uint64(rule.IntensityCap) {
+		cut = uint64(rule.IntensityCap)
+	}
+	if cut >= uint64(pl.IntensityRate) {
+		return 0
+	}
+	return pl.IntensityRate - uint32(cut)
+}
+
+// Blacklist converts the probation list to a kick-out list with the intensity rate of the first offense
+func (pl *ProbationList) Blacklist() *Blacklist {
+	blacklistInfos := make(map[string]uint32, len(pl.ProbationInfo))
+	for addr, count := range pl.ProbationInfo {
+		blacklistInfos[addr] = count
+	}
+	return &Blacklist{
+		BlacklistInfos: blacklistInfos,
+		IntensityRate:  float64(pl.IntensityRate) / float64(100),
+	}
+}
+
+// Serialize serializes the probation list to bytes
+func (pl *ProbationList) Serialize() ([]byte, error) {
+	return proto.Marshal(pl.Proto())
+}
+
+// Proto converts the probation list to a protobuf message, where the delegates are sorted by address
+func (pl *ProbationList) Proto() *updpb.ProbationList {
+	addrs := make([]string, 0, len(pl.ProbationInfo))
+	for addr := range pl.ProbationInfo {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	probationInfo := make([]*updpb.ProbationInfo, 0, len(addrs))
+	for _, addr := range addrs {
+		probationInfo = append(probationInfo, &updpb.ProbationInfo{
+			Address: addr,
+			Count:   pl.ProbationInfo[addr],
+			Streak:  pl.Streaks[addr],
+		})
+	}
+	return &updpb.ProbationList{
+		EpochNum:      pl.EpochNum,
+		IntensityRate: pl.IntensityRate,
+		ProbationInfo: probationInfo,
+	}
+}
+
+// Deserialize deserializes bytes to the probation list
+func (pl *ProbationList) Deserialize(buf []byte) error {
+	plpb := &updpb.ProbationList{}
+	if err := proto.Unmarshal(buf, plpb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal probation list")
+	}
+	return pl.LoadProto(plpb)
+}
+
+// LoadProto loads the probation list from proto
+func (pl *ProbationList) LoadProto(plpb *updpb.ProbationList) error {
+	probationInfo := make(map[string]uint32, len(plpb.ProbationInfo))
+	streaks := make(map[string]uint32)
+	for _, info := range plpb.ProbationInfo {
+		if _, ok := probationInfo[info.Address]; ok {
+			return errors.Errorf("duplicate delegate %s in probation list", info.Address)
+		}
+		probationInfo[info.Address] = info.Count
+		if info.Streak != 0 {
+			streaks[info.Address] = info.Streak
+		}
+	}
+	pl.ProbationInfo = probationInfo
+	pl.Streaks = streaks
+	pl.IntensityRate = plpb.IntensityRate
+	pl.EpochNum = plpb.EpochNum
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbationListSerializeAndDeserialize(t *testing.T) {
+	r := require.New(t)
+
+	pl := NewProbationList(1, 10)
+	sbytes, err := pl.Serialize()
+	r.NoError(err)
+	r.Equal("0801100a", hex.EncodeToString(sbytes))
+
+	// the delegates are serialized in the order of address regardless of the map order
+	pl = NewProbationList(3, 10)
+	pl.ProbationInfo["b"] = 2
+	pl.ProbationInfo["a"] = 1
+	for i := 0; i < 10; i++ {
+		sbytes, err = pl.Serialize()
+		r.NoError(err)
+		r.Equal("0803100a1a050a016110011a050a01621002", hex.EncodeToString(sbytes))
+	}
+
+	pl2 := &ProbationList{}
+	r.NoError(pl2.Deserialize(sbytes))
+	r.Equal(pl, pl2)
+
+	r.Error(pl2.Deserialize([]byte{0x1a, 0x05}))
+	// duplicate delegate
+	dup, err := hex.DecodeString("0803100a1a050a016110011a050a01611002")
+	r.NoError(err)
+	r.Error(pl2.Deserialize(dup))
+}
+
+func TestProbationListMerge(t *testing.T) {
+	r := require.New(t)
+	rule := ProbationRule{IntensityStep: 20}
+
+	pl := NewProbationList(1, 50)
+	r.Equal(uint32(100), pl.IntensityRateOf("a", rule))
+
+	// a is unproductive in 3 consecutive epochs, and b only in the first one
+	pl = pl.Merge(2, []string{"a", "b"}, rule)
+	r.Equal(uint64(2), pl.EpochNum)
+	r.Equal(uint32(50), pl.IntensityRate)
+	r.Equal(map[string]uint32{"a": 1, "b": 1}, pl.ProbationInfo)
+	r.Equal(uint32(50), pl.IntensityRateOf("a", rule))
+	pl = pl.Merge(3, []string{"a"}, rule)
+	r.Equal(map[string]uint32{"a": 2}, pl.ProbationInfo)
+	r.Equal(uint32(30), pl.IntensityRateOf("a", rule))
+	r.Equal(uint32(100), pl.IntensityRateOf("b", rule))
+	pl = pl.Merge(4, []string{"a"}, rule)
+	r.Equal(map[string]uint32{"a": 3}, pl.ProbationInfo)
+	r.Equal(uint32(10), pl.IntensityRateOf("a", rule))
+	// the intensity doesn't go below 0
+	r.Equal(uint32(0), pl.Merge(5, []string{"a"}, rule).IntensityRateOf("a", rule))
+
+	// the repeat offender is on probation for as many epochs as its offenses
+	for _, count := range []uint32{2, 1} {
+		pl = pl.Merge(pl.EpochNum+1, nil, rule)
+		r.Equal(map[string]uint32{"a": count}, pl.ProbationInfo)
+	}
+	pl = pl.Merge(pl.EpochNum+1, nil, rule)
+	r.Equal(0, len(pl.ProbationInfo))
+	r.Equal(uint64(7), pl.EpochNum)
+
+	// the count is capped by the max count
+	rule.MaxCount = 2
+	pl = NewProbationList(1, 50)
+	for epochNum := uint64(2); epochNum <= 4; epochNum++ {
+		pl = pl.Merge(epochNum, []string{"a"}, rule)
+	}
+	r.Equal(map[string]uint32{"a": 2}, pl.ProbationInfo)
+
+	// merging doesn't change the previous list
+	next := pl.Merge(5, nil, rule)
+	r.Equal(map[string]uint32{"a": 2}, pl.ProbationInfo)
+	r.Equal(map[string]uint32{"a": 1}, next.ProbationInfo)
+
+	bl := next.Blacklist()
+	r.Equal(map[string]uint32{"a": 1}, bl.BlacklistInfos)
+	r.Equal(0.5, bl.IntensityRate)
+}
+
+func TestProbationListConsecutive(t *testing.T) {
+	r := require.New(t)
+	rule := ProbationRule{IntensityStep: 20, Consecutive: true, IntensityCap: 30}
+
+	// a offends, offends, is productive, and offends again
+	pl := NewProbationList(1, 50)
+	tests := []struct {
+		unproductive []string
+		count        uint32
+		streak       uint32
+		rate         uint32
+	}{
+		{[]string{"a"}, 1, 1, 50},
+		{[]string{"a"}, 2, 2, 30},
+		// the clean epoch resets the escalation, while a is still on probation
+		{nil, 1, 0, 50},
+		{[]string{"a"}, 2, 1, 50},
+		{[]string{"a"}, 3, 2, 30},
+		// the escalation is capped
+		{[]string{"a"}, 4, 3, 20},
+		{[]string{"a"}, 5, 4, 20},
+	}
+	for _, test := range tests {
+		pl = pl.Merge(pl.EpochNum+1, test.unproductive, rule)
+		r.Equal(test.count, pl.ProbationInfo["a"])
+		r.Equal(test.streak, pl.Streaks["a"])
+		r.Equal(test.rate, pl.IntensityRateOf("a", rule))
+	}
+	// the offense count decides the intensity without the consecutive rule
+	r.Equal(uint32(0), pl.IntensityRateOf("a", ProbationRule{IntensityStep: 20}))
+
+	// the streaks are serialized along with the counts
+	sbytes, err := pl.Serialize()
+	r.NoError(err)
+	pl2 := &ProbationList{}
+	r.NoError(pl2.Deserialize(sbytes))
+	r.Equal(pl, pl2)
+
+	// the streaks aren't tracked without the consecutive rule
+	pl = NewProbationList(1, 50).Merge(2, []string{"a"}, ProbationRule{IntensityStep: 20})
+	r.Equal(0, len(pl.Streaks))
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	updpb "github.com/iotexproject/iotex-core/action/protocol/vote/unproductivedelegatepb"
+)
+
+// Productivity counts the blocks produced by each active block producer in an epoch
+type Productivity struct {
+	epoch  uint64
+	counts map[string]uint64
+}
+
+// NewProductivity creates a new Productivity of the epoch, with zero produced block for each of the active producers
+func NewProductivity(epoch uint64, producers []string) *Productivity {
+	counts := make(map[string]uint64, len(producers))
+	for _, addr := range producers {
+		counts[addr] = 0
+	}
+	return &Productivity{
+		epoch:  epoch,
+		counts: counts,
+	}
+}
+
+// Epoch returns the epoch number which the counts belong to
+func (pd *Productivity) Epoch() uint64 {
+	return pd.epoch
+}
+
+// Add counts a block produced by the producer
+func (pd *Productivity) Add(producer string) {
+	pd.counts[producer]++
+}
+
+// Count returns the number of blocks produced by the producer
+func (pd *Productivity) Count(producer string) uint64 {
+	return pd.counts[producer]
+}
+
+// Unproductive returns the producers, in ascending order of address, whose productivity is lower than the threshold.
+// The productivity is the percentage of the produced blocks over the expected ones, which is the total number of
+// blocks evenly divided by the producers.
+func (pd *Productivity) Unproductive(threshold uint64) []string {
+	var total uint64
+	for _, count := range pd.counts {
+		total += count
+	}
+	unqualified := make([]string, 0)
+	if len(pd.counts) == 0 {
+		return unqualified
+	}
+	expected := total / uint64(len(pd.counts))
+	if expected == 0 {
+		return unqualified
+	}
+	for addr, count := range pd.counts {
+		if count*100/expected < threshold {
+			unqualified = append(unqualified, addr)
+		}
+	}
+	sort.Strings(unqualified)
+	return unqualified
+}
+
+// Serialize serializes Productivity struct to bytes
+func (pd *Productivity) Serialize() ([]byte, error) {
+	return proto.Marshal(pd.Proto())
+}
+
+// Proto converts the Productivity struct to a protobuf message, the counts are sorted by address
+func (pd *Productivity) Proto() *updpb.Productivity {
+	addrs := make([]string, 0, len(pd.counts))
+	for addr := range pd.counts {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	counts := make([]*updpb.ProducerCount, 0, len(addrs))
+	for _, addr := range addrs {
+		counts = append(counts, &updpb.ProducerCount{
+			Address: addr,
+			Count:   pd.counts[addr],
+		})
+	}
+	return &updpb.Productivity{
+		Epoch:  pd.epoch,
+		Counts: counts,
+	}
+}
+
+// Deserialize deserializes bytes to Productivity struct
+func (pd *Productivity) Deserialize(buf []byte) error {
+	productivityPb := &updpb.Productivity{}
+	if err := proto.Unmarshal(buf, productivityPb); err != nil {
+		return errors.Wrap(err, "failed to unmarshal productivity")
+	}
+	return pd.LoadProto(productivityPb)
+}
+
+// LoadProto converts protobuf message to Productivity struct
+func (pd *Productivity) LoadProto(productivityPb *updpb.Productivity) error {
+	counts := make(map[string]uint64, len(productivityPb.Counts))
+	for _, c := range productivityPb.Counts {
+		if _, ok := counts[c.Address]; ok {
+			return errors.Errorf("duplicate producer %s", c.Address)
+		}
+		counts[c.Address] = c.Count
+	}
+	pd.epoch = productivityPb.Epoch
+	pd.counts = counts
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductivity(t *testing.T) {
+	r := require.New(t)
+	pd := NewProductivity(3, []string{"a", "b", "c", "d"})
+	r.Equal(uint64(3), pd.Epoch())
+	r.Empty(pd.Unproductive(85))
+
+	for i := 0; i < 10; i++ {
+		pd.Add("a")
+		pd.Add("b")
+		pd.Add("c")
+	}
+	pd.Add("d")
+	// expected number of blocks is 31 / 4 = 7
+	r.Equal(uint64(10), pd.Count("a"))
+	r.Equal(uint64(1), pd.Count("d"))
+	r.Equal([]string{"d"}, pd.Unproductive(85))
+	r.Equal([]string{"d"}, pd.Unproductive(15))
+	r.Empty(pd.Unproductive(14))
+
+	// a producer not seeded is counted as well
+	pd.Add("e")
+	r.Equal([]string{"d", "e"}, pd.Unproductive(85))
+
+	data, err := pd.Serialize()
+	r.NoError(err)
+	pd2 := &Productivity{}
+	r.NoError(pd2.Deserialize(data))
+	r.Equal(pd, pd2)
+	data2, err := pd2.Serialize()
+	r.NoError(err)
+	r.Equal(data, data2)
+}
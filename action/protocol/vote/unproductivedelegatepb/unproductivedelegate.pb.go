@@ -1,5 +1,5 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
-// source: action/protocol/vote/unproductivedelegatepb/unproductivedelegate.proto
+// source: unproductivedelegate.proto
 
 package unproductivedelegatepb
 
@@ -33,7 +33,7 @@ func (m *UnproductiveDelegate) Reset()         { *m = UnproductiveDelegate{} }
 func (m *UnproductiveDelegate) String() string { return proto.CompactTextString(m) }
 func (*UnproductiveDelegate) ProtoMessage()    {}
 func (*UnproductiveDelegate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef4b0fa66012b010, []int{0}
+	return fileDescriptor_11e6919dc08d7343, []int{0}
 }
 
 func (m *UnproductiveDelegate) XXX_Unmarshal(b []byte) error {
@@ -86,7 +86,7 @@ func (m *Delegatelist) Reset()         { *m = Delegatelist{} }
 func (m *Delegatelist) String() string { return proto.CompactTextString(m) }
 func (*Delegatelist) ProtoMessage()    {}
 func (*Delegatelist) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef4b0fa66012b010, []int{1}
+	return fileDescriptor_11e6919dc08d7343, []int{1}
 }
 
 func (m *Delegatelist) XXX_Unmarshal(b []byte) error {
@@ -114,27 +114,427 @@ func (m *Delegatelist) GetDelegates() []string {
 	return nil
 }
 
+type Productivity struct {
+	Epoch                uint64           `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Counts               []*ProducerCount `protobuf:"bytes,2,rep,name=counts,proto3" json:"counts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Productivity) Reset()         { *m = Productivity{} }
+func (m *Productivity) String() string { return proto.CompactTextString(m) }
+func (*Productivity) ProtoMessage()    {}
+func (*Productivity) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{2}
+}
+
+func (m *Productivity) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Productivity.Unmarshal(m, b)
+}
+func (m *Productivity) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Productivity.Marshal(b, m, deterministic)
+}
+func (m *Productivity) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Productivity.Merge(m, src)
+}
+func (m *Productivity) XXX_Size() int {
+	return xxx_messageInfo_Productivity.Size(m)
+}
+func (m *Productivity) XXX_DiscardUnknown() {
+	xxx_messageInfo_Productivity.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Productivity proto.InternalMessageInfo
+
+func (m *Productivity) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Productivity) GetCounts() []*ProducerCount {
+	if m != nil {
+		return m.Counts
+	}
+	return nil
+}
+
+type ProducerCount struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Count                uint64   `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProducerCount) Reset()         { *m = ProducerCount{} }
+func (m *ProducerCount) String() string { return proto.CompactTextString(m) }
+func (*ProducerCount) ProtoMessage()    {}
+func (*ProducerCount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{3}
+}
+
+func (m *ProducerCount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProducerCount.Unmarshal(m, b)
+}
+func (m *ProducerCount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProducerCount.Marshal(b, m, deterministic)
+}
+func (m *ProducerCount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProducerCount.Merge(m, src)
+}
+func (m *ProducerCount) XXX_Size() int {
+	return xxx_messageInfo_ProducerCount.Size(m)
+}
+func (m *ProducerCount) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProducerCount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProducerCount proto.InternalMessageInfo
+
+func (m *ProducerCount) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *ProducerCount) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type ProbationList struct {
+	EpochNum             uint64           `protobuf:"varint,1,opt,name=epochNum,proto3" json:"epochNum,omitempty"`
+	IntensityRate        uint32           `protobuf:"varint,2,opt,name=intensityRate,proto3" json:"intensityRate,omitempty"`
+	ProbationInfo        []*ProbationInfo `protobuf:"bytes,3,rep,name=probationInfo,proto3" json:"probationInfo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ProbationList) Reset()         { *m = ProbationList{} }
+func (m *ProbationList) String() string { return proto.CompactTextString(m) }
+func (*ProbationList) ProtoMessage()    {}
+func (*ProbationList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{4}
+}
+
+func (m *ProbationList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProbationList.Unmarshal(m, b)
+}
+func (m *ProbationList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProbationList.Marshal(b, m, deterministic)
+}
+func (m *ProbationList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProbationList.Merge(m, src)
+}
+func (m *ProbationList) XXX_Size() int {
+	return xxx_messageInfo_ProbationList.Size(m)
+}
+func (m *ProbationList) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProbationList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProbationList proto.InternalMessageInfo
+
+func (m *ProbationList) GetEpochNum() uint64 {
+	if m != nil {
+		return m.EpochNum
+	}
+	return 0
+}
+
+func (m *ProbationList) GetIntensityRate() uint32 {
+	if m != nil {
+		return m.IntensityRate
+	}
+	return 0
+}
+
+func (m *ProbationList) GetProbationInfo() []*ProbationInfo {
+	if m != nil {
+		return m.ProbationInfo
+	}
+	return nil
+}
+
+type ProbationInfo struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Count                uint32   `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Streak               uint32   `protobuf:"varint,3,opt,name=streak,proto3" json:"streak,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProbationInfo) Reset()         { *m = ProbationInfo{} }
+func (m *ProbationInfo) String() string { return proto.CompactTextString(m) }
+func (*ProbationInfo) ProtoMessage()    {}
+func (*ProbationInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{5}
+}
+
+func (m *ProbationInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProbationInfo.Unmarshal(m, b)
+}
+func (m *ProbationInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProbationInfo.Marshal(b, m, deterministic)
+}
+func (m *ProbationInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProbationInfo.Merge(m, src)
+}
+func (m *ProbationInfo) XXX_Size() int {
+	return xxx_messageInfo_ProbationInfo.Size(m)
+}
+func (m *ProbationInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProbationInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProbationInfo proto.InternalMessageInfo
+
+func (m *ProbationInfo) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *ProbationInfo) GetCount() uint32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *ProbationInfo) GetStreak() uint32 {
+	if m != nil {
+		return m.Streak
+	}
+	return 0
+}
+
+type DoubleSignRecord struct {
+	Offender             string   `protobuf:"bytes,1,opt,name=offender,proto3" json:"offender,omitempty"`
+	Height               uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DoubleSignRecord) Reset()         { *m = DoubleSignRecord{} }
+func (m *DoubleSignRecord) String() string { return proto.CompactTextString(m) }
+func (*DoubleSignRecord) ProtoMessage()    {}
+func (*DoubleSignRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{6}
+}
+
+func (m *DoubleSignRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DoubleSignRecord.Unmarshal(m, b)
+}
+func (m *DoubleSignRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DoubleSignRecord.Marshal(b, m, deterministic)
+}
+func (m *DoubleSignRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DoubleSignRecord.Merge(m, src)
+}
+func (m *DoubleSignRecord) XXX_Size() int {
+	return xxx_messageInfo_DoubleSignRecord.Size(m)
+}
+func (m *DoubleSignRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_DoubleSignRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DoubleSignRecord proto.InternalMessageInfo
+
+func (m *DoubleSignRecord) GetOffender() string {
+	if m != nil {
+		return m.Offender
+	}
+	return ""
+}
+
+func (m *DoubleSignRecord) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type DelegateStats struct {
+	Epoch                uint64          `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Stats                []*DelegateStat `protobuf:"bytes,2,rep,name=stats,proto3" json:"stats,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *DelegateStats) Reset()         { *m = DelegateStats{} }
+func (m *DelegateStats) String() string { return proto.CompactTextString(m) }
+func (*DelegateStats) ProtoMessage()    {}
+func (*DelegateStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{7}
+}
+
+func (m *DelegateStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DelegateStats.Unmarshal(m, b)
+}
+func (m *DelegateStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DelegateStats.Marshal(b, m, deterministic)
+}
+func (m *DelegateStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DelegateStats.Merge(m, src)
+}
+func (m *DelegateStats) XXX_Size() int {
+	return xxx_messageInfo_DelegateStats.Size(m)
+}
+func (m *DelegateStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_DelegateStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DelegateStats proto.InternalMessageInfo
+
+func (m *DelegateStats) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *DelegateStats) GetStats() []*DelegateStat {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+type DelegateStat struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	ExpectedSlots        uint64   `protobuf:"varint,2,opt,name=expectedSlots,proto3" json:"expectedSlots,omitempty"`
+	ProducedSlots        uint64   `protobuf:"varint,3,opt,name=producedSlots,proto3" json:"producedSlots,omitempty"`
+	ProbationEpochs      uint64   `protobuf:"varint,4,opt,name=probationEpochs,proto3" json:"probationEpochs,omitempty"`
+	LastOffenseEpoch     uint64   `protobuf:"varint,5,opt,name=lastOffenseEpoch,proto3" json:"lastOffenseEpoch,omitempty"`
+	LastSeenEpoch        uint64   `protobuf:"varint,6,opt,name=lastSeenEpoch,proto3" json:"lastSeenEpoch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DelegateStat) Reset()         { *m = DelegateStat{} }
+func (m *DelegateStat) String() string { return proto.CompactTextString(m) }
+func (*DelegateStat) ProtoMessage()    {}
+func (*DelegateStat) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11e6919dc08d7343, []int{8}
+}
+
+func (m *DelegateStat) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DelegateStat.Unmarshal(m, b)
+}
+func (m *DelegateStat) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DelegateStat.Marshal(b, m, deterministic)
+}
+func (m *DelegateStat) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DelegateStat.Merge(m, src)
+}
+func (m *DelegateStat) XXX_Size() int {
+	return xxx_messageInfo_DelegateStat.Size(m)
+}
+func (m *DelegateStat) XXX_DiscardUnknown() {
+	xxx_messageInfo_DelegateStat.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DelegateStat proto.InternalMessageInfo
+
+func (m *DelegateStat) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *DelegateStat) GetExpectedSlots() uint64 {
+	if m != nil {
+		return m.ExpectedSlots
+	}
+	return 0
+}
+
+func (m *DelegateStat) GetProducedSlots() uint64 {
+	if m != nil {
+		return m.ProducedSlots
+	}
+	return 0
+}
+
+func (m *DelegateStat) GetProbationEpochs() uint64 {
+	if m != nil {
+		return m.ProbationEpochs
+	}
+	return 0
+}
+
+func (m *DelegateStat) GetLastOffenseEpoch() uint64 {
+	if m != nil {
+		return m.LastOffenseEpoch
+	}
+	return 0
+}
+
+func (m *DelegateStat) GetLastSeenEpoch() uint64 {
+	if m != nil {
+		return m.LastSeenEpoch
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*UnproductiveDelegate)(nil), "unproductivedelegatepb.unproductiveDelegate")
 	proto.RegisterType((*Delegatelist)(nil), "unproductivedelegatepb.delegatelist")
+	proto.RegisterType((*Productivity)(nil), "unproductivedelegatepb.productivity")
+	proto.RegisterType((*ProducerCount)(nil), "unproductivedelegatepb.producerCount")
+	proto.RegisterType((*ProbationList)(nil), "unproductivedelegatepb.probationList")
+	proto.RegisterType((*ProbationInfo)(nil), "unproductivedelegatepb.probationInfo")
+	proto.RegisterType((*DoubleSignRecord)(nil), "unproductivedelegatepb.doubleSignRecord")
+	proto.RegisterType((*DelegateStats)(nil), "unproductivedelegatepb.delegateStats")
+	proto.RegisterType((*DelegateStat)(nil), "unproductivedelegatepb.delegateStat")
 }
 
-func init() {
-	proto.RegisterFile("action/protocol/vote/unproductivedelegatepb/unproductivedelegate.proto", fileDescriptor_ef4b0fa66012b010)
-}
-
-var fileDescriptor_ef4b0fa66012b010 = []byte{
-	// 190 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x72, 0x4b, 0x4c, 0x2e, 0xc9,
-	0xcc, 0xcf, 0xd3, 0x2f, 0x28, 0xca, 0x2f, 0xc9, 0x4f, 0xce, 0xcf, 0xd1, 0x2f, 0xcb, 0x2f, 0x49,
-	0xd5, 0x2f, 0xcd, 0x2b, 0x28, 0xca, 0x4f, 0x29, 0x4d, 0x2e, 0xc9, 0x2c, 0x4b, 0x4d, 0x49, 0xcd,
-	0x49, 0x4d, 0x4f, 0x2c, 0x49, 0x2d, 0x48, 0xc2, 0x2a, 0xac, 0x07, 0xd6, 0x29, 0x24, 0x86, 0x5d,
-	0x8b, 0xd2, 0x12, 0x46, 0x2e, 0x11, 0x64, 0x29, 0x17, 0xa8, 0x94, 0x90, 0x0c, 0x17, 0x67, 0x72,
-	0x62, 0x72, 0x46, 0x6a, 0x70, 0x66, 0x55, 0xaa, 0x04, 0xa3, 0x02, 0xa3, 0x06, 0x4b, 0x10, 0x42,
-	0x40, 0x48, 0x85, 0x8b, 0x37, 0x3b, 0x33, 0x39, 0x3b, 0xbf, 0xb4, 0x24, 0x20, 0xb5, 0x28, 0x33,
-	0x3f, 0x45, 0x82, 0x09, 0xac, 0x02, 0x55, 0x50, 0xc8, 0x83, 0x8b, 0x07, 0x66, 0x95, 0x4f, 0x66,
-	0x71, 0x89, 0x04, 0xb3, 0x02, 0xb3, 0x06, 0xb7, 0x91, 0x8a, 0x1e, 0x76, 0xb7, 0xe8, 0xc1, 0x98,
-	0x39, 0x99, 0xc5, 0x25, 0x41, 0x28, 0x3a, 0x95, 0x74, 0x10, 0x26, 0x81, 0x64, 0x41, 0xae, 0x83,
-	0xf1, 0x8b, 0x25, 0x18, 0x15, 0x98, 0x35, 0x38, 0x83, 0x10, 0x02, 0x49, 0x6c, 0x60, 0x3f, 0x1b,
-	0x03, 0x02, 0x00, 0x00, 0xff, 0xff, 0xf3, 0x67, 0xa0, 0x82, 0x3d, 0x01, 0x00, 0x00,
+func init() { proto.RegisterFile("unproductivedelegate.proto", fileDescriptor_11e6919dc08d7343) }
+
+var fileDescriptor_11e6919dc08d7343 = []byte{
+	// 467 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4d, 0x8f, 0xd3, 0x30,
+	0x10, 0x55, 0x36, 0xdb, 0x42, 0x87, 0x46, 0xac, 0xac, 0xd5, 0xca, 0x5a, 0x71, 0xa8, 0xa2, 0x22,
+	0x55, 0x08, 0xf5, 0x00, 0x37, 0x24, 0xc4, 0x81, 0x0f, 0x81, 0x40, 0x80, 0x9c, 0x03, 0x67, 0x37,
+	0x9e, 0xb6, 0x56, 0x43, 0x1c, 0xc5, 0x13, 0xc4, 0xf2, 0x63, 0x38, 0xf1, 0x03, 0xf9, 0x09, 0xc8,
+	0x8e, 0x93, 0x36, 0xcb, 0x2e, 0xe5, 0xd6, 0xf7, 0xe6, 0xcd, 0xbc, 0x79, 0xe3, 0xb6, 0x70, 0xd9,
+	0x94, 0x55, 0x6d, 0x54, 0x93, 0x93, 0xfe, 0x86, 0x0a, 0x0b, 0xdc, 0x48, 0xc2, 0x65, 0x55, 0x1b,
+	0x32, 0xec, 0xe2, 0xa6, 0x5a, 0xb5, 0x4a, 0x7f, 0x45, 0x70, 0x7e, 0x58, 0x7a, 0x15, 0x4a, 0xec,
+	0x01, 0x4c, 0x72, 0x99, 0x6f, 0x31, 0xd3, 0x3f, 0x90, 0x47, 0xb3, 0x68, 0x71, 0x2a, 0xf6, 0x04,
+	0x9b, 0x43, 0xb2, 0xd3, 0xf9, 0xce, 0x34, 0xf4, 0x19, 0x6b, 0x6d, 0x14, 0x3f, 0xf1, 0x8a, 0x21,
+	0xc9, 0xde, 0xc2, 0xb4, 0xb3, 0xfa, 0xa0, 0x2d, 0xf1, 0x78, 0x16, 0x2f, 0xee, 0x3d, 0x99, 0x2f,
+	0x6f, 0xde, 0x65, 0xd9, 0x7d, 0x2c, 0xb4, 0x25, 0x31, 0xe8, 0x4c, 0x1f, 0xc3, 0xf4, 0xb0, 0xea,
+	0xb6, 0xeb, 0xb0, 0xe5, 0xd1, 0x2c, 0x5e, 0x4c, 0xc4, 0x9e, 0x48, 0x73, 0x98, 0xf6, 0x06, 0x9a,
+	0xae, 0xd8, 0x39, 0x8c, 0xb0, 0x32, 0xf9, 0x36, 0xe4, 0x68, 0x01, 0x7b, 0x0e, 0xe3, 0xdc, 0x34,
+	0x25, 0x59, 0x7e, 0xe2, 0xf7, 0x7a, 0x78, 0xdb, 0x5e, 0x2d, 0x89, 0xf5, 0x4b, 0xa7, 0x16, 0xa1,
+	0x29, 0x7d, 0x01, 0xc9, 0xa0, 0xc0, 0x38, 0xdc, 0x91, 0x4a, 0xd5, 0x68, 0xad, 0xf7, 0x99, 0x88,
+	0x0e, 0x3a, 0x7f, 0xdf, 0x14, 0xae, 0xd4, 0x82, 0xf4, 0x67, 0xe4, 0x27, 0xac, 0x24, 0x69, 0x53,
+	0xba, 0x94, 0xec, 0x12, 0xee, 0xfa, 0xd5, 0x3e, 0x36, 0x5f, 0xc3, 0xaa, 0x3d, 0x76, 0x17, 0xd7,
+	0x25, 0x61, 0x69, 0x35, 0x5d, 0x09, 0x49, 0xe8, 0x67, 0x25, 0x62, 0x48, 0xb2, 0xf7, 0x07, 0x23,
+	0xdf, 0x95, 0x6b, 0xc3, 0xe3, 0xa3, 0xd1, 0xf6, 0x62, 0x31, 0xec, 0x4d, 0xbf, 0x5c, 0x1b, 0xf6,
+	0xbf, 0x09, 0x93, 0x90, 0x90, 0x5d, 0xc0, 0xd8, 0x52, 0x8d, 0x72, 0xc7, 0x63, 0x4f, 0x07, 0x94,
+	0xbe, 0x81, 0x33, 0x65, 0x9a, 0x55, 0x81, 0x99, 0xde, 0x94, 0x02, 0x73, 0x53, 0x2b, 0x97, 0xdd,
+	0xac, 0xd7, 0x58, 0x2a, 0xac, 0xc3, 0xf0, 0x1e, 0xbb, 0x39, 0x5b, 0xd4, 0x9b, 0x6d, 0x77, 0xc0,
+	0x80, 0x52, 0x09, 0x49, 0x97, 0x25, 0x23, 0x49, 0xf6, 0x96, 0x87, 0x7e, 0x06, 0x23, 0x4b, 0xb2,
+	0x7f, 0xe7, 0xa3, 0xdf, 0x3f, 0x37, 0x4b, 0xb4, 0x2d, 0xe9, 0xef, 0x08, 0xa6, 0x87, 0xfc, 0x3f,
+	0x6e, 0x30, 0x87, 0x04, 0xbf, 0x57, 0x98, 0x13, 0xaa, 0xac, 0x30, 0xde, 0xce, 0xff, 0x26, 0x06,
+	0xa4, 0x53, 0xb5, 0xe6, 0x9d, 0x2a, 0x6e, 0x55, 0x03, 0x92, 0x2d, 0xe0, 0x7e, 0x7f, 0xfa, 0xd7,
+	0x2e, 0x84, 0xe5, 0xa7, 0x5e, 0x77, 0x9d, 0x66, 0x8f, 0xe0, 0xac, 0x90, 0x96, 0x3e, 0xb9, 0x5b,
+	0x59, 0xf4, 0x24, 0x1f, 0x79, 0xe9, 0x5f, 0xbc, 0xf3, 0x76, 0x5c, 0x86, 0xd8, 0x76, 0xf3, 0x71,
+	0xeb, 0x3d, 0x20, 0x57, 0x63, 0xff, 0x8f, 0xf1, 0xf4, 0xcf, 0x00, 0x5b, 0xe6, 0xce, 0xad, 0x4f,
+	0x04, 0x00, 0x00,
 }
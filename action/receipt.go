@@ -7,13 +7,30 @@
 package action
 
 import (
+	"encoding/binary"
+
 	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
 
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-proto/golang/iotextypes"
 )
 
+// BucketIndexTopic is the first topic of the log of a bucket index
+var BucketIndexTopic = hash.Hash256b([]byte("BucketIndex"))
+
+// ErrNoBucketIndex indicates that a receipt has no log of a bucket index
+var ErrNoBucketIndex = errors.New("no bucket index in receipt")
+
+// ReceiptStatusExecutionTimeout is the status of the receipt of an action whose handling doesn't finish before the
+// timeout of running it, whose writes are reverted
+const ReceiptStatusExecutionTimeout = uint64(300)
+
+// ReceiptStatusBufferFull is the status of the receipt of an action whose writes exceed the limit of the buffer of the
+// working set, which are reverted
+const ReceiptStatusBufferFull = uint64(301)
+
 // Receipt represents the result of a contract
 type Receipt struct {
 	Status          uint64
@@ -89,6 +106,31 @@ func (receipt *Receipt) Hash() hash.Hash256 {
 	return hash.Hash256b(data)
 }
 
+// AddBucketIndexLog adds the log of the index of the bucket created or designated by a staking action, which is
+// emitted by the given address. The first topic of the log is BucketIndexTopic, and the second one is the index in
+// big endian.
+func (receipt *Receipt) AddBucketIndexLog(addr string, index uint64) *Receipt {
+	var topic hash.Hash256
+	binary.BigEndian.PutUint64(topic[len(topic)-8:], index)
+	receipt.Logs = append(receipt.Logs, &Log{
+		Address:     addr,
+		Topics:      []hash.Hash256{BucketIndexTopic, topic},
+		BlockHeight: receipt.BlockHeight,
+		ActionHash:  receipt.ActionHash,
+	})
+	return receipt
+}
+
+// BucketIndex returns the bucket index in the bucket index log of the receipt
+func (receipt *Receipt) BucketIndex() (uint64, error) {
+	for _, l := range receipt.Logs {
+		if len(l.Topics) == 2 && l.Topics[0] == BucketIndexTopic {
+			return binary.BigEndian.Uint64(l.Topics[1][len(l.Topics[1])-8:]), nil
+		}
+	}
+	return 0, ErrNoBucketIndex
+}
+
 // ConvertToLogPb converts a Log to protobuf's Log
 func (log *Log) ConvertToLogPb() *iotextypes.Log {
 	l := &iotextypes.Log{}
@@ -63,6 +63,29 @@ func TestSerDer(t *testing.T) {
 	hash := receipt.Hash()
 	require.Equal("9b1d77d8b8902e8d4e662e7cd07d8a74179e032f030d92441ca7fba1ca68e0f4", hex.EncodeToString(hash[:]))
 }
+func TestBucketIndexSerDer(t *testing.T) {
+	require := require.New(t)
+
+	addr := "io1qyqsyqcy6nm58gjd2wr035wz5eyd5uq47zyqpng3gxe7nh"
+	// the receipts without the log are serialized as before
+	receipt := &Receipt{1, 1, hash.ZeroHash256, 1, addr, nil}
+	old := receipt.Hash()
+	_, err := receipt.BucketIndex()
+	require.Equal(ErrNoBucketIndex, err)
+
+	receipt.AddBucketIndexLog(addr, 1<<40+17)
+	require.NotEqual(old, receipt.Hash())
+	require.Equal(addr, receipt.ContractAddress)
+	ser, err := receipt.Serialize()
+	require.NoError(err)
+	receipt2 := &Receipt{}
+	require.NoError(receipt2.Deserialize(ser))
+	require.Equal(receipt.Hash(), receipt2.Hash())
+	require.Equal(addr, receipt2.ContractAddress)
+	index, err := receipt2.BucketIndex()
+	require.NoError(err)
+	require.Equal(uint64(1<<40+17), index)
+}
 func TestConvertLog(t *testing.T) {
 	require := require.New(t)
 
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+// SetDelegateFilter replaces the allow and deny lists of the life long delegates by operator address. An empty allow
+// list allows every delegate.
+type SetDelegateFilter struct {
+	AbstractAction
+
+	allowList []string
+	denyList  []string
+}
+
+// NewSetDelegateFilter instantiates a set delegate filter action struct.
+func NewSetDelegateFilter(
+	nonce uint64,
+	allowList []string,
+	denyList []string,
+) *SetDelegateFilter {
+	return &SetDelegateFilter{
+		AbstractAction: AbstractAction{
+			version:  version.ProtocolVersion,
+			nonce:    nonce,
+			gasLimit: 0,
+			gasPrice: big.NewInt(0),
+		},
+		allowList: allowList,
+		denyList:  denyList,
+	}
+}
+
+// AllowList returns the operator addresses of the allowed delegates
+func (f *SetDelegateFilter) AllowList() []string { return f.allowList }
+
+// DenyList returns the operator addresses of the denied delegates
+func (f *SetDelegateFilter) DenyList() []string { return f.denyList }
+
+// Serialize returns the byte representation of set delegate filter action.
+func (f *SetDelegateFilter) Serialize() []byte {
+	return byteutil.Must(proto.Marshal(f.Proto()))
+}
+
+// Proto converts set delegate filter action into a proto message.
+func (f *SetDelegateFilter) Proto() *pollpb.DelegateFilter {
+	return &pollpb.DelegateFilter{
+		AllowList: f.allowList,
+		DenyList:  f.denyList,
+	}
+}
+
+// LoadProto converts a proto message into set delegate filter action.
+func (f *SetDelegateFilter) LoadProto(pbAct *pollpb.DelegateFilter) error {
+	if pbAct == nil {
+		return errors.New("empty action proto to load")
+	}
+	if f == nil {
+		return errors.New("nil action to load proto")
+	}
+	f.allowList = pbAct.GetAllowList()
+	f.denyList = pbAct.GetDenyList()
+	return nil
+}
+
+// IntrinsicGas returns the intrinsic gas of a set delegate filter action
+func (f *SetDelegateFilter) IntrinsicGas() (uint64, error) {
+	return 0, nil
+}
+
+// Cost returns the total cost of a set delegate filter action
+func (f *SetDelegateFilter) Cost() (*big.Int, error) {
+	return big.NewInt(0), nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDelegateFilter(t *testing.T) {
+	require := require.New(t)
+	f := NewSetDelegateFilter(1, []string{"a", "b"}, []string{"c"})
+	require.Equal(uint64(1), f.Nonce())
+	igas, err := f.IntrinsicGas()
+	require.NoError(err)
+	require.Equal(uint64(0), igas)
+	cost, err := f.Cost()
+	require.NoError(err)
+	require.Equal(0, big.NewInt(0).Cmp(cost))
+
+	clone := &SetDelegateFilter{}
+	require.NoError(clone.LoadProto(f.Proto()))
+	require.Equal([]string{"a", "b"}, clone.AllowList())
+	require.Equal([]string{"c"}, clone.DenyList())
+	require.Error(clone.LoadProto(nil))
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol/poll/pollpb"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+)
+
+// UpdateDelegates replaces the life long delegates from next epoch
+type UpdateDelegates struct {
+	AbstractAction
+
+	delegates []genesis.Delegate
+}
+
+// NewUpdateDelegates instantiates an update delegates action struct.
+func NewUpdateDelegates(nonce uint64, delegates []genesis.Delegate) *UpdateDelegates {
+	return &UpdateDelegates{
+		AbstractAction: AbstractAction{
+			version:  version.ProtocolVersion,
+			nonce:    nonce,
+			gasLimit: 0,
+			gasPrice: big.NewInt(0),
+		},
+		delegates: delegates,
+	}
+}
+
+// Delegates returns the new life long delegates
+func (u *UpdateDelegates) Delegates() []genesis.Delegate { return u.delegates }
+
+// Serialize returns the byte representation of update delegates action.
+func (u *UpdateDelegates) Serialize() []byte {
+	return byteutil.Must(proto.Marshal(u.Proto()))
+}
+
+// Proto converts update delegates action into a proto message.
+func (u *UpdateDelegates) Proto() *pollpb.DelegateUpdate {
+	delegates := make([]*pollpb.LifeLongDelegate, 0, len(u.delegates))
+	for _, d := range u.delegates {
+		delegates = append(delegates, &pollpb.LifeLongDelegate{
+			OperatorAddress: d.OperatorAddrStr,
+			RewardAddress:   d.RewardAddrStr,
+			Votes:           d.VotesStr,
+		})
+	}
+	return &pollpb.DelegateUpdate{Delegates: delegates}
+}
+
+// LoadProto converts a proto message into update delegates action.
+func (u *UpdateDelegates) LoadProto(pbAct *pollpb.DelegateUpdate) error {
+	if pbAct == nil {
+		return errors.New("empty action proto to load")
+	}
+	if u == nil {
+		return errors.New("nil action to load proto")
+	}
+	delegates := make([]genesis.Delegate, 0, len(pbAct.GetDelegates()))
+	for _, d := range pbAct.GetDelegates() {
+		delegates = append(delegates, genesis.Delegate{
+			OperatorAddrStr: d.GetOperatorAddress(),
+			RewardAddrStr:   d.GetRewardAddress(),
+			VotesStr:        d.GetVotes(),
+		})
+	}
+	u.delegates = delegates
+	return nil
+}
+
+// IntrinsicGas returns the intrinsic gas of an update delegates action
+func (u *UpdateDelegates) IntrinsicGas() (uint64, error) {
+	return 0, nil
+}
+
+// Cost returns the total cost of an update delegates action
+func (u *UpdateDelegates) Cost() (*big.Int, error) {
+	return big.NewInt(0), nil
+}
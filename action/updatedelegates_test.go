@@ -0,0 +1,37 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
+)
+
+func TestUpdateDelegates(t *testing.T) {
+	require := require.New(t)
+	delegates := []genesis.Delegate{
+		{OperatorAddrStr: "a", RewardAddrStr: "b", VotesStr: "10"},
+		{OperatorAddrStr: "c"},
+	}
+	u := NewUpdateDelegates(1, delegates)
+	require.Equal(uint64(1), u.Nonce())
+	igas, err := u.IntrinsicGas()
+	require.NoError(err)
+	require.Equal(uint64(0), igas)
+	cost, err := u.Cost()
+	require.NoError(err)
+	require.Equal(0, big.NewInt(0).Cmp(cost))
+
+	clone := &UpdateDelegates{}
+	require.NoError(clone.LoadProto(u.Proto()))
+	require.Equal(delegates, clone.Delegates())
+	require.Error(clone.LoadProto(nil))
+}
@@ -132,6 +132,10 @@ func NewActPool(sf factory.Factory, cfg config.ActPool, opts ...Option) (ActPool
 		return nil, err
 	}
 	ap.timerFactory = timerFactory
+	// the actions timing out in the blocks built are evicted, rather than run again in the next blocks
+	if minter, ok := sf.(factory.Minter); ok {
+		minter.AddActionTimeoutSubscriber(ap)
+	}
 	return ap, nil
 }
 
@@ -250,6 +254,32 @@ func (ap *actPool) Add(ctx context.Context, act action.SealedEnvelope) error {
 	return ap.enqueueAction(caller.String(), act, hash, act.Nonce())
 }
 
+// HandleActionTimeout evicts the action timing out when a block is built. The later actions of the sender stay in the
+// pool, which are pending again once the nonce of the action is filled by another one.
+func (ap *actPool) HandleActionTimeout(act action.SealedEnvelope) {
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	caller, err := address.FromBytes(act.SrcPubkey().Hash())
+	if err != nil {
+		log.L().Error("Error when evicting the action timing out", zap.Error(err))
+		return
+	}
+	sender := caller.String()
+	queue, ok := ap.accountActs[sender]
+	if !ok || !queue.Remove(act) {
+		return
+	}
+	actpoolMtc.WithLabelValues("timedOut").Inc()
+	ap.removeInvalidActs([]action.SealedEnvelope{act})
+	if act.Nonce() < queue.PendingNonce() {
+		queue.SetPendingNonce(act.Nonce())
+	}
+	if queue.Empty() {
+		delete(ap.accountActs, sender)
+	}
+}
+
 // GetPendingNonce returns pending nonce in pool or confirmed nonce given an account address
 func (ap *actPool) GetPendingNonce(addr string) (uint64, error) {
 	ap.mutex.RLock()
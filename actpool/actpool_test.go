@@ -1173,6 +1173,93 @@ func TestActPool_AddActionNotEnoughGasPrice(t *testing.T) {
 	require.Error(t, ap.Add(ctx, tsf))
 }
 
+func TestActPool_HandleActionTimeout(t *testing.T) {
+	require := require.New(t)
+	cfg := config.Default
+	cfg.Genesis.InitBalanceMap[addr1] = "100"
+	cfg.Chain.ActionTimeout = 20 * time.Millisecond
+	registry := protocol.NewRegistry()
+	// the handler of the second transfer runs past the timeout, regardless of the context
+	slow := &slowProtocol{nonce: 2}
+	require.NoError(registry.Register("slow", slow))
+	acc := account.NewProtocol(rewarding.DepositGas)
+	require.NoError(acc.Register(registry))
+	sf, err := factory.NewFactory(cfg, factory.InMemTrieOption())
+	require.NoError(err)
+	dao := blockdao.NewBlockDAO(db.NewMemKVStore(), nil, cfg.Chain.CompressBlock, cfg.DB)
+	bc := blockchain.NewBlockchain(
+		cfg,
+		dao,
+		sf,
+		blockchain.RegistryOption(registry),
+	)
+	require.NoError(bc.Start(context.Background()))
+	// Create actpool
+	Ap, err := NewActPool(sf, getActPoolCfg(), EnableExperimentalActions())
+	require.NoError(err)
+	ap, ok := Ap.(*actPool)
+	require.True(ok)
+
+	tsf1, err := testutil.SignedTransfer(addr2, priKey1, uint64(1), big.NewInt(10), []byte{}, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	tsf2, err := testutil.SignedTransfer(addr2, priKey1, uint64(2), big.NewInt(10), []byte{}, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	tsf3, err := testutil.SignedTransfer(addr2, priKey1, uint64(3), big.NewInt(10), []byte{}, uint64(100000), big.NewInt(0))
+	require.NoError(err)
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{Registry: registry})
+	require.NoError(ap.Add(ctx, tsf1))
+	require.NoError(ap.Add(ctx, tsf2))
+	require.NoError(ap.Add(ctx, tsf3))
+
+	ctx = protocol.WithBlockchainCtx(
+		protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{
+			BlockHeight: 1,
+			Producer:    identityset.Address(27),
+			GasLimit:    cfg.Genesis.BlockGasLimit,
+		}),
+		protocol.BlockchainCtx{Genesis: cfg.Genesis, Registry: registry},
+	)
+	minter, ok := sf.(factory.Minter)
+	require.True(ok)
+	build := func() []action.SealedEnvelope {
+		blkBuilder, err := minter.NewBlockBuilder(ctx, ap.PendingActionMap(), nil)
+		require.NoError(err)
+		blk, err := blkBuilder.SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		return blk.Actions
+	}
+	require.Equal([]action.SealedEnvelope{tsf1}, build())
+	require.Equal(1, slow.handled)
+	// the action timing out is evicted, and the later one isn't pending until the nonce is filled again
+	_, err = ap.GetActionByHash(tsf2.Hash())
+	require.Error(err)
+	_, err = ap.GetActionByHash(tsf3.Hash())
+	require.NoError(err)
+	pendingNonce, err := ap.GetPendingNonce(addr1)
+	require.NoError(err)
+	require.Equal(uint64(2), pendingNonce)
+	// the next block doesn't wait out the timeout again
+	require.Equal([]action.SealedEnvelope{tsf1}, build())
+	require.Equal(1, slow.handled)
+}
+
+// slowProtocol sleeps in handling the action of the nonce, regardless of the context
+type slowProtocol struct {
+	protocol.Protocol
+	nonce   uint64
+	handled int
+}
+
+func (p *slowProtocol) Handle(ctx context.Context, _ action.Action, _ protocol.StateManager) (*action.Receipt, error) {
+	if protocol.MustGetActionCtx(ctx).Nonce == p.nonce {
+		p.handled++
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil, nil
+}
+
+func (p *slowProtocol) Validate(context.Context, action.Action) error { return nil }
+
 // Helper function to return the correct pending nonce just in case of empty queue
 func (ap *actPool) getPendingNonce(addr string) (uint64, error) {
 	if queue, ok := ap.accountActs[addr]; ok {
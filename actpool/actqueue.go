@@ -54,6 +54,7 @@ type ActQueue interface {
 	Put(action.SealedEnvelope) error
 	FilterNonce(uint64) []action.SealedEnvelope
 	UpdateQueue(uint64) []action.SealedEnvelope
+	Remove(action.SealedEnvelope) bool
 	SetPendingNonce(uint64)
 	PendingNonce() uint64
 	SetPendingBalance(*big.Int)
@@ -132,6 +133,22 @@ func (q *actQueue) FilterNonce(threshold uint64) []action.SealedEnvelope {
 	return removed
 }
 
+// Remove removes the action from the queue, and returns false if the action of its nonce isn't the one in the queue
+func (q *actQueue) Remove(act action.SealedEnvelope) bool {
+	nonce := act.Nonce()
+	if queued, exist := q.items[nonce]; !exist || queued.Hash() != act.Hash() {
+		return false
+	}
+	delete(q.items, nonce)
+	for i := range q.index {
+		if q.index[i].nonce == nonce {
+			heap.Remove(&q.index, i)
+			break
+		}
+	}
+	return true
+}
+
 func (q *actQueue) cleanTimeout() []action.SealedEnvelope {
 	removedFromQueue := make([]action.SealedEnvelope, 0)
 	for i := 0; i < len(q.index); i++ {
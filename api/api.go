@@ -94,6 +94,7 @@ func WithNativeElection(committee committee.Committee) Option {
 type Server struct {
 	bc                blockchain.Blockchain
 	sf                factory.Factory
+	sr                protocol.StateReader // reader of the committed states which the reads of the protocols go through
 	dao               blockdao.BlockDAO
 	indexer           blockindex.Indexer
 	ap                actpool.ActPool
@@ -134,9 +135,14 @@ func NewServer(
 		return nil, errors.New("range query upper limit cannot be less than tps window")
 	}
 
+	sr, err := sf.NewReader()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the reader of the committed states")
+	}
 	svr := &Server{
 		bc:                chain,
 		sf:                sf,
+		sr:                sr,
 		dao:               dao,
 		indexer:           indexer,
 		ap:                actPool,
@@ -328,6 +334,7 @@ func (api *Server) SendAction(ctx context.Context, in *iotexapi.SendActionReques
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	// Add to local actpool
+	ctx = protocol.WithRegistry(ctx, api.registry)
 	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Registry: api.registry})
 	if err = api.ap.Add(ctx, selp); err != nil {
 		log.L().Debug(err.Error())
@@ -439,13 +446,16 @@ func (api *Server) ReadContract(ctx context.Context, in *iotexapi.ReadContractRe
 
 // ReadState reads state on blockchain
 func (api *Server) ReadState(ctx context.Context, in *iotexapi.ReadStateRequest) (*iotexapi.ReadStateResponse, error) {
-	p, ok := api.registry.Find(string(in.ProtocolID))
-	if !ok {
+	if _, ok := api.registry.Find(string(in.ProtocolID)); !ok {
 		return nil, status.Errorf(codes.Internal, "protocol %s isn't registered", string(in.ProtocolID))
 	}
-	data, err := api.readState(ctx, p, in.MethodName, in.Arguments...)
+	ctx, err := api.readStateCtx(ctx)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	data, err := api.registry.ReadState(ctx, api.sr, string(in.ProtocolID), in.MethodName, in.Arguments...)
+	if err != nil {
+		return nil, status.Error(readStateStatusCode(err), err.Error())
 	}
 	out := iotexapi.ReadStateResponse{
 		Data: data,
@@ -771,21 +781,56 @@ func (api *Server) Stop() error {
 }
 
 func (api *Server) readState(ctx context.Context, p protocol.Protocol, methodName []byte, arguments ...[]byte) ([]byte, error) {
+	ctx, err := api.readStateCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.ReadState(ctx, api.sr, methodName, arguments...)
+}
+
+// readStateCtx returns the context of reading the states at the tip. The tip is the one of the committed states read,
+// so that the protocols reading the tip from the context agree with the states, while the chain may be ahead of them.
+func (api *Server) readStateCtx(ctx context.Context) (context.Context, error) {
 	// TODO: need to complete the context
-	tipHeight := api.bc.TipHeight()
+	tipHeight, err := api.sr.TipHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the height of the committed states")
+	}
 	ctx = protocol.WithBlockCtx(ctx, protocol.BlockCtx{
 		BlockHeight: tipHeight,
 	})
+	ctx = protocol.WithRegistry(ctx, api.registry)
 	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{
 		Registry: api.registry,
 		Genesis:  api.cfg.Genesis,
 		Tip: protocol.TipInfo{
 			Height: tipHeight,
 		},
+		GetBlockHash: api.dao.GetBlockHash,
 	})
 
-	// TODO: need to distinguish user error and system error
-	return p.ReadState(ctx, api.sf, methodName, arguments...)
+	return ctx, nil
+}
+
+// readStateStatusCode returns the status code of the error of ReadState, which is NotFound unless the error is caused
+// by the request
+func readStateStatusCode(err error) codes.Code {
+	code, ok := protocol.ReadStateErrorCodeOf(err)
+	if !ok {
+		return codes.NotFound
+	}
+	switch code {
+	case protocol.UnknownMethod:
+		return codes.Unimplemented
+	case protocol.BadArgCount, protocol.BadArgEncoding:
+		return codes.InvalidArgument
+	case protocol.EpochOutOfRange:
+		return codes.OutOfRange
+	case protocol.ArchiveUnavailable:
+		return codes.FailedPrecondition
+	default:
+		return codes.NotFound
+	}
 }
 
 func (api *Server) getActionsFromIndex(totalActions, start, count uint64) (*iotexapi.GetActionsResponse, error) {
@@ -1158,6 +1203,9 @@ func (api *Server) getGravityChainStartHeight(epochHeight uint64) (uint64, error
 		methodName := []byte("GetGravityChainStartHeight")
 		arguments := [][]byte{byteutil.Uint64ToBytes(epochHeight)}
 		data, err := api.readState(context.Background(), pp, methodName, arguments...)
+		if errors.Cause(err) == poll.ErrNoGravityChain {
+			return 0, nil
+		}
 		if err != nil {
 			return 0, err
 		}
@@ -22,6 +22,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
@@ -974,10 +976,13 @@ func TestServer_GetChainMeta(t *testing.T) {
 	defer ctrl.Finish()
 
 	var pol poll.Protocol
+	var err error
 	for _, test := range getChainMetaTests {
 		cfg := newConfig()
 		if test.pollProtocolType == lld {
-			pol = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			cfg.Genesis.GravityChainStartHeight = 1
+			pol, err = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			require.NoError(err)
 		} else if test.pollProtocolType == "governanceChainCommittee" {
 			committee := mock_committee.NewMockCommittee(ctrl)
 			pol, _ = poll.NewGovernanceChainCommitteeProtocol(
@@ -988,8 +993,6 @@ func TestServer_GetChainMeta(t *testing.T) {
 				committee,
 				uint64(123456),
 				func(uint64) (time.Time, error) { return time.Now(), nil },
-				cfg.Genesis.NumCandidateDelegates,
-				cfg.Genesis.NumDelegates,
 				cfg.Chain.PollInitialCandidatesInterval,
 				nil,
 				func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -1026,6 +1029,22 @@ func TestServer_GetChainMeta(t *testing.T) {
 	}
 }
 
+func TestServer_GetChainMeta_NoGravityChain(t *testing.T) {
+	require := require.New(t)
+	cfg := newConfig()
+	cfg.Genesis.GravityChainStartHeight = 0
+
+	svr, err := createServer(cfg, false)
+	require.NoError(err)
+	pol, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	require.NoError(err)
+	require.NoError(pol.ForceRegister(svr.registry))
+	res, err := svr.GetChainMeta(context.Background(), &iotexapi.GetChainMetaRequest{})
+	require.NoError(err)
+	require.Equal(uint64(1), res.ChainMeta.Epoch.Num)
+	require.Equal(uint64(0), res.ChainMeta.Epoch.GravityChainStartHeight)
+}
+
 func TestServer_SendAction(t *testing.T) {
 	require := require.New(t)
 
@@ -1246,9 +1265,11 @@ func TestServer_ReadCandidatesByEpoch(t *testing.T) {
 
 	for _, test := range readCandidatesByEpochTests {
 		var pol poll.Protocol
+		var err error
 		if test.protocolType == lld {
 			cfg.Genesis.Delegates = delegates
-			pol = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			pol, err = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			require.NoError(err)
 		} else {
 			pol, _ = poll.NewGovernanceChainCommitteeProtocol(
 				func(protocol.StateReader, uint64) ([]*state.Candidate, error) { return candidates, nil },
@@ -1258,8 +1279,6 @@ func TestServer_ReadCandidatesByEpoch(t *testing.T) {
 				committee,
 				uint64(123456),
 				func(uint64) (time.Time, error) { return time.Now(), nil },
-				cfg.Genesis.NumCandidateDelegates,
-				cfg.Genesis.NumDelegates,
 				cfg.Chain.PollInitialCandidatesInterval,
 				nil,
 				func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -1309,10 +1328,15 @@ func TestServer_ReadBlockProducersByEpoch(t *testing.T) {
 
 	for _, test := range readBlockProducersByEpochTests {
 		var pol poll.Protocol
+		var err error
 		if test.protocolType == lld {
 			cfg.Genesis.Delegates = delegates
-			pol = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			pol, err = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			require.NoError(err)
 		} else {
+			// the number of candidate delegates is decided by the rolldpos protocol
+			cfg.Genesis.NumCandidateDelegates = test.numCandidateDelegates
+			cfg.Genesis.NumDelegates = test.numCandidateDelegates
 			pol, _ = poll.NewGovernanceChainCommitteeProtocol(
 				func(protocol.StateReader, uint64) ([]*state.Candidate, error) { return candidates, nil },
 				nil,
@@ -1321,8 +1345,6 @@ func TestServer_ReadBlockProducersByEpoch(t *testing.T) {
 				committee,
 				uint64(123456),
 				func(uint64) (time.Time, error) { return time.Now(), nil },
-				test.numCandidateDelegates,
-				cfg.Genesis.NumDelegates,
 				cfg.Chain.PollInitialCandidatesInterval,
 				nil,
 				func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -1374,10 +1396,14 @@ func TestServer_ReadActiveBlockProducersByEpoch(t *testing.T) {
 
 	for _, test := range readActiveBlockProducersByEpochTests {
 		var pol poll.Protocol
+		var err error
 		if test.protocolType == lld {
 			cfg.Genesis.Delegates = delegates
-			pol = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			pol, err = poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			require.NoError(err)
 		} else {
+			// the number of delegates is decided by the rolldpos protocol
+			cfg.Genesis.NumDelegates = test.numDelegates
 			pol, _ = poll.NewGovernanceChainCommitteeProtocol(
 				func(protocol.StateReader, uint64) ([]*state.Candidate, error) { return candidates, nil },
 				nil,
@@ -1386,8 +1412,6 @@ func TestServer_ReadActiveBlockProducersByEpoch(t *testing.T) {
 				committee,
 				uint64(123456),
 				func(uint64) (time.Time, error) { return time.Now(), nil },
-				cfg.Genesis.NumCandidateDelegates,
-				test.numDelegates,
 				cfg.Chain.PollInitialCandidatesInterval,
 				sm,
 				func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -1448,9 +1472,44 @@ func TestServer_ReadEpochCtx(t *testing.T) {
 	}
 }
 
+func TestServer_ReadStateErrorCode(t *testing.T) {
+	require := require.New(t)
+	cfg := newConfig()
+	cfg.Genesis.Delegates = delegates
+
+	svr, err := createServer(cfg, false)
+	require.NoError(err)
+	pol, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	require.NoError(err)
+	require.NoError(pol.ForceRegister(svr.registry))
+
+	for _, test := range []struct {
+		methodName string
+		arguments  [][]byte
+		code       codes.Code
+	}{
+		{"UnknownMethod", nil, codes.Unimplemented},
+		{"ProductivityByEpoch", nil, codes.InvalidArgument},
+		{"CandidatesByEpoch", [][]byte{{1, 2, 3}}, codes.InvalidArgument},
+	} {
+		_, err := svr.ReadState(context.Background(), &iotexapi.ReadStateRequest{
+			ProtocolID: []byte("poll"),
+			MethodName: []byte(test.methodName),
+			Arguments:  test.arguments,
+		})
+		require.Error(err)
+		require.Equal(test.code, status.Code(err))
+	}
+
+	require.Equal(codes.OutOfRange, readStateStatusCode(protocol.ReadStateErrorf(protocol.EpochOutOfRange, "")))
+	require.Equal(codes.FailedPrecondition, readStateStatusCode(protocol.ReadStateErrorf(protocol.ArchiveUnavailable, "")))
+	require.Equal(codes.NotFound, readStateStatusCode(errors.New("failed to read state")))
+}
+
 func TestServer_GetEpochMeta(t *testing.T) {
 	require := require.New(t)
 	cfg := newConfig()
+	cfg.Genesis.GravityChainStartHeight = 1
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1462,7 +1521,8 @@ func TestServer_GetEpochMeta(t *testing.T) {
 	require.NoError(err)
 	for _, test := range getEpochMetaTests {
 		if test.pollProtocolType == lld {
-			pol := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			pol, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+			require.NoError(err)
 			require.NoError(pol.ForceRegister(svr.registry))
 		} else if test.pollProtocolType == "governanceChainCommittee" {
 			committee := mock_committee.NewMockCommittee(ctrl)
@@ -1508,8 +1568,6 @@ func TestServer_GetEpochMeta(t *testing.T) {
 				committee,
 				uint64(123456),
 				func(uint64) (time.Time, error) { return time.Now(), nil },
-				cfg.Genesis.NumCandidateDelegates,
-				cfg.Genesis.NumDelegates,
 				cfg.Chain.PollInitialCandidatesInterval,
 				sm,
 				func(context.Context, uint64) (uint64, map[string]uint64, error) {
@@ -1828,7 +1886,10 @@ func setupChain(cfg config.Config) (blockchain.Blockchain, blockdao.BlockDAO, bl
 
 	acc := account.NewProtocol(rewarding.DepositGas)
 	evm := execution.NewProtocol(dao.GetBlockHash)
-	p := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	p, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
 	rolldposProtocol := rolldpos.NewProtocol(
 		genesis.Default.NumCandidateDelegates,
 		genesis.Default.NumDelegates,
@@ -1925,9 +1986,14 @@ func createServer(cfg config.Config, needActPool bool) (*Server, error) {
 		}
 	}
 
+	sr, err := sf.NewReader()
+	if err != nil {
+		return nil, err
+	}
 	svr := &Server{
 		bc:             bc,
 		sf:             sf,
+		sr:             sr,
 		dao:            dao,
 		indexer:        indexer,
 		ap:             ap,
@@ -100,6 +100,18 @@ type Blockchain interface {
 	RemoveSubscriber(BlockCreationSubscriber) error
 }
 
+// BlockHashByHeight returns the hash of the block at the height, which is the zero hash for the genesis block
+func BlockHashByHeight(bc Blockchain, height uint64) (hash.Hash256, error) {
+	if height == 0 {
+		return hash.ZeroHash256, nil
+	}
+	header, err := bc.BlockHeaderByHeight(height)
+	if err != nil {
+		return hash.ZeroHash256, err
+	}
+	return header.HashBlock(), nil
+}
+
 // ProductivityByEpoch returns the map of the number of blocks produced per delegate in an epoch
 func ProductivityByEpoch(ctx context.Context, bc Blockchain, epochNum uint64) (uint64, map[string]uint64, error) {
 	bcCtx := protocol.MustGetBlockchainCtx(ctx)
@@ -288,25 +300,38 @@ func (bc *blockchain) Start(ctx context.Context) error {
 	// get blockchain tip height
 	tipHeight := bc.dao.GetTipHeight()
 	if tipHeight == 0 {
+		// the protocols aren't started on an empty chain, where the states they prepare with, such as the poll result
+		// of the genesis block, are created along with the genesis states
 		return nil
 	}
-	if bcCtx, ok := protocol.GetBlockchainCtx(ctx); ok {
-		for _, p := range bcCtx.Registry.All() {
-			if s, ok := p.(lifecycle.Starter); ok {
-				if err := s.Start(ctx); err != nil {
-					return errors.Wrap(err, "failed to start protocol")
-				}
-			}
-		}
+	if err := bc.startExistingBlockchain(ctx); err != nil {
+		return err
 	}
+	return bc.startProtocols(ctx)
+}
 
-	return bc.startExistingBlockchain(ctx)
+// startProtocols starts the protocols once the state factory catches up with the tip, so that a node whose protocols
+// can't get ready, such as a poll protocol which can't read the gravity chain, doesn't join the consensus
+func (bc *blockchain) startProtocols(ctx context.Context) error {
+	if bc.sf == nil {
+		return nil
+	}
+	ctx, err := bc.context(ctx, true, false)
+	if err != nil {
+		return err
+	}
+	tip := protocol.MustGetBlockchainCtx(ctx).Tip
+	ctx = bc.contextWithBlock(ctx, bc.config.ProducerAddress(), tip.Height, tip.Timestamp)
+	return bc.registry.StartAll(ctx, bc.sf)
 }
 
 // Stop stops the blockchain.
 func (bc *blockchain) Stop(ctx context.Context) error {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
+	if err := bc.registry.StopAll(ctx); err != nil {
+		return err
+	}
 	return bc.lifecycle.OnStop(ctx)
 }
 
@@ -382,12 +407,13 @@ func (bc *blockchain) context(ctx context.Context, tipInfoFlag, candidateFlag bo
 		}
 	}
 	return protocol.WithBlockchainCtx(
-		ctx,
+		protocol.WithRegistry(ctx, bc.registry),
 		protocol.BlockchainCtx{
-			Registry:   bc.registry,
-			Genesis:    bc.config.Genesis,
-			Tip:        tip,
-			Candidates: candidates,
+			Registry:     bc.registry,
+			Genesis:      bc.config.Genesis,
+			Tip:          tip,
+			Candidates:   candidates,
+			GetBlockHash: bc.dao.GetBlockHash,
 		}), nil
 }
 
@@ -503,11 +529,12 @@ func (bc *blockchain) candidatesByHeight(height uint64) (state.CandidateList, er
 		return nil, err
 	}
 	ctx := protocol.WithBlockchainCtx(
-		context.Background(),
+		protocol.WithRegistry(context.Background(), bc.registry),
 		protocol.BlockchainCtx{
-			Registry: bc.registry,
-			Genesis:  bc.config.Genesis,
-			Tip:      *tipInfo,
+			Registry:     bc.registry,
+			Genesis:      bc.config.Genesis,
+			Tip:          *tipInfo,
+			GetBlockHash: bc.dao.GetBlockHash,
 		})
 
 	if pp := poll.FindProtocol(bc.registry); pp != nil {
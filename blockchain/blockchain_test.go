@@ -20,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/golang/mock/gomock"
 	"github.com/iotexproject/iotex-address/address"
+	"github.com/iotexproject/iotex-election/test/mock/mock_committee"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
@@ -1079,7 +1080,8 @@ func TestBlockchainInitialCandidate(t *testing.T) {
 	require.NoError(rolldposProtocol.Register(registry))
 	rewardingProtocol := rewarding.NewProtocol(cfg.Genesis.KickoutIntensityRate, nil, nil)
 	require.NoError(rewardingProtocol.Register(registry))
-	pollProtocol := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	pollProtocol, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	require.NoError(err)
 	require.NoError(pollProtocol.Register(registry))
 
 	require.NoError(bc.Start(context.Background()))
@@ -1091,6 +1093,76 @@ func TestBlockchainInitialCandidate(t *testing.T) {
 	require.Equal(24, len(candidate))
 }
 
+func TestBlockchain_StartWithDeadGravityChain(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	testTrieFile, _ := ioutil.TempFile(os.TempDir(), "trie")
+	testTriePath := testTrieFile.Name()
+	testDBFile, _ := ioutil.TempFile(os.TempDir(), "db")
+	testDBPath := testDBFile.Name()
+	defer func() {
+		testutil.CleanupPath(t, testTriePath)
+		testutil.CleanupPath(t, testDBPath)
+	}()
+
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.ChainDBPath = testDBPath
+	cfg.Consensus.Scheme = config.RollDPoSScheme
+	sf, err := factory.NewFactory(cfg, factory.DefaultTrieOption())
+	require.NoError(err)
+	cfg.DB.DbPath = cfg.Chain.ChainDBPath
+	dao := blockdao.NewBlockDAO(db.NewBoltDB(cfg.DB), nil, cfg.Chain.CompressBlock, cfg.DB)
+	newRegistry := func(pp poll.Protocol) *protocol.Registry {
+		registry := protocol.NewRegistry()
+		require.NoError(account.NewProtocol(rewarding.DepositGas).Register(registry))
+		rp := rolldpos.NewProtocol(cfg.Genesis.NumCandidateDelegates, cfg.Genesis.NumDelegates, cfg.Genesis.NumSubEpochs)
+		require.NoError(rp.Register(registry))
+		require.NoError(rewarding.NewProtocol(cfg.Genesis.KickoutIntensityRate, nil, nil).Register(registry))
+		require.NoError(pp.Register(registry))
+		return registry
+	}
+
+	// create a chain of one block with the life long delegates
+	lifelong, err := poll.NewLifeLongDelegatesProtocol(cfg.Genesis.Delegates)
+	require.NoError(err)
+	bc := NewBlockchain(cfg, dao, sf, RegistryOption(newRegistry(lifelong)))
+	require.NoError(bc.Start(ctx))
+	blk, err := bc.MintNewBlock(map[string][]action.SealedEnvelope{}, testutil.TimestampNow())
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	require.NoError(bc.Stop(ctx))
+
+	// restart the chain with a gravity chain which can't be reached
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	committee := mock_committee.NewMockCommittee(ctrl)
+	committee.EXPECT().HeightByTime(gomock.Any()).Return(uint64(0), errors.New("gravity chain endpoint is down")).AnyTimes()
+	governance, err := poll.NewGovernanceChainCommitteeProtocol(
+		candidatesutil.CandidatesByHeight,
+		nil,
+		nil,
+		nil,
+		committee,
+		uint64(123456),
+		func(uint64) (time.Time, error) { return time.Now(), nil },
+		cfg.Chain.PollInitialCandidatesInterval,
+		sf,
+		nil,
+		cfg.Genesis.ProductivityThreshold,
+		cfg.Genesis.KickoutEpochPeriod,
+		cfg.Genesis.KickoutIntensityRate,
+		cfg.Genesis.UnproductiveDelegateMaxCacheSize,
+	)
+	require.NoError(err)
+	bc = NewBlockchain(cfg, dao, sf, RegistryOption(newRegistry(governance)))
+	err = bc.Start(ctx)
+	require.Error(err)
+	require.Contains(err.Error(), "failed to start protocol poll")
+	require.Contains(err.Error(), "gravity chain endpoint is down")
+}
+
 func TestBlockchain_AccountState(t *testing.T) {
 	require := require.New(t)
 
@@ -1431,11 +1503,10 @@ func BalanceOfContract(contract, genesisAccount string, sf factory.Factory, t *t
 	require := require.New(t)
 	ws, err := sf.NewWorkingSet()
 	require.NoError(err)
-	kv := ws.GetDB()
 	addr, err := address.FromString(contract)
 	require.NoError(err)
 	addrHash := hash.BytesToHash160(addr.Bytes())
-	dbForTrie, err := db.NewKVStoreForTrie(evm.ContractKVNameSpace, kv)
+	dbForTrie, err := ws.KVStore(evm.ContractKVNameSpace)
 	require.NoError(err)
 	options := []trie.Option{
 		trie.KVStoreOption(dbForTrie),
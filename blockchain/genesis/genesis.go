@@ -61,9 +61,16 @@ func defaultConfig() Genesis {
 		},
 		Poll: Poll{
 			EnableGravityChainVoting:         true,
+			GravityChainBlockInterval:        15 * time.Second,
 			KickoutEpochPeriod:               3,
 			KickoutIntensityRate:             0,
 			UnproductiveDelegateMaxCacheSize: 20,
+			DelegateShortagePolicy:           "shrink",
+			EpochSnapshotRetention:           720,
+			DoubleSignProbationCount:         24,
+			ElectableVoteThresholdStr:        "0",
+			DelegateStatsRetention:           720,
+			SortSeedStrategy:                 "constant",
 		},
 		Rewarding: Rewarding{
 			InitBalanceStr:                 unit.ConvertIotxToRau(200000000).String(),
@@ -77,6 +84,18 @@ func defaultConfig() Genesis {
 			NumDelegatesForFoundationBonus: 36,
 			FoundationBonusLastEpoch:       8760,
 		},
+		Staking: Staking{
+			VoteWeightCalConsts: VoteWeightCalConsts{
+				DurationLg: 1.2,
+				AutoStake:  1,
+				SelfStake:  1.06,
+			},
+			SelfStakeThresholdStr:  unit.ConvertIotxToRau(1200000).String(),
+			SelfStakeMinDuration:   91,
+			MaxPayloadSize:         256,
+			MaxBucketsPerVoter:     10000,
+			MaxBucketsPerCandidate: 100000,
+		},
 	}
 }
 
@@ -105,6 +124,7 @@ type (
 		Account    `yaml:"account"`
 		Poll       `yaml:"poll"`
 		Rewarding  `yaml:"rewarding"`
+		Staking    `yaml:"staking"`
 	}
 	// Blockchain contains blockchain level configs
 	Blockchain struct {
@@ -124,6 +144,12 @@ type (
 		NumDelegates uint64 `yaml:"numDelegates"`
 		// NumCandidateDelegates is the number of candidate delegates, who may be selected as a delegate via roll dpos
 		NumCandidateDelegates uint64 `yaml:"numCandidateDelegates"`
+		// NumDelegatesSchedule changes the number of delegates from the given epochs on, in ascending order of epoch.
+		// The length of an epoch changes along with it
+		NumDelegatesSchedule []EpochValue `yaml:"numDelegatesSchedule"`
+		// NumCandidateDelegatesSchedule changes the number of candidate delegates from the given epochs on, in
+		// ascending order of epoch
+		NumCandidateDelegatesSchedule []EpochValue `yaml:"numCandidateDelegatesSchedule"`
 		// TimeBasedRotation is the flag to enable rotating delegates' time slots on a block height
 		TimeBasedRotation bool `yaml:"timeBasedRotation"`
 		// PacificBlockHeight is the start height of using the logic of Pacific version
@@ -141,6 +167,14 @@ type (
 		DaytonaBlockHeight uint64 `yaml:"daytonaBlockHeight"`
 		// EasterBlockHeight is the start height of kick-out for slashing
 		EasterBlockHeight uint64 `yaml:"easterHeight"`
+		// TrieRootIndexHeight is the height from which the root hash of the account trie at a height is written under the
+		// height in 8-byte big endian as well as under the legacy key, 0 means it's always written
+		TrieRootIndexHeight uint64 `yaml:"trieRootIndexHeight"`
+	}
+	// EpochValue is the value of a parameter which takes effect from the epoch on
+	EpochValue struct {
+		Epoch uint64 `yaml:"epoch"`
+		Value uint64 `yaml:"value"`
 	}
 	// Account contains the configs for account protocol
 	Account struct {
@@ -155,6 +189,10 @@ type (
 		GravityChainStartHeight uint64 `yaml:"gravityChainStartHeight"`
 		// GravityChainHeightInterval the height interval on gravity chain to pull delegate information
 		GravityChainHeightInterval uint64 `yaml:"gravityChainHeightInterval"`
+		// GravityChainBlockInterval is the average interval between two blocks on gravity chain
+		GravityChainBlockInterval time.Duration `yaml:"gravityChainBlockInterval"`
+		// GravityChainCeilingHeight is the highest known height on gravity chain, 0 means there is no ceiling
+		GravityChainCeilingHeight uint64 `yaml:"gravityChainCeilingHeight"`
 		// RegisterContractAddress is the address of register contract
 		RegisterContractAddress string `yaml:"registerContractAddress"`
 		// StakingContractAddress is the address of staking contract
@@ -177,6 +215,79 @@ type (
 		KickoutIntensityRate float64 `yaml:"kickoutIntensityRate"`
 		// UnproductiveDelegateMaxCacheSize is a max cache size of upd which is stored into state DB (kickoutEpochPeriod <= UnproductiveDelegateMaxCacheSize)
 		UnproductiveDelegateMaxCacheSize uint64 `yaml:unproductiveDelegateMaxCacheSize`
+		// ProductivityKickoutHeight is the height from which the blocks produced by each delegate are counted in the
+		// state, and the delegates whose productivity in the previous epoch is lower than the productivity threshold
+		// are excluded from the active block producers. 0 means it is disabled
+		ProductivityKickoutHeight uint64 `yaml:"productivityKickoutHeight"`
+		// DelegateShortagePolicy is the behavior when there are fewer block producers than NumDelegates, which is
+		// "shrink" to produce blocks with the available ones, "error" to refuse to compute the active block producers,
+		// or "pad" to fill the slots by repeating the block producers round-robin
+		DelegateShortagePolicy string `yaml:"delegateShortagePolicy"`
+		// StrictPollResultHeight is the height from which a PutPollResult must target the next epoch and carry
+		// exactly the serialized candidate list calculated locally. 0 means it is disabled
+		StrictPollResultHeight uint64 `yaml:"strictPollResultHeight"`
+		// EpochSnapshotHeight is the height from which the active block producers and the candidates of each epoch
+		// are persisted at the last block of the epoch. 0 means it is disabled
+		EpochSnapshotHeight uint64 `yaml:"epochSnapshotHeight"`
+		// EpochSnapshotRetention is the number of recent epochs whose active block producers are kept in the state
+		// for historical queries, 0 means all of them are kept
+		EpochSnapshotRetention uint64 `yaml:"epochSnapshotRetention"`
+		// GovernanceActivationHeight is the height from which the life long delegates hand over the poll to the
+		// governance chain committee. The first epoch starting at or after it is served by the governance chain
+		// committee. 0 means the poll protocol is decided by EnableGravityChainVoting alone
+		GovernanceActivationHeight uint64 `yaml:"governanceActivationHeight"`
+		// ProbationListHeight is the height from which the probation list of an epoch is derived from the list of the
+		// last epoch and its unproductive delegates, and the voting power of a delegate on probation is cut according
+		// to its offense count. 0 means it is disabled
+		ProbationListHeight uint64 `yaml:"probationListHeight"`
+		// ProbationIntensityStep is the percentage of the voting power further cut for each repeated offense
+		ProbationIntensityStep uint32 `yaml:"probationIntensityStep"`
+		// ProbationMaxCount is the highest offense count of a delegate on probation, 0 means there is no limit
+		ProbationMaxCount uint32 `yaml:"probationMaxCount"`
+		// ProbationEscalationHeight is the height from which the voting power of a delegate on probation is cut
+		// according to its consecutive unproductive epochs instead of its offense count, so that a productive epoch
+		// resets the escalation. 0 means it is disabled
+		ProbationEscalationHeight uint64 `yaml:"probationEscalationHeight"`
+		// ProbationIntensityCap is the highest percentage of the voting power cut by the escalation on top of the
+		// kick-out intensity rate, 0 means there is no limit
+		ProbationIntensityCap uint32 `yaml:"probationIntensityCap"`
+		// DoubleSignEvidenceHeight is the height from which the evidence of a block producer signing two different
+		// blocks at the same height and round is accepted, and the offender is put on probation. It takes effect only
+		// with the probation list. 0 means it is disabled
+		DoubleSignEvidenceHeight uint64 `yaml:"doubleSignEvidenceHeight"`
+		// DoubleSignProbationCount is the offense count given to a double signing block producer, which keeps it on
+		// probation for as many epochs
+		DoubleSignProbationCount uint32 `yaml:"doubleSignProbationCount"`
+		// ElectableVoteThresholdStr is the minimum votes in decimal string format for a candidate to be elected as a
+		// delegate, no matter how it ranks
+		ElectableVoteThresholdStr string `yaml:"electableVoteThreshold"`
+		// StakingHybridActivationHeight is the height from which the votes of the candidates on the native staking
+		// protocol are added to their votes on the gravity chain. 0 means it is disabled
+		StakingHybridActivationHeight uint64 `yaml:"stakingHybridActivationHeight"`
+		// StakingHybridDeactivationHeight is the height from which the native staking votes are no longer merged, 0
+		// means they are merged ever since the activation
+		StakingHybridDeactivationHeight uint64 `yaml:"stakingHybridDeactivationHeight"`
+		// DelegateFilterAdmin is the address allowed to change the allow and deny lists of the life long delegates, empty
+		// means the lists can't be changed
+		DelegateFilterAdmin string `yaml:"delegateFilterAdmin"`
+		// DelegateStatsHeight is the height from which the reliability of each delegate is aggregated over the epochs
+		// along with the probation list. It takes effect only with the probation list. 0 means it is disabled
+		DelegateStatsHeight uint64 `yaml:"delegateStatsHeight"`
+		// DelegateStatsRetention is the number of epochs for which the statistics of a delegate neither producing
+		// blocks nor on probation are kept, 0 means they are always kept
+		DelegateStatsRetention uint64 `yaml:"delegateStatsRetention"`
+		// SortSeedStrategy is the seed ordering the active block producers of an epoch, which is "constant" for the
+		// fixed seed, or "blockHash" for the hash of the last block of the previous epoch mixed with the epoch height
+		SortSeedStrategy string `yaml:"sortSeedStrategy"`
+		// SortSeedHeight is the height from which the epochs starting at or after it are ordered by the seed of
+		// SortSeedStrategy. 0 means the fixed seed is always used
+		SortSeedHeight uint64 `yaml:"sortSeedHeight"`
+		// DelegateUpdateAdmin is the address allowed to replace the life long delegates, empty means the admin can't
+		// replace them
+		DelegateUpdateAdmin string `yaml:"delegateUpdateAdmin"`
+		// DelegateUpdateQuorum is the number of current life long delegates whose approvals of the same update replace
+		// the life long delegates, 0 means the delegates can't replace them
+		DelegateUpdateQuorum uint64 `yaml:"delegateUpdateQuorum"`
 	}
 	// Delegate defines a delegate with address and votes
 	Delegate struct {
@@ -213,6 +324,45 @@ type (
 		// epoch reward
 		ProductivityThreshold uint64 `yaml:"productivityThreshold"`
 	}
+	// Staking contains the configs for staking protocol
+	Staking struct {
+		// VoteWeightCalConsts is the set of constants used to calculate the weighted votes of a bucket
+		VoteWeightCalConsts VoteWeightCalConsts `yaml:"voteWeightCalConsts"`
+		// SelfStakeThresholdStr is the minimum amount of a candidate's self-stake bucket in decimal string format
+		SelfStakeThresholdStr string `yaml:"selfStakeThreshold"`
+		// SelfStakeMinDuration is the minimum staked duration in days of a candidate's self-stake bucket
+		SelfStakeMinDuration uint32 `yaml:"selfStakeMinDuration"`
+		// RewardAddressUniqueHeight is the height from which a reward address can't be shared by candidates
+		RewardAddressUniqueHeight uint64 `yaml:"rewardAddressUniqueHeight"`
+		// ActivationHeight is the height at which native staking is activated and the legacy buckets on the gravity
+		// chain are imported, 0 means there is nothing to import
+		ActivationHeight uint64 `yaml:"activationHeight"`
+		// MaxPayloadSize is the maximum size in bytes of the payload of a staking action, including the recipient in
+		// the payload of a withdrawal
+		MaxPayloadSize uint32 `yaml:"maxPayloadSize"`
+		// BucketIndexReceiptHeight is the height from which the receipt of an action creating a bucket, or registering
+		// a candidate, carries the log of the bucket index
+		BucketIndexReceiptHeight uint64 `yaml:"bucketIndexReceiptHeight"`
+		// MaxBucketsPerVoter is the maximum number of buckets owned by a voter
+		MaxBucketsPerVoter uint64 `yaml:"maxBucketsPerVoter"`
+		// MaxBucketsPerCandidate is the maximum number of buckets voting for a candidate
+		MaxBucketsPerCandidate uint64 `yaml:"maxBucketsPerCandidate"`
+		// WithdrawRecipientHeight is the height from which the payload of a withdrawal is parsed as the address of the
+		// recipient of the withdrawn amount, and a withdrawal with a malformed payload fails
+		WithdrawRecipientHeight uint64 `yaml:"withdrawRecipientHeight"`
+		// VersionedStateHeight is the height from which the buckets and the delegates are written with a version
+		// prefix, and the ones read by an action are upgraded, 0 means they are always written in version 1
+		VersionedStateHeight uint64 `yaml:"versionedStateHeight"`
+	}
+	// VoteWeightCalConsts contains the configs for calculating vote weight
+	VoteWeightCalConsts struct {
+		// DurationLg is the base of the logarithm applied to the staked duration
+		DurationLg float64 `yaml:"durationLg"`
+		// AutoStake is the bonus factor of the staked duration if auto-stake is on
+		AutoStake float64 `yaml:"autoStake"`
+		// SelfStake is the multiplier of the weighted votes of a candidate's self-stake bucket
+		SelfStake float64 `yaml:"selfStake"`
+	}
 )
 
 // New constructs a genesis config. It loads the default values, and could be overwritten by values defined in the yaml
@@ -364,6 +514,19 @@ func (d *Delegate) Votes() *big.Int {
 	return val
 }
 
+// ElectableVoteThreshold returns the minimum votes of a candidate to be elected as a delegate, a missing threshold is
+// treated as zero
+func (p *Poll) ElectableVoteThreshold() *big.Int {
+	if p.ElectableVoteThresholdStr == "" {
+		return big.NewInt(0)
+	}
+	val, ok := big.NewInt(0).SetString(p.ElectableVoteThresholdStr, 10)
+	if !ok {
+		log.S().Panicf("Error when casting electable vote threshold string %s into big int", p.ElectableVoteThresholdStr)
+	}
+	return val
+}
+
 // InitBalance returns the init balance of the rewarding fund
 func (r *Rewarding) InitBalance() *big.Int {
 	val, ok := big.NewInt(0).SetString(r.InitBalanceStr, 10)
@@ -430,3 +593,12 @@ func (r *Rewarding) FoundationBonus() *big.Int {
 	}
 	return val
 }
+
+// SelfStakeThreshold returns the minimum amount of a candidate's self-stake bucket
+func (s *Staking) SelfStakeThreshold() *big.Int {
+	val, ok := big.NewInt(0).SetString(s.SelfStakeThresholdStr, 10)
+	if !ok {
+		log.S().Panicf("Error when casting self-stake threshold string %s into big int", s.SelfStakeThresholdStr)
+	}
+	return val
+}
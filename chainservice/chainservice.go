@@ -8,6 +8,7 @@ package chainservice
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/iotexproject/iotex-core/action/protocol/poll"
 	"github.com/iotexproject/iotex-core/action/protocol/rewarding"
 	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
+	"github.com/iotexproject/iotex-core/action/protocol/staking"
 	"github.com/iotexproject/iotex-core/action/protocol/vote/candidatesutil"
 	"github.com/iotexproject/iotex-core/actpool"
 	"github.com/iotexproject/iotex-core/api"
@@ -132,16 +134,7 @@ func New(
 		committeeConfig.SelfStakingThreshold = cfg.Genesis.SelfStakingThreshold
 
 		if committeeConfig.GravityChainStartHeight != 0 {
-			arch, err := committee.NewArchive(
-				cfg.Chain.GravityChainDB.DbPath,
-				cfg.Chain.GravityChainDB.NumRetries,
-				committeeConfig.GravityChainStartHeight,
-				committeeConfig.GravityChainHeightInterval,
-			)
-			if err != nil {
-				return nil, err
-			}
-			if electionCommittee, err = committee.NewCommittee(arch, committeeConfig); err != nil {
+			if electionCommittee, err = newElectionCommittee(cfg, committeeConfig); err != nil {
 				return nil, err
 			}
 		}
@@ -199,12 +192,14 @@ func New(
 	}
 	var rDPoSProtocol *rolldpos.Protocol
 	var pollProtocol poll.Protocol
+	var stakingProtocol *staking.Protocol
 	if cfg.Consensus.Scheme == config.RollDPoSScheme {
 		rDPoSProtocol = rolldpos.NewProtocol(
 			cfg.Genesis.NumCandidateDelegates,
 			cfg.Genesis.NumDelegates,
 			cfg.Genesis.NumSubEpochs,
 			rolldpos.EnableDardanellesSubEpoch(cfg.Genesis.DardanellesBlockHeight, cfg.Genesis.DardanellesNumSubEpochs),
+			rolldpos.EnableCommitteeSchedule(cfg.Genesis.NumDelegatesSchedule, cfg.Genesis.NumCandidateDelegatesSchedule),
 		)
 		copts = append(copts, consensus.WithRollDPoSProtocol(rDPoSProtocol))
 		pollProtocol, err = poll.NewProtocol(
@@ -251,6 +246,16 @@ func New(
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to generate poll protocol")
 		}
+		if pollProtocol != nil && cfg.Genesis.StakingHybridActivationHeight != 0 {
+			// the native candidates are read from the states of the staking protocol, which has to be registered
+			stakingProtocol = staking.NewProtocol(
+				staking.WithStateMetrics(sf, cfg.Genesis.NumDelegates*cfg.Genesis.NumSubEpochs),
+			)
+			pollProtocol, err = poll.NewStakingHybridProtocol(pollProtocol, staking.ActiveCandidates, sf)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to generate staking hybrid poll protocol")
+			}
+		}
 		if pollProtocol != nil {
 			copts = append(copts, consensus.WithPollProtocol(pollProtocol))
 		}
@@ -332,6 +337,18 @@ func New(
 		if err = pollProtocol.Register(registry); err != nil {
 			return nil, err
 		}
+		// the cached candidates are dropped once the blocks changing them are committed
+		if err = chain.AddSubscriber(pollProtocol); err != nil {
+			return nil, errors.Wrap(err, "failed to subscribe poll protocol to blocks")
+		}
+	}
+	if stakingProtocol != nil {
+		if err = stakingProtocol.Register(registry); err != nil {
+			return nil, err
+		}
+		if err = chain.AddSubscriber(stakingProtocol); err != nil {
+			return nil, errors.Wrap(err, "failed to subscribe staking protocol to blocks")
+		}
 	}
 	if executionProtocol != nil {
 		if err = executionProtocol.Register(registry); err != nil {
@@ -358,6 +375,54 @@ func New(
 	}, nil
 }
 
+// newElectionCommittee creates the election committee reading the gravity chain. With a list of gravity chain
+// endpoints, a committee with its own archive is created for each endpoint, and the calls fail over among them.
+func newElectionCommittee(cfg config.Config, committeeConfig committee.Config) (committee.Committee, error) {
+	endpoints := cfg.Chain.GravityChainEndpoints
+	if len(endpoints) == 0 {
+		arch, err := committee.NewArchive(
+			cfg.Chain.GravityChainDB.DbPath,
+			cfg.Chain.GravityChainDB.NumRetries,
+			committeeConfig.GravityChainStartHeight,
+			committeeConfig.GravityChainHeightInterval,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return committee.NewCommittee(arch, committeeConfig)
+	}
+	committees := make([]committee.Committee, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		dbPath := cfg.Chain.GravityChainDB.DbPath
+		if i > 0 {
+			// the first endpoint keeps the archive of a single endpoint
+			dbPath = fmt.Sprintf("%s.%d", dbPath, i)
+		}
+		arch, err := committee.NewArchive(
+			dbPath,
+			cfg.Chain.GravityChainDB.NumRetries,
+			committeeConfig.GravityChainStartHeight,
+			committeeConfig.GravityChainHeightInterval,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create archive of gravity chain endpoint %s", endpoint)
+		}
+		endpointConfig := committeeConfig
+		endpointConfig.GravityChainAPIs = []string{endpoint}
+		c, err := committee.NewCommittee(arch, endpointConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create committee of gravity chain endpoint %s", endpoint)
+		}
+		committees = append(committees, c)
+	}
+	return poll.NewFailoverCommittee(
+		endpoints,
+		committees,
+		cfg.Chain.GravityChainCallTimeout,
+		cfg.Chain.GravityChainEndpointBackoff,
+	)
+}
+
 // Start starts the server
 func (cs *ChainService) Start(ctx context.Context) error {
 	if cs.electionCommittee != nil {
@@ -423,6 +488,7 @@ func (cs *ChainService) HandleAction(ctx context.Context, actPb *iotextypes.Acti
 	if err := act.LoadProto(actPb); err != nil {
 		return err
 	}
+	ctx = protocol.WithRegistry(ctx, cs.registry)
 	ctx = protocol.WithBlockchainCtx(ctx, protocol.BlockchainCtx{Registry: cs.registry})
 	err := cs.actpool.Add(ctx, act)
 	if err != nil {
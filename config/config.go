@@ -121,8 +121,13 @@ var (
 			AllowedBlockGasResidue:        10000,
 			MaxCacheSize:                  0,
 			PollInitialCandidatesInterval: 10 * time.Second,
+			PollCacheSize:                 32,
+			GravityChainCallTimeout:       10 * time.Second,
+			GravityChainEndpointBackoff:   time.Minute,
 			WorkingSetCacheSize:           20,
 			EnableArchiveMode:             false,
+			ActionTimeout:                 5 * time.Second,
+			SlowFlushThreshold:            time.Second,
 		},
 		ActPool: ActPool{
 			MaxNumActsPerPool:  32000,
@@ -246,8 +251,36 @@ type (
 		MaxCacheSize int `yaml:"maxCacheSize"`
 		// PollInitialCandidatesInterval is the config for committee init db
 		PollInitialCandidatesInterval time.Duration `yaml:"pollInitialCandidatesInterval"`
+		// PollCacheSize is the max number of candidate lists of the tip epoch cached by the poll protocol
+		PollCacheSize int `yaml:"pollCacheSize"`
+		// GravityChainEndpoints is the list of gravity chain endpoints the governance poll protocol fails over among
+		// in order. If empty, the committee reads the gravity chain through the APIs in its own config
+		GravityChainEndpoints []string `yaml:"gravityChainEndpoints"`
+		// GravityChainCallTimeout is the timeout of a call to a gravity chain endpoint, 0 means there is no timeout
+		GravityChainCallTimeout time.Duration `yaml:"gravityChainCallTimeout"`
+		// GravityChainEndpointBackoff is the period a failing gravity chain endpoint is skipped for
+		GravityChainEndpointBackoff time.Duration `yaml:"gravityChainEndpointBackoff"`
 		// WorkingSetCacheSize is the max size of workingset cache in state factory
 		WorkingSetCacheSize uint64 `yaml:workingSetCacheSize`
+		// ActionTimeout is the timeout of running an action when producing a block or simulating the action, 0 means
+		// there is no timeout. The actions of a block being validated are never timed out.
+		ActionTimeout time.Duration `yaml:"actionTimeout"`
+		// WorkingSetBufferLimit is the max size in bytes of the writes buffered by the working set when producing a block,
+		// 0 means there is no limit. The action exceeding it fails, and its writes are reverted.
+		WorkingSetBufferLimit uint64 `yaml:"workingSetBufferLimit"`
+		// TrieNodeCacheSize is the max number of the trie nodes cached by the state factory, and TrieNodeCacheBytes is the
+		// max size of them in bytes. The cache is disabled if both are 0.
+		TrieNodeCacheSize  uint64 `yaml:"trieNodeCacheSize"`
+		TrieNodeCacheBytes uint64 `yaml:"trieNodeCacheBytes"`
+		// TrieDBWALPath is the path of the write-ahead log of the trie DB. If it's set, the state factory commits the
+		// blocks to the log, which are applied to the trie DB in the background.
+		TrieDBWALPath string `yaml:"trieDBWALPath"`
+		// SlowFlushThreshold is the duration beyond which a flush of the state factory is logged as a warning, along
+		// with its writes by namespace. 0 means no flush is logged.
+		SlowFlushThreshold time.Duration `yaml:"slowFlushThreshold"`
+		// TrieCompactNodes writes the trie nodes in the compact format, which saves space on disk. The nodes written
+		// before are still read, so it may be turned on or off for an existing DB.
+		TrieCompactNodes bool `yaml:"trieCompactNodes"`
 	}
 
 	// Consensus is the config struct for consensus package
@@ -10,6 +10,7 @@ import (
 	"context"
 
 	"github.com/facebookgo/clock"
+	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
@@ -112,17 +113,28 @@ func NewConsensus(
 				}
 				tipHeight := bc.TipHeight()
 				ctx := protocol.WithBlockchainCtx(
-					context.Background(),
+					protocol.WithRegistry(context.Background(), re),
 					protocol.BlockchainCtx{
 						Registry: re,
 						Genesis:  cfg.Genesis,
 						Tip: protocol.TipInfo{
 							Height: tipHeight,
 						},
+						GetBlockHash: func(height uint64) (hash.Hash256, error) {
+							return blockchain.BlockHashByHeight(bc, height)
+						},
 					},
 				)
 				candidatesList, err := ops.pp.DelegatesByEpoch(ctx, epochNum)
 				if err != nil {
+					if errors.Cause(err) == poll.ErrNoElectedDelegates {
+						// no block is produced or validated until the delegates are elected
+						log.Logger("consensus").Error(
+							"Consensus is halted.",
+							zap.Uint64("epoch", epochNum),
+							zap.Error(err),
+						)
+					}
 					return nil, err
 				}
 				addrs := []string{}
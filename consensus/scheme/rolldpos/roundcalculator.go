@@ -248,7 +248,7 @@ func (c *roundCalculator) calculateProposer(
 	round uint32,
 	delegates []string,
 ) (proposer string, err error) {
-	numDelegates := c.rp.NumDelegates()
+	numDelegates := c.rp.NumDelegatesAt(c.rp.GetEpochNum(height))
 	if numDelegates != uint64(len(delegates)) {
 		err = errors.New("invalid delegate list")
 		return
@@ -41,6 +41,8 @@ type (
 		Put(string, []byte, []byte, string, ...interface{})
 		// Delete deletes a record by (namespace, key)
 		Delete(string, []byte, string, ...interface{})
+		// DeletePrefix deletes the records whose keys start with the prefix in the namespace
+		DeletePrefix(string, []byte, string, ...interface{})
 		// Size returns the size of batch
 		Size() int
 		// Entry returns the entry at the index
@@ -63,5 +65,7 @@ type (
 		Snapshot() int
 		// Revert sets the cached batch to the state at the given snapshot
 		Revert(int) error
+		// SizeInBytes returns the total size of the keys and the values in the batch
+		SizeInBytes() int
 	}
 )
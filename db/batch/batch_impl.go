@@ -7,6 +7,7 @@
 package batch
 
 import (
+	"bytes"
 	"sync"
 
 	"github.com/iotexproject/go-pkgs/hash"
@@ -18,16 +19,24 @@ type (
 	baseKVStoreBatch struct {
 		mutex      sync.RWMutex
 		writeQueue []*WriteInfo
+		// sizeInBytes is the total size of the keys and the values in the write queue
+		sizeInBytes int
 	}
 
 	// cachedBatch implements the CachedBatch interface
 	cachedBatch struct {
 		lock sync.RWMutex
-		KVStoreCache
+		*kvCache
 		kvStoreBatch *baseKVStoreBatch
-		tag          int            // latest snapshot + 1
-		batchShots   []int          // snapshots of batch are merely size of write queue at time of snapshot
-		cacheShots   []KVStoreCache // snapshots of cache
+		tag          int   // latest snapshot + 1
+		batchShots   []int // snapshots of batch are merely size of write queue at time of snapshot
+		// journal is the states in the cache of the keys before they're written, since the first snapshot. Revert
+		// restores them backwards to the snapshot, so that a snapshot doesn't copy the cache.
+		journal      []cacheEntry
+		journalShots []int // snapshots of cache are merely size of journal at time of snapshot
+		// prefixDeletes are the prefix deletes in the write queue, which mask the keys not in the cache
+		prefixDeletes []*WriteInfo
+		prefixShots   []int // snapshots of prefix deletes are merely size of them at time of snapshot
 	}
 )
 
@@ -54,6 +63,7 @@ func (b *baseKVStoreBatch) Unlock() {
 func (b *baseKVStoreBatch) ClearAndUnlock() {
 	defer b.mutex.Unlock()
 	b.writeQueue = nil
+	b.sizeInBytes = 0
 }
 
 // Put inserts a <key, value> record
@@ -70,11 +80,23 @@ func (b *baseKVStoreBatch) Delete(namespace string, key []byte, errorFormat stri
 	b.batch(Delete, namespace, key, nil, errorFormat, errorArgs)
 }
 
+// DeletePrefix deletes the records whose keys start with the prefix
+func (b *baseKVStoreBatch) DeletePrefix(namespace string, prefix []byte, errorFormat string, errorArgs ...interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.batch(DeletePrefix, namespace, prefix, nil, errorFormat, errorArgs)
+}
+
 // Size returns the size of batch
 func (b *baseKVStoreBatch) Size() int {
 	return len(b.writeQueue)
 }
 
+// SizeInBytes returns the total size of the keys and the values in the batch
+func (b *baseKVStoreBatch) SizeInBytes() int {
+	return b.sizeInBytes
+}
+
 // Entry returns the entry at the index
 func (b *baseKVStoreBatch) Entry(index int) (*WriteInfo, error) {
 	if index < 0 || index >= len(b.writeQueue) {
@@ -88,14 +110,19 @@ func (b *baseKVStoreBatch) SerializeQueue(filter WriteInfoFilter) []byte {
 	defer b.mutex.Unlock()
 	// 1. This could be improved by being processed in parallel
 	// 2. Digest could be replaced by merkle root if we need proof
-	bytes := make([]byte, 0)
+	ser := make([]byte, 0)
 	for _, wi := range b.writeQueue {
 		if filter != nil && filter(wi) {
 			continue
 		}
-		bytes = append(bytes, wi.SerializeWithoutWriteType()...)
+		if wi.writeType == DeletePrefix {
+			// a prefix delete is serialized along with its type, so that it's told from a delete of the prefix
+			ser = append(ser, wi.Serialize()...)
+			continue
+		}
+		ser = append(ser, wi.SerializeWithoutWriteType()...)
 	}
-	return bytes
+	return ser
 }
 
 // Clear clear write queue
@@ -103,6 +130,7 @@ func (b *baseKVStoreBatch) Clear() {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 	b.writeQueue = nil
+	b.sizeInBytes = 0
 }
 
 func (b *baseKVStoreBatch) Translate(wit WriteInfoTranslate) KVStoreBatch {
@@ -110,7 +138,8 @@ func (b *baseKVStoreBatch) Translate(wit WriteInfoTranslate) KVStoreBatch {
 	defer b.mutex.Unlock()
 	if wit == nil {
 		c := &baseKVStoreBatch{
-			writeQueue: make([]*WriteInfo, b.Size()),
+			writeQueue:  make([]*WriteInfo, b.Size()),
+			sizeInBytes: b.sizeInBytes,
 		}
 		// clone the writeQueue
 		copy(c.writeQueue, b.writeQueue)
@@ -123,6 +152,7 @@ func (b *baseKVStoreBatch) Translate(wit WriteInfoTranslate) KVStoreBatch {
 		newWi := wit(wi)
 		if newWi != nil {
 			c.writeQueue = append(c.writeQueue, newWi)
+			c.sizeInBytes += newWi.sizeInBytes()
 		}
 	}
 
@@ -131,20 +161,23 @@ func (b *baseKVStoreBatch) Translate(wit WriteInfoTranslate) KVStoreBatch {
 
 // batch puts an entry into the write queue
 func (b *baseKVStoreBatch) batch(op WriteType, namespace string, key, value []byte, errorFormat string, errorArgs ...interface{}) {
-	b.writeQueue = append(
-		b.writeQueue,
-		&WriteInfo{
-			writeType:   op,
-			namespace:   namespace,
-			key:         key,
-			value:       value,
-			errorFormat: errorFormat,
-			errorArgs:   errorArgs,
-		})
+	wi := &WriteInfo{
+		writeType:   op,
+		namespace:   namespace,
+		key:         key,
+		value:       value,
+		errorFormat: errorFormat,
+		errorArgs:   errorArgs,
+	}
+	b.writeQueue = append(b.writeQueue, wi)
+	b.sizeInBytes += wi.sizeInBytes()
 }
 
 // truncate the write queue
 func (b *baseKVStoreBatch) truncate(size int) {
+	for _, wi := range b.writeQueue[size:] {
+		b.sizeInBytes -= wi.sizeInBytes()
+	}
 	b.writeQueue = b.writeQueue[:size]
 }
 
@@ -156,9 +189,9 @@ func (b *baseKVStoreBatch) truncate(size int) {
 func NewCachedBatch() CachedBatch {
 	return &cachedBatch{
 		kvStoreBatch: newBaseKVStoreBatch(),
-		KVStoreCache: NewKVCache(),
+		kvCache:      NewKVCache().(*kvCache),
 		batchShots:   make([]int, 0),
-		cacheShots:   make([]KVStoreCache, 0),
+		journalShots: make([]int, 0),
 	}
 }
 
@@ -178,6 +211,12 @@ func (cb *cachedBatch) Size() int {
 	return cb.kvStoreBatch.Size()
 }
 
+// SizeInBytes returns the total size of the keys and the values in the batch. An overwrite or a delete of a key adds to
+// the size, as the earlier writes of the key stay in the batch, and the writes reverted are subtracted.
+func (cb *cachedBatch) SizeInBytes() int {
+	return cb.kvStoreBatch.SizeInBytes()
+}
+
 // Lock locks the batch
 func (cb *cachedBatch) Lock() {
 	cb.lock.Lock()
@@ -191,14 +230,16 @@ func (cb *cachedBatch) Unlock() {
 // ClearAndUnlock clears the write queue and unlocks the batch
 func (cb *cachedBatch) ClearAndUnlock() {
 	defer cb.lock.Unlock()
-	cb.KVStoreCache.Clear()
+	cb.kvCache.Clear()
 	cb.kvStoreBatch.Clear()
 	// clear all saved snapshots
 	cb.tag = 0
 	cb.batchShots = nil
-	cb.cacheShots = nil
 	cb.batchShots = make([]int, 0)
-	cb.cacheShots = make([]KVStoreCache, 0)
+	cb.journal = nil
+	cb.journalShots = make([]int, 0)
+	cb.prefixDeletes = nil
+	cb.prefixShots = nil
 }
 
 // Put inserts a <key, value> record
@@ -206,6 +247,7 @@ func (cb *cachedBatch) Put(namespace string, key, value []byte, errorFormat stri
 	cb.lock.Lock()
 	defer cb.lock.Unlock()
 	h := cb.hash(namespace, key)
+	cb.record(h)
 	cb.Write(h, value)
 	cb.kvStoreBatch.batch(Put, namespace, key, value, errorFormat, errorArgs)
 }
@@ -215,22 +257,41 @@ func (cb *cachedBatch) Delete(namespace string, key []byte, errorFormat string,
 	cb.lock.Lock()
 	defer cb.lock.Unlock()
 	h := cb.hash(namespace, key)
+	cb.record(h)
 	cb.Evict(h)
 	cb.kvStoreBatch.batch(Delete, namespace, key, nil, errorFormat, errorArgs)
 }
 
+// DeletePrefix deletes the records whose keys start with the prefix. The keys written in the batch are evicted from the
+// cache, and the others are masked by the prefix until they're written again.
+func (cb *cachedBatch) DeletePrefix(namespace string, prefix []byte, errorFormat string, errorArgs ...interface{}) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	for _, wi := range cb.kvStoreBatch.writeQueue {
+		if wi.writeType != DeletePrefix && wi.namespace == namespace && bytes.HasPrefix(wi.key, prefix) {
+			h := cb.hash(namespace, wi.key)
+			cb.record(h)
+			cb.Evict(h)
+		}
+	}
+	cb.kvStoreBatch.batch(DeletePrefix, namespace, prefix, nil, errorFormat, errorArgs)
+	cb.prefixDeletes = append(cb.prefixDeletes, cb.kvStoreBatch.writeQueue[cb.kvStoreBatch.Size()-1])
+}
+
 // Clear clear the cached batch buffer
 func (cb *cachedBatch) Clear() {
 	cb.lock.Lock()
 	defer cb.lock.Unlock()
-	cb.KVStoreCache.Clear()
+	cb.kvCache.Clear()
 	cb.kvStoreBatch.Clear()
 	// clear all saved snapshots
 	cb.tag = 0
 	cb.batchShots = nil
-	cb.cacheShots = nil
 	cb.batchShots = make([]int, 0)
-	cb.cacheShots = make([]KVStoreCache, 0)
+	cb.journal = nil
+	cb.journalShots = make([]int, 0)
+	cb.prefixDeletes = nil
+	cb.prefixShots = nil
 }
 
 // Get retrieves a record
@@ -238,7 +299,30 @@ func (cb *cachedBatch) Get(namespace string, key []byte) ([]byte, error) {
 	cb.lock.RLock()
 	defer cb.lock.RUnlock()
 	h := cb.hash(namespace, key)
-	return cb.Read(h)
+	value, err := cb.Read(h)
+	if err == ErrNotExist && cb.prefixDeleted(namespace, key) {
+		return nil, ErrAlreadyDeleted
+	}
+	return value, err
+}
+
+// prefixDeleted returns true if the key is masked by a prefix delete in the batch
+func (cb *cachedBatch) prefixDeleted(namespace string, key []byte) bool {
+	for _, wi := range cb.prefixDeletes {
+		if wi.namespace == namespace && bytes.HasPrefix(key, wi.key) {
+			return true
+		}
+	}
+	return false
+}
+
+// record appends the state of the key in the cache to the journal before the key is written, unless there is no
+// snapshot to revert to
+func (cb *cachedBatch) record(h hash.Hash160) {
+	if cb.tag == 0 {
+		return
+	}
+	cb.journal = append(cb.journal, cb.kvCache.entry(h))
 }
 
 // Snapshot takes a snapshot of current cached batch
@@ -246,9 +330,10 @@ func (cb *cachedBatch) Snapshot() int {
 	cb.lock.Lock()
 	defer cb.lock.Unlock()
 	defer func() { cb.tag++ }()
-	// save a copy of current batch/cache
+	// save the sizes of current batch/journal
 	cb.batchShots = append(cb.batchShots, cb.kvStoreBatch.Size())
-	cb.cacheShots = append(cb.cacheShots, cb.KVStoreCache.Clone())
+	cb.journalShots = append(cb.journalShots, len(cb.journal))
+	cb.prefixShots = append(cb.prefixShots, len(cb.prefixDeletes))
 	return cb.tag
 }
 
@@ -263,9 +348,16 @@ func (cb *cachedBatch) Revert(snapshot int) error {
 	cb.tag = snapshot + 1
 	cb.batchShots = cb.batchShots[:cb.tag]
 	cb.kvStoreBatch.truncate(cb.batchShots[snapshot])
-	cb.cacheShots = cb.cacheShots[:cb.tag]
-	cb.KVStoreCache = nil
-	cb.KVStoreCache = cb.cacheShots[snapshot]
+	cb.journalShots = cb.journalShots[:cb.tag]
+	// the keys written after the snapshot are restored in reverse order, and the journal before it is kept, so that
+	// it can be reverted to again
+	size := cb.journalShots[snapshot]
+	for i := len(cb.journal) - 1; i >= size; i-- {
+		cb.kvCache.restore(cb.journal[i])
+	}
+	cb.journal = cb.journal[:size]
+	cb.prefixShots = cb.prefixShots[:cb.tag]
+	cb.prefixDeletes = cb.prefixDeletes[:cb.prefixShots[snapshot]]
 	return nil
 }
 
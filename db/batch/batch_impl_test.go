@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/iotexproject/go-pkgs/hash"
@@ -193,6 +194,317 @@ func TestSnapshot(t *testing.T) {
 	require.Equal(ErrNotExist, err)
 }
 
+func TestCachedBatchSizeInBytes(t *testing.T) {
+	require := require.New(t)
+
+	cb := NewCachedBatch()
+	require.Equal(0, cb.SizeInBytes())
+	k, v1, v2 := []byte("key"), []byte("value"), []byte("longer value")
+	cb.Put(bucket1, k, v1, "")
+	require.Equal(len(k)+len(v1), cb.SizeInBytes())
+	s0 := cb.Snapshot()
+	// the overwrite and the delete are appended to the batch
+	cb.Put(bucket1, k, v2, "")
+	require.Equal(2*len(k)+len(v1)+len(v2), cb.SizeInBytes())
+	s1 := cb.Snapshot()
+	cb.Delete(bucket1, k, "")
+	require.Equal(3*len(k)+len(v1)+len(v2), cb.SizeInBytes())
+	cb.Put(bucket1, []byte("k"), nil, "")
+	require.Equal(3*len(k)+len(v1)+len(v2)+1, cb.SizeInBytes())
+
+	// the writes reverted are subtracted
+	require.NoError(cb.Revert(s1))
+	require.Equal(2*len(k)+len(v1)+len(v2), cb.SizeInBytes())
+	cb.Delete(bucket1, k, "")
+	require.Equal(3*len(k)+len(v1)+len(v2), cb.SizeInBytes())
+	require.NoError(cb.Revert(s0))
+	require.Equal(len(k)+len(v1), cb.SizeInBytes())
+	require.Equal(len(k)+len(v1), cb.Translate(nil).(*baseKVStoreBatch).SizeInBytes())
+	require.Equal(0, cb.Translate(func(*WriteInfo) *WriteInfo { return nil }).(*baseKVStoreBatch).SizeInBytes())
+
+	cb.Clear()
+	require.Equal(0, cb.SizeInBytes())
+	cb.Put(bucket1, k, v1, "")
+	cb.Lock()
+	cb.ClearAndUnlock()
+	require.Equal(0, cb.SizeInBytes())
+}
+
+func TestCachedBatchDeletePrefix(t *testing.T) {
+	require := require.New(t)
+
+	cb := NewCachedBatch()
+	cb.Put(bucket1, []byte("ab1"), testV1[0], "")
+	cb.Put(bucket1, []byte("ab2"), testV1[1], "")
+	cb.Put(bucket1, []byte("ac1"), testV1[2], "")
+	cb.Put("other", []byte("ab1"), testV2[0], "")
+	s0 := cb.Snapshot()
+	cb.DeletePrefix(bucket1, []byte("ab"), "")
+	// the keys written and the ones not in the batch are masked, but those written again after the delete
+	for _, k := range []string{"ab1", "ab2", "ab3"} {
+		_, err := cb.Get(bucket1, []byte(k))
+		require.Equal(ErrAlreadyDeleted, errors.Cause(err))
+	}
+	cb.Put(bucket1, []byte("ab2"), testV2[1], "")
+	v, err := cb.Get(bucket1, []byte("ab2"))
+	require.NoError(err)
+	require.Equal(testV2[1], v)
+	for k, v := range map[string][]byte{"ac1": testV1[2]} {
+		value, err := cb.Get(bucket1, []byte(k))
+		require.NoError(err)
+		require.Equal(v, value)
+	}
+	v, err = cb.Get("other", []byte("ab1"))
+	require.NoError(err)
+	require.Equal(testV2[0], v)
+	_, err = cb.Get(bucket1, []byte("ad"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	wi, err := cb.Entry(4)
+	require.NoError(err)
+	require.Equal(DeletePrefix, wi.WriteType())
+	require.Equal([]byte("ab"), wi.Key())
+	// the prefix delete is serialized along with its type
+	require.True(bytes.Contains(cb.SerializeQueue(nil), append([]byte{byte(DeletePrefix)}, bucket1+"ab"...)))
+
+	// the prefix delete is reverted along with the writes after it
+	require.NoError(cb.Revert(s0))
+	for k, v := range map[string][]byte{"ab1": testV1[0], "ab2": testV1[1]} {
+		value, err := cb.Get(bucket1, []byte(k))
+		require.NoError(err)
+		require.Equal(v, value)
+	}
+	_, err = cb.Get(bucket1, []byte("ab3"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	cb.DeletePrefix(bucket1, nil, "")
+	_, err = cb.Get(bucket1, []byte("ac1"))
+	require.Equal(ErrAlreadyDeleted, errors.Cause(err))
+	cb.Clear()
+	_, err = cb.Get(bucket1, []byte("ac1"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+}
+
+func TestCachedBatchDeletePrefixFuzz(t *testing.T) {
+	require := require.New(t)
+	seed := rand.Int63()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	// the model is the state of each key written, and the prefixes deleted mask the keys not written after them
+	type model struct {
+		states   map[string][]byte
+		prefixes []string
+	}
+	clone := func(m model) model {
+		c := model{states: map[string][]byte{}, prefixes: append([]string{}, m.prefixes...)}
+		for k, v := range m.states {
+			c.states[k] = v
+		}
+		return c
+	}
+	randomKey := func() string {
+		k := make([]byte, 1+r.Intn(3))
+		for i := range k {
+			k[i] = "ab"[r.Intn(2)]
+		}
+		return string(k)
+	}
+	cb := NewCachedBatch()
+	m := model{states: map[string][]byte{}}
+	var snapshots []int
+	var models []model
+	for i := 0; i < 2000; i++ {
+		switch op := r.Intn(10); {
+		case op < 4:
+			k, v := randomKey(), []byte(strconv.Itoa(i))
+			cb.Put(bucket1, []byte(k), v, "")
+			m.states[k] = v
+		case op < 6:
+			k := randomKey()
+			cb.Delete(bucket1, []byte(k), "")
+			m.states[k] = nil
+		case op < 7:
+			p := randomKey()
+			p = p[:1+r.Intn(len(p))]
+			cb.DeletePrefix(bucket1, []byte(p), "")
+			for k := range m.states {
+				if strings.HasPrefix(k, p) {
+					m.states[k] = nil
+				}
+			}
+			m.prefixes = append(m.prefixes, p)
+		case op < 8:
+			snapshots = append(snapshots, cb.Snapshot())
+			models = append(models, clone(m))
+		case len(snapshots) > 0:
+			i := r.Intn(len(snapshots))
+			require.NoError(cb.Revert(snapshots[i]))
+			m = clone(models[i])
+			snapshots, models = snapshots[:i+1], models[:i+1]
+		}
+		k := randomKey()
+		value, err := cb.Get(bucket1, []byte(k))
+		v, written := m.states[k]
+		switch {
+		case written && v != nil:
+			require.NoError(err)
+			require.Equal(v, value)
+		case written:
+			require.Equal(ErrAlreadyDeleted, errors.Cause(err))
+		default:
+			masked := false
+			for _, p := range m.prefixes {
+				masked = masked || strings.HasPrefix(k, p)
+			}
+			if masked {
+				require.Equal(ErrAlreadyDeleted, errors.Cause(err), "key %s", k)
+			} else {
+				require.Equal(ErrNotExist, errors.Cause(err), "key %s", k)
+			}
+		}
+	}
+}
+
+// cloningCachedBatch is the cached batch taking a snapshot by cloning the cache, which the journal replaces
+type cloningCachedBatch struct {
+	*baseKVStoreBatch
+	cache         KVStoreCache
+	prefixDeletes []*WriteInfo
+	batchShots    []int
+	cacheShots    []KVStoreCache
+	prefixShots   []int
+}
+
+func (cb *cloningCachedBatch) Put(namespace string, key, value []byte) {
+	cb.cache.Write(hash.Hash160b(append([]byte(namespace), key...)), value)
+	cb.batch(Put, namespace, key, value, "")
+}
+
+func (cb *cloningCachedBatch) Delete(namespace string, key []byte) {
+	cb.cache.Evict(hash.Hash160b(append([]byte(namespace), key...)))
+	cb.batch(Delete, namespace, key, nil, "")
+}
+
+func (cb *cloningCachedBatch) DeletePrefix(namespace string, prefix []byte) {
+	for _, wi := range cb.writeQueue {
+		if wi.writeType != DeletePrefix && wi.namespace == namespace && bytes.HasPrefix(wi.key, prefix) {
+			cb.cache.Evict(hash.Hash160b(append([]byte(namespace), wi.key...)))
+		}
+	}
+	cb.batch(DeletePrefix, namespace, prefix, nil, "")
+	cb.prefixDeletes = append(cb.prefixDeletes, cb.writeQueue[len(cb.writeQueue)-1])
+}
+
+func (cb *cloningCachedBatch) Get(namespace string, key []byte) ([]byte, error) {
+	value, err := cb.cache.Read(hash.Hash160b(append([]byte(namespace), key...)))
+	if err != ErrNotExist {
+		return value, err
+	}
+	for _, wi := range cb.prefixDeletes {
+		if wi.namespace == namespace && bytes.HasPrefix(key, wi.key) {
+			return nil, ErrAlreadyDeleted
+		}
+	}
+	return nil, err
+}
+
+func (cb *cloningCachedBatch) Snapshot() int {
+	cb.batchShots = append(cb.batchShots, cb.Size())
+	cb.cacheShots = append(cb.cacheShots, cb.cache.Clone())
+	cb.prefixShots = append(cb.prefixShots, len(cb.prefixDeletes))
+	return len(cb.batchShots) - 1
+}
+
+func (cb *cloningCachedBatch) Revert(snapshot int) {
+	cb.batchShots = cb.batchShots[:snapshot+1]
+	cb.truncate(cb.batchShots[snapshot])
+	cb.cacheShots = cb.cacheShots[:snapshot+1]
+	cb.cache = cb.cacheShots[snapshot].Clone()
+	cb.prefixShots = cb.prefixShots[:snapshot+1]
+	cb.prefixDeletes = cb.prefixDeletes[:cb.prefixShots[snapshot]]
+}
+
+func TestCachedBatchJournalDifferential(t *testing.T) {
+	require := require.New(t)
+	seed := rand.Int63()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	var keys []string
+	for _, k := range []string{"a", "b", "aa", "ab", "ba", "bb"} {
+		keys = append(keys, k, k+"a", k+"b")
+	}
+	namespaces := []string{bucket1, "other"}
+	cb := NewCachedBatch()
+	expected := &cloningCachedBatch{baseKVStoreBatch: newBaseKVStoreBatch(), cache: NewKVCache()}
+	for i := 0; i < 3000; i++ {
+		ns, k := namespaces[r.Intn(len(namespaces))], []byte(keys[r.Intn(len(keys))])
+		switch op := r.Intn(20); {
+		case op < 7:
+			v := []byte(strconv.Itoa(i))
+			cb.Put(ns, k, v, "")
+			expected.Put(ns, k, v)
+		case op < 11:
+			cb.Delete(ns, k, "")
+			expected.Delete(ns, k)
+		case op < 12:
+			k = k[:r.Intn(len(k))]
+			cb.DeletePrefix(ns, k, "")
+			expected.DeletePrefix(ns, k)
+		case op < 16:
+			require.Equal(expected.Snapshot(), cb.Snapshot())
+		case len(expected.batchShots) > 0:
+			// the latest snapshots are reverted to more often, like the nested ones of the actions
+			snapshot := len(expected.batchShots) - 1 - r.Intn(1+r.Intn(len(expected.batchShots)))
+			require.NoError(cb.Revert(snapshot))
+			expected.Revert(snapshot)
+		case r.Intn(10) == 0:
+			cb.Clear()
+			expected = &cloningCachedBatch{baseKVStoreBatch: newBaseKVStoreBatch(), cache: NewKVCache()}
+		}
+		require.Equal(expected.Size(), cb.Size())
+		require.Equal(expected.SizeInBytes(), cb.SizeInBytes())
+		serialized := expected.SerializeQueue(nil)
+		require.Equal(serialized, cb.SerializeQueue(nil))
+		require.Equal(hash.Hash256b(serialized), hash.Hash256b(cb.SerializeQueue(nil)))
+		for _, ns := range namespaces {
+			for _, k := range keys {
+				ev, eerr := expected.Get(ns, []byte(k))
+				v, err := cb.Get(ns, []byte(k))
+				require.Equal(eerr, errors.Cause(err), "op %d, key %s in %s", i, k, ns)
+				require.Equal(ev, v, "op %d, key %s in %s", i, k, ns)
+			}
+		}
+	}
+}
+
+// BenchmarkCachedBatch_NestedSnapshots takes 5k nested snapshots over a batch of 10k entries, with a write after each,
+// and reverts them one by one
+func BenchmarkCachedBatch_NestedSnapshots(b *testing.B) {
+	keys := make([][]byte, 10000)
+	for i := range keys {
+		k := hash.Hash256b([]byte(strconv.Itoa(i)))
+		keys[i] = k[:]
+	}
+	value := make([]byte, 32)
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		cb := NewCachedBatch()
+		for _, k := range keys {
+			cb.Put(bucket1, k, value, "")
+		}
+		b.StartTimer()
+		for i := 0; i < 5000; i++ {
+			cb.Snapshot()
+			cb.Put(bucket1, keys[i], value, "")
+		}
+		for i := 4999; i >= 0; i-- {
+			if err := cb.Revert(i); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
 func BenchmarkCachedBatch_Digest(b *testing.B) {
 	cb := NewCachedBatch()
 
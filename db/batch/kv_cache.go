@@ -32,6 +32,14 @@ type (
 		cache   map[hash.Hash160][]byte // local cache of batched <k, v> for fast query
 		deleted map[hash.Hash160]struct{}
 	}
+
+	// cacheEntry is the state of a key in the cache, which is written, deleted, or neither
+	cacheEntry struct {
+		key     hash.Hash160
+		value   []byte
+		written bool
+		deleted bool
+	}
 )
 
 // NewKVCache returns a KVCache
@@ -84,6 +92,27 @@ func (c *kvCache) Clear() {
 	c.deleted = make(map[hash.Hash160]struct{})
 }
 
+// entry returns the state of the key in the cache
+func (c *kvCache) entry(k hash.Hash160) cacheEntry {
+	v, written := c.cache[k]
+	_, deleted := c.deleted[k]
+	return cacheEntry{key: k, value: v, written: written, deleted: deleted}
+}
+
+// restore sets the key back to the state in the cache
+func (c *kvCache) restore(e cacheEntry) {
+	if e.written {
+		c.cache[e.key] = e.value
+	} else {
+		delete(c.cache, e.key)
+	}
+	if e.deleted {
+		c.deleted[e.key] = struct{}{}
+	} else {
+		delete(c.deleted, e.key)
+	}
+}
+
 // Clone clones the cache
 func (c *kvCache) Clone() KVStoreCache {
 	clone := kvCache{
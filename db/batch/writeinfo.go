@@ -11,6 +11,9 @@ const (
 	Put WriteType = iota
 	// Delete indicate the type of write operation to be Delete
 	Delete
+	// DeletePrefix indicate the type of write operation to be deleting the records whose keys start with the key of
+	// the write
+	DeletePrefix
 )
 
 type (
@@ -89,6 +92,11 @@ func (wi *WriteInfo) ErrorArgs() interface{} {
 	return wi.errorArgs
 }
 
+// sizeInBytes returns the size of the key and the value of the write info
+func (wi *WriteInfo) sizeInBytes() int {
+	return len(wi.key) + len(wi.value)
+}
+
 // Serialize serializes the write info
 func (wi *WriteInfo) Serialize() []byte {
 	bytes := []byte{byte(wi.writeType)}
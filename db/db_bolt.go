@@ -140,6 +140,31 @@ func (b *boltDB) Range(namespace string, key []byte, count uint64) ([][]byte, er
 	return nil, errors.Wrap(ErrIO, err.Error())
 }
 
+// Prefix retrieves the records whose keys start with the prefix, ordered by key
+func (b *boltDB) Prefix(namespace string, prefix []byte) ([][]byte, [][]byte, error) {
+	var keys, values [][]byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(namespace))
+		if bucket == nil {
+			return nil
+		}
+		cur := bucket.Cursor()
+		for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+			key := make([]byte, len(k))
+			copy(key, k)
+			value := make([]byte, len(v))
+			copy(value, v)
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrIO, err.Error())
+	}
+	return keys, values, nil
+}
+
 // GetBucketByPrefix retrieves all bucket those with const namespace prefix
 func (b *boltDB) GetBucketByPrefix(namespace []byte) ([][]byte, error) {
 	allKey := make([][]byte, 0)
@@ -208,6 +233,42 @@ func (b *boltDB) Delete(namespace string, key []byte) (err error) {
 	return err
 }
 
+// DeletePrefix deletes the records whose keys start with the prefix, and returns the number of them
+func (b *boltDB) DeletePrefix(namespace string, prefix []byte) (n int, err error) {
+	for c := uint8(0); c < b.config.NumRetries; c++ {
+		if err = b.db.Update(func(tx *bolt.Tx) error {
+			var e error
+			n, e = deleteBucketPrefix(tx.Bucket([]byte(namespace)), prefix)
+			return e
+		}); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, errors.Wrap(ErrIO, err.Error())
+	}
+	return n, nil
+}
+
+// deleteBucketPrefix deletes the keys with the prefix in the bucket, which may be nil
+func deleteBucketPrefix(bucket *bolt.Bucket, prefix []byte) (int, error) {
+	if bucket == nil {
+		return 0, nil
+	}
+	// the keys are collected first, as deleting at the cursor moves it
+	var keys [][]byte
+	cur := bucket.Cursor()
+	for k, _ := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cur.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+	for _, k := range keys {
+		if err := bucket.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
 // WriteBatch commits a batch
 func (b *boltDB) WriteBatch(kvsb batch.KVStoreBatch) (err error) {
 	succeed := true
@@ -250,6 +311,10 @@ func (b *boltDB) WriteBatch(kvsb batch.KVStoreBatch) (err error) {
 					if e := bucket.Delete(write.Key()); e != nil {
 						return errors.Wrapf(e, errFmt, errArgs)
 					}
+				} else if write.WriteType() == batch.DeletePrefix {
+					if _, e := deleteBucketPrefix(tx.Bucket([]byte(ns)), write.Key()); e != nil {
+						return errors.Wrapf(e, errFmt, errArgs)
+					}
 				}
 			}
 			return nil
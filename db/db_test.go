@@ -12,6 +12,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/pkg/errors"
+
 	"github.com/iotexproject/iotex-core/db/batch"
 	"github.com/iotexproject/iotex-core/testutil"
 
@@ -70,6 +72,144 @@ func TestKVStorePutGet(t *testing.T) {
 
 }
 
+func TestKVStorePrefix(t *testing.T) {
+	testKVStorePrefix := func(kvStore KVStoreWithPrefix, t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+
+		require.NoError(kvStore.Start(ctx))
+		defer func() {
+			require.NoError(kvStore.Stop(ctx))
+		}()
+
+		for _, k := range []string{"ka4", "ka1", "ka3", "kb1"} {
+			require.NoError(kvStore.Put(bucket1, []byte(k), []byte("v"+k)))
+		}
+		require.NoError(kvStore.Put(bucket2, []byte("ka2"), []byte("vka2")))
+		keys, values, err := kvStore.Prefix(bucket1, []byte("ka"))
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("ka1"), []byte("ka3"), []byte("ka4")}, keys)
+		require.Equal([][]byte{[]byte("vka1"), []byte("vka3"), []byte("vka4")}, values)
+		keys, _, err = kvStore.Prefix("test_ns_unknown", []byte("ka"))
+		require.NoError(err)
+		require.Empty(keys)
+
+		// the matches are split between the buffer and the store
+		f, err := NewKVStoreFlusher(kvStore, batch.NewCachedBatch())
+		require.NoError(err)
+		kvb := f.KVStoreWithBuffer().(KVStoreWithPrefix)
+		require.NoError(kvb.Put(bucket1, []byte("ka2"), []byte("new")))
+		require.NoError(kvb.Put(bucket1, []byte("ka3"), []byte("new")))
+		require.NoError(kvb.Delete(bucket1, []byte("ka4")))
+		require.NoError(kvb.Put(bucket1, []byte("kb2"), []byte("new")))
+		require.NoError(kvb.Put(bucket2, []byte("ka5"), []byte("new")))
+		keys, values, err = kvb.Prefix(bucket1, []byte("ka"))
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("ka1"), []byte("ka2"), []byte("ka3")}, keys)
+		require.Equal([][]byte{[]byte("vka1"), []byte("new"), []byte("new")}, values)
+
+		// the last write of a key wins
+		require.NoError(kvb.Put(bucket1, []byte("ka4"), []byte("new")))
+		require.NoError(kvb.Delete(bucket1, []byte("ka1")))
+		keys, _, err = kvb.(KVStoreWithPrefix).Prefix(bucket1, []byte("ka"))
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("ka2"), []byte("ka3"), []byte("ka4")}, keys)
+
+		// the store is the same as the buffer once flushed
+		require.NoError(f.Flush())
+		storeKeys, _, err := kvStore.Prefix(bucket1, []byte("ka"))
+		require.NoError(err)
+		require.Equal(keys, storeKeys)
+	}
+
+	t.Run("In-memory KV Store", func(t *testing.T) {
+		testKVStorePrefix(NewMemKVStore().(KVStoreWithPrefix), t)
+	})
+
+	path := "test-kv-store-prefix.bolt"
+	testFile, _ := ioutil.TempFile(os.TempDir(), path)
+	testPath := testFile.Name()
+	cfg.DbPath = testPath
+	t.Run("Bolt DB", func(t *testing.T) {
+		testutil.CleanupPath(t, testPath)
+		defer testutil.CleanupPath(t, testPath)
+		testKVStorePrefix(NewBoltDB(cfg).(KVStoreWithPrefix), t)
+	})
+}
+
+func TestKVStoreDeletePrefix(t *testing.T) {
+	testKVStoreDeletePrefix := func(kvStore KVStoreWithPrefix, t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+		require.NoError(kvStore.Start(ctx))
+		defer func() {
+			require.NoError(kvStore.Stop(ctx))
+		}()
+		for _, k := range []string{"ka1", "ka2", "ka3", "kb1", "kc1"} {
+			require.NoError(kvStore.Put(bucket1, []byte(k), []byte("v"+k)))
+		}
+		require.NoError(kvStore.Put(bucket2, []byte("ka1"), []byte("vka1")))
+		n, err := kvStore.(KVStoreWithDeletePrefix).DeletePrefix(bucket1, []byte("ka"))
+		require.NoError(err)
+		require.Equal(3, n)
+		keys, _, err := kvStore.Prefix(bucket1, nil)
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("kb1"), []byte("kc1")}, keys)
+		_, err = kvStore.Get(bucket2, []byte("ka1"))
+		require.NoError(err)
+		n, err = kvStore.(KVStoreWithDeletePrefix).DeletePrefix("missing", nil)
+		require.NoError(err)
+		require.Equal(0, n)
+
+		// the prefix delete in the buffer masks the records in the store and the buffer until flushed
+		f, err := NewKVStoreFlusher(kvStore, batch.NewCachedBatch())
+		require.NoError(err)
+		kvb := f.KVStoreWithBuffer()
+		require.NoError(kvb.Put(bucket1, []byte("kb2"), []byte("vkb2")))
+		s := kvb.Snapshot()
+		kvb.MustDeletePrefix(bucket1, []byte("kb"))
+		require.NoError(kvb.Put(bucket1, []byte("kb3"), []byte("vkb3")))
+		for _, k := range []string{"kb1", "kb2"} {
+			_, err = kvb.Get(bucket1, []byte(k))
+			require.Equal(ErrNotExist, errors.Cause(err))
+			require.True(kvb.Deleted(bucket1, []byte(k)))
+		}
+		keys, _, err = kvb.(KVStoreWithPrefix).Prefix(bucket1, nil)
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("kb3"), []byte("kc1")}, keys)
+		// the prefix deleted is shorter than the one scanned
+		keys, _, err = kvb.(KVStoreWithPrefix).Prefix(bucket1, []byte("kb1"))
+		require.NoError(err)
+		require.Empty(keys)
+		require.NoError(kvb.Revert(s))
+		keys, _, err = kvb.(KVStoreWithPrefix).Prefix(bucket1, nil)
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("kb1"), []byte("kb2"), []byte("kc1")}, keys)
+
+		kvb.MustDeletePrefix(bucket1, []byte("kb"))
+		require.NoError(kvb.Put(bucket1, []byte("kb3"), []byte("vkb3")))
+		require.NoError(f.Flush())
+		keys, values, err := kvStore.Prefix(bucket1, nil)
+		require.NoError(err)
+		require.Equal([][]byte{[]byte("kb3"), []byte("kc1")}, keys)
+		require.Equal([][]byte{[]byte("vkb3"), []byte("vkc1")}, values)
+	}
+
+	t.Run("In-memory KV Store", func(t *testing.T) {
+		testKVStoreDeletePrefix(NewMemKVStore().(KVStoreWithPrefix), t)
+	})
+
+	path := "test-kv-store-delete-prefix.bolt"
+	testFile, _ := ioutil.TempFile(os.TempDir(), path)
+	testPath := testFile.Name()
+	cfg.DbPath = testPath
+	t.Run("Bolt DB", func(t *testing.T) {
+		testutil.CleanupPath(t, testPath)
+		defer testutil.CleanupPath(t, testPath)
+		testKVStoreDeletePrefix(NewBoltDB(cfg).(KVStoreWithPrefix), t)
+	})
+}
+
 func TestBatchRollback(t *testing.T) {
 	testBatchRollback := func(kvStore KVStore, t *testing.T) {
 		assert := assert.New(t)
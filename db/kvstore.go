@@ -27,6 +27,18 @@ type (
 		WriteBatch(batch.KVStoreBatch) error
 	}
 
+	// KVStoreBasic is the KV store of the records in a single namespace
+	KVStoreBasic interface {
+		lifecycle.StartStopper
+
+		// Put insert or update a record identified by key
+		Put([]byte, []byte) error
+		// Get gets a record by key
+		Get([]byte) ([]byte, error)
+		// Delete deletes a record by key
+		Delete([]byte) error
+	}
+
 	// KVStoreWithRange is KVStore with Range() API
 	KVStoreWithRange interface {
 		KVStore
@@ -34,6 +46,22 @@ type (
 		Range(string, []byte, uint64) ([][]byte, error)
 	}
 
+	// KVStoreWithPrefix is KVStore with Prefix() API
+	KVStoreWithPrefix interface {
+		KVStore
+		// Prefix gets the keys and values of the records whose keys start with the prefix in the namespace, ordered
+		// by key
+		Prefix(string, []byte) ([][]byte, [][]byte, error)
+	}
+
+	// KVStoreWithDeletePrefix is KVStore with DeletePrefix() API
+	KVStoreWithDeletePrefix interface {
+		KVStore
+		// DeletePrefix deletes the records whose keys start with the prefix in the namespace, and returns the number
+		// of them
+		DeletePrefix(string, []byte) (int, error)
+	}
+
 	// KVStoreWithBucketFillPercent is KVStore with option to set bucket fill percent
 	KVStoreWithBucketFillPercent interface {
 		KVStore
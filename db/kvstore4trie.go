@@ -7,14 +7,29 @@
 package db
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/iotexproject/iotex-core/db/batch"
 	"github.com/iotexproject/iotex-core/pkg/lifecycle"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 )
 
+// checksumNamespace is the namespace of the markers of the stores whose values have checksums
+const checksumNamespace = "TrieChecksum"
+
 var (
+	// ErrCorruptedNode indicates the value of a key doesn't match its checksum
+	ErrCorruptedNode = errors.New("corrupted node")
+
+	checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
 	trieKeystoreMtc = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "iotex_trie_keystore",
@@ -28,28 +43,136 @@ func init() {
 	prometheus.MustRegister(trieKeystoreMtc)
 }
 
-// KVStoreForTrie defines a kvStore with fixed bucket and cache layer for trie.
-// It may be used in other cases as well
-type KVStoreForTrie struct {
-	lc     lifecycle.Lifecycle
-	bucket string
-	dao    KVStore
+type (
+	// KVStoreForTrie defines a kvStore with fixed bucket and cache layer for trie.
+	// It may be used in other cases as well
+	KVStoreForTrie struct {
+		lc     lifecycle.Lifecycle
+		bucket string
+		// prefix is prepended to the keys, so that the stores of different prefixes share the bucket
+		prefix []byte
+		dao    KVStore
+		// checksum appends the checksum to the stored values, and verifies it on read
+		checksum bool
+	}
+
+	// KVStoreForTrieOption sets option for KVStoreForTrie
+	KVStoreForTrieOption func(*KVStoreForTrie) error
+
+	// CorruptedNodeError is the error of a value which doesn't match its checksum, along with the key of it, which is
+	// the hash of the node for a trie, and the namespace of the store
+	CorruptedNodeError struct {
+		Namespace string
+		Hash      []byte
+	}
+)
+
+// ChecksumOption appends a checksum to every value stored, and verifies it on read. It changes the stored bytes, so it
+// is only allowed for a store without records, or one migrated by AddChecksums, which is checked on start.
+func ChecksumOption() KVStoreForTrieOption {
+	return func(s *KVStoreForTrie) error {
+		s.checksum = true
+		return nil
+	}
+}
+
+// Error returns the message of the error
+func (e *CorruptedNodeError) Error() string {
+	return fmt.Sprintf("corrupted node %x in namespace %s", e.Hash, e.Namespace)
+}
+
+// Cause returns ErrCorruptedNode, so that the error is found by errors.Cause
+func (e *CorruptedNodeError) Cause() error { return ErrCorruptedNode }
+
+// CorruptedNodeOf returns the first CorruptedNodeError in the chain of the error, and false if there is none
+func CorruptedNodeOf(err error) (*CorruptedNodeError, bool) {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		if e, ok := err.(*CorruptedNodeError); ok {
+			return e, true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return nil, false
 }
 
 // NewKVStoreForTrie creates a new KVStoreForTrie
-func NewKVStoreForTrie(bucket string, dao KVStore) (*KVStoreForTrie, error) {
+func NewKVStoreForTrie(bucket string, dao KVStore, opts ...KVStoreForTrieOption) (*KVStoreForTrie, error) {
 	s := &KVStoreForTrie{
 		bucket: bucket,
 		dao:    dao,
 	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
 	s.lc.Add(s.dao)
 
 	return s, nil
 }
 
-// Start starts the kv store
+// NewPrefixedKVStoreForTrie creates a new KVStoreForTrie, whose keys are stored with the prefix in the bucket, so that
+// the tries of different prefixes share the bucket without seeing the nodes of one another. None of the prefixes of
+// the stores sharing a bucket can be a prefix of another.
+func NewPrefixedKVStoreForTrie(
+	bucket string,
+	prefix []byte,
+	dao KVStore,
+	opts ...KVStoreForTrieOption,
+) (*KVStoreForTrie, error) {
+	if len(prefix) == 0 {
+		return nil, errors.New("prefix cannot be empty")
+	}
+	s, err := NewKVStoreForTrie(bucket, dao, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.prefix = append([]byte{}, prefix...)
+
+	return s, nil
+}
+
+// Start starts the kv store, and checks the values of the store have checksums iff the option is set. A store with the
+// option and without records is marked as one with checksums.
 func (s *KVStoreForTrie) Start(ctx context.Context) error {
-	return s.lc.OnStart(ctx)
+	if err := s.lc.OnStart(ctx); err != nil {
+		return err
+	}
+	_, err := s.dao.Get(checksumNamespace, s.markerKey())
+	switch errors.Cause(err) {
+	case nil:
+		if !s.checksum {
+			return errors.Errorf("the values in namespace %s have checksums, which requires the option", s.bucket)
+		}
+		return nil
+	case ErrNotExist, ErrBucketNotExist:
+		if !s.checksum {
+			return nil
+		}
+	default:
+		return errors.Wrapf(err, "failed to get the checksum marker of namespace %s", s.bucket)
+	}
+	dao, ok := s.dao.(KVStoreWithPrefix)
+	if !ok {
+		return errors.Errorf("store %T doesn't support prefix scan to check the store is new", s.dao)
+	}
+	keys, _, err := dao.Prefix(s.bucket, s.prefix)
+	switch errors.Cause(err) {
+	case nil, ErrNotExist, ErrBucketNotExist:
+	default:
+		return err
+	}
+	if len(keys) > 0 {
+		return errors.Errorf("the values in namespace %s don't have checksums, which requires a migration", s.bucket)
+	}
+	return s.dao.Put(checksumNamespace, s.markerKey(), []byte{1})
 }
 
 // Stop stops the kv store
@@ -62,17 +185,105 @@ func (s *KVStoreForTrie) Delete(key []byte) error {
 	trieKeystoreMtc.WithLabelValues("delete").Inc()
 	// TODO: bug, need to mark key as deleted
 
-	return s.dao.Delete(s.bucket, key)
+	return s.dao.Delete(s.bucket, s.key(key))
 }
 
 // Put puts value for key
 func (s *KVStoreForTrie) Put(key, value []byte) error {
 	trieKeystoreMtc.WithLabelValues("put").Inc()
-	return s.dao.Put(s.bucket, key, value)
+	if s.checksum {
+		value = appendChecksum(value)
+	}
+	return s.dao.Put(s.bucket, s.key(key), value)
 }
 
 // Get gets value of key
 func (s *KVStoreForTrie) Get(key []byte) ([]byte, error) {
 	trieKeystoreMtc.WithLabelValues("get").Inc()
-	return s.dao.Get(s.bucket, key)
+	value, err := s.dao.Get(s.bucket, s.key(key))
+	if err != nil || !s.checksum {
+		return value, err
+	}
+	return s.verify(key, value)
+}
+
+// Prefix returns the keys and the values of the records whose keys start with the prefix, ordered by key. The keys
+// are the ones without the prefix of the store, and the records of the other stores in the bucket are skipped.
+func (s *KVStoreForTrie) Prefix(prefix []byte) ([][]byte, [][]byte, error) {
+	trieKeystoreMtc.WithLabelValues("prefix").Inc()
+	dao, ok := s.dao.(KVStoreWithPrefix)
+	if !ok {
+		return nil, nil, errors.Errorf("store %T doesn't support prefix scan", s.dao)
+	}
+	keys, values, err := dao.Prefix(s.bucket, s.key(prefix))
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range keys {
+		keys[i] = bytes.TrimPrefix(keys[i], s.prefix)
+		if !s.checksum {
+			continue
+		}
+		if values[i], err = s.verify(keys[i], values[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, values, nil
+}
+
+// AddChecksums migrates the store of the bucket and the prefix, which is empty for a store without one, to the values
+// with checksums in a batch, and returns the number of the values. The store is opened with ChecksumOption afterwards.
+func AddChecksums(dao KVStoreWithPrefix, bucket string, prefix []byte) (int, error) {
+	s := &KVStoreForTrie{bucket: bucket, prefix: prefix, dao: dao}
+	switch _, err := dao.Get(checksumNamespace, s.markerKey()); errors.Cause(err) {
+	case nil:
+		return 0, errors.Errorf("the values in namespace %s have checksums already", bucket)
+	case ErrNotExist, ErrBucketNotExist:
+	default:
+		return 0, err
+	}
+	keys, values, err := dao.Prefix(bucket, prefix)
+	switch errors.Cause(err) {
+	case nil, ErrNotExist, ErrBucketNotExist:
+	default:
+		return 0, err
+	}
+	b := batch.NewBatch()
+	for i, key := range keys {
+		b.Put(bucket, key, appendChecksum(values[i]), "failed to add checksum to key %x", key)
+	}
+	b.Put(checksumNamespace, s.markerKey(), []byte{1}, "failed to mark namespace %s", bucket)
+	if err := dao.WriteBatch(b); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// verify returns the value without the checksum, or a CorruptedNodeError if it doesn't match the checksum
+func (s *KVStoreForTrie) verify(key, value []byte) ([]byte, error) {
+	n := len(value) - crc32.Size
+	if n < 0 || crc32.Checksum(value[:n], checksumTable) != binary.BigEndian.Uint32(value[n:]) {
+		return nil, &CorruptedNodeError{Namespace: s.bucket, Hash: append([]byte{}, key...)}
+	}
+	return value[:n], nil
+}
+
+// markerKey returns the key of the marker of the store with checksums, which is the bucket followed by the prefix
+func (s *KVStoreForTrie) markerKey() []byte {
+	k := make([]byte, 0, len(s.bucket)+1+len(s.prefix))
+	return append(append(append(k, s.bucket...), 0), s.prefix...)
+}
+
+func appendChecksum(value []byte) []byte {
+	v := make([]byte, len(value), len(value)+crc32.Size)
+	copy(v, value)
+	return append(v, byteutil.Uint32ToBytesBigEndian(crc32.Checksum(value, checksumTable))...)
+}
+
+func (s *KVStoreForTrie) key(key []byte) []byte {
+	if len(s.prefix) == 0 {
+		return key
+	}
+	k := make([]byte, 0, len(s.prefix)+len(key))
+	return append(append(k, s.prefix...), key...)
 }
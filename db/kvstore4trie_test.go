@@ -7,6 +7,7 @@
 package db
 
 import (
+	"context"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -25,3 +26,111 @@ func TestKVStoreForTrie_ErrNotExist(t *testing.T) {
 	_, err = store.Get([]byte("key1"))
 	require.Equal(t, ErrNotExist, errors.Cause(err))
 }
+
+func TestPrefixedKVStoreForTrie(t *testing.T) {
+	require := require.New(t)
+	_, err := NewPrefixedKVStoreForTrie("test", nil, NewMemKVStore())
+	require.Error(err)
+
+	dao := NewMemKVStore()
+	plain, err := NewKVStoreForTrie("test", dao)
+	require.NoError(err)
+	s1, err := NewPrefixedKVStoreForTrie("test", []byte("a"), dao)
+	require.NoError(err)
+	s2, err := NewPrefixedKVStoreForTrie("test", []byte("b"), dao)
+	require.NoError(err)
+	require.NoError(s1.Put([]byte("key1"), []byte("a1")))
+	require.NoError(s1.Put([]byte("key2"), []byte("a2")))
+	require.NoError(s2.Put([]byte("key1"), []byte("b1")))
+	v, err := s1.Get([]byte("key1"))
+	require.NoError(err)
+	require.Equal([]byte("a1"), v)
+	v, err = plain.Get([]byte("bkey1"))
+	require.NoError(err)
+	require.Equal([]byte("b1"), v)
+	_, err = s2.Get([]byte("key2"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+
+	// the deletion and the scan are within the prefix
+	require.NoError(s2.Delete([]byte("key1")))
+	_, err = s2.Get([]byte("key1"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	keys, values, err := s1.Prefix([]byte("key"))
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("key1"), []byte("key2")}, keys)
+	require.Equal([][]byte{[]byte("a1"), []byte("a2")}, values)
+	keys, _, err = s2.Prefix(nil)
+	require.NoError(err)
+	require.Empty(keys)
+}
+
+func TestKVStoreForTrieChecksum(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dao := NewMemKVStore()
+	store, err := NewPrefixedKVStoreForTrie("test", []byte("a"), dao, ChecksumOption())
+	require.NoError(err)
+	require.NoError(store.Start(ctx))
+	require.NoError(store.Put([]byte("key1"), []byte("value1")))
+	require.NoError(store.Put([]byte("key2"), []byte("value2")))
+	v, err := store.Get([]byte("key1"))
+	require.NoError(err)
+	require.Equal([]byte("value1"), v)
+	raw, err := dao.Get("test", []byte("akey1"))
+	require.NoError(err)
+	require.Equal(len("value1")+4, len(raw))
+
+	// a store marked with checksums can't be opened without the option
+	plain, err := NewPrefixedKVStoreForTrie("test", []byte("a"), dao)
+	require.NoError(err)
+	require.Error(plain.Start(ctx))
+
+	// the flipped bytes and the truncated values are detected
+	for i := range raw {
+		corrupted := append([]byte{}, raw...)
+		corrupted[i] ^= 0x10
+		require.NoError(dao.Put("test", []byte("akey1"), corrupted))
+		_, err = store.Get([]byte("key1"))
+		require.Equal(ErrCorruptedNode, errors.Cause(err))
+		e, ok := CorruptedNodeOf(errors.Wrap(err, "failed to get node"))
+		require.True(ok)
+		require.Equal("test", e.Namespace)
+		require.Equal([]byte("key1"), e.Hash)
+	}
+	require.NoError(dao.Put("test", []byte("akey1"), raw[:3]))
+	_, err = store.Get([]byte("key1"))
+	require.Equal(ErrCorruptedNode, errors.Cause(err))
+	_, _, err = store.Prefix([]byte("key"))
+	require.Equal(ErrCorruptedNode, errors.Cause(err))
+	v, err = store.Get([]byte("key2"))
+	require.NoError(err)
+	require.Equal([]byte("value2"), v)
+}
+
+func TestKVStoreForTrieAddChecksums(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dao := NewMemKVStore()
+	legacy, err := NewKVStoreForTrie("test", dao)
+	require.NoError(err)
+	require.NoError(legacy.Start(ctx))
+	require.NoError(legacy.Put([]byte("key1"), []byte("value1")))
+	require.NoError(legacy.Put([]byte("key2"), []byte("value2")))
+
+	// the option isn't activated on an existing store without the migration
+	store, err := NewKVStoreForTrie("test", dao, ChecksumOption())
+	require.NoError(err)
+	require.Error(store.Start(ctx))
+
+	n, err := AddChecksums(dao.(KVStoreWithPrefix), "test", nil)
+	require.NoError(err)
+	require.Equal(2, n)
+	_, err = AddChecksums(dao.(KVStoreWithPrefix), "test", nil)
+	require.Error(err)
+	require.Error(legacy.Start(ctx))
+	require.NoError(store.Start(ctx))
+	keys, values, err := store.Prefix(nil)
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("key1"), []byte("key2")}, keys)
+	require.Equal([][]byte{[]byte("value1"), []byte("value2")}, values)
+}
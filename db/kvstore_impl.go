@@ -8,6 +8,8 @@ package db
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -85,12 +87,59 @@ func (m *memKVStore) Range(namespace string, key []byte, count uint64) ([][]byte
 	return value, nil
 }
 
+// Prefix retrieves the records whose keys start with the prefix, ordered by key
+func (m *memKVStore) Prefix(namespace string, prefix []byte) ([][]byte, [][]byte, error) {
+	records := make(map[string][]byte)
+	nsPrefix := namespace + keyDelimiter
+	m.data.Range(func(k, v interface{}) bool {
+		key := k.(string)
+		if strings.HasPrefix(key, nsPrefix) && strings.HasPrefix(key[len(nsPrefix):], string(prefix)) {
+			value := make([]byte, len(v.([]byte)))
+			copy(value, v.([]byte))
+			records[key[len(nsPrefix):]] = value
+		}
+		return true
+	})
+	keys, values := sortRecords(records)
+	return keys, values, nil
+}
+
+// sortRecords returns the keys and values of the records ordered by key
+func sortRecords(records map[string][]byte) ([][]byte, [][]byte) {
+	ks := make([]string, 0, len(records))
+	for k := range records {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	keys := make([][]byte, 0, len(ks))
+	values := make([][]byte, 0, len(ks))
+	for _, k := range ks {
+		keys = append(keys, []byte(k))
+		values = append(values, records[k])
+	}
+	return keys, values
+}
+
 // Delete deletes a record
 func (m *memKVStore) Delete(namespace string, key []byte) error {
 	m.data.Delete(namespace + keyDelimiter + string(key))
 	return nil
 }
 
+// DeletePrefix deletes the records whose keys start with the prefix, and returns the number of them
+func (m *memKVStore) DeletePrefix(namespace string, prefix []byte) (int, error) {
+	n := 0
+	nsPrefix := namespace + keyDelimiter + string(prefix)
+	m.data.Range(func(k, _ interface{}) bool {
+		if strings.HasPrefix(k.(string), nsPrefix) {
+			m.data.Delete(k)
+			n++
+		}
+		return true
+	})
+	return n, nil
+}
+
 // WriteBatch commits a batch
 func (m *memKVStore) WriteBatch(b batch.KVStoreBatch) (e error) {
 	succeed := false
@@ -117,6 +166,10 @@ func (m *memKVStore) WriteBatch(b batch.KVStoreBatch) (e error) {
 			if err := m.Delete(write.Namespace(), write.Key()); err != nil {
 				e = err
 			}
+		case batch.DeletePrefix:
+			if _, err := m.DeletePrefix(write.Namespace(), write.Key()); err != nil {
+				e = err
+			}
 		}
 		if e != nil {
 			break
@@ -1,14 +1,65 @@
 package db
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 
 	"github.com/iotexproject/iotex-core/db/batch"
 	"github.com/iotexproject/iotex-core/pkg/log"
 )
 
+var (
+	// ErrBufferFull indicates that the writes in the buffer exceed the limit of its size
+	ErrBufferFull = errors.New("buffer is full")
+	// ErrConditionFailed indicates that the condition of a conditional put doesn't hold
+	ErrConditionFailed = errors.New("condition failed")
+)
+
+var (
+	flushDurationMtc = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "iotex_db_flush_duration",
+			Help:    "IoTeX db flush duration in milliseconds",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 15),
+		},
+	)
+	flushEntriesMtc = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iotex_db_flush_entries",
+			Help: "IoTeX db entries flushed by namespace",
+		},
+		[]string{"namespace"},
+	)
+	flushBytesMtc = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "iotex_db_flush_bytes",
+			Help: "IoTeX db bytes of the serialized entries flushed by namespace",
+		},
+		[]string{"namespace"},
+	)
+	flushFailureMtc = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "iotex_db_flush_failure",
+			Help: "IoTeX db flush failures",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(flushDurationMtc)
+	prometheus.MustRegister(flushEntriesMtc)
+	prometheus.MustRegister(flushBytesMtc)
+	prometheus.MustRegister(flushFailureMtc)
+}
+
 type (
 	withBuffer interface {
 		Snapshot() int
@@ -16,7 +67,13 @@ type (
 		SerializeQueue(batch.WriteInfoFilter) []byte
 		MustPut(string, []byte, []byte)
 		MustDelete(string, []byte)
+		MustDeletePrefix(string, []byte)
+		PutIfEqual(string, []byte, []byte, []byte) error
+		PutIfAbsent(string, []byte, []byte) error
+		Deleted(string, []byte) bool
 		Size() int
+		SizeInBytes() int
+		CheckSize(int) error
 	}
 
 	// KVStoreWithBuffer defines a KVStore with a buffer, which enables snapshot, revert,
@@ -30,11 +87,13 @@ type (
 	kvStoreWithBuffer struct {
 		store  KVStore
 		buffer batch.CachedBatch
+		// limit is the max size in bytes of the writes in the buffer, 0 means there is no limit
+		limit int
 	}
 
 	// KVStoreFlusher is a wrapper of KVStoreWithBuffer, which has flush api
 	KVStoreFlusher interface {
-		SerializeQueue() []byte
+		SerializeQueue(...SerializeQueueOption) []byte
 		Flush() error
 		KVStoreWithBuffer() KVStoreWithBuffer
 	}
@@ -43,12 +102,81 @@ type (
 		kvb             *kvStoreWithBuffer
 		serializeFilter batch.WriteInfoFilter
 		flushTranslate  batch.WriteInfoTranslate
+		namespaceFilter func(string) bool
+		// slowFlush is the duration beyond which a flush is logged, 0 means it's never logged
+		slowFlush time.Duration
+	}
+
+	// flushStat is the number of the entries flushed in a namespace, and their size in the serialized queue
+	flushStat struct {
+		entries int
+		bytes   int
 	}
 
 	// KVStoreFlusherOption sets option for KVStoreFlusher
 	KVStoreFlusherOption func(*flusher) error
+
+	serializeQueueConfig struct {
+		sorted  bool
+		only    map[string]bool
+		exclude map[string]bool
+	}
+
+	// SerializeQueueOption sets option for serializing the write queue of KVStoreFlusher
+	SerializeQueueOption func(*serializeQueueConfig)
 )
 
+// SortedSerialization serializes the effective writes of the queue sorted by namespace and key, where the writes of a
+// key are collapsed to the last one, so that the same effective writes serialize to the same bytes regardless of
+// their order. Unlike the default serialization, a write is serialized along with its type, which tells a delete from
+// a put of an empty value. A prefix delete drops the writes before it of the keys with the prefix, and the prefix
+// deletes, but the ones covered by a shorter prefix, are serialized ahead of the writes.
+func SortedSerialization() SerializeQueueOption {
+	return func(cfg *serializeQueueConfig) {
+		cfg.sorted = true
+	}
+}
+
+// OnlyNamespaces serializes the writes in the namespaces only
+func OnlyNamespaces(namespaces ...string) SerializeQueueOption {
+	return func(cfg *serializeQueueConfig) {
+		if cfg.only == nil {
+			cfg.only = make(map[string]bool, len(namespaces))
+		}
+		for _, ns := range namespaces {
+			cfg.only[ns] = true
+		}
+	}
+}
+
+// ExcludeNamespaces serializes the writes except the ones in the namespaces
+func ExcludeNamespaces(namespaces ...string) SerializeQueueOption {
+	return func(cfg *serializeQueueConfig) {
+		if cfg.exclude == nil {
+			cfg.exclude = make(map[string]bool, len(namespaces))
+		}
+		for _, ns := range namespaces {
+			cfg.exclude[ns] = true
+		}
+	}
+}
+
+// filter returns the filter of the writes skipped by the serialization, which wraps the filter of the flusher
+func (cfg *serializeQueueConfig) filter(filter batch.WriteInfoFilter) batch.WriteInfoFilter {
+	if cfg.only == nil && cfg.exclude == nil {
+		return filter
+	}
+	return func(wi *batch.WriteInfo) bool {
+		if cfg.only != nil && !cfg.only[wi.Namespace()] {
+			return true
+		}
+		if cfg.exclude[wi.Namespace()] {
+			return true
+		}
+		return filter != nil && filter(wi)
+	}
+}
+
 // SerializeFilterOption sets the filter for serialize write queue
 func SerializeFilterOption(filter batch.WriteInfoFilter) KVStoreFlusherOption {
 	return func(f *flusher) error {
@@ -73,6 +201,45 @@ func FlushTranslateOption(wit batch.WriteInfoTranslate) KVStoreFlusherOption {
 	}
 }
 
+// NamespaceFilterOption sets the filter of the namespaces, and the writes in the namespaces which fail it are neither
+// flushed nor serialized. They're still buffered, and covered by snapshot and revert, until the buffer is flushed.
+func NamespaceFilterOption(filter func(ns string) bool) KVStoreFlusherOption {
+	return func(f *flusher) error {
+		if filter == nil {
+			return errors.New("namespace filter cannot be nil")
+		}
+		f.namespaceFilter = filter
+
+		return nil
+	}
+}
+
+// BufferLimitOption sets the max size in bytes of the keys and the values in the buffer, which CheckSize checks against.
+// The writes are buffered regardless of the limit, and it's up to the user to revert the ones exceeding it.
+func BufferLimitOption(limit int) KVStoreFlusherOption {
+	return func(f *flusher) error {
+		if limit < 0 {
+			return errors.Errorf("invalid buffer limit %d", limit)
+		}
+		f.kvb.limit = limit
+
+		return nil
+	}
+}
+
+// SlowFlushOption sets the duration beyond which a flush is logged as a warning, along with the entries and the bytes
+// flushed in each namespace. 0 means no flush is logged.
+func SlowFlushOption(threshold time.Duration) KVStoreFlusherOption {
+	return func(f *flusher) error {
+		if threshold < 0 {
+			return errors.Errorf("invalid slow flush threshold %s", threshold)
+		}
+		f.slowFlush = threshold
+
+		return nil
+	}
+}
+
 // NewKVStoreFlusher returns kv store flusher
 func NewKVStoreFlusher(store KVStore, buffer batch.CachedBatch, opts ...KVStoreFlusherOption) (KVStoreFlusher, error) {
 	if store == nil {
@@ -92,14 +259,56 @@ func NewKVStoreFlusher(store KVStore, buffer batch.CachedBatch, opts ...KVStoreF
 			return nil, errors.Wrap(err, "failed to apply option")
 		}
 	}
+	if f.namespaceFilter != nil {
+		f.filterNamespaces()
+	}
 
 	return f, nil
 }
 
+// filterNamespaces wraps the serialize filter and the flush translate, so that they skip the writes in the namespaces
+// failing the namespace filter
+func (f *flusher) filterNamespaces() {
+	keep, serializeFilter, flushTranslate := f.namespaceFilter, f.serializeFilter, f.flushTranslate
+	f.serializeFilter = func(wi *batch.WriteInfo) bool {
+		if !keep(wi.Namespace()) {
+			return true
+		}
+		return serializeFilter != nil && serializeFilter(wi)
+	}
+	f.flushTranslate = func(wi *batch.WriteInfo) *batch.WriteInfo {
+		if !keep(wi.Namespace()) {
+			return nil
+		}
+		if flushTranslate == nil {
+			return wi
+		}
+		return flushTranslate(wi)
+	}
+}
+
 func (f *flusher) Flush() error {
-	if err := f.kvb.store.WriteBatch(f.kvb.buffer.Translate(f.flushTranslate)); err != nil {
+	b := f.kvb.buffer.Translate(f.flushTranslate)
+	// the stats are taken ahead, as the store clears the batch written
+	stats := newFlushStats(b)
+	start := time.Now()
+	if err := f.kvb.store.WriteBatch(b); err != nil {
+		flushFailureMtc.Inc()
 		return err
 	}
+	duration := time.Since(start)
+	flushDurationMtc.Observe(float64(duration.Nanoseconds()) / 1e6)
+	for ns, stat := range stats {
+		flushEntriesMtc.WithLabelValues(ns).Add(float64(stat.entries))
+		flushBytesMtc.WithLabelValues(ns).Add(float64(stat.bytes))
+	}
+	if f.slowFlush > 0 && duration > f.slowFlush {
+		log.L().Warn(
+			"Slow flush of db.",
+			zap.Duration("duration", duration),
+			zap.Strings("namespaces", flushBreakdown(stats)),
+		)
+	}
 
 	f.kvb.buffer.Lock()
 	f.kvb.buffer.ClearAndUnlock()
@@ -107,8 +316,56 @@ func (f *flusher) Flush() error {
 	return nil
 }
 
-func (f *flusher) SerializeQueue() []byte {
-	return f.kvb.SerializeQueue(f.serializeFilter)
+// newFlushStats returns the stats of the writes in the batch by namespace, where the bytes of a namespace are the size
+// of its writes in the serialized queue
+func newFlushStats(b batch.KVStoreBatch) map[string]*flushStat {
+	stats := map[string]*flushStat{}
+	for i := 0; i < b.Size(); i++ {
+		write, err := b.Entry(i)
+		if err != nil {
+			break
+		}
+		stat, ok := stats[write.Namespace()]
+		if !ok {
+			stat = &flushStat{}
+			stats[write.Namespace()] = stat
+		}
+		stat.entries++
+	}
+	for ns, stat := range stats {
+		stat.bytes = len(b.SerializeQueue(func(wi *batch.WriteInfo) bool {
+			return wi.Namespace() != ns
+		}))
+	}
+	return stats
+}
+
+// flushBreakdown returns the entries and the bytes flushed in each namespace, ordered by namespace
+func flushBreakdown(stats map[string]*flushStat) []string {
+	namespaces := make([]string, 0, len(stats))
+	for ns := range stats {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	breakdown := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		breakdown[i] = fmt.Sprintf("%s: %d entries, %d bytes", ns, stats[ns].entries, stats[ns].bytes)
+	}
+	return breakdown
+}
+
+// SerializeQueue serializes the writes in the queue in order by default, and the options change the writes serialized
+// and their order
+func (f *flusher) SerializeQueue(opts ...SerializeQueueOption) []byte {
+	cfg := serializeQueueConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	filter := cfg.filter(f.serializeFilter)
+	if !cfg.sorted {
+		return f.kvb.SerializeQueue(filter)
+	}
+	return f.kvb.serializeSorted(filter)
 }
 
 func (f *flusher) KVStoreWithBuffer() KVStoreWithBuffer {
@@ -135,10 +392,100 @@ func (kvb *kvStoreWithBuffer) SerializeQueue(filter batch.WriteInfoFilter) []byt
 	return kvb.buffer.SerializeQueue(filter)
 }
 
+// serializeSorted serializes the prefix deletes and the last write of each key in the buffer, sorted by namespace and
+// key
+func (kvb *kvStoreWithBuffer) serializeSorted(filter batch.WriteInfoFilter) []byte {
+	kvb.buffer.Lock()
+	last := make(map[nsKey]*batch.WriteInfo, kvb.buffer.Size())
+	prefixes := make(map[nsKey]*batch.WriteInfo)
+	for i := 0; i < kvb.buffer.Size(); i++ {
+		write, err := kvb.buffer.Entry(i)
+		if err != nil {
+			log.L().Panic("Failed to get the write in the buffer.", zap.Error(err))
+		}
+		k := nsKey{write.Namespace(), string(write.Key())}
+		if write.WriteType() != batch.DeletePrefix {
+			last[k] = write
+			continue
+		}
+		for lk := range last {
+			if lk.ns == k.ns && strings.HasPrefix(lk.key, k.key) {
+				delete(last, lk)
+			}
+		}
+		// the prefix deletes are applied ahead of the writes, so the one covered by another is redundant
+		covered := false
+		for pk := range prefixes {
+			switch {
+			case pk.ns != k.ns:
+			case strings.HasPrefix(k.key, pk.key):
+				covered = true
+			case strings.HasPrefix(pk.key, k.key):
+				delete(prefixes, pk)
+			}
+		}
+		if !covered {
+			prefixes[k] = write
+		}
+	}
+	kvb.buffer.Unlock()
+	ser := make([]byte, 0)
+	for _, writes := range []map[nsKey]*batch.WriteInfo{prefixes, last} {
+		for _, k := range sortedNsKeys(writes) {
+			if write := writes[k]; filter == nil || !filter(write) {
+				ser = append(ser, write.Serialize()...)
+			}
+		}
+	}
+	return ser
+}
+
+type nsKey struct {
+	ns  string
+	key string
+}
+
+func sortedNsKeys(writes map[nsKey]*batch.WriteInfo) []nsKey {
+	keys := make([]nsKey, 0, len(writes))
+	for k := range writes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ns != keys[j].ns {
+			return keys[i].ns < keys[j].ns
+		}
+		return keys[i].key < keys[j].key
+	})
+	return keys
+}
+
+// Deleted returns true if the key in the namespace has been deleted in the buffer
+func (kvb *kvStoreWithBuffer) Deleted(ns string, key []byte) bool {
+	_, err := kvb.buffer.Get(ns, key)
+	return errors.Cause(err) == batch.ErrAlreadyDeleted
+}
+
 func (kvb *kvStoreWithBuffer) Size() int {
 	return kvb.buffer.Size()
 }
 
+// SizeInBytes returns the total size of the keys and the values in the buffer
+func (kvb *kvStoreWithBuffer) SizeInBytes() int {
+	return kvb.buffer.SizeInBytes()
+}
+
+// CheckSize returns ErrBufferFull if the size in bytes of the buffer, along with the pending bytes to be written into it
+// later, exceeds the limit
+func (kvb *kvStoreWithBuffer) CheckSize(pending int) error {
+	if kvb.limit == 0 {
+		return nil
+	}
+	if size := kvb.buffer.SizeInBytes() + pending; size > kvb.limit {
+		return errors.Wrapf(ErrBufferFull, "size %d exceeds the limit %d", size, kvb.limit)
+	}
+	return nil
+}
+
 func (kvb *kvStoreWithBuffer) Get(ns string, key []byte) ([]byte, error) {
 	value, err := kvb.buffer.Get(ns, key)
 	if errors.Cause(err) == batch.ErrNotExist {
@@ -150,6 +497,58 @@ func (kvb *kvStoreWithBuffer) Get(ns string, key []byte) ([]byte, error) {
 	return value, err
 }
 
+// Prefix returns the records whose keys start with the prefix in the namespace, ordered by key. The pending writes in
+// the buffer override the records in the store, and the records deleted in the buffer are skipped.
+func (kvb *kvStoreWithBuffer) Prefix(ns string, prefix []byte) ([][]byte, [][]byte, error) {
+	store, ok := kvb.store.(KVStoreWithPrefix)
+	if !ok {
+		return nil, nil, errors.Errorf("store %T doesn't support prefix scan", kvb.store)
+	}
+	keys, values, err := store.Prefix(ns, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make(map[string][]byte, len(keys))
+	for i, key := range keys {
+		records[string(key)] = values[i]
+	}
+	kvb.buffer.Lock()
+	defer kvb.buffer.Unlock()
+	// the writes are replayed in order, so that the last write of a key wins
+	for i := 0; i < kvb.buffer.Size(); i++ {
+		write, err := kvb.buffer.Entry(i)
+		if err != nil {
+			return nil, nil, err
+		}
+		applyWrite(records, ns, prefix, write)
+	}
+	keys, values = sortRecords(records)
+	return keys, values, nil
+}
+
+// applyWrite applies the write to the records of the keys with the prefix in the namespace
+func applyWrite(records map[string][]byte, ns string, prefix []byte, write *batch.WriteInfo) {
+	if write.Namespace() != ns {
+		return
+	}
+	switch write.WriteType() {
+	case batch.Put:
+		if bytes.HasPrefix(write.Key(), prefix) {
+			records[string(write.Key())] = write.Value()
+		}
+	case batch.Delete:
+		delete(records, string(write.Key()))
+	case batch.DeletePrefix:
+		// the prefix deleted may be shorter than the one scanned
+		deleted := string(write.Key())
+		for key := range records {
+			if strings.HasPrefix(key, deleted) {
+				delete(records, key)
+			}
+		}
+	}
+}
+
 func (kvb *kvStoreWithBuffer) Put(ns string, key, value []byte) error {
 	kvb.buffer.Put(ns, key, value, "faild to put %x in %s", key, ns)
 	return nil
@@ -168,6 +567,45 @@ func (kvb *kvStoreWithBuffer) MustDelete(ns string, key []byte) {
 	kvb.buffer.Delete(ns, key, "failed to delete %x in %s", key, ns)
 }
 
+// PutIfEqual puts the value of the key if its current value, including the writes in the buffer, equals to the expected
+// one, or returns ErrConditionFailed. The put is buffered as a plain put, which a revert undoes like the others.
+func (kvb *kvStoreWithBuffer) PutIfEqual(ns string, key, expected, value []byte) error {
+	current, err := kvb.Get(ns, key)
+	switch errors.Cause(err) {
+	case nil:
+	case ErrNotExist:
+		return errors.Wrapf(ErrConditionFailed, "key %x in %s doesn't exist", key, ns)
+	default:
+		return err
+	}
+	if !bytes.Equal(current, expected) {
+		return errors.Wrapf(ErrConditionFailed, "value of key %x in %s is %x rather than %x", key, ns, current, expected)
+	}
+	kvb.MustPut(ns, key, value)
+	return nil
+}
+
+// PutIfAbsent puts the value of the key if it doesn't exist, including the writes in the buffer, or returns
+// ErrConditionFailed. The put is buffered as a plain put, which a revert undoes like the others.
+func (kvb *kvStoreWithBuffer) PutIfAbsent(ns string, key, value []byte) error {
+	_, err := kvb.Get(ns, key)
+	switch errors.Cause(err) {
+	case nil:
+		return errors.Wrapf(ErrConditionFailed, "key %x in %s exists", key, ns)
+	case ErrNotExist:
+	default:
+		return err
+	}
+	kvb.MustPut(ns, key, value)
+	return nil
+}
+
+// MustDeletePrefix deletes the records whose keys start with the prefix in the namespace, which are masked in the
+// buffer until it's flushed
+func (kvb *kvStoreWithBuffer) MustDeletePrefix(ns string, prefix []byte) {
+	kvb.buffer.DeletePrefix(ns, prefix, "failed to delete prefix %x in %s", prefix, ns)
+}
+
 func (kvb *kvStoreWithBuffer) WriteBatch(b batch.KVStoreBatch) (err error) {
 	b.Lock()
 	defer func() {
@@ -184,7 +622,9 @@ func (kvb *kvStoreWithBuffer) WriteBatch(b batch.KVStoreBatch) (err error) {
 		if e != nil {
 			return e
 		}
-		if write.WriteType() != batch.Put && write.WriteType() != batch.Delete {
+		switch write.WriteType() {
+		case batch.Put, batch.Delete, batch.DeletePrefix:
+		default:
 			return errors.Errorf("invalid write type %d", write.WriteType())
 		}
 		writes[i] = write
@@ -197,6 +637,8 @@ func (kvb *kvStoreWithBuffer) WriteBatch(b batch.KVStoreBatch) (err error) {
 			kvb.buffer.Put(write.Namespace(), write.Key(), write.Value(), write.ErrorFormat(), write.ErrorArgs())
 		case batch.Delete:
 			kvb.buffer.Delete(write.Namespace(), write.Key(), write.ErrorFormat(), write.ErrorArgs())
+		case batch.DeletePrefix:
+			kvb.buffer.DeletePrefix(write.Namespace(), write.Key(), write.ErrorFormat(), write.ErrorArgs())
 		default:
 			log.S().Panic("unexpected write type")
 		}
@@ -3,10 +3,15 @@ package db
 import (
 	"bytes"
 	"context"
+	"math/rand"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/db/batch"
@@ -56,11 +61,13 @@ func TestFlusher(t *testing.T) {
 		})
 		t.Run("fail to flush", func(t *testing.T) {
 			buffer.EXPECT().Translate(gomock.Any()).Return(buffer).Times(1)
+			buffer.EXPECT().Size().Return(0).Times(1)
 			store.EXPECT().WriteBatch(gomock.Any()).Return(expectedError).Times(1)
 			require.Equal(t, expectedError, f.Flush())
 		})
 		t.Run("flush successfully", func(t *testing.T) {
 			buffer.EXPECT().Translate(gomock.Any()).Return(buffer).Times(1)
+			buffer.EXPECT().Size().Return(0).Times(1)
 			store.EXPECT().WriteBatch(gomock.Any()).Return(nil).Times(1)
 			buffer.EXPECT().Lock().Times(1)
 			buffer.EXPECT().ClearAndUnlock().Times(1)
@@ -81,6 +88,14 @@ func TestFlusher(t *testing.T) {
 			require.Nil(t, v)
 			require.Equal(t, errors.Cause(err), ErrNotExist)
 		})
+		t.Run("Deleted", func(t *testing.T) {
+			buffer.EXPECT().Get(ns, key).Return(nil, batch.ErrAlreadyDeleted).Times(1)
+			require.True(t, kvb.Deleted(ns, key))
+			buffer.EXPECT().Get(ns, key).Return(nil, batch.ErrNotExist).Times(1)
+			require.False(t, kvb.Deleted(ns, key))
+			buffer.EXPECT().Get(ns, key).Return(value, nil).Times(1)
+			require.False(t, kvb.Deleted(ns, key))
+		})
 		t.Run("Snapshot", func(t *testing.T) {
 			buffer.EXPECT().Snapshot().Return(1).Times(1)
 			require.Equal(t, 1, kvb.Snapshot())
@@ -109,3 +124,379 @@ func TestFlusher(t *testing.T) {
 		})
 	})
 }
+
+func TestFlusherNamespaceFilter(t *testing.T) {
+	require := require.New(t)
+	_, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), NamespaceFilterOption(nil))
+	require.Error(err)
+
+	local := func(ns string) bool { return ns != "local" }
+	newFlusher := func(store KVStore) KVStoreFlusher {
+		f, err := NewKVStoreFlusher(store, batch.NewCachedBatch(), NamespaceFilterOption(local))
+		require.NoError(err)
+		return f
+	}
+	store := NewMemKVStore()
+	f := newFlusher(store)
+	kvb := f.KVStoreWithBuffer()
+	kvb.MustPut("state", []byte("key"), []byte("value"))
+	kvb.MustPut("local", []byte("key"), []byte("value1"))
+	// the writes in the filtered namespace are still reverted
+	s := kvb.Snapshot()
+	kvb.MustPut("local", []byte("key"), []byte("value2"))
+	kvb.MustPut("local", []byte("other"), []byte("value"))
+	require.NoError(kvb.Revert(s))
+	v, err := kvb.Get("local", []byte("key"))
+	require.NoError(err)
+	require.Equal([]byte("value1"), v)
+	_, err = kvb.Get("local", []byte("other"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+
+	// only the unfiltered writes are serialized
+	unfiltered := newFlusher(NewMemKVStore())
+	unfiltered.KVStoreWithBuffer().MustPut("state", []byte("key"), []byte("value"))
+	require.Equal(unfiltered.SerializeQueue(), f.SerializeQueue())
+	kvb.MustDelete("local", []byte("key"))
+	require.Equal(unfiltered.SerializeQueue(), f.SerializeQueue())
+
+	// and flushed
+	require.NoError(f.Flush())
+	v, err = store.Get("state", []byte("key"))
+	require.NoError(err)
+	require.Equal([]byte("value"), v)
+	_, err = store.Get("local", []byte("key"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Zero(kvb.Size())
+
+	// the filter is composed with the other options
+	f, err = NewKVStoreFlusher(
+		NewMemKVStore(),
+		batch.NewCachedBatch(),
+		SerializeFilterOption(func(wi *batch.WriteInfo) bool { return bytes.Equal(wi.Key(), []byte("skip")) }),
+		NamespaceFilterOption(local),
+	)
+	require.NoError(err)
+	f.KVStoreWithBuffer().MustPut("state", []byte("skip"), []byte("value"))
+	f.KVStoreWithBuffer().MustPut("local", []byte("key"), []byte("value"))
+	require.Empty(f.SerializeQueue())
+	f.KVStoreWithBuffer().MustPut("state", []byte("key"), []byte("value"))
+	require.Equal(unfiltered.SerializeQueue(), f.SerializeQueue())
+}
+
+func TestFlusherBufferLimit(t *testing.T) {
+	require := require.New(t)
+	_, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), BufferLimitOption(-1))
+	require.Error(err)
+
+	f, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), BufferLimitOption(16))
+	require.NoError(err)
+	kvb := f.KVStoreWithBuffer()
+	kvb.MustPut("ns", []byte("key"), []byte("value"))
+	require.Equal(8, kvb.SizeInBytes())
+	s := kvb.Snapshot()
+	kvb.MustPut("ns", []byte("key"), []byte("value"))
+	require.NoError(kvb.CheckSize(0))
+	kvb.MustDelete("ns", []byte("key"))
+	require.Equal(19, kvb.SizeInBytes())
+	require.Equal(ErrBufferFull, errors.Cause(kvb.CheckSize(0)))
+	// the writes exceeding the limit are buffered until they're reverted
+	_, err = kvb.Get("ns", []byte("key"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.NoError(kvb.Revert(s))
+	require.Equal(8, kvb.SizeInBytes())
+	require.NoError(kvb.CheckSize(0))
+	// the pending bytes are counted along with the buffered ones
+	require.Equal(ErrBufferFull, errors.Cause(kvb.CheckSize(9)))
+	require.NoError(f.Flush())
+	require.Equal(0, kvb.SizeInBytes())
+
+	// there is no limit by default
+	f, err = NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch())
+	require.NoError(err)
+	f.KVStoreWithBuffer().MustPut("ns", []byte("key"), make([]byte, 1<<20))
+	require.NoError(f.KVStoreWithBuffer().CheckSize(0))
+}
+
+func TestFlusherSerializeQueueOptions(t *testing.T) {
+	require := require.New(t)
+	f, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch())
+	require.NoError(err)
+	kvb := f.KVStoreWithBuffer()
+	kvb.MustPut("b", []byte("key"), []byte("value"))
+	kvb.MustPut("a", []byte("key2"), []byte("value"))
+	kvb.MustPut("a", []byte("key1"), []byte("value1"))
+	kvb.MustDelete("b", []byte("key"))
+	kvb.MustPut("a", []byte("key1"), []byte("value2"))
+
+	// the default serialization is in order
+	require.Equal([]byte("bkeyvalueakey2valueakey1value1bkeyakey1value2"), f.SerializeQueue())
+	require.Equal(kvb.SerializeQueue(nil), f.SerializeQueue())
+	require.Equal([]byte("bkeyvaluebkey"), f.SerializeQueue(OnlyNamespaces("b")))
+	require.Equal([]byte("bkeyvaluebkey"), f.SerializeQueue(ExcludeNamespaces("a")))
+	require.Empty(f.SerializeQueue(OnlyNamespaces("a"), ExcludeNamespaces("a")))
+	require.Equal(f.SerializeQueue(), f.SerializeQueue(OnlyNamespaces("a", "b")))
+	require.Equal(f.SerializeQueue(), f.SerializeQueue(OnlyNamespaces("a"), OnlyNamespaces("b")))
+
+	// the sorted serialization has the last write of each key along with its type
+	put, del := string([]byte{byte(batch.Put)}), string([]byte{byte(batch.Delete)})
+	require.Equal([]byte(put+"akey1value2"+put+"akey2value"+del+"bkey"), f.SerializeQueue(SortedSerialization()))
+	require.Equal([]byte(del+"bkey"), f.SerializeQueue(SortedSerialization(), ExcludeNamespaces("a")))
+
+	// the filter of the flusher applies to both
+	f, err = NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), SerializeFilterOption(func(wi *batch.WriteInfo) bool {
+		return wi.WriteType() == batch.Delete
+	}))
+	require.NoError(err)
+	f.KVStoreWithBuffer().MustPut("a", []byte("key"), []byte("value"))
+	f.KVStoreWithBuffer().MustPut("b", []byte("key"), []byte("value"))
+	f.KVStoreWithBuffer().MustDelete("a", []byte("key"))
+	require.Equal([]byte("akeyvaluebkeyvalue"), f.SerializeQueue())
+	require.Equal([]byte(put+"bkeyvalue"), f.SerializeQueue(SortedSerialization()))
+}
+
+func TestFlusherSortedSerializationFuzz(t *testing.T) {
+	require := require.New(t)
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	type write struct {
+		ns, key string
+		value   []byte
+	}
+	namespaces := []string{"a", "b", "ab"}
+	keys := []string{"", "k", "k1", "k2"}
+	randomWrite := func() write {
+		w := write{ns: namespaces[r.Intn(len(namespaces))], key: keys[r.Intn(len(keys))]}
+		// a nil value is a delete
+		switch r.Intn(3) {
+		case 0:
+			w.value = []byte{}
+		case 1:
+			w.value = []byte{byte(r.Intn(4))}
+		}
+		return w
+	}
+	serialize := func(writes []write) []byte {
+		f, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch())
+		require.NoError(err)
+		for _, w := range writes {
+			if w.value == nil {
+				f.KVStoreWithBuffer().MustDelete(w.ns, []byte(w.key))
+			} else {
+				f.KVStoreWithBuffer().MustPut(w.ns, []byte(w.key), w.value)
+			}
+		}
+		return f.SerializeQueue(SortedSerialization())
+	}
+	for i := 0; i < 200; i++ {
+		writes := make([]write, r.Intn(30))
+		last := map[string]write{}
+		for j := range writes {
+			writes[j] = randomWrite()
+			last[writes[j].ns+"/"+writes[j].key] = writes[j]
+		}
+		// the same effective writes, interleaved with other writes of the same keys in another order
+		var final, other []write
+		for _, w := range last {
+			final = append(final, w)
+		}
+		for j := r.Intn(30); j > 0 && len(final) > 0; j-- {
+			w, k := randomWrite(), final[r.Intn(len(final))]
+			w.ns, w.key = k.ns, k.key
+			other = append(other, w)
+		}
+		r.Shuffle(len(final), func(i, j int) { final[i], final[j] = final[j], final[i] })
+		for _, w := range final {
+			other = append(other, w)
+			if r.Intn(2) == 0 {
+				// the write is repeated
+				other = append(other, w)
+			}
+		}
+
+		sort.Slice(final, func(i, j int) bool {
+			if final[i].ns != final[j].ns {
+				return final[i].ns < final[j].ns
+			}
+			return final[i].key < final[j].key
+		})
+		expected := []byte{}
+		for _, w := range final {
+			writeType := batch.Put
+			if w.value == nil {
+				writeType = batch.Delete
+			}
+			expected = append(expected, byte(writeType))
+			expected = append(expected, w.ns+w.key...)
+			expected = append(expected, w.value...)
+		}
+		require.Equal(expected, serialize(writes))
+		require.Equal(expected, serialize(other))
+	}
+}
+
+func TestFlusherSortedSerializationDeletePrefix(t *testing.T) {
+	require := require.New(t)
+	serialize := func(writes func(kvb KVStoreWithBuffer)) []byte {
+		f, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch())
+		require.NoError(err)
+		writes(f.KVStoreWithBuffer())
+		return f.SerializeQueue(SortedSerialization())
+	}
+	expected := serialize(func(kvb KVStoreWithBuffer) {
+		kvb.MustDeletePrefix("ns", []byte("k"))
+		kvb.MustPut("ns", []byte("k2"), []byte("v2"))
+	})
+	// the writes before the prefix delete and the prefix deletes it covers are dropped
+	require.Equal(expected, serialize(func(kvb KVStoreWithBuffer) {
+		kvb.MustPut("ns", []byte("k1"), []byte("v1"))
+		kvb.MustDeletePrefix("ns", []byte("k1"))
+		kvb.MustDelete("ns", []byte("k3"))
+		kvb.MustDeletePrefix("ns", []byte("k"))
+		kvb.MustPut("ns", []byte("k2"), []byte("v2"))
+		kvb.MustDeletePrefix("ns", []byte("k2"))
+		kvb.MustPut("ns", []byte("k2"), []byte("v2"))
+	}))
+	// a prefix delete isn't a delete of the prefix
+	require.NotEqual(expected, serialize(func(kvb KVStoreWithBuffer) {
+		kvb.MustDelete("ns", []byte("k"))
+		kvb.MustPut("ns", []byte("k2"), []byte("v2"))
+	}))
+	require.NotEqual(expected, serialize(func(kvb KVStoreWithBuffer) {
+		kvb.MustDeletePrefix("ns", []byte("k2"))
+		kvb.MustPut("ns", []byte("k2"), []byte("v2"))
+	}))
+}
+
+func TestFlusherConditionalPut(t *testing.T) {
+	require := require.New(t)
+	store := NewMemKVStore()
+	require.NoError(store.Put("ns", []byte("k1"), []byte("v1")))
+	f, err := NewKVStoreFlusher(store, batch.NewCachedBatch())
+	require.NoError(err)
+	kvb := f.KVStoreWithBuffer()
+
+	// the conditions are evaluated against the store and the buffer
+	require.NoError(kvb.PutIfEqual("ns", []byte("k1"), []byte("v1"), []byte("v2")))
+	require.Equal(ErrConditionFailed, errors.Cause(kvb.PutIfEqual("ns", []byte("k1"), []byte("v1"), []byte("v3"))))
+	require.Equal(ErrConditionFailed, errors.Cause(kvb.PutIfAbsent("ns", []byte("k1"), []byte("v3"))))
+	require.Equal(ErrConditionFailed, errors.Cause(kvb.PutIfEqual("ns", []byte("k2"), nil, []byte("v3"))))
+	require.NoError(kvb.PutIfAbsent("ns", []byte("k2"), []byte("v3")))
+	kvb.MustDelete("ns", []byte("k2"))
+	require.NoError(kvb.PutIfAbsent("ns", []byte("k2"), []byte("v4")))
+	require.NoError(kvb.PutIfEqual("ns", []byte("k2"), []byte("v4"), []byte("v4")))
+
+	// two actions claim the same name, and the second one fails until the first is reverted
+	s0 := kvb.Snapshot()
+	require.NoError(kvb.PutIfAbsent("name", []byte("alice"), []byte("owner1")))
+	s1 := kvb.Snapshot()
+	require.Equal(ErrConditionFailed, errors.Cause(kvb.PutIfAbsent("name", []byte("alice"), []byte("owner2"))))
+	require.NoError(kvb.Revert(s1))
+	v, err := kvb.Get("name", []byte("alice"))
+	require.NoError(err)
+	require.Equal([]byte("owner1"), v)
+	require.NoError(kvb.Revert(s0))
+	require.NoError(kvb.PutIfAbsent("name", []byte("alice"), []byte("owner2")))
+
+	// two actions allocate an index with the same value read
+	require.NoError(kvb.PutIfAbsent("index", []byte("next"), []byte{0}))
+	s2 := kvb.Snapshot()
+	require.NoError(kvb.PutIfEqual("index", []byte("next"), []byte{0}, []byte{1}))
+	require.Equal(ErrConditionFailed, errors.Cause(kvb.PutIfEqual("index", []byte("next"), []byte{0}, []byte{1})))
+	require.NoError(kvb.PutIfEqual("index", []byte("next"), []byte{1}, []byte{2}))
+	// a revert restoring the old value undoes the conditional puts
+	require.NoError(kvb.Revert(s2))
+	require.NoError(kvb.PutIfEqual("index", []byte("next"), []byte{0}, []byte{1}))
+
+	// the conditional puts are serialized and flushed as plain puts
+	expected, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch())
+	require.NoError(err)
+	ekvb := expected.KVStoreWithBuffer()
+	ekvb.MustPut("ns", []byte("k1"), []byte("v2"))
+	ekvb.MustPut("ns", []byte("k2"), []byte("v3"))
+	ekvb.MustDelete("ns", []byte("k2"))
+	ekvb.MustPut("ns", []byte("k2"), []byte("v4"))
+	ekvb.MustPut("ns", []byte("k2"), []byte("v4"))
+	ekvb.MustPut("name", []byte("alice"), []byte("owner2"))
+	ekvb.MustPut("index", []byte("next"), []byte{0})
+	ekvb.MustPut("index", []byte("next"), []byte{1})
+	require.Equal(expected.SerializeQueue(), f.SerializeQueue())
+	require.NoError(f.Flush())
+	for ns, records := range map[string]map[string][]byte{
+		"ns":    {"k1": []byte("v2"), "k2": []byte("v4")},
+		"name":  {"alice": []byte("owner2")},
+		"index": {"next": {1}},
+	} {
+		for k, v := range records {
+			value, err := store.Get(ns, []byte(k))
+			require.NoError(err)
+			require.Equal(v, value)
+		}
+	}
+}
+
+// failingKVStore is an in-memory KVStore failing the batches written
+type failingKVStore struct {
+	KVStore
+}
+
+func (s *failingKVStore) WriteBatch(batch.KVStoreBatch) error {
+	return errors.New("failed to write batch")
+}
+
+func TestFlusherMetrics(t *testing.T) {
+	require := require.New(t)
+	_, err := NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), SlowFlushOption(-time.Second))
+	require.Error(err)
+
+	flushes := func() uint64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(err)
+		for _, family := range families {
+			if family.GetName() == "iotex_db_flush_duration" {
+				return family.GetMetric()[0].GetHistogram().GetSampleCount()
+			}
+		}
+		return 0
+	}
+	entries := func(ns string) float64 {
+		return testutil.ToFloat64(flushEntriesMtc.WithLabelValues(ns))
+	}
+	sizes := func(ns string) float64 {
+		return testutil.ToFloat64(flushBytesMtc.WithLabelValues(ns))
+	}
+	ns1, ns2 := "testFlushMetrics1", "testFlushMetrics2"
+	write := func(kvb KVStoreWithBuffer) {
+		kvb.MustPut(ns1, []byte("k1"), []byte("v1"))
+		kvb.MustDelete(ns1, []byte("k2"))
+		kvb.MustDeletePrefix(ns1, []byte("k"))
+		kvb.MustPut(ns2, []byte("key"), []byte("value"))
+	}
+	flushCount, failures := flushes(), testutil.ToFloat64(flushFailureMtc)
+	entries1, entries2, sizes1, sizes2 := entries(ns1), entries(ns2), sizes(ns1), sizes(ns2)
+
+	// a failed flush counts the failure only
+	f, err := NewKVStoreFlusher(&failingKVStore{NewMemKVStore()}, batch.NewCachedBatch())
+	require.NoError(err)
+	write(f.KVStoreWithBuffer())
+	require.Error(f.Flush())
+	require.Equal(failures+1, testutil.ToFloat64(flushFailureMtc))
+	require.Equal(flushCount, flushes())
+	require.Equal(entries1, entries(ns1))
+	require.Equal(sizes1, sizes(ns1))
+
+	f, err = NewKVStoreFlusher(NewMemKVStore(), batch.NewCachedBatch(), SlowFlushOption(time.Nanosecond))
+	require.NoError(err)
+	write(f.KVStoreWithBuffer())
+	serialized := f.SerializeQueue()
+	require.NoError(f.Flush())
+	require.Equal(failures+1, testutil.ToFloat64(flushFailureMtc))
+	require.Equal(flushCount+1, flushes())
+	require.Equal(entries1+3, entries(ns1))
+	require.Equal(entries2+1, entries(ns2))
+	// the put and the delete are serialized without the type, unlike the prefix delete
+	require.Equal(sizes1+float64(len(ns1+"k1v1")+len(ns1+"k2")+1+len(ns1+"k")), sizes(ns1))
+	require.Equal(sizes2+float64(len(ns2+"keyvalue")), sizes(ns2))
+	require.Equal(float64(len(serialized)), sizes(ns1)-sizes1+sizes(ns2)-sizes2)
+}
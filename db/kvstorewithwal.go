@@ -0,0 +1,441 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+)
+
+// walNamespace is the namespace of the sequence number of the last batch applied to the store, which is written in
+// the same batch
+const walNamespace = "WAL"
+
+var walAppliedKey = []byte("applied")
+
+type (
+	// kvStoreWithWAL is an implementation of KVStore, which commits the batches asynchronously. WriteBatch appends the
+	// batch to the write-ahead log and fsyncs it, and a background writer applies the batches to the store in order.
+	// The batches not yet applied are overlaid on the store, so that a read sees a batch either as a whole or not at
+	// all.
+	kvStoreWithWAL struct {
+		store   KVStore
+		path    string
+		mutex   sync.RWMutex
+		file    *os.File
+		seq     uint64
+		pending []*walEntry
+		notify  chan struct{}
+		done    chan struct{}
+		closing bool
+		// err is the error failing the writer, after which the batches are no longer accepted
+		err error
+		// beforeApply is called by the writer before it applies a batch, and an error stops the writer as if it
+		// crashed, which is for testing
+		beforeApply func(uint64) error
+	}
+
+	walEntry struct {
+		seq    uint64
+		writes []*batch.WriteInfo
+	}
+)
+
+// NewKVStoreWithWAL returns a KVStore which commits the batches to the store asynchronously through the write-ahead
+// log at the path. Start replays the batches in the log not yet applied to the store before it returns, and Stop
+// waits for the pending batches to be applied.
+func NewKVStoreWithWAL(store KVStore, path string) KVStore {
+	return &kvStoreWithWAL{
+		store: store,
+		path:  path,
+	}
+}
+
+func (w *kvStoreWithWAL) Start(ctx context.Context) error {
+	if err := w.store.Start(ctx); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open write-ahead log %s", w.path)
+	}
+	if err := w.replay(file); err != nil {
+		file.Close()
+		return err
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.file = file
+	w.pending = nil
+	w.notify = make(chan struct{}, 1)
+	w.done = make(chan struct{})
+	w.closing = false
+	w.err = nil
+	go w.run()
+	return nil
+}
+
+func (w *kvStoreWithWAL) Stop(ctx context.Context) error {
+	w.mutex.Lock()
+	if w.file == nil {
+		w.mutex.Unlock()
+		return w.store.Stop(ctx)
+	}
+	w.closing = true
+	w.mutex.Unlock()
+	w.wake()
+	<-w.done
+
+	w.mutex.Lock()
+	err := w.err
+	if e := w.file.Close(); e != nil && err == nil {
+		err = errors.Wrap(e, "failed to close write-ahead log")
+	}
+	w.file = nil
+	w.pending = nil
+	w.mutex.Unlock()
+	if err != nil {
+		// the batches not applied are left in the log, and replayed by the next start
+		w.store.Stop(ctx)
+		return errors.Wrap(err, "failed to apply the pending batches")
+	}
+	return w.store.Stop(ctx)
+}
+
+func (w *kvStoreWithWAL) Put(ns string, key, value []byte) error {
+	return w.append([]*batch.WriteInfo{newWALWrite(batch.Put, ns, key, value)})
+}
+
+func (w *kvStoreWithWAL) Get(ns string, key []byte) ([]byte, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	// the store is read under the lock, so that a batch applied is still overlaid until it's removed from pending
+	for i := len(w.pending) - 1; i >= 0; i-- {
+		write := w.pending[i].find(ns, key)
+		if write == nil {
+			continue
+		}
+		if write.WriteType() != batch.Put {
+			return nil, errors.Wrapf(ErrNotExist, "key = %x in %s is deleted by pending batch", key, ns)
+		}
+		return append([]byte{}, write.Value()...), nil
+	}
+	return w.store.Get(ns, key)
+}
+
+// Prefix returns the records whose keys start with the prefix in the namespace, ordered by key. The writes of the
+// pending batches override the records in the store.
+func (w *kvStoreWithWAL) Prefix(ns string, prefix []byte) ([][]byte, [][]byte, error) {
+	store, ok := w.store.(KVStoreWithPrefix)
+	if !ok {
+		return nil, nil, errors.Errorf("store %T doesn't support prefix scan", w.store)
+	}
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	keys, values, err := store.Prefix(ns, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make(map[string][]byte, len(keys))
+	for i, key := range keys {
+		records[string(key)] = values[i]
+	}
+	for _, e := range w.pending {
+		for _, write := range e.writes {
+			applyWrite(records, ns, prefix, write)
+		}
+	}
+	keys, values = sortRecords(records)
+	return keys, values, nil
+}
+
+func (w *kvStoreWithWAL) Delete(ns string, key []byte) error {
+	return w.append([]*batch.WriteInfo{newWALWrite(batch.Delete, ns, key, nil)})
+}
+
+// WriteBatch appends the batch to the write-ahead log, and returns once the log is synced to the disk
+func (w *kvStoreWithWAL) WriteBatch(b batch.KVStoreBatch) (err error) {
+	b.Lock()
+	defer func() {
+		if err == nil {
+			// clear the batch if commit succeeds
+			b.ClearAndUnlock()
+		} else {
+			b.Unlock()
+		}
+	}()
+	writes := make([]*batch.WriteInfo, 0, b.Size())
+	for i := 0; i < b.Size(); i++ {
+		write, e := b.Entry(i)
+		if e != nil {
+			return e
+		}
+		switch write.WriteType() {
+		case batch.Put, batch.Delete, batch.DeletePrefix:
+		default:
+			return errors.Errorf("invalid write type %d", write.WriteType())
+		}
+		writes = append(writes, newWALWrite(write.WriteType(), write.Namespace(), write.Key(), write.Value()))
+	}
+	return w.append(writes)
+}
+
+// Pending returns the number of the batches not yet applied to the store
+func (w *kvStoreWithWAL) Pending() int {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return len(w.pending)
+}
+
+func (w *kvStoreWithWAL) append(writes []*batch.WriteInfo) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.file == nil {
+		return errors.New("write-ahead log isn't started")
+	}
+	if w.err != nil {
+		return errors.Wrap(w.err, "writer of write-ahead log failed")
+	}
+	e := &walEntry{seq: w.seq + 1, writes: writes}
+	if _, err := w.file.Write(e.record()); err != nil {
+		// the log may end with a partial record now, so nothing is appended after it
+		w.err = errors.Wrap(err, "failed to append to write-ahead log")
+		return w.err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.err = errors.Wrap(err, "failed to sync write-ahead log")
+		return w.err
+	}
+	w.seq = e.seq
+	w.pending = append(w.pending, e)
+	w.wake()
+	return nil
+}
+
+func (w *kvStoreWithWAL) wake() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run applies the pending batches in order until the store is stopped and the batches are drained
+func (w *kvStoreWithWAL) run() {
+	defer close(w.done)
+	for {
+		w.mutex.RLock()
+		var e *walEntry
+		if len(w.pending) > 0 {
+			e = w.pending[0]
+		}
+		closing := w.closing
+		w.mutex.RUnlock()
+		if e == nil {
+			if closing {
+				return
+			}
+			<-w.notify
+			continue
+		}
+		if err := w.applyPending(e); err != nil {
+			log.L().Error("Failed to apply batch of write-ahead log.", zap.Uint64("seq", e.seq), zap.Error(err))
+			w.mutex.Lock()
+			w.err = err
+			w.mutex.Unlock()
+			return
+		}
+	}
+}
+
+func (w *kvStoreWithWAL) applyPending(e *walEntry) error {
+	if w.beforeApply != nil {
+		if err := w.beforeApply(e.seq); err != nil {
+			return err
+		}
+	}
+	if err := w.apply(e); err != nil {
+		return err
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending = w.pending[1:]
+	if len(w.pending) > 0 {
+		return nil
+	}
+	// all the batches in the log are applied
+	if err := truncateWAL(w.file); err != nil {
+		log.L().Error("Failed to truncate write-ahead log.", zap.Error(err))
+	}
+	return nil
+}
+
+// apply writes the batch to the store along with its sequence number, in a single batch of the store
+func (w *kvStoreWithWAL) apply(e *walEntry) error {
+	b := batch.NewBatch()
+	for _, write := range e.writes {
+		switch write.WriteType() {
+		case batch.Put:
+			b.Put(write.Namespace(), write.Key(), write.Value(), "failed to put %x in %s", write.Key(), write.Namespace())
+		case batch.Delete:
+			b.Delete(write.Namespace(), write.Key(), "failed to delete %x in %s", write.Key(), write.Namespace())
+		case batch.DeletePrefix:
+			b.DeletePrefix(write.Namespace(), write.Key(), "failed to delete prefix %x in %s", write.Key(), write.Namespace())
+		}
+	}
+	b.Put(walNamespace, walAppliedKey, byteutil.Uint64ToBytesBigEndian(e.seq), "failed to put sequence %d", e.seq)
+	return w.store.WriteBatch(b)
+}
+
+// replay applies the batches in the log after the last one applied to the store, and empties the log
+func (w *kvStoreWithWAL) replay(file *os.File) error {
+	entries, err := readWAL(file)
+	if err != nil {
+		return err
+	}
+	applied := uint64(0)
+	value, err := w.store.Get(walNamespace, walAppliedKey)
+	switch errors.Cause(err) {
+	case nil:
+		applied = byteutil.BytesToUint64BigEndian(value)
+	case ErrNotExist:
+	default:
+		return errors.Wrap(err, "failed to get the last batch applied")
+	}
+	w.seq = applied
+	for _, e := range entries {
+		if e.seq <= applied {
+			continue
+		}
+		if err := w.apply(e); err != nil {
+			return errors.Wrapf(err, "failed to replay batch %d", e.seq)
+		}
+		w.seq = e.seq
+	}
+	return truncateWAL(file)
+}
+
+func truncateWAL(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// readWAL reads the entries in the log. The log is read up to the first incomplete or corrupted record, which is the
+// one torn by a crash in appending it, and never synced.
+func readWAL(file *os.File) ([]*walEntry, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "failed to seek write-ahead log")
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read write-ahead log")
+	}
+	var entries []*walEntry
+	for len(data) >= 8 {
+		size := binary.BigEndian.Uint32(data)
+		if uint64(len(data)-8) < uint64(size) {
+			break
+		}
+		payload := data[8 : 8+size]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[4:]) {
+			break
+		}
+		e, err := deserializeWALEntry(payload)
+		if err != nil {
+			break
+		}
+		entries = append(entries, e)
+		data = data[8+size:]
+	}
+	return entries, nil
+}
+
+func newWALWrite(writeType batch.WriteType, ns string, key, value []byte) *batch.WriteInfo {
+	return batch.NewWriteInfo(writeType, ns, append([]byte{}, key...), append([]byte{}, value...), "", nil)
+}
+
+// find returns the last write of the key in the batch, which may be a prefix delete of it
+func (e *walEntry) find(ns string, key []byte) *batch.WriteInfo {
+	for i := len(e.writes) - 1; i >= 0; i-- {
+		write := e.writes[i]
+		if write.Namespace() != ns {
+			continue
+		}
+		if bytes.Equal(write.Key(), key) || (write.WriteType() == batch.DeletePrefix && bytes.HasPrefix(key, write.Key())) {
+			return write
+		}
+	}
+	return nil
+}
+
+// record returns the record of the entry in the log, which is the size and the checksum of the payload followed by
+// the payload, where the payload is the sequence number followed by the writes
+func (e *walEntry) record() []byte {
+	payload := make([]byte, 8, 64)
+	binary.BigEndian.PutUint64(payload, e.seq)
+	for _, write := range e.writes {
+		payload = append(payload, byte(write.WriteType()))
+		for _, field := range [][]byte{[]byte(write.Namespace()), write.Key(), write.Value()} {
+			payload = appendUvarint(payload, uint64(len(field)))
+			payload = append(payload, field...)
+		}
+	}
+	record := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	return append(record, payload...)
+}
+
+func deserializeWALEntry(payload []byte) (*walEntry, error) {
+	if len(payload) < 8 {
+		return nil, errors.New("missing sequence number")
+	}
+	e := &walEntry{seq: binary.BigEndian.Uint64(payload)}
+	payload = payload[8:]
+	for len(payload) > 0 {
+		writeType := batch.WriteType(payload[0])
+		switch writeType {
+		case batch.Put, batch.Delete, batch.DeletePrefix:
+		default:
+			return nil, errors.Errorf("invalid write type %d", writeType)
+		}
+		payload = payload[1:]
+		fields := make([][]byte, 3)
+		for i := range fields {
+			size, n := binary.Uvarint(payload)
+			if n <= 0 || uint64(len(payload)-n) < size {
+				return nil, errors.New("invalid size of field")
+			}
+			fields[i] = payload[n : n+int(size)]
+			payload = payload[n+int(size):]
+		}
+		e.writes = append(e.writes, newWALWrite(writeType, string(fields[0]), fields[1], fields[2]))
+	}
+	return e, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
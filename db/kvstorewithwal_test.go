@@ -0,0 +1,255 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db/batch"
+)
+
+var errCrash = errors.New("crash")
+
+// slowKVStore is an in-memory KVStore applying the writes of a batch one by one, and calling step after each of them
+// but the first, when the batch is applied partially. It counts the batches applied as well.
+type slowKVStore struct {
+	*memKVStore
+	mutex   sync.Mutex
+	batches int
+	step    func()
+}
+
+func newSlowKVStore() *slowKVStore {
+	return &slowKVStore{memKVStore: NewMemKVStore().(*memKVStore)}
+}
+
+func (s *slowKVStore) WriteBatch(b batch.KVStoreBatch) error {
+	s.mutex.Lock()
+	s.batches++
+	s.mutex.Unlock()
+	for i := 0; i < b.Size(); i++ {
+		write, err := b.Entry(i)
+		if err != nil {
+			return err
+		}
+		switch write.WriteType() {
+		case batch.Put:
+			s.Put(write.Namespace(), write.Key(), write.Value())
+		case batch.Delete:
+			s.Delete(write.Namespace(), write.Key())
+		}
+		if i == 0 && s.step != nil {
+			s.step()
+		}
+	}
+	return nil
+}
+
+func (s *slowKVStore) applied() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.batches
+}
+
+func walPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "wal")
+	require.NoError(t, err)
+	return filepath.Join(dir, "trie.wal"), func() { os.RemoveAll(dir) }
+}
+
+func TestKVStoreWithWAL(t *testing.T) {
+	require := require.New(t)
+	path, cleanup := walPath(t)
+	defer cleanup()
+	store := NewMemKVStore()
+	kv := NewKVStoreWithWAL(store, path)
+	require.Error(kv.Put("ns", []byte("k"), []byte("v")))
+	require.NoError(kv.Start(context.Background()))
+
+	require.NoError(kv.Put("ns", []byte("k1"), []byte("v1")))
+	b := batch.NewBatch()
+	b.Put("ns", []byte("k2"), []byte("v2"), "")
+	b.Put("ns", []byte("k3"), []byte("v3"), "")
+	b.Delete("ns", []byte("k1"), "")
+	b.Put("other", []byte("k1"), []byte("o1"), "")
+	require.NoError(kv.WriteBatch(b))
+	require.Equal(0, b.Size())
+	_, err := kv.Get("ns", []byte("k1"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	v, err := kv.Get("ns", []byte("k2"))
+	require.NoError(err)
+	require.Equal([]byte("v2"), v)
+	keys, values, err := kv.(KVStoreWithPrefix).Prefix("ns", []byte("k"))
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("k2"), []byte("k3")}, keys)
+	require.Equal([][]byte{[]byte("v2"), []byte("v3")}, values)
+
+	// the flusher commits to the log as well
+	f, err := NewKVStoreFlusher(kv, batch.NewCachedBatch())
+	require.NoError(err)
+	require.NoError(f.KVStoreWithBuffer().Put("ns", []byte("k4"), []byte("v4")))
+	require.NoError(f.Flush())
+	v, err = kv.Get("ns", []byte("k4"))
+	require.NoError(err)
+	require.Equal([]byte("v4"), v)
+
+	// the prefix delete masks the records in the store and the pending batches
+	b = batch.NewBatch()
+	b.Put("other", []byte("k2"), []byte("o2"), "")
+	b.DeletePrefix("other", []byte("k"), "")
+	b.Put("other", []byte("k3"), []byte("o3"), "")
+	require.NoError(kv.WriteBatch(b))
+	_, err = kv.Get("other", []byte("k1"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	keys, _, err = kv.(KVStoreWithPrefix).Prefix("other", nil)
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("k3")}, keys)
+
+	// stop drains the batches, and empties the log
+	require.NoError(kv.Stop(context.Background()))
+	require.Equal(0, kv.(*kvStoreWithWAL).Pending())
+	for k, v := range map[string]string{"k2": "v2", "k3": "v3", "k4": "v4"} {
+		value, err := store.Get("ns", []byte(k))
+		require.NoError(err)
+		require.Equal([]byte(v), value)
+	}
+	_, err = store.Get("ns", []byte("k1"))
+	require.Equal(ErrNotExist, errors.Cause(err))
+	keys, _, err = store.(KVStoreWithPrefix).Prefix("other", nil)
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("k3")}, keys)
+	info, err := os.Stat(path)
+	require.NoError(err)
+	require.Equal(int64(0), info.Size())
+
+	// the sequence continues after a restart
+	require.NoError(kv.Start(context.Background()))
+	require.NoError(kv.Put("ns", []byte("k5"), []byte("v5")))
+	require.NoError(kv.Stop(context.Background()))
+	seq, err := store.Get(walNamespace, walAppliedKey)
+	require.NoError(err)
+	require.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 5}, seq)
+}
+
+func TestKVStoreWithWALCrashRecovery(t *testing.T) {
+	for _, crashAt := range []uint64{1, 3} {
+		t.Run(fmt.Sprintf("crash before applying batch %d", crashAt), func(t *testing.T) {
+			require := require.New(t)
+			path, cleanup := walPath(t)
+			defer cleanup()
+			store := newSlowKVStore()
+			kv := NewKVStoreWithWAL(store, path).(*kvStoreWithWAL)
+			crashed := make(chan struct{})
+			kv.beforeApply = func(seq uint64) error {
+				if seq == crashAt {
+					close(crashed)
+					return errCrash
+				}
+				return nil
+			}
+			require.NoError(kv.Start(context.Background()))
+			for i := 1; i <= 4; i++ {
+				b := batch.NewBatch()
+				b.Put("ns", []byte(fmt.Sprintf("k%d", i)), []byte(fmt.Sprintf("v%d", i)), "")
+				b.Delete("ns", []byte(fmt.Sprintf("k%d", i-1)), "")
+				require.NoError(kv.WriteBatch(b))
+			}
+			<-crashed
+			// the batches appended are read from the log, while the store misses them
+			for i := 1; i <= 4; i++ {
+				_, err := kv.Get("ns", []byte(fmt.Sprintf("k%d", i)))
+				if i == 4 {
+					require.NoError(err)
+				} else {
+					require.Equal(ErrNotExist, errors.Cause(err))
+				}
+			}
+			_, err := store.Get("ns", []byte("k4"))
+			require.Equal(ErrNotExist, errors.Cause(err))
+			require.Equal(int(crashAt-1), store.applied())
+			// the writer is dead, so the batches are no longer accepted
+			<-kv.done
+			require.Equal(errCrash, errors.Cause(kv.Put("ns", []byte("k"), []byte("v"))))
+
+			// the process restarts without stopping the store, with a record torn in appending it
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			require.NoError(err)
+			record := (&walEntry{seq: 5, writes: []*batch.WriteInfo{
+				newWALWrite(batch.Put, "ns", []byte("k5"), []byte("v5")),
+			}}).record()
+			_, err = file.Write(record[:len(record)-1])
+			require.NoError(err)
+			require.NoError(file.Close())
+			kv = NewKVStoreWithWAL(store, path).(*kvStoreWithWAL)
+			require.NoError(kv.Start(context.Background()))
+			// the batches applied before the crash aren't applied again
+			require.Equal(4, store.applied())
+			for i := 1; i <= 5; i++ {
+				_, err := store.Get("ns", []byte(fmt.Sprintf("k%d", i)))
+				if i == 4 {
+					require.NoError(err)
+				} else {
+					require.Equal(ErrNotExist, errors.Cause(err))
+				}
+			}
+			info, err := os.Stat(path)
+			require.NoError(err)
+			require.Equal(int64(0), info.Size())
+			require.NoError(kv.Put("ns", []byte("k5"), []byte("v5")))
+			require.NoError(kv.Stop(context.Background()))
+			seq, err := store.Get(walNamespace, walAppliedKey)
+			require.NoError(err)
+			require.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 5}, seq)
+		})
+	}
+}
+
+func TestKVStoreWithWALAtomicRead(t *testing.T) {
+	require := require.New(t)
+	path, cleanup := walPath(t)
+	defer cleanup()
+	store := newSlowKVStore()
+	kv := NewKVStoreWithWAL(store, path)
+	require.NoError(kv.Start(context.Background()))
+
+	// each batch updates all the keys to the same value, and the records are read in the middle of applying it
+	const keys = 20
+	var errs []error
+	store.step = func() {
+		_, values, err := kv.(KVStoreWithPrefix).Prefix("ns", nil)
+		if err == nil && len(values) != keys {
+			err = errors.Errorf("%d records", len(values))
+		}
+		for i := 1; err == nil && i < len(values); i++ {
+			if values[i][0] != values[0][0] {
+				err = errors.Errorf("partial batch, %x and %x", values[0], values[i])
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for value := 0; value < 10; value++ {
+		b := batch.NewBatch()
+		for i := 0; i < keys; i++ {
+			b.Put("ns", []byte{byte(i)}, []byte{byte(value)}, "")
+		}
+		require.NoError(kv.WriteBatch(b))
+	}
+	require.NoError(kv.Stop(context.Background()))
+	require.Empty(errs)
+	require.Equal(10, store.applied())
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// batchTrie handles the nodes of a batch of mutations of the trie. The nodes put in the batch are dirty, which are
+// kept in memory and referred to by placeholders instead of their hashes, so that the mutations run the same as the
+// ones of the trie without hashing the nodes. The dirty nodes reachable from the root are hashed once bottom-up and
+// written by the flush, after the nodes deleted in the batch.
+type batchTrie struct {
+	*branchRootTrie
+	dirty   map[Node]string
+	nodes   map[string]Node
+	deleted [][]byte
+}
+
+func newBatchTrie(tr *branchRootTrie) *batchTrie {
+	return &batchTrie{
+		branchRootTrie: tr,
+		dirty:          map[Node]string{},
+		nodes:          map[string]Node{},
+	}
+}
+
+func (bt *batchTrie) deleteNodeFromDB(tn Node) error {
+	if _, ok := bt.dirty[tn]; ok {
+		// a dirty node isn't in the db, and it's dropped from the flush once it's unreachable
+		return nil
+	}
+	bt.deleted = append(bt.deleted, bt.branchRootTrie.nodeHash(tn))
+	return nil
+}
+
+func (bt *batchTrie) putNodeIntoDB(tn Node) error {
+	if _, ok := bt.dirty[tn]; ok {
+		return nil
+	}
+	// the placeholder starts with a zero byte followed by the sequence number of the dirty node, which doesn't collide
+	// with the hashes in practice
+	placeholder := make([]byte, 9)
+	binary.BigEndian.PutUint64(placeholder[1:], uint64(len(bt.nodes)))
+	bt.dirty[tn] = string(placeholder)
+	bt.nodes[string(placeholder)] = tn
+	return nil
+}
+
+func (bt *batchTrie) loadNodeFromDB(key []byte) (Node, error) {
+	if tn, ok := bt.nodes[string(key)]; ok {
+		return tn, nil
+	}
+	return bt.branchRootTrie.loadNodeFromDB(key)
+}
+
+func (bt *batchTrie) nodeHash(tn Node) []byte {
+	if placeholder, ok := bt.dirty[tn]; ok {
+		return []byte(placeholder)
+	}
+	return bt.branchRootTrie.nodeHash(tn)
+}
+
+// flush deletes the nodes deleted in the batch from the db, hashes and puts the dirty nodes reachable from the root,
+// and resets the root hash of the trie
+func (bt *batchTrie) flush() error {
+	for _, h := range bt.deleted {
+		if err := bt.deleteNode(h); err != nil {
+			return errors.Wrapf(err, "failed to delete node %x", h)
+		}
+	}
+	if _, err := bt.hash(bt.root); err != nil {
+		return err
+	}
+	bt.resetRoot(bt.root)
+
+	return nil
+}
+
+// hash replaces the placeholders of the dirty children of the node with their hashes, and puts the node into the db
+// if it's dirty
+func (bt *batchTrie) hash(tn Node) ([]byte, error) {
+	placeholder, ok := bt.dirty[tn]
+	if !ok {
+		return bt.branchRootTrie.nodeHash(tn), nil
+	}
+	switch n := tn.(type) {
+	case *branchNode:
+		for i, h := range n.hashes {
+			child, ok := bt.nodes[string(h)]
+			if !ok {
+				continue
+			}
+			ch, err := bt.hash(child)
+			if err != nil {
+				return nil, err
+			}
+			n.hashes[i] = ch
+		}
+		n.ser = nil
+	case *extensionNode:
+		if child, ok := bt.nodes[string(n.childHash)]; ok {
+			ch, err := bt.hash(child)
+			if err != nil {
+				return nil, err
+			}
+			n.childHash = ch
+		}
+		n.ser = nil
+	}
+	delete(bt.dirty, tn)
+	delete(bt.nodes, placeholder)
+	if err := bt.branchRootTrie.putNodeIntoDB(tn); err != nil {
+		return nil, err
+	}
+	return bt.branchRootTrie.nodeHash(tn), nil
+}
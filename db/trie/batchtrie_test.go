@@ -0,0 +1,162 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/go-pkgs/hash"
+)
+
+// randomBatch returns the keys and the values of a batch, whose keys are drawn from a few bytes so that they share
+// prefixes and repeat
+func randomBatch(r *rand.Rand, size int) ([][]byte, [][]byte) {
+	keys := make([][]byte, size)
+	values := make([][]byte, size)
+	for i := range keys {
+		keys[i] = make([]byte, 4)
+		for j := range keys[i] {
+			keys[i][j] = byte(r.Intn(3))
+		}
+		values[i] = testV[r.Intn(len(testV))]
+	}
+	return keys, values
+}
+
+func TestBatchDifferential(t *testing.T) {
+	require := require.New(t)
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	newTrie := func() Trie {
+		tr, err := NewTrie(KeyLengthOption(4))
+		require.NoError(err)
+		require.NoError(tr.Start(context.Background()))
+		return tr
+	}
+	seq, bat := newTrie(), newTrie()
+	entries := map[string][]byte{}
+	for round := 0; round < 50; round++ {
+		keys, values := randomBatch(r, 1+r.Intn(40))
+		for i, key := range keys {
+			require.NoError(seq.Upsert(key, values[i]))
+			entries[string(key)] = values[i]
+		}
+		require.NoError(bat.UpsertBatch(keys, values))
+		require.Equal(seq.RootHash(), bat.RootHash(), "round %d", round)
+
+		// delete a random subset of the entries
+		var deleted [][]byte
+		for key := range entries {
+			if r.Intn(3) == 0 {
+				deleted = append(deleted, []byte(key))
+			}
+		}
+		for _, key := range deleted {
+			require.NoError(seq.Delete(key))
+			delete(entries, string(key))
+		}
+		require.NoError(bat.DeleteBatch(deleted))
+		require.Equal(seq.RootHash(), bat.RootHash(), "round %d", round)
+	}
+
+	// the nodes of the batches are in the db
+	tr, err := NewTrie(KeyLengthOption(4), KVStoreOption(bat.DB()), RootHashOption(bat.RootHash()))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	for key, value := range entries {
+		v, err := tr.Get([]byte(key))
+		require.NoError(err)
+		require.Equal(value, v)
+	}
+}
+
+func TestBatchAllOrNothing(t *testing.T) {
+	require := require.New(t)
+	tr, err := NewTrie(KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	require.NoError(tr.UpsertBatch([][]byte{cat, rat, dog}, [][]byte{testV[2], testV[1], testV[3]}))
+	root := tr.RootHash()
+
+	// the batch deleting a key which doesn't exist deletes none of the keys
+	err = tr.DeleteBatch([][]byte{cat, egg, rat})
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Equal(root, tr.RootHash())
+	for _, key := range [][]byte{cat, rat, dog} {
+		_, err := tr.Get(key)
+		require.NoError(err)
+	}
+	// a key deleted twice doesn't exist the second time
+	err = tr.DeleteBatch([][]byte{cat, cat})
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Equal(root, tr.RootHash())
+	// the batch with an invalid key inserts none of the keys
+	require.Error(tr.UpsertBatch([][]byte{egg, {1, 2}}, [][]byte{testV[4], testV[0]}))
+	require.Equal(root, tr.RootHash())
+	_, err = tr.Get(egg)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Error(tr.UpsertBatch([][]byte{egg}, nil))
+
+	require.NoError(tr.DeleteBatch([][]byte{cat, rat, dog}))
+	require.True(tr.isEmptyRootHash(tr.RootHash()))
+	require.NoError(tr.UpsertBatch(nil, nil))
+	require.True(tr.isEmptyRootHash(tr.RootHash()))
+}
+
+func benchmarkKeys(n int) ([][]byte, [][]byte) {
+	keys := make([][]byte, n)
+	values := make([][]byte, n)
+	var k hash.Hash256
+	for i := range keys {
+		k = hash.Hash256b(k[:])
+		keys[i] = append([]byte{}, k[:20]...)
+		values[i] = testV[k[0]&7]
+	}
+	return keys, values
+}
+
+func BenchmarkUpsert10k(b *testing.B) {
+	keys, values := benchmarkKeys(10000)
+	for n := 0; n < b.N; n++ {
+		tr, err := NewTrie()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := tr.Start(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+		for i, key := range keys {
+			if err := tr.Upsert(key, values[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkUpsertBatch10k(b *testing.B) {
+	keys, values := benchmarkKeys(10000)
+	for n := 0; n < b.N; n++ {
+		tr, err := NewTrie()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := tr.Start(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+		if err := tr.UpsertBatch(keys, values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
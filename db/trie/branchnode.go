@@ -144,11 +144,11 @@ func (b *branchNode) upsert(tr Trie, key keyType, offset uint8, value []byte) (N
 	return b.updateChild(tr, offsetKey, newChild)
 }
 
-func (b *branchNode) search(tr Trie, key keyType, offset uint8) Node {
+func (b *branchNode) search(tr Trie, key keyType, offset uint8) (Node, error) {
 	trieMtc.WithLabelValues("branchNode", "search").Inc()
 	child, err := b.child(tr, key[offset])
-	if errors.Cause(err) == ErrNotExist {
-		return nil
+	if err != nil {
+		return nil, err
 	}
 	return child.search(tr, key, offset+1)
 }
@@ -185,7 +185,11 @@ func (b *branchNode) child(tr Trie, key byte) (Node, error) {
 	}
 	child, err := tr.loadNodeFromDB(h)
 	if err != nil {
-		return nil, errors.Errorf("failed to fetch node for key %x", h)
+		if errors.Cause(err) == ErrNotExist {
+			// a missing node isn't a missing child
+			return nil, errors.Wrapf(ErrInvalidTrie, "node %x doesn't exist", h)
+		}
+		return nil, errors.Wrapf(err, "failed to fetch node for key %x", h)
 	}
 	return child, nil
 }
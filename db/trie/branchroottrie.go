@@ -22,6 +22,7 @@ type (
 	// HashFunc defines a function to generate the hash which will be used as key in db
 	HashFunc       func([]byte) []byte
 	branchRootTrie struct {
+		// the writes hold the mutex exclusively, and the reads shared
 		mutex     sync.RWMutex
 		keyLength int
 		kvStore   KVStore
@@ -29,6 +30,14 @@ type (
 		root      *branchNode
 		rootHash  []byte
 		rootKey   string
+		// the number of the open iterators, which pin the nodes of their roots, and the nodes deleted meanwhile
+		pins   int
+		pinned map[string]struct{}
+		// the shared cache of the nodes, and the nodes put or deleted by the trie which bypass the cache
+		cache   *NodeCache
+		written map[string]struct{}
+		// compact writes the nodes in the compact format
+		compact bool
 	}
 )
 
@@ -46,7 +55,7 @@ func (tr *branchRootTrie) Start(ctx context.Context) error {
 		}
 	}
 
-	return tr.SetRootHash(tr.rootHash)
+	return tr.setRootHash(tr.rootHash)
 }
 
 func (tr *branchRootTrie) Stop(_ context.Context) error {
@@ -54,20 +63,31 @@ func (tr *branchRootTrie) Stop(_ context.Context) error {
 }
 
 func (tr *branchRootTrie) RootHash() []byte {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
 	return tr.rootHash
 }
 
+// SetRootHash loads and decodes the root node of the hash right away, so that a root missing from the store fails here
+// rather than the reads after it. The empty root hash, or an empty one, is the empty trie, which isn't read from the
+// store.
 func (tr *branchRootTrie) SetRootHash(rootHash []byte) error {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	return tr.setRootHash(rootHash)
+}
+
+func (tr *branchRootTrie) setRootHash(rootHash []byte) error {
 	if len(rootHash) == 0 {
 		rootHash = tr.emptyRootHash()
 	}
 	node, err := tr.loadNodeFromDB(rootHash)
 	if err != nil {
-		return err
+		return errors.Wrapf(ErrInvalidRoot, "failed to load root %x: %v", rootHash, err)
 	}
 	root, ok := node.(*branchNode)
 	if !ok {
-		return errors.Wrapf(ErrInvalidTrie, "root should be a branch")
+		return errors.Wrapf(ErrInvalidRoot, "root %x should be a branch", rootHash)
 	}
 	tr.resetRoot(root)
 
@@ -80,9 +100,11 @@ func (tr *branchRootTrie) Get(key []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	t := tr.root.search(tr, kt, 0)
-	if t == nil {
-		return nil, ErrNotExist
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+	t, err := tr.root.search(tr, kt, 0)
+	if err != nil {
+		return nil, err
 	}
 	if l, ok := t.(*leafNode); ok {
 		return l.Value(), nil
@@ -92,42 +114,112 @@ func (tr *branchRootTrie) Get(key []byte) ([]byte, error) {
 
 func (tr *branchRootTrie) Delete(key []byte) error {
 	trieMtc.WithLabelValues("root", "Delete").Inc()
-	kt, err := tr.checkKeyType(key)
-	if err != nil {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	if err := tr.delete(tr, key); err != nil {
 		return err
 	}
-	child, err := tr.root.child(tr, kt[0])
-	if err != nil {
-		return errors.Wrapf(ErrNotExist, "key %x does not exist", kt)
+	tr.resetRoot(tr.root)
+
+	return nil
+}
+
+func (tr *branchRootTrie) Upsert(key []byte, value []byte) error {
+	trieMtc.WithLabelValues("root", "Upsert").Inc()
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	if err := tr.upsert(tr, key, value); err != nil {
+		return err
 	}
-	newChild, err := child.delete(tr, kt, 1)
+	tr.resetRoot(tr.root)
+
+	return nil
+}
+
+func (tr *branchRootTrie) UpsertBatch(keys [][]byte, values [][]byte) error {
+	trieMtc.WithLabelValues("root", "UpsertBatch").Inc()
+	if len(keys) != len(values) {
+		return errors.Errorf("number of keys %d doesn't match number of values %d", len(keys), len(values))
+	}
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	return tr.batch(func(bt *batchTrie) error {
+		for i, key := range keys {
+			if err := tr.upsert(bt, key, values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (tr *branchRootTrie) DeleteBatch(keys [][]byte) error {
+	trieMtc.WithLabelValues("root", "DeleteBatch").Inc()
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	return tr.batch(func(bt *batchTrie) error {
+		for _, key := range keys {
+			if err := tr.delete(bt, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// batch applies the mutations with the nodes handled by a batchTrie, which hashes and writes the dirty nodes once at
+// the end. If any of the mutations fails, none of them is applied.
+func (tr *branchRootTrie) batch(apply func(*batchTrie) error) error {
+	rootHash := tr.rootHash
+	bt := newBatchTrie(tr)
+	if err := apply(bt); err != nil {
+		// the nodes mutated in memory are discarded, and the db isn't touched until the flush
+		if rerr := tr.setRootHash(rootHash); rerr != nil {
+			return errors.Wrapf(rerr, "failed to restore root hash after %v", err)
+		}
+		return err
+	}
+	return bt.flush()
+}
+
+// upsert inserts the entry with the nodes handled by ntr, and leaves the root hash to be reset by the caller
+func (tr *branchRootTrie) upsert(ntr Trie, key []byte, value []byte) error {
+	kt, err := tr.checkKeyType(key)
 	if err != nil {
 		return err
 	}
-	newRoot, err := tr.root.updateChild(tr, kt[0], newChild)
+	newRoot, err := tr.root.upsert(ntr, kt, 0, value)
 	if err != nil {
 		return err
 	}
-	tr.resetRoot(newRoot)
+	bn, ok := newRoot.(*branchNode)
+	if !ok {
+		panic("unexpected new root")
+	}
+	tr.root = bn
 
 	return nil
 }
 
-func (tr *branchRootTrie) Upsert(key []byte, value []byte) error {
-	trieMtc.WithLabelValues("root", "Upsert").Inc()
+// delete deletes the entry with the nodes handled by ntr, and leaves the root hash to be reset by the caller
+func (tr *branchRootTrie) delete(ntr Trie, key []byte) error {
 	kt, err := tr.checkKeyType(key)
 	if err != nil {
 		return err
 	}
-	newRoot, err := tr.root.upsert(tr, kt, 0, value)
+	child, err := tr.root.child(ntr, kt[0])
+	if err != nil {
+		return errors.Wrapf(ErrNotExist, "key %x does not exist", kt)
+	}
+	newChild, err := child.delete(ntr, kt, 1)
 	if err != nil {
 		return err
 	}
-	bn, ok := newRoot.(*branchNode)
-	if !ok {
-		panic("unexpected new root")
+	newRoot, err := tr.root.updateChild(ntr, kt[0], newChild)
+	if err != nil {
+		return err
 	}
-	tr.resetRoot(bn)
+	tr.root = newRoot
 
 	return nil
 }
@@ -136,8 +228,54 @@ func (tr *branchRootTrie) DB() KVStore {
 	return tr.kvStore
 }
 
+func (tr *branchRootTrie) Iterator(startKey []byte) (Iter, error) {
+	if len(startKey) > tr.keyLength {
+		return nil, errors.Errorf("invalid start key length %d", len(startKey))
+	}
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	return newOrderedIterator(tr, startKey)
+}
+
 func (tr *branchRootTrie) deleteNodeFromDB(tn Node) error {
-	return tr.kvStore.Delete(tr.nodeHash(tn))
+	return tr.deleteNode(tr.nodeHash(tn))
+}
+
+// deleteNode deletes the node of the hash from db, which is deferred until the last iterator is closed if there is any
+func (tr *branchRootTrie) deleteNode(h []byte) error {
+	if tr.cache != nil {
+		tr.written[string(h)] = struct{}{}
+		tr.cache.remove(h)
+	}
+	if tr.pins > 0 {
+		tr.pinned[string(h)] = struct{}{}
+		return nil
+	}
+	return tr.kvStore.Delete(h)
+}
+
+// pin defers the deletions of the nodes until unpin is called as many times
+func (tr *branchRootTrie) pin() {
+	if tr.pins == 0 {
+		tr.pinned = map[string]struct{}{}
+	}
+	tr.pins++
+}
+
+// unpin deletes the nodes deleted while they're pinned, once the last pin is released
+func (tr *branchRootTrie) unpin() error {
+	tr.pins--
+	if tr.pins > 0 {
+		return nil
+	}
+	pinned := tr.pinned
+	tr.pinned = nil
+	for h := range pinned {
+		if err := tr.kvStore.Delete([]byte(h)); err != nil {
+			return errors.Wrapf(err, "failed to delete node %x", h)
+		}
+	}
+	return nil
 }
 
 func (tr *branchRootTrie) putNodeIntoDB(tn Node) error {
@@ -145,7 +283,15 @@ func (tr *branchRootTrie) putNodeIntoDB(tn Node) error {
 	if tr.isEmptyRootHash(h) {
 		return nil
 	}
+	// the node put again isn't deleted by unpin
+	delete(tr.pinned, string(h))
+	if tr.cache != nil {
+		tr.written[string(h)] = struct{}{}
+	}
 	s := tn.serialize()
+	if tr.compact {
+		s = encodeCompact(tn)
+	}
 	return tr.kvStore.Put(h, s)
 }
 
@@ -153,22 +299,65 @@ func (tr *branchRootTrie) loadNodeFromDB(key []byte) (Node, error) {
 	if tr.isEmptyRootHash(key) {
 		return newEmptyBranchNode(), nil
 	}
+	if tr.cache == nil {
+		return tr.loadNode(key)
+	}
+	if _, ok := tr.written[string(key)]; ok {
+		return tr.loadNode(key)
+	}
+	if tn, ok := tr.cache.get(key); ok {
+		return tn, nil
+	}
+	s, err := tr.kvStore.Get(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get key %x", key)
+	}
+	tn, err := decodeNode(s)
+	if err != nil {
+		return nil, err
+	}
+	tr.cache.add(key, tn, len(s))
+	return tn, nil
+}
+
+// loadNode loads the node of the key from db
+func (tr *branchRootTrie) loadNode(key []byte) (Node, error) {
 	s, err := tr.kvStore.Get(key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get key %x", key)
 	}
+	return decodeNode(s)
+}
+
+func decodeNode(s []byte) (Node, error) {
+	if len(s) > 0 && s[0] == compactFormat {
+		tn, err := decodeCompact(append([]byte{}, s...))
+		if err != nil {
+			return nil, err
+		}
+		// the protobuf serialization, which is hashed, is set before the node is shared by the cache
+		tn.serialize()
+		return tn, nil
+	}
 	pb := triepb.NodePb{}
 	if err := proto.Unmarshal(s, &pb); err != nil {
 		return nil, err
 	}
+	// the serialization is kept, so that the nodes shared by the cache are read only by the reads
 	if pbBranch := pb.GetBranch(); pbBranch != nil {
-		return newBranchNodeFromProtoPb(pbBranch), nil
+		b := newBranchNodeFromProtoPb(pbBranch)
+		b.ser = s
+		return b, nil
 	}
 	if pbLeaf := pb.GetLeaf(); pbLeaf != nil {
-		return newLeafNodeFromProtoPb(pbLeaf), nil
+		l := newLeafNodeFromProtoPb(pbLeaf)
+		l.ser = s
+		return l, nil
 	}
 	if pbExtend := pb.GetExtend(); pbExtend != nil {
-		return newExtensionNodeFromProtoPb(pbExtend), nil
+		e := newExtensionNodeFromProtoPb(pbExtend)
+		e.ser = s
+		return e, nil
 	}
 	return nil, errors.New("invalid node type")
 }
@@ -0,0 +1,174 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// The nodes are stored either in the protobuf format, which is the one hashed, or in the compact format, which starts
+// with compactFormat followed by the type of the node. A branch is followed by the length of the hashes, the indices
+// of the children preceded by the number of them, or the bitmap of them if it's shorter, and the hashes of them. A
+// leaf is followed by the uvarint length of the key, the key and the value, and an extension by the uvarint length of
+// the path, the path and the hash of the child. The first byte of a protobuf node is the tag of a field, which is never
+// zero, so the formats are told apart.
+const (
+	compactFormat byte = 0
+
+	compactIndexedBranch byte = iota
+	compactBitmapBranch
+	compactLeaf
+	compactExtension
+
+	// bitmapSize is the size of the bitmap of the children of a branch, which is used by a branch of as many children
+	bitmapSize = radix / 8
+)
+
+// CompactNodeOption writes the nodes in the compact format, while the nodes are read in either format. The hashes of
+// the nodes, and the proofs, are the ones of the protobuf format regardless of the option.
+func CompactNodeOption() Option {
+	return func(tr Trie) error {
+		switch t := tr.(type) {
+		case *branchRootTrie:
+			t.compact = true
+		default:
+			return errors.New("invalid trie type")
+		}
+		return nil
+	}
+}
+
+// encodeCompact returns the node in the compact format, or the protobuf format for a branch whose hashes are of
+// different lengths
+func encodeCompact(tn Node) []byte {
+	switch node := tn.(type) {
+	case *branchNode:
+		return encodeCompactBranch(node)
+	case *leafNode:
+		return appendWithLength([]byte{compactFormat, compactLeaf}, node.key, node.value)
+	case *extensionNode:
+		return appendWithLength([]byte{compactFormat, compactExtension}, node.path, node.childHash)
+	}
+	return tn.serialize()
+}
+
+func encodeCompactBranch(b *branchNode) []byte {
+	hashLen := -1
+	for _, h := range b.hashes {
+		if hashLen >= 0 && len(h) != hashLen || len(h) > 255 {
+			return b.serialize()
+		}
+		hashLen = len(h)
+	}
+	if hashLen < 0 {
+		hashLen = 0
+	}
+	n := len(b.hashes)
+	var s []byte
+	if n < bitmapSize {
+		s = make([]byte, 0, 4+n*(1+hashLen))
+		s = append(s, compactFormat, compactIndexedBranch, byte(hashLen), byte(n))
+		for i := 0; i < radix; i++ {
+			if _, ok := b.hashes[byte(i)]; ok {
+				s = append(s, byte(i))
+			}
+		}
+	} else {
+		s = make([]byte, 3+bitmapSize, 3+bitmapSize+n*hashLen)
+		s[0], s[1], s[2] = compactFormat, compactBitmapBranch, byte(hashLen)
+		for i := range b.hashes {
+			s[3+i/8] |= 1 << (i % 8)
+		}
+	}
+	for i := 0; i < radix; i++ {
+		if h, ok := b.hashes[byte(i)]; ok {
+			s = append(s, h...)
+		}
+	}
+	return s
+}
+
+// decodeCompact decodes a node in the compact format
+func decodeCompact(s []byte) (Node, error) {
+	if len(s) < 2 || s[0] != compactFormat {
+		return nil, errors.New("invalid compact node")
+	}
+	switch s[1] {
+	case compactIndexedBranch, compactBitmapBranch:
+		return decodeCompactBranch(s[1], s[2:])
+	case compactLeaf:
+		key, value, err := splitWithLength(s[2:])
+		if err != nil {
+			return nil, err
+		}
+		return &leafNode{key: key, value: value}, nil
+	case compactExtension:
+		path, childHash, err := splitWithLength(s[2:])
+		if err != nil {
+			return nil, err
+		}
+		return &extensionNode{path: path, childHash: childHash}, nil
+	}
+	return nil, errors.Errorf("invalid compact node type %d", s[1])
+}
+
+func decodeCompactBranch(nodeType byte, s []byte) (*branchNode, error) {
+	if len(s) < 1 {
+		return nil, errors.New("missing hash length of compact branch")
+	}
+	hashLen := int(s[0])
+	s = s[1:]
+	var indices []byte
+	switch nodeType {
+	case compactIndexedBranch:
+		if len(s) < 1 || len(s) < 1+int(s[0]) {
+			return nil, errors.New("invalid indices of compact branch")
+		}
+		indices, s = s[1:1+int(s[0])], s[1+int(s[0]):]
+	default:
+		if len(s) < bitmapSize {
+			return nil, errors.New("invalid bitmap of compact branch")
+		}
+		for i := 0; i < radix; i++ {
+			if s[i/8]&(1<<(i%8)) != 0 {
+				indices = append(indices, byte(i))
+			}
+		}
+		s = s[bitmapSize:]
+	}
+	if len(s) != len(indices)*hashLen {
+		return nil, errors.Errorf("%d bytes of %d hashes in compact branch", len(s), len(indices))
+	}
+	b := newEmptyBranchNode()
+	for i, index := range indices {
+		if _, ok := b.hashes[index]; ok {
+			return nil, errors.Errorf("duplicate child %d in compact branch", index)
+		}
+		b.hashes[index] = s[i*hashLen : (i+1)*hashLen]
+	}
+	return b, nil
+}
+
+// appendWithLength appends the uvarint length of a, a and b to s
+func appendWithLength(s, a, b []byte) []byte {
+	var l [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(l[:], uint64(len(a)))
+	s = append(s, l[:n]...)
+	s = append(s, a...)
+	return append(s, b...)
+}
+
+// splitWithLength splits the bytes encoded by appendWithLength
+func splitWithLength(s []byte) ([]byte, []byte, error) {
+	l, n := binary.Uvarint(s)
+	if n <= 0 || l > uint64(len(s)-n) {
+		return nil, nil, errors.New("invalid length in compact node")
+	}
+	return s[n : n+int(l)], s[n+int(l):], nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactNodeRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	branch := func(n int) *branchNode {
+		b := newEmptyBranchNode()
+		for i := 0; i < n; i++ {
+			h := hash.Hash160b([]byte{byte(i)})
+			b.hashes[byte(i*7)] = h[:]
+		}
+		return b
+	}
+	h := hash.Hash160b([]byte("child"))
+	nodes := []Node{
+		branch(1),
+		branch(2),
+		branch(bitmapSize - 1),
+		branch(bitmapSize),
+		branch(radix),
+		&leafNode{key: cat, value: []byte("value")},
+		&leafNode{key: cat},
+		&leafNode{key: bytes.Repeat([]byte{1}, 200), value: bytes.Repeat([]byte{2}, 1000)},
+		&extensionNode{path: []byte{1, 2, 3}, childHash: h[:]},
+		&extensionNode{path: bytes.Repeat([]byte{3}, 128), childHash: h[:]},
+	}
+	for _, tn := range nodes {
+		s := encodeCompact(tn)
+		require.Equal(compactFormat, s[0])
+		decoded, err := decodeNode(s)
+		require.NoError(err)
+		require.Equal(tn.Type(), decoded.Type())
+		// the node hashes the same protobuf serialization in both formats
+		require.Equal(tn.serialize(), decoded.serialize())
+		legacy, err := decodeNode(tn.serialize())
+		require.NoError(err)
+		require.Equal(s, encodeCompact(legacy))
+	}
+	for _, n := range []int{1, 8, bitmapSize, radix} {
+		require.True(len(encodeCompact(branch(n))) < len(branch(n).serialize()), "%d children", n)
+	}
+
+	// the hashes of different lengths are kept in the protobuf format
+	b := branch(2)
+	b.hashes[255] = []byte{1, 2, 3}
+	require.Equal(b.serialize(), encodeCompact(b))
+
+	for _, s := range [][]byte{
+		{compactFormat},
+		{compactFormat, 9},
+		{compactFormat, compactIndexedBranch},
+		{compactFormat, compactIndexedBranch, 20, 2, 1},
+		{compactFormat, compactIndexedBranch, 1, 2, 1, 1, 7, 8},
+		{compactFormat, compactBitmapBranch, 20, 1},
+		{compactFormat, compactLeaf, 5, 1, 2},
+		{compactFormat, compactExtension, 0x80},
+	} {
+		_, err := decodeNode(s)
+		require.Error(err, "%x", s)
+	}
+}
+
+func TestCompactNodeCrossFormat(t *testing.T) {
+	require := require.New(t)
+
+	// the trie written in both formats by turns has the same root as the one written in the protobuf format
+	legacy, err := NewTrie(KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(legacy.Start(context.Background()))
+	kv := newInMemKVStore()
+	var root []byte
+	keys := [][]byte{cat, rat, dog, egg, ham, car}
+	for round := 0; round < 4; round++ {
+		opts := []Option{KVStoreOption(kv), KeyLengthOption(8), RootHashOption(root)}
+		if round%2 == 1 {
+			opts = append(opts, CompactNodeOption())
+		}
+		tr, err := NewTrie(opts...)
+		require.NoError(err)
+		require.NoError(tr.Start(context.Background()))
+		if round == 0 {
+			// the leaves of the keys put only once are kept in the protobuf format
+			for _, k := range [][]byte{{2, 1}, {3, 1}} {
+				k = append(k, cat[2:]...)
+				require.NoError(tr.Upsert(k, testV[0]))
+				require.NoError(legacy.Upsert(k, testV[0]))
+			}
+		}
+		for i, k := range keys {
+			v := testV[(i+round)%len(testV)]
+			if round > 0 && (i+round)%3 == 0 {
+				require.NoError(tr.Delete(k))
+				require.NoError(legacy.Delete(k))
+			} else {
+				require.NoError(tr.Upsert(k, v))
+				require.NoError(legacy.Upsert(k, v))
+			}
+			require.Equal(legacy.RootHash(), tr.RootHash())
+		}
+		root = tr.RootHash()
+		require.NoError(tr.Stop(context.Background()))
+	}
+	formats := map[bool]int{}
+	for _, s := range kv.(*inMemKVStore).kvpairs {
+		formats[s[0] == compactFormat]++
+	}
+	require.True(formats[true] > 0 && formats[false] > 0, "%v", formats)
+
+	// the reads of the nodes in both formats have the same values and proofs
+	tr, err := NewTrie(KVStoreOption(kv), KeyLengthOption(8), RootHashOption(root))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	for _, k := range keys {
+		v, proof, err := tr.GetWithProof(k)
+		lv, lproof, lerr := legacy.GetWithProof(k)
+		require.Equal(errors.Cause(lerr), errors.Cause(err))
+		require.Equal(lv, v)
+		require.Equal(lproof, proof)
+		require.NoError(VerifyProof(root, k, v, proof))
+	}
+}
+
+func TestCompactNodeSavings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestCompactNodeSavings in short mode.")
+	}
+	require := require.New(t)
+
+	// a trie of 1M accounts of 20-byte addresses, whose nodes are stored in both formats
+	tr, err := NewTrie()
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	const accounts, batchSize = 1 << 20, 1 << 14
+	value := make([]byte, 64)
+	for i := 0; i < accounts; i += batchSize {
+		keys, values := make([][]byte, batchSize), make([][]byte, batchSize)
+		for j := range keys {
+			var n [8]byte
+			binary.BigEndian.PutUint64(n[:], uint64(i+j))
+			k := hash.Hash160b(n[:])
+			keys[j] = k[:]
+			binary.BigEndian.PutUint64(value, uint64(i+j))
+			values[j] = append([]byte{}, value...)
+		}
+		require.NoError(tr.UpsertBatch(keys, values))
+	}
+	var legacy, compact int
+	for _, s := range tr.(*branchRootTrie).kvStore.(*inMemKVStore).kvpairs {
+		tn, err := decodeNode(s)
+		require.NoError(err)
+		legacy += len(s)
+		compact += len(encodeCompact(tn))
+	}
+	t.Logf(
+		"%d nodes of %d accounts: %d bytes in protobuf, %d bytes compact, %.1f%% saved",
+		len(tr.(*branchRootTrie).kvStore.(*inMemKVStore).kvpairs),
+		accounts,
+		legacy,
+		compact,
+		100*float64(legacy-compact)/float64(legacy),
+	)
+	require.True(compact < legacy)
+}
@@ -129,15 +129,15 @@ func (e *extensionNode) upsert(tr Trie, key keyType, offset uint8, value []byte)
 	return newExtensionNodeAndPutIntoDB(tr, key[offset:offset+matched], bnode)
 }
 
-func (e *extensionNode) search(tr Trie, key keyType, offset uint8) Node {
+func (e *extensionNode) search(tr Trie, key keyType, offset uint8) (Node, error) {
 	trieMtc.WithLabelValues("extensionNode", "search").Inc()
 	matched := e.commonPrefixLength(key[offset:])
 	if matched != uint8(len(e.path)) {
-		return nil
+		return nil, ErrNotExist
 	}
 	child, err := e.child(tr)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	return child.search(tr, key, offset+matched)
@@ -6,7 +6,11 @@
 
 package trie
 
-import "github.com/pkg/errors"
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
 
 // ErrEndOfIterator defines an error which will be returned
 var ErrEndOfIterator = errors.New("hit the end of the iterator, no more item")
@@ -44,7 +48,7 @@ func (li *LeafIterator) Next() ([]byte, []byte, error) {
 			key := node.Key()
 			value := node.Value()
 
-			return append(key[:0:0], key...), append(value, value...), nil
+			return append(key[:0:0], key...), append(value[:0:0], value...), nil
 		}
 		children, err := node.children(li.tr)
 		if err != nil {
@@ -55,3 +59,150 @@ func (li *LeafIterator) Next() ([]byte, []byte, error) {
 
 	return nil, nil, ErrEndOfIterator
 }
+
+// Iter iterates the leaves of a trie in the order of the keys, and is closed once it hits the end
+type Iter interface {
+	Iterator
+	// Close releases the root pinned by the iterator
+	Close() error
+}
+
+type (
+	// iterFrame is a branch on the path of the iterator, along with the index of its next child to visit
+	iterFrame struct {
+		branch *branchNode
+		next   int
+	}
+
+	// orderedIterator traverses the trie in order from its root at the creation, which is pinned, so that the nodes
+	// deleted by the mutations afterwards are kept until the iterator is closed. It only keeps the branches on the
+	// path to the current leaf.
+	orderedIterator struct {
+		tr      *branchRootTrie
+		start   []byte
+		stack   []iterFrame
+		pending Node
+		closed  bool
+	}
+)
+
+func newOrderedIterator(tr *branchRootTrie, startKey []byte) (*orderedIterator, error) {
+	root, err := tr.loadNodeFromDB(tr.rootHash)
+	if err != nil {
+		return nil, err
+	}
+	it := &orderedIterator{tr: tr, start: append(startKey[:0:0], startKey...)}
+	if err := it.seek(root, 0); err != nil {
+		return nil, err
+	}
+	tr.pin()
+	return it, nil
+}
+
+// seek pushes the branches on the path of the start key under the node, and leaves the first node whose leaves are
+// all no less than the start key pending
+func (it *orderedIterator) seek(tn Node, offset int) error {
+	if offset >= len(it.start) {
+		it.pending = tn
+		return nil
+	}
+	switch node := tn.(type) {
+	case *branchNode:
+		idx := it.start[offset]
+		h, ok := node.hashes[idx]
+		if !ok {
+			it.stack = append(it.stack, iterFrame{branch: node, next: int(idx)})
+			return nil
+		}
+		it.stack = append(it.stack, iterFrame{branch: node, next: int(idx) + 1})
+		child, err := it.tr.loadNodeFromDB(h)
+		if err != nil {
+			return err
+		}
+		return it.seek(child, offset+1)
+	case *extensionNode:
+		rest := it.start[offset:]
+		n := len(node.path)
+		if len(rest) < n {
+			n = len(rest)
+		}
+		switch c := bytes.Compare(node.path[:n], rest[:n]); {
+		case c > 0:
+			it.pending = node
+		case c == 0:
+			child, err := node.child(it.tr)
+			if err != nil {
+				return err
+			}
+			return it.seek(child, offset+len(node.path))
+		}
+		return nil
+	case *leafNode:
+		if bytes.Compare(node.key, it.start) >= 0 {
+			it.pending = node
+		}
+		return nil
+	default:
+		return errors.Wrapf(ErrInvalidTrie, "unexpected node type %d", tn.Type())
+	}
+}
+
+// Next returns the key and the value of the next leaf
+func (it *orderedIterator) Next() ([]byte, []byte, error) {
+	if it.closed {
+		return nil, nil, ErrEndOfIterator
+	}
+	for {
+		if it.pending != nil {
+			tn := it.pending
+			it.pending = nil
+			switch node := tn.(type) {
+			case *leafNode:
+				key, value := node.Key(), node.Value()
+				return append(key[:0:0], key...), append(value[:0:0], value...), nil
+			case *extensionNode:
+				child, err := node.child(it.tr)
+				if err != nil {
+					return nil, nil, err
+				}
+				it.pending = child
+			case *branchNode:
+				it.stack = append(it.stack, iterFrame{branch: node})
+			}
+			continue
+		}
+		if len(it.stack) == 0 {
+			if err := it.Close(); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, ErrEndOfIterator
+		}
+		top := &it.stack[len(it.stack)-1]
+		for ; top.next < radix; top.next++ {
+			if _, ok := top.branch.hashes[byte(top.next)]; ok {
+				break
+			}
+		}
+		if top.next == radix {
+			it.stack = it.stack[:len(it.stack)-1]
+			continue
+		}
+		child, err := it.tr.loadNodeFromDB(top.branch.hashes[byte(top.next)])
+		if err != nil {
+			return nil, nil, err
+		}
+		top.next++
+		it.pending = child
+	}
+}
+
+// Close releases the root pinned by the iterator, after which it hits the end
+func (it *orderedIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	it.stack = nil
+	it.pending = nil
+	return it.tr.unpin()
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// iterateAll returns the keys and the values of the iterator until its end
+func iterateAll(t *testing.T, it Iter) ([][]byte, [][]byte) {
+	var keys, values [][]byte
+	for {
+		key, value, err := it.Next()
+		if errors.Cause(err) == ErrEndOfIterator {
+			return keys, values
+		}
+		require.NoError(t, err)
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+}
+
+func TestOrderedIterator(t *testing.T) {
+	require := require.New(t)
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	tr, err := NewTrie(KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	// the empty trie has no entries
+	it, err := tr.Iterator(nil)
+	require.NoError(err)
+	keys, _ := iterateAll(t, it)
+	require.Empty(keys)
+
+	entries := map[string][]byte{}
+	batchKeys := make([][]byte, 100000)
+	batchValues := make([][]byte, len(batchKeys))
+	for i := range batchKeys {
+		batchKeys[i] = make([]byte, 8)
+		r.Read(batchKeys[i])
+		batchValues[i] = testV[r.Intn(len(testV))]
+		entries[string(batchKeys[i])] = batchValues[i]
+	}
+	require.NoError(tr.UpsertBatch(batchKeys, batchValues))
+	sorted := make([]string, 0, len(entries))
+	for key := range entries {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	check := func(start []byte, keys, values [][]byte) {
+		from := sort.SearchStrings(sorted, string(start))
+		require.Equal(len(sorted)-from, len(keys), "start %x", start)
+		for i, key := range keys {
+			require.Equal(sorted[from+i], string(key))
+			require.Equal(entries[string(key)], values[i])
+		}
+	}
+
+	it, err = tr.Iterator(nil)
+	require.NoError(err)
+	keys, values := iterateAll(t, it)
+	check(nil, keys, values)
+	// resume from a key in the trie, a key not in the trie, and a prefix
+	mid := []byte(sorted[len(sorted)/2])
+	notIn := append([]byte{}, mid...)
+	notIn[7]++
+	for _, start := range [][]byte{mid, notIn, mid[:3], {0xff}, {}} {
+		it, err := tr.Iterator(start)
+		require.NoError(err)
+		keys, values := iterateAll(t, it)
+		check(start, keys, values)
+	}
+	_, err = tr.Iterator(make([]byte, 9))
+	require.Error(err)
+}
+
+func TestOrderedIteratorPinnedRoot(t *testing.T) {
+	require := require.New(t)
+	tr, err := NewTrie(KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	for i, key := range [][]byte{ham, car, cat, rat, egg} {
+		require.NoError(tr.Upsert(key, testV[i]))
+	}
+
+	it, err := tr.Iterator(car)
+	require.NoError(err)
+	key, value, err := it.Next()
+	require.NoError(err)
+	require.Equal(car, key)
+	require.Equal(testV[1], value)
+	// the entries added, updated and deleted after the creation aren't seen
+	require.NoError(tr.Upsert(dog, testV[3]))
+	require.NoError(tr.Upsert(egg, testV[7]))
+	require.NoError(tr.Delete(rat))
+	require.NoError(tr.DeleteBatch([][]byte{cat}))
+	keys, values := iterateAll(t, it)
+	require.Equal([][]byte{cat, rat, egg}, keys)
+	require.Equal([][]byte{testV[2], testV[3], testV[4]}, values)
+	_, _, err = it.Next()
+	require.Equal(ErrEndOfIterator, errors.Cause(err))
+
+	// the nodes deleted are released once the iterators are closed
+	it, err = tr.Iterator(nil)
+	require.NoError(err)
+	it2, err := tr.Iterator(nil)
+	require.NoError(err)
+	root := tr.RootHash()
+	require.NoError(tr.Delete(ham))
+	require.NoError(it.Close())
+	require.NoError(it.Close())
+	_, err = tr.DB().Get(root)
+	require.NoError(err)
+	keys, _ = iterateAll(t, it2)
+	require.Equal([][]byte{ham, car, egg, dog}, keys)
+	_, err = tr.DB().Get(root)
+	require.Error(err)
+
+	// the live entries are intact
+	it, err = tr.Iterator(nil)
+	require.NoError(err)
+	keys, values = iterateAll(t, it)
+	require.Equal([][]byte{car, egg, dog}, keys)
+	require.Equal([][]byte{testV[1], testV[7], testV[3]}, values)
+}
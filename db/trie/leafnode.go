@@ -90,13 +90,13 @@ func (l *leafNode) upsert(tr Trie, key keyType, offset uint8, value []byte) (Nod
 	return newExtensionNodeAndPutIntoDB(tr, l.key[offset:offset+matched], bnode)
 }
 
-func (l *leafNode) search(_ Trie, key keyType, offset uint8) Node {
+func (l *leafNode) search(_ Trie, key keyType, offset uint8) (Node, error) {
 	trieMtc.WithLabelValues("leafNode", "search").Inc()
 	if !bytes.Equal(l.key[offset:], key[offset:]) {
-		return nil
+		return nil, ErrNotExist
 	}
 
-	return l
+	return l, nil
 }
 
 func (l *leafNode) serialize() []byte {
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var nodeCacheMtc = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "iotex_trie_node_cache",
+		Help: "IoTeX trie node cache",
+	},
+	[]string{"type"},
+)
+
+func init() {
+	prometheus.MustRegister(nodeCacheMtc)
+}
+
+type (
+	// NodeCache is an LRU cache of the decoded nodes keyed by their hashes. As a node is addressed by the hash of its
+	// content, the cache is safe to share among the tries reading the same committed nodes. A trie bypasses the cache
+	// for the nodes it puts or deletes itself, so that the uncommitted nodes aren't shared.
+	NodeCache struct {
+		mutex      sync.Mutex
+		maxEntries int
+		maxBytes   int
+		size       int
+		lru        *list.List
+		entries    map[string]*list.Element
+	}
+
+	nodeCacheEntry struct {
+		key  string
+		node Node
+		size int
+	}
+)
+
+// NewNodeCache returns a node cache holding up to maxEntries nodes and maxBytes bytes of serialized nodes, where 0
+// means there is no limit
+func NewNodeCache(maxEntries, maxBytes int) (*NodeCache, error) {
+	if maxEntries < 0 || maxBytes < 0 {
+		return nil, errors.Errorf("invalid limits of node cache, entries %d, bytes %d", maxEntries, maxBytes)
+	}
+	if maxEntries == 0 && maxBytes == 0 {
+		return nil, errors.New("node cache without limits")
+	}
+	return &NodeCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+	}, nil
+}
+
+// Len returns the number of the nodes in the cache
+func (c *NodeCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+// SizeInBytes returns the total size of the keys and the serialized nodes in the cache
+func (c *NodeCache) SizeInBytes() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.size
+}
+
+// get returns a copy of the node of the key, which the trie is free to mutate
+func (c *NodeCache) get(key []byte) (Node, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[string(key)]
+	if !ok {
+		nodeCacheMtc.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	nodeCacheMtc.WithLabelValues("hit").Inc()
+	c.lru.MoveToFront(e)
+	return cloneNode(e.Value.(*nodeCacheEntry).node), true
+}
+
+// add adds a copy of the node of the key, whose serialization is of the size, and evicts the least recently used
+// nodes beyond the limits
+func (c *NodeCache) add(key []byte, tn Node, size int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[string(key)]; ok {
+		return
+	}
+	entry := &nodeCacheEntry{key: string(key), node: cloneNode(tn), size: len(key) + size}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += entry.size
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		c.removeElement(c.lru.Back())
+		nodeCacheMtc.WithLabelValues("eviction").Inc()
+	}
+}
+
+// remove removes the node of the key
+func (c *NodeCache) remove(key []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if e, ok := c.entries[string(key)]; ok {
+		c.removeElement(e)
+	}
+}
+
+func (c *NodeCache) removeElement(e *list.Element) {
+	entry := c.lru.Remove(e).(*nodeCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// cloneNode returns a copy of the node, which doesn't share anything the trie mutates with the node. The trie mutates
+// the children of a branch in place, and replaces the slices of the other nodes.
+func cloneNode(tn Node) Node {
+	switch n := tn.(type) {
+	case *branchNode:
+		c := &branchNode{hashes: make(map[byte][]byte, len(n.hashes)), ser: n.ser}
+		for i, h := range n.hashes {
+			c.hashes[i] = h
+		}
+		return c
+	case *extensionNode:
+		c := *n
+		return &c
+	case *leafNode:
+		c := *n
+		return &c
+	default:
+		panic("unexpected node type")
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// overlayKVStore is a KVStore buffering the writes over a base KVStore, like the buffer of a working set
+type overlayKVStore struct {
+	KVStore
+	base KVStore
+}
+
+func newOverlayKVStore(base KVStore) *overlayKVStore {
+	return &overlayKVStore{KVStore: newInMemKVStore(), base: base}
+}
+
+func (s *overlayKVStore) Get(k []byte) ([]byte, error) {
+	v, err := s.KVStore.Get(k)
+	if errors.Cause(err) == ErrNotExist {
+		return s.base.Get(k)
+	}
+	return v, err
+}
+
+// countingKVStore is a KVStore counting the gets
+type countingKVStore struct {
+	KVStore
+	gets int
+}
+
+func (s *countingKVStore) Get(k []byte) ([]byte, error) {
+	s.gets++
+	return s.KVStore.Get(k)
+}
+
+func TestNodeCache(t *testing.T) {
+	require := require.New(t)
+	_, err := NewNodeCache(0, 0)
+	require.Error(err)
+	_, err = NewNodeCache(-1, 100)
+	require.Error(err)
+
+	c, err := NewNodeCache(2, 0)
+	require.NoError(err)
+	b := &branchNode{hashes: map[byte][]byte{1: cat}}
+	c.add([]byte("b"), b, 10)
+	c.add([]byte("l"), &leafNode{key: cat, value: testV[0]}, 20)
+	// the node returned is a copy
+	tn, ok := c.get([]byte("b"))
+	require.True(ok)
+	tn.(*branchNode).hashes[2] = rat
+	require.Equal(1, len(b.hashes))
+	tn, ok = c.get([]byte("b"))
+	require.True(ok)
+	require.Equal(1, len(tn.(*branchNode).hashes))
+	require.Equal(2, c.Len())
+	require.Equal(32, c.SizeInBytes())
+	// the least recently used node is evicted
+	c.add([]byte("e"), &extensionNode{path: []byte{1}, childHash: cat}, 30)
+	require.Equal(2, c.Len())
+	_, ok = c.get([]byte("l"))
+	require.False(ok)
+	_, ok = c.get([]byte("b"))
+	require.True(ok)
+	c.remove([]byte("b"))
+	require.Equal(1, c.Len())
+	require.Equal(31, c.SizeInBytes())
+
+	// the size is limited as well
+	c, err = NewNodeCache(0, 50)
+	require.NoError(err)
+	c.add([]byte("1"), &leafNode{key: cat, value: testV[0]}, 20)
+	c.add([]byte("2"), &leafNode{key: rat, value: testV[1]}, 20)
+	c.add([]byte("3"), &leafNode{key: dog, value: testV[2]}, 20)
+	require.Equal(2, c.Len())
+	require.Equal(42, c.SizeInBytes())
+	_, ok = c.get([]byte("1"))
+	require.False(ok)
+}
+
+func TestNodeCacheDifferential(t *testing.T) {
+	require := require.New(t)
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	// the cache is small, so that the nodes are evicted
+	c, err := NewNodeCache(16, 0)
+	require.NoError(err)
+	plain, cached := newInMemKVStore(), newInMemKVStore()
+	var plainRoot, cachedRoot []byte
+	for block := 0; block < 50; block++ {
+		// a trie per block, like the working sets
+		newTrie := func(kv KVStore, root []byte, opts ...Option) Trie {
+			tr, err := NewTrie(append(opts, KeyLengthOption(4), KVStoreOption(kv), RootHashOption(root))...)
+			require.NoError(err)
+			require.NoError(tr.Start(context.Background()))
+			return tr
+		}
+		ptr := newTrie(plain, plainRoot)
+		ctr := newTrie(cached, cachedRoot, NodeCacheOption(c))
+		keys, values := randomBatch(r, 1+r.Intn(20))
+		for i, key := range keys {
+			if r.Intn(4) == 0 {
+				perr, cerr := ptr.Delete(key), ctr.Delete(key)
+				require.Equal(errors.Cause(perr), errors.Cause(cerr))
+				continue
+			}
+			require.NoError(ptr.Upsert(key, values[i]))
+			require.NoError(ctr.Upsert(key, values[i]))
+		}
+		require.Equal(ptr.RootHash(), ctr.RootHash(), "block %d", block)
+		for _, key := range keys {
+			pv, perr := ptr.Get(key)
+			cv, cerr := ctr.Get(key)
+			require.Equal(errors.Cause(perr), errors.Cause(cerr))
+			require.Equal(pv, cv)
+		}
+		plainRoot, cachedRoot = ptr.RootHash(), ctr.RootHash()
+	}
+	require.True(c.Len() <= 16)
+}
+
+func TestNodeCacheUncommittedNodes(t *testing.T) {
+	require := require.New(t)
+	c, err := NewNodeCache(1000, 0)
+	require.NoError(err)
+	committed := newInMemKVStore()
+	tr, err := NewTrie(KeyLengthOption(8), KVStoreOption(committed), NodeCacheOption(c))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	require.NoError(tr.Upsert(cat, testV[2]))
+	require.NoError(tr.Upsert(rat, testV[1]))
+	root := tr.RootHash()
+
+	// two working sets share the cache
+	newTrie := func(kv KVStore) Trie {
+		tr, err := NewTrie(KeyLengthOption(8), KVStoreOption(kv), RootHashOption(root), NodeCacheOption(c))
+		require.NoError(err)
+		require.NoError(tr.Start(context.Background()))
+		return tr
+	}
+	ws1 := newTrie(newOverlayKVStore(committed))
+	ws2 := newTrie(newOverlayKVStore(committed))
+	v, err := ws2.Get(cat)
+	require.NoError(err)
+	require.Equal(testV[2], v)
+	require.True(c.Len() > 0)
+	// the nodes put by one of them aren't cached, and the other doesn't see them
+	require.NoError(ws1.Upsert(dog, testV[3]))
+	require.NoError(ws1.Delete(cat))
+	other, err := NewTrie(KeyLengthOption(8), KVStoreOption(committed), RootHashOption(ws1.RootHash()), NodeCacheOption(c))
+	require.NoError(err)
+	require.Error(other.Start(context.Background()))
+	_, err = ws2.Get(dog)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	v, err = ws2.Get(cat)
+	require.NoError(err)
+	require.Equal(testV[2], v)
+	_, err = ws1.Get(cat)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	v, err = ws1.Get(dog)
+	require.NoError(err)
+	require.Equal(testV[3], v)
+}
+
+// BenchmarkNodeCacheReplay replays 1k blocks on 10k accounts, each of which reads 200 accounts and updates 50 with a
+// trie of its own, and reports the gets of the nodes from the kvStore per block
+func BenchmarkNodeCacheReplay(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	accounts, values := benchmarkKeys(10000)
+	type block struct {
+		reads, writes []int
+	}
+	blocks := make([]block, 1000)
+	for i := range blocks {
+		blocks[i].reads = make([]int, 200)
+		for j := range blocks[i].reads {
+			blocks[i].reads[j] = r.Intn(len(accounts))
+		}
+		blocks[i].writes = make([]int, 50)
+		for j := range blocks[i].writes {
+			blocks[i].writes[j] = r.Intn(len(accounts))
+		}
+	}
+	replay := func(b *testing.B, cache func() *NodeCache) {
+		gets := 0
+		for n := 0; n < b.N; n++ {
+			b.StopTimer()
+			kv := &countingKVStore{KVStore: newInMemKVStore()}
+			tr, err := NewTrie(KVStoreOption(kv))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := tr.Start(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			if err := tr.UpsertBatch(accounts, values); err != nil {
+				b.Fatal(err)
+			}
+			root := tr.RootHash()
+			c := cache()
+			kv.gets = 0
+			b.StartTimer()
+			for _, blk := range blocks {
+				opts := []Option{KVStoreOption(kv), RootHashOption(root)}
+				if c != nil {
+					opts = append(opts, NodeCacheOption(c))
+				}
+				tr, err := NewTrie(opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := tr.Start(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+				for _, i := range blk.reads {
+					if _, err := tr.Get(accounts[i]); err != nil {
+						b.Fatal(err)
+					}
+				}
+				for _, i := range blk.writes {
+					if err := tr.Upsert(accounts[i], testV[r.Intn(len(testV))]); err != nil {
+						b.Fatal(err)
+					}
+				}
+				root = tr.RootHash()
+			}
+			gets += kv.gets
+		}
+		b.ReportMetric(float64(gets)/float64(b.N*len(blocks)), "gets/block")
+	}
+	b.Run("uncached", func(b *testing.B) {
+		replay(b, func() *NodeCache { return nil })
+	})
+	b.Run("cached", func(b *testing.B) {
+		replay(b, func() *NodeCache {
+			c, err := NewNodeCache(100000, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			return c
+		})
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"bytes"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/db/trie/triepb"
+)
+
+// ProofVersion is the version of the encoding of the proofs. A proof starts with the version, followed by the
+// serialized nodes on the path of the key from the root, which are the ones hashed by the trie. An inclusion proof ends
+// with the leaf of the key, and an exclusion proof ends with the node where the path of the key diverges from the trie.
+const ProofVersion = byte(1)
+
+// ErrInvalidProof indicates that a proof doesn't prove the key and the value against the root hash
+var ErrInvalidProof = errors.New("invalid proof")
+
+func (tr *branchRootTrie) GetWithProof(key []byte) ([]byte, [][]byte, error) {
+	trieMtc.WithLabelValues("root", "GetWithProof").Inc()
+	kt, err := tr.checkKeyType(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+	proof := [][]byte{{ProofVersion}}
+	var tn Node = tr.root
+	offset := 0
+	for {
+		proof = append(proof, tn.serialize())
+		switch node := tn.(type) {
+		case *branchNode:
+			h, ok := node.hashes[kt[offset]]
+			if !ok {
+				return nil, proof, errors.Wrapf(ErrNotExist, "key %x does not exist", kt)
+			}
+			tn, err = tr.loadNodeFromDB(h)
+			offset++
+		case *extensionNode:
+			if !bytes.HasPrefix(kt[offset:], node.path) {
+				return nil, proof, errors.Wrapf(ErrNotExist, "key %x does not exist", kt)
+			}
+			tn, err = node.child(tr)
+			offset += len(node.path)
+		case *leafNode:
+			if !bytes.Equal(node.key, kt) {
+				return nil, proof, errors.Wrapf(ErrNotExist, "key %x does not exist", kt)
+			}
+			return append(node.value[:0:0], node.value...), proof, nil
+		default:
+			return nil, nil, errors.Wrapf(ErrInvalidTrie, "unexpected node type %d", tn.Type())
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// VerifyProof verifies the proof of the key and the value against the root hash of a trie with the default hash
+// function. A nil value verifies the exclusion proof of the key.
+func VerifyProof(rootHash, key, value []byte, proof [][]byte) error {
+	return VerifyProofWithHashFunc(DefaultHashFunc, rootHash, key, value, proof)
+}
+
+// VerifyProofWithHashFunc verifies the proof like VerifyProof against the root hash of a trie with the hash function
+func VerifyProofWithHashFunc(hashFunc HashFunc, rootHash, key, value []byte, proof [][]byte) error {
+	if len(proof) < 2 || len(proof[0]) != 1 {
+		return errors.Wrap(ErrInvalidProof, "missing version or nodes")
+	}
+	if proof[0][0] != ProofVersion {
+		return errors.Wrapf(ErrInvalidProof, "unsupported version %d", proof[0][0])
+	}
+	nodes := proof[1:]
+	expected := rootHash
+	offset := 0
+	for i, ser := range nodes {
+		if !bytes.Equal(hashFunc(ser), expected) {
+			return errors.Wrapf(ErrInvalidProof, "hash of node %d doesn't match", i)
+		}
+		last := i == len(nodes)-1
+		var pb triepb.NodePb
+		if err := proto.Unmarshal(ser, &pb); err != nil {
+			return errors.Wrapf(ErrInvalidProof, "failed to unmarshal node %d: %v", i, err)
+		}
+		if offset > len(key) {
+			return errors.Wrapf(ErrInvalidProof, "path of node %d exceeds key", i)
+		}
+		var child []byte
+		switch {
+		case pb.GetBranch() != nil:
+			if offset == len(key) {
+				return errors.Wrapf(ErrInvalidProof, "path of node %d exceeds key", i)
+			}
+			for _, b := range pb.GetBranch().Branches {
+				if b.Index == uint32(key[offset]) {
+					child = b.Path
+					break
+				}
+			}
+			offset++
+		case pb.GetExtend() != nil:
+			path := pb.GetExtend().Path
+			if bytes.HasPrefix(key[offset:], path) {
+				child = pb.GetExtend().Value
+			}
+			offset += len(path)
+		case pb.GetLeaf() != nil:
+			if !last {
+				return errors.Wrapf(ErrInvalidProof, "leaf %d isn't the last node", i)
+			}
+			leaf := pb.GetLeaf()
+			if !bytes.Equal(leaf.Path, key) {
+				return verifyExclusion(key, value)
+			}
+			if value == nil {
+				return errors.Wrapf(ErrInvalidProof, "key %x exists", key)
+			}
+			if !bytes.Equal(leaf.Value, value) {
+				return errors.Wrapf(ErrInvalidProof, "value of key %x doesn't match", key)
+			}
+			return nil
+		default:
+			return errors.Wrapf(ErrInvalidProof, "invalid type of node %d", i)
+		}
+		if child == nil {
+			// the path of the key diverges at the node
+			if !last {
+				return errors.Wrapf(ErrInvalidProof, "node %d after the divergence", i+1)
+			}
+			return verifyExclusion(key, value)
+		}
+		expected = child
+	}
+	return errors.Wrap(ErrInvalidProof, "proof ends before the path of the key does")
+}
+
+func verifyExclusion(key, value []byte) error {
+	if value != nil {
+		return errors.Wrapf(ErrInvalidProof, "key %x doesn't exist", key)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iotexproject/go-pkgs/hash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProof(t *testing.T) {
+	wide := make([][]byte, radix)
+	for i := range wide {
+		wide[i] = []byte{byte(i), 2, 3, 4, 5, 6, 7, 8}
+	}
+	for _, e := range []struct {
+		name    string
+		present [][]byte
+		absent  [][]byte
+	}{
+		{"empty", nil, [][]byte{cat}},
+		{"single leaf", [][]byte{cat}, [][]byte{rat, dog, ant}},
+		// the keys share the prefix of 6 bytes, and the absent ones diverge in and after the extension
+		{"deep extension", [][]byte{car, cat, rat}, [][]byte{egg, dog, fox, {1, 2, 3, 4, 5, 6, 7, 6}}},
+		{"mixed", [][]byte{ham, car, cat, rat, egg, dog, fox, cow, ant}, [][]byte{br1, br2, cl1, cl2}},
+		{"wide branch", wide[1:], [][]byte{wide[0], {1, 2, 3, 4, 5, 6, 7, 9}}},
+	} {
+		t.Run(e.name, func(t *testing.T) {
+			require := require.New(t)
+			tr, err := NewTrie(KeyLengthOption(8))
+			require.NoError(err)
+			require.NoError(tr.Start(context.Background()))
+			for i, key := range e.present {
+				require.NoError(tr.Upsert(key, testV[i%len(testV)]))
+			}
+			root := tr.RootHash()
+
+			for i, key := range e.present {
+				value, proof, err := tr.GetWithProof(key)
+				require.NoError(err)
+				require.Equal(testV[i%len(testV)], value)
+				require.Equal([]byte{ProofVersion}, proof[0])
+				require.NoError(VerifyProof(root, key, value, proof))
+				// the proof of inclusion doesn't prove another value or the exclusion
+				require.Equal(ErrInvalidProof, errors.Cause(VerifyProof(root, key, []byte("other"), proof)))
+				require.Equal(ErrInvalidProof, errors.Cause(VerifyProof(root, key, nil, proof)))
+				testTamperedProof(t, root, key, value, proof)
+			}
+			for _, key := range e.absent {
+				value, proof, err := tr.GetWithProof(key)
+				require.Equal(ErrNotExist, errors.Cause(err))
+				require.Nil(value)
+				require.NoError(VerifyProof(root, key, nil, proof))
+				require.Equal(ErrInvalidProof, errors.Cause(VerifyProof(root, key, testV[0], proof)))
+				testTamperedProof(t, root, key, nil, proof)
+			}
+		})
+	}
+}
+
+// testTamperedProof checks that the proof fails to verify once it's tampered with
+func testTamperedProof(t *testing.T, root, key, value []byte, proof [][]byte) {
+	require := require.New(t)
+	tampered := func(f func([][]byte) [][]byte) [][]byte {
+		p := make([][]byte, len(proof))
+		for i := range proof {
+			p[i] = append([]byte{}, proof[i]...)
+		}
+		return f(p)
+	}
+	invalid := [][][]byte{
+		tampered(func(p [][]byte) [][]byte { return p[:len(p)-1] }),
+		tampered(func(p [][]byte) [][]byte { return p[1:] }),
+		tampered(func(p [][]byte) [][]byte { p[0][0]++; return p }),
+		tampered(func(p [][]byte) [][]byte { return append(p, p[len(p)-1]) }),
+	}
+	for i := 1; i < len(proof); i++ {
+		for _, j := range []int{0, len(proof[i]) / 2, len(proof[i]) - 1} {
+			i, j := i, j
+			invalid = append(invalid, tampered(func(p [][]byte) [][]byte { p[i][j] ^= 1; return p }))
+		}
+	}
+	for _, p := range invalid {
+		require.Equal(ErrInvalidProof, errors.Cause(VerifyProof(root, key, value, p)))
+	}
+	otherRoot := hash.Hash160b(root)
+	require.Equal(ErrInvalidProof, errors.Cause(VerifyProof(otherRoot[:], key, value, proof)))
+	require.Equal(ErrInvalidProof, errors.Cause(VerifyProofWithHashFunc(func(data []byte) []byte {
+		h := hash.Hash256b(data)
+		return h[:]
+	}, root, key, value, proof)))
+}
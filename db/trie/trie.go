@@ -34,6 +34,9 @@ var (
 
 	// ErrNotExist indicates entry does not exist
 	ErrNotExist = errors.New("not exist in trie")
+
+	// ErrInvalidRoot indicates that the root node of a hash is missing from the store or fails to be decoded
+	ErrInvalidRoot = errors.New("invalid root")
 )
 
 // DefaultHashFunc implements a default hash function
@@ -52,11 +55,25 @@ type Trie interface {
 	Upsert([]byte, []byte) error
 	// Get retrieves an existing entry
 	Get([]byte) ([]byte, error)
+	// GetWithProof retrieves an existing entry along with the proof of it, which is verified by VerifyProof. The
+	// exclusion proof is returned along with ErrNotExist if the entry doesn't exist.
+	GetWithProof([]byte) ([]byte, [][]byte, error)
 	// Delete deletes an entry
 	Delete([]byte) error
+	// UpsertBatch inserts the entries of the keys and the values in order, which hashes the nodes mutated once rather
+	// than once per entry, and results in the same root as upserting them one by one. None of the entries is inserted
+	// if it fails.
+	UpsertBatch([][]byte, [][]byte) error
+	// DeleteBatch deletes the entries of the keys in order like UpsertBatch. None of the entries is deleted if any of
+	// them doesn't exist.
+	DeleteBatch([][]byte) error
+	// Iterator returns an iterator of the entries from the start key in the order of the keys. The entries are the
+	// ones at the creation of the iterator, whose nodes are kept until it's closed. Unlike Get, the iterator isn't safe
+	// to use along with the writes in another goroutine.
+	Iterator([]byte) (Iter, error)
 	// RootHash returns trie's root hash
 	RootHash() []byte
-	// SetRootHash sets a new root to trie
+	// SetRootHash sets a new root to trie, and fails with ErrInvalidRoot if the root node can't be loaded
 	SetRootHash([]byte) error
 	// DB returns the KVStore storing the node data
 	DB() KVStore
@@ -146,6 +163,20 @@ func KVStoreOption(kvStore KVStore) Option {
 	}
 }
 
+// NodeCacheOption sets the cache of the nodes loaded from the kvStore, which may be shared among the tries
+func NodeCacheOption(cache *NodeCache) Option {
+	return func(tr Trie) error {
+		switch t := tr.(type) {
+		case *branchRootTrie:
+			t.cache = cache
+			t.written = map[string]struct{}{}
+		default:
+			return errors.New("invalid trie type")
+		}
+		return nil
+	}
+}
+
 // NewTrie creates a trie with DB filename
 func NewTrie(options ...Option) (Trie, error) {
 	t := &branchRootTrie{
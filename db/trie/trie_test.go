@@ -7,10 +7,13 @@
 package trie
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -137,6 +140,113 @@ func Test2Roots(t *testing.T) {
 	require.NoError(tr2.Stop(context.Background()))
 }
 
+func TestPrefixedTries(t *testing.T) {
+	require := require.New(t)
+	dao := db.NewMemKVStore()
+	newTrie := func(prefix string) (Trie, *db.KVStoreForTrie) {
+		kv, err := db.NewPrefixedKVStoreForTrie("trie", []byte(prefix), dao)
+		require.NoError(err)
+		tr, err := NewTrie(KVStoreOption(kv), KeyLengthOption(8), RootKeyOption("root"))
+		require.NoError(err)
+		require.NoError(tr.Start(context.Background()))
+		return tr, kv
+	}
+	tr1, kv1 := newTrie("1")
+	tr2, kv2 := newTrie("2")
+	// the tries of the same entries have the same nodes, under their own prefixes
+	keys := [][]byte{ham, car, cat, rat, egg}
+	for i, key := range keys {
+		require.NoError(tr1.Upsert(key, testV[i]))
+		require.NoError(tr2.Upsert(key, testV[i]))
+	}
+	require.Equal(tr1.RootHash(), tr2.RootHash())
+	nodes1, _, err := kv1.Prefix(nil)
+	require.NoError(err)
+	nodes2, _, err := kv2.Prefix(nil)
+	require.NoError(err)
+	require.Equal(nodes1, nodes2)
+	all, _, err := dao.(db.KVStoreWithPrefix).Prefix("trie", nil)
+	require.NoError(err)
+	require.Equal(2*len(nodes1), len(all))
+
+	// the nodes deleted by one of them are intact in the other
+	for _, key := range keys[:3] {
+		require.NoError(tr1.Delete(key))
+	}
+	require.NoError(tr2.Upsert(dog, testV[5]))
+	for i, key := range keys {
+		v, err := tr2.Get(key)
+		require.NoError(err)
+		require.Equal(testV[i], v)
+	}
+	for _, key := range [][]byte{ham, car, cat, dog} {
+		_, err := tr1.Get(key)
+		require.Equal(ErrNotExist, errors.Cause(err))
+	}
+	nodes1, _, err = kv1.Prefix(nil)
+	require.NoError(err)
+	nodes2, _, err = kv2.Prefix(nil)
+	require.NoError(err)
+	require.True(len(nodes1) < len(nodes2))
+
+	// the trie reopened with its prefix loads its own root, which is saved by the user of the trie
+	require.NoError(kv1.Put([]byte("root"), tr1.RootHash()))
+	tr3, _ := newTrie("1")
+	require.Equal(tr1.RootHash(), tr3.RootHash())
+	v, err := tr3.Get(rat)
+	require.NoError(err)
+	require.Equal(testV[3], v)
+	_, err = tr3.Get(dog)
+	require.Equal(ErrNotExist, errors.Cause(err))
+}
+
+func TestSetRootHash(t *testing.T) {
+	require := require.New(t)
+	trieDB := newInMemKVStore()
+	tr, err := NewTrie(KVStoreOption(trieDB), KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	require.NoError(tr.Upsert(cat, testV[2]))
+	pruned := tr.RootHash()
+	require.NoError(tr.Upsert(car, testV[1]))
+	root := tr.RootHash()
+	// the node of the old root is deleted on the update
+	_, err = trieDB.Get(pruned)
+	require.Error(err)
+	undecodable := []byte("undecodable root hash")
+	require.NoError(trieDB.Put(undecodable, []byte{0xff, 0xff}))
+	child := tr.(*branchRootTrie).root.hashes[cat[0]]
+
+	for _, invalid := range [][]byte{[]byte("garbage"), pruned, undecodable, child} {
+		err := tr.SetRootHash(invalid)
+		require.Equal(ErrInvalidRoot, errors.Cause(err))
+		require.Contains(err.Error(), fmt.Sprintf("%x", invalid))
+		// the trie keeps the root it had
+		require.Equal(root, tr.RootHash())
+		v, err := tr.Get(car)
+		require.NoError(err)
+		require.Equal(testV[1], v)
+
+		other, err := NewTrie(KVStoreOption(trieDB), KeyLengthOption(8), RootHashOption(invalid))
+		require.NoError(err)
+		require.Equal(ErrInvalidRoot, errors.Cause(other.Start(context.Background())))
+	}
+	// the empty root isn't read from the store
+	empty, err := NewTrie(KVStoreOption(newInMemKVStore()), KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(empty.Start(context.Background()))
+	emptyRoot := empty.RootHash()
+	require.NoError(tr.SetRootHash(emptyRoot))
+	_, err = tr.Get(car)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.NoError(tr.SetRootHash(nil))
+	require.Equal(emptyRoot, tr.RootHash())
+	require.NoError(tr.SetRootHash(root))
+	v, err := tr.Get(cat)
+	require.NoError(err)
+	require.Equal(testV[2], v)
+}
+
 func TestInsert(t *testing.T) {
 	require := require.New(t)
 
@@ -509,6 +619,88 @@ func TestCollision(t *testing.T) {
 	require.Equal(testV[0], v)
 }
 
+func TestConcurrentGet(t *testing.T) {
+	require := require.New(t)
+
+	cache, err := NewNodeCache(64, 0)
+	require.NoError(err)
+	tr, err := NewTrie(KeyLengthOption(4), NodeCacheOption(cache))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	defer func() {
+		require.NoError(tr.Stop(context.Background()))
+	}()
+
+	// the writer flips the values of the keys between 2 known ones, so a read sees one of them
+	const keys = 64
+	key := func(i int) []byte {
+		return byteutil.Uint32ToBytesBigEndian(uint32(i * 0x01020304))
+	}
+	value := func(i, round int) []byte {
+		return []byte(fmt.Sprintf("value %d of round %d", i, round%2))
+	}
+	for i := 0; i < keys; i++ {
+		require.NoError(tr.Upsert(key(i), value(i, 0)))
+	}
+	done := make(chan struct{})
+	errs := make(chan error, 5)
+	go func() {
+		defer close(done)
+		for round := 1; round <= 20; round++ {
+			for i := 0; i < keys; i++ {
+				if err := tr.Upsert(key(i), value(i, round)); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				i := (n + r) % keys
+				var v []byte
+				var err error
+				if r%2 == 0 {
+					v, err = tr.Get(key(i))
+				} else {
+					var proof [][]byte
+					v, proof, err = tr.GetWithProof(key(i))
+					if err == nil && len(proof) < 2 {
+						err = errors.Errorf("proof of %d nodes", len(proof))
+					}
+				}
+				if err == nil && !bytes.Equal(v, value(i, 0)) && !bytes.Equal(v, value(i, 1)) {
+					err = errors.Errorf("torn read %q of key %d", v, i)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+	<-done
+	close(errs)
+	for err := range errs {
+		require.NoError(err)
+	}
+	for i := 0; i < keys; i++ {
+		v, err := tr.Get(key(i))
+		require.NoError(err)
+		require.Equal(value(i, 20), v)
+	}
+}
+
 func Test4kEntries(t *testing.T) {
 	require := require.New(t)
 
@@ -639,3 +831,51 @@ func TestPressure(t *testing.T) {
 	require.NoError(tr.Stop(context.Background()))
 	t.Logf("Warning: test %d entries", c)
 }
+
+func TestCorruptedNode(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	kv, err := db.NewKVStoreForTrie("trie", dao, db.ChecksumOption())
+	require.NoError(err)
+	tr, err := NewTrie(KVStoreOption(kv), KeyLengthOption(8))
+	require.NoError(err)
+	require.NoError(tr.Start(context.Background()))
+	for i, k := range [][]byte{cat, rat, dog, egg, ham} {
+		require.NoError(tr.Upsert(k, testV[i]))
+	}
+	root := tr.RootHash()
+	require.NoError(tr.Stop(context.Background()))
+
+	// flip a byte in each of the stored nodes, and the reads through the node fail with its hash
+	keys, values, err := dao.(db.KVStoreWithPrefix).Prefix("trie", nil)
+	require.NoError(err)
+	require.NotEqual(0, len(keys))
+	for i, key := range keys {
+		corrupted := append([]byte{}, values[i]...)
+		corrupted[len(corrupted)/2] ^= 0xff
+		require.NoError(dao.Put("trie", key, corrupted))
+		tr, err := NewTrie(KVStoreOption(kv), KeyLengthOption(8), RootHashOption(root))
+		if err == nil {
+			err = tr.Start(context.Background())
+		}
+		for _, k := range [][]byte{cat, rat, dog, egg, ham} {
+			if err != nil {
+				break
+			}
+			_, err = tr.Get(k)
+		}
+		if bytes.Equal(key, root) {
+			// the root fails to be set, with the corrupted node in the message
+			require.Equal(ErrInvalidRoot, errors.Cause(err))
+			require.Contains(err.Error(), (&db.CorruptedNodeError{Namespace: "trie", Hash: key}).Error())
+		} else {
+			require.Equal(db.ErrCorruptedNode, errors.Cause(err))
+			e, ok := db.CorruptedNodeOf(err)
+			require.True(ok)
+			require.Equal("trie", e.Namespace)
+			require.Equal(key, e.Hash)
+		}
+		require.NoError(dao.Put("trie", key, values[i]))
+	}
+}
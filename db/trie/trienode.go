@@ -33,7 +33,7 @@ type Node interface {
 	Value() []byte
 
 	children(Trie) ([]Node, error)
-	search(Trie, keyType, uint8) Node
+	search(Trie, keyType, uint8) (Node, error)
 	delete(Trie, keyType, uint8) (Node, error)
 	upsert(Trie, keyType, uint8, []byte) (Node, error)
 
@@ -36,6 +36,9 @@ type (
 		Votes         *big.Int
 		RewardAddress string
 		CanName       []byte // used as identifier to merge with native staking result, not part of protobuf
+		// RawVotes is the total tokens staked for the candidate before any bonus or probation cut, while Votes is the
+		// weighted votes used for the election. It is nil if unknown, and not part of protobuf
+		RawVotes *big.Int
 	}
 
 	// CandidateList indicates the list of Candidates which is sortable
@@ -65,12 +68,25 @@ func (c *Candidate) Clone() *Candidate {
 	}
 	name := make([]byte, len(c.CanName))
 	copy(name, c.CanName)
+	var rawVotes *big.Int
+	if c.RawVotes != nil {
+		rawVotes = new(big.Int).Set(c.RawVotes)
+	}
 	return &Candidate{
 		Address:       c.Address,
 		Votes:         new(big.Int).Set(c.Votes),
 		RewardAddress: c.RewardAddress,
 		CanName:       name,
+		RawVotes:      rawVotes,
+	}
+}
+
+// RawVotesOrVotes returns the raw votes of the candidate, or its weighted votes if the raw votes are unknown
+func (c *Candidate) RawVotesOrVotes() *big.Int {
+	if c.RawVotes != nil {
+		return c.RawVotes
 	}
+	return c.Votes
 }
 
 func (l CandidateList) Len() int      { return len(l) }
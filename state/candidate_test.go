@@ -63,6 +63,14 @@ func TestCandidateClone(t *testing.T) {
 		Votes:   big.NewInt(2),
 	}
 	r.True(cand1.Equal(cand1.Clone()))
+	r.Nil(cand1.Clone().RawVotes)
+	r.Equal(big.NewInt(2), cand1.RawVotesOrVotes())
+
+	cand1.RawVotes = big.NewInt(1)
+	cand2 := cand1.Clone()
+	r.Equal(big.NewInt(1), cand2.RawVotesOrVotes())
+	cand2.RawVotes.SetInt64(3)
+	r.Equal(big.NewInt(1), cand1.RawVotes)
 }
 
 func TestCandidateListSerializeAndDeserialize(t *testing.T) {
@@ -89,16 +97,19 @@ func TestCandidate(t *testing.T) {
 	require := require.New(t)
 
 	cand1 := &Candidate{
-		Address: identityset.Address(28).String(),
-		Votes:   big.NewInt(1),
+		Address:  identityset.Address(28).String(),
+		Votes:    big.NewInt(1),
+		RawVotes: big.NewInt(30),
 	}
 	cand2 := &Candidate{
-		Address: identityset.Address(29).String(),
-		Votes:   big.NewInt(2),
+		Address:  identityset.Address(29).String(),
+		Votes:    big.NewInt(2),
+		RawVotes: big.NewInt(20),
 	}
 	cand3 := &Candidate{
-		Address: identityset.Address(30).String(),
-		Votes:   big.NewInt(3),
+		Address:  identityset.Address(30).String(),
+		Votes:    big.NewInt(3),
+		RawVotes: big.NewInt(10),
 	}
 
 	cand1Addr, err := address.FromString(cand1.Address)
@@ -121,6 +132,7 @@ func TestCandidate(t *testing.T) {
 	candidateList, err := MapToCandidates(candidateMap)
 	require.NoError(err)
 	require.Equal(3, len(candidateList))
+	// the candidates are ranked by the weighted votes
 	sort.Sort(candidateList)
 
 	require.Equal(identityset.Address(30).String(), candidateList[0].Address)
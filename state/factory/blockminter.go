@@ -13,8 +13,18 @@ import (
 	"github.com/iotexproject/iotex-core/blockchain/block"
 )
 
-// Minter is the interface of block minter
-type Minter interface {
-	// NewBlockBuilder creates block builder
-	NewBlockBuilder(context.Context, map[string][]action.SealedEnvelope, []action.SealedEnvelope) (*block.Builder, error)
-}
+type (
+	// Minter is the interface of block minter
+	Minter interface {
+		// NewBlockBuilder creates block builder
+		NewBlockBuilder(context.Context, map[string][]action.SealedEnvelope, []action.SealedEnvelope) (*block.Builder, error)
+		// AddActionTimeoutSubscriber adds the subscriber of the actions timing out in the blocks built
+		AddActionTimeoutSubscriber(ActionTimeoutSubscriber)
+	}
+
+	// ActionTimeoutSubscriber is notified of the action timing out when a block is built, which is left out of the block
+	// and would time out again in the next blocks
+	ActionTimeoutSubscriber interface {
+		HandleActionTimeout(action.SealedEnvelope)
+	}
+)
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
@@ -24,6 +25,7 @@ import (
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/action/protocol/execution/evm"
 	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/db/batch"
@@ -48,6 +50,9 @@ const (
 	CurrentHeightKey = "currentHeight"
 	// AccountTrieRootKey indicates the key of accountTrie root hash in underlying DB
 	AccountTrieRootKey = "accountTrieRoot"
+	// TrieRootNamespace is the bucket of the accountTrie root hashes of the heights, keyed by the height in 8-byte big
+	// endian, so that they're ordered by height
+	TrieRootNamespace = "TrieRoot"
 )
 
 var (
@@ -55,6 +60,8 @@ var (
 	ErrNotSupported = errors.New("not supported")
 	// ErrNoArchiveData is the error that the node have no archive data
 	ErrNoArchiveData = errors.New("no archive data")
+	// ErrViewReleased is the error that the read view is used after it's released
+	ErrViewReleased = errors.New("read view is released")
 	// TotalBucketKey indicates the total count of staking buckets
 	TotalBucketKey = []byte("totalBucket")
 )
@@ -70,6 +77,11 @@ type (
 		SimulateExecution(context.Context, address.Address, *action.Execution, evm.GetBlockHash) ([]byte, *action.Receipt, error)
 		Commit(context.Context, *block.Block) error
 		DeleteWorkingSet(*block.Block) error
+		// ReadView returns a reader of the states committed at the current height, which the later commits don't
+		// change, along with the function releasing it
+		ReadView() (protocol.StateReader, func(), error)
+		// NewReader returns a reader of the committed states, which is safe for concurrent use
+		NewReader(...ReaderOption) (protocol.StateReader, error)
 	}
 
 	// factory implements StateFactory interface, tracks changes to account/contract and batch-commits to DB
@@ -83,12 +95,46 @@ type (
 		dao                db.KVStore // the underlying DB for account/contract storage
 		timerFactory       *prometheustimer.TimerFactory
 		workingsets        *lru.Cache // lru cache for workingsets
+		audit              StateAudit
+		views              int32           // number of the read views open
+		nodeCache          *trie.NodeCache // cache of the trie nodes shared by the tries, nil if it's disabled
+		timeoutSubscribers []ActionTimeoutSubscriber
 	}
+
+	// StateAudit checks the invariants of the state of a block before it's committed
+	StateAudit func(protocol.StateReader, genesis.Genesis) error
 )
 
 // Option sets Factory construction parameter
 type Option func(*factory, config.Config) error
 
+type (
+	// ReaderOption sets the construction parameter of a reader of the committed states
+	ReaderOption func(*readerConfig)
+
+	readerConfig struct {
+		pinned bool
+		height uint64
+	}
+)
+
+// ReaderHeightOption pins the reader at the height, whose states are kept in archive mode only. A reader which isn't
+// pinned reads the states committed at the latest height when each read starts.
+func ReaderHeightOption(height uint64) ReaderOption {
+	return func(cfg *readerConfig) {
+		cfg.pinned = true
+		cfg.height = height
+	}
+}
+
+func createReaderConfig(opts ...ReaderOption) readerConfig {
+	var cfg readerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // PrecreatedTrieDBOption uses pre-created trie DB for state factory
 func PrecreatedTrieDBOption(kv db.KVStore) Option {
 	return func(sf *factory, cfg config.Config) (err error) {
@@ -122,6 +168,17 @@ func InMemTrieOption() Option {
 	}
 }
 
+// StateAuditOption checks the state of each block with the audit before committing it, which is meant for debugging
+func StateAuditOption(audit StateAudit) Option {
+	return func(sf *factory, cfg config.Config) error {
+		if audit == nil {
+			return errors.New("audit cannot be nil")
+		}
+		sf.audit = audit
+		return nil
+	}
+}
+
 // NewFactory creates a new state factory
 func NewFactory(cfg config.Config, opts ...Option) (Factory, error) {
 	sf := &factory{
@@ -135,6 +192,16 @@ func NewFactory(cfg config.Config, opts ...Option) (Factory, error) {
 			return nil, err
 		}
 	}
+	if cfg.Chain.TrieDBWALPath != "" {
+		sf.dao = db.NewKVStoreWithWAL(sf.dao, cfg.Chain.TrieDBWALPath)
+	}
+	if cfg.Chain.TrieNodeCacheSize > 0 || cfg.Chain.TrieNodeCacheBytes > 0 {
+		cache, err := trie.NewNodeCache(int(cfg.Chain.TrieNodeCacheSize), int(cfg.Chain.TrieNodeCacheBytes))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create trie node cache")
+		}
+		sf.nodeCache = cache
+	}
 	// The sf.dao passed into the dbForTrie could be read only
 	dbForTrie, err := db.NewKVStoreForTrie(AccountTrieNamespace, sf.dao)
 	if err != nil {
@@ -143,6 +210,7 @@ func NewFactory(cfg config.Config, opts ...Option) (Factory, error) {
 	if sf.accountTrie, err = trie.NewTrie(
 		trie.KVStoreOption(dbForTrie),
 		trie.RootKeyOption(AccountTrieRootKey),
+		trie.NodeCacheOption(sf.nodeCache),
 	); err != nil {
 		return nil, errors.Wrap(err, "failed to generate accountTrie from config")
 	}
@@ -213,6 +281,20 @@ func (sf *factory) Height() (uint64, error) {
 	return byteutil.BytesToUint64(height), nil
 }
 
+// TipHeight returns the height of the last committed block, which is the height of the states of the factory
+func (sf *factory) TipHeight() (uint64, error) {
+	return sf.Height()
+}
+
+// trieOptions returns the options of the tries of the working sets
+func (sf *factory) trieOptions() []trie.Option {
+	opts := []trie.Option{trie.NodeCacheOption(sf.nodeCache)}
+	if sf.cfg.Chain.TrieCompactNodes {
+		opts = append(opts, trie.CompactNodeOption())
+	}
+	return opts
+}
+
 // NewWorkingSet returns new working set
 func (sf *factory) NewWorkingSet() (WorkingSet, error) {
 	sf.mutex.RLock()
@@ -222,6 +304,8 @@ func (sf *factory) NewWorkingSet() (WorkingSet, error) {
 		sf.currentChainHeight+1,
 		sf.dao,
 		sf.rootHash(),
+		sf.trieOptions(),
+		sf.cfg.Genesis.TrieRootIndexHeight,
 		sf.flusherOptions(context.Background(), sf.currentChainHeight+1)...,
 	)
 }
@@ -229,7 +313,7 @@ func (sf *factory) NewWorkingSet() (WorkingSet, error) {
 func (sf *factory) flusherOptions(ctx context.Context, height uint64) []db.KVStoreFlusherOption {
 	opts := []db.KVStoreFlusherOption{
 		db.SerializeFilterOption(func(wi *batch.WriteInfo) bool {
-			if wi.Namespace() == AccountTrieNamespace {
+			if wi.Namespace() == AccountTrieNamespace || wi.Namespace() == TrieRootNamespace {
 				return true
 			}
 			if wi.Namespace() != evm.CodeKVNameSpace {
@@ -243,6 +327,7 @@ func (sf *factory) flusherOptions(ctx context.Context, height uint64) []db.KVSto
 			hu := config.NewHeightUpgrade(&bcCtx.Genesis)
 			return hu.IsPre(config.Easter, height)
 		}),
+		db.SlowFlushOption(sf.cfg.Chain.SlowFlushThreshold),
 	}
 	if sf.saveHistory {
 		opts = append(opts, db.FlushTranslateOption(func(wi *batch.WriteInfo) *batch.WriteInfo {
@@ -260,6 +345,16 @@ func (sf *factory) flusherOptions(ctx context.Context, height uint64) []db.KVSto
 				wi.ErrorArgs(),
 			)
 		}))
+	} else {
+		opts = append(opts, db.FlushTranslateOption(func(wi *batch.WriteInfo) *batch.WriteInfo {
+			// the trie nodes are kept while any read view is open, as the view may still read them
+			if wi.WriteType() == batch.Delete &&
+				wi.Namespace() == AccountTrieNamespace &&
+				atomic.LoadInt32(&sf.views) > 0 {
+				return nil
+			}
+			return wi
+		}))
 	}
 
 	return opts
@@ -292,13 +387,26 @@ func (sf *factory) NewBlockBuilder(
 		sf.currentChainHeight+1,
 		sf.dao,
 		sf.rootHash(),
-		sf.flusherOptions(ctx, sf.currentChainHeight+1)...,
+		sf.trieOptions(),
+		sf.cfg.Genesis.TrieRootIndexHeight,
+		append(
+			sf.flusherOptions(ctx, sf.currentChainHeight+1),
+			db.BufferLimitOption(int(sf.cfg.Chain.WorkingSetBufferLimit)),
+		)...,
 	)
+	timeoutSubscribers := sf.timeoutSubscribers
 	sf.mutex.Unlock()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to obtain working set from state factory")
 	}
-	blkBuilder, err := createBuilderWithWorkingset(ctx, ws, actionMap, postSystemActions, sf.cfg.Chain.AllowedBlockGasResidue)
+	blkBuilder, err := createBuilderWithWorkingset(
+		withActionTimeout(ctx, sf.cfg.Chain.ActionTimeout),
+		ws,
+		actionMap,
+		postSystemActions,
+		sf.cfg.Chain.AllowedBlockGasResidue,
+		timeoutSubscribers,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +417,13 @@ func (sf *factory) NewBlockBuilder(
 	return blkBuilder, nil
 }
 
+// AddActionTimeoutSubscriber adds the subscriber of the actions timing out in the blocks built
+func (sf *factory) AddActionTimeoutSubscriber(s ActionTimeoutSubscriber) {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+	sf.timeoutSubscribers = append(sf.timeoutSubscribers, s)
+}
+
 // SimulateExecution simulates a running of smart contract operation, this is done off the network since it does not
 // cause any state change
 func (sf *factory) SimulateExecution(
@@ -322,6 +437,8 @@ func (sf *factory) SimulateExecution(
 		sf.currentChainHeight+1,
 		sf.dao,
 		sf.rootHash(),
+		sf.trieOptions(),
+		sf.cfg.Genesis.TrieRootIndexHeight,
 		sf.flusherOptions(ctx, sf.currentChainHeight+1)...,
 	)
 	sf.mutex.Unlock()
@@ -374,7 +491,12 @@ func (sf *factory) Commit(ctx context.Context, blk *block.Block) error {
 			ws.Version(),
 		)
 	}
-
+	if sf.audit != nil {
+		// the state isn't committed if it violates an invariant
+		if err := sf.audit(ws, bcCtx.Genesis); err != nil {
+			return errors.Wrapf(err, "state audit failed at height %d", ws.Version())
+		}
+	}
 	return sf.commit(ws)
 }
 
@@ -386,12 +508,94 @@ func (sf *factory) State(state interface{}, opts ...protocol.StateOption) (uint6
 	if err != nil {
 		return 0, err
 	}
+	if err := cfg.CheckHeight(sf.currentChainHeight); err != nil {
+		return 0, err
+	}
 	if cfg.AtHeight {
 		return sf.currentChainHeight, sf.stateAtHeight(cfg.Height, cfg.Key, state)
 	}
 	return sf.currentChainHeight, sf.state(cfg.Key, state)
 }
 
+// States returns the confirmed states of the keys in the state factory
+func (sf *factory) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := cfg.CheckHeight(sf.currentChainHeight); err != nil {
+		return 0, nil, err
+	}
+	tr := sf.accountTrie
+	if cfg.AtHeight {
+		if tr, err = sf.trieAtHeight(cfg.Height); err != nil {
+			return 0, nil, err
+		}
+		defer tr.Stop(context.Background())
+	}
+	var iter state.Iterator
+	if cfg.Keys != nil {
+		iter, err = readStates(cfg.Context, cfg.Keys, tr.Get)
+	} else {
+		iter, err = trieStates(cfg.Context, tr, cfg.Prefix)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return sf.currentChainHeight, iter, nil
+}
+
+// ReadView returns a reader of the states pinned to the current root of the trie, along with the function releasing
+// it. The view copies nothing, and the trie nodes which the commits obsolete are kept in the DB while any view is open.
+// The reads of a released view fail with ErrViewReleased.
+func (sf *factory) ReadView() (protocol.StateReader, func(), error) {
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+	dbForTrie, err := db.NewKVStoreForTrie(AccountTrieNamespace, sf.dao)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate state tire db")
+	}
+	tr, err := trie.NewTrie(
+		trie.KVStoreOption(dbForTrie),
+		trie.RootHashOption(sf.rootHash()),
+		trie.NodeCacheOption(sf.nodeCache),
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate state trie from config")
+	}
+	if err := tr.Start(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	// the commits are blocked while the lock is held, so none of them drops the nodes of the root before counting
+	atomic.AddInt32(&sf.views, 1)
+	view, release := newReadView(&trieReader{height: sf.currentChainHeight, tr: tr}, func() {
+		atomic.AddInt32(&sf.views, -1)
+		if err := tr.Stop(context.Background()); err != nil {
+			log.L().Error("Failed to stop the trie of the read view.", zap.Error(err))
+		}
+	})
+	return view, release, nil
+}
+
+// NewReader returns a reader of the committed states. Each read of the reader goes through a view of the root
+// committed when the read starts, so that it's consistent while blocks are committed, and the reads at a height go to
+// the archive. The reader pinned at a height reads the archived trie of the height only.
+func (sf *factory) NewReader(opts ...ReaderOption) (protocol.StateReader, error) {
+	cfg := createReaderConfig(opts...)
+	if !cfg.pinned {
+		return &committedReader{sf: sf}, nil
+	}
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+	tr, err := sf.trieAtHeight(cfg.height)
+	if err != nil {
+		return nil, err
+	}
+	return &trieReader{height: cfg.height, tr: tr}, nil
+}
+
 // DeleteWorkingSet returns true if it remove ws from workingsets cache successfully
 func (sf *factory) DeleteWorkingSet(blk *block.Block) error {
 	sf.mutex.RLock()
@@ -425,23 +629,7 @@ func (sf *factory) state(addr []byte, s interface{}) error {
 }
 
 func (sf *factory) stateAtHeight(height uint64, addr []byte, s interface{}) error {
-	if !sf.saveHistory {
-		return ErrNoArchiveData
-	}
-	// get root through height
-	rootHash, err := sf.dao.Get(AccountTrieNamespace, []byte(fmt.Sprintf("%s-%d", AccountTrieRootKey, height)))
-	if err != nil {
-		return errors.Wrap(err, "failed to get root hash through height")
-	}
-	dbForTrie, err := db.NewKVStoreForTrie(AccountTrieNamespace, sf.dao)
-	if err != nil {
-		return errors.Wrap(err, "failed to generate state tire db")
-	}
-	tr, err := trie.NewTrie(trie.KVStoreOption(dbForTrie), trie.RootHashOption(rootHash))
-	if err != nil {
-		return errors.Wrap(err, "failed to generate state trie from config")
-	}
-	err = tr.Start(context.Background())
+	tr, err := sf.trieAtHeight(height)
 	if err != nil {
 		return err
 	}
@@ -456,6 +644,33 @@ func (sf *factory) stateAtHeight(height uint64, addr []byte, s interface{}) erro
 	return state.Deserialize(s, mstate)
 }
 
+// trieAtHeight returns the started account trie of the height, which the caller has to stop
+func (sf *factory) trieAtHeight(height uint64) (trie.Trie, error) {
+	if !sf.saveHistory {
+		return nil, ErrNoArchiveData
+	}
+	rootHash, err := trieRootAt(sf.dao, height)
+	if err != nil {
+		return nil, err
+	}
+	dbForTrie, err := db.NewKVStoreForTrie(AccountTrieNamespace, sf.dao)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate state tire db")
+	}
+	tr, err := trie.NewTrie(
+		trie.KVStoreOption(dbForTrie),
+		trie.RootHashOption(rootHash),
+		trie.NodeCacheOption(sf.nodeCache),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate state trie from config")
+	}
+	if err := tr.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
 func (sf *factory) commit(ws WorkingSet) error {
 	if err := ws.Commit(); err != nil {
 		return errors.Wrap(err, "failed to commit working set")
@@ -481,6 +696,8 @@ func (sf *factory) createGenesisStates(ctx context.Context) error {
 		0,
 		sf.dao,
 		sf.rootHash(),
+		sf.trieOptions(),
+		sf.cfg.Genesis.TrieRootIndexHeight,
 		sf.flusherOptions(ctx, 0)...,
 	)
 	if err != nil {
@@ -511,6 +728,8 @@ func (sf *factory) getFromWorkingSets(ctx context.Context, key hash.Hash256) (Wo
 		sf.currentChainHeight+1,
 		sf.dao,
 		sf.rootHash(),
+		sf.trieOptions(),
+		sf.cfg.Genesis.TrieRootIndexHeight,
 		sf.flusherOptions(ctx, sf.currentChainHeight+1)...,
 	)
 	if err != nil {
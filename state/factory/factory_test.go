@@ -7,13 +7,16 @@
 package factory
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-address/address"
 	"github.com/iotexproject/iotex-election/test/mock/mock_committee"
+	"github.com/iotexproject/iotex-proto/golang/iotextypes"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
@@ -37,7 +41,9 @@ import (
 	"github.com/iotexproject/iotex-core/blockchain/genesis"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/db/trie"
 	"github.com/iotexproject/iotex-core/pkg/enc"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/pkg/util/fileutil"
 	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/identityset"
@@ -262,8 +268,6 @@ func testCandidates(sf Factory, t *testing.T) {
 		committee,
 		uint64(123456),
 		func(uint64) (time.Time, error) { return time.Now(), nil },
-		config.Default.Genesis.NumCandidateDelegates,
-		config.Default.Genesis.NumDelegates,
 		config.Default.Chain.PollInitialCandidatesInterval,
 		nil,
 		nil,
@@ -286,6 +290,23 @@ func testCandidates(sf Factory, t *testing.T) {
 		GasLimit:    gasLimit,
 	})
 
+	// the poll result put by anyone but the producer fails, rather than the block produced along with it
+	selp28, err := action.Sign(elp, identityset.PrivateKey(28))
+	require.NoError(t, err)
+	minter, ok := sf.(Minter)
+	require.True(t, ok)
+	blkBuilder, err := minter.NewBlockBuilder(
+		ctx,
+		map[string][]action.SealedEnvelope{identityset.Address(28).String(): {selp28}},
+		nil,
+	)
+	require.NoError(t, err)
+	produced, err := blkBuilder.SignAndBuild(identityset.PrivateKey(27))
+	require.NoError(t, err)
+	require.Equal(t, []action.SealedEnvelope{selp28}, produced.Actions)
+	require.Equal(t, 1, len(produced.Receipts))
+	require.Equal(t, uint64(iotextypes.ReceiptStatus_Failure), produced.Receipts[0].Status)
+
 	blk, err := block.NewTestingBuilder().
 		SetHeight(1).
 		SetPrevBlockHash(hash.ZeroHash256).
@@ -316,6 +337,35 @@ func TestState(t *testing.T) {
 	testState(sf, t)
 }
 
+func TestStateWithNodeCache(t *testing.T) {
+	cfg := config.Default
+	cfg.Chain.TrieNodeCacheSize = 1000
+	sf, err := NewFactory(cfg, InMemTrieOption())
+	require.NoError(t, err)
+	require.NotNil(t, sf.(*factory).nodeCache)
+	testState(sf, t)
+	require.True(t, sf.(*factory).nodeCache.Len() > 0)
+}
+
+func TestStateWithWAL(t *testing.T) {
+	testTrieFile, _ := ioutil.TempFile(os.TempDir(), triePath)
+	testWALFile, _ := ioutil.TempFile(os.TempDir(), "wal")
+	defer func() {
+		testutil.CleanupPath(t, testTrieFile.Name())
+		testutil.CleanupPath(t, testWALFile.Name())
+	}()
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTrieFile.Name()
+	cfg.Chain.TrieDBWALPath = testWALFile.Name()
+	sf, err := NewFactory(cfg, DefaultTrieOption())
+	require.NoError(t, err)
+	testState(sf, t)
+	// the batches are drained by stop
+	info, err := os.Stat(testWALFile.Name())
+	require.NoError(t, err)
+	require.Equal(t, int64(0), info.Size())
+}
+
 func TestHistoryState(t *testing.T) {
 	// using factory and enable history
 	testTrieFile, _ := ioutil.TempFile(os.TempDir(), triePath)
@@ -423,6 +473,64 @@ func testState(sf Factory, t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, accountA, &testAccount)
 	require.Equal(t, big.NewInt(90), accountA.Balance)
+
+	// test States() against State()
+	var keys [][]byte
+	for _, i := range []int{28, 31, 28} {
+		h := hash.BytesToHash160(identityset.Address(i).Bytes())
+		keys = append(keys, h[:])
+	}
+	missing := hash.Hash160b([]byte("missing"))
+	keys = append(keys, missing[:])
+	ws, err := sf.NewWorkingSet()
+	require.NoError(t, err)
+	for _, sr := range []protocol.StateReader{sf, ws} {
+		_, iter, err := sr.States(protocol.KeysOption(keys))
+		require.NoError(t, err)
+		require.Equal(t, len(keys), iter.Size())
+		for _, key := range keys[:3] {
+			var expected, actual state.Account
+			_, err = sr.State(&expected, protocol.KeyOption(key))
+			require.NoError(t, err)
+			k, err := iter.Next(&actual)
+			require.NoError(t, err)
+			require.Equal(t, key, k)
+			require.Equal(t, expected, actual)
+		}
+		k, err := iter.Next(&testAccount)
+		require.Equal(t, state.ErrStateNotExist, errors.Cause(err))
+		require.Equal(t, keys[3], k)
+		_, err = iter.Next(&testAccount)
+		require.Equal(t, state.ErrOutOfBoundary, err)
+		// a batch of missing keys doesn't fail the call
+		_, iter, err = sr.States(protocol.KeysOption([][]byte{missing[:], missing[:]}))
+		require.NoError(t, err)
+		require.Equal(t, 2, iter.Size())
+		for i := 0; i < 2; i++ {
+			_, err = iter.Next(&testAccount)
+			require.Equal(t, state.ErrStateNotExist, errors.Cause(err))
+		}
+		// all the states of the namespace are listed in the order of the keys without the keys given
+		_, iter, err = sr.States()
+		require.NoError(t, err)
+		listed := make(map[string]state.Account)
+		var prev []byte
+		for i := 0; i < iter.Size(); i++ {
+			var acct state.Account
+			k, err := iter.Next(&acct)
+			require.NoError(t, err)
+			require.True(t, bytes.Compare(prev, k) < 0)
+			prev = k
+			listed[string(k)] = acct
+		}
+		for _, key := range keys[:2] {
+			var expected state.Account
+			_, err = sr.State(&expected, protocol.KeyOption(key))
+			require.NoError(t, err)
+			require.Equal(t, expected, listed[string(key)])
+		}
+		require.NotContains(t, listed, string(missing[:]))
+	}
 }
 
 func testHistoryState(sf Factory, t *testing.T, statetx, archive bool) {
@@ -690,6 +798,57 @@ func testLoadStoreHeight(sf Factory, t *testing.T) {
 	}
 }
 
+func TestStateAudit(t *testing.T) {
+	require := require.New(t)
+	errAudit := errors.New("audit failed")
+	for _, newFactory := range []func(audit StateAudit) (Factory, error){
+		func(audit StateAudit) (Factory, error) {
+			return NewFactory(config.Default, InMemTrieOption(), StateAuditOption(audit))
+		},
+		func(audit StateAudit) (Factory, error) {
+			return NewStateDB(config.Default, InMemStateDBOption(), StateAuditStateDBOption(audit))
+		},
+	} {
+		var audited []uint64
+		sf, err := newFactory(func(sr protocol.StateReader, g genesis.Genesis) error {
+			height, err := sr.Height()
+			require.NoError(err)
+			audited = append(audited, height)
+			require.Equal(config.Default.Genesis.BlockGasLimit, g.BlockGasLimit)
+			if height == 2 {
+				return errAudit
+			}
+			return nil
+		})
+		require.NoError(err)
+		require.NoError(sf.Start(context.Background()))
+		ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+			Genesis:  config.Default.Genesis,
+			Registry: protocol.NewRegistry(),
+		})
+		for i := uint64(1); i <= 2; i++ {
+			blk, err := block.NewTestingBuilder().
+				SetHeight(i).
+				SetPrevBlockHash(hash.ZeroHash256).
+				SetTimeStamp(testutil.TimestampNow()).
+				SignAndBuild(identityset.PrivateKey(27))
+			require.NoError(err)
+			err = sf.Commit(ctx, &blk)
+			if i == 2 {
+				// the block violating the invariants isn't committed
+				require.Equal(errAudit, errors.Cause(err))
+			} else {
+				require.NoError(err)
+			}
+		}
+		require.Equal([]uint64{1, 2}, audited)
+		height, err := sf.Height()
+		require.NoError(err)
+		require.Equal(uint64(1), height)
+		require.NoError(sf.Stop(context.Background()))
+	}
+}
+
 func TestRunActions(t *testing.T) {
 	require := require.New(t)
 	testTrieFile, _ := ioutil.TempFile(os.TempDir(), triePath)
@@ -1044,6 +1203,247 @@ func TestSTXGetDB(t *testing.T) {
 	require.True(ok)
 }
 
+func TestKVStore(t *testing.T) {
+	testKVStore := func(t *testing.T, newWorkingSet func() WorkingSet) {
+		require := require.New(t)
+		digest := func(ws WorkingSet) hash.Hash256 {
+			require.NoError(ws.Finalize())
+			h, err := ws.Digest()
+			require.NoError(err)
+			return h
+		}
+
+		ws := newWorkingSet()
+		kv, err := ws.KVStore("test")
+		require.NoError(err)
+		require.NoError(kv.Put([]byte("key"), []byte("value1")))
+		// the writes reverted are neither read nor in the digest
+		s := ws.Snapshot()
+		require.NoError(kv.Put([]byte("key"), []byte("value2")))
+		require.NoError(kv.Put([]byte("other"), []byte("value")))
+		require.NoError(ws.Revert(s))
+		value, err := kv.Get([]byte("key"))
+		require.NoError(err)
+		require.Equal([]byte("value1"), value)
+		_, err = kv.Get([]byte("other"))
+		require.Equal(db.ErrNotExist, errors.Cause(err))
+		// the write is bound to the namespace
+		other, err := ws.KVStore("other")
+		require.NoError(err)
+		_, err = other.Get([]byte("key"))
+		require.Equal(db.ErrNotExist, errors.Cause(err))
+
+		expected := newWorkingSet()
+		kv, err = expected.KVStore("test")
+		require.NoError(err)
+		require.NoError(kv.Put([]byte("key"), []byte("value1")))
+		empty := newWorkingSet()
+		require.Equal(digest(expected), digest(ws))
+		require.NotEqual(digest(empty), digest(ws))
+
+		// the namespaces of the states can't be accessed
+		for _, ns := range []string{"", AccountKVNamespace, AccountTrieNamespace} {
+			_, err = newWorkingSet().KVStore(ns)
+			require.Equal(ErrNotSupported, errors.Cause(err))
+		}
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		testKVStore(t, func() WorkingSet {
+			ws, err := sf.NewWorkingSet()
+			require.NoError(t, err)
+			return ws
+		})
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		testKVStore(t, func() WorkingSet {
+			ws, err := newStateTX(1, db.NewMemKVStore())
+			require.NoError(t, err)
+			return ws
+		})
+	})
+}
+
+func TestSTXStatesPrefix(t *testing.T) {
+	require := require.New(t)
+	sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+	require.NoError(err)
+	const ns = "prefixTest"
+	put := func(ws WorkingSet, key string, balance int64) {
+		acct := state.EmptyAccount()
+		acct.Balance = big.NewInt(balance)
+		_, err := ws.PutState(&acct, protocol.NamespaceOption(ns), protocol.KeyOption([]byte(key)))
+		require.NoError(err)
+	}
+	balances := func(sr protocol.StateReader) map[string]int64 {
+		_, iter, err := sr.States(protocol.NamespaceOption(ns), protocol.PrefixOption([]byte("a")))
+		require.NoError(err)
+		res := make(map[string]int64)
+		var prev []byte
+		for i := 0; i < iter.Size(); i++ {
+			var acct state.Account
+			key, err := iter.Next(&acct)
+			require.NoError(err)
+			require.True(bytes.Compare(prev, key) < 0)
+			prev = key
+			res[string(key)] = acct.Balance.Int64()
+		}
+		return res
+	}
+
+	ws, err := sdb.NewWorkingSet()
+	require.NoError(err)
+	put(ws, "a1", 1)
+	put(ws, "a2", 2)
+	put(ws, "b1", 10)
+	require.NoError(ws.Finalize())
+	require.NoError(ws.Commit())
+	require.Equal(map[string]int64{"a1": 1, "a2": 2}, balances(sdb))
+
+	// the matches are split between the pending writes of the working set and the committed states
+	ws, err = sdb.NewWorkingSet()
+	require.NoError(err)
+	put(ws, "a3", 3)
+	put(ws, "a2", 20)
+	_, err = ws.DelState(protocol.NamespaceOption(ns), protocol.KeyOption([]byte("a1")))
+	require.NoError(err)
+	require.Equal(map[string]int64{"a2": 20, "a3": 3}, balances(ws))
+	require.Equal(map[string]int64{"a1": 1, "a2": 2}, balances(sdb))
+
+	// the prefix can't be used along with a key
+	_, _, err = ws.States(protocol.PrefixOption([]byte("a")), protocol.KeyOption([]byte("a1")))
+	require.Error(err)
+	_, _, err = ws.States(protocol.PrefixOption([]byte("a")), protocol.KeysOption([][]byte{[]byte("a1")}))
+	require.Error(err)
+	// nor can the keys
+	_, _, err = ws.States(protocol.KeysOption([][]byte{[]byte("a1")}), protocol.KeyOption([]byte("a1")))
+	require.Error(err)
+}
+
+func TestIndexOption(t *testing.T) {
+	require := require.New(t)
+	const indexNS = "nonceIndex"
+	// the accounts are indexed by their nonces
+	index := protocol.IndexOption(indexNS, func(value []byte) [][]byte {
+		var acct state.Account
+		require.NoError(acct.Deserialize(value))
+		return [][]byte{{byte(acct.Nonce)}}
+	})
+	put := func(ws WorkingSet, key string, nonce uint64) {
+		acct := state.EmptyAccount()
+		acct.Nonce = nonce
+		_, err := ws.PutState(&acct, protocol.KeyOption([]byte(key)), index)
+		require.NoError(err)
+	}
+	indexed := func(sr protocol.StateReader, nonce byte) [][]byte {
+		keys, err := protocol.IndexedKeys(sr, indexNS, []byte{nonce})
+		require.NoError(err)
+		return keys
+	}
+
+	ws, err := newStateTX(1, db.NewMemKVStore())
+	require.NoError(err)
+	put(ws, "a", 1)
+	put(ws, "b", 1)
+	require.Equal([][]byte{[]byte("a"), []byte("b")}, indexed(ws, 1))
+	// the index entry is moved along with the indexed field
+	s := ws.Snapshot()
+	put(ws, "a", 2)
+	require.Equal([][]byte{[]byte("b")}, indexed(ws, 1))
+	require.Equal([][]byte{[]byte("a")}, indexed(ws, 2))
+	_, err = ws.DelState(protocol.KeyOption([]byte("b")), index)
+	require.NoError(err)
+	require.Empty(indexed(ws, 1))
+	// the index is reverted along with the states
+	require.NoError(ws.Revert(s))
+	require.Equal([][]byte{[]byte("a"), []byte("b")}, indexed(ws, 1))
+	require.Empty(indexed(ws, 2))
+
+	// the state of the trie can't be indexed
+	sf, err := NewFactory(config.Default, InMemTrieOption())
+	require.NoError(err)
+	tws, err := sf.NewWorkingSet()
+	require.NoError(err)
+	acct := state.EmptyAccount()
+	_, err = tws.PutState(&acct, protocol.KeyOption([]byte("a")), index)
+	require.Equal(ErrNotSupported, errors.Cause(err))
+	_, err = tws.DelState(protocol.KeyOption([]byte("a")), index)
+	require.Equal(ErrNotSupported, errors.Cause(err))
+}
+
+func TestStatesWithContext(t *testing.T) {
+	const total = 2000
+	testStatesWithContext := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		keys := make([][]byte, total)
+		for i := range keys {
+			acct := state.EmptyAccount()
+			acct.Balance = big.NewInt(int64(i))
+			keys[i] = []byte(fmt.Sprintf("key%017d", i))
+			_, err := ws.PutState(&acct, protocol.KeyOption(keys[i]))
+			require.NoError(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, opt := range []protocol.StateOption{protocol.KeysOption(keys), protocol.PrefixOption([]byte("key"))} {
+			_, iter, err := ws.States(protocol.WithContext(ctx), opt)
+			require.Equal(context.Canceled, errors.Cause(err))
+			require.Nil(iter)
+		}
+		// the aborted scans leave nothing behind
+		_, iter, err := ws.States(protocol.WithContext(context.Background()), protocol.KeysOption(keys))
+		require.NoError(err)
+		require.Equal(total, iter.Size())
+		for i := 0; i < total; i++ {
+			var acct state.Account
+			key, err := iter.Next(&acct)
+			require.NoError(err)
+			require.Equal(keys[i], key)
+			require.Equal(int64(i), acct.Balance.Int64())
+		}
+		// the reads of a single state ignore the context
+		var acct state.Account
+		_, err = ws.State(&acct, protocol.WithContext(ctx), protocol.KeyOption(keys[1]))
+		require.NoError(err)
+		require.Equal(int64(1), acct.Balance.Int64())
+	}
+
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(t, err)
+		testStatesWithContext(t, ws)
+	})
+	t.Run("stateTX", func(t *testing.T) {
+		sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+		require.NoError(t, err)
+		ws, err := sdb.NewWorkingSet()
+		require.NoError(t, err)
+		testStatesWithContext(t, ws)
+	})
+	t.Run("abortMidScan", func(t *testing.T) {
+		require := require.New(t)
+		keys := make([][]byte, total)
+		for i := range keys {
+			keys[i] = []byte(fmt.Sprintf("key%017d", i))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		read := 0
+		_, err := readStates(ctx, keys, func(key []byte) ([]byte, error) {
+			if read++; read == 300 {
+				cancel()
+			}
+			return key, nil
+		})
+		require.Equal(context.Canceled, errors.Cause(err))
+		// the scan stops at the next check of the context
+		require.Equal(2*contextCheckInterval, read)
+	})
+}
+
 func TestDeleteAndPutSameKey(t *testing.T) {
 	testDeleteAndPutSameKey := func(t *testing.T, ws WorkingSet) {
 		key := hash.Hash160b([]byte("test"))
@@ -1073,100 +1473,903 @@ func TestDeleteAndPutSameKey(t *testing.T) {
 	})
 }
 
-func BenchmarkInMemRunAction(b *testing.B) {
-	cfg := config.Default
-	sf, err := NewFactory(cfg, InMemTrieOption())
-	if err != nil {
-		b.Fatal(err)
-	}
-	benchRunAction(sf, b)
+// handlerProtocol is a protocol handling the actions with a function
+type handlerProtocol struct {
+	protocol.Protocol
+	handle func(context.Context, protocol.StateManager) error
 }
 
-func BenchmarkDBRunAction(b *testing.B) {
-	tp := filepath.Join(os.TempDir(), triePath)
-	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
-		b.Error("Fail to remove testDB file")
-	}
+func (p *handlerProtocol) Name() string { return "" }
 
-	cfg := config.Default
-	cfg.DB.DbPath = tp
-	sf, err := NewFactory(cfg, PrecreatedTrieDBOption(db.NewBoltDB(cfg.DB)))
-	if err != nil {
-		b.Fatal(err)
-	}
-	benchRunAction(sf, b)
+func (p *handlerProtocol) Version() uint { return 0 }
 
-	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
-		b.Error("Fail to remove testDB file")
-	}
+func (p *handlerProtocol) Handle(ctx context.Context, _ action.Action, sm protocol.StateManager) (*action.Receipt, error) {
+	return nil, p.handle(ctx, sm)
 }
 
-func BenchmarkSDBInMemRunAction(b *testing.B) {
-	cfg := config.Default
-	sdb, err := NewStateDB(cfg, InMemStateDBOption())
-	if err != nil {
-		b.Fatal(err)
-	}
-	benchRunAction(sdb, b)
+// timeoutSubscriber records the actions timing out
+type timeoutSubscriber struct {
+	timedOut []action.SealedEnvelope
 }
 
-func BenchmarkSDBRunAction(b *testing.B) {
-	tp := filepath.Join(os.TempDir(), stateDBPath)
-	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
-		b.Error("Fail to remove testDB file")
-	}
-	cfg := config.Default
-	cfg.Chain.TrieDBPath = tp
-	sdb, err := NewStateDB(cfg, DefaultStateDBOption())
-	if err != nil {
-		b.Fatal(err)
-	}
-	benchRunAction(sdb, b)
-	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
-		b.Error("Fail to remove testDB file")
-	}
+func (s *timeoutSubscriber) HandleActionTimeout(selp action.SealedEnvelope) {
+	s.timedOut = append(s.timedOut, selp)
 }
 
-func benchRunAction(sf Factory, b *testing.B) {
-	// set up
-	accounts := []string{
-		identityset.Address(28).String(),
-		identityset.Address(29).String(),
-		identityset.Address(30).String(),
-		identityset.Address(31).String(),
-		identityset.Address(32).String(),
-		identityset.Address(33).String(),
-	}
-	pubKeys := []crypto.PublicKey{
-		identityset.PrivateKey(28).PublicKey(),
-		identityset.PrivateKey(29).PublicKey(),
-		identityset.PrivateKey(30).PublicKey(),
-		identityset.PrivateKey(31).PublicKey(),
-		identityset.PrivateKey(32).PublicKey(),
-		identityset.PrivateKey(33).PublicKey(),
+// genesisProtocol is a protocol creating its genesis states with a function, after the protocols it depends on
+type genesisProtocol struct {
+	protocol.Protocol
+	dependencies []string
+	create       func(protocol.StateManager) error
+}
+
+func (p *genesisProtocol) Name() string { return "" }
+
+func (p *genesisProtocol) Version() uint { return 0 }
+
+func (p *genesisProtocol) Dependencies() []string { return p.dependencies }
+
+func (p *genesisProtocol) CreateGenesisStates(_ context.Context, sm protocol.StateManager) error {
+	return p.create(sm)
+}
+
+func TestGenesisStatesCreationOrder(t *testing.T) {
+	baseKey, derivedKey := hash.Hash160b([]byte("base")), hash.Hash160b([]byte("derived"))
+	balance := func(sr protocol.StateReader, key hash.Hash160) (int64, error) {
+		var acct state.Account
+		if _, err := sr.State(&acct, protocol.LegacyKeyOption(key)); err != nil {
+			return 0, err
+		}
+		return acct.Balance.Int64(), nil
 	}
-	nonces := make([]uint64, len(accounts))
-	ge := genesis.Default
-	for _, acc := range accounts {
-		ge.InitBalanceMap[acc] = big.NewInt(int64(b.N * 100)).String()
+	put := func(sm protocol.StateManager, key hash.Hash160, balance int64) error {
+		acct := state.EmptyAccount()
+		acct.Balance = big.NewInt(balance)
+		_, err := sm.PutState(&acct, protocol.LegacyKeyOption(key))
+		return err
 	}
-	registry := protocol.NewRegistry()
-	acc := account.NewProtocol(rewarding.DepositGas)
-	if err := acc.Register(registry); err != nil {
-		b.Fatal(err)
+	testGenesisStatesCreationOrder := func(t *testing.T, sf Factory) {
+		require := require.New(t)
+		fail := true
+		registry := protocol.NewRegistry()
+		// the protocols are registered in the reverse order of their dependencies
+		require.NoError(registry.Register("failing", &genesisProtocol{
+			dependencies: []string{"derived"},
+			create: func(protocol.StateManager) error {
+				if fail {
+					return errors.New("failed to create genesis states")
+				}
+				return nil
+			},
+		}))
+		require.NoError(registry.Register("derived", &genesisProtocol{
+			dependencies: []string{"base"},
+			create: func(sm protocol.StateManager) error {
+				// the genesis states of the dependency have been created
+				base, err := balance(sm, baseKey)
+				if err != nil {
+					return err
+				}
+				return put(sm, derivedKey, 2*base)
+			},
+		}))
+		require.NoError(registry.Register("base", &genesisProtocol{
+			create: func(sm protocol.StateManager) error {
+				return put(sm, baseKey, 10)
+			},
+		}))
+		ctx := protocol.WithBlockCtx(
+			protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+				Genesis:  config.Default.Genesis,
+				Registry: registry,
+			}),
+			protocol.BlockCtx{},
+		)
+
+		// none of the genesis states are created if one of the protocols fails
+		require.Error(sf.Start(ctx))
+		_, err := sf.Height()
+		require.Error(err)
+
+		fail = false
+		require.NoError(sf.Start(ctx))
+		defer func() {
+			require.NoError(sf.Stop(ctx))
+		}()
+		height, err := sf.Height()
+		require.NoError(err)
+		require.Equal(uint64(0), height)
+		base, err := balance(sf, baseKey)
+		require.NoError(err)
+		require.Equal(int64(10), base)
+		derived, err := balance(sf, derivedKey)
+		require.NoError(err)
+		require.Equal(int64(20), derived)
 	}
-	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
-		Genesis:  ge,
-		Registry: registry,
+
+	t.Run("factory", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		testGenesisStatesCreationOrder(t, sf)
 	})
-	if err := sf.Start(ctx); err != nil {
-		b.Fatal(err)
+	t.Run("stateDB", func(t *testing.T) {
+		sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+		require.NoError(t, err)
+		testGenesisStatesCreationOrder(t, sdb)
+	})
+}
+
+func TestDelStateExistence(t *testing.T) {
+	testDelState := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		// delete missing
+		_, err := ws.DelState(protocol.LegacyKeyOption(key))
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		require.False(state.IsStateDeleted(err))
+		_, err = protocol.DelStateIfExists(ws, protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		// delete existing
+		_, err = ws.PutState(state.EmptyAccount(), protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		_, err = ws.DelState(protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		// delete after delete
+		_, err = ws.DelState(protocol.LegacyKeyOption(key))
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		require.True(state.IsStateDeleted(err))
+		_, err = protocol.DelStateIfExists(ws, protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		// the cached object exists before it's serialized
+		acc := state.EmptyAccount()
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		_, err = ws.DelState(protocol.LegacyKeyOption(key))
+		require.NoError(err)
 	}
-	defer func() {
-		if err := sf.Stop(ctx); err != nil {
-			b.Fatal(err)
-		}
-	}()
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(t, err)
+		testDelState(t, ws)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		ws, err := newStateTX(0, db.NewMemKVStore())
+		require.NoError(t, err)
+		testDelState(t, ws)
+	})
+}
+
+func TestStateDeleted(t *testing.T) {
+	testStateDeleted := func(t *testing.T, ws WorkingSet, newWorkingSet func() WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		_, err := ws.PutState(state.EmptyAccount(), protocol.LegacyKeyOption(key))
+		require.NoError(err)
+
+		selp1, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), 1, big.NewInt(1), nil, 100000, big.NewInt(0))
+		require.NoError(err)
+		selp2, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), 2, big.NewInt(1), nil, 100000, big.NewInt(0))
+		require.NoError(err)
+		var readErr error
+		registry := protocol.NewRegistry()
+		require.NoError(registry.Register("test", &handlerProtocol{
+			handle: func(ctx context.Context, sm protocol.StateManager) error {
+				// the first action deletes the state, and the second one reads it
+				if protocol.MustGetActionCtx(ctx).Nonce == 1 {
+					_, err := sm.DelState(protocol.LegacyKeyOption(key))
+					return err
+				}
+				var acc state.Account
+				_, readErr = sm.State(&acc, protocol.LegacyKeyOption(key))
+				return nil
+			},
+		}))
+		ctx := protocol.WithBlockchainCtx(
+			protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{BlockHeight: 1}),
+			protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+		)
+		_, err = ws.RunActions(ctx, []action.SealedEnvelope{selp1, selp2})
+		require.NoError(err)
+		require.True(state.IsStateDeleted(readErr))
+		require.Equal(state.ErrStateNotExist, errors.Cause(readErr))
+		h := selp1.Hash()
+		require.Contains(readErr.Error(), hex.EncodeToString(h[:]))
+
+		// the state which has never existed isn't deleted
+		var acc state.Account
+		_, err = ws.State(&acc, protocol.LegacyKeyOption(hash.Hash160b([]byte("other"))))
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		require.False(state.IsStateDeleted(err))
+
+		// the deletion is forgotten once committed
+		require.NoError(ws.Finalize())
+		require.NoError(ws.Commit())
+		_, err = newWorkingSet().State(&acc, protocol.LegacyKeyOption(key))
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		require.False(state.IsStateDeleted(err))
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		newWorkingSet := func() WorkingSet {
+			ws, err := sf.NewWorkingSet()
+			require.NoError(t, err)
+			return ws
+		}
+		testStateDeleted(t, newWorkingSet(), newWorkingSet)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		kv := db.NewMemKVStore()
+		newWorkingSet := func() WorkingSet {
+			ws, err := newStateTX(1, kv)
+			require.NoError(t, err)
+			return ws
+		}
+		testStateDeleted(t, newWorkingSet(), newWorkingSet)
+	})
+}
+
+func TestReadView(t *testing.T) {
+	require := require.New(t)
+	key1, key2 := hash.Hash160b([]byte("key1")), hash.Hash160b([]byte("key2"))
+	keys := [][]byte{key1[:], key2[:]}
+	registry := protocol.NewRegistry()
+	// each action adds 1 to the balances of the keys
+	require.NoError(registry.Register("test", &handlerProtocol{
+		handle: func(_ context.Context, sm protocol.StateManager) error {
+			for _, key := range keys {
+				acc := state.EmptyAccount()
+				if _, err := sm.State(&acc, protocol.KeyOption(key)); err != nil && errors.Cause(err) != state.ErrStateNotExist {
+					return err
+				}
+				acc.Balance.Add(acc.Balance, big.NewInt(1))
+				if _, err := sm.PutState(&acc, protocol.KeyOption(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}))
+	ctx := protocol.WithBlockchainCtx(
+		context.Background(),
+		protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+	)
+	sf, err := NewFactory(config.Default, InMemTrieOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	commit := func(height uint64) {
+		selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), height, big.NewInt(1), nil, 100000, big.NewInt(0))
+		require.NoError(err)
+		blk, err := block.NewTestingBuilder().
+			SetHeight(height).
+			SetPrevBlockHash(hash.ZeroHash256).
+			SetTimeStamp(testutil.TimestampNow()).
+			AddActions(selp).
+			SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(sf.Commit(ctx, &blk))
+	}
+	balance := func(sr protocol.StateReader, key []byte) int64 {
+		var acc state.Account
+		_, err := sr.State(&acc, protocol.KeyOption(key))
+		require.NoError(err)
+		return acc.Balance.Int64()
+	}
+
+	commit(1)
+	view, release, err := sf.ReadView()
+	require.NoError(err)
+	require.Equal(int64(1), balance(view, keys[0]))
+	// the block committed between the reads through the view isn't seen by the view
+	commit(2)
+	require.Equal(int64(2), balance(sf, keys[1]))
+	require.Equal(int64(1), balance(view, keys[1]))
+	height, iter, err := view.States(protocol.KeysOption(keys))
+	require.NoError(err)
+	require.Equal(uint64(1), height)
+	for range keys {
+		var acc state.Account
+		_, err := iter.Next(&acc)
+		require.NoError(err)
+		require.Equal(int64(1), acc.Balance.Int64())
+	}
+	_, err = view.State(&state.Account{}, protocol.KeyOption(keys[0]), protocol.BlockHeightOption(1))
+	require.Equal(ErrNotSupported, errors.Cause(err))
+
+	// the released view can't be read
+	release()
+	release()
+	_, err = view.State(&state.Account{}, protocol.KeyOption(keys[0]))
+	require.Equal(ErrViewReleased, errors.Cause(err))
+	_, _, err = view.States()
+	require.Equal(ErrViewReleased, errors.Cause(err))
+	_, err = view.Height()
+	require.Equal(ErrViewReleased, errors.Cause(err))
+	commit(3)
+	require.Equal(int64(3), balance(sf, keys[0]))
+
+	// the view of a working set passes the reads to it
+	ws, err := sf.NewWorkingSet()
+	require.NoError(err)
+	view, release, err = ws.ReadView()
+	require.NoError(err)
+	require.Equal(int64(3), balance(view, keys[0]))
+	release()
+	_, err = view.State(&state.Account{}, protocol.KeyOption(keys[0]))
+	require.Equal(ErrViewReleased, errors.Cause(err))
+
+	// the state DB has no view
+	sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+	require.NoError(err)
+	_, _, err = sdb.ReadView()
+	require.Equal(ErrNotSupported, errors.Cause(err))
+}
+
+func TestNewReader(t *testing.T) {
+	require := require.New(t)
+	key1, key2 := hash.Hash160b([]byte("key1")), hash.Hash160b([]byte("key2"))
+	keys := [][]byte{key1[:], key2[:]}
+	registry := protocol.NewRegistry()
+	// each action adds 1 to the balances of the keys, so the balances are the height once committed
+	require.NoError(registry.Register("test", &handlerProtocol{
+		handle: func(_ context.Context, sm protocol.StateManager) error {
+			for _, key := range keys {
+				acc := state.EmptyAccount()
+				if _, err := sm.State(&acc, protocol.KeyOption(key)); err != nil && errors.Cause(err) != state.ErrStateNotExist {
+					return err
+				}
+				acc.Balance.Add(acc.Balance, big.NewInt(1))
+				if _, err := sm.PutState(&acc, protocol.KeyOption(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}))
+	ctx := protocol.WithBlockchainCtx(
+		context.Background(),
+		protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+	)
+	testTrieFile, err := ioutil.TempFile(os.TempDir(), triePath)
+	require.NoError(err)
+	defer os.Remove(testTrieFile.Name())
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTrieFile.Name()
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := NewFactory(cfg, DefaultTrieOption())
+	require.NoError(err)
+	require.NoError(sf.Start(ctx))
+	defer func() {
+		require.NoError(sf.Stop(ctx))
+	}()
+	commit := func(height uint64) {
+		selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), height, big.NewInt(1), nil, 100000, big.NewInt(0))
+		require.NoError(err)
+		blk, err := block.NewTestingBuilder().
+			SetHeight(height).
+			SetPrevBlockHash(hash.ZeroHash256).
+			SetTimeStamp(testutil.TimestampNow()).
+			AddActions(selp).
+			SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(sf.Commit(ctx, &blk))
+	}
+	// read returns an error unless the states read by each call are the ones of the height the call returns
+	read := func(sr protocol.StateReader) error {
+		height, iter, err := sr.States(protocol.KeysOption(keys))
+		if err != nil {
+			return err
+		}
+		for range keys {
+			var acc state.Account
+			if _, err := iter.Next(&acc); err != nil {
+				return err
+			}
+			if acc.Balance.Uint64() != height {
+				return errors.Errorf("balance %d is read at height %d", acc.Balance.Uint64(), height)
+			}
+		}
+		var acc state.Account
+		if height, err = sr.State(&acc, protocol.KeyOption(keys[1])); err != nil {
+			return err
+		}
+		if acc.Balance.Uint64() != height {
+			return errors.Errorf("balance %d is read at height %d", acc.Balance.Uint64(), height)
+		}
+		return nil
+	}
+
+	commit(1)
+	reader, err := sf.NewReader()
+	require.NoError(err)
+	done := make(chan struct{})
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if err := read(reader); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for height := uint64(2); height <= 20; height++ {
+		commit(height)
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(err)
+	}
+	// the reader reads the latest height once the commits are done
+	require.NoError(read(reader))
+	height, err := reader.Height()
+	require.NoError(err)
+	require.Equal(uint64(20), height)
+	var acc state.Account
+	_, err = reader.State(&acc, protocol.KeyOption(keys[0]), protocol.BlockHeightOption(5))
+	require.NoError(err)
+	require.Equal(int64(5), acc.Balance.Int64())
+
+	// the pinned reader reads the archived states of its height
+	pinned, err := sf.NewReader(ReaderHeightOption(10))
+	require.NoError(err)
+	commit(21)
+	require.NoError(read(pinned))
+	height, err = pinned.Height()
+	require.NoError(err)
+	require.Equal(uint64(10), height)
+	_, err = sf.NewReader(ReaderHeightOption(22))
+	require.Equal(ErrNoArchiveData, errors.Cause(err))
+
+	// the factory out of archive mode and the state DB can't pin a reader
+	inMem, err := NewFactory(config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = inMem.NewReader(ReaderHeightOption(0))
+	require.Equal(ErrNoArchiveData, errors.Cause(err))
+	sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+	require.NoError(err)
+	require.NoError(sdb.Start(ctx))
+	defer func() {
+		require.NoError(sdb.Stop(ctx))
+	}()
+	_, err = sdb.NewReader(ReaderHeightOption(0))
+	require.Equal(ErrNotSupported, errors.Cause(err))
+	reader, err = sdb.NewReader()
+	require.NoError(err)
+	height, err = reader.Height()
+	require.NoError(err)
+	require.Zero(height)
+}
+
+func TestTipHeight(t *testing.T) {
+	testTipHeight := func(t *testing.T, sf Factory) {
+		require := require.New(t)
+		ctx := protocol.WithBlockchainCtx(
+			context.Background(),
+			protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: protocol.NewRegistry()},
+		)
+		require.NoError(sf.Start(ctx))
+		defer func() {
+			require.NoError(sf.Stop(ctx))
+		}()
+		selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), 1, big.NewInt(1), nil, 100000, big.NewInt(0))
+		require.NoError(err)
+		blk, err := block.NewTestingBuilder().
+			SetHeight(1).
+			SetPrevBlockHash(hash.ZeroHash256).
+			SetTimeStamp(testutil.TimestampNow()).
+			AddActions(selp).
+			SignAndBuild(identityset.PrivateKey(27))
+		require.NoError(err)
+		require.NoError(sf.Commit(ctx, &blk))
+		heights := func(sr protocol.StateReader) [2]uint64 {
+			height, err := sr.Height()
+			require.NoError(err)
+			tipHeight, err := sr.TipHeight()
+			require.NoError(err)
+			return [2]uint64{height, tipHeight}
+		}
+
+		// the working set reads the states of the block being built atop the committed tip
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+		require.Equal([2]uint64{2, 1}, heights(ws))
+		// the reader of the committed states reads the states of the tip
+		require.Equal([2]uint64{1, 1}, heights(sf))
+		reader, err := sf.NewReader()
+		require.NoError(err)
+		require.Equal([2]uint64{1, 1}, heights(reader))
+	}
+	t.Run("factory", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		testTipHeight(t, sf)
+	})
+	t.Run("stateDB", func(t *testing.T) {
+		sdb, err := NewStateDB(config.Default, InMemStateDBOption())
+		require.NoError(t, err)
+		testTipHeight(t, sdb)
+	})
+}
+
+func TestObjectOption(t *testing.T) {
+	testObjectOption := func(t *testing.T, newWorkingSet func() WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		ws := newWorkingSet()
+		acc := state.EmptyAccount()
+		acc.Nonce = 1
+		_, err := ws.PutState(&acc, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		// the cached object is read with and without the option
+		var read state.Account
+		_, err = ws.State(&read, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		require.Equal(uint64(1), read.Nonce)
+		read = state.Account{}
+		_, err = ws.State(&read, protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		require.Equal(uint64(1), read.Nonce)
+
+		// the object mutated after a snapshot isn't seen after the revert
+		s := ws.Snapshot()
+		mutated := state.EmptyAccount()
+		_, err = ws.State(&mutated, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		mutated.Nonce = 2
+		require.NoError(mutated.AddBalance(big.NewInt(10)))
+		_, err = ws.PutState(&mutated, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		require.NoError(ws.Revert(s))
+		read = state.Account{}
+		_, err = ws.State(&read, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		require.Equal(uint64(1), read.Nonce)
+		require.Equal(big.NewInt(0), read.Balance)
+		require.False(mutated.Balance == read.Balance)
+
+		// the cached objects aren't serialized by a snapshot, and the ones put after it are dropped by the revert
+		fresh := hash.Hash160b([]byte("fresh"))
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(fresh), protocol.ObjectOption())
+		require.NoError(err)
+		s = ws.Snapshot()
+		var buffer db.KVStoreWithBuffer
+		switch w := ws.(type) {
+		case *workingSet:
+			buffer = w.flusher.KVStoreWithBuffer()
+		case *stateTX:
+			buffer = w.flusher.KVStoreWithBuffer()
+		}
+		_, err = buffer.Get(AccountKVNamespace, fresh[:])
+		require.Equal(db.ErrNotExist, errors.Cause(err))
+		added := hash.Hash160b([]byte("added"))
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(added), protocol.ObjectOption())
+		require.NoError(err)
+		require.NoError(ws.Revert(s))
+		_, err = ws.State(&read, protocol.LegacyKeyOption(added), protocol.ObjectOption())
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		_, err = ws.State(&read, protocol.LegacyKeyOption(fresh), protocol.ObjectOption())
+		require.NoError(err)
+
+		// the cached object is deleted along with the state
+		other := hash.Hash160b([]byte("other"))
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(other), protocol.ObjectOption())
+		require.NoError(err)
+		_, err = ws.DelState(protocol.LegacyKeyOption(other))
+		require.NoError(err)
+		_, err = ws.State(&read, protocol.LegacyKeyOption(other), protocol.ObjectOption())
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+
+		// the cached objects are only serialized once when the working set is finalized
+		ws = newWorkingSet()
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		read = state.Account{}
+		_, err = ws.State(&read, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		read.Nonce = 3
+		_, err = ws.PutState(&read, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		require.NoError(ws.Finalize())
+		digest, err := ws.Digest()
+		require.NoError(err)
+
+		expected := newWorkingSet()
+		_, err = expected.PutState(&read, protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		require.NoError(expected.Finalize())
+		expectedDigest, err := expected.Digest()
+		require.NoError(err)
+		require.Equal(expectedDigest, digest)
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		testObjectOption(t, func() WorkingSet {
+			ws, err := sf.NewWorkingSet()
+			require.NoError(t, err)
+			return ws
+		})
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		testObjectOption(t, func() WorkingSet {
+			ws, err := newStateTX(0, db.NewMemKVStore())
+			require.NoError(t, err)
+			return ws
+		})
+	})
+}
+
+func TestLegacyKeyState(t *testing.T) {
+	testLegacyKeyState := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		acc := state.EmptyAccount()
+		acc.Nonce = 1
+		_, err := protocol.LegacyKeyPutState(ws, &acc, "", key)
+		require.NoError(err)
+		var read state.Account
+		_, err = ws.State(&read, protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		require.Equal(uint64(1), read.Nonce)
+
+		acc.Nonce = 2
+		_, err = ws.PutState(&acc, protocol.NoCopyKeyOption(key[:]))
+		require.NoError(err)
+		_, err = protocol.LegacyKeyState(ws, &read, "", key)
+		require.NoError(err)
+		require.Equal(uint64(2), read.Nonce)
+
+		// the cached object is put before the state is read through the fast path
+		acc.Nonce = 3
+		_, err = ws.PutState(&acc, protocol.LegacyKeyOption(key), protocol.ObjectOption())
+		require.NoError(err)
+		_, err = protocol.LegacyKeyState(ws, &read, "", key)
+		require.NoError(err)
+		require.Equal(uint64(3), read.Nonce)
+		_, err = protocol.LegacyKeyState(ws, &read, "", hash.Hash160b([]byte("other")))
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(t, err)
+		testLegacyKeyState(t, ws)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		ws, err := newStateTX(0, db.NewMemKVStore())
+		require.NoError(t, err)
+		testLegacyKeyState(t, ws)
+
+		// the namespace is kept by the fast path of the state db
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		acc := state.EmptyAccount()
+		_, err = protocol.LegacyKeyPutState(ws, &acc, "ns", key)
+		require.NoError(err)
+		_, err = ws.State(&acc, protocol.NamespaceOption("ns"), protocol.LegacyKeyOption(key))
+		require.NoError(err)
+		_, err = protocol.LegacyKeyState(ws, &acc, "other", key)
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+	})
+}
+
+// BenchmarkState compares the ways of reading a state, which are best compared on a million-read loop by
+// -benchtime=1000000x
+func BenchmarkState(b *testing.B) {
+	ws, err := newStateTX(0, db.NewMemKVStore())
+	if err != nil {
+		b.Fatal(err)
+	}
+	key := hash.Hash160b([]byte("test"))
+	acc := state.EmptyAccount()
+	if _, err := ws.PutState(&acc, protocol.NamespaceOption(AccountKVNamespace), protocol.LegacyKeyOption(key)); err != nil {
+		b.Fatal(err)
+	}
+	for _, read := range []struct {
+		name  string
+		state func(*state.Account) error
+	}{
+		{"options", func(acc *state.Account) error {
+			_, err := ws.State(acc, protocol.NamespaceOption(AccountKVNamespace), protocol.LegacyKeyOption(key))
+			return err
+		}},
+		{"noCopyKey", func(acc *state.Account) error {
+			_, err := ws.State(acc, protocol.NamespaceOption(AccountKVNamespace), protocol.NoCopyKeyOption(key[:]))
+			return err
+		}},
+		{"legacyKey", func(acc *state.Account) error {
+			_, err := protocol.LegacyKeyState(ws, acc, AccountKVNamespace, key)
+			return err
+		}},
+	} {
+		read := read
+		b.Run(read.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var acc state.Account
+			for i := 0; i < b.N; i++ {
+				if err := read.state(&acc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTransfer(b *testing.B) {
+	for _, object := range []bool{false, true} {
+		name := "serialized"
+		if object {
+			name = "object"
+		}
+		b.Run(name, func(b *testing.B) {
+			ws, err := newStateTX(0, db.NewMemKVStore())
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchTransfer(ws, object, b)
+		})
+	}
+}
+
+// benchTransfer moves balance around a few accounts, as a block full of transfers does
+func benchTransfer(ws WorkingSet, object bool, b *testing.B) {
+	keys := make([]hash.Hash160, 10)
+	for i := range keys {
+		keys[i] = hash.Hash160b(identityset.Address(i).Bytes())
+		acc := state.EmptyAccount()
+		acc.Balance = big.NewInt(1000000000)
+		if _, err := ws.PutState(&acc, protocol.LegacyKeyOption(keys[i])); err != nil {
+			b.Fatal(err)
+		}
+	}
+	opts := func(key hash.Hash160) []protocol.StateOption {
+		if object {
+			return []protocol.StateOption{protocol.LegacyKeyOption(key), protocol.ObjectOption()}
+		}
+		return []protocol.StateOption{protocol.LegacyKeyOption(key)}
+	}
+	amount := big.NewInt(1)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sender, recipient := keys[n%len(keys)], keys[(n+1)%len(keys)]
+		var from, to state.Account
+		if _, err := ws.State(&from, opts(sender)...); err != nil {
+			b.Fatal(err)
+		}
+		if err := from.SubBalance(amount); err != nil {
+			b.Fatal(err)
+		}
+		from.Nonce++
+		if _, err := ws.PutState(&from, opts(sender)...); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ws.State(&to, opts(recipient)...); err != nil {
+			b.Fatal(err)
+		}
+		if err := to.AddBalance(amount); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ws.PutState(&to, opts(recipient)...); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := ws.Finalize(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkInMemRunAction(b *testing.B) {
+	cfg := config.Default
+	sf, err := NewFactory(cfg, InMemTrieOption())
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchRunAction(sf, b)
+}
+
+func BenchmarkDBRunAction(b *testing.B) {
+	tp := filepath.Join(os.TempDir(), triePath)
+	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
+		b.Error("Fail to remove testDB file")
+	}
+
+	cfg := config.Default
+	cfg.DB.DbPath = tp
+	sf, err := NewFactory(cfg, PrecreatedTrieDBOption(db.NewBoltDB(cfg.DB)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchRunAction(sf, b)
+
+	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
+		b.Error("Fail to remove testDB file")
+	}
+}
+
+func BenchmarkSDBInMemRunAction(b *testing.B) {
+	cfg := config.Default
+	sdb, err := NewStateDB(cfg, InMemStateDBOption())
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchRunAction(sdb, b)
+}
+
+func BenchmarkSDBRunAction(b *testing.B) {
+	tp := filepath.Join(os.TempDir(), stateDBPath)
+	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
+		b.Error("Fail to remove testDB file")
+	}
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = tp
+	sdb, err := NewStateDB(cfg, DefaultStateDBOption())
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchRunAction(sdb, b)
+	if fileutil.FileExists(tp) && os.RemoveAll(tp) != nil {
+		b.Error("Fail to remove testDB file")
+	}
+}
+
+func benchRunAction(sf Factory, b *testing.B) {
+	// set up
+	accounts := []string{
+		identityset.Address(28).String(),
+		identityset.Address(29).String(),
+		identityset.Address(30).String(),
+		identityset.Address(31).String(),
+		identityset.Address(32).String(),
+		identityset.Address(33).String(),
+	}
+	pubKeys := []crypto.PublicKey{
+		identityset.PrivateKey(28).PublicKey(),
+		identityset.PrivateKey(29).PublicKey(),
+		identityset.PrivateKey(30).PublicKey(),
+		identityset.PrivateKey(31).PublicKey(),
+		identityset.PrivateKey(32).PublicKey(),
+		identityset.PrivateKey(33).PublicKey(),
+	}
+	nonces := make([]uint64, len(accounts))
+	ge := genesis.Default
+	for _, acc := range accounts {
+		ge.InitBalanceMap[acc] = big.NewInt(int64(b.N * 100)).String()
+	}
+	registry := protocol.NewRegistry()
+	acc := account.NewProtocol(rewarding.DepositGas)
+	if err := acc.Register(registry); err != nil {
+		b.Fatal(err)
+	}
+	ctx := protocol.WithBlockchainCtx(context.Background(), protocol.BlockchainCtx{
+		Genesis:  ge,
+		Registry: registry,
+	})
+	if err := sf.Start(ctx); err != nil {
+		b.Fatal(err)
+	}
+	defer func() {
+		if err := sf.Stop(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}()
 
 	gasLimit := testutil.TestGasLimit * 100000
 
@@ -1224,6 +2427,434 @@ func benchRunAction(sf Factory, b *testing.B) {
 	}
 }
 
+func TestActionTimeout(t *testing.T) {
+	testActionTimeout := func(t *testing.T, newWorkingSet func() WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		selps := make([]action.SealedEnvelope, 2)
+		for i := range selps {
+			selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), uint64(i+1), big.NewInt(1), nil, 100000, big.NewInt(0))
+			require.NoError(err)
+			selps[i] = selp
+		}
+		registry := protocol.NewRegistry()
+		// each action sets the balance to its nonce, and the second one sleeps after that, regardless of the context
+		require.NoError(registry.Register("test", &handlerProtocol{
+			handle: func(ctx context.Context, sm protocol.StateManager) error {
+				actionCtx := protocol.MustGetActionCtx(ctx)
+				acc := state.EmptyAccount()
+				acc.Balance = new(big.Int).SetUint64(actionCtx.Nonce)
+				if _, err := sm.PutState(&acc, protocol.LegacyKeyOption(key)); err != nil {
+					return err
+				}
+				if actionCtx.Nonce == 2 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				return nil
+			},
+		}))
+		ctx := protocol.WithBlockchainCtx(
+			protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{BlockHeight: 1}),
+			protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+		)
+		ctx = withActionTimeout(ctx, 20*time.Millisecond)
+		balance := func(ws WorkingSet) uint64 {
+			var acc state.Account
+			_, err := ws.State(&acc, protocol.LegacyKeyOption(key))
+			require.NoError(err)
+			return acc.Balance.Uint64()
+		}
+
+		ws := newWorkingSet()
+		receipt, err := ws.RunAction(ctx, selps[0])
+		require.NoError(err)
+		require.Nil(receipt)
+		require.Equal(uint64(1), balance(ws))
+		// the action timing out fails, and its writes are reverted
+		receipt, err = ws.RunAction(ctx, selps[1])
+		require.NoError(err)
+		require.Equal(action.ReceiptStatusExecutionTimeout, receipt.Status)
+		require.Equal(selps[1].Hash(), receipt.ActionHash)
+		require.Equal(uint64(1), receipt.BlockHeight)
+		require.Zero(receipt.GasConsumed)
+		require.Equal(uint64(1), balance(ws))
+		receipt, err = ws.SimulateAction(ctx, selps[1])
+		require.NoError(err)
+		require.Equal(action.ReceiptStatusExecutionTimeout, receipt.Status)
+		require.Equal(uint64(1), balance(ws))
+		// the actions of a block are never timed out
+		receipts, err := ws.RunActions(ctx, selps[1:])
+		require.NoError(err)
+		require.Empty(receipts)
+		require.Equal(uint64(2), balance(ws))
+
+		// the producer leaves the action timing out out of the block, and notifies the subscribers of it
+		subscriber := &timeoutSubscriber{}
+		_, executed, _, err := pickAndRunActions(
+			ctx,
+			newWorkingSet(),
+			map[string][]action.SealedEnvelope{identityset.Address(28).String(): selps},
+			nil,
+			0,
+			[]ActionTimeoutSubscriber{subscriber},
+		)
+		require.NoError(err)
+		require.Equal([]action.SealedEnvelope{selps[0]}, executed)
+		require.Equal([]action.SealedEnvelope{selps[1]}, subscriber.timedOut)
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		testActionTimeout(t, func() WorkingSet {
+			ws, err := sf.NewWorkingSet()
+			require.NoError(t, err)
+			return ws
+		})
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		kv := db.NewMemKVStore()
+		testActionTimeout(t, func() WorkingSet {
+			ws, err := newStateTX(1, kv)
+			require.NoError(t, err)
+			return ws
+		})
+	})
+}
+
+func TestActionStatusError(t *testing.T) {
+	testActionStatusError := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		key := hash.Hash160b([]byte("test"))
+		selps := make([]action.SealedEnvelope, 4)
+		for i := range selps {
+			selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), uint64(i+1), big.NewInt(1), nil, 100000, big.NewInt(0))
+			require.NoError(err)
+			selps[i] = selp
+		}
+		registry := protocol.NewRegistry()
+		// each action sets the balance to its nonce, and then the second one fails with a status, and the third one
+		// fails with an error without a status
+		require.NoError(registry.Register("test", &handlerProtocol{
+			handle: func(ctx context.Context, sm protocol.StateManager) error {
+				actionCtx := protocol.MustGetActionCtx(ctx)
+				acc := state.EmptyAccount()
+				acc.Balance = new(big.Int).SetUint64(actionCtx.Nonce)
+				if _, err := sm.PutState(&acc, protocol.LegacyKeyOption(key)); err != nil {
+					return err
+				}
+				switch actionCtx.Nonce {
+				case 2:
+					return errors.Wrap(protocol.WrapWithStatus(errors.New("rejected"), 202), "failed to handle")
+				case 3:
+					return errors.New("failed to handle")
+				}
+				return nil
+			},
+		}))
+		ctx := protocol.WithBlockchainCtx(
+			protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{BlockHeight: 1}),
+			protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+		)
+		balance := func() uint64 {
+			var acc state.Account
+			_, err := ws.State(&acc, protocol.LegacyKeyOption(key))
+			require.NoError(err)
+			return acc.Balance.Uint64()
+		}
+
+		receipt, err := ws.RunAction(ctx, selps[0])
+		require.NoError(err)
+		require.Nil(receipt)
+		require.Equal(uint64(1), balance())
+		// the action failing with a status gets the failure receipt, and its writes are reverted
+		receipt, err = ws.RunAction(ctx, selps[1])
+		require.NoError(err)
+		require.Equal(uint64(202), receipt.Status)
+		require.Equal(selps[1].Hash(), receipt.ActionHash)
+		require.Equal(uint64(1), receipt.BlockHeight)
+		intrinsicGas, err := selps[1].IntrinsicGas()
+		require.NoError(err)
+		require.Equal(intrinsicGas, receipt.GasConsumed)
+		require.Equal(uint64(1), balance())
+		// the error without a status still fails the block
+		_, err = ws.RunAction(ctx, selps[2])
+		require.Error(err)
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(t, err)
+		testActionStatusError(t, ws)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		ws, err := newStateTX(1, db.NewMemKVStore())
+		require.NoError(t, err)
+		testActionStatusError(t, ws)
+	})
+}
+
+func TestActionBufferLimit(t *testing.T) {
+	testActionBufferLimit := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		selps := make([]action.SealedEnvelope, 4)
+		for i := range selps {
+			selp, err := testutil.SignedTransfer(identityset.Address(29).String(), identityset.PrivateKey(28), uint64(i+1), big.NewInt(1), nil, 100000, big.NewInt(0))
+			require.NoError(err)
+			selps[i] = selp
+		}
+		registry := protocol.NewRegistry()
+		// the second and the fourth actions put 200 states which exceed the limit, and the others put one state. The
+		// fourth one puts the states as the objects cached, which aren't serialized.
+		require.NoError(registry.Register("test", &handlerProtocol{
+			handle: func(ctx context.Context, sm protocol.StateManager) error {
+				actionCtx := protocol.MustGetActionCtx(ctx)
+				n := 1
+				if actionCtx.Nonce%2 == 0 {
+					n = 200
+				}
+				for i := 0; i < n; i++ {
+					acc := state.EmptyAccount()
+					acc.Balance = new(big.Int).SetUint64(actionCtx.Nonce)
+					key := hash.Hash160b([]byte(fmt.Sprintf("test-%d-%d", actionCtx.Nonce, i)))
+					opts := []protocol.StateOption{protocol.LegacyKeyOption(key)}
+					if actionCtx.Nonce == 4 {
+						opts = append(opts, protocol.ObjectOption())
+					}
+					if _, err := sm.PutState(&acc, opts...); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}))
+		ctx := protocol.WithBlockchainCtx(
+			protocol.WithBlockCtx(context.Background(), protocol.BlockCtx{BlockHeight: 1}),
+			protocol.BlockchainCtx{Genesis: config.Default.Genesis, Registry: registry},
+		)
+		exists := func(nonce, i int) bool {
+			var acc state.Account
+			_, err := ws.State(&acc, protocol.LegacyKeyOption(hash.Hash160b([]byte(fmt.Sprintf("test-%d-%d", nonce, i)))))
+			if errors.Cause(err) == state.ErrStateNotExist {
+				return false
+			}
+			require.NoError(err)
+			return true
+		}
+
+		receipt, err := ws.RunAction(ctx, selps[0])
+		require.NoError(err)
+		require.Nil(receipt)
+		require.True(exists(1, 0))
+		// the action exceeding the limit gets the failure receipt, and its writes are reverted
+		receipt, err = ws.RunAction(ctx, selps[1])
+		require.NoError(err)
+		require.Equal(action.ReceiptStatusBufferFull, receipt.Status)
+		require.Equal(selps[1].Hash(), receipt.ActionHash)
+		require.False(exists(2, 0))
+		require.False(exists(2, 199))
+		// the size reverted leaves room for the next action
+		receipt, err = ws.RunAction(ctx, selps[2])
+		require.NoError(err)
+		require.Nil(receipt)
+		require.True(exists(1, 0))
+		require.True(exists(3, 0))
+		receipt, err = ws.RunAction(ctx, selps[3])
+		require.NoError(err)
+		require.Equal(action.ReceiptStatusBufferFull, receipt.Status)
+		require.False(exists(4, 0))
+		require.False(exists(4, 199))
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := newWorkingSet(1, sf.(*factory).dao, sf.(*factory).rootHash(), nil, 0, db.BufferLimitOption(4096))
+		require.NoError(t, err)
+		testActionBufferLimit(t, ws)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		ws, err := newStateTX(1, db.NewMemKVStore(), db.BufferLimitOption(4096))
+		require.NoError(t, err)
+		testActionBufferLimit(t, ws)
+	})
+}
+
+func TestWorkingSetInvalidRoot(t *testing.T) {
+	require := require.New(t)
+	sf, err := NewFactory(config.Default, InMemTrieOption())
+	require.NoError(err)
+	dao := sf.(*factory).dao
+	_, err = newWorkingSet(1, dao, []byte("garbage"), nil, 0)
+	require.Equal(trie.ErrInvalidRoot, errors.Cause(err))
+	require.Contains(err.Error(), AccountTrieNamespace)
+
+	ws, err := newWorkingSet(1, dao, sf.(*factory).rootHash(), nil, 0)
+	require.NoError(err)
+	s := ws.Snapshot()
+	// the ordinary failure of revert is told from the root missing from the store
+	err = ws.Revert(s + 1)
+	require.Error(err)
+	require.NotEqual(trie.ErrInvalidRoot, errors.Cause(err))
+	ws.(*workingSet).trieRoots[s] = []byte("pruned")
+	err = ws.Revert(s)
+	require.Equal(trie.ErrInvalidRoot, errors.Cause(err))
+	require.Contains(err.Error(), AccountTrieNamespace)
+}
+
+func TestTrieRootIndex(t *testing.T) {
+	require := require.New(t)
+	cfg := config.Default
+	cfg.Genesis.TrieRootIndexHeight = 3
+	sf, err := NewFactory(cfg, InMemTrieOption())
+	require.NoError(err)
+	f := sf.(*factory)
+	newWS := func(height, rootIndexHeight uint64) WorkingSet {
+		ws, err := newWorkingSet(height, f.dao, f.rootHash(), nil, rootIndexHeight, f.flusherOptions(context.Background(), height)...)
+		require.NoError(err)
+		_, err = ws.PutState(&state.Account{Balance: big.NewInt(int64(height))}, protocol.LegacyKeyOption(hash.Hash160b([]byte{byte(height)})))
+		require.NoError(err)
+		require.NoError(ws.Finalize())
+		return ws
+	}
+
+	// the heights before the activation are written under the legacy keys only
+	roots := map[uint64][]byte{}
+	for height := uint64(1); height <= 4; height++ {
+		if height == 3 {
+			// the root hash in the index isn't serialized, so the digest is the same as before the activation
+			expected, err := newWS(height, height+1).Digest()
+			require.NoError(err)
+			digest, err := newWS(height, height).Digest()
+			require.NoError(err)
+			require.Equal(expected, digest)
+		}
+		ws := newWS(height, cfg.Genesis.TrieRootIndexHeight)
+		require.NoError(f.commit(ws))
+		roots[height] = f.rootHash()
+		legacy, err := f.dao.Get(AccountTrieNamespace, []byte(fmt.Sprintf("%s-%d", AccountTrieRootKey, height)))
+		require.NoError(err)
+		require.Equal(roots[height], legacy)
+		_, err = f.dao.Get(TrieRootNamespace, byteutil.Uint64ToBytesBigEndian(height))
+		if height < 3 {
+			require.Equal(db.ErrNotExist, errors.Cause(err))
+		} else {
+			require.NoError(err)
+		}
+	}
+	for height, root := range roots {
+		rootHash, err := trieRootAt(f.dao, height)
+		require.NoError(err)
+		require.Equal(root, rootHash)
+	}
+	_, err = trieRootAt(f.dao, 5)
+	require.Equal(ErrNoArchiveData, errors.Cause(err))
+
+	// the backfill converts the legacy keys before the activation, and skips the keys which aren't of a height
+	require.NoError(f.dao.Put(AccountTrieNamespace, []byte(AccountTrieRootKey+"-x"), []byte("garbage")))
+	n, err := BackfillTrieRootIndex(f.dao)
+	require.NoError(err)
+	require.Equal(2, n)
+	n, err = BackfillTrieRootIndex(f.dao)
+	require.NoError(err)
+	require.Zero(n)
+	keys, values, err := f.dao.(db.KVStoreWithPrefix).Prefix(TrieRootNamespace, nil)
+	require.NoError(err)
+	require.Equal(4, len(keys))
+	for i, key := range keys {
+		// the index is ordered by height
+		height := byteutil.BytesToUint64BigEndian(key)
+		require.Equal(uint64(i+1), height)
+		require.Equal(roots[height], values[i])
+	}
+	for height := uint64(1); height <= 4; height++ {
+		require.NoError(f.dao.Delete(AccountTrieNamespace, []byte(fmt.Sprintf("%s-%d", AccountTrieRootKey, height))))
+		rootHash, err := trieRootAt(f.dao, height)
+		require.NoError(err)
+		require.Equal(roots[height], rootHash)
+	}
+}
+
+// unserializableState is a state which fails to be serialized
+type unserializableState struct{}
+
+func (unserializableState) Serialize() ([]byte, error) {
+	return nil, errors.New("failed to serialize")
+}
+
+func TestPutStatesAndDelStates(t *testing.T) {
+	testPutStates := func(t *testing.T, ws WorkingSet) {
+		require := require.New(t)
+		keys := make([]hash.Hash160, 5)
+		writes := make([]protocol.StateWrite, 5)
+		for i := range keys {
+			keys[i] = hash.Hash160b([]byte{byte(i)})
+			acc := state.EmptyAccount()
+			acc.Nonce = uint64(i)
+			writes[i] = protocol.StateWrite{State: acc, Options: []protocol.StateOption{protocol.LegacyKeyOption(keys[i])}}
+		}
+		// the state written before isn't overwritten either
+		_, err := ws.PutState(state.EmptyAccount(), protocol.LegacyKeyOption(keys[0]))
+		require.NoError(err)
+
+		// the failure of the third write reverts the first two
+		failing := append([]protocol.StateWrite{}, writes...)
+		failing[2] = protocol.StateWrite{State: unserializableState{}, Options: writes[2].Options}
+		_, err = ws.PutStates(failing)
+		require.Error(err)
+		var acc state.Account
+		_, err = ws.State(&acc, protocol.LegacyKeyOption(keys[0]))
+		require.NoError(err)
+		require.Equal(uint64(0), acc.Nonce)
+		for _, key := range keys[1:] {
+			_, err = ws.State(&acc, protocol.LegacyKeyOption(key))
+			require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		}
+
+		_, err = ws.PutStates(writes)
+		require.NoError(err)
+		for i, key := range keys {
+			_, err = ws.State(&acc, protocol.LegacyKeyOption(key))
+			require.NoError(err)
+			require.Equal(uint64(i), acc.Nonce)
+		}
+
+		// the deletion of the missing state reverts the deletions before it
+		missing := hash.Hash160b([]byte("missing"))
+		_, err = ws.DelStates([]protocol.StateKey{
+			{protocol.LegacyKeyOption(keys[0])},
+			{protocol.LegacyKeyOption(keys[1])},
+			{protocol.LegacyKeyOption(missing)},
+		})
+		require.Equal(state.ErrStateNotExist, errors.Cause(err))
+		for _, key := range keys {
+			_, err = ws.State(&acc, protocol.LegacyKeyOption(key))
+			require.NoError(err)
+		}
+		_, err = ws.DelStates([]protocol.StateKey{{protocol.LegacyKeyOption(keys[0])}, {protocol.LegacyKeyOption(keys[1])}})
+		require.NoError(err)
+		for i, key := range keys {
+			_, err = ws.State(&acc, protocol.LegacyKeyOption(key))
+			if i < 2 {
+				require.Equal(state.ErrStateNotExist, errors.Cause(err))
+			} else {
+				require.NoError(err)
+			}
+		}
+	}
+	t.Run("workingSet", func(t *testing.T) {
+		sf, err := NewFactory(config.Default, InMemTrieOption())
+		require.NoError(t, err)
+		ws, err := sf.NewWorkingSet()
+		require.NoError(t, err)
+		testPutStates(t, ws)
+	})
+	t.Run("stateTx", func(t *testing.T) {
+		ws, err := newStateTX(0, db.NewMemKVStore())
+		require.NoError(t, err)
+		testPutStates(t, ws)
+	})
+}
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
@@ -0,0 +1,290 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package factory
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/state"
+)
+
+type (
+	// cachedObject is a live object of a state, which is dirty if it has been put but not serialized yet. The size is
+	// the one of the state last serialized, and the epoch is the number of the snapshots taken when it was cached.
+	cachedObject struct {
+		ns    string
+		key   []byte
+		obj   interface{}
+		dirty bool
+		size  int
+		epoch int
+	}
+
+	// journalEntry is a cached object replaced after a snapshot, which is nil if there was none or it was clean
+	journalEntry struct {
+		key  string
+		prev *cachedObject
+	}
+
+	// objectCache keeps the live objects of the states read or put with ObjectOption, so that the states are only
+	// serialized when the working set is finalized, instead of in each call. The snapshots are copy-on-write: an object
+	// cached before the latest snapshot is journaled the first time it's changed or read after it, so that it's put
+	// back by revert.
+	objectCache struct {
+		objects map[string]*cachedObject
+		journal []journalEntry
+		// the lengths of the journal at the snapshots of the underlying store, which are numbered alike
+		shots map[int]int
+		epoch int
+		// dirtySize is the estimated size in bytes of the keys and the states of the dirty objects
+		dirtySize int
+	}
+
+	// putFunc serializes the state and puts it into the underlying store of the working set
+	putFunc func(ns string, key []byte, s interface{}) error
+)
+
+func newObjectCache() *objectCache {
+	return &objectCache{
+		objects: make(map[string]*cachedObject),
+		shots:   make(map[int]int),
+	}
+}
+
+// namespacedKey returns the key in the namespace as a map key
+func namespacedKey(ns string, key []byte) string {
+	return string([]byte{byte(len(ns))}) + ns + string(key)
+}
+
+// get copies the cached object of the key into s, and returns false if there is no cached object of the same type
+func (oc *objectCache) get(ns string, key []byte, s interface{}) (bool, error) {
+	k := namespacedKey(ns, key)
+	co, ok := oc.objects[k]
+	if !ok {
+		return false, nil
+	}
+	dst, src := reflect.ValueOf(s), reflect.ValueOf(co.obj)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return false, nil
+	}
+	// the object may have been put either by value or by pointer
+	if src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return false, nil
+		}
+		src = src.Elem()
+	}
+	if src.Type() != dst.Elem().Type() {
+		return false, nil
+	}
+	// the copy in s shares the fields of pointers with the object, which may be mutated in place before it's put back,
+	// so a dirty object kept by the snapshot is journaled as a copy
+	if oc.pinned(co) {
+		prev := co
+		if co.dirty {
+			obj, err := copyObject(co.obj)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to copy the cached object of %x", co.key)
+			}
+			prev = &cachedObject{
+				ns:    co.ns,
+				key:   co.key,
+				obj:   obj,
+				dirty: true,
+				size:  co.size,
+				epoch: co.epoch,
+			}
+		}
+		oc.record(k, prev)
+		co.epoch = oc.epoch
+	}
+	dst.Elem().Set(src)
+	return true, nil
+}
+
+// cached returns true if the key has a cached object
+func (oc *objectCache) cached(ns string, key []byte) bool {
+	_, ok := oc.objects[namespacedKey(ns, key)]
+	return ok
+}
+
+// put caches the object of the key, which is dirty if it has to be serialized later. The size is the one of the state
+// serialized, which is estimated by the size of the object replaced, or by serializing the object if there is none, if
+// it's 0.
+func (oc *objectCache) put(ns string, key []byte, s interface{}, dirty bool, size int) {
+	k := namespacedKey(ns, key)
+	co := oc.objects[k]
+	if co != nil {
+		if co.dirty {
+			dirty = true
+			oc.dirtySize -= len(co.key) + co.size
+		}
+		if size == 0 {
+			size = co.size
+		}
+	}
+	if size == 0 && dirty {
+		// a failure to serialize the object is returned once it's flushed
+		if ss, err := state.Serialize(s); err == nil {
+			size = len(ss)
+		}
+	}
+	if oc.pinned(co) {
+		oc.record(k, co)
+	}
+	oc.objects[k] = &cachedObject{
+		ns:    ns,
+		key:   key,
+		obj:   s,
+		dirty: dirty,
+		size:  size,
+		epoch: oc.epoch,
+	}
+	if dirty {
+		oc.dirtySize += len(key) + size
+	}
+}
+
+// evict removes the cached object of the key, after serializing it if it's dirty and put isn't nil
+func (oc *objectCache) evict(ns string, key []byte, put putFunc) error {
+	k := namespacedKey(ns, key)
+	co, ok := oc.objects[k]
+	if !ok {
+		return nil
+	}
+	if oc.pinned(co) {
+		oc.record(k, co)
+	}
+	delete(oc.objects, k)
+	if co.dirty {
+		oc.dirtySize -= len(co.key) + co.size
+	}
+	if co.dirty && put != nil {
+		return put(co.ns, co.key, co.obj)
+	}
+	return nil
+}
+
+// flush serializes the dirty objects in the order of the keys, which are cached as clean afterwards
+func (oc *objectCache) flush(put putFunc) error {
+	keys := make([]string, 0, len(oc.objects))
+	for k, co := range oc.objects {
+		if co.dirty {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		co := oc.objects[k]
+		if err := put(co.ns, co.key, co.obj); err != nil {
+			return errors.Wrapf(err, "failed to put the cached object of %x", co.key)
+		}
+		// the object is replaced by a clean one, rather than marked, since a snapshot may keep it
+		if oc.pinned(co) {
+			oc.record(k, co)
+		}
+		oc.objects[k] = &cachedObject{
+			ns:    co.ns,
+			key:   co.key,
+			obj:   co.obj,
+			size:  co.size,
+			epoch: oc.epoch,
+		}
+		oc.dirtySize -= len(co.key) + co.size
+	}
+	return nil
+}
+
+// size returns the estimated size in bytes of the keys and the states of the dirty objects, which are to be serialized
+func (oc *objectCache) size() int {
+	return oc.dirtySize
+}
+
+// snapshot marks the cached objects as kept by the snapshot of the underlying store, without serializing them
+func (oc *objectCache) snapshot(s int) {
+	oc.shots[s] = len(oc.journal)
+	oc.epoch++
+}
+
+// revert puts back the cached objects at the snapshot of the underlying store, which is still valid afterwards unlike
+// the later ones
+func (oc *objectCache) revert(s int) error {
+	n, ok := oc.shots[s]
+	if !ok {
+		return errors.Errorf("failed to get the cached objects at snapshot %d", s)
+	}
+	for i := len(oc.journal) - 1; i >= n; i-- {
+		if e := oc.journal[i]; e.prev != nil {
+			oc.objects[e.key] = e.prev
+		} else {
+			delete(oc.objects, e.key)
+		}
+	}
+	oc.journal = oc.journal[:n]
+	oc.dirtySize = 0
+	for _, co := range oc.objects {
+		if co.dirty {
+			oc.dirtySize += len(co.key) + co.size
+		}
+	}
+	for shot := range oc.shots {
+		if shot > s {
+			delete(oc.shots, shot)
+		}
+	}
+	// the objects cached afterwards are journaled again once changed
+	oc.epoch++
+	return nil
+}
+
+// pinned returns true if the cached object of the key, which is nil if there is none, is kept by the latest snapshot,
+// so that it has to be journaled the first time it's changed or read afterwards
+func (oc *objectCache) pinned(co *cachedObject) bool {
+	return len(oc.shots) > 0 && (co == nil || co.epoch != oc.epoch)
+}
+
+// record journals the cached object of the key, or its absence. A clean object is journaled as absent, since it's
+// read again from the underlying store once reverted.
+func (oc *objectCache) record(k string, co *cachedObject) {
+	if co != nil && !co.dirty {
+		co = nil
+	}
+	oc.journal = append(oc.journal, journalEntry{key: k, prev: co})
+}
+
+// copyObject returns a copy of the object sharing nothing with it, made by serializing it
+func copyObject(obj interface{}) (interface{}, error) {
+	ss, err := state.Serialize(obj)
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		c := reflect.New(t.Elem())
+		if err := state.Deserialize(c.Interface(), ss); err != nil {
+			return nil, err
+		}
+		return c.Interface(), nil
+	}
+	c := reflect.New(t)
+	if err := state.Deserialize(c.Interface(), ss); err != nil {
+		return nil, err
+	}
+	return c.Elem().Interface(), nil
+}
+
+// clear drops all the cached objects along with the snapshots
+func (oc *objectCache) clear() {
+	oc.objects = make(map[string]*cachedObject)
+	oc.journal = nil
+	oc.shots = make(map[int]int)
+	oc.epoch = 0
+	oc.dirtySize = 0
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package factory
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/db/trie"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+type (
+	// trieReader reads the states of a trie pinned to a root, which it never writes to
+	trieReader struct {
+		height uint64
+		tr     trie.Trie
+	}
+
+	// readView passes the reads to the reader until it's released, after which the reads fail with ErrViewReleased
+	readView struct {
+		mutex     sync.RWMutex
+		reader    protocol.StateReader
+		onRelease func()
+	}
+
+	// committedReader reads the states committed at the latest height of the factory, through a view opened for each
+	// read
+	committedReader struct {
+		sf *factory
+	}
+)
+
+// newReadView creates a view of the reader, along with the function releasing it. The release function calls
+// onRelease once no read is in progress, and it can be called more than once.
+func newReadView(reader protocol.StateReader, onRelease func()) (protocol.StateReader, func()) {
+	v := &readView{reader: reader, onRelease: onRelease}
+	return v, v.release
+}
+
+func (v *readView) release() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.reader == nil {
+		return
+	}
+	v.reader = nil
+	if v.onRelease != nil {
+		v.onRelease()
+	}
+}
+
+func (v *readView) Height() (uint64, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	if v.reader == nil {
+		return 0, ErrViewReleased
+	}
+	return v.reader.Height()
+}
+
+func (v *readView) TipHeight() (uint64, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	if v.reader == nil {
+		return 0, ErrViewReleased
+	}
+	return v.reader.TipHeight()
+}
+
+func (v *readView) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	if v.reader == nil {
+		return 0, ErrViewReleased
+	}
+	return v.reader.State(s, opts...)
+}
+
+func (v *readView) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	if v.reader == nil {
+		return 0, nil, ErrViewReleased
+	}
+	return v.reader.States(opts...)
+}
+
+func (r *trieReader) Height() (uint64, error) {
+	return r.height, nil
+}
+
+func (r *trieReader) TipHeight() (uint64, error) {
+	return r.height, nil
+}
+
+func (r *trieReader) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.AtHeight {
+		return 0, errors.Wrap(ErrNotSupported, "the view reads the states of its own height only")
+	}
+	data, err := r.tr.Get(cfg.Key)
+	if errors.Cause(err) == trie.ErrNotExist {
+		return r.height, errors.Wrapf(state.ErrStateNotExist, "state of %x doesn't exist", cfg.Key)
+	}
+	if err != nil {
+		return r.height, errors.Wrapf(err, "error when getting the state of %x", cfg.Key)
+	}
+	if err := state.Deserialize(s, data); err != nil {
+		return r.height, errors.Wrapf(err, "error when deserializing state data into %T", s)
+	}
+	return r.height, nil
+}
+
+func (r *trieReader) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return 0, nil, errors.Wrap(ErrNotSupported, "the view reads the states of its own height only")
+	}
+	var iter state.Iterator
+	if cfg.Keys != nil {
+		iter, err = readStates(cfg.Context, cfg.Keys, r.tr.Get)
+	} else {
+		iter, err = trieStates(cfg.Context, r.tr, cfg.Prefix)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return r.height, iter, nil
+}
+
+func (r *committedReader) Height() (uint64, error) {
+	return r.sf.Height()
+}
+
+func (r *committedReader) TipHeight() (uint64, error) {
+	return r.sf.Height()
+}
+
+func (r *committedReader) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.AtHeight {
+		return r.sf.State(s, opts...)
+	}
+	view, release, err := r.sf.ReadView()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return view.State(s, opts...)
+}
+
+func (r *committedReader) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return r.sf.States(opts...)
+	}
+	view, release, err := r.sf.ReadView()
+	if err != nil {
+		return 0, nil, err
+	}
+	// the iterator holds the states read, so the view is released once they're read
+	defer release()
+	return view.States(opts...)
+}
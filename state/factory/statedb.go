@@ -40,6 +40,8 @@ type stateDB struct {
 	dao                db.KVStore // the underlying DB for account/contract storage
 	timerFactory       *prometheustimer.TimerFactory
 	workingsets        *lru.Cache // lru cache for workingsets
+	audit              StateAudit
+	timeoutSubscribers []ActionTimeoutSubscriber
 }
 
 // StateDBOption sets stateDB construction parameter
@@ -78,6 +80,18 @@ func InMemStateDBOption() StateDBOption {
 	}
 }
 
+// StateAuditStateDBOption checks the state of each block with the audit before committing it, which is meant for
+// debugging
+func StateAuditStateDBOption(audit StateAudit) StateDBOption {
+	return func(sdb *stateDB, cfg config.Config) error {
+		if audit == nil {
+			return errors.New("audit cannot be nil")
+		}
+		sdb.audit = audit
+		return nil
+	}
+}
+
 // NewStateDB creates a new state db
 func NewStateDB(cfg config.Config, opts ...StateDBOption) (Factory, error) {
 	sdb := stateDB{
@@ -90,6 +104,9 @@ func NewStateDB(cfg config.Config, opts ...StateDBOption) (Factory, error) {
 			return nil, err
 		}
 	}
+	if cfg.Chain.TrieDBWALPath != "" {
+		sdb.dao = db.NewKVStoreWithWAL(sdb.dao, cfg.Chain.TrieDBWALPath)
+	}
 	timerFactory, err := prometheustimer.New(
 		"iotex_statefactory_perf",
 		"Performance of state factory module",
@@ -154,6 +171,11 @@ func (sdb *stateDB) Height() (uint64, error) {
 	return byteutil.BytesToUint64(height), nil
 }
 
+// TipHeight returns the height of the last committed block, which is the height of the states of the state DB
+func (sdb *stateDB) TipHeight() (uint64, error) {
+	return sdb.Height()
+}
+
 func (sdb *stateDB) NewWorkingSet() (WorkingSet, error) {
 	sdb.mutex.RLock()
 	defer sdb.mutex.RUnlock()
@@ -187,13 +209,24 @@ func (sdb *stateDB) NewBlockBuilder(
 	ws, err := newStateTX(
 		sdb.currentChainHeight+1,
 		sdb.dao,
-		sdb.flusherOptions(ctx, sdb.currentChainHeight+1)...,
+		append(
+			sdb.flusherOptions(ctx, sdb.currentChainHeight+1),
+			db.BufferLimitOption(int(sdb.cfg.Chain.WorkingSetBufferLimit)),
+		)...,
 	)
+	timeoutSubscribers := sdb.timeoutSubscribers
 	sdb.mutex.Unlock()
 	if err != nil {
 		return nil, err
 	}
-	blkBuilder, err := createBuilderWithWorkingset(ctx, ws, actionMap, postSystemActions, sdb.cfg.Chain.AllowedBlockGasResidue)
+	blkBuilder, err := createBuilderWithWorkingset(
+		withActionTimeout(ctx, sdb.cfg.Chain.ActionTimeout),
+		ws,
+		actionMap,
+		postSystemActions,
+		sdb.cfg.Chain.AllowedBlockGasResidue,
+		timeoutSubscribers,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +237,13 @@ func (sdb *stateDB) NewBlockBuilder(
 	return blkBuilder, nil
 }
 
+// AddActionTimeoutSubscriber adds the subscriber of the actions timing out in the blocks built
+func (sdb *stateDB) AddActionTimeoutSubscriber(s ActionTimeoutSubscriber) {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+	sdb.timeoutSubscribers = append(sdb.timeoutSubscribers, s)
+}
+
 // SimulateExecution simulates a running of smart contract operation, this is done off the network since it does not
 // cause any state change
 func (sdb *stateDB) SimulateExecution(
@@ -226,6 +266,21 @@ func (sdb *stateDB) SimulateExecution(
 	return simulateExecution(ctx, ws, caller, ex, getBlockHash)
 }
 
+// ReadView isn't supported by the state DB, which keeps the states of the current height only
+func (sdb *stateDB) ReadView() (protocol.StateReader, func(), error) {
+	return nil, nil, errors.Wrap(ErrNotSupported, "state DB has no read view")
+}
+
+// NewReader returns a reader of the committed states, each read of which holds the lock of the state DB. The state DB
+// keeps the states of the current height only, so the reader can't be pinned at a height.
+func (sdb *stateDB) NewReader(opts ...ReaderOption) (protocol.StateReader, error) {
+	if createReaderConfig(opts...).pinned {
+		return nil, errors.Wrap(ErrNotSupported, "state DB has no archive to pin the reader at")
+	}
+	reader, _ := newReadView(sdb, nil)
+	return reader, nil
+}
+
 // Commit persists all changes in RunActions() into the DB
 func (sdb *stateDB) Commit(ctx context.Context, blk *block.Block) error {
 	sdb.mutex.Lock()
@@ -267,7 +322,12 @@ func (sdb *stateDB) Commit(ctx context.Context, blk *block.Block) error {
 			ws.Version(),
 		)
 	}
-
+	if sdb.audit != nil {
+		// the state isn't committed if it violates an invariant
+		if err := sdb.audit(ws, bcCtx.Genesis); err != nil {
+			return errors.Wrapf(err, "state audit failed at height %d", ws.Version())
+		}
+	}
 	return sdb.commit(ws)
 }
 
@@ -291,6 +351,39 @@ func (sdb *stateDB) State(state interface{}, opts ...protocol.StateOption) (uint
 	return sdb.currentChainHeight, sdb.state(ns, cfg.Key, state)
 }
 
+// States returns the confirmed states of the keys in the state factory
+func (sdb *stateDB) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return 0, nil, ErrNotSupported
+	}
+	ns := AccountKVNamespace
+	if cfg.Namespace != "" {
+		ns = cfg.Namespace
+	}
+	var iter state.Iterator
+	switch {
+	case cfg.Prefix != nil:
+		iter, err = prefixStates(cfg.Context, sdb.dao, ns, cfg.Prefix)
+	case cfg.Keys != nil:
+		iter, err = readStates(cfg.Context, cfg.Keys, func(key []byte) ([]byte, error) {
+			return sdb.dao.Get(ns, key)
+		})
+	default:
+		iter, err = prefixStates(cfg.Context, sdb.dao, ns, nil)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return sdb.currentChainHeight, iter, nil
+}
+
 // DeleteWorkingSet returns true if it remove ws from workingsets cache successfully
 func (sdb *stateDB) DeleteWorkingSet(blk *block.Block) error {
 	sdb.mutex.Lock()
@@ -306,7 +399,7 @@ func (sdb *stateDB) DeleteWorkingSet(blk *block.Block) error {
 //======================================
 
 func (sdb *stateDB) flusherOptions(ctx context.Context, height uint64) []db.KVStoreFlusherOption {
-	opts := []db.KVStoreFlusherOption{}
+	opts := []db.KVStoreFlusherOption{db.SlowFlushOption(sdb.cfg.Chain.SlowFlushThreshold)}
 	bcCtx, ok := protocol.GetBlockchainCtx(ctx)
 	if !ok {
 		// TODO: Change to MustGetBlockchainCtx after deleting NewWorkingSet API
@@ -8,15 +8,18 @@ package factory
 
 import (
 	"context"
+	"time"
 
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"github.com/iotexproject/iotex-address/address"
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
 )
@@ -24,8 +27,11 @@ import (
 // stateTX implements stateTX interface, tracks pending changes to account/contract in local cache
 type stateTX struct {
 	flusher     db.KVStoreFlusher // the underlying DB for account/contract storage
+	objects     *objectCache
 	finalized   bool
 	blockHeight uint64
+	actionHash  hash.Hash256            // hash of the action being run
+	deletedBy   map[string]hash.Hash256 // hashes of the actions deleting the states
 }
 
 // newStateTX creates a new state tx
@@ -41,7 +47,9 @@ func newStateTX(
 
 	return &stateTX{
 		flusher:     flusher,
+		objects:     newObjectCache(),
 		blockHeight: blockHeight,
+		deletedBy:   make(map[string]hash.Hash256),
 		finalized:   false,
 	}, nil
 }
@@ -71,6 +79,11 @@ func (stx *stateTX) Height() (uint64, error) {
 	return stx.blockHeight, nil
 }
 
+// TipHeight returns the height of the last committed block, which the block being worked on is built atop
+func (stx *stateTX) TipHeight() (uint64, error) {
+	return tipHeight(stx.blockHeight), nil
+}
+
 // RunActions runs actions in the block and track pending changes in working set
 func (stx *stateTX) RunActions(
 	ctx context.Context,
@@ -79,7 +92,8 @@ func (stx *stateTX) RunActions(
 	// Handle actions
 	receipts := make([]*action.Receipt, 0)
 	for _, elp := range elps {
-		receipt, err := stx.runAction(ctx, elp)
+		// the actions of a block are never timed out, so that the validation is deterministic
+		receipt, err := stx.runAction(ctx, elp, false, 0)
 		if err != nil {
 			return nil, errors.Wrap(err, "error when run action")
 		}
@@ -92,7 +106,25 @@ func (stx *stateTX) RunActions(
 }
 
 func (stx *stateTX) RunAction(ctx context.Context, elp action.SealedEnvelope) (*action.Receipt, error) {
-	return stx.runAction(ctx, elp)
+	return stx.runAction(ctx, elp, false, actionTimeout(ctx))
+}
+
+// SimulateAction runs the action with ActionCtx.ReadOnly set, and reverts the states it changes, so that the receipt
+// is the one of running the action while the working set remains unchanged
+func (stx *stateTX) SimulateAction(ctx context.Context, elp action.SealedEnvelope) (*action.Receipt, error) {
+	snapshot := stx.Snapshot()
+	receipt, err := stx.runAction(ctx, elp, true, actionTimeout(ctx))
+	if revertErr := stx.Revert(snapshot); revertErr != nil {
+		return nil, errors.Wrap(revertErr, "failed to revert the states of the simulated action")
+	}
+	return receipt, err
+}
+
+// ReadView returns a reader passing the reads to the working set until it's released. The working set is only changed
+// by its owner, so the view sees the states the owner sees.
+func (stx *stateTX) ReadView() (protocol.StateReader, func(), error) {
+	view, release := newReadView(stx, nil)
+	return view, release, nil
 }
 
 func (stx *stateTX) validateBlockHeight(blkCtx protocol.BlockCtx) error {
@@ -105,6 +137,8 @@ func (stx *stateTX) validateBlockHeight(blkCtx protocol.BlockCtx) error {
 func (stx *stateTX) runAction(
 	ctx context.Context,
 	elp action.SealedEnvelope,
+	readOnly bool,
+	timeout time.Duration,
 ) (*action.Receipt, error) {
 	if stx.finalized {
 		return nil, errors.Errorf("cannot run action on a finalized working set")
@@ -112,7 +146,6 @@ func (stx *stateTX) runAction(
 
 	// Handle action
 	var actionCtx protocol.ActionCtx
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	blkCtx := protocol.MustGetBlockCtx(ctx)
 	if err := stx.validateBlockHeight(blkCtx); err != nil {
 		return nil, err
@@ -131,12 +164,41 @@ func (stx *stateTX) runAction(
 
 	actionCtx.IntrinsicGas = intrinsicGas
 	actionCtx.Nonce = elp.Nonce()
-	if bcCtx.Registry == nil {
+	actionCtx.ReadOnly = readOnly
+	reg, ok := protocol.GetRegistry(ctx)
+	if !ok {
 		return nil, nil
 	}
+	stx.actionHash = actionCtx.ActionHash
+	defer func() {
+		stx.actionHash = hash.ZeroHash256
+	}()
 	ctx = protocol.WithActionCtx(ctx, actionCtx)
-	for _, actionHandler := range bcCtx.Registry.All() {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// the writes of an action failing with a status, or timing out, are reverted
+	snapshot := stx.Snapshot()
+	for _, actionHandler := range reg.All() {
 		receipt, err := actionHandler.Handle(ctx, elp.Action(), stx)
+		if err == nil {
+			err = stx.checkBufferSize()
+		}
+		if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+			// the handling of the action timing out fails regardless of its result
+			if err := stx.Revert(snapshot); err != nil {
+				return nil, errors.Wrap(err, "failed to revert the states of the action timing out")
+			}
+			return timeoutReceipt(actionCtx.ActionHash, blkCtx.BlockHeight), nil
+		}
+		if receipt, ok := protocol.FailureReceipt(ctx, err); ok {
+			if err := stx.Revert(snapshot); err != nil {
+				return nil, errors.Wrap(err, "failed to revert the states of the failed action")
+			}
+			return receipt, nil
+		}
 		if err != nil {
 			return nil, errors.Wrapf(
 				err,
@@ -158,6 +220,9 @@ func (stx *stateTX) Finalize() error {
 	if stx.finalized {
 		return errors.New("Cannot finalize a working set twice")
 	}
+	if err := stx.objects.flush(stx.put); err != nil {
+		return err
+	}
 	// Persist current chain Height
 	stx.flusher.KVStoreWithBuffer().MustPut(
 		AccountKVNamespace,
@@ -170,11 +235,26 @@ func (stx *stateTX) Finalize() error {
 }
 
 func (stx *stateTX) Snapshot() int {
-	return stx.flusher.KVStoreWithBuffer().Snapshot()
+	s := stx.flusher.KVStoreWithBuffer().Snapshot()
+	// the cached objects aren't serialized, but the ones changed after the snapshot are journaled by the cache
+	stx.objects.snapshot(s)
+	return s
+}
+
+// checkBufferSize returns the error failing the action with ReceiptStatusBufferFull if the writes buffered, along with
+// the estimated ones of the cached objects which aren't serialized yet, exceed the limit of the buffer
+func (stx *stateTX) checkBufferSize() error {
+	return protocol.WrapWithStatus(
+		stx.flusher.KVStoreWithBuffer().CheckSize(stx.objects.size()),
+		action.ReceiptStatusBufferFull,
+	)
 }
 
 func (stx *stateTX) Revert(snapshot int) error {
-	return stx.flusher.KVStoreWithBuffer().Revert(snapshot)
+	if err := stx.flusher.KVStoreWithBuffer().Revert(snapshot); err != nil {
+		return err
+	}
+	return stx.objects.revert(snapshot)
 }
 
 // Commit persists all changes in RunActions() into the DB
@@ -183,76 +263,265 @@ func (stx *stateTX) Commit() error {
 		return errors.New("cannot commit a working set which has not been finalized")
 	}
 	// Commit all changes in a batch
-	dbBatchSizelMtc.WithLabelValues().Set(float64(stx.flusher.KVStoreWithBuffer().Size()))
+	dbBatchSizelMtc.WithLabelValues().Set(float64(stx.flusher.KVStoreWithBuffer().SizeInBytes()))
+	if err := stx.flusher.Flush(); err != nil {
+		return err
+	}
+	stx.objects.clear()
+	stx.deletedBy = make(map[string]hash.Hash256)
 
-	return stx.flusher.Flush()
+	return nil
 }
 
 // GetDB returns the underlying DB for account/contract storage
+//
+// Deprecated: use KVStore instead
 func (stx *stateTX) GetDB() db.KVStore {
+	if err := stx.objects.flush(stx.put); err != nil {
+		log.L().Panic("Failed to put the cached objects.", zap.Error(err))
+	}
 	return stx.flusher.KVStoreWithBuffer()
 }
 
+// KVStore returns the store of the records in the namespace through the buffer of the working set
+func (stx *stateTX) KVStore(ns string) (db.KVStoreBasic, error) {
+	if err := stx.objects.flush(stx.put); err != nil {
+		return nil, err
+	}
+	return namespaceKVStore(stx.flusher.KVStoreWithBuffer(), ns)
+}
+
 // State pulls a state from DB
 func (stx *stateTX) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
-	stateDBMtc.WithLabelValues("get").Inc()
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
 	if cfg.AtHeight {
 		return 0, ErrNotSupported
 	}
-	ns := AccountKVNamespace
-	if cfg.Namespace != "" {
-		ns = cfg.Namespace
+	return stx.state(s, cfg.Namespace, cfg.Key, cfg.Object)
+}
+
+// LegacyKeyState pulls the state of the legacy key in the namespace from DB
+func (stx *stateTX) LegacyKeyState(s interface{}, ns string, key hash.Hash160) (uint64, error) {
+	return stx.state(s, ns, key[:], false)
+}
+
+func (stx *stateTX) state(s interface{}, ns string, key []byte, object bool) (uint64, error) {
+	stateDBMtc.WithLabelValues("get").Inc()
+	if ns == "" {
+		ns = AccountKVNamespace
+	}
+	if object {
+		ok, err := stx.objects.get(ns, key, s)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return stx.blockHeight, nil
+		}
+	}
+	if err := stx.objects.evict(ns, key, stx.put); err != nil {
+		return 0, err
 	}
 
-	mstate, err := stx.flusher.KVStoreWithBuffer().Get(ns, cfg.Key)
+	mstate, err := stx.flusher.KVStoreWithBuffer().Get(ns, key)
 	switch errors.Cause(err) {
 	case db.ErrNotExist:
-		return 0, errors.Wrapf(state.ErrStateNotExist, "k = %x doesn't exist", cfg.Key)
+		if stx.flusher.KVStoreWithBuffer().Deleted(ns, key) {
+			return 0, stateDeletedError(key, stx.deletedBy[namespacedKey(ns, key)])
+		}
+		return 0, errors.Wrapf(state.ErrStateNotExist, "k = %x doesn't exist", key)
 	case nil:
-		return stx.blockHeight, state.Deserialize(s, mstate)
+		if err := state.Deserialize(s, mstate); err != nil {
+			return 0, err
+		}
+		if object {
+			stx.objects.put(ns, key, s, false, len(mstate))
+		}
+		return stx.blockHeight, nil
+	}
+	return 0, errors.Wrapf(err, "failed to get account of %x", key)
+}
+
+// States pulls the states of the keys from DB
+func (stx *stateTX) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	stateDBMtc.WithLabelValues("gets").Inc()
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return 0, nil, ErrNotSupported
+	}
+	ns := AccountKVNamespace
+	if cfg.Namespace != "" {
+		ns = cfg.Namespace
 	}
-	return 0, errors.Wrapf(err, "failed to get account of %x", cfg.Key)
+	if err := stx.objects.flush(stx.put); err != nil {
+		return 0, nil, err
+	}
+	var iter state.Iterator
+	switch {
+	case cfg.Prefix != nil:
+		iter, err = prefixStates(cfg.Context, stx.flusher.KVStoreWithBuffer(), ns, cfg.Prefix)
+	case cfg.Keys != nil:
+		iter, err = readStates(cfg.Context, cfg.Keys, func(key []byte) ([]byte, error) {
+			return stx.flusher.KVStoreWithBuffer().Get(ns, key)
+		})
+	default:
+		iter, err = prefixStates(cfg.Context, stx.flusher.KVStoreWithBuffer(), ns, nil)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return stx.blockHeight, iter, nil
 }
 
 // PutState puts a state into DB
 func (stx *stateTX) PutState(s interface{}, opts ...protocol.StateOption) (uint64, error) {
-	stateDBMtc.WithLabelValues("put").Inc()
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
+	if cfg.IndexKeys == nil {
+		return stx.putState(s, cfg.Namespace, cfg.Key, cfg.Object)
+	}
 
+	stateDBMtc.WithLabelValues("put").Inc()
 	ns := AccountKVNamespace
 	if cfg.Namespace != "" {
 		ns = cfg.Namespace
 	}
-
 	ss, err := state.Serialize(s)
 	if err != nil {
 		return 0, errors.Wrapf(err, "failed to convert account %v to bytes", s)
 	}
-
+	if err := stx.updateIndex(cfg, ns, ss); err != nil {
+		return 0, err
+	}
 	stx.flusher.KVStoreWithBuffer().MustPut(ns, cfg.Key, ss)
-
 	return stx.blockHeight, nil
 }
 
-// DelState deletes a state from DB
+// LegacyKeyPutState puts the state of the legacy key in the namespace into DB
+func (stx *stateTX) LegacyKeyPutState(s interface{}, ns string, key hash.Hash160) (uint64, error) {
+	return stx.putState(s, ns, key[:], false)
+}
+
+func (stx *stateTX) putState(s interface{}, ns string, key []byte, object bool) (uint64, error) {
+	stateDBMtc.WithLabelValues("put").Inc()
+	if ns == "" {
+		ns = AccountKVNamespace
+	}
+	if object {
+		stx.objects.put(ns, key, s, true, 0)
+		return stx.blockHeight, nil
+	}
+	if err := stx.objects.evict(ns, key, nil); err != nil {
+		return 0, err
+	}
+
+	return stx.blockHeight, stx.put(ns, key, s)
+}
+
+func (stx *stateTX) put(ns string, key []byte, s interface{}) error {
+	ss, err := state.Serialize(s)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert account %v to bytes", s)
+	}
+	stx.flusher.KVStoreWithBuffer().MustPut(ns, key, ss)
+
+	return nil
+}
+
+// DelState deletes a state from DB, and returns ErrStateNotExist if there is no state to delete
 func (stx *stateTX) DelState(opts ...protocol.StateOption) (uint64, error) {
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
 
 	ns := AccountKVNamespace
 	if cfg.Namespace != "" {
 		ns = cfg.Namespace
 	}
+	// nothing is written if the state doesn't exist, the cached object of which is checked first
+	if !stx.objects.cached(ns, cfg.Key) {
+		_, err := stx.flusher.KVStoreWithBuffer().Get(ns, cfg.Key)
+		switch errors.Cause(err) {
+		case nil:
+		case db.ErrNotExist:
+			if stx.flusher.KVStoreWithBuffer().Deleted(ns, cfg.Key) {
+				return 0, stateDeletedError(cfg.Key, stx.deletedBy[namespacedKey(ns, cfg.Key)])
+			}
+			return 0, errors.Wrapf(state.ErrStateNotExist, "k = %x doesn't exist", cfg.Key)
+		default:
+			return 0, errors.Wrapf(err, "failed to get account of %x", cfg.Key)
+		}
+	}
+	if cfg.IndexKeys != nil {
+		if err := stx.updateIndex(cfg, ns, nil); err != nil {
+			return 0, err
+		}
+	} else if err := stx.objects.evict(ns, cfg.Key, nil); err != nil {
+		return 0, err
+	}
 	stx.flusher.KVStoreWithBuffer().MustDelete(ns, cfg.Key)
+	stx.deletedBy[namespacedKey(ns, cfg.Key)] = stx.actionHash
 
 	return stx.blockHeight, nil
 }
+
+// PutStates puts the states together, either all of which are put or none of which is
+func (stx *stateTX) PutStates(writes []protocol.StateWrite) (uint64, error) {
+	return putStates(stx, writes)
+}
+
+// DelStates deletes the states together, either all of which are deleted or none of which is
+func (stx *stateTX) DelStates(keys []protocol.StateKey) (uint64, error) {
+	return delStates(stx, keys)
+}
+
+// updateIndex moves the index entries of the state of the key from the index keys of its old value to the ones of the
+// new value, which is nil if the state is deleted. The cached object of the state is put first, so that the old value
+// is read from the store.
+func (stx *stateTX) updateIndex(cfg *protocol.StateConfig, ns string, value []byte) error {
+	if err := stx.objects.evict(ns, cfg.Key, stx.put); err != nil {
+		return err
+	}
+	kv := stx.flusher.KVStoreWithBuffer()
+	var oldKeys, newKeys [][]byte
+	old, err := kv.Get(ns, cfg.Key)
+	switch errors.Cause(err) {
+	case nil:
+		oldKeys = cfg.IndexKeys(old)
+	case db.ErrNotExist:
+	default:
+		return errors.Wrapf(err, "failed to get the indexed state of %x", cfg.Key)
+	}
+	if value != nil {
+		newKeys = cfg.IndexKeys(value)
+	}
+	indexed := make(map[string]bool, len(oldKeys))
+	for _, indexKey := range oldKeys {
+		indexed[string(indexKey)] = true
+	}
+	for _, indexKey := range newKeys {
+		if indexed[string(indexKey)] {
+			delete(indexed, string(indexKey))
+			continue
+		}
+		kv.MustPut(cfg.IndexNamespace, protocol.IndexEntryKey(indexKey, cfg.Key), cfg.Key)
+	}
+	for _, indexKey := range oldKeys {
+		if indexed[string(indexKey)] {
+			kv.MustDelete(cfg.IndexNamespace, protocol.IndexEntryKey(indexKey, cfg.Key))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package factory
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+)
+
+// legacyTrieRootPrefix is the prefix of the legacy keys of the root hashes of the heights, which are followed by the
+// height in decimal
+var legacyTrieRootPrefix = []byte(AccountTrieRootKey + "-")
+
+// trieRootAt returns the root hash of the account trie at the height. The binary index is read first, and the legacy
+// key is read for a height before the index is activated, unless it's backfilled.
+func trieRootAt(kv db.KVStore, height uint64) ([]byte, error) {
+	rootHash, err := kv.Get(TrieRootNamespace, byteutil.Uint64ToBytesBigEndian(height))
+	if errors.Cause(err) == db.ErrNotExist {
+		rootHash, err = kv.Get(AccountTrieNamespace, []byte(fmt.Sprintf("%s-%d", AccountTrieRootKey, height)))
+	}
+	if errors.Cause(err) == db.ErrNotExist {
+		// the height is either pruned or not committed yet
+		return nil, errors.Wrapf(ErrNoArchiveData, "no root hash at height %d", height)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get root hash through height")
+	}
+	return rootHash, nil
+}
+
+// BackfillTrieRootIndex writes the root hashes under the legacy keys into the binary index, which are the ones of the
+// heights before the index is activated, and returns the number of them written. It's a one-time migration invoked
+// explicitly, and the root hashes already in the index are kept.
+func BackfillTrieRootIndex(kv db.KVStore) (int, error) {
+	store, ok := kv.(db.KVStoreWithPrefix)
+	if !ok {
+		return 0, errors.Errorf("store %T doesn't support prefix scan", kv)
+	}
+	keys, values, err := store.Prefix(AccountTrieNamespace, legacyTrieRootPrefix)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read legacy root hashes")
+	}
+	b := batch.NewBatch()
+	for i, key := range keys {
+		height, err := strconv.ParseUint(string(key[len(legacyTrieRootPrefix):]), 10, 64)
+		if err != nil {
+			// a trie node whose hash happens to start with the prefix
+			continue
+		}
+		indexKey := byteutil.Uint64ToBytesBigEndian(height)
+		switch _, err := kv.Get(TrieRootNamespace, indexKey); errors.Cause(err) {
+		case nil:
+			continue
+		case db.ErrNotExist:
+		default:
+			return 0, errors.Wrapf(err, "failed to get root hash at height %d", height)
+		}
+		b.Put(TrieRootNamespace, indexKey, values[i], "failed to backfill root hash at height %d", height)
+	}
+	n := b.Size()
+	if n == 0 {
+		return 0, nil
+	}
+	if err := kv.WriteBatch(b); err != nil {
+		return 0, errors.Wrap(err, "failed to backfill root hashes")
+	}
+	return n, nil
+}
@@ -7,6 +7,7 @@
 package factory
 
 import (
+	"bytes"
 	"context"
 	"sort"
 	"time"
@@ -23,19 +24,220 @@ import (
 	"github.com/iotexproject/iotex-core/action/protocol"
 	accountutil "github.com/iotexproject/iotex-core/action/protocol/account/util"
 	"github.com/iotexproject/iotex-core/action/protocol/execution/evm"
+	"github.com/iotexproject/iotex-core/action/protocol/rolldpos"
 	"github.com/iotexproject/iotex-core/actpool/actioniterator"
 	"github.com/iotexproject/iotex-core/blockchain/block"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/db/trie"
 	"github.com/iotexproject/iotex-core/pkg/log"
+	"github.com/iotexproject/iotex-core/state"
 )
 
-// createGenesisStates initialize the genesis states
+// tipHeight returns the height of the last committed block which the block of the height is built atop. The genesis
+// states are worked on atop nothing, so their tip is the genesis height itself.
+func tipHeight(height uint64) uint64 {
+	if height == 0 {
+		return 0
+	}
+	return height - 1
+}
+
+// actionTimeoutKey is the key of the context carrying the timeout of running an action
+type actionTimeoutKey struct{}
+
+// withActionTimeout sets the timeout of running an action in the context, which only the block production and the
+// simulation set. The validation of a block never times an action out, as the result would depend on the speed of
+// the validator, and the producer leaves the actions timing out out of the block instead.
+func withActionTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, actionTimeoutKey{}, timeout)
+}
+
+// actionTimeout returns the timeout of running an action in the context, 0 if there is none
+func actionTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(actionTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
+// timeoutReceipt returns the receipt of the action whose handling times out, which consumes no gas as its writes are
+// reverted
+func timeoutReceipt(actionHash hash.Hash256, height uint64) *action.Receipt {
+	return &action.Receipt{
+		Status:      action.ReceiptStatusExecutionTimeout,
+		BlockHeight: height,
+		ActionHash:  actionHash,
+	}
+}
+
+// contextCheckInterval is the number of states scanned between two checks of the context bounding the scan
+const contextCheckInterval = 256
+
+// checkContext returns the error of the context bounding the scan once it's done, which is checked before the first
+// state and then every contextCheckInterval states. A nil context never ends the scan.
+func checkContext(ctx context.Context, scanned int) error {
+	if ctx == nil || scanned%contextCheckInterval != 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "scan of states is aborted after %d states", scanned)
+	}
+	return nil
+}
+
+// readStates reads the serialized states of the keys with get, in the order of the keys. The distinct keys are read
+// once each in ascending order, so that the adjacent keys share the traversal of the trie nodes cached along the way,
+// and a key which has no state gets a nil state.
+func readStates(ctx context.Context, keys [][]byte, get func([]byte) ([]byte, error)) (state.Iterator, error) {
+	distinct := make([]string, 0, len(keys))
+	found := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if _, ok := found[string(key)]; !ok {
+			found[string(key)] = nil
+			distinct = append(distinct, string(key))
+		}
+	}
+	sort.Strings(distinct)
+	for i, key := range distinct {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		value, err := get([]byte(key))
+		switch errors.Cause(err) {
+		case nil:
+			found[key] = value
+		case trie.ErrNotExist, db.ErrNotExist:
+		default:
+			return nil, errors.Wrapf(err, "failed to get state of %x", key)
+		}
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = found[string(key)]
+	}
+	return state.NewIterator(keys, values)
+}
+
+// prefixStates reads the serialized states whose keys start with the prefix in the namespace, ordered by key. All the
+// states of the namespace are read if the prefix is nil. The store scans the states in one call, so the context is
+// checked before and after it.
+func prefixStates(ctx context.Context, kv db.KVStore, ns string, prefix []byte) (state.Iterator, error) {
+	store, ok := kv.(db.KVStoreWithPrefix)
+	if !ok {
+		return nil, errors.Wrapf(ErrNotSupported, "store %T can't be scanned by prefix", kv)
+	}
+	if err := checkContext(ctx, 0); err != nil {
+		return nil, err
+	}
+	keys, values, err := store.Prefix(ns, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan states of prefix %x", prefix)
+	}
+	if err := checkContext(ctx, 0); err != nil {
+		return nil, err
+	}
+	return state.NewIterator(keys, values)
+}
+
+// trieStates reads the serialized states in the trie whose keys start with the prefix, ordered by key. The trie keeps
+// the states of all the namespaces, the same as State reads them regardless of the namespace, so all the states in
+// the trie are read if the prefix is nil.
+func trieStates(ctx context.Context, tr trie.Trie, prefix []byte) (state.Iterator, error) {
+	if err := checkContext(ctx, 0); err != nil {
+		return nil, err
+	}
+	iter, err := trie.NewLeafIterator(tr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the trie")
+	}
+	records := make(map[string][]byte)
+	for scanned := 1; ; scanned++ {
+		if err := checkContext(ctx, scanned); err != nil {
+			return nil, err
+		}
+		key, value, err := iter.Next()
+		if err == trie.ErrEndOfIterator {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to iterate the trie")
+		}
+		if bytes.HasPrefix(key, prefix) {
+			records[string(key)] = value
+		}
+	}
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ks, values := make([][]byte, len(keys)), make([][]byte, len(keys))
+	for i, key := range keys {
+		ks[i], values[i] = []byte(key), records[key]
+	}
+	return state.NewIterator(ks, values)
+}
+
+// namespaceKVStore returns the store of the records in the namespace of kv, which can't be the namespace of the
+// account states or of the account trie
+func namespaceKVStore(kv db.KVStore, ns string) (db.KVStoreBasic, error) {
+	switch ns {
+	case "", AccountKVNamespace, AccountTrieNamespace:
+		return nil, errors.Wrapf(ErrNotSupported, "namespace %q can't be accessed as a KV store", ns)
+	}
+	return db.NewKVStoreForTrie(ns, kv)
+}
+
+// stateDeletedError returns the error that the state of the key has been deleted, by the action of the hash if it
+// isn't zero
+func stateDeletedError(key []byte, actionHash hash.Hash256) error {
+	if actionHash == hash.ZeroHash256 {
+		return errors.Wrapf(state.ErrStateDeleted, "k = %x has been deleted", key)
+	}
+	return errors.Wrapf(state.ErrStateDeleted, "k = %x has been deleted by action %x", key, actionHash)
+}
+
+// putStates puts the states one by one, all of which are reverted once any of them fails
+func putStates(sm protocol.StateManager, writes []protocol.StateWrite) (uint64, error) {
+	snapshot := sm.Snapshot()
+	for i, w := range writes {
+		if _, err := sm.PutState(w.State, w.Options...); err != nil {
+			if err := sm.Revert(snapshot); err != nil {
+				return 0, errors.Wrap(err, "failed to revert the states put")
+			}
+			return 0, errors.Wrapf(err, "failed to put state %d of %d", i, len(writes))
+		}
+	}
+	return sm.Height()
+}
+
+// delStates deletes the states one by one, all of which are reverted once any of them fails
+func delStates(sm protocol.StateManager, keys []protocol.StateKey) (uint64, error) {
+	snapshot := sm.Snapshot()
+	for i, key := range keys {
+		if _, err := sm.DelState(key...); err != nil {
+			if err := sm.Revert(snapshot); err != nil {
+				return 0, errors.Wrap(err, "failed to revert the states deleted")
+			}
+			return 0, errors.Wrapf(err, "failed to delete state %d of %d", i, len(keys))
+		}
+	}
+	return sm.Height()
+}
+
+// createGenesisStates initialize the genesis states in the order of Registry.GenesisStatesCreationOrder. The genesis
+// states are created all or nothing, so the working set is reverted once a protocol fails to create its ones.
 func createGenesisStates(ctx context.Context, ws WorkingSet) error {
 	if bcCtx, ok := protocol.GetBlockchainCtx(ctx); ok {
-		for _, p := range bcCtx.Registry.All() {
-			if gsc, ok := p.(protocol.GenesisStateCreator); ok {
-				if err := gsc.CreateGenesisStates(ctx, ws); err != nil {
-					return errors.Wrap(err, "failed to create genesis states for protocol")
+		creators, err := bcCtx.Registry.GenesisStatesCreationOrder()
+		if err != nil {
+			return err
+		}
+		snapshot := ws.Snapshot()
+		for _, p := range creators {
+			if err := p.(protocol.GenesisStateCreator).CreateGenesisStates(ctx, ws); err != nil {
+				if revertErr := ws.Revert(snapshot); revertErr != nil {
+					log.L().Error("Failed to revert the genesis states.", zap.Error(revertErr))
 				}
+				return errors.Wrapf(err, "failed to create genesis states for protocol %q", p.Name())
 			}
 		}
 	}
@@ -117,14 +319,8 @@ func appendActionIndex(accountNonceMap map[string][]uint64, srcAddr string, nonc
 }
 
 func runActions(ctx context.Context, ws WorkingSet, actions []action.SealedEnvelope) ([]*action.Receipt, WorkingSet, error) {
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
-	registry := bcCtx.Registry
-	for _, p := range registry.All() {
-		if pp, ok := p.(protocol.PreStatesCreator); ok {
-			if err := pp.CreatePreStates(ctx, ws); err != nil {
-				return nil, nil, err
-			}
-		}
+	if err := createPreStates(ctx, ws); err != nil {
+		return nil, nil, err
 	}
 	// TODO: verify whether the post system actions are appended tail
 
@@ -132,17 +328,97 @@ func runActions(ctx context.Context, ws WorkingSet, actions []action.SealedEnvel
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := createPostStates(ctx, ws); err != nil {
+		return nil, nil, err
+	}
 	return receipts, ws, ws.Finalize()
 }
 
+func createPreStates(ctx context.Context, ws WorkingSet) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	epochNum, boundaries := epochBoundaries(ctx, false)
+	for _, p := range bcCtx.Registry.All() {
+		if pp, ok := p.(protocol.PreStatesCreator); ok {
+			if err := pp.CreatePreStates(ctx, ws); err != nil {
+				return err
+			}
+		}
+		if err := onEpochBoundaries(ctx, ws, p, epochNum, boundaries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createPostStates(ctx context.Context, ws WorkingSet) error {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	epochNum, boundaries := epochBoundaries(ctx, true)
+	for _, p := range bcCtx.Registry.All() {
+		if pp, ok := p.(protocol.PostStatesCreator); ok {
+			if err := pp.CreatePostStates(ctx, ws); err != nil {
+				return err
+			}
+		}
+		if err := onEpochBoundaries(ctx, ws, p, epochNum, boundaries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// epochBoundaries returns the epoch of the block and the boundaries it is at, before or after running its actions
+func epochBoundaries(ctx context.Context, post bool) (uint64, []protocol.EpochBoundary) {
+	bcCtx := protocol.MustGetBlockchainCtx(ctx)
+	blkCtx := protocol.MustGetBlockCtx(ctx)
+	rp := rolldpos.FindProtocol(bcCtx.Registry)
+	if rp == nil {
+		return 0, nil
+	}
+	height := blkCtx.BlockHeight
+	var boundaries []protocol.EpochBoundary
+	if post {
+		if rp.IsEpochEnd(height) {
+			boundaries = append(boundaries, protocol.EpochEndPost)
+		}
+		return rp.EpochOf(height), boundaries
+	}
+	if rp.IsEpochStart(height) {
+		boundaries = append(boundaries, protocol.EpochStart)
+	}
+	if rp.IsEpochEnd(height) {
+		boundaries = append(boundaries, protocol.EpochEnd)
+	}
+	return rp.EpochOf(height), boundaries
+}
+
+func onEpochBoundaries(
+	ctx context.Context,
+	ws WorkingSet,
+	p protocol.Protocol,
+	epochNum uint64,
+	boundaries []protocol.EpochBoundary,
+) error {
+	hook, ok := p.(protocol.EpochBoundaryHook)
+	if !ok {
+		return nil
+	}
+	for _, boundary := range boundaries {
+		if err := hook.OnEpochBoundary(ctx, ws, epochNum, boundary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func createBuilderWithWorkingset(
 	ctx context.Context,
 	ws WorkingSet,
 	actionMap map[string][]action.SealedEnvelope,
 	postSystemActions []action.SealedEnvelope,
 	allowedBlockGasResidue uint64,
+	timeoutSubscribers []ActionTimeoutSubscriber,
 ) (*block.Builder, error) {
-	rc, actions, ws, err := pickAndRunActions(ctx, ws, actionMap, postSystemActions, allowedBlockGasResidue)
+	rc, actions, ws, err := pickAndRunActions(ctx, ws, actionMap, postSystemActions, allowedBlockGasResidue, timeoutSubscribers)
 	if err != nil {
 		return nil, err
 	}
@@ -181,19 +457,14 @@ func pickAndRunActions(
 	actionMap map[string][]action.SealedEnvelope,
 	postSystemActions []action.SealedEnvelope,
 	allowedBlockGasResidue uint64,
+	timeoutSubscribers []ActionTimeoutSubscriber,
 ) ([]*action.Receipt, []action.SealedEnvelope, WorkingSet, error) {
 	receipts := make([]*action.Receipt, 0)
 	executedActions := make([]action.SealedEnvelope, 0)
 
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	blkCtx := protocol.MustGetBlockCtx(ctx)
-	registry := bcCtx.Registry
-	for _, p := range registry.All() {
-		if pp, ok := p.(protocol.PreStatesCreator); ok {
-			if err := pp.CreatePreStates(ctx, ws); err != nil {
-				return nil, nil, nil, err
-			}
-		}
+	if err := createPreStates(ctx, ws); err != nil {
+		return nil, nil, nil, err
 	}
 
 	// initial action iterator
@@ -214,6 +485,16 @@ func pickAndRunActions(
 			}
 			return nil, nil, nil, errors.Wrapf(err, "Failed to update state changes for selp %x", nextAction.Hash())
 		}
+		if receipt != nil && receipt.Status == action.ReceiptStatusExecutionTimeout {
+			// the validators never time the action out, so it's left out of the block along with the later actions
+			// of the same user, and the subscribers evict it so that it isn't run again in the next blocks
+			log.L().Warn("Action timed out.", log.Hex("hash", receipt.ActionHash[:]))
+			actionIterator.PopAccount()
+			for _, s := range timeoutSubscribers {
+				s.HandleActionTimeout(nextAction)
+			}
+			continue
+		}
 		if receipt != nil {
 			blkCtx.GasLimit -= receipt.GasConsumed
 			ctx = protocol.WithBlockCtx(ctx, blkCtx)
@@ -227,6 +508,8 @@ func pickAndRunActions(
 			break
 		}
 	}
+	// the system actions are part of every block, so they're never timed out
+	ctx = withActionTimeout(ctx, 0)
 	for _, selp := range postSystemActions {
 		receipt, err := ws.RunAction(ctx, selp)
 		if err != nil {
@@ -237,6 +520,9 @@ func pickAndRunActions(
 		}
 		executedActions = append(executedActions, selp)
 	}
+	if err := createPostStates(ctx, ws); err != nil {
+		return nil, nil, nil, err
+	}
 
 	return receipts, executedActions, ws, ws.Finalize()
 }
@@ -252,7 +538,8 @@ func simulateExecution(
 	ctx = protocol.WithActionCtx(
 		ctx,
 		protocol.ActionCtx{
-			Caller: caller,
+			Caller:   caller,
+			ReadOnly: true,
 		},
 	)
 	zeroAddr, err := address.FromString(address.ZeroAddress)
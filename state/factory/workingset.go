@@ -9,17 +9,20 @@ package factory
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/iotexproject/go-pkgs/hash"
 	"github.com/iotexproject/iotex-address/address"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 
 	"github.com/iotexproject/iotex-core/action"
 	"github.com/iotexproject/iotex-core/action/protocol"
 	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/db/batch"
 	"github.com/iotexproject/iotex-core/db/trie"
+	"github.com/iotexproject/iotex-core/pkg/log"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/state"
 )
@@ -35,7 +38,7 @@ var (
 	dbBatchSizelMtc = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "iotex_db_batch_size",
-			Help: "DB batch size",
+			Help: "DB batch size in bytes",
 		},
 		[]string{},
 	)
@@ -53,6 +56,10 @@ type (
 		// states and actions
 		RunAction(context.Context, action.SealedEnvelope) (*action.Receipt, error)
 		RunActions(context.Context, []action.SealedEnvelope) ([]*action.Receipt, error)
+		// SimulateAction runs the action with ActionCtx.ReadOnly set, and reverts the states it changes
+		SimulateAction(context.Context, action.SealedEnvelope) (*action.Receipt, error)
+		// ReadView returns a reader passing the reads to the working set, along with the function releasing it
+		ReadView() (protocol.StateReader, func(), error)
 		Finalize() error
 		Commit() error
 		RootHash() ([]byte, error)
@@ -67,6 +74,11 @@ type (
 		accountTrie trie.Trie      // global account state trie
 		trieRoots   map[int][]byte // root of trie at time of snapshot
 		flusher     db.KVStoreFlusher
+		objects     *objectCache
+		actionHash  hash.Hash256            // hash of the action being run
+		deletedBy   map[string]hash.Hash256 // hashes of the actions deleting the states
+		// rootIndexHeight is the height from which the root hash is indexed by the height in binary as well
+		rootIndexHeight uint64
 	}
 )
 
@@ -75,6 +87,8 @@ func newWorkingSet(
 	height uint64,
 	kv db.KVStore,
 	root []byte,
+	trieOpts []trie.Option,
+	rootIndexHeight uint64,
 	opts ...db.KVStoreFlusherOption,
 ) (WorkingSet, error) {
 	flusher, err := db.NewKVStoreFlusher(kv, batch.NewCachedBatch(), opts...)
@@ -86,18 +100,28 @@ func newWorkingSet(
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate state tire db")
 	}
-	tr, err := trie.NewTrie(trie.KVStoreOption(dbForTrie), trie.RootHashOption(root[:]))
+	tr, err := trie.NewTrie(append(
+		[]trie.Option{trie.KVStoreOption(dbForTrie), trie.RootHashOption(root[:])},
+		trieOpts...,
+	)...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate state trie from config")
 	}
 
+	if err := tr.Start(context.Background()); err != nil {
+		return nil, errors.Wrapf(err, "failed to load trie in namespace %s", AccountTrieNamespace)
+	}
+
 	return &workingSet{
-		accountTrie: tr,
-		finalized:   false,
-		blockHeight: height,
-		trieRoots:   make(map[int][]byte),
-		flusher:     flusher,
-	}, tr.Start(context.Background())
+		accountTrie:     tr,
+		finalized:       false,
+		blockHeight:     height,
+		rootIndexHeight: rootIndexHeight,
+		trieRoots:       make(map[int][]byte),
+		flusher:         flusher,
+		objects:         newObjectCache(),
+		deletedBy:       make(map[string]hash.Hash256),
+	}, nil
 }
 
 // RootHash returns the hash of the root node of the accountTrie
@@ -126,6 +150,11 @@ func (ws *workingSet) Height() (uint64, error) {
 	return ws.blockHeight, nil
 }
 
+// TipHeight returns the height of the last committed block, which the block being worked on is built atop
+func (ws *workingSet) TipHeight() (uint64, error) {
+	return tipHeight(ws.blockHeight), nil
+}
+
 // RunActions runs actions in the block and track pending changes in working set
 func (ws *workingSet) RunActions(
 	ctx context.Context,
@@ -134,7 +163,8 @@ func (ws *workingSet) RunActions(
 	// Handle actions
 	receipts := make([]*action.Receipt, 0)
 	for _, elp := range elps {
-		receipt, err := ws.runAction(ctx, elp)
+		// the actions of a block are never timed out, so that the validation is deterministic
+		receipt, err := ws.runAction(ctx, elp, false, 0)
 		if err != nil {
 			return nil, errors.Wrap(err, "error when run action")
 		}
@@ -150,12 +180,35 @@ func (ws *workingSet) RunAction(
 	ctx context.Context,
 	elp action.SealedEnvelope,
 ) (*action.Receipt, error) {
-	return ws.runAction(ctx, elp)
+	return ws.runAction(ctx, elp, false, actionTimeout(ctx))
+}
+
+// SimulateAction runs the action with ActionCtx.ReadOnly set, and reverts the states it changes, so that the receipt
+// is the one of running the action while the working set remains unchanged
+func (ws *workingSet) SimulateAction(
+	ctx context.Context,
+	elp action.SealedEnvelope,
+) (*action.Receipt, error) {
+	snapshot := ws.Snapshot()
+	receipt, err := ws.runAction(ctx, elp, true, actionTimeout(ctx))
+	if revertErr := ws.Revert(snapshot); revertErr != nil {
+		return nil, errors.Wrap(revertErr, "failed to revert the states of the simulated action")
+	}
+	return receipt, err
+}
+
+// ReadView returns a reader passing the reads to the working set until it's released. The working set is only changed
+// by its owner, so the view sees the states the owner sees.
+func (ws *workingSet) ReadView() (protocol.StateReader, func(), error) {
+	view, release := newReadView(ws, nil)
+	return view, release, nil
 }
 
 func (ws *workingSet) runAction(
 	ctx context.Context,
 	elp action.SealedEnvelope,
+	readOnly bool,
+	timeout time.Duration,
 ) (*action.Receipt, error) {
 	if ws.finalized {
 		return nil, errors.Errorf("cannot run action on a finalized working set")
@@ -163,7 +216,6 @@ func (ws *workingSet) runAction(
 	// Handle action
 	var actionCtx protocol.ActionCtx
 	blkCtx := protocol.MustGetBlockCtx(ctx)
-	bcCtx := protocol.MustGetBlockchainCtx(ctx)
 	if blkCtx.BlockHeight != ws.blockHeight {
 		return nil, errors.Errorf(
 			"invalid block height %d, %d expected",
@@ -184,13 +236,42 @@ func (ws *workingSet) runAction(
 	}
 	actionCtx.IntrinsicGas = intrinsicGas
 	actionCtx.Nonce = elp.Nonce()
+	actionCtx.ReadOnly = readOnly
 
 	ctx = protocol.WithActionCtx(ctx, actionCtx)
-	if bcCtx.Registry == nil {
+	reg, ok := protocol.GetRegistry(ctx)
+	if !ok {
 		return nil, nil
 	}
-	for _, actionHandler := range bcCtx.Registry.All() {
+	ws.actionHash = actionCtx.ActionHash
+	defer func() {
+		ws.actionHash = hash.ZeroHash256
+	}()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// the writes of an action failing with a status, or timing out, are reverted
+	snapshot := ws.Snapshot()
+	for _, actionHandler := range reg.All() {
 		receipt, err := actionHandler.Handle(ctx, elp.Action(), ws)
+		if err == nil {
+			err = ws.checkBufferSize()
+		}
+		if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+			// the handling of the action timing out fails regardless of its result
+			if err := ws.Revert(snapshot); err != nil {
+				return nil, errors.Wrap(err, "failed to revert the states of the action timing out")
+			}
+			return timeoutReceipt(actionCtx.ActionHash, blkCtx.BlockHeight), nil
+		}
+		if receipt, ok := protocol.FailureReceipt(ctx, err); ok {
+			if err := ws.Revert(snapshot); err != nil {
+				return nil, errors.Wrap(err, "failed to revert the states of the failed action")
+			}
+			return receipt, nil
+		}
 		if err != nil {
 			return nil, errors.Wrapf(
 				err,
@@ -212,6 +293,9 @@ func (ws *workingSet) Finalize() error {
 	if ws.finalized {
 		return errors.New("Cannot finalize a working set twice")
 	}
+	if err := ws.flushObjects(); err != nil {
+		return err
+	}
 	ws.finalized = true
 	// Persist current chain Height
 	h := byteutil.Uint64ToBytes(ws.blockHeight)
@@ -225,6 +309,9 @@ func (ws *workingSet) Finalize() error {
 		[]byte(fmt.Sprintf("%s-%d", AccountTrieRootKey, ws.blockHeight)),
 		rootHash,
 	)
+	if ws.blockHeight >= ws.rootIndexHeight {
+		ws.flusher.KVStoreWithBuffer().MustPut(TrieRootNamespace, byteutil.Uint64ToBytesBigEndian(ws.blockHeight), rootHash)
+	}
 
 	return nil
 }
@@ -232,6 +319,8 @@ func (ws *workingSet) Finalize() error {
 func (ws *workingSet) Snapshot() int {
 	s := ws.flusher.KVStoreWithBuffer().Snapshot()
 	ws.trieRoots[s] = ws.accountTrie.RootHash()
+	// the cached objects aren't serialized, but the ones changed after the snapshot are journaled by the cache
+	ws.objects.snapshot(s)
 	return s
 }
 
@@ -239,18 +328,25 @@ func (ws *workingSet) Revert(snapshot int) error {
 	if err := ws.flusher.KVStoreWithBuffer().Revert(snapshot); err != nil {
 		return err
 	}
+	if err := ws.objects.revert(snapshot); err != nil {
+		return err
+	}
 	root, ok := ws.trieRoots[snapshot]
 	if !ok {
 		// this should not happen, b/c we save the trie root on a successful return of Snapshot(), but check anyway
 		return errors.Wrapf(trie.ErrInvalidTrie, "failed to get trie root for snapshot = %d", snapshot)
 	}
-	return ws.accountTrie.SetRootHash(root[:])
+	// the root missing from the store fails with trie.ErrInvalidRoot, unlike the other failures of revert
+	if err := ws.accountTrie.SetRootHash(root[:]); err != nil {
+		return errors.Wrapf(err, "failed to revert trie in namespace %s to snapshot %d", AccountTrieNamespace, snapshot)
+	}
+	return nil
 }
 
 // Commit persists all changes in RunActions() into the DB
 func (ws *workingSet) Commit() error {
 	// Commit all changes in a batch
-	dbBatchSizelMtc.WithLabelValues().Set(float64(ws.flusher.KVStoreWithBuffer().Size()))
+	dbBatchSizelMtc.WithLabelValues().Set(float64(ws.flusher.KVStoreWithBuffer().SizeInBytes()))
 	if err := ws.flusher.Flush(); err != nil {
 		return errors.Wrap(err, "failed to Commit all changes to underlying DB in a batch")
 	}
@@ -259,60 +355,215 @@ func (ws *workingSet) Commit() error {
 }
 
 // GetDB returns the underlying DB for account/contract storage
+//
+// Deprecated: use KVStore instead
 func (ws *workingSet) GetDB() db.KVStore {
+	if err := ws.flushObjects(); err != nil {
+		log.L().Panic("Failed to put the cached objects.", zap.Error(err))
+	}
 	return ws.flusher.KVStoreWithBuffer()
 }
 
+// KVStore returns the store of the records in the namespace through the buffer of the working set
+func (ws *workingSet) KVStore(ns string) (db.KVStoreBasic, error) {
+	if err := ws.flushObjects(); err != nil {
+		return nil, err
+	}
+	return namespaceKVStore(ws.flusher.KVStoreWithBuffer(), ns)
+}
+
 // State pulls a state from DB
 func (ws *workingSet) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
 	if cfg.AtHeight {
 		return 0, ErrNotSupported
 	}
+	return ws.state(s, cfg.Key, cfg.Object)
+}
 
+// LegacyKeyState pulls the state of the legacy key from DB, regardless of the namespace the same as State
+func (ws *workingSet) LegacyKeyState(s interface{}, _ string, key hash.Hash160) (uint64, error) {
+	return ws.state(s, key[:], false)
+}
+
+func (ws *workingSet) state(s interface{}, key []byte, object bool) (uint64, error) {
 	stateDBMtc.WithLabelValues("get").Inc()
-	mstate, err := ws.accountTrie.Get(cfg.Key)
+	if object {
+		ok, err := ws.objects.get(AccountKVNamespace, key, s)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return ws.blockHeight, nil
+		}
+	}
+	if err := ws.objects.evict(AccountKVNamespace, key, ws.put); err != nil {
+		return 0, err
+	}
+	mstate, err := ws.accountTrie.Get(key)
 	if errors.Cause(err) == trie.ErrNotExist {
-		return 0, errors.Wrapf(state.ErrStateNotExist, "addrHash = %x", cfg.Key)
+		if ws.flusher.KVStoreWithBuffer().Deleted(AccountKVNamespace, key) {
+			return 0, stateDeletedError(key, ws.deletedBy[namespacedKey(AccountKVNamespace, key)])
+		}
+		return 0, errors.Wrapf(state.ErrStateNotExist, "addrHash = %x", key)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get account of %x", key)
+	}
+	if err := state.Deserialize(s, mstate); err != nil {
+		return 0, err
+	}
+	if object {
+		ws.objects.put(AccountKVNamespace, key, s, false, len(mstate))
+	}
+	return ws.blockHeight, nil
+}
+
+// States pulls the states of the keys from DB
+func (ws *workingSet) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return 0, nil, ErrNotSupported
+	}
+
+	stateDBMtc.WithLabelValues("gets").Inc()
+	if err := ws.flushObjects(); err != nil {
+		return 0, nil, err
+	}
+	var iter state.Iterator
+	if cfg.Keys != nil {
+		iter, err = readStates(cfg.Context, cfg.Keys, ws.accountTrie.Get)
+	} else {
+		iter, err = trieStates(cfg.Context, ws.accountTrie, cfg.Prefix)
 	}
 	if err != nil {
-		return 0, errors.Wrapf(err, "failed to get account of %x", cfg.Key)
+		return 0, nil, err
 	}
-	return ws.blockHeight, state.Deserialize(s, mstate)
+	return ws.blockHeight, iter, nil
 }
 
 // PutState puts a state into DB
 func (ws *workingSet) PutState(s interface{}, opts ...protocol.StateOption) (uint64, error) {
-	stateDBMtc.WithLabelValues("put").Inc()
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
+	if cfg.IndexKeys != nil {
+		// the trie keeps the states of all the namespaces in one key space, which has no room for the index
+		return 0, errors.Wrap(ErrNotSupported, "working set of trie can't maintain index")
+	}
+	return ws.putState(s, cfg.Key, cfg.Object)
+}
+
+// LegacyKeyPutState puts the state of the legacy key into DB, regardless of the namespace the same as PutState
+func (ws *workingSet) LegacyKeyPutState(s interface{}, _ string, key hash.Hash160) (uint64, error) {
+	return ws.putState(s, key[:], false)
+}
+
+func (ws *workingSet) putState(s interface{}, key []byte, object bool) (uint64, error) {
+	stateDBMtc.WithLabelValues("put").Inc()
+	if object {
+		ws.objects.put(AccountKVNamespace, key, s, true, 0)
+		return ws.blockHeight, nil
+	}
+	if err := ws.objects.evict(AccountKVNamespace, key, nil); err != nil {
+		return 0, err
+	}
+
+	return ws.blockHeight, ws.put(AccountKVNamespace, key, s)
+}
+
+// flushObjects serializes the dirty cached objects, and upserts them into the trie in one batch
+func (ws *workingSet) flushObjects() error {
+	var keys, values [][]byte
+	if err := ws.objects.flush(func(ns string, key []byte, s interface{}) error {
+		ss, err := state.Serialize(s)
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert account %v to bytes", s)
+		}
+		ws.flusher.KVStoreWithBuffer().MustPut(ns, key, ss)
+		keys = append(keys, key)
+		values = append(values, ss)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return ws.accountTrie.UpsertBatch(keys, values)
+}
+
+// checkBufferSize returns the error failing the action with ReceiptStatusBufferFull if the writes buffered, along with
+// the estimated ones of the cached objects which aren't serialized yet, exceed the limit of the buffer
+func (ws *workingSet) checkBufferSize() error {
+	return protocol.WrapWithStatus(
+		ws.flusher.KVStoreWithBuffer().CheckSize(ws.objects.size()),
+		action.ReceiptStatusBufferFull,
+	)
+}
+
+func (ws *workingSet) put(ns string, key []byte, s interface{}) error {
 	ss, err := state.Serialize(s)
 	if err != nil {
-		return 0, errors.Wrapf(err, "failed to convert account %v to bytes", s)
+		return errors.Wrapf(err, "failed to convert account %v to bytes", s)
 	}
-	ws.flusher.KVStoreWithBuffer().MustPut(AccountKVNamespace, cfg.Key, ss)
+	ws.flusher.KVStoreWithBuffer().MustPut(ns, key, ss)
 
-	return ws.blockHeight, ws.accountTrie.Upsert(cfg.Key, ss)
+	return ws.accountTrie.Upsert(key, ss)
 }
 
-// DelState deletes a state from DB
+// DelState deletes a state from DB, and returns ErrStateNotExist if there is no state to delete
 func (ws *workingSet) DelState(opts ...protocol.StateOption) (uint64, error) {
-	cfg, err := protocol.CreateStateConfig(opts...)
+	cfg, err := protocol.AcquireStateConfig(opts...)
 	if err != nil {
 		return 0, err
 	}
+	defer protocol.ReleaseStateConfig(cfg)
+	if cfg.IndexKeys != nil {
+		return 0, errors.Wrap(ErrNotSupported, "working set of trie can't maintain index")
+	}
+	// nothing is written if the state doesn't exist, the cached object of which is checked first
+	if !ws.objects.cached(AccountKVNamespace, cfg.Key) {
+		_, err := ws.accountTrie.Get(cfg.Key)
+		if errors.Cause(err) == trie.ErrNotExist {
+			if ws.flusher.KVStoreWithBuffer().Deleted(AccountKVNamespace, cfg.Key) {
+				return 0, stateDeletedError(cfg.Key, ws.deletedBy[namespacedKey(AccountKVNamespace, cfg.Key)])
+			}
+			return 0, errors.Wrapf(state.ErrStateNotExist, "addrHash = %x", cfg.Key)
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get account of %x", cfg.Key)
+		}
+	}
+	if err := ws.objects.evict(AccountKVNamespace, cfg.Key, nil); err != nil {
+		return 0, err
+	}
 	ws.flusher.KVStoreWithBuffer().MustDelete(AccountKVNamespace, cfg.Key)
+	ws.deletedBy[namespacedKey(AccountKVNamespace, cfg.Key)] = ws.actionHash
 
 	return ws.blockHeight, ws.accountTrie.Delete(cfg.Key)
 }
 
+// PutStates puts the states together, either all of which are put or none of which is
+func (ws *workingSet) PutStates(writes []protocol.StateWrite) (uint64, error) {
+	return putStates(ws, writes)
+}
+
+// DelStates deletes the states together, either all of which are deleted or none of which is
+func (ws *workingSet) DelStates(keys []protocol.StateKey) (uint64, error) {
+	return delStates(ws, keys)
+}
+
 // clearCache removes all local changes after committing to trie
 func (ws *workingSet) clear() {
 	ws.trieRoots = nil
 	ws.trieRoots = make(map[int][]byte)
+	ws.objects.clear()
+	ws.deletedBy = make(map[string]hash.Hash256)
 }
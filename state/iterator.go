@@ -0,0 +1,59 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrOutOfBoundary is the error that the iterator has no more state
+var ErrOutOfBoundary = errors.New("index is out of boundary")
+
+type (
+	// Iterator iterates over a set of serialized states
+	Iterator interface {
+		// Size returns the number of states
+		Size() int
+		// Next deserializes the next state into s and returns its key. ErrStateNotExist is returned along with the
+		// key if the key has no state, and ErrOutOfBoundary is returned once all the states have been read.
+		Next(s interface{}) ([]byte, error)
+	}
+
+	iterator struct {
+		keys   [][]byte
+		states [][]byte
+		index  int
+	}
+)
+
+// NewIterator returns an iterator over the serialized states, where keys[i] is the key of states[i], and a nil state
+// means the key has no state
+func NewIterator(keys [][]byte, states [][]byte) (Iterator, error) {
+	if len(keys) != len(states) {
+		return nil, errors.Errorf("the number of keys %d doesn't match the number of states %d", len(keys), len(states))
+	}
+	return &iterator{keys: keys, states: states}, nil
+}
+
+func (it *iterator) Size() int {
+	return len(it.states)
+}
+
+func (it *iterator) Next(s interface{}) ([]byte, error) {
+	i := it.index
+	if i >= len(it.states) {
+		return nil, ErrOutOfBoundary
+	}
+	it.index = i + 1
+	if it.states[i] == nil {
+		return it.keys[i], errors.Wrapf(ErrStateNotExist, "state of %x doesn't exist", it.keys[i])
+	}
+	if err := Deserialize(s, it.states[i]); err != nil {
+		return nil, errors.Wrapf(err, "failed to deserialize the state of %x", it.keys[i])
+	}
+	return it.keys[i], nil
+}
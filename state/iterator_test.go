@@ -0,0 +1,57 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/test/identityset"
+)
+
+func TestIterator(t *testing.T) {
+	r := require.New(t)
+
+	_, err := NewIterator([][]byte{{1}}, nil)
+	r.Error(err)
+
+	var keys, states [][]byte
+	for i := 1; i <= 2; i++ {
+		c := &Candidate{
+			Address: identityset.Address(i).String(),
+			Votes:   big.NewInt(int64(i)),
+		}
+		list := CandidateList{c}
+		bytes, err := Serialize(&list)
+		r.NoError(err)
+		keys = append(keys, []byte{byte(i)})
+		states = append(states, bytes)
+	}
+	iter, err := NewIterator(keys, states)
+	r.NoError(err)
+	r.Equal(2, iter.Size())
+	for i := 1; i <= 2; i++ {
+		var list CandidateList
+		key, err := iter.Next(&list)
+		r.NoError(err)
+		r.Equal([]byte{byte(i)}, key)
+		r.Equal(identityset.Address(i).String(), list[0].Address)
+	}
+	var list CandidateList
+	_, err = iter.Next(&list)
+	r.Equal(ErrOutOfBoundary, err)
+
+	// a nil state means the key has no state
+	iter, err = NewIterator([][]byte{{3}}, [][]byte{nil})
+	r.NoError(err)
+	key, err := iter.Next(&list)
+	r.Equal(ErrStateNotExist, errors.Cause(err))
+	r.Equal([]byte{3}, key)
+}
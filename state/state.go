@@ -19,8 +19,27 @@ var (
 
 	// ErrStateNotExist is the error that the state does not exist
 	ErrStateNotExist = errors.New("state does not exist")
+
+	// ErrStateDeleted is the error that the state has been deleted earlier in the block being worked on. Its cause is
+	// ErrStateNotExist, so that the callers which don't tell the two apart keep working.
+	ErrStateDeleted = errors.WithMessage(ErrStateNotExist, "state has been deleted")
 )
 
+// IsStateDeleted returns true if the error is caused by ErrStateDeleted
+func IsStateDeleted(err error) bool {
+	for err != nil {
+		if err == ErrStateDeleted {
+			return true
+		}
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = cause.Cause()
+	}
+	return false
+}
+
 // State is the interface, which defines the common methods for state struct to be handled by state factory
 type State interface {
 	Serialize() ([]byte, error)
@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/test/identityset"
@@ -39,3 +40,13 @@ func TestSerializeAndDeserialize(t *testing.T) {
 		r.True(c.Equal(list1[i]))
 	}
 }
+
+func TestIsStateDeleted(t *testing.T) {
+	r := require.New(t)
+
+	err := errors.Wrapf(ErrStateDeleted, "k = %x has been deleted", []byte("key"))
+	r.True(IsStateDeleted(err))
+	r.Equal(ErrStateNotExist, errors.Cause(err))
+	r.False(IsStateDeleted(errors.Wrap(ErrStateNotExist, "never existed")))
+	r.False(IsStateDeleted(nil))
+}
@@ -103,6 +103,23 @@ func (mr *MockKVStoreBatchMockRecorder) Delete(arg0, arg1, arg2 interface{}, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockKVStoreBatch)(nil).Delete), varargs...)
 }
 
+// DeletePrefix mocks base method
+func (m *MockKVStoreBatch) DeletePrefix(arg0 string, arg1 []byte, arg2 string, arg3 ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "DeletePrefix", varargs...)
+}
+
+// DeletePrefix indicates an expected call of DeletePrefix
+func (mr *MockKVStoreBatchMockRecorder) DeletePrefix(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MockKVStoreBatch)(nil).DeletePrefix), varargs...)
+}
+
 // Size mocks base method
 func (m *MockKVStoreBatch) Size() int {
 	m.ctrl.T.Helper()
@@ -265,6 +282,23 @@ func (mr *MockCachedBatchMockRecorder) Delete(arg0, arg1, arg2 interface{}, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCachedBatch)(nil).Delete), varargs...)
 }
 
+// DeletePrefix mocks base method
+func (m *MockCachedBatch) DeletePrefix(arg0 string, arg1 []byte, arg2 string, arg3 ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "DeletePrefix", varargs...)
+}
+
+// DeletePrefix indicates an expected call of DeletePrefix
+func (mr *MockCachedBatchMockRecorder) DeletePrefix(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MockCachedBatch)(nil).DeletePrefix), varargs...)
+}
+
 // Size mocks base method
 func (m *MockCachedBatch) Size() int {
 	m.ctrl.T.Helper()
@@ -279,6 +313,20 @@ func (mr *MockCachedBatchMockRecorder) Size() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockCachedBatch)(nil).Size))
 }
 
+// SizeInBytes mocks base method
+func (m *MockCachedBatch) SizeInBytes() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SizeInBytes")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// SizeInBytes indicates an expected call of SizeInBytes
+func (mr *MockCachedBatchMockRecorder) SizeInBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SizeInBytes", reflect.TypeOf((*MockCachedBatch)(nil).SizeInBytes))
+}
+
 // Entry mocks base method
 func (m *MockCachedBatch) Entry(arg0 int) (*batch.WriteInfo, error) {
 	m.ctrl.T.Helper()
@@ -8,6 +8,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	protocol "github.com/iotexproject/iotex-core/action/protocol"
 	db "github.com/iotexproject/iotex-core/db"
+	state "github.com/iotexproject/iotex-core/state"
 	reflect "reflect"
 )
 
@@ -49,6 +50,21 @@ func (mr *MockStateReaderMockRecorder) Height() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Height", reflect.TypeOf((*MockStateReader)(nil).Height))
 }
 
+// TipHeight mocks base method
+func (m *MockStateReader) TipHeight() (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TipHeight")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TipHeight indicates an expected call of TipHeight
+func (mr *MockStateReaderMockRecorder) TipHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TipHeight", reflect.TypeOf((*MockStateReader)(nil).TipHeight))
+}
+
 // State mocks base method
 func (m *MockStateReader) State(arg0 interface{}, arg1 ...protocol.StateOption) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -69,6 +85,26 @@ func (mr *MockStateReaderMockRecorder) State(arg0 interface{}, arg1 ...interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockStateReader)(nil).State), varargs...)
 }
 
+// States mocks base method
+func (m *MockStateReader) States(arg0 ...protocol.StateOption) (uint64, state.Iterator, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "States", varargs...)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(state.Iterator)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// States indicates an expected call of States
+func (mr *MockStateReaderMockRecorder) States(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "States", reflect.TypeOf((*MockStateReader)(nil).States), arg0...)
+}
+
 // MockStateManager is a mock of StateManager interface
 type MockStateManager struct {
 	ctrl     *gomock.Controller
@@ -107,6 +143,21 @@ func (mr *MockStateManagerMockRecorder) Height() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Height", reflect.TypeOf((*MockStateManager)(nil).Height))
 }
 
+// TipHeight mocks base method
+func (m *MockStateManager) TipHeight() (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TipHeight")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TipHeight indicates an expected call of TipHeight
+func (mr *MockStateManagerMockRecorder) TipHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TipHeight", reflect.TypeOf((*MockStateManager)(nil).TipHeight))
+}
+
 // State mocks base method
 func (m *MockStateManager) State(arg0 interface{}, arg1 ...protocol.StateOption) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -127,6 +178,26 @@ func (mr *MockStateManagerMockRecorder) State(arg0 interface{}, arg1 ...interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockStateManager)(nil).State), varargs...)
 }
 
+// States mocks base method
+func (m *MockStateManager) States(arg0 ...protocol.StateOption) (uint64, state.Iterator, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "States", varargs...)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(state.Iterator)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// States indicates an expected call of States
+func (mr *MockStateManagerMockRecorder) States(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "States", reflect.TypeOf((*MockStateManager)(nil).States), arg0...)
+}
+
 // Snapshot mocks base method
 func (m *MockStateManager) Snapshot() int {
 	m.ctrl.T.Helper()
@@ -194,6 +265,36 @@ func (mr *MockStateManagerMockRecorder) DelState(arg0 ...interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelState", reflect.TypeOf((*MockStateManager)(nil).DelState), arg0...)
 }
 
+// PutStates mocks base method
+func (m *MockStateManager) PutStates(arg0 []protocol.StateWrite) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutStates", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutStates indicates an expected call of PutStates
+func (mr *MockStateManagerMockRecorder) PutStates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutStates", reflect.TypeOf((*MockStateManager)(nil).PutStates), arg0)
+}
+
+// DelStates mocks base method
+func (m *MockStateManager) DelStates(arg0 []protocol.StateKey) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelStates", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelStates indicates an expected call of DelStates
+func (mr *MockStateManagerMockRecorder) DelStates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelStates", reflect.TypeOf((*MockStateManager)(nil).DelStates), arg0)
+}
+
 // GetDB mocks base method
 func (m *MockStateManager) GetDB() db.KVStore {
 	m.ctrl.T.Helper()
@@ -207,3 +308,18 @@ func (mr *MockStateManagerMockRecorder) GetDB() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockStateManager)(nil).GetDB))
 }
+
+// KVStore mocks base method
+func (m *MockStateManager) KVStore(arg0 string) (db.KVStoreBasic, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVStore", arg0)
+	ret0, _ := ret[0].(db.KVStoreBasic)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KVStore indicates an expected call of KVStore
+func (mr *MockStateManagerMockRecorder) KVStore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVStore", reflect.TypeOf((*MockStateManager)(nil).KVStore), arg0)
+}
@@ -11,6 +11,7 @@ import (
 	action "github.com/iotexproject/iotex-core/action"
 	protocol "github.com/iotexproject/iotex-core/action/protocol"
 	db "github.com/iotexproject/iotex-core/db"
+	state "github.com/iotexproject/iotex-core/state"
 	reflect "reflect"
 )
 
@@ -52,6 +53,21 @@ func (mr *MockWorkingSetMockRecorder) Height() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Height", reflect.TypeOf((*MockWorkingSet)(nil).Height))
 }
 
+// TipHeight mocks base method
+func (m *MockWorkingSet) TipHeight() (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TipHeight")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TipHeight indicates an expected call of TipHeight
+func (mr *MockWorkingSetMockRecorder) TipHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TipHeight", reflect.TypeOf((*MockWorkingSet)(nil).TipHeight))
+}
+
 // State mocks base method
 func (m *MockWorkingSet) State(arg0 interface{}, arg1 ...protocol.StateOption) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -72,6 +88,26 @@ func (mr *MockWorkingSetMockRecorder) State(arg0 interface{}, arg1 ...interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockWorkingSet)(nil).State), varargs...)
 }
 
+// States mocks base method
+func (m *MockWorkingSet) States(arg0 ...protocol.StateOption) (uint64, state.Iterator, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "States", varargs...)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(state.Iterator)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// States indicates an expected call of States
+func (mr *MockWorkingSetMockRecorder) States(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "States", reflect.TypeOf((*MockWorkingSet)(nil).States), arg0...)
+}
+
 // Snapshot mocks base method
 func (m *MockWorkingSet) Snapshot() int {
 	m.ctrl.T.Helper()
@@ -139,6 +175,36 @@ func (mr *MockWorkingSetMockRecorder) DelState(arg0 ...interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelState", reflect.TypeOf((*MockWorkingSet)(nil).DelState), arg0...)
 }
 
+// PutStates mocks base method
+func (m *MockWorkingSet) PutStates(arg0 []protocol.StateWrite) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutStates", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutStates indicates an expected call of PutStates
+func (mr *MockWorkingSetMockRecorder) PutStates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutStates", reflect.TypeOf((*MockWorkingSet)(nil).PutStates), arg0)
+}
+
+// DelStates mocks base method
+func (m *MockWorkingSet) DelStates(arg0 []protocol.StateKey) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelStates", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelStates indicates an expected call of DelStates
+func (mr *MockWorkingSetMockRecorder) DelStates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelStates", reflect.TypeOf((*MockWorkingSet)(nil).DelStates), arg0)
+}
+
 // GetDB mocks base method
 func (m *MockWorkingSet) GetDB() db.KVStore {
 	m.ctrl.T.Helper()
@@ -153,6 +219,21 @@ func (mr *MockWorkingSetMockRecorder) GetDB() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockWorkingSet)(nil).GetDB))
 }
 
+// KVStore mocks base method
+func (m *MockWorkingSet) KVStore(arg0 string) (db.KVStoreBasic, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVStore", arg0)
+	ret0, _ := ret[0].(db.KVStoreBasic)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KVStore indicates an expected call of KVStore
+func (mr *MockWorkingSetMockRecorder) KVStore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVStore", reflect.TypeOf((*MockWorkingSet)(nil).KVStore), arg0)
+}
+
 // RunAction mocks base method
 func (m *MockWorkingSet) RunAction(arg0 context.Context, arg1 action.SealedEnvelope) (*action.Receipt, error) {
 	m.ctrl.T.Helper()
@@ -183,6 +264,21 @@ func (mr *MockWorkingSetMockRecorder) RunActions(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunActions", reflect.TypeOf((*MockWorkingSet)(nil).RunActions), arg0, arg1)
 }
 
+// SimulateAction mocks base method
+func (m *MockWorkingSet) SimulateAction(arg0 context.Context, arg1 action.SealedEnvelope) (*action.Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SimulateAction", arg0, arg1)
+	ret0, _ := ret[0].(*action.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulateAction indicates an expected call of SimulateAction
+func (mr *MockWorkingSetMockRecorder) SimulateAction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulateAction", reflect.TypeOf((*MockWorkingSet)(nil).SimulateAction), arg0, arg1)
+}
+
 // Finalize mocks base method
 func (m *MockWorkingSet) Finalize() error {
 	m.ctrl.T.Helper()
@@ -254,3 +350,19 @@ func (mr *MockWorkingSetMockRecorder) Version() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockWorkingSet)(nil).Version))
 }
+
+// ReadView mocks base method
+func (m *MockWorkingSet) ReadView() (protocol.StateReader, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadView")
+	ret0, _ := ret[0].(protocol.StateReader)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReadView indicates an expected call of ReadView
+func (mr *MockWorkingSetMockRecorder) ReadView() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadView", reflect.TypeOf((*MockWorkingSet)(nil).ReadView))
+}
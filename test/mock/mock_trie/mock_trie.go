@@ -91,6 +91,22 @@ func (mr *MockTrieMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTrie)(nil).Get), arg0)
 }
 
+// GetWithProof mocks base method
+func (m *MockTrie) GetWithProof(arg0 []byte) ([]byte, [][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithProof", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].([][]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWithProof indicates an expected call of GetWithProof
+func (mr *MockTrieMockRecorder) GetWithProof(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithProof", reflect.TypeOf((*MockTrie)(nil).GetWithProof), arg0)
+}
+
 // Delete mocks base method
 func (m *MockTrie) Delete(arg0 []byte) error {
 	m.ctrl.T.Helper()
@@ -105,6 +121,49 @@ func (mr *MockTrieMockRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTrie)(nil).Delete), arg0)
 }
 
+// UpsertBatch mocks base method
+func (m *MockTrie) UpsertBatch(arg0, arg1 [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertBatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertBatch indicates an expected call of UpsertBatch
+func (mr *MockTrieMockRecorder) UpsertBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertBatch", reflect.TypeOf((*MockTrie)(nil).UpsertBatch), arg0, arg1)
+}
+
+// DeleteBatch mocks base method
+func (m *MockTrie) DeleteBatch(arg0 [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBatch indicates an expected call of DeleteBatch
+func (mr *MockTrieMockRecorder) DeleteBatch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBatch", reflect.TypeOf((*MockTrie)(nil).DeleteBatch), arg0)
+}
+
+// Iterator mocks base method
+func (m *MockTrie) Iterator(arg0 []byte) (trie.Iter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterator", arg0)
+	ret0, _ := ret[0].(trie.Iter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Iterator indicates an expected call of Iterator
+func (mr *MockTrieMockRecorder) Iterator(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterator", reflect.TypeOf((*MockTrie)(nil).Iterator), arg0)
+}
+
 // RootHash mocks base method
 func (m *MockTrie) RootHash() []byte {
 	m.ctrl.T.Helper()
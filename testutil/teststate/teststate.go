@@ -0,0 +1,344 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package teststate provides an in-memory protocol.StateManager for the unit tests of protocols, which behaves the
+// same as the working set of the state db.
+package teststate
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/db/batch"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+)
+
+type (
+	// entry is a value written in a layer, or a tombstone if it is deleted
+	entry struct {
+		value   []byte
+		deleted bool
+	}
+
+	// layer holds the writes made after a snapshot, keyed by namespace and key
+	layer map[string]map[string]entry
+
+	// StateManager is an in-memory state manager. The writes are kept in layers, a new layer is pushed on each
+	// snapshot, and reverting to a snapshot drops the layers pushed after it, so that the states of a snapshot are
+	// never modified by later writes.
+	StateManager struct {
+		height uint64
+		layers []layer
+		// snapshots[i] is the number of layers when the snapshot i is taken
+		snapshots []int
+	}
+
+	// kvStore is the view of the state manager as a KVStore
+	kvStore struct {
+		sm *StateManager
+	}
+)
+
+var _ protocol.StateManager = (*StateManager)(nil)
+
+// New creates an empty state manager working on the block of the given height
+func New(height uint64) *StateManager {
+	return &StateManager{
+		height: height,
+		layers: []layer{make(layer)},
+	}
+}
+
+// SetHeight sets the height of the block being worked on
+func (sm *StateManager) SetHeight(height uint64) {
+	sm.height = height
+}
+
+// Height returns the height of the block being worked on
+func (sm *StateManager) Height() (uint64, error) {
+	return sm.height, nil
+}
+
+// TipHeight returns the height of the last committed block, which the block being worked on is built atop
+func (sm *StateManager) TipHeight() (uint64, error) {
+	if sm.height == 0 {
+		return 0, nil
+	}
+	return sm.height - 1, nil
+}
+
+// State reads a state
+func (sm *StateManager) State(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.AtHeight {
+		return 0, factory.ErrNotSupported
+	}
+	value, ok := sm.get(namespace(cfg), cfg.Key)
+	if !ok {
+		return 0, errors.Wrapf(state.ErrStateNotExist, "k = %x doesn't exist", cfg.Key)
+	}
+	return sm.height, state.Deserialize(s, value)
+}
+
+// States reads the states of the keys in order, or the states whose keys start with the prefix
+func (sm *StateManager) States(opts ...protocol.StateOption) (uint64, state.Iterator, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if cfg.AtHeight {
+		return 0, nil, factory.ErrNotSupported
+	}
+	var keys, values [][]byte
+	switch {
+	case cfg.Prefix != nil:
+		keys, values = sm.prefix(namespace(cfg), cfg.Prefix)
+	case cfg.Keys != nil:
+		keys = cfg.Keys
+		values = make([][]byte, len(keys))
+		for i, key := range keys {
+			if value, ok := sm.get(namespace(cfg), key); ok {
+				values[i] = value
+			}
+		}
+	default:
+		return 0, nil, errors.Wrap(factory.ErrNotSupported, "the keys or the prefix of the states are required")
+	}
+	iter, err := state.NewIterator(keys, values)
+	if err != nil {
+		return 0, nil, err
+	}
+	return sm.height, iter, nil
+}
+
+// PutState writes a state
+func (sm *StateManager) PutState(s interface{}, opts ...protocol.StateOption) (uint64, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, err
+	}
+	ss, err := state.Serialize(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to convert account %v to bytes", s)
+	}
+	sm.put(namespace(cfg), cfg.Key, entry{value: ss})
+	return sm.height, nil
+}
+
+// DelState deletes a state, and returns ErrStateNotExist if there is no state to delete
+func (sm *StateManager) DelState(opts ...protocol.StateOption) (uint64, error) {
+	cfg, err := protocol.CreateStateConfig(opts...)
+	if err != nil {
+		return 0, err
+	}
+	ns := namespace(cfg)
+	if _, ok := sm.get(ns, cfg.Key); !ok {
+		if sm.deleted(ns, cfg.Key) {
+			return 0, errors.Wrapf(state.ErrStateDeleted, "k = %x has been deleted", cfg.Key)
+		}
+		return 0, errors.Wrapf(state.ErrStateNotExist, "k = %x doesn't exist", cfg.Key)
+	}
+	sm.put(ns, cfg.Key, entry{deleted: true})
+	return sm.height, nil
+}
+
+// PutStates writes the states together, either all of which are written or none of which is
+func (sm *StateManager) PutStates(writes []protocol.StateWrite) (uint64, error) {
+	snapshot := sm.Snapshot()
+	for _, w := range writes {
+		if _, err := sm.PutState(w.State, w.Options...); err != nil {
+			return 0, sm.revertOnError(snapshot, err)
+		}
+	}
+	return sm.height, nil
+}
+
+// DelStates deletes the states together, either all of which are deleted or none of which is
+func (sm *StateManager) DelStates(keys []protocol.StateKey) (uint64, error) {
+	snapshot := sm.Snapshot()
+	for _, key := range keys {
+		if _, err := sm.DelState(key...); err != nil {
+			return 0, sm.revertOnError(snapshot, err)
+		}
+	}
+	return sm.height, nil
+}
+
+func (sm *StateManager) revertOnError(snapshot int, err error) error {
+	if revertErr := sm.Revert(snapshot); revertErr != nil {
+		return errors.Wrapf(revertErr, "failed to revert on error %v", err)
+	}
+	return err
+}
+
+// Snapshot takes a snapshot of the states
+func (sm *StateManager) Snapshot() int {
+	sm.snapshots = append(sm.snapshots, len(sm.layers))
+	sm.layers = append(sm.layers, make(layer))
+	return len(sm.snapshots) - 1
+}
+
+// Revert reverts the states to a snapshot. The snapshots taken after it are discarded, while the snapshot itself can
+// be reverted to again.
+func (sm *StateManager) Revert(snapshot int) error {
+	if snapshot < 0 || snapshot >= len(sm.snapshots) {
+		return errors.Wrapf(batch.ErrOutOfBound, "invalid snapshot number = %d", snapshot)
+	}
+	sm.layers = append(sm.layers[:sm.snapshots[snapshot]], make(layer))
+	sm.snapshots = sm.snapshots[:snapshot+1]
+	return nil
+}
+
+// GetDB returns the states as a KVStore, the writes through which are visible to State
+func (sm *StateManager) GetDB() db.KVStore {
+	return &kvStore{sm: sm}
+}
+
+// KVStore returns the states of the namespace as a KVStore, which can't be the namespace of the account states
+func (sm *StateManager) KVStore(ns string) (db.KVStoreBasic, error) {
+	switch ns {
+	case "", factory.AccountKVNamespace, factory.AccountTrieNamespace:
+		return nil, errors.Wrapf(factory.ErrNotSupported, "namespace %q can't be accessed as a KV store", ns)
+	}
+	return db.NewKVStoreForTrie(ns, &kvStore{sm: sm})
+}
+
+// Dump returns all the states, keyed by namespace and key
+func (sm *StateManager) Dump() map[string]map[string][]byte {
+	dump := make(map[string]map[string][]byte)
+	for _, l := range sm.layers {
+		for ns, kvs := range l {
+			if _, ok := dump[ns]; !ok {
+				dump[ns] = make(map[string][]byte)
+			}
+			for k, e := range kvs {
+				if e.deleted {
+					delete(dump[ns], k)
+				} else {
+					dump[ns][k] = e.value
+				}
+			}
+		}
+	}
+	for ns, kvs := range dump {
+		if len(kvs) == 0 {
+			delete(dump, ns)
+		}
+	}
+	return dump
+}
+
+func (sm *StateManager) get(ns string, key []byte) ([]byte, bool) {
+	for i := len(sm.layers) - 1; i >= 0; i-- {
+		if e, ok := sm.layers[i][ns][string(key)]; ok {
+			return e.value, !e.deleted
+		}
+	}
+	return nil, false
+}
+
+// deleted returns true if the latest write of the key is a tombstone
+func (sm *StateManager) deleted(ns string, key []byte) bool {
+	for i := len(sm.layers) - 1; i >= 0; i-- {
+		if e, ok := sm.layers[i][ns][string(key)]; ok {
+			return e.deleted
+		}
+	}
+	return false
+}
+
+// prefix returns the states whose keys start with the prefix in the namespace, ordered by key
+func (sm *StateManager) prefix(ns string, prefix []byte) ([][]byte, [][]byte) {
+	states := sm.Dump()[ns]
+	ks := make([]string, 0, len(states))
+	for k := range states {
+		if strings.HasPrefix(k, string(prefix)) {
+			ks = append(ks, k)
+		}
+	}
+	sort.Strings(ks)
+	keys := make([][]byte, 0, len(ks))
+	values := make([][]byte, 0, len(ks))
+	for _, k := range ks {
+		keys = append(keys, []byte(k))
+		values = append(values, states[k])
+	}
+	return keys, values
+}
+
+func (sm *StateManager) put(ns string, key []byte, e entry) {
+	top := sm.layers[len(sm.layers)-1]
+	if _, ok := top[ns]; !ok {
+		top[ns] = make(map[string]entry)
+	}
+	if e.value != nil {
+		e.value = append([]byte{}, e.value...)
+	}
+	top[ns][string(key)] = e
+}
+
+func namespace(cfg *protocol.StateConfig) string {
+	if cfg.Namespace != "" {
+		return cfg.Namespace
+	}
+	return factory.AccountKVNamespace
+}
+
+func (kv *kvStore) Start(context.Context) error { return nil }
+
+func (kv *kvStore) Stop(context.Context) error { return nil }
+
+func (kv *kvStore) Put(ns string, key, value []byte) error {
+	kv.sm.put(ns, key, entry{value: value})
+	return nil
+}
+
+func (kv *kvStore) Get(ns string, key []byte) ([]byte, error) {
+	value, ok := kv.sm.get(ns, key)
+	if !ok {
+		return nil, errors.Wrapf(db.ErrNotExist, "failed to get key %x in %s", key, ns)
+	}
+	return value, nil
+}
+
+func (kv *kvStore) Delete(ns string, key []byte) error {
+	kv.sm.put(ns, key, entry{deleted: true})
+	return nil
+}
+
+func (kv *kvStore) WriteBatch(b batch.KVStoreBatch) error {
+	b.Lock()
+	defer b.ClearAndUnlock()
+	for i := 0; i < b.Size(); i++ {
+		write, err := b.Entry(i)
+		if err != nil {
+			return err
+		}
+		switch write.WriteType() {
+		case batch.Put:
+			kv.sm.put(write.Namespace(), write.Key(), entry{value: write.Value()})
+		case batch.Delete:
+			kv.sm.put(write.Namespace(), write.Key(), entry{deleted: true})
+		case batch.DeletePrefix:
+			keys, _ := kv.sm.prefix(write.Namespace(), write.Key())
+			for _, key := range keys {
+				kv.sm.put(write.Namespace(), key, entry{deleted: true})
+			}
+		default:
+			return errors.Errorf("invalid write type %d", write.WriteType())
+		}
+	}
+	return nil
+}
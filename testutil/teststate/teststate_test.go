@@ -0,0 +1,323 @@
+// Copyright (c) 2020 IoTeX Foundation
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package teststate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/action/protocol"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/state/factory"
+	"github.com/iotexproject/iotex-core/testutil"
+)
+
+const testNS = "Test"
+
+type testState []byte
+
+func (s *testState) Serialize() ([]byte, error) { return *s, nil }
+
+func (s *testState) Deserialize(data []byte) error {
+	*s = append(testState{}, data...)
+	return nil
+}
+
+// badState is a state which fails to be serialized
+type badState struct{}
+
+func (badState) Serialize() ([]byte, error) { return nil, errors.New("failed to serialize") }
+
+type op func(protocol.StateManager) string
+
+func put(ns, key, value string) op {
+	return func(sm protocol.StateManager) string {
+		s := testState(value)
+		h, err := sm.PutState(&s, protocol.NamespaceOption(ns), protocol.KeyOption([]byte(key)))
+		return fmt.Sprintf("put %s/%s: %d %v", ns, key, h, err)
+	}
+}
+
+func del(ns, key string) op {
+	return func(sm protocol.StateManager) string {
+		h, err := sm.DelState(protocol.NamespaceOption(ns), protocol.KeyOption([]byte(key)))
+		return fmt.Sprintf("del %s/%s: %d %v", ns, key, h, err)
+	}
+}
+
+// putStates puts the states of the keys and values together, in which an empty value fails to be serialized
+func putStates(ns string, kvs ...string) op {
+	return func(sm protocol.StateManager) string {
+		writes := make([]protocol.StateWrite, 0, len(kvs)/2)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			opts := []protocol.StateOption{protocol.NamespaceOption(ns), protocol.KeyOption([]byte(kvs[i]))}
+			if kvs[i+1] == "" {
+				writes = append(writes, protocol.StateWrite{State: badState{}, Options: opts})
+				continue
+			}
+			s := testState(kvs[i+1])
+			writes = append(writes, protocol.StateWrite{State: &s, Options: opts})
+		}
+		h, err := sm.PutStates(writes)
+		return fmt.Sprintf("put states %s/%v: %d %v", ns, kvs, h, errors.Cause(err))
+	}
+}
+
+func delStates(ns string, keys ...string) op {
+	return func(sm protocol.StateManager) string {
+		stateKeys := make([]protocol.StateKey, 0, len(keys))
+		for _, key := range keys {
+			stateKeys = append(stateKeys, protocol.StateKey{protocol.NamespaceOption(ns), protocol.KeyOption([]byte(key))})
+		}
+		h, err := sm.DelStates(stateKeys)
+		return fmt.Sprintf("del states %s/%v: %d %v", ns, keys, h, errors.Cause(err))
+	}
+}
+
+func get(ns, key string) op {
+	return func(sm protocol.StateManager) string {
+		var s testState
+		h, err := sm.State(&s, protocol.NamespaceOption(ns), protocol.KeyOption([]byte(key)))
+		switch errors.Cause(err) {
+		case nil:
+			return fmt.Sprintf("get %s/%s: %d %s", ns, key, h, s)
+		case state.ErrStateNotExist:
+			return fmt.Sprintf("get %s/%s: %d not exist", ns, key, h)
+		default:
+			return fmt.Sprintf("get %s/%s: %d %v", ns, key, h, errors.Cause(err))
+		}
+	}
+}
+
+func getAtHeight(key string) op {
+	return func(sm protocol.StateManager) string {
+		var s testState
+		_, err := sm.State(&s, protocol.KeyOption([]byte(key)), protocol.BlockHeightOption(0))
+		return fmt.Sprintf("get %s at height: %v", key, errors.Cause(err))
+	}
+}
+
+func dbPut(ns, key, value string) op {
+	return func(sm protocol.StateManager) string {
+		return fmt.Sprintf("db put %s/%s: %v", ns, key, sm.GetDB().Put(ns, []byte(key), []byte(value)))
+	}
+}
+
+func dbGet(ns, key string) op {
+	return func(sm protocol.StateManager) string {
+		value, err := sm.GetDB().Get(ns, []byte(key))
+		if errors.Cause(err) == db.ErrNotExist {
+			return fmt.Sprintf("db get %s/%s: not exist", ns, key)
+		}
+		return fmt.Sprintf("db get %s/%s: %s %v", ns, key, value, err)
+	}
+}
+
+func kvPut(ns, key, value string) op {
+	return func(sm protocol.StateManager) string {
+		kv, err := sm.KVStore(ns)
+		if err != nil {
+			return fmt.Sprintf("kv %s: %v", ns, errors.Cause(err))
+		}
+		return fmt.Sprintf("kv put %s/%s: %v", ns, key, kv.Put([]byte(key), []byte(value)))
+	}
+}
+
+func kvGet(ns, key string) op {
+	return func(sm protocol.StateManager) string {
+		kv, err := sm.KVStore(ns)
+		if err != nil {
+			return fmt.Sprintf("kv %s: %v", ns, errors.Cause(err))
+		}
+		value, err := kv.Get([]byte(key))
+		if errors.Cause(err) == db.ErrNotExist {
+			return fmt.Sprintf("kv get %s/%s: not exist", ns, key)
+		}
+		return fmt.Sprintf("kv get %s/%s: %s %v", ns, key, value, err)
+	}
+}
+
+func snapshot() op {
+	return func(sm protocol.StateManager) string {
+		return fmt.Sprintf("snapshot: %d", sm.Snapshot())
+	}
+}
+
+func revert(s int) op {
+	return func(sm protocol.StateManager) string {
+		return fmt.Sprintf("revert %d: %v", s, errors.Cause(sm.Revert(s)))
+	}
+}
+
+func height() op {
+	return func(sm protocol.StateManager) string {
+		h, err := sm.Height()
+		return fmt.Sprintf("height: %d %v", h, err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	require := require.New(t)
+
+	sequences := [][]op{
+		{
+			height(),
+			get(testNS, "a"),
+			put(testNS, "a", "1"),
+			get(testNS, "a"),
+			put(testNS, "a", "2"),
+			get(testNS, "a"),
+			del(testNS, "a"),
+			get(testNS, "a"),
+			del(testNS, "b"),
+			get(testNS, "b"),
+		},
+		{
+			// nothing is deleted twice
+			put(testNS, "a", "1"),
+			del(testNS, "a"),
+			del(testNS, "a"),
+			put(testNS, "a", "2"),
+			del(testNS, "a"),
+			get(testNS, "a"),
+		},
+		{
+			// the default namespace is the account namespace
+			put("", "a", "1"),
+			get(factory.AccountKVNamespace, "a"),
+			get(testNS, "a"),
+			getAtHeight("a"),
+		},
+		{
+			put(testNS, "a", "1"),
+			snapshot(),
+			put(testNS, "a", "2"),
+			put(testNS, "b", "2"),
+			snapshot(),
+			del(testNS, "a"),
+			put(testNS, "c", "3"),
+			get(testNS, "a"),
+			revert(1),
+			get(testNS, "a"),
+			get(testNS, "b"),
+			get(testNS, "c"),
+			put(testNS, "c", "4"),
+			revert(0),
+			get(testNS, "a"),
+			get(testNS, "b"),
+			get(testNS, "c"),
+			// the snapshots after the reverted one are discarded
+			revert(1),
+			revert(-1),
+			snapshot(),
+			put(testNS, "a", "5"),
+			revert(1),
+			get(testNS, "a"),
+		},
+		{
+			// the writes through the db are visible to the states, and vice versa
+			dbPut(testNS, "a", "1"),
+			get(testNS, "a"),
+			put(testNS, "b", "2"),
+			dbGet(testNS, "b"),
+			snapshot(),
+			del(testNS, "a"),
+			dbGet(testNS, "a"),
+			revert(0),
+			dbGet(testNS, "a"),
+		},
+		{
+			// the states are put and deleted all or nothing
+			put(testNS, "a", "1"),
+			putStates(testNS, "a", "2", "b", "2"),
+			get(testNS, "a"),
+			get(testNS, "b"),
+			putStates(testNS, "c", "3", "d", "3", "e", "", "f", "3", "g", "3"),
+			get(testNS, "a"),
+			get(testNS, "c"),
+			get(testNS, "d"),
+			get(testNS, "f"),
+			delStates(testNS, "a", "missing"),
+			get(testNS, "a"),
+			delStates(testNS, "a", "b"),
+			get(testNS, "a"),
+			get(testNS, "b"),
+			putStates(testNS),
+			delStates(testNS),
+		},
+		{
+			// the writes through the KV store of the namespace are bound to it
+			kvPut(testNS, "a", "1"),
+			get(testNS, "a"),
+			kvGet("other", "a"),
+			snapshot(),
+			kvPut(testNS, "a", "2"),
+			revert(0),
+			kvGet(testNS, "a"),
+			kvPut("", "a", "1"),
+			kvGet(factory.AccountKVNamespace, "a"),
+			kvGet(factory.AccountTrieNamespace, "a"),
+		},
+	}
+
+	for i, seq := range sequences {
+		testTrieFile, _ := ioutil.TempFile(os.TempDir(), "teststate")
+		testTriePath := testTrieFile.Name()
+		cfg := config.Default
+		cfg.Chain.TrieDBPath = testTriePath
+		sf, err := factory.NewStateDB(cfg, factory.DefaultStateDBOption())
+		require.NoError(err)
+		require.NoError(sf.Start(context.Background()))
+		ws, err := sf.NewWorkingSet()
+		require.NoError(err)
+
+		sm := New(1)
+		for j, o := range seq {
+			require.Equal(o(ws), o(sm), "sequence %d, step %d", i, j)
+		}
+		require.NoError(sf.Stop(context.Background()))
+		testutil.CleanupPath(t, testTriePath)
+	}
+}
+
+func TestDump(t *testing.T) {
+	require := require.New(t)
+
+	sm := New(1)
+	for _, o := range []op{
+		put(testNS, "a", "1"),
+		put(testNS, "b", "2"),
+		snapshot(),
+		del(testNS, "a"),
+		put(testNS, "b", "3"),
+		put("", "c", "4"),
+	} {
+		o(sm)
+	}
+	require.Equal(map[string]map[string][]byte{
+		testNS:                     {"b": []byte("3")},
+		factory.AccountKVNamespace: {"c": []byte("4")},
+	}, sm.Dump())
+
+	require.NoError(sm.Revert(0))
+	require.Equal(map[string]map[string][]byte{
+		testNS: {"a": []byte("1"), "b": []byte("2")},
+	}, sm.Dump())
+
+	sm.SetHeight(5)
+	h, err := sm.PutState(&testState{}, protocol.KeyOption([]byte("d")))
+	require.NoError(err)
+	require.Equal(uint64(5), h)
+}